@@ -0,0 +1,34 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backend provides common configuration and functions.
+//
+// The Backend module contains common infrastructure and assets for other modules
+// to be defined and used in their administration user interface (UI). It does
+// not contain anything specific to other modules.
+//
+// Generate creates the source code of a strongly typed Configuration struct
+// from an element.SectionSlice. Each element.Field becomes a struct field of
+// the matching cfgmodel type together with a New() constructor which
+// initializes all models. This removes the need for hundreds of loose package
+// level cfgmodel variables. A typical usage in a package looks like:
+//
+//	//go:generate go run gen_backend.go
+//
+// where gen_backend.go (build tag ignore) calls:
+//
+//	cfgStruct, _ := NewConfigStructure()
+//	f, _ := os.Create("backend_gen.go")
+//	backend.Generate(f, backend.GenerateOptions{Package: "tax"}, cfgStruct)
+package backend
@@ -0,0 +1,180 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"go/format"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/util"
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/csfw/util/wordwrap"
+	"github.com/corestoreio/errors"
+)
+
+// GenerateOptions defines the output of the Generate function.
+type GenerateOptions struct {
+	// Package name of the generated file. Required.
+	Package string
+	// StructName of the generated configuration type. Defaults to
+	// "Configuration".
+	StructName string
+	// BuildTags optional build tags written at the top of the file.
+	BuildTags []string
+}
+
+// genField represents one struct field in the generated source code.
+type genField struct {
+	Name     string
+	Model    string
+	Path     string
+	Comments []string
+}
+
+type genData struct {
+	GenerateOptions
+	Fields []genField
+}
+
+const tplConfiguration = `// Auto generated by backend.Generate. DO NOT EDIT.
+{{range .BuildTags}}
+// +build {{.}}
+{{end}}
+package {{.Package}}
+
+import (
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/csfw/config/element"
+)
+
+// {{.StructName}} just exported for the sake of documentation. See fields for
+// more information.
+type {{.StructName}} struct {
+{{range .Fields}}{{range .Comments}}	// {{.}}
+{{end}}	//
+	// Path: {{.Path}}
+	{{.Name}} cfgmodel.{{.Model}}
+
+{{end}}}
+
+// New{{.StructName}} initializes the backend configuration models containing
+// the cfgpath.Route variable to the appropriate entries. The SectionSlice will
+// be applied to all models.
+func New{{.StructName}}(cfgStruct element.SectionSlice, opts ...cfgmodel.Option) *{{.StructName}} {
+	be := &{{.StructName}}{}
+	opts = append(opts, cfgmodel.WithFieldFromSectionSlice(cfgStruct))
+{{range .Fields}}	be.{{.Name}} = cfgmodel.New{{.Model}}(` + "`{{.Path}}`" + `, opts...)
+{{end}}
+	return be
+}
+`
+
+var tplConfigurationParsed = template.Must(template.New("configuration").Parse(tplConfiguration))
+
+// ModelType returns the name of the cfgmodel type which matches the
+// element.Field. The field Type has precedence over the type of the default
+// value. Falls back to "Str".
+func ModelType(f element.Field) string {
+	if f.Type != nil {
+		switch f.Type.Type() {
+		case element.TypeObscure:
+			return "Obscure"
+		case element.TypeMultiselect:
+			return "StringCSV"
+		case element.TypeTime:
+			return "Time"
+		case element.TypeDuration:
+			return "Duration"
+		}
+	}
+	switch f.Default.(type) {
+	case bool:
+		return "Bool"
+	case int, int64:
+		return "Int"
+	case float64:
+		return "Float64"
+	}
+	return "Str"
+}
+
+// FieldName converts a configuration path like tax/classes/shipping_tax_class
+// into an exported Go identifier like TaxClassesShippingTaxClass.
+func FieldName(path string) string {
+	return util.UnderscoreCamelize(path)
+}
+
+// Generate writes the formatted Go source code of a configuration struct and
+// its constructor to w. For each field in the SectionSlice a struct field gets
+// generated. Error behaviour: NotValid, Fatal or WriteFailed.
+func Generate(w io.Writer, o GenerateOptions, ss element.SectionSlice) error {
+	if o.Package == "" {
+		return errors.NewNotValidf("[backend] Generate: Package name cannot be empty")
+	}
+	if o.StructName == "" {
+		o.StructName = "Configuration"
+	}
+
+	gd := genData{
+		GenerateOptions: o,
+		Fields:          make([]genField, 0, ss.TotalFields()),
+	}
+	for _, s := range ss {
+		for _, g := range s.Groups {
+			for _, f := range g.Fields {
+				r, err := f.Route(s.ID, g.ID)
+				if err != nil {
+					return errors.Wrapf(err, "[backend] Generate.Field.Route. Section %q Group %q", s.ID, g.ID)
+				}
+				gd.Fields = append(gd.Fields, newGenField(r.String(), f))
+			}
+		}
+	}
+
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err := tplConfigurationParsed.Execute(buf, gd); err != nil {
+		return errors.NewFatal(err, "[backend] Generate.template.Execute")
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.NewFatal(err, "[backend] Generate.format.Source")
+	}
+	if _, err := w.Write(src); err != nil {
+		return errors.NewWriteFailed(err, "[backend] Generate.Write")
+	}
+	return nil
+}
+
+func newGenField(path string, f element.Field) genField {
+	gf := genField{
+		Name:  FieldName(path),
+		Model: ModelType(f),
+		Path:  path,
+	}
+	first := gf.Name
+	if !f.Label.IsEmpty() {
+		first += " => " + strings.TrimSuffix(f.Label.String(), ".") + "."
+	}
+	gf.Comments = append(gf.Comments, first)
+	if !f.Comment.IsEmpty() {
+		c := strings.Join(strings.Fields(f.Comment.String()), " ")
+		gf.Comments = append(gf.Comments, strings.Split(wordwrap.String(c, 76), "\n")...)
+	}
+	return gf
+}
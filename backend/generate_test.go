@@ -0,0 +1,118 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/corestoreio/csfw/backend"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/storage/text"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelType(t *testing.T) {
+	tests := []struct {
+		have element.Field
+		want string
+	}{
+		{element.Field{}, "Str"},
+		{element.Field{Type: element.TypeText}, "Str"},
+		{element.Field{Type: element.TypeSelect, Default: true}, "Bool"},
+		{element.Field{Default: 3}, "Int"},
+		{element.Field{Default: int64(3)}, "Int"},
+		{element.Field{Default: 3.14}, "Float64"},
+		{element.Field{Type: element.TypeObscure, Default: "x"}, "Obscure"},
+		{element.Field{Type: element.TypeMultiselect}, "StringCSV"},
+		{element.Field{Type: element.TypeTime}, "Time"},
+		{element.Field{Type: element.TypeDuration, Default: "1h"}, "Duration"},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, backend.ModelType(test.have), "Index %d", i)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	ss := element.MustNewConfiguration(
+		element.Section{
+			ID: cfgpath.NewRoute(`tax`),
+			Groups: element.NewGroupSlice(
+				element.Group{
+					ID: cfgpath.NewRoute(`classes`),
+					Fields: element.NewFieldSlice(
+						element.Field{
+							ID:    cfgpath.NewRoute(`shipping_tax_class`),
+							Label: text.Chars(`Tax Class for Shipping`),
+							Type:  element.TypeSelect,
+						},
+					),
+				},
+				element.Group{
+					ID: cfgpath.NewRoute(`cart_display`),
+					Fields: element.NewFieldSlice(
+						element.Field{
+							ID:      cfgpath.NewRoute(`zero_tax`),
+							Label:   text.Chars(`Display Zero Tax Subtotal`),
+							Comment: text.Chars(`Shows a zero tax line in the cart.`),
+							Type:    element.TypeSelect,
+							Default: false,
+						},
+					),
+				},
+			),
+		},
+	)
+
+	var buf bytes.Buffer
+	if err := backend.Generate(&buf, backend.GenerateOptions{Package: "tax"}, ss); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	src := buf.String()
+	assert.Contains(t, src, "package tax\n")
+	assert.Contains(t, src, "type Configuration struct {")
+	assert.Contains(t, src, "// TaxClassesShippingTaxClass => Tax Class for Shipping.\n")
+	assert.Contains(t, src, "TaxClassesShippingTaxClass cfgmodel.Str\n")
+	assert.Contains(t, src, "// Shows a zero tax line in the cart.\n")
+	assert.Contains(t, src, "TaxCartDisplayZeroTax cfgmodel.Bool\n")
+	assert.Contains(t, src, "func NewConfiguration(cfgStruct element.SectionSlice, opts ...cfgmodel.Option) *Configuration {")
+	assert.Contains(t, src, "be.TaxCartDisplayZeroTax = cfgmodel.NewBool(`tax/cart_display/zero_tax`, opts...)")
+}
+
+func TestGenerate_BuildTags(t *testing.T) {
+	ss := element.MustNewConfiguration(
+		element.Section{
+			ID: cfgpath.NewRoute(`aa`),
+			Groups: element.NewGroupSlice(
+				element.Group{
+					ID:     cfgpath.NewRoute(`bb`),
+					Fields: element.NewFieldSlice(element.Field{ID: cfgpath.NewRoute(`cc`)}),
+				},
+			),
+		},
+	)
+	var buf bytes.Buffer
+	err := backend.Generate(&buf, backend.GenerateOptions{Package: "xx", StructName: "PkgBackend", BuildTags: []string{"ignore"}}, ss)
+	assert.NoError(t, err, "%+v", err)
+	assert.Contains(t, buf.String(), "// +build ignore\n\npackage xx")
+	assert.Contains(t, buf.String(), "func NewPkgBackend(")
+}
+
+func TestGenerate_EmptyPackage(t *testing.T) {
+	err := backend.Generate(nil, backend.GenerateOptions{}, nil)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
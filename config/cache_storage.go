@@ -0,0 +1,289 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/errors"
+)
+
+// DefaultCacheSize maximum number of cached paths of a CacheStorage.
+const DefaultCacheSize = 4096
+
+// CacheOption applies options to the NewCacheStorage function.
+type CacheOption func(*CacheStorage)
+
+// WithCacheSize sets the maximum number of entries. If the limit has been
+// reached, the least recently used entry gets evicted.
+func WithCacheSize(size int) CacheOption {
+	return func(c *CacheStorage) {
+		if size > 0 {
+			c.size = size
+		}
+	}
+}
+
+// WithCacheTTL sets the default time to live for all entries. Zero means no
+// expiration and entries are only removed by LRU eviction or invalidation.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *CacheStorage) {
+		c.ttl = ttl
+	}
+}
+
+// WithCacheRouteTTL sets a time to live for all paths starting with route.
+// The route can have up to three levels, e.g. "web", "web/secure" or
+// "web/secure/base_url". The most specific route wins.
+func WithCacheRouteTTL(route string, ttl time.Duration) CacheOption {
+	return func(c *CacheStorage) {
+		if c.routeTTL == nil {
+			c.routeTTL = make(map[string]time.Duration)
+		}
+		c.routeTTL[route] = ttl
+	}
+}
+
+// CacheStorage wraps a Storager and keeps the most recently used values in
+// memory. Keys contain the scope and the scope ID, so a store scoped value
+// gets cached independently of its website or default value. Values which
+// cannot be found in the backend get cached as well, because the scope
+// fallback in Scoped asks for many non existing store and website paths.
+//
+// Set writes through to the backend and removes the cached entry. Register
+// the CacheStorage as a MessageReceiver via Service.Subscribe to invalidate
+// entries which have been written by other parts of the application.
+//
+// CacheStorage implements the expvar.Var interface and can be published via
+// expvar.Publish("config_cache", cs) to monitor the hit ratio.
+type CacheStorage struct {
+	// Backend the wrapped Storager.
+	Backend Storager
+
+	size     int
+	ttl      time.Duration
+	routeTTL map[string]time.Duration
+	// now returns the current time. Can be changed for testing.
+	now func() time.Time
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[uint32]*list.Element
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type cacheEntry struct {
+	key     uint32
+	value   interface{}
+	err     error // only NotFound errors get cached
+	expires time.Time
+}
+
+// NewCacheStorage creates a new LRU cache in front of backend. Default size
+// is DefaultCacheSize without expiration.
+func NewCacheStorage(backend Storager, opts ...CacheOption) *CacheStorage {
+	c := &CacheStorage{
+		Backend: backend,
+		size:    DefaultCacheSize,
+		now:     time.Now,
+		ll:      list.New(),
+		items:   make(map[uint32]*list.Element),
+	}
+	for _, o := range opts {
+		if o != nil {
+			o(c)
+		}
+	}
+	return c
+}
+
+// WithCache wraps the current Storager of the Service with the CacheStorage.
+// The Backend field of cs gets set to the current Storager if it is nil.
+// Service.Write invalidates the written path automatically. If the publish
+// and subscribe service is running, the cache subscribes itself to the
+// provided routes to get notified about paths written by other Services
+// sharing the same backend. Apply this option after WithPubSub.
+func WithCache(cs *CacheStorage, routes ...cfgpath.Route) Option {
+	return func(s *Service) error {
+		if cs.Backend == nil {
+			cs.Backend = s.backend
+		}
+		s.backend = cs
+		if s.pubSub == nil {
+			return nil
+		}
+		for _, r := range routes {
+			if _, err := s.Subscribe(r, cs); err != nil {
+				return errors.Wrapf(err, "[config] WithCache.Subscribe Route %q", r)
+			}
+		}
+		return nil
+	}
+}
+
+// Set implements the Storager interface. It writes the value into the
+// backend and invalidates the cached entry.
+func (c *CacheStorage) Set(key cfgpath.Path, value interface{}) error {
+	if err := c.Backend.Set(key, value); err != nil {
+		return errors.Wrap(err, "[config] CacheStorage.Backend.Set")
+	}
+	c.Invalidate(key)
+	return nil
+}
+
+// Get implements the Storager interface. A NotFound error of the backend gets
+// cached. All other errors get returned without caching.
+func (c *CacheStorage) Get(key cfgpath.Path) (interface{}, error) {
+	h32, err := key.Hash(-1)
+	if err != nil {
+		return nil, errors.Wrap(err, "[config] CacheStorage.key.Hash")
+	}
+
+	now := c.now()
+	c.mu.Lock()
+	if el, ok := c.items[h32]; ok {
+		ce := el.Value.(*cacheEntry)
+		if ce.expires.IsZero() || now.Before(ce.expires) {
+			c.ll.MoveToFront(el)
+			c.mu.Unlock()
+			atomic.AddUint64(&c.hits, 1)
+			return ce.value, ce.err
+		}
+		c.removeElement(el)
+	}
+	c.mu.Unlock()
+	atomic.AddUint64(&c.misses, 1)
+
+	v, err := c.Backend.Get(key)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	ce := &cacheEntry{key: h32, value: v, err: err}
+	if ttl := c.ttlFor(key); ttl > 0 {
+		ce.expires = now.Add(ttl)
+	}
+	c.mu.Lock()
+	if el, ok := c.items[h32]; ok {
+		c.removeElement(el)
+	}
+	c.items[h32] = c.ll.PushFront(ce)
+	for c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+		atomic.AddUint64(&c.evictions, 1)
+	}
+	c.mu.Unlock()
+	return v, err
+}
+
+// AllKeys implements the Storager interface and asks always the backend.
+func (c *CacheStorage) AllKeys() (cfgpath.PathSlice, error) {
+	return c.Backend.AllKeys()
+}
+
+// MessageConfig implements the MessageReceiver interface and invalidates the
+// written path.
+func (c *CacheStorage) MessageConfig(p cfgpath.Path) error {
+	c.Invalidate(p)
+	return nil
+}
+
+// Invalidate removes a scoped path from the cache.
+func (c *CacheStorage) Invalidate(key cfgpath.Path) {
+	h32, err := key.Hash(-1)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	if el, ok := c.items[h32]; ok {
+		c.removeElement(el)
+	}
+	c.mu.Unlock()
+}
+
+// Purge removes all entries from the cache.
+func (c *CacheStorage) Purge() {
+	c.mu.Lock()
+	c.ll.Init()
+	c.items = make(map[uint32]*list.Element)
+	c.mu.Unlock()
+}
+
+// Len returns the number of cached entries.
+func (c *CacheStorage) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// removeElement caller must hold the lock.
+func (c *CacheStorage) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*cacheEntry).key)
+}
+
+func (c *CacheStorage) ttlFor(key cfgpath.Path) time.Duration {
+	for depth := 3; depth > 0 && len(c.routeTTL) > 0; depth-- {
+		r, err := key.Route.Level(depth)
+		if err != nil {
+			break
+		}
+		if ttl, ok := c.routeTTL[r.String()]; ok {
+			return ttl
+		}
+	}
+	return c.ttl
+}
+
+// CacheStats contains the metrics of a CacheStorage.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// HitRatio returns the percentage of hits between 0 and 1.
+func (cs CacheStats) HitRatio() float64 {
+	if total := cs.Hits + cs.Misses; total > 0 {
+		return float64(cs.Hits) / float64(total)
+	}
+	return 0
+}
+
+// Stats returns the current metrics.
+func (c *CacheStorage) Stats() CacheStats {
+	return CacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Size:      c.Len(),
+	}
+}
+
+// String implements the expvar.Var interface and returns the metrics as JSON.
+func (c *CacheStorage) String() string {
+	st := c.Stats()
+	return fmt.Sprintf(`{"hits":%d,"misses":%d,"evictions":%d,"size":%d,"hit_ratio":%.4f}`,
+		st.Hits, st.Misses, st.Evictions, st.Size, st.HitRatio())
+}
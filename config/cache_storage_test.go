@@ -0,0 +1,207 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ Storager = (*CacheStorage)(nil)
+var _ MessageReceiver = (*CacheStorage)(nil)
+var _ expvar.Var = (*CacheStorage)(nil)
+
+type countingStorage struct {
+	Storager
+	mu   sync.Mutex
+	gets int
+	err  error
+}
+
+func (cs *countingStorage) Get(key cfgpath.Path) (interface{}, error) {
+	cs.mu.Lock()
+	cs.gets++
+	cs.mu.Unlock()
+	if cs.err != nil {
+		return nil, cs.err
+	}
+	return cs.Storager.Get(key)
+}
+
+func (cs *countingStorage) getCount() int {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.gets
+}
+
+func TestCacheStorage_Get(t *testing.T) {
+	be := &countingStorage{Storager: NewInMemoryStore()}
+	cs := NewCacheStorage(be)
+
+	p := cfgpath.MustNewByParts("web/secure/base_url")
+	require.NoError(t, cs.Set(p, "https://corestore.io"))
+	require.NoError(t, cs.Set(p.BindStore(2), "https://store2.corestore.io"))
+
+	for i := 0; i < 3; i++ {
+		v, err := cs.Get(p)
+		assert.NoError(t, err)
+		assert.Exactly(t, "https://corestore.io", v)
+
+		v, err = cs.Get(p.BindStore(2))
+		assert.NoError(t, err)
+		assert.Exactly(t, "https://store2.corestore.io", v)
+	}
+	assert.Exactly(t, 2, be.getCount(), "Backend calls")
+
+	st := cs.Stats()
+	assert.Exactly(t, uint64(4), st.Hits)
+	assert.Exactly(t, uint64(2), st.Misses)
+	assert.Exactly(t, 2, st.Size)
+	assert.InDelta(t, 0.6666, st.HitRatio(), 0.001)
+}
+
+func TestCacheStorage_NotFound(t *testing.T) {
+	be := &countingStorage{Storager: NewInMemoryStore()}
+	cs := NewCacheStorage(be)
+	p := cfgpath.MustNewByParts("web/secure/base_url").BindWebsite(3)
+
+	for i := 0; i < 2; i++ {
+		v, err := cs.Get(p)
+		assert.Nil(t, v)
+		assert.True(t, errors.IsNotFound(err), "%+v", err)
+	}
+	assert.Exactly(t, 1, be.getCount())
+
+	require.NoError(t, cs.Set(p, 3.14159))
+	v, err := cs.Get(p)
+	assert.NoError(t, err)
+	assert.Exactly(t, 3.14159, v)
+	assert.Exactly(t, 2, be.getCount())
+}
+
+func TestCacheStorage_BackendError(t *testing.T) {
+	be := &countingStorage{Storager: NewInMemoryStore(), err: errors.NewFatalf("Database gone")}
+	cs := NewCacheStorage(be)
+	p := cfgpath.MustNewByParts("web/secure/base_url")
+
+	for i := 0; i < 2; i++ {
+		_, err := cs.Get(p)
+		assert.True(t, errors.IsFatal(err), "%+v", err)
+	}
+	assert.Exactly(t, 2, be.getCount(), "errors must not be cached")
+	assert.Exactly(t, 0, cs.Len())
+}
+
+func TestCacheStorage_TTL(t *testing.T) {
+	now := time.Unix(1480000000, 0)
+	be := &countingStorage{Storager: NewInMemoryStore()}
+	cs := NewCacheStorage(be,
+		WithCacheTTL(time.Minute),
+		WithCacheRouteTTL("carriers/flatrate", time.Second),
+	)
+	cs.now = func() time.Time { return now }
+
+	pWeb := cfgpath.MustNewByParts("web/secure/base_url")
+	pFlat := cfgpath.MustNewByParts("carriers/flatrate/price")
+
+	assert.Exactly(t, time.Minute, cs.ttlFor(pWeb))
+	assert.Exactly(t, time.Second, cs.ttlFor(pFlat))
+
+	_, _ = cs.Get(pWeb)
+	_, _ = cs.Get(pFlat)
+	assert.Exactly(t, 2, be.getCount())
+
+	now = now.Add(2 * time.Second)
+	_, _ = cs.Get(pWeb)
+	_, _ = cs.Get(pFlat)
+	assert.Exactly(t, 3, be.getCount(), "flatrate must be expired")
+
+	now = now.Add(2 * time.Minute)
+	_, _ = cs.Get(pWeb)
+	assert.Exactly(t, 4, be.getCount(), "web must be expired")
+}
+
+func TestCacheStorage_LRU(t *testing.T) {
+	be := &countingStorage{Storager: NewInMemoryStore()}
+	cs := NewCacheStorage(be, WithCacheSize(2))
+
+	p1 := cfgpath.MustNewByParts("aa/bb/cc")
+	p2 := cfgpath.MustNewByParts("aa/bb/dd")
+	p3 := cfgpath.MustNewByParts("aa/bb/ee")
+
+	_, _ = cs.Get(p1)
+	_, _ = cs.Get(p2)
+	_, _ = cs.Get(p1) // p2 becomes the least recently used entry
+	_, _ = cs.Get(p3)
+	assert.Exactly(t, 2, cs.Len())
+	assert.Exactly(t, uint64(1), cs.Stats().Evictions)
+
+	_, _ = cs.Get(p1)
+	assert.Exactly(t, 3, be.getCount(), "p1 must still be cached")
+	_, _ = cs.Get(p2)
+	assert.Exactly(t, 4, be.getCount(), "p2 must have been evicted")
+
+	cs.Purge()
+	assert.Exactly(t, 0, cs.Len())
+}
+
+func TestCacheStorage_String(t *testing.T) {
+	cs := NewCacheStorage(NewInMemoryStore())
+	_, _ = cs.Get(cfgpath.MustNewByParts("aa/bb/cc"))
+	_, _ = cs.Get(cfgpath.MustNewByParts("aa/bb/cc"))
+
+	var m map[string]float64
+	require.NoError(t, json.Unmarshal([]byte(cs.String()), &m))
+	assert.Exactly(t, map[string]float64{
+		"hits": 1, "misses": 1, "evictions": 0, "size": 1, "hit_ratio": 0.5,
+	}, m)
+}
+
+func TestWithCache(t *testing.T) {
+	be := &countingStorage{Storager: NewInMemoryStore()}
+	cs := NewCacheStorage(nil)
+	p := cfgpath.MustNewByParts("web/secure/base_url").BindStore(1)
+
+	srv := MustNewService(be, WithPubSub(), WithCache(cs, cfgpath.NewRoute("web/secure")))
+	defer func() { assert.NoError(t, srv.Close()) }()
+
+	require.NoError(t, srv.Write(p, "https://a.io"))
+	for i := 0; i < 2; i++ {
+		v, err := srv.String(p)
+		assert.NoError(t, err)
+		assert.Exactly(t, "https://a.io", v)
+	}
+	assert.Exactly(t, 1, be.getCount())
+
+	require.NoError(t, srv.Write(p, "https://b.io"))
+	v, err := srv.String(p)
+	assert.NoError(t, err)
+	assert.Exactly(t, "https://b.io", v)
+
+	// value written directly into the backend, e.g. by another Service.
+	require.NoError(t, be.Set(p, "https://c.io"))
+	require.NoError(t, cs.MessageConfig(p))
+	v, err = srv.String(p)
+	assert.NoError(t, err)
+	assert.Exactly(t, "https://c.io", v)
+}
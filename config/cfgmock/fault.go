@@ -0,0 +1,167 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgmock
+
+import (
+	"math/rand"
+	"path"
+	"time"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/errors"
+)
+
+// FaultOp defines the operations on which a Fault gets injected.
+type FaultOp uint8
+
+// Operations for the field Ops of type Fault. Can be combined with the binary
+// OR operator.
+const (
+	FaultGet FaultOp = 1 << iota
+	FaultWrite
+)
+
+// Fault defines latency and an intermittent error which gets injected into
+// the Service whenever a path matches the Pattern. Useful to test the
+// resilience of code depending on configuration values under degraded
+// conditions.
+type Fault struct {
+	// Pattern gets matched with path.Match against the fully qualified path,
+	// for example "websites/*/web/cookie/*" or "*/*/web/*/*". An empty Pattern
+	// matches all paths.
+	Pattern string
+	// Ops defines the operations where the fault applies. Zero applies the
+	// fault to FaultGet and FaultWrite.
+	Ops FaultOp
+	// Latency gets added to each matching call. Latencies of several matching
+	// faults sum up.
+	Latency time.Duration
+	// ErrorRate between 0 and 1 defines the probability of returning Err. One
+	// returns always an error.
+	ErrorRate float64
+	// Err optional custom error. Defaults to an error with behaviour Temporary.
+	Err error
+}
+
+func (f Fault) matches(op FaultOp, fq string) bool {
+	if f.Ops != 0 && f.Ops&op == 0 {
+		return false
+	}
+	if f.Pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(f.Pattern, fq)
+	return ok
+}
+
+// InjectFaults adds faults to the Service. The random numbers for the error
+// rates derive from a fixed seed, so the sequence of injected errors stays
+// reproducible between test runs. An invalid Pattern panics.
+func (s *Service) InjectFaults(fs ...Fault) *Service {
+	s.faultMu.Lock()
+	defer s.faultMu.Unlock()
+	for _, f := range fs {
+		if _, err := path.Match(f.Pattern, ""); err != nil {
+			panic(errors.NewNotValidf("[cfgmock] Fault Pattern %q: %s", f.Pattern, err))
+		}
+	}
+	s.faults = append(s.faults, fs...)
+	if s.faultRand == nil {
+		s.faultRand = rand.New(rand.NewSource(1))
+	}
+	return s
+}
+
+// ResetFaults removes all faults and their invocations.
+func (s *Service) ResetFaults() {
+	s.faultMu.Lock()
+	defer s.faultMu.Unlock()
+	s.faults = nil
+	s.faultInvokes = nil
+}
+
+// FaultInvokes returns the paths and the number of injected errors.
+func (s *Service) FaultInvokes() Invocations {
+	s.faultMu.Lock()
+	defer s.faultMu.Unlock()
+	ret := make(Invocations, len(s.faultInvokes))
+	for k, v := range s.faultInvokes {
+		ret[k] = v
+	}
+	return ret
+}
+
+// injectFault sleeps the summed latency of all matching faults and returns an
+// error if one of the faults hits its error rate. The latency applies outside
+// of any lock so that concurrent calls do not get serialized.
+func (s *Service) injectFault(op FaultOp, fq string) error {
+	s.faultMu.Lock()
+	if len(s.faults) == 0 {
+		s.faultMu.Unlock()
+		return nil
+	}
+	var latency time.Duration
+	var err error
+	for _, f := range s.faults {
+		if !f.matches(op, fq) {
+			continue
+		}
+		latency += f.Latency
+		if err == nil && f.ErrorRate > 0 && s.faultRand.Float64() < f.ErrorRate {
+			err = f.Err
+			if err == nil {
+				err = errors.NewTemporaryf("[cfgmock] Injected fault for path %q", fq)
+			}
+		}
+	}
+	if err != nil {
+		if s.faultInvokes == nil {
+			s.faultInvokes = make(Invocations)
+		}
+		s.faultInvokes[fq]++
+	}
+	s.faultMu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	return err
+}
+
+// Write writes a value into the Storage. Matching faults with operation
+// FaultWrite can delay or fail the call. Implements interface config.Writer.
+func (s *Service) Write(p cfgpath.Path, v interface{}) error {
+	if err := s.injectFault(FaultWrite, p.String()); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writeInvokes == nil {
+		s.writeInvokes = make(Invocations)
+	}
+	s.writeInvokes[p.String()]++
+	if s.Storage == nil {
+		s.Storage = config.NewInMemoryStore()
+	}
+	return s.Storage.Set(p, v)
+}
+
+// WriteInvokes returns the number of Write() invocations.
+func (s *Service) WriteInvokes() Invocations {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeInvokes
+}
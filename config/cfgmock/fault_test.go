@@ -0,0 +1,121 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgmock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ config.Writer = (*cfgmock.Service)(nil)
+
+func TestService_InjectFaults_Error(t *testing.T) {
+	pCookie := cfgpath.MustNewByParts("web/cookie/cookie_path")
+	pName := cfgpath.MustNewByParts("general/store_information/name")
+
+	s := cfgmock.NewService(cfgmock.PathValue{
+		pCookie.String(): "/",
+		pName.String():   "CoreStore",
+	}).InjectFaults(cfgmock.Fault{
+		Pattern:   "default/*/web/*/*",
+		ErrorRate: 1,
+	})
+
+	v, err := s.String(pCookie)
+	assert.True(t, errors.IsTemporary(err), "%+v", err)
+	assert.Empty(t, v)
+
+	v, err = s.String(pName)
+	assert.NoError(t, err)
+	assert.Exactly(t, "CoreStore", v)
+
+	assert.Exactly(t, cfgmock.Invocations{"default/0/web/cookie/cookie_path": 1}, s.FaultInvokes())
+	assert.Exactly(t, []string{"default/0/general/store_information/name"}, s.AllInvocations().Paths())
+
+	s.ResetFaults()
+	v, err = s.String(pCookie)
+	assert.NoError(t, err)
+	assert.Exactly(t, "/", v)
+	assert.Empty(t, s.FaultInvokes())
+}
+
+func TestService_InjectFaults_ErrorRate(t *testing.T) {
+	p := cfgpath.MustNewByParts("aa/bb/cc")
+
+	countErrors := func() (n int) {
+		s := cfgmock.NewService(cfgmock.PathValue{p.String(): 1}).
+			InjectFaults(cfgmock.Fault{ErrorRate: 0.3})
+		for i := 0; i < 1000; i++ {
+			if _, err := s.Int(p); err != nil {
+				n++
+			}
+		}
+		assert.Exactly(t, n, s.FaultInvokes().Sum())
+		assert.Exactly(t, 1000-n, s.AllInvocations().Sum())
+		return n
+	}
+
+	n := countErrors()
+	assert.InDelta(t, 300, n, 60)
+	assert.Exactly(t, n, countErrors(), "Sequence of errors must be reproducible")
+}
+
+func TestService_InjectFaults_Latency(t *testing.T) {
+	p := cfgpath.MustNewByParts("aa/bb/cc")
+	s := cfgmock.NewService(cfgmock.PathValue{p.String(): true}).InjectFaults(
+		cfgmock.Fault{Latency: 10 * time.Millisecond},
+		cfgmock.Fault{Pattern: "*/*/aa/*/*", Latency: 15 * time.Millisecond},
+		cfgmock.Fault{Pattern: "*/*/xx/*/*", Latency: time.Hour},
+	)
+
+	now := time.Now()
+	v, err := s.Bool(p)
+	assert.NoError(t, err)
+	assert.True(t, v)
+	assert.True(t, time.Since(now) >= 25*time.Millisecond, "Latency too short: %s", time.Since(now))
+}
+
+func TestService_InjectFaults_Write(t *testing.T) {
+	p := cfgpath.MustNewByParts("aa/bb/cc")
+	wantErr := errors.NewAlreadyClosedf("DB gone")
+
+	s := cfgmock.NewService()
+	assert.NoError(t, s.Write(p, "Gopher"))
+	assert.Exactly(t, cfgmock.Invocations{"default/0/aa/bb/cc": 1}, s.WriteInvokes())
+
+	s.InjectFaults(cfgmock.Fault{Ops: cfgmock.FaultWrite, ErrorRate: 1, Err: wantErr})
+	assert.Exactly(t, wantErr, s.Write(p, "Bug"))
+
+	v, err := s.String(p)
+	assert.NoError(t, err)
+	assert.Exactly(t, "Gopher", v)
+}
+
+func TestService_InjectFaults_InvalidPattern(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			assert.True(t, errors.IsNotValid(r.(error)), "%+v", r)
+		} else {
+			t.Fatal("Expecting a panic")
+		}
+	}()
+	cfgmock.NewService().InjectFaults(cfgmock.Fault{Pattern: "[a-"})
+}
@@ -16,6 +16,7 @@ package cfgmock
 
 import (
 	"fmt"
+	"math/rand"
 	"reflect"
 	"sort"
 	"sync"
@@ -127,6 +128,12 @@ type Service struct {
 	durationInvokes  Invocations
 	SubscribeFn      func(cfgpath.Route, config.MessageReceiver) (subscriptionID int, err error)
 	SubscribeInvokes int32
+	writeInvokes     Invocations
+
+	faultMu      sync.Mutex
+	faults       []Fault
+	faultRand    *rand.Rand
+	faultInvokes Invocations
 }
 
 // PathValue is a required type for an option function. PV = path => value. This
@@ -237,12 +244,15 @@ func (s *Service) getVal(p cfgpath.Path) interface{} {
 
 // Byte returns a byte slice value
 func (s *Service) Byte(p cfgpath.Path) ([]byte, error) {
+	ps := p.String()
+	if err := s.injectFault(FaultGet, ps); err != nil {
+		return nil, err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.byteInvokes == nil {
 		s.byteInvokes = make(Invocations)
 	}
-	ps := p.String()
 	s.byteInvokes[ps]++
 
 	switch {
@@ -262,12 +272,15 @@ func (s *Service) ByteInvokes() Invocations {
 
 // String returns a string value
 func (s *Service) String(p cfgpath.Path) (string, error) {
+	ps := p.String()
+	if err := s.injectFault(FaultGet, ps); err != nil {
+		return "", err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.stringInvokes == nil {
 		s.stringInvokes = make(Invocations)
 	}
-	ps := p.String()
 	s.stringInvokes[ps]++
 
 	switch {
@@ -287,12 +300,15 @@ func (s *Service) StringInvokes() Invocations {
 
 // Bool returns a bool value
 func (s *Service) Bool(p cfgpath.Path) (bool, error) {
+	ps := p.String()
+	if err := s.injectFault(FaultGet, ps); err != nil {
+		return false, err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.boolInvokes == nil {
 		s.boolInvokes = make(Invocations)
 	}
-	ps := p.String()
 	s.boolInvokes[ps]++
 
 	switch {
@@ -312,12 +328,15 @@ func (s *Service) BoolInvokes() Invocations {
 
 // Float64 returns a float64 value
 func (s *Service) Float64(p cfgpath.Path) (float64, error) {
+	ps := p.String()
+	if err := s.injectFault(FaultGet, ps); err != nil {
+		return 0.0, err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.float64Invokes == nil {
 		s.float64Invokes = make(Invocations)
 	}
-	ps := p.String()
 	s.float64Invokes[ps]++
 
 	switch {
@@ -337,12 +356,15 @@ func (s *Service) Float64Invokes() Invocations {
 
 // Int returns an integer value
 func (s *Service) Int(p cfgpath.Path) (int, error) {
+	ps := p.String()
+	if err := s.injectFault(FaultGet, ps); err != nil {
+		return 0, err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.intInvokes == nil {
 		s.intInvokes = make(Invocations)
 	}
-	ps := p.String()
 	s.intInvokes[ps]++
 
 	switch {
@@ -362,12 +384,15 @@ func (s *Service) IntInvokes() Invocations {
 
 // Time returns a time value
 func (s *Service) Time(p cfgpath.Path) (time.Time, error) {
+	ps := p.String()
+	if err := s.injectFault(FaultGet, ps); err != nil {
+		return time.Time{}, err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.timeInvokes == nil {
 		s.timeInvokes = make(Invocations)
 	}
-	ps := p.String()
 	s.timeInvokes[ps]++
 
 	switch {
@@ -387,12 +412,15 @@ func (s *Service) TimeInvokes() Invocations {
 
 // Duration returns a duration value or a NotFound error.
 func (s *Service) Duration(p cfgpath.Path) (time.Duration, error) {
+	ps := p.String()
+	if err := s.injectFault(FaultGet, ps); err != nil {
+		return 0, err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if s.durationInvokes == nil {
 		s.durationInvokes = make(Invocations)
 	}
-	ps := p.String()
 	s.durationInvokes[ps]++
 
 	switch {
@@ -131,6 +131,9 @@ type baseValue struct {
 	// Validation gets triggered only when the slice has been set. The Options()
 	// function will be used to access this slice.
 	Source cfgsource.Slice
+	// Validators check and normalize a value in Write() before it reaches
+	// the config.Writer. See WithValidator.
+	Validators []Validator
 	// LastError might contain an error when an applied functional option
 	// returns an error in any New*() constructor. Exported for testing reasons.
 	// Every Get() function in a primitive type checks for this error.
@@ -178,12 +181,16 @@ func (bv baseValue) initScope() (p scope.Perm) {
 
 // Write writes a value v to the config.Writer without checking if the value has
 // changed. Checks if the Scope matches as defined in the non-nil
-// ConfigStructure. Error behaviour: Unauthorized
+// ConfigStructure and runs the Validators. Error behaviour: Unauthorized,
+// NotValid
 func (bv baseValue) Write(w config.Writer, v interface{}, h scope.TypeID) error {
 	pp, err := bv.ToPath(h)
 	if err != nil {
 		return errors.Wrap(err, "[cfgmodel] baseValue.ToPath")
 	}
+	if v, err = bv.validate(v); err != nil {
+		return errors.Wrap(err, "[cfgmodel] baseValue.validate")
+	}
 	return w.Write(pp, v)
 }
 
@@ -33,6 +33,10 @@
 // the supported type of the underlying storage engine. E.g. for package
 // config/storage/ccd it config.Writer converts all types to a byte slice.
 //
+// Before a value reaches the config.Writer it passes the optional chain of
+// Validators added with WithValidator. A Validator can reject a value with a
+// NotValid error or normalize it, e.g. ValidateCountryCSV upper cases the codes.
+//
 //
 // The global PackageConfiguration variable (type element.SectionSlice), which
 // is present in each package, gets set to the cfgmodel.New* variables during
@@ -18,4 +18,8 @@ const (
 	errScopePermissionInsufficient = `[cfgmodel] Scope permission insufficient: Have %q; Want %q; Route: %q`
 	errValueNotFoundInOptions      = `[cfgmodel] The value '%s' cannot be found within the allowed Options():\n%s`
 	errIntCSVFailedToConvertToInt  = `[cfgmodel] IntCsv.Get: Cannot cannot convert %q to type int: %v`
+	errValidateURLNotAbsolute      = `[cfgmodel] URL %q must be absolute and contain a host`
+	errValidateURLScheme           = `[cfgmodel] URL scheme %q of %q not allowed. Allowed: %q`
+	errValidateIntRange            = `[cfgmodel] Integer %d out of range [%d, %d]`
+	errValidateCountryCode         = `[cfgmodel] Invalid ISO 3166-1 alpha-2 country code %q in %q`
 )
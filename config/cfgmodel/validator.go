@@ -0,0 +1,149 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgmodel
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/errors"
+	"golang.org/x/text/language"
+)
+
+// Validator checks and optionally normalizes a value before it gets written
+// to the config.Writer. The returned value replaces the input value for the
+// next Validator in the chain. Returned errors should have the NotValid
+// behaviour; all other errors get converted to NotValid.
+type Validator func(v interface{}) (interface{}, error)
+
+// WithValidator appends validators to the chain which runs in baseValue.Write
+// after the scope permission check. Validators receive the value in the same
+// representation as it gets passed to the config.Writer, e.g. StringCSV passes
+// the joined string.
+func WithValidator(vs ...Validator) Option {
+	return func(b *optionBox) error {
+		for _, v := range vs {
+			if v != nil {
+				b.Validators = append(b.Validators, v)
+			}
+		}
+		return nil
+	}
+}
+
+// validate runs the Validator chain. Error behaviour: NotValid
+func (bv baseValue) validate(v interface{}) (interface{}, error) {
+	for _, vf := range bv.Validators {
+		var err error
+		if v, err = vf(v); err != nil {
+			if errors.IsNotValid(err) {
+				return nil, errors.Wrapf(err, "[cfgmodel] Route %q", bv.route)
+			}
+			return nil, errors.NewNotValid(err, "[cfgmodel] Route "+bv.route.String())
+		}
+	}
+	return v, nil
+}
+
+// SanitizeTrimSpace removes leading and trailing white space from string and
+// byte slice values. Other types get passed through.
+func SanitizeTrimSpace() Validator {
+	return func(v interface{}) (interface{}, error) {
+		switch vt := v.(type) {
+		case string:
+			return strings.TrimSpace(vt), nil
+		case []byte:
+			return []byte(strings.TrimSpace(string(vt))), nil
+		}
+		return v, nil
+	}
+}
+
+// ValidateURL checks that the value is an absolute URL with a host. The
+// optional schemes restrict the allowed URL schemes, e.g. "https". An empty
+// string passes. Error behaviour: NotValid
+func ValidateURL(schemes ...string) Validator {
+	return func(v interface{}) (interface{}, error) {
+		raw, err := conv.ToStringE(v)
+		if err != nil {
+			return nil, errors.NewNotValid(err, "[cfgmodel] ValidateURL.ToString")
+		}
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			return raw, nil
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, errors.NewNotValid(err, "[cfgmodel] ValidateURL.Parse")
+		}
+		if !u.IsAbs() || u.Host == "" {
+			return nil, errors.NewNotValidf(errValidateURLNotAbsolute, raw)
+		}
+		if len(schemes) == 0 {
+			return raw, nil
+		}
+		for _, s := range schemes {
+			if strings.EqualFold(u.Scheme, s) {
+				return raw, nil
+			}
+		}
+		return nil, errors.NewNotValidf(errValidateURLScheme, u.Scheme, raw, schemes)
+	}
+}
+
+// ValidateIntRange checks that an integer value, or a string containing an
+// integer, lies within min and max, both inclusive. Error behaviour: NotValid
+func ValidateIntRange(min, max int) Validator {
+	return func(v interface{}) (interface{}, error) {
+		i, err := conv.ToIntE(v)
+		if err != nil {
+			return nil, errors.NewNotValid(err, "[cfgmodel] ValidateIntRange.ToInt")
+		}
+		if i < min || i > max {
+			return nil, errors.NewNotValidf(errValidateIntRange, i, min, max)
+		}
+		return v, nil
+	}
+}
+
+// ValidateCountryCSV checks a separated list of ISO 3166-1 alpha-2 country
+// codes and normalizes it: white space gets removed, the codes get upper
+// cased and empty entries dropped. A zero separator defaults to a comma.
+// Error behaviour: NotValid
+func ValidateCountryCSV(sep rune) Validator {
+	if sep == 0 {
+		sep = CSVComma
+	}
+	return func(v interface{}) (interface{}, error) {
+		raw, err := conv.ToStringE(v)
+		if err != nil {
+			return nil, errors.NewNotValid(err, "[cfgmodel] ValidateCountryCSV.ToString")
+		}
+		parts := strings.Split(raw, string(sep))
+		codes := parts[:0]
+		for _, p := range parts {
+			p = strings.ToUpper(strings.TrimSpace(p))
+			if p == "" {
+				continue
+			}
+			if r, err := language.ParseRegion(p); len(p) != 2 || err != nil || !r.IsCountry() {
+				return nil, errors.NewNotValidf(errValidateCountryCode, p, raw)
+			}
+			codes = append(codes, p)
+		}
+		return strings.Join(codes, string(sep)), nil
+	}
+}
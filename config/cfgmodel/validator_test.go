@@ -0,0 +1,144 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgmodel_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		schemes []string
+		have    interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{nil, " https://corestore.io/shop ", "https://corestore.io/shop", false},
+		{nil, "", "", false},
+		{nil, "/relative/path", nil, true},
+		{nil, "http://", nil, true},
+		{[]string{"https"}, "http://corestore.io", nil, true},
+		{[]string{"http", "HTTPS"}, "https://corestore.io", "https://corestore.io", false},
+		{nil, []byte("http://cs.io"), "http://cs.io", false},
+		{nil, struct{}{}, nil, true},
+	}
+	for i, test := range tests {
+		have, err := cfgmodel.ValidateURL(test.schemes...)(test.have)
+		if test.wantErr {
+			assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
+
+func TestValidateIntRange(t *testing.T) {
+	tests := []struct {
+		have    interface{}
+		wantErr bool
+	}{
+		{1, false},
+		{10, false},
+		{"5", false},
+		{0, true},
+		{11, true},
+		{"x", true},
+	}
+	for i, test := range tests {
+		have, err := cfgmodel.ValidateIntRange(1, 10)(test.have)
+		if test.wantErr {
+			assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.have, have, "Index %d", i)
+	}
+}
+
+func TestValidateCountryCSV(t *testing.T) {
+	tests := []struct {
+		sep     rune
+		have    string
+		want    string
+		wantErr bool
+	}{
+		{0, "de, ch,AT", "DE,CH,AT", false},
+		{0, "DE,,NZ,", "DE,NZ", false},
+		{0, "", "", false},
+		{'|', "us|gb", "US|GB", false},
+		{0, "DE,XX", "", true},
+		{0, "DEU", "", true},
+		{0, "DE,419", "", true},
+	}
+	for i, test := range tests {
+		have, err := cfgmodel.ValidateCountryCSV(test.sep)(test.have)
+		if test.wantErr {
+			assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
+
+func TestWithValidator_Write(t *testing.T) {
+
+	t.Run("sanitized chain", func(t *testing.T) {
+		sc := cfgmodel.NewStringCSV("general/country/allow",
+			cfgmodel.WithScopeStore(),
+			cfgmodel.WithValidator(cfgmodel.SanitizeTrimSpace(), cfgmodel.ValidateCountryCSV(0)),
+		)
+		mw := &cfgmock.Write{}
+		assert.NoError(t, sc.Write(mw, []string{"de ", " ch"}, scope.Store.Pack(2)))
+		assert.Exactly(t, "stores/2/general/country/allow", mw.ArgPath)
+		assert.Exactly(t, "DE,CH", mw.ArgValue)
+	})
+
+	t.Run("int range violated", func(t *testing.T) {
+		i := cfgmodel.NewInt("web/cors/int", cfgmodel.WithValidator(cfgmodel.ValidateIntRange(0, 100)))
+		mw := &cfgmock.Write{}
+		err := i.Write(mw, 101, scope.DefaultTypeID)
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+		assert.Contains(t, err.Error(), "web/cors/int")
+		assert.Empty(t, mw.ArgPath, "Writer must not be called")
+	})
+
+	t.Run("custom error converted to NotValid", func(t *testing.T) {
+		s := cfgmodel.NewStr("web/unsecure/base_url", cfgmodel.WithValidator(func(v interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("custom failure")
+		}))
+		err := s.Write(&cfgmock.Write{}, "x", scope.DefaultTypeID)
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+
+	t.Run("scope checked before validation", func(t *testing.T) {
+		var called bool
+		s := cfgmodel.NewStr("web/unsecure/base_url", cfgmodel.WithValidator(func(v interface{}) (interface{}, error) {
+			called = true
+			return v, nil
+		}))
+		err := s.Write(&cfgmock.Write{}, "x", scope.Store.Pack(1))
+		assert.True(t, errors.IsUnauthorized(err), "%+v", err)
+		assert.False(t, called)
+	})
+}
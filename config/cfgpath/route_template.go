@@ -0,0 +1,149 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgpath
+
+import (
+	"strings"
+
+	"github.com/corestoreio/errors"
+)
+
+// RoutePlaceholder marks a variable within a RouteTemplate.
+const RoutePlaceholder = `%s`
+
+// RouteTemplate defines a parametrized route like `carriers/%s/active` where
+// each placeholder gets replaced by an argument of the Bind function. Useful
+// for payment or shipping modules to address the configuration of each
+// method. A RouteTemplate is immutable and safe for concurrent use.
+type RouteTemplate struct {
+	// tpl split at the placeholders, contains one more entry than there are
+	// placeholders.
+	fragments []string
+	tpl       string
+}
+
+// NewRouteTemplate parses a route template. The static parts must only
+// contain valid route characters and the template must have at least Levels
+// parts. A placeholder can be a whole part or a part of it, e.g.
+// `payment/%s/title` or `carriers/%s_express/active`. Error behaviour: Empty
+// or NotValid.
+func NewRouteTemplate(tpl string) (RouteTemplate, error) {
+	if tpl == "" {
+		return RouteTemplate{}, errors.NewEmptyf("[cfgpath] RouteTemplate is empty")
+	}
+	if strings.Count(tpl, sSeparator) < Levels-1 {
+		return RouteTemplate{}, errors.NewNotValidf(errIncorrectPathTpl, tpl)
+	}
+
+	rt := RouteTemplate{
+		fragments: strings.Split(tpl, RoutePlaceholder),
+		tpl:       tpl,
+	}
+	for _, f := range rt.fragments {
+		for _, r := range f {
+			if r != rune(Separator) && !isToken(r) {
+				return RouteTemplate{}, errors.NewNotValidf("[cfgpath] Invalid character %q in RouteTemplate %q", string(r), tpl)
+			}
+		}
+	}
+	for _, p := range strings.Split(tpl, sSeparator) {
+		if p == "" {
+			return RouteTemplate{}, errors.NewNotValidf("[cfgpath] RouteTemplate %q contains an empty part", tpl)
+		}
+	}
+	return rt, nil
+}
+
+// MustNewRouteTemplate same as NewRouteTemplate but panics on error.
+func MustNewRouteTemplate(tpl string) RouteTemplate {
+	rt, err := NewRouteTemplate(tpl)
+	if err != nil {
+		panic(err)
+	}
+	return rt
+}
+
+// Placeholders returns the number of placeholders.
+func (rt RouteTemplate) Placeholders() int {
+	if len(rt.fragments) == 0 {
+		return 0
+	}
+	return len(rt.fragments) - 1
+}
+
+// String returns the template.
+func (rt RouteTemplate) String() string {
+	return rt.tpl
+}
+
+// Bind replaces the placeholders in order with the arguments and returns a
+// validated Route. The number of arguments must match the number of
+// placeholders. An argument must not be empty and must not contain a
+// Separator or any other invalid route character.
+//
+//	rt := MustNewRouteTemplate("carriers/%s/active")
+//	r, err := rt.Bind("dhl") // carriers/dhl/active
+//
+// Error behaviour: NotValid.
+func (rt RouteTemplate) Bind(args ...string) (Route, error) {
+	if len(args) != rt.Placeholders() {
+		return Route{}, errors.NewNotValidf("[cfgpath] RouteTemplate %q requires %d arguments, got %d", rt.tpl, rt.Placeholders(), len(args))
+	}
+
+	l := len(rt.tpl) - len(args)*len(RoutePlaceholder)
+	for i, a := range args {
+		if a == "" {
+			return Route{}, errors.NewNotValidf("[cfgpath] RouteTemplate %q argument %d is empty", rt.tpl, i)
+		}
+		for _, r := range a {
+			if r == rune(Separator) || !isToken(r) {
+				return Route{}, errors.NewNotValidf("[cfgpath] Invalid character %q in argument %d for RouteTemplate %q", string(r), i, rt.tpl)
+			}
+		}
+		l += len(a)
+	}
+
+	buf := make([]byte, 0, l)
+	for i, f := range rt.fragments {
+		buf = append(buf, f...)
+		if i < len(args) {
+			buf = append(buf, args[i]...)
+		}
+	}
+	r := newRoute(buf)
+	if err := r.Validate(); err != nil {
+		return Route{}, errors.Wrapf(err, "[cfgpath] RouteTemplate %q", rt.tpl)
+	}
+	return r, nil
+}
+
+// MustBind same as Bind but panics on error.
+func (rt RouteTemplate) MustBind(args ...string) Route {
+	r, err := rt.Bind(args...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// BindPath same as Bind but returns a Path with the default scope. Use
+// Path.Bind to change the scope.
+func (rt RouteTemplate) BindPath(args ...string) (Path, error) {
+	r, err := rt.Bind(args...)
+	if err != nil {
+		return Path{}, errors.Wrap(err, "[cfgpath] RouteTemplate.BindPath")
+	}
+	return New(r)
+}
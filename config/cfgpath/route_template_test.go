@@ -0,0 +1,100 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgpath_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRouteTemplate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		tpl          string
+		placeholders int
+		errBhf       errors.BehaviourFunc
+	}{
+		{"carriers/%s/active", 1, nil},
+		{"carriers/%s_express/%s", 2, nil},
+		{"payment/checkmo/title", 0, nil},
+		{"", 0, errors.IsEmpty},
+		{"carriers/%s", 0, errors.IsNotValid},
+		{"carriers//%s/active", 0, errors.IsNotValid},
+		{"carriers/%d/active", 0, errors.IsNotValid},
+		{"carriers/%s/äctive", 0, errors.IsNotValid},
+	}
+	for i, test := range tests {
+		rt, err := cfgpath.NewRouteTemplate(test.tpl)
+		if test.errBhf != nil {
+			assert.True(t, test.errBhf(err), "Index %d => %+v", i, err)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Index %d => %+v", i, err)
+		}
+		assert.Exactly(t, test.placeholders, rt.Placeholders(), "Index %d", i)
+		assert.Exactly(t, test.tpl, rt.String(), "Index %d", i)
+	}
+}
+
+func TestRouteTemplate_Bind(t *testing.T) {
+	t.Parallel()
+	rt := cfgpath.MustNewRouteTemplate("carriers/%s_express/%s")
+
+	tests := []struct {
+		args   []string
+		want   string
+		errBhf errors.BehaviourFunc
+	}{
+		{[]string{"dhl", "active"}, "carriers/dhl_express/active", nil},
+		{[]string{"ups", "title"}, "carriers/ups_express/title", nil},
+		{[]string{"dhl"}, "", errors.IsNotValid},
+		{[]string{"dhl", "active", "x"}, "", errors.IsNotValid},
+		{[]string{"", "active"}, "", errors.IsNotValid},
+		{[]string{"dhl/x", "active"}, "", errors.IsNotValid},
+		{[]string{"dhl", "act ive"}, "", errors.IsNotValid},
+	}
+	for i, test := range tests {
+		r, err := rt.Bind(test.args...)
+		if test.errBhf != nil {
+			assert.True(t, test.errBhf(err), "Index %d => %+v", i, err)
+			assert.True(t, r.IsEmpty(), "Index %d", i)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Index %d => %+v", i, err)
+		}
+		assert.Exactly(t, test.want, r.String(), "Index %d", i)
+		assert.True(t, r.Equal(cfgpath.NewRoute(test.want)), "Index %d", i)
+	}
+}
+
+func TestRouteTemplate_BindPath(t *testing.T) {
+	t.Parallel()
+	rt := cfgpath.MustNewRouteTemplate("payment/%s/active")
+	p, err := rt.BindPath("checkmo")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, "default/0/payment/checkmo/active", p.Bind(scope.DefaultTypeID).String())
+	assert.Exactly(t, "websites/2/payment/checkmo/active", p.BindWebsite(2).String())
+
+	assert.Panics(t, func() { rt.MustBind() })
+	assert.Panics(t, func() { cfgpath.MustNewRouteTemplate("payment") })
+}
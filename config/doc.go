@@ -40,5 +40,12 @@ path.Path. If you use the ScopedGetter via function NewScoped() you can only pro
 path.Route to the type methods String(), Int(), Float64(), etc.
 
 The examples show the overall best practices.
+
+Feature Flags
+
+The type FeatureFlags reads flags below the path csfeature/<flag>/ for each scope.
+A flag can have a rollout percentage, which gets applied to the hashed store or
+customer ID, and an expiry date. Loaded flags are cached until the publish and
+subscribe service reports a write to a flag path.
 */
 package config
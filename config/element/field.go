@@ -15,6 +15,7 @@
 package element
 
 import (
+	"reflect"
 	"sort"
 
 	"github.com/corestoreio/csfw/config/cfgpath"
@@ -161,6 +162,19 @@ func (f Field) Update(new Field) Field {
 	return f
 }
 
+// checkType returns a NotValid error if the new Field defines a different Type
+// or a Default value of a different Go type than the current Field. Empty
+// values on either side do not conflict.
+func (f Field) checkType(new Field) error {
+	if f.Type != nil && new.Type != nil && f.Type.Type() != new.Type.Type() {
+		return errors.NewNotValidf("[element] Field %q Type mismatch: %s != %s", f.ID, f.Type.Type(), new.Type.Type())
+	}
+	if f.Default != nil && new.Default != nil && reflect.TypeOf(f.Default) != reflect.TypeOf(new.Default) {
+		return errors.NewNotValidf("[element] Field %q Default type mismatch: %T != %T", f.ID, f.Default, new.Default)
+	}
+	return nil
+}
+
 // Route returns the merged route of either Section.ID + Group.ID + Field.ID OR
 // Field.ConfgPath if set. Owner of the returned cfgpath.Route is *Field.
 func (f Field) Route(preRoutes ...cfgpath.Route) (cfgpath.Route, error) {
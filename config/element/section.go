@@ -177,6 +177,94 @@ func (ss *SectionSlice) merge(s Section) error {
 	return nil
 }
 
+// MergeWith merges the other SectionSlice into a copy of the current slice and
+// returns the combined tree. The current slice and other do not get modified,
+// so each package can contribute its own sections at startup.
+//
+// Sections, groups and fields get matched by their ID. For duplicates the
+// later item wins, the non-empty struct fields of other overwrite the current
+// ones. If both fields define a Type or a Default value of a different type,
+// an error with behaviour NotValid gets returned.
+//
+// After merging all three levels get stable sorted by SortOrder. Items with the
+// same SortOrder keep their order: first the items of the current slice, then
+// the new items of other.
+func (ss SectionSlice) MergeWith(other SectionSlice) (SectionSlice, error) {
+	ret := ss.clone()
+	for _, s := range other {
+		if cs, _, err := ret.Find(s.ID); err == nil {
+			if err := checkGroupTypes(cs, s); err != nil {
+				return nil, errors.Wrap(err, "[element] SectionSlice.MergeWith")
+			}
+		}
+		if err := ret.merge(s.clone()); err != nil {
+			return nil, errors.Wrap(err, "[element] SectionSlice.MergeWith.merge")
+		}
+	}
+	return ret.sortStable(), nil
+}
+
+// checkGroupTypes checks all fields which exists in the current Section and in
+// the new Section for conflicting types.
+func checkGroupTypes(cur, new Section) error {
+	for _, g := range new.Groups {
+		cg, _, err := cur.Groups.Find(g.ID)
+		if err != nil {
+			continue
+		}
+		for _, f := range g.Fields {
+			cf, _, err := cg.Fields.Find(f.ID)
+			if err != nil {
+				continue
+			}
+			if err := cf.checkType(f); err != nil {
+				return errors.Wrapf(err, "[element] Path \"%s/%s/%s\"", cur.ID, cg.ID, cf.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// clone creates a copy of the slice including all groups and fields. The
+// returned slice can be modified without changing the original one.
+func (ss SectionSlice) clone() SectionSlice {
+	if ss == nil {
+		return nil
+	}
+	ret := make(SectionSlice, len(ss))
+	for i, s := range ss {
+		ret[i] = s.clone()
+	}
+	return ret
+}
+
+// clone creates a copy of the Section including all groups and fields.
+func (s Section) clone() Section {
+	if s.Groups == nil {
+		return s
+	}
+	gs := make(GroupSlice, len(s.Groups))
+	for i, g := range s.Groups {
+		g.Fields = append(FieldSlice(nil), g.Fields...)
+		gs[i] = g
+	}
+	s.Groups = gs
+	return s
+}
+
+// sortStable recursively sorts all slices by SortOrder and keeps the original
+// order of equal items. Not thread safe.
+func (ss SectionSlice) sortStable() SectionSlice {
+	for _, s := range ss {
+		for _, g := range s.Groups {
+			sort.Stable(g.Fields)
+		}
+		sort.Stable(s.Groups)
+	}
+	sort.Stable(ss)
+	return ss
+}
+
 // Find returns a Section pointer or ErrSectionNotFound. Route must be a single
 // part. E.g. if you have path "a/b/c" route would be in this case "a". For
 // comparison the field Sum32 of a route will be used. 2nd return parameter
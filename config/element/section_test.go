@@ -113,6 +113,140 @@ func TestSectionUpdateField(t *testing.T) {
 
 }
 
+func TestSectionSlice_MergeWith(t *testing.T) {
+
+	moduleA := element.NewSectionSlice(
+		element.Section{
+			ID:        cfgpath.NewRoute(`payment`),
+			Label:     text.Chars(`Payment`),
+			SortOrder: 20,
+			Groups: element.NewGroupSlice(
+				element.Group{
+					ID:        cfgpath.NewRoute(`checkmo`),
+					SortOrder: 10,
+					Fields: element.NewFieldSlice(
+						element.Field{ID: cfgpath.NewRoute(`active`), Type: element.TypeSelect, Default: true, SortOrder: 10},
+						element.Field{ID: cfgpath.NewRoute(`title`), Type: element.TypeText, Default: `Check`, SortOrder: 20},
+					),
+				},
+			),
+		},
+		element.Section{
+			ID:        cfgpath.NewRoute(`general`),
+			SortOrder: 10,
+		},
+	)
+	moduleB := element.NewSectionSlice(
+		element.Section{
+			ID:        cfgpath.NewRoute(`payment`),
+			Label:     text.Chars(`Payment Methods`),
+			SortOrder: 0, // keeps 20
+			Groups: element.NewGroupSlice(
+				element.Group{
+					ID:        cfgpath.NewRoute(`paypal`),
+					SortOrder: 10,
+					Fields: element.NewFieldSlice(
+						element.Field{ID: cfgpath.NewRoute(`active`), Type: element.TypeSelect, Default: false},
+					),
+				},
+				element.Group{
+					ID: cfgpath.NewRoute(`checkmo`),
+					Fields: element.NewFieldSlice(
+						element.Field{ID: cfgpath.NewRoute(`title`), Default: `Check / Money order`},
+						element.Field{ID: cfgpath.NewRoute(`order_status`), Type: element.TypeSelect, SortOrder: 15},
+					),
+				},
+			),
+		},
+		element.Section{
+			ID:        cfgpath.NewRoute(`catalog`),
+			SortOrder: 15,
+		},
+	)
+
+	have, err := moduleA.MergeWith(moduleB)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.NoError(t, have.Validate())
+
+	var ids []string
+	for _, s := range have {
+		ids = append(ids, s.ID.String())
+	}
+	assert.Exactly(t, []string{`general`, `catalog`, `payment`}, ids)
+
+	pay, _, err := have.Find(cfgpath.NewRoute(`payment`))
+	assert.NoError(t, err)
+	assert.Exactly(t, `Payment Methods`, pay.Label.String())
+	assert.Exactly(t, 20, pay.SortOrder)
+	assert.Len(t, pay.Groups, 2)
+	// same SortOrder: existing group first, then the new group
+	assert.Exactly(t, `checkmo`, pay.Groups[0].ID.String())
+	assert.Exactly(t, `paypal`, pay.Groups[1].ID.String())
+
+	ids = ids[:0]
+	for _, f := range pay.Groups[0].Fields {
+		ids = append(ids, f.ID.String())
+	}
+	assert.Exactly(t, []string{`active`, `order_status`, `title`}, ids)
+
+	f, _, err := have.FindField(cfgpath.NewRoute(`payment/checkmo/title`))
+	assert.NoError(t, err)
+	assert.Exactly(t, `Check / Money order`, f.Default)
+	assert.Exactly(t, element.TypeText, f.Type)
+
+	// the source slices must not be modified
+	assert.Len(t, moduleA, 2)
+	assert.Len(t, moduleA[0].Groups, 1)
+	assert.Len(t, moduleA[0].Groups[0].Fields, 2)
+	assert.Exactly(t, `Check`, moduleA[0].Groups[0].Fields[1].Default)
+	assert.Exactly(t, `Payment`, moduleA[0].Label.String())
+
+	// merging again gives the same result
+	have2, err := moduleA.MergeWith(moduleB)
+	assert.NoError(t, err)
+	assert.Exactly(t, have.ToJSON(), have2.ToJSON())
+}
+
+func TestSectionSlice_MergeWith_TypeMismatch(t *testing.T) {
+
+	newSS := func(f element.Field) element.SectionSlice {
+		return element.NewSectionSlice(
+			element.Section{
+				ID: cfgpath.NewRoute(`aa`),
+				Groups: element.NewGroupSlice(
+					element.Group{
+						ID:     cfgpath.NewRoute(`bb`),
+						Fields: element.NewFieldSlice(f),
+					},
+				),
+			},
+		)
+	}
+
+	tests := []struct {
+		a, b    element.Field
+		wantErr bool
+	}{
+		{element.Field{ID: cfgpath.NewRoute(`cc`), Type: element.TypeText}, element.Field{ID: cfgpath.NewRoute(`cc`), Type: element.TypeSelect}, true},
+		{element.Field{ID: cfgpath.NewRoute(`cc`), Default: 1}, element.Field{ID: cfgpath.NewRoute(`cc`), Default: `1`}, true},
+		{element.Field{ID: cfgpath.NewRoute(`cc`), Type: element.TypeText}, element.Field{ID: cfgpath.NewRoute(`cc`)}, false},
+		{element.Field{ID: cfgpath.NewRoute(`cc`), Default: 1}, element.Field{ID: cfgpath.NewRoute(`cc`), Default: 2}, false},
+		{element.Field{ID: cfgpath.NewRoute(`cc`), Type: element.TypeText}, element.Field{ID: cfgpath.NewRoute(`dd`), Type: element.TypeSelect}, false},
+	}
+	for i, test := range tests {
+		ss, err := newSS(test.a).MergeWith(newSS(test.b))
+		if test.wantErr {
+			assert.Nil(t, ss, "Index %d", i)
+			assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.NotNil(t, ss, "Index %d", i)
+	}
+}
+
 var _ element.ConfigurationWriter = (*config.Service)(nil)
 var _ config.Writer = (*config.Service)(nil)
 
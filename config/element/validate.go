@@ -0,0 +1,218 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package element
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/errors"
+)
+
+// ValidateOptions configures the optional checks of SectionSlice.Inspect.
+type ValidateOptions struct {
+	// HasSourceModel if set reports a Field of type TypeSelect or
+	// TypeMultiselect whose route cannot be resolved to a source model. The
+	// argument contains the fully qualified route, e.g. a/b/c.
+	HasSourceModel func(route string) bool
+}
+
+// ValidationIssue describes a single finding of SectionSlice.Inspect.
+type ValidationIssue struct {
+	// Route to the affected Section, Group or Field. E.g. a or a/b or a/b/c.
+	Route string
+	// Reason contains a human readable description of the problem.
+	Reason string
+}
+
+// String returns the route and the reason.
+func (vi ValidationIssue) String() string {
+	return vi.Route + ": " + vi.Reason
+}
+
+// ValidationReport contains all findings of SectionSlice.Inspect. An empty
+// report indicates a valid configuration structure.
+type ValidationReport []ValidationIssue
+
+func (vr *ValidationReport) add(route, format string, args ...interface{}) {
+	*vr = append(*vr, ValidationIssue{Route: route, Reason: fmt.Sprintf(format, args...)})
+}
+
+// Err returns nil if the report is empty. Otherwise an error with behaviour
+// NotValid gets returned which lists all issues, one per line.
+func (vr ValidationReport) Err() error {
+	if len(vr) == 0 {
+		return nil
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	for _, vi := range vr {
+		buf.WriteString("\n\t")
+		buf.WriteString(vi.String())
+	}
+	return errors.NewNotValidf("[element] SectionSlice contains %d issue(s):%s", len(vr), buf.String())
+}
+
+// validScopes all scopes which can be stored in the core_config_data table.
+const validScopes = scope.PermStore
+
+// Inspect runs a full validation pass over all Sections, Groups and Fields and
+// collects every finding instead of stopping at the first error like
+// Validate(). It detects empty and duplicate IDs on all three levels,
+// duplicate fully qualified paths, default values whose type does not match
+// the Field Type, scope permissions of a child which are not granted by its
+// parent and, if configured, missing source models.
+func (ss SectionSlice) Inspect(o ValidateOptions) ValidationReport {
+	var vr ValidationReport
+	paths := make(map[string]bool, ss.TotalFields())
+	sIDs := make(map[string]bool, len(ss))
+
+	for _, s := range ss {
+		sRoute := s.ID.String()
+		switch {
+		case s.ID.IsEmpty():
+			vr.add(sRoute, "Section ID is empty")
+		case sIDs[sRoute]:
+			vr.add(sRoute, "duplicate Section ID")
+		}
+		sIDs[sRoute] = true
+		if s.Scopes&^validScopes != 0 {
+			vr.add(sRoute, "Section contains unsupported scopes %q", s.Scopes)
+		}
+
+		gIDs := make(map[string]bool, len(s.Groups))
+		for _, g := range s.Groups {
+			gRoute := sRoute + "/" + g.ID.String()
+			switch {
+			case g.ID.IsEmpty():
+				vr.add(gRoute, "Group ID is empty")
+			case gIDs[gRoute]:
+				vr.add(gRoute, "duplicate Group ID")
+			}
+			gIDs[gRoute] = true
+			checkScopes(&vr, gRoute, "Group", g.Scopes, "Section", s.Scopes)
+
+			fIDs := make(map[string]bool, len(g.Fields))
+			for _, f := range g.Fields {
+				fRoute := gRoute + "/" + f.ID.String()
+				dupID := fIDs[fRoute]
+				switch {
+				case f.ID.IsEmpty():
+					vr.add(fRoute, "Field ID is empty")
+				case dupID:
+					vr.add(fRoute, "duplicate Field ID")
+				}
+				fIDs[fRoute] = true
+
+				if r, err := f.Route(s.ID, g.ID); err != nil {
+					vr.add(fRoute, "invalid route: %s", err)
+				} else {
+					p := r.String()
+					if paths[p] && !dupID {
+						vr.add(fRoute, "duplicate path %q", p)
+					}
+					paths[p] = true
+					fRoute = p
+				}
+
+				parent, parentName := g.Scopes, "Group"
+				if parent == 0 {
+					parent, parentName = s.Scopes, "Section"
+				}
+				checkScopes(&vr, fRoute, "Field", f.Scopes, parentName, parent)
+
+				if reason := checkDefault(f); reason != "" {
+					vr.add(fRoute, "%s", reason)
+				}
+
+				if o.HasSourceModel != nil && f.Type != nil {
+					switch f.Type.Type() {
+					case TypeSelect, TypeMultiselect:
+						if !o.HasSourceModel(fRoute) {
+							vr.add(fRoute, "missing source model for Field type %s", f.Type.Type())
+						}
+					}
+				}
+			}
+		}
+	}
+	return vr
+}
+
+// checkScopes reports unsupported scopes and child scopes which are not
+// available in the parent. A zero scope gets ignored because it inherits
+// from the parent.
+func checkScopes(vr *ValidationReport, route, name string, child scope.Perm, parentName string, parent scope.Perm) {
+	if child&^validScopes != 0 {
+		vr.add(route, "%s contains unsupported scopes %q", name, child)
+	}
+	if child != 0 && parent != 0 && child&^parent != 0 {
+		vr.add(route, "%s scopes %q exceed %s scopes %q", name, child, parentName, parent)
+	}
+}
+
+// checkDefault returns a non-empty reason if the type of the default value
+// cannot be used with the Field type.
+func checkDefault(f Field) string {
+	if f.Default == nil {
+		return ""
+	}
+	switch f.Default.(type) {
+	case bool, int, int64, float64, string:
+	case time.Duration:
+		if f.Type == nil || f.Type.Type() != TypeDuration {
+			return fmt.Sprintf("default value of type %T requires Field type %s", f.Default, TypeDuration)
+		}
+	case time.Time:
+		if f.Type == nil || f.Type.Type() != TypeTime {
+			return fmt.Sprintf("default value of type %T requires Field type %s", f.Default, TypeTime)
+		}
+	case []string:
+		if f.Type == nil || f.Type.Type() != TypeMultiselect {
+			return fmt.Sprintf("default value of type %T requires Field type %s", f.Default, TypeMultiselect)
+		}
+	default:
+		return fmt.Sprintf("unsupported default value type %T", f.Default)
+	}
+	if f.Type == nil {
+		return ""
+	}
+
+	switch ft := f.Type.Type(); ft {
+	case TypeButton, TypeLabel:
+		return fmt.Sprintf("Field type %s cannot have a default value", ft)
+	case TypeObscure, TypeMultiselect, TypeTextarea:
+		switch f.Default.(type) {
+		case string, []string:
+		default:
+			return fmt.Sprintf("default value of type %T not allowed for Field type %s", f.Default, ft)
+		}
+	case TypeTime:
+		switch f.Default.(type) {
+		case string, time.Time, int64:
+		default:
+			return fmt.Sprintf("default value of type %T not allowed for Field type %s", f.Default, ft)
+		}
+	case TypeDuration:
+		switch f.Default.(type) {
+		case string, time.Duration, int64:
+		default:
+			return fmt.Sprintf("default value of type %T not allowed for Field type %s", f.Default, ft)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package element_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSectionSlice_Inspect_Valid(t *testing.T) {
+	ss := element.NewSectionSlice(
+		element.Section{
+			ID:     cfgpath.NewRoute(`web`),
+			Scopes: scope.PermStore,
+			Groups: element.NewGroupSlice(
+				element.Group{
+					ID:     cfgpath.NewRoute(`cors`),
+					Scopes: scope.PermWebsite,
+					Fields: element.NewFieldSlice(
+						element.Field{ID: cfgpath.NewRoute(`allowed_origins`), Type: element.TypeMultiselect, Default: []string{"*"}, Scopes: scope.PermWebsite},
+						element.Field{ID: cfgpath.NewRoute(`max_age`), Type: element.TypeDuration, Default: time.Hour},
+						element.Field{ID: cfgpath.NewRoute(`enabled`), Type: element.TypeSelect, Default: true},
+					),
+				},
+			),
+		},
+	)
+	vr := ss.Inspect(element.ValidateOptions{})
+	assert.Len(t, vr, 0, "%v", vr)
+	assert.NoError(t, vr.Err())
+}
+
+func TestSectionSlice_Inspect_Issues(t *testing.T) {
+	ss := element.NewSectionSlice(
+		element.Section{
+			ID:     cfgpath.NewRoute(`web`),
+			Scopes: scope.PermWebsite,
+			Groups: element.NewGroupSlice(
+				element.Group{
+					ID:     cfgpath.NewRoute(`cors`),
+					Scopes: scope.PermStore, // exceeds section
+					Fields: element.NewFieldSlice(
+						element.Field{ID: cfgpath.NewRoute(`enabled`), Type: element.TypeSelect},
+						element.Field{ID: cfgpath.NewRoute(`enabled`)},                                       // duplicate
+						element.Field{ID: cfgpath.NewRoute(`label`), Type: element.TypeLabel, Default: "x"},  // no default allowed
+						element.Field{ID: cfgpath.NewRoute(`secret`), Type: element.TypeObscure, Default: 4}, // wrong type
+						element.Field{ID: cfgpath.NewRoute(`ttl`), Type: element.TypeText, Default: time.Second},
+						element.Field{ID: cfgpath.NewRoute(`odd`), Default: struct{}{}},
+					),
+				},
+			),
+		},
+		element.Section{ID: cfgpath.NewRoute(`web`)},
+	)
+
+	vr := ss.Inspect(element.ValidateOptions{
+		HasSourceModel: func(route string) bool { return false },
+	})
+	want := []string{
+		"web/cors: Group scopes \"Default,Website,Store\" exceed Section scopes \"Default,Website\"",
+		"web/cors/enabled: missing source model for Field type TypeSelect",
+		"web/cors/enabled: duplicate Field ID",
+		"web/cors/label: Field type TypeLabel cannot have a default value",
+		"web/cors/secret: default value of type int not allowed for Field type TypeObscure",
+		"web/cors/ttl: default value of type time.Duration requires Field type TypeDuration",
+		"web/cors/odd: unsupported default value type struct {}",
+		"web: duplicate Section ID",
+	}
+	var have []string
+	for _, vi := range vr {
+		have = append(have, vi.String())
+	}
+	assert.Exactly(t, want, have)
+
+	err := vr.Err()
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	assert.Contains(t, err.Error(), "contains 8 issue(s)")
+}
+
+func TestSectionSlice_Inspect_DuplicateConfigPath(t *testing.T) {
+	ss := element.NewSectionSlice(
+		element.Section{
+			ID: cfgpath.NewRoute(`aa`),
+			Groups: element.NewGroupSlice(
+				element.Group{
+					ID: cfgpath.NewRoute(`bb`),
+					Fields: element.NewFieldSlice(
+						element.Field{ID: cfgpath.NewRoute(`cc`), ConfigPath: cfgpath.NewRoute(`aa/dd/ee`)},
+					),
+				},
+				element.Group{
+					ID: cfgpath.NewRoute(`dd`),
+					Fields: element.NewFieldSlice(
+						element.Field{ID: cfgpath.NewRoute(`ee`)},
+					),
+				},
+			),
+		},
+	)
+	vr := ss.Inspect(element.ValidateOptions{})
+	assert.Len(t, vr, 1)
+	assert.Exactly(t, `aa/dd/ee: duplicate path "aa/dd/ee"`, vr[0].String())
+}
@@ -0,0 +1,211 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+)
+
+// PathFeatureFlag defines the configuration namespace of the feature flags. A
+// flag consists of the three paths:
+//		csfeature/<flag>/enabled  bool, default false
+//		csfeature/<flag>/rollout  int percentage 0-100, default 100
+//		csfeature/<flag>/expires  time, optional, after this date the flag is off
+// All three paths can be set per default, website and store scope.
+const PathFeatureFlag = "csfeature"
+
+// FeatureFlag contains the configuration of a flag for a scope.
+type FeatureFlag struct {
+	Name    string
+	Enabled bool
+	// Rollout percentage 0-100 of the IDs for which the flag is enabled.
+	Rollout int
+	// Expires if not zero, the flag is disabled after this date.
+	Expires time.Time
+}
+
+// IsActive reports whether the flag is enabled and not expired at the
+// provided time.
+func (ff FeatureFlag) IsActive(now time.Time) bool {
+	return ff.Enabled && (ff.Expires.IsZero() || now.Before(ff.Expires))
+}
+
+// InRollout reports whether the ID falls into the rollout percentage. The
+// bucket gets calculated by the FNV-1a hash of the flag name and the ID,
+// therefore the same ID gets always the same result for the same flag, and
+// different flags select different IDs.
+func (ff FeatureFlag) InRollout(id int64) bool {
+	switch {
+	case ff.Rollout >= 100:
+		return true
+	case ff.Rollout <= 0:
+		return false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(ff.Name))
+	_, _ = h.Write([]byte{'/'})
+	_, _ = h.Write(strconv.AppendInt(nil, id, 10))
+	return int(h.Sum32()%100) < ff.Rollout
+}
+
+type featureKey struct {
+	name string
+	scope.TypeID
+}
+
+// FeatureFlags reads feature flags from the configuration namespace
+// PathFeatureFlag and replaces ad-hoc bool configuration reads. Loaded flags
+// get cached per scope until a path below PathFeatureFlag gets written. Safe
+// for concurrent use.
+type FeatureFlags struct {
+	// Log can be nil.
+	Log log.Logger
+	// now returns the current time, can be mocked for testing.
+	now func() time.Time
+
+	mu    sync.RWMutex
+	cache map[featureKey]FeatureFlag
+	// gen gets incremented on each invalidation. A flag loaded while the
+	// generation changed might be outdated and does not get cached.
+	gen uint64
+}
+
+// NewFeatureFlags creates a new feature flag reader. If the Subscriber is not
+// nil, the reader subscribes itself to PathFeatureFlag to invalidate the cache
+// whenever a flag gets written.
+func NewFeatureFlags(s Subscriber) (*FeatureFlags, error) {
+	ff := &FeatureFlags{
+		Log:   log.BlackHole{},
+		now:   time.Now,
+		cache: make(map[featureKey]FeatureFlag),
+	}
+	if s != nil {
+		if _, err := s.Subscribe(cfgpath.NewRoute(PathFeatureFlag), ff); err != nil {
+			return nil, errors.Wrap(err, "[config] NewFeatureFlags.Subscribe")
+		}
+	}
+	return ff, nil
+}
+
+// Flag loads the feature flag for the scope of sc. The values bubble up the
+// scope chain store -> website -> default. A not existing flag is disabled.
+// Error behaviour: NotValid
+func (fs *FeatureFlags) Flag(name string, sc Scoped) (FeatureFlag, error) {
+	key := featureKey{name: name, TypeID: sc.ScopeID()}
+	fs.mu.RLock()
+	ff, ok := fs.cache[key]
+	gen := fs.gen
+	fs.mu.RUnlock()
+	if ok {
+		return ff, nil
+	}
+
+	ff, err := loadFeatureFlag(name, sc)
+	if err != nil {
+		return FeatureFlag{Name: name}, errors.Wrapf(err, "[config] FeatureFlags.Flag %q", name)
+	}
+	fs.mu.Lock()
+	if fs.gen == gen {
+		fs.cache[key] = ff
+	}
+	fs.mu.Unlock()
+	return ff, nil
+}
+
+func loadFeatureFlag(name string, sc Scoped) (FeatureFlag, error) {
+	ff := FeatureFlag{Name: name, Rollout: 100}
+	r := cfgpath.NewRoute(PathFeatureFlag, name)
+	if err := r.Validate(); err != nil {
+		return ff, errors.NewNotValid(err, "[config] Invalid feature flag name")
+	}
+
+	var err error
+	if ff.Enabled, err = sc.Bool(featureRoute(name, "enabled")); err != nil && !errors.IsNotFound(err) {
+		return ff, errors.Wrap(err, "[config] enabled")
+	}
+	if ff.Rollout, err = sc.Int(featureRoute(name, "rollout")); errors.IsNotFound(err) {
+		ff.Rollout = 100
+	} else if err != nil {
+		return ff, errors.Wrap(err, "[config] rollout")
+	}
+	if ff.Expires, err = sc.Time(featureRoute(name, "expires")); err != nil && !errors.IsNotFound(err) {
+		return ff, errors.Wrap(err, "[config] expires")
+	}
+	return ff, nil
+}
+
+func featureRoute(name, field string) cfgpath.Route {
+	return cfgpath.NewRoute(PathFeatureFlag, name, field)
+}
+
+// IsEnabled reports whether the flag is active for the scope of sc. The
+// rollout percentage gets applied to the store ID, or if empty, to the website
+// ID. Errors get logged on info level and disable the flag.
+func (fs *FeatureFlags) IsEnabled(name string, sc Scoped) bool {
+	id := sc.StoreID
+	if id == 0 {
+		id = sc.WebsiteID
+	}
+	return fs.IsEnabledFor(name, sc, id)
+}
+
+// IsEnabledFor reports whether the flag is active for the scope of sc and
+// whether the id, for example a customer ID, falls into the rollout
+// percentage. Errors get logged on info level and disable the flag.
+func (fs *FeatureFlags) IsEnabledFor(name string, sc Scoped, id int64) bool {
+	ff, err := fs.Flag(name, sc)
+	if err != nil {
+		if fs.Log.IsInfo() {
+			fs.Log.Info("config.FeatureFlags.IsEnabledFor.Flag", log.Err(err), log.String("flag", name), log.Stringer("scope", sc.ScopeID()))
+		}
+		return false
+	}
+	return ff.IsActive(fs.now()) && ff.InRollout(id)
+}
+
+// MessageConfig implements the MessageReceiver interface and removes the
+// written flag from the cache for all scopes.
+func (fs *FeatureFlags) MessageConfig(p cfgpath.Path) error {
+	name, err := p.Route.Part(2)
+	if err != nil {
+		fs.Purge()
+		return nil
+	}
+	fs.mu.Lock()
+	fs.gen++
+	for k := range fs.cache {
+		if k.name == name.String() {
+			delete(fs.cache, k)
+		}
+	}
+	fs.mu.Unlock()
+	return nil
+}
+
+// Purge removes all flags from the cache.
+func (fs *FeatureFlags) Purge() {
+	fs.mu.Lock()
+	fs.gen++
+	fs.cache = make(map[featureKey]FeatureFlag)
+	fs.mu.Unlock()
+}
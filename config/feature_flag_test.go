@@ -0,0 +1,172 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ MessageReceiver = (*FeatureFlags)(nil)
+
+type subscriberFunc func(cfgpath.Route, MessageReceiver) (int, error)
+
+func (sf subscriberFunc) Subscribe(r cfgpath.Route, mr MessageReceiver) (int, error) {
+	return sf(r, mr)
+}
+
+func TestNewFeatureFlags_Subscribe(t *testing.T) {
+	var route cfgpath.Route
+	var rec MessageReceiver
+	ff, err := NewFeatureFlags(subscriberFunc(func(r cfgpath.Route, mr MessageReceiver) (int, error) {
+		route, rec = r, mr
+		return 1, nil
+	}))
+	require.NoError(t, err)
+	assert.Exactly(t, PathFeatureFlag, route.String())
+	assert.Exactly(t, ff, rec)
+
+	ff, err = NewFeatureFlags(subscriberFunc(func(cfgpath.Route, MessageReceiver) (int, error) {
+		return 0, errors.NewAlreadyClosedf("Closed")
+	}))
+	assert.Nil(t, ff)
+	assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
+}
+
+func TestFeatureFlags_IsEnabled(t *testing.T) {
+	be := &countingStorage{Storager: NewInMemoryStore()}
+	srv := MustNewService(be)
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	w := func(path string, v interface{}, binds ...int64) {
+		p := cfgpath.MustNewByParts(path)
+		if len(binds) == 2 {
+			p = p.BindStore(binds[1])
+		} else if len(binds) == 1 {
+			p = p.BindWebsite(binds[0])
+		}
+		require.NoError(t, srv.Write(p, v))
+	}
+	w("csfeature/checkout_v2/enabled", false)
+	w("csfeature/checkout_v2/enabled", true, 1)
+	w("csfeature/checkout_v2/enabled", false, 1, 3)
+	w("csfeature/new_search/enabled", true)
+	w("csfeature/new_search/expires", "2017-02-28 23:59:59")
+	w("csfeature/new_search/enabled", true, 1, 2)
+	w("csfeature/new_search/expires", "2017-03-31 23:59:59", 1, 2)
+	w("csfeature/half/enabled", true)
+	w("csfeature/half/rollout", 50)
+
+	ff, err := NewFeatureFlags(nil)
+	require.NoError(t, err)
+	ff.now = func() time.Time { return now }
+
+	tests := []struct {
+		flag string
+		sc   Scoped
+		want bool
+	}{
+		{"checkout_v2", srv.NewScoped(0, 0), false},
+		{"checkout_v2", srv.NewScoped(1, 0), true},
+		{"checkout_v2", srv.NewScoped(1, 2), true},
+		{"checkout_v2", srv.NewScoped(1, 3), false},
+		{"new_search", srv.NewScoped(0, 0), false},
+		{"new_search", srv.NewScoped(1, 1), false},
+		{"new_search", srv.NewScoped(1, 2), true},
+		{"not_existent", srv.NewScoped(1, 2), false},
+		{"invalid flag!", srv.NewScoped(1, 2), false},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, ff.IsEnabled(test.flag, test.sc), "Index %d", i)
+	}
+
+	gets := be.getCount()
+	assert.True(t, ff.IsEnabled("checkout_v2", srv.NewScoped(1, 2)))
+	assert.Exactly(t, gets, be.getCount(), "Flag must be cached")
+
+	w("csfeature/checkout_v2/enabled", false, 1)
+	assert.True(t, ff.IsEnabled("checkout_v2", srv.NewScoped(1, 2)), "Cached value")
+	require.NoError(t, ff.MessageConfig(cfgpath.MustNewByParts("csfeature/checkout_v2/enabled").BindWebsite(1)))
+	assert.False(t, ff.IsEnabled("checkout_v2", srv.NewScoped(1, 2)), "Invalidated value")
+	assert.True(t, be.getCount() > gets)
+
+	var enabled int
+	for id := int64(1); id <= 1000; id++ {
+		if ff.IsEnabledFor("half", srv.NewScoped(1, 2), id) {
+			enabled++
+		}
+		assert.Exactly(t, ff.IsEnabledFor("half", srv.NewScoped(1, 2), id), ff.IsEnabledFor("half", srv.NewScoped(1, 2), id), "Must be stable")
+	}
+	assert.InDelta(t, 500, enabled, 60)
+}
+
+// hookStorage calls the hook once after the first Get has found a value.
+type hookStorage struct {
+	Storager
+	hook func()
+}
+
+func (hs *hookStorage) Get(key cfgpath.Path) (interface{}, error) {
+	v, err := hs.Storager.Get(key)
+	if h := hs.hook; h != nil && err == nil {
+		hs.hook = nil
+		h()
+	}
+	return v, err
+}
+
+func TestFeatureFlags_InvalidateDuringLoad(t *testing.T) {
+	be := &hookStorage{Storager: NewInMemoryStore()}
+	srv := MustNewService(be)
+	p := cfgpath.MustNewByParts("csfeature/checkout_v2/enabled")
+	require.NoError(t, srv.Write(p, true))
+
+	ff, err := NewFeatureFlags(nil)
+	require.NoError(t, err)
+
+	be.hook = func() {
+		require.NoError(t, srv.Write(p, false))
+		require.NoError(t, ff.MessageConfig(p))
+	}
+	assert.True(t, ff.IsEnabled("checkout_v2", srv.NewScoped(1, 2)), "Value loaded before the invalidation")
+	assert.False(t, ff.IsEnabled("checkout_v2", srv.NewScoped(1, 2)), "Outdated value must not be cached")
+}
+
+func TestFeatureFlag_InRollout(t *testing.T) {
+	assert.True(t, FeatureFlag{Name: "a", Rollout: 100}.InRollout(5))
+	assert.False(t, FeatureFlag{Name: "a", Rollout: 0}.InRollout(5))
+
+	a, b := FeatureFlag{Name: "a", Rollout: 50}, FeatureFlag{Name: "b", Rollout: 50}
+	var diff int
+	for id := int64(1); id <= 100; id++ {
+		if a.InRollout(id) != b.InRollout(id) {
+			diff++
+		}
+	}
+	assert.True(t, diff > 0, "Different flags must select different IDs")
+}
+
+func TestFeatureFlag_IsActive(t *testing.T) {
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.False(t, FeatureFlag{}.IsActive(now))
+	assert.True(t, FeatureFlag{Enabled: true}.IsActive(now))
+	assert.True(t, FeatureFlag{Enabled: true, Expires: now.Add(time.Second)}.IsActive(now))
+	assert.False(t, FeatureFlag{Enabled: true, Expires: now}.IsActive(now))
+}
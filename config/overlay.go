@@ -0,0 +1,179 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/errors"
+)
+
+// Overlay contains request scoped configuration values which take precedence
+// over the values of the underlying Getter. An Overlay never writes to a
+// Storager. Use case: Admin preview features or A/B tests which render a
+// response with a modified configuration, e.g. a new tax display setting,
+// without affecting other requests. Safe for concurrent use.
+type Overlay struct {
+	mu sync.RWMutex
+	kv map[uint32]keyVal
+}
+
+// NewOverlay creates a new empty Overlay.
+func NewOverlay() *Overlay {
+	return &Overlay{
+		kv: make(map[uint32]keyVal),
+	}
+}
+
+// Set adds a value for a scope bound path to the overlay. Existing values get
+// overwritten.
+func (o *Overlay) Set(p cfgpath.Path, value interface{}) error {
+	h32, err := p.Hash(-1)
+	if err != nil {
+		return errors.Wrap(err, "[config] Overlay.Set.Hash")
+	}
+	o.mu.Lock()
+	o.kv[h32] = keyVal{p, value}
+	o.mu.Unlock()
+	return nil
+}
+
+// Get returns the overlaid value of a scope bound path. The returned bool is
+// false if the path has not been set.
+func (o *Overlay) Get(p cfgpath.Path) (interface{}, bool) {
+	if o == nil {
+		return nil, false
+	}
+	h32, err := p.Hash(-1)
+	if err != nil {
+		return nil, false
+	}
+	o.mu.RLock()
+	kv, ok := o.kv[h32]
+	o.mu.RUnlock()
+	return kv.v, ok
+}
+
+// Len returns the number of overlaid paths.
+func (o *Overlay) Len() int {
+	if o == nil {
+		return 0
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.kv)
+}
+
+type keyCtxOverlay struct{}
+
+// WithContextOverlay attaches an Overlay to the context. Middlewares call this
+// function to enable e.g. a preview mode for the current request.
+func WithContextOverlay(ctx context.Context, o *Overlay) context.Context {
+	return context.WithValue(ctx, keyCtxOverlay{}, o)
+}
+
+// FromContextOverlay returns the Overlay from a context. The returned bool
+// reports whether an Overlay has been found.
+func FromContextOverlay(ctx context.Context) (*Overlay, bool) {
+	o, ok := ctx.Value(keyCtxOverlay{}).(*Overlay)
+	return o, ok && o != nil
+}
+
+// OverlayService wraps a Getter and applies the request scoped values of an
+// Overlay before asking the wrapped Getter. An OverlayService must be created
+// for each request with NewOverlayService and must not be shared across
+// requests. Writing is not supported, the underlying Storager stays untouched.
+type OverlayService struct {
+	// Root the wrapped Getter, mostly *Service.
+	Root    Getter
+	overlay *Overlay
+}
+
+// NewOverlayService creates a new Getter which applies the Overlay found in
+// the context. If the context does not contain an Overlay all calls will be
+// forwarded directly to the Root Getter.
+func NewOverlayService(ctx context.Context, root Getter) OverlayService {
+	o, _ := FromContextOverlay(ctx)
+	return OverlayService{
+		Root:    root,
+		overlay: o,
+	}
+}
+
+// NewScoped creates a new scope base configuration reader which uses the
+// overlay during the hierarchical fallback.
+func (os OverlayService) NewScoped(websiteID, storeID int64) Scoped {
+	return NewScoped(os, websiteID, storeID)
+}
+
+// Byte returns a byte slice from the overlay or the Root Getter.
+func (os OverlayService) Byte(p cfgpath.Path) ([]byte, error) {
+	if v, ok := os.overlay.Get(p); ok {
+		return conv.ToByteE(v)
+	}
+	return os.Root.Byte(p)
+}
+
+// String returns a string from the overlay or the Root Getter.
+func (os OverlayService) String(p cfgpath.Path) (string, error) {
+	if v, ok := os.overlay.Get(p); ok {
+		return conv.ToStringE(v)
+	}
+	return os.Root.String(p)
+}
+
+// Bool returns a bool from the overlay or the Root Getter.
+func (os OverlayService) Bool(p cfgpath.Path) (bool, error) {
+	if v, ok := os.overlay.Get(p); ok {
+		return conv.ToBoolE(v)
+	}
+	return os.Root.Bool(p)
+}
+
+// Float64 returns a float64 from the overlay or the Root Getter.
+func (os OverlayService) Float64(p cfgpath.Path) (float64, error) {
+	if v, ok := os.overlay.Get(p); ok {
+		return conv.ToFloat64E(v)
+	}
+	return os.Root.Float64(p)
+}
+
+// Int returns an int from the overlay or the Root Getter.
+func (os OverlayService) Int(p cfgpath.Path) (int, error) {
+	if v, ok := os.overlay.Get(p); ok {
+		return conv.ToIntE(v)
+	}
+	return os.Root.Int(p)
+}
+
+// Time returns a date and time object from the overlay or the Root Getter.
+func (os OverlayService) Time(p cfgpath.Path) (time.Time, error) {
+	if v, ok := os.overlay.Get(p); ok {
+		return conv.ToTimeE(v)
+	}
+	return os.Root.Time(p)
+}
+
+// Duration returns a duration from the overlay or the Root Getter.
+func (os OverlayService) Duration(p cfgpath.Path) (time.Duration, error) {
+	if v, ok := os.overlay.Get(p); ok {
+		return conv.ToDurationE(v)
+	}
+	return os.Root.Duration(p)
+}
@@ -0,0 +1,67 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ config.Getter = (*config.OverlayService)(nil)
+
+func TestOverlayService(t *testing.T) {
+	srv := config.MustNewService(config.NewInMemoryStore())
+	p := cfgpath.MustNewByParts("tax/display/type")
+	assert.NoError(t, srv.Write(p, 1))
+
+	ol := config.NewOverlay()
+	assert.NoError(t, ol.Set(p.Bind(scope.Store.Pack(3)), 3))
+	assert.Exactly(t, 1, ol.Len())
+
+	ctx := config.WithContextOverlay(context.Background(), ol)
+	ols := config.NewOverlayService(ctx, srv)
+
+	// store 3 gets the overlaid value
+	v, err := ols.NewScoped(1, 3).Int(cfgpath.NewRoute("tax/display/type"))
+	assert.NoError(t, err)
+	assert.Exactly(t, 3, v)
+
+	// store 4 falls back to the default value from the Storager
+	v, err = ols.NewScoped(1, 4).Int(cfgpath.NewRoute("tax/display/type"))
+	assert.NoError(t, err)
+	assert.Exactly(t, 1, v)
+
+	// Storager has not been modified
+	v, err = srv.NewScoped(1, 3).Int(cfgpath.NewRoute("tax/display/type"))
+	assert.NoError(t, err)
+	assert.Exactly(t, 1, v)
+}
+
+func TestOverlayService_NoOverlay(t *testing.T) {
+	srv := config.MustNewService(config.NewInMemoryStore())
+	ols := config.NewOverlayService(context.Background(), srv)
+
+	s, err := ols.String(cfgpath.MustNewByParts(config.PathCSBaseURL))
+	assert.NoError(t, err)
+	assert.Exactly(t, config.CSBaseURL, s)
+
+	_, ok := config.FromContextOverlay(context.Background())
+	assert.False(t, ok)
+}
@@ -97,6 +97,13 @@ type Service struct {
 	// package to log within functional option calls. For example in
 	// config/storage/ccd.
 	Log log.Logger
+
+	// writeStats tracks the last writes per scope for the Status function.
+	writeStats writeStats
+
+	// closers get called by Close before the pub/sub service terminates, for
+	// example to flush a WriteBehindStorage.
+	closers []func() error
 }
 
 // NewService creates the main new configuration for all scopes: default,
@@ -138,6 +145,23 @@ func MustNewService(backend Storager, opts ...Option) *Service {
 	return s
 }
 
+// Close closes the registered storages, which flushes for example the
+// buffered values of a WriteBehindStorage, and terminates the pub/sub
+// goroutine. Returns the first occurred error.
+func (s *Service) Close() error {
+	var err error
+	for _, c := range s.closers {
+		if cErr := c(); cErr != nil && err == nil {
+			err = errors.Wrap(cErr, "[config] Service.Close")
+		}
+	}
+	s.closers = nil
+	if psErr := s.pubSub.Close(); psErr != nil && err == nil {
+		err = psErr
+	}
+	return err
+}
+
 // Options applies service options.
 func (s *Service) Options(opts ...Option) error {
 	for _, opt := range opts {
@@ -175,6 +199,7 @@ func (s *Service) Write(p cfgpath.Path, v interface{}) error {
 	if err := s.backend.Set(p, v); err != nil {
 		return errors.Wrap(err, "[config] sStorage.Set")
 	}
+	s.writeStats.add(p, time.Now())
 	if s.pubSub != nil {
 		s.sendMsg(p)
 	}
@@ -0,0 +1,211 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// Pinger can be implemented by a Storager to report the reachability of its
+// backend, for example a database connection. Storagers without this
+// interface get probed by reading the path PathCSBaseURL.
+type Pinger interface {
+	Ping() error
+}
+
+// Status contains the health data of the configuration Service. The struct
+// can be directly encoded to JSON for health endpoints. Use Metrics to feed a
+// Prometheus collector.
+type Status struct {
+	// Time when the status has been collected.
+	Time time.Time `json:"time"`
+	// Backend reports the reachability of the Storager.
+	Backend BackendStatus `json:"backend"`
+	// Cache is nil if the Service does not use a CacheStorage.
+	Cache *CacheStats `json:"cache,omitempty"`
+	// PubSub reports the state of the publish and subscribe service.
+	PubSub PubSubStatus `json:"pubsub"`
+	// Writes contains the last write per scope ordered by scope.
+	Writes []ScopeWriteStatus `json:"writes"`
+}
+
+// BackendStatus reports the reachability of the Storager.
+type BackendStatus struct {
+	Reachable bool `json:"reachable"`
+	// Latency duration of the Ping or of the probe.
+	Latency time.Duration `json:"latency_ns"`
+	// Error contains the failure message if not reachable.
+	Error string `json:"error,omitempty"`
+}
+
+// PubSubStatus reports the state of the publish and subscribe service.
+type PubSubStatus struct {
+	Running bool `json:"running"`
+	// Subscriptions number of registered MessageReceivers.
+	Subscriptions int `json:"subscriptions"`
+	// Routes number of distinct routes with at least one subscription.
+	Routes int `json:"routes"`
+}
+
+// ScopeWriteStatus contains the write statistics for a scope.
+type ScopeWriteStatus struct {
+	Scope scope.Type `json:"scope"`
+	ID    int64      `json:"id"`
+	// Writes number of successful writes since the start of the Service.
+	Writes uint64 `json:"writes"`
+	// LastWrite time of the last successful write.
+	LastWrite time.Time `json:"last_write"`
+	// LastPath the last written path.
+	LastPath string `json:"last_path"`
+}
+
+// StatusMetric a single metric value of the Status. Name follows the
+// Prometheus naming conventions and Labels can be used as constant labels.
+type StatusMetric struct {
+	Name   string
+	Help   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Metrics flattens the Status into gauges and counters. Each ScopeWriteStatus
+// creates two metrics with the labels scope and id.
+func (st Status) Metrics() []StatusMetric {
+	ms := make([]StatusMetric, 0, 9+len(st.Writes)*2)
+	ms = append(ms,
+		StatusMetric{Name: "config_backend_up", Help: "Whether the configuration backend is reachable.", Value: boolToFloat(st.Backend.Reachable)},
+		StatusMetric{Name: "config_backend_latency_seconds", Help: "Latency of the last reachability check.", Value: st.Backend.Latency.Seconds()},
+		StatusMetric{Name: "config_pubsub_running", Help: "Whether the publish and subscribe service is running.", Value: boolToFloat(st.PubSub.Running)},
+		StatusMetric{Name: "config_pubsub_subscriptions", Help: "Number of registered subscriptions.", Value: float64(st.PubSub.Subscriptions)},
+	)
+	if c := st.Cache; c != nil {
+		ms = append(ms,
+			StatusMetric{Name: "config_cache_hits_total", Help: "Number of cache hits.", Value: float64(c.Hits)},
+			StatusMetric{Name: "config_cache_misses_total", Help: "Number of cache misses.", Value: float64(c.Misses)},
+			StatusMetric{Name: "config_cache_evictions_total", Help: "Number of evicted cache entries.", Value: float64(c.Evictions)},
+			StatusMetric{Name: "config_cache_entries", Help: "Number of cached entries.", Value: float64(c.Size)},
+			StatusMetric{Name: "config_cache_hit_ratio", Help: "Ratio of hits to all lookups.", Value: c.HitRatio()},
+		)
+	}
+	for _, w := range st.Writes {
+		lbl := map[string]string{"scope": w.Scope.StrType(), "id": strconv.FormatInt(w.ID, 10)}
+		ms = append(ms,
+			StatusMetric{Name: "config_writes_total", Help: "Number of successful writes per scope.", Labels: lbl, Value: float64(w.Writes)},
+			StatusMetric{Name: "config_last_write_timestamp_seconds", Help: "Unix time of the last successful write per scope.", Labels: lbl, Value: float64(w.LastWrite.UnixNano()) / 1e9},
+		)
+	}
+	return ms
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// writeStats tracks the successful writes per scope.
+type writeStats struct {
+	mu     sync.Mutex
+	scopes map[scope.TypeID]*ScopeWriteStatus
+}
+
+func (ws *writeStats) add(p cfgpath.Path, now time.Time) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.scopes == nil {
+		ws.scopes = make(map[scope.TypeID]*ScopeWriteStatus)
+	}
+	sws, ok := ws.scopes[p.ScopeID]
+	if !ok {
+		scp, id := p.ScopeID.Unpack()
+		sws = &ScopeWriteStatus{Scope: scp, ID: id}
+		ws.scopes[p.ScopeID] = sws
+	}
+	sws.Writes++
+	sws.LastWrite = now
+	sws.LastPath = p.Route.String()
+}
+
+func (ws *writeStats) list() []ScopeWriteStatus {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ret := make([]ScopeWriteStatus, 0, len(ws.scopes))
+	for _, sws := range ws.scopes {
+		ret = append(ret, *sws)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Scope != ret[j].Scope {
+			return ret[i].Scope < ret[j].Scope
+		}
+		return ret[i].ID < ret[j].ID
+	})
+	return ret
+}
+
+// Status collects the health data of the Service. The reachability check
+// calls the Ping function of the Storager, if implemented, otherwise it reads
+// the path PathCSBaseURL. A NotFound error counts as reachable.
+func (s *Service) Status() Status {
+	st := Status{
+		Time:   time.Now(),
+		Writes: s.writeStats.list(),
+	}
+
+	start := time.Now()
+	err := s.pingBackend()
+	st.Backend.Latency = time.Since(start)
+	st.Backend.Reachable = err == nil
+	if err != nil {
+		st.Backend.Error = err.Error()
+	}
+
+	if cs, ok := s.backend.(*CacheStorage); ok {
+		stats := cs.Stats()
+		st.Cache = &stats
+	}
+
+	if ps := s.pubSub; ps != nil {
+		ps.mu.RLock()
+		st.PubSub.Running = !ps.closed
+		st.PubSub.Routes = len(ps.subMap)
+		for _, subs := range ps.subMap {
+			st.PubSub.Subscriptions += len(subs)
+		}
+		ps.mu.RUnlock()
+	}
+	return st
+}
+
+func (s *Service) pingBackend() error {
+	be := s.backend
+	if cs, ok := be.(*CacheStorage); ok {
+		be = cs.Backend // bypass the cache to reach the real storage
+	}
+	if p, ok := be.(Pinger); ok {
+		return errors.Wrap(p.Ping(), "[config] Service.Status.Ping")
+	}
+	if _, err := be.Get(cfgpath.MustNewByParts(PathCSBaseURL)); err != nil && !errors.IsNotFound(err) {
+		return errors.Wrap(err, "[config] Service.Status.Get")
+	}
+	return nil
+}
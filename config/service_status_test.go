@@ -0,0 +1,128 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pingStorage struct {
+	config.Storager
+	err error
+}
+
+func (ps pingStorage) Ping() error { return ps.err }
+
+type failingStorage struct {
+	config.Storager
+}
+
+func (failingStorage) Get(_ cfgpath.Path) (interface{}, error) {
+	return nil, errors.NewFatalf("Connection refused")
+}
+
+type nopMessageReceiver struct{}
+
+func (nopMessageReceiver) MessageConfig(_ cfgpath.Path) error { return nil }
+
+func TestService_Status(t *testing.T) {
+	cs := config.NewCacheStorage(nil)
+	srv := config.MustNewService(config.NewInMemoryStore(), config.WithPubSub(), config.WithCache(cs))
+	defer func() { assert.NoError(t, srv.Close()) }()
+
+	_, err := srv.Subscribe(cfgpath.NewRoute("web/cors"), nopMessageReceiver{})
+	require.NoError(t, err)
+	_, err = srv.Subscribe(cfgpath.NewRoute("web/cors"), nopMessageReceiver{})
+	require.NoError(t, err)
+	_, err = srv.Subscribe(cfgpath.NewRoute("web"), nopMessageReceiver{})
+	require.NoError(t, err)
+
+	p := cfgpath.MustNewByParts("web/cors/allow_credentials")
+	require.NoError(t, srv.Write(p.BindStore(2), true))
+	require.NoError(t, srv.Write(p.BindStore(2), false))
+	require.NoError(t, srv.Write(p.BindWebsite(1), true))
+	require.NoError(t, srv.Write(p, true))
+
+	_, err = srv.Bool(p.BindStore(2))
+	require.NoError(t, err)
+	_, err = srv.Bool(p.BindStore(2))
+	require.NoError(t, err)
+
+	st := srv.Status()
+	assert.True(t, st.Backend.Reachable)
+	assert.Empty(t, st.Backend.Error)
+	assert.Exactly(t, config.PubSubStatus{Running: true, Subscriptions: 3, Routes: 2}, st.PubSub)
+	require.NotNil(t, st.Cache)
+	assert.Exactly(t, uint64(1), st.Cache.Hits)
+	assert.Exactly(t, uint64(1), st.Cache.Misses)
+
+	require.Len(t, st.Writes, 3)
+	assert.Exactly(t, scope.Default, st.Writes[0].Scope)
+	assert.Exactly(t, scope.Website, st.Writes[1].Scope)
+	assert.Exactly(t, int64(1), st.Writes[1].ID)
+	assert.Exactly(t, scope.Store, st.Writes[2].Scope)
+	assert.Exactly(t, int64(2), st.Writes[2].ID)
+	assert.Exactly(t, uint64(2), st.Writes[2].Writes)
+	assert.Exactly(t, "web/cors/allow_credentials", st.Writes[2].LastPath)
+	assert.False(t, st.Writes[2].LastWrite.IsZero())
+
+	data, err := json.Marshal(st)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"pubsub":{"running":true,"subscriptions":3,"routes":2}`)
+	assert.Contains(t, string(data), `"scope":"Store","id":2,"writes":2`)
+
+	metrics := make(map[string]float64)
+	for _, m := range st.Metrics() {
+		if m.Labels != nil {
+			metrics[m.Name+"/"+m.Labels["scope"]+"/"+m.Labels["id"]] = m.Value
+			continue
+		}
+		metrics[m.Name] = m.Value
+	}
+	assert.Exactly(t, 1.0, metrics["config_backend_up"])
+	assert.Exactly(t, 3.0, metrics["config_pubsub_subscriptions"])
+	assert.Exactly(t, 0.5, metrics["config_cache_hit_ratio"])
+	assert.Exactly(t, 2.0, metrics["config_writes_total/stores/2"])
+	assert.Exactly(t, 1.0, metrics["config_writes_total/default/0"])
+}
+
+func TestService_Status_Backend(t *testing.T) {
+	tests := []struct {
+		name          string
+		backend       config.Storager
+		wantReachable bool
+	}{
+		{"pinger ok", pingStorage{Storager: config.NewInMemoryStore()}, true},
+		{"pinger fails", pingStorage{Storager: config.NewInMemoryStore(), err: errors.New("dial tcp: connection refused")}, false},
+		{"probe fails", failingStorage{Storager: config.NewInMemoryStore()}, false},
+	}
+	for _, test := range tests {
+		srv := config.MustNewService(test.backend)
+		st := srv.Status()
+		assert.Exactly(t, test.wantReachable, st.Backend.Reachable, test.name)
+		assert.Exactly(t, test.wantReachable, st.Backend.Error == "", test.name)
+		assert.Nil(t, st.Cache, test.name)
+		assert.False(t, st.PubSub.Running, test.name)
+		assert.Empty(t, st.Writes, test.name)
+	}
+}
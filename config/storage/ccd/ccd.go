@@ -15,6 +15,7 @@
 package ccd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"time"
@@ -33,9 +34,11 @@ import (
 var TableCollection *csdb.Tables
 
 // DBStorage connects the MySQL DB with the config.Service type. Implements
-// interface config.Storager.
+// interface config.Storager and config.BatchSetter.
 type DBStorage struct {
 	log log.Logger
+	// db prepares the multi row statements of SetBatch.
+	db csdb.Preparer
 	// All is a SQL statement for the all keys query
 	All *csdb.ResurrectStmt
 	// Read is a SQL statement for selecting a value from a path/key
@@ -58,6 +61,7 @@ func NewDBStorage(p csdb.Preparer) (*DBStorage, error) {
 
 	dbs := &DBStorage{
 		log: log.BlackHole{}, // skip debug and info level via init with empty fields
+		db:  p,
 		All: csdb.NewResurrectStmt(p, fmt.Sprintf(
 			"SELECT scope,scope_id,path FROM `%s` ORDER BY scope,scope_id,path",
 			TableCollection.Name(TableIndexCoreConfigData),
@@ -170,6 +174,71 @@ func (dbs *DBStorage) Set(key cfgpath.Path, value interface{}) error {
 	return nil
 }
 
+// SetBatch implements the config.BatchSetter interface and writes all values
+// with one multi row INSERT ... ON DUPLICATE KEY UPDATE statement. Used by
+// the config.WriteBehindStorage. Error behaviour: Mismatch.
+func (dbs *DBStorage) SetBatch(keys cfgpath.PathSlice, values []interface{}) error {
+	if lk, lv := len(keys), len(values); lk != lv {
+		return errors.NewMismatchf("[ccd] SetBatch: %d keys but %d values", lk, lv)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(keys)*4)
+	for i, key := range keys {
+		valStr, err := conv.ToStringE(values[i])
+		if err != nil {
+			return errors.Wrapf(err, "[ccd] SetBatch.conv.ToStringE. Key: %q Value: %v", key, values[i])
+		}
+		pathLeveled, err := key.Level(-1)
+		if err != nil {
+			return errors.Wrapf(err, "[ccd] SetBatch.key.Level. Key: %q", key)
+		}
+		scp, id := key.ScopeID.Unpack()
+		args = append(args, scp.StrType(), id, pathLeveled, valStr)
+	}
+
+	sqlStr := batchWriteSQL(TableCollection.Name(TableIndexCoreConfigData), len(keys))
+	stmt, err := dbs.db.PrepareContext(context.TODO(), sqlStr)
+	if err != nil {
+		return errors.Wrapf(err, "[ccd] SetBatch.PrepareContext. SQL: %q", sqlStr)
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(context.TODO(), args...)
+	if err != nil {
+		return errors.Wrapf(err, "[ccd] SetBatch.stmt.Exec. SQL: %q Keys: %q", sqlStr, keys)
+	}
+	if dbs.log.IsDebug() {
+		ra, err := result.RowsAffected()
+		dbs.log.Debug(
+			"config.DBStorage.SetBatch.Result",
+			log.Int64("rowsAffected", ra),
+			log.ErrWithKey("rowsAffectedErr", err),
+			log.String("SQL", sqlStr),
+			log.Int("rows", len(keys)),
+		)
+	}
+	return nil
+}
+
+// batchWriteSQL creates the multi row insert statement for SetBatch.
+func batchWriteSQL(table string, rows int) string {
+	var buf bytes.Buffer
+	buf.WriteString("INSERT INTO `")
+	buf.WriteString(table)
+	buf.WriteString("` (`scope`,`scope_id`,`path`,`value`) VALUES ")
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("(?,?,?,?)")
+	}
+	buf.WriteString(" ON DUPLICATE KEY UPDATE `value`=VALUES(`value`)")
+	return buf.String()
+}
+
 // Get returns a value from the database by its key. It is guaranteed that the
 // type in the empty interface is a string. It returns nil on error but errors
 // get logged as info message. Error behaviour: NotFound
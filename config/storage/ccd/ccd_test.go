@@ -26,10 +26,12 @@ import (
 	"github.com/corestoreio/csfw/config/storage/ccd"
 	"github.com/corestoreio/csfw/store/scope"
 	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
 	"github.com/stretchr/testify/assert"
 )
 
 var _ config.Storager = (*ccd.DBStorage)(nil)
+var _ config.BatchSetter = (*ccd.DBStorage)(nil)
 
 func TestDBStorageOneStmt(t *testing.T) {
 	t.Parallel()
@@ -179,6 +181,33 @@ func TestDBStorageMultipleStmt_Set(t *testing.T) {
 	assert.NoError(t, sdb.Stop())
 }
 
+func TestDBStorage_SetBatch(t *testing.T) {
+	t.Parallel()
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	sdb := ccd.MustNewDBStorage(dbc.DB)
+
+	keys := cfgpath.PathSlice{
+		cfgpath.MustNewByParts("web/secure/base_url").BindStore(1),
+		cfgpath.MustNewByParts("web/cookie/cookie_lifetime").BindWebsite(2),
+	}
+	dbMock.ExpectPrepare(cstesting.SQLMockQuoteMeta("INSERT INTO `core_config_data` (`scope`,`scope_id`,`path`,`value`) VALUES (?,?,?,?),(?,?,?,?) ON DUPLICATE KEY UPDATE `value`=VALUES(`value`)")).
+		ExpectExec().WithArgs(
+		"stores", int64(1), "web/secure/base_url", "https://corestore.io",
+		"websites", int64(2), "web/cookie/cookie_lifetime", "3600",
+	).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, sdb.SetBatch(keys, []interface{}{"https://corestore.io", 3600}))
+	assert.True(t, errors.IsMismatch(sdb.SetBatch(keys, []interface{}{1})))
+}
+
 func TestDBStorageMultipleStmt_Get(t *testing.T) {
 	t.Parallel()
 	dbc, dbMock := cstesting.MockDB(t)
@@ -0,0 +1,47 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cfgembed serves the default configuration values of the
+// element.SectionSlice from a blob compiled into the binary.
+//
+// The package acts as the last fallback layer when the MySQL database is not
+// reachable. Critical middlewares which read their configuration via a
+// config.Service, for example the geoip or rate limit services, keep on
+// working with the shipped defaults instead of failing each request.
+//
+// A generator, mostly called via go:generate in your main package, serializes
+// the defaults of all packages into a Go file:
+//
+//		// +build ignore
+//
+//		package main
+//
+//		func main() {
+//			f, _ := os.Create("config_defaults.go")
+//			defer f.Close()
+//			if err := cfgembed.GenerateGo(f, "main", "configDefaults", backend.ConfigStructure); err != nil {
+//				panic(err)
+//			}
+//		}
+//
+// At runtime the generated variable gets loaded with NewStorage and wrapped
+// around the database backed Storager:
+//
+//		defaults, err := cfgembed.NewStorage(configDefaults)
+//		cfgSrv := config.MustNewService(cfgembed.NewFallback(dbStorage, defaults))
+//
+// The Storage only knows values of the default scope. Website or store scoped
+// paths return a NotFound error so that the scoped getters of the config
+// package fall back to the default scope.
+package cfgembed
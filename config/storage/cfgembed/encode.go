@@ -0,0 +1,264 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgembed
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/errors"
+)
+
+// magic identifies the format of a blob. The last byte contains the version.
+const magic = "CSCFGDEF\x01"
+
+// maxDecodeLength limits the length of a single string or slice while
+// decoding to detect corrupt blobs early.
+const maxDecodeLength = 1 << 24
+
+// Type tags of the encoded default values.
+const (
+	tagString   byte = 's'
+	tagBool     byte = 'b'
+	tagInt      byte = 'i'
+	tagInt64    byte = 'I'
+	tagFloat64  byte = 'f'
+	tagDuration byte = 'd'
+	tagTime     byte = 't'
+	tagStrings  byte = 'S'
+)
+
+// Encode serializes all non-nil default values of the sections into a
+// compact gzip compressed blob. The paths get sorted to generate the same
+// blob for the same input. Supported types of the default values are the
+// ones allowed by element.SectionSlice.Validate: bool, int, int64, float64,
+// string, time.Duration, time.Time and []string.
+func Encode(ss element.SectionSlice) ([]byte, error) {
+	dm, err := ss.Defaults()
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgembed] Encode.Defaults")
+	}
+	return EncodeMap(dm)
+}
+
+// EncodeMap same as Encode but uses an already generated DefaultMap.
+func EncodeMap(dm element.DefaultMap) ([]byte, error) {
+	paths := make([]string, 0, len(dm))
+	for p, v := range dm {
+		if v != nil {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var raw bytes.Buffer
+	raw.WriteString(magic)
+	writeUvarint(&raw, uint64(len(paths)))
+	for _, p := range paths {
+		writeString(&raw, p)
+		if err := writeValue(&raw, dm[p]); err != nil {
+			return nil, errors.Wrapf(err, "[cfgembed] EncodeMap path %q", p)
+		}
+	}
+
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgembed] EncodeMap.gzip.NewWriterLevel")
+	}
+	if _, err := zw.Write(raw.Bytes()); err != nil {
+		return nil, errors.Wrap(err, "[cfgembed] EncodeMap.gzip.Write")
+	}
+	if err := zw.Close(); err != nil {
+		return nil, errors.Wrap(err, "[cfgembed] EncodeMap.gzip.Close")
+	}
+	return buf.Bytes(), nil
+}
+
+func writeUvarint(w *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func writeVarint(w *bytes.Buffer, v int64) {
+	var b [binary.MaxVarintLen64]byte
+	w.Write(b[:binary.PutVarint(b[:], v)])
+}
+
+func writeString(w *bytes.Buffer, s string) {
+	writeUvarint(w, uint64(len(s)))
+	w.WriteString(s)
+}
+
+func writeValue(w *bytes.Buffer, v interface{}) error {
+	switch vt := v.(type) {
+	case string:
+		w.WriteByte(tagString)
+		writeString(w, vt)
+	case bool:
+		w.WriteByte(tagBool)
+		if vt {
+			w.WriteByte(1)
+		} else {
+			w.WriteByte(0)
+		}
+	case int:
+		w.WriteByte(tagInt)
+		writeVarint(w, int64(vt))
+	case int64:
+		w.WriteByte(tagInt64)
+		writeVarint(w, vt)
+	case float64:
+		w.WriteByte(tagFloat64)
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(vt))
+		w.Write(b[:])
+	case time.Duration:
+		w.WriteByte(tagDuration)
+		writeVarint(w, int64(vt))
+	case time.Time:
+		tb, err := vt.MarshalBinary()
+		if err != nil {
+			return errors.Wrap(err, "[cfgembed] time.MarshalBinary")
+		}
+		w.WriteByte(tagTime)
+		writeString(w, string(tb))
+	case []string:
+		w.WriteByte(tagStrings)
+		writeUvarint(w, uint64(len(vt)))
+		for _, s := range vt {
+			writeString(w, s)
+		}
+	default:
+		return errors.NewNotSupportedf("[cfgembed] Default value type %T not supported", v)
+	}
+	return nil
+}
+
+// Decode uncompresses the blob created by Encode and returns the default
+// values. Returns a NotValid error behaviour if the blob is corrupt or has
+// been created by an incompatible version.
+func Decode(blob []byte) (element.DefaultMap, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, errors.NewNotValid(err, "[cfgembed] Decode.gzip.NewReader")
+	}
+	defer zr.Close()
+	raw, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, errors.NewNotValid(err, "[cfgembed] Decode.gzip.ReadAll")
+	}
+	if !bytes.HasPrefix(raw, []byte(magic)) {
+		return nil, errors.NewNotValidf("[cfgembed] Decode: Unknown blob format or version")
+	}
+
+	r := bufio.NewReader(bytes.NewReader(raw[len(magic):]))
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, errors.NewNotValid(err, "[cfgembed] Decode.Count")
+	}
+	if n > maxDecodeLength {
+		return nil, errors.NewNotValidf("[cfgembed] Decode: Invalid count %d", n)
+	}
+	dm := make(element.DefaultMap, n)
+	for i := uint64(0); i < n; i++ {
+		p, err := readString(r)
+		if err != nil {
+			return nil, errors.NewNotValid(err, fmt.Sprintf("[cfgembed] Decode.Path at index %d", i))
+		}
+		v, err := readValue(r)
+		if err != nil {
+			return nil, errors.NewNotValid(err, fmt.Sprintf("[cfgembed] Decode.Value of path %q", p))
+		}
+		dm[p] = v
+	}
+	return dm, nil
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if l > maxDecodeLength {
+		return "", errors.NewNotValidf("[cfgembed] Invalid length %d", l)
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func readValue(r *bufio.Reader) (interface{}, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case tagString:
+		return readString(r)
+	case tagBool:
+		b, err := r.ReadByte()
+		return b == 1, err
+	case tagInt:
+		v, err := binary.ReadVarint(r)
+		return int(v), err
+	case tagInt64:
+		return binary.ReadVarint(r)
+	case tagFloat64:
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[:])), nil
+	case tagDuration:
+		v, err := binary.ReadVarint(r)
+		return time.Duration(v), err
+	case tagTime:
+		s, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		var t time.Time
+		err = t.UnmarshalBinary([]byte(s))
+		return t, err
+	case tagStrings:
+		l, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if l > maxDecodeLength {
+			return nil, errors.NewNotValidf("[cfgembed] Invalid slice length %d", l)
+		}
+		ss := make([]string, l)
+		for i := range ss {
+			if ss[i], err = readString(r); err != nil {
+				return nil, err
+			}
+		}
+		return ss, nil
+	}
+	return nil, errors.NewNotValidf("[cfgembed] Unknown type tag %q", tag)
+}
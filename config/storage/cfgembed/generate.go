@@ -0,0 +1,69 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgembed
+
+import (
+	"bytes"
+	"go/format"
+	"io"
+	"strconv"
+
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/errors"
+)
+
+// GenerateGo encodes the default values of the sections and writes a
+// formatted Go source file into w. The file declares in package pkgName the
+// byte slice variable varName which can be passed to NewStorage. Run it via
+// go:generate whenever the element.SectionSlice changes.
+func GenerateGo(w io.Writer, pkgName, varName string, ss element.SectionSlice) error {
+	blob, err := Encode(ss)
+	if err != nil {
+		return errors.Wrap(err, "[cfgembed] GenerateGo.Encode")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by cfgembed.GenerateGo. DO NOT EDIT.\n\n")
+	buf.WriteString("package ")
+	buf.WriteString(pkgName)
+	buf.WriteString("\n\n// ")
+	buf.WriteString(varName)
+	buf.WriteString(" contains the gzip compressed default configuration values.\nvar ")
+	buf.WriteString(varName)
+	buf.WriteString(" = []byte(")
+	// strconv.Quote would create a long line, so split it into chunks
+	const chunk = 64
+	for i := 0; i < len(blob); i += chunk {
+		if i > 0 {
+			buf.WriteString(" +\n\t")
+		}
+		end := i + chunk
+		if end > len(blob) {
+			end = len(blob)
+		}
+		buf.WriteString(strconv.Quote(string(blob[i:end])))
+	}
+	if len(blob) == 0 {
+		buf.WriteString(`""`)
+	}
+	buf.WriteString(")\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.NewFatal(err, "[cfgembed] GenerateGo.format.Source")
+	}
+	_, err = w.Write(src)
+	return errors.Wrap(err, "[cfgembed] GenerateGo.Write")
+}
@@ -0,0 +1,157 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgembed
+
+import (
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// Storage serves the embedded default configuration values without any
+// database access. It is read only and safe for concurrent use. Implements
+// interface config.Storager.
+type Storage struct {
+	keys   cfgpath.PathSlice
+	values map[uint32]interface{}
+}
+
+// NewStorage decodes the blob created by Encode or GenerateGo.
+func NewStorage(blob []byte) (*Storage, error) {
+	dm, err := Decode(blob)
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgembed] NewStorage.Decode")
+	}
+	s := &Storage{
+		keys:   make(cfgpath.PathSlice, 0, len(dm)),
+		values: make(map[uint32]interface{}, len(dm)),
+	}
+	for r, v := range dm {
+		p, err := cfgpath.NewByParts(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[cfgembed] NewStorage.NewByParts %q", r)
+		}
+		h32, err := p.Hash(-1)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[cfgembed] NewStorage.Hash %q", r)
+		}
+		s.keys = append(s.keys, p)
+		s.values[h32] = v
+	}
+	s.keys.Sort()
+	return s, nil
+}
+
+// MustNewStorage same as NewStorage but panics on error. Use it in the init
+// function together with a generated blob.
+func MustNewStorage(blob []byte) *Storage {
+	s, err := NewStorage(blob)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Set returns always a NotSupported error behaviour because the embedded
+// defaults cannot be modified.
+func (s *Storage) Set(key cfgpath.Path, _ interface{}) error {
+	return errors.NewNotSupportedf("[cfgembed] Storage is read only. Cannot set %q", key.String())
+}
+
+// Get returns the default value for a path bound to the default scope. All
+// other scopes or unknown paths return a NotFound error behaviour.
+func (s *Storage) Get(key cfgpath.Path) (interface{}, error) {
+	if key.ScopeID != scope.DefaultTypeID {
+		return nil, errors.NewNotFoundf("[cfgembed] Path %q not found", key.String())
+	}
+	h32, err := key.Hash(-1)
+	if err != nil {
+		return nil, errors.Wrap(err, "[cfgembed] Storage.Get.Hash")
+	}
+	v, ok := s.values[h32]
+	if !ok {
+		return nil, errors.NewNotFoundf("[cfgembed] Path %q not found", key.String())
+	}
+	return v, nil
+}
+
+// AllKeys returns all paths bound to the default scope, sorted.
+func (s *Storage) AllKeys() (cfgpath.PathSlice, error) {
+	ret := make(cfgpath.PathSlice, len(s.keys))
+	copy(ret, s.keys)
+	return ret, nil
+}
+
+// Len returns the number of embedded default values.
+func (s *Storage) Len() int {
+	return len(s.values)
+}
+
+// Fallback reads from the Primary Storager and serves the embedded Defaults
+// if the path cannot be found or the Primary returns any other error, e.g.
+// because the database is down. Writes go only to the Primary. Implements
+// interface config.Storager.
+type Fallback struct {
+	Primary  config.Storager
+	Defaults *Storage
+	// OnError gets called, if set, when the Primary returns an error which is
+	// not a NotFound error behaviour. Useful to log an outage of the database
+	// while the application runs on the defaults.
+	OnError func(key cfgpath.Path, err error)
+}
+
+// NewFallback creates a new fallback layer for the primary storage.
+func NewFallback(primary config.Storager, defaults *Storage) *Fallback {
+	return &Fallback{
+		Primary:  primary,
+		Defaults: defaults,
+	}
+}
+
+// Set writes into the Primary Storager.
+func (f *Fallback) Set(key cfgpath.Path, value interface{}) error {
+	return f.Primary.Set(key, value)
+}
+
+// Get returns the value of the Primary Storager. On any error the embedded
+// default value gets returned. If there is no default value the error of the
+// Primary gets returned.
+func (f *Fallback) Get(key cfgpath.Path) (interface{}, error) {
+	v, err := f.Primary.Get(key)
+	if err == nil {
+		return v, nil
+	}
+	if !errors.IsNotFound(err) && f.OnError != nil {
+		f.OnError(key, err)
+	}
+	if dv, dErr := f.Defaults.Get(key); dErr == nil {
+		return dv, nil
+	}
+	return nil, err
+}
+
+// AllKeys returns the keys of the Primary Storager or, if the Primary fails,
+// the keys of the embedded defaults.
+func (f *Fallback) AllKeys() (cfgpath.PathSlice, error) {
+	ps, err := f.Primary.AllKeys()
+	if err == nil {
+		return ps, nil
+	}
+	if f.OnError != nil {
+		f.OnError(cfgpath.Path{}, err)
+	}
+	return f.Defaults.AllKeys()
+}
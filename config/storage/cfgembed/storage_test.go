@@ -0,0 +1,248 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfgembed_test
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/config/storage/cfgembed"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSections = element.MustNewConfiguration(
+	element.Section{
+		ID: cfgpath.NewRoute(`web`),
+		Groups: element.NewGroupSlice(
+			element.Group{
+				ID: cfgpath.NewRoute(`cors`),
+				Fields: element.NewFieldSlice(
+					element.Field{ID: cfgpath.NewRoute(`enabled`), Default: true},
+					element.Field{ID: cfgpath.NewRoute(`max_age`), Default: time.Hour},
+					element.Field{ID: cfgpath.NewRoute(`allowed_origins`), Default: []string{"https://a.io", "https://b.io"}},
+					element.Field{ID: cfgpath.NewRoute(`comment`)}, // nil default gets skipped
+				),
+			},
+			element.Group{
+				ID: cfgpath.NewRoute(`ratelimit`),
+				Fields: element.NewFieldSlice(
+					element.Field{ID: cfgpath.NewRoute(`burst`), Default: 20},
+					element.Field{ID: cfgpath.NewRoute(`requests`), Default: int64(100)},
+					element.Field{ID: cfgpath.NewRoute(`factor`), Default: 2.7182},
+					element.Field{ID: cfgpath.NewRoute(`storage`), Default: "memstore"},
+					element.Field{ID: cfgpath.NewRoute(`since`), Default: time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)},
+				),
+			},
+		),
+	},
+)
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	blob, err := cfgembed.Encode(testSections)
+	require.NoError(t, err, "%+v", err)
+
+	blob2, err := cfgembed.Encode(testSections)
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, blob, blob2, "Encoding must be deterministic")
+
+	dm, err := cfgembed.Decode(blob)
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, element.DefaultMap{
+		"web/cors/enabled":         true,
+		"web/cors/max_age":         time.Hour,
+		"web/cors/allowed_origins": []string{"https://a.io", "https://b.io"},
+		"web/ratelimit/burst":      20,
+		"web/ratelimit/requests":   int64(100),
+		"web/ratelimit/factor":     2.7182,
+		"web/ratelimit/storage":    "memstore",
+		"web/ratelimit/since":      time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+	}, dm)
+}
+
+func TestEncodeMap_NotSupported(t *testing.T) {
+	t.Parallel()
+	blob, err := cfgembed.EncodeMap(element.DefaultMap{"a/b/c": struct{}{}})
+	assert.Nil(t, blob)
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+}
+
+func TestDecode_Corrupt(t *testing.T) {
+	t.Parallel()
+
+	blob, err := cfgembed.Encode(testSections)
+	require.NoError(t, err, "%+v", err)
+
+	tests := [][]byte{
+		nil,
+		[]byte("not gzip"),
+		blob[:len(blob)/2],
+	}
+	for i, test := range tests {
+		dm, err := cfgembed.Decode(test)
+		assert.Nil(t, dm, "Index %d", i)
+		assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+	}
+
+	emptyBlob, err := cfgembed.EncodeMap(nil)
+	require.NoError(t, err)
+	dm, err := cfgembed.Decode(emptyBlob)
+	require.NoError(t, err, "empty map is valid")
+	assert.Len(t, dm, 0)
+}
+
+func TestGenerateGo(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, cfgembed.GenerateGo(&buf, "main", "configDefaults", testSections))
+
+	assert.Contains(t, buf.String(), "// Code generated by cfgembed.GenerateGo. DO NOT EDIT.")
+	assert.Contains(t, buf.String(), "var configDefaults = []byte(")
+
+	f, err := parser.ParseFile(token.NewFileSet(), "config_defaults.go", buf.Bytes(), 0)
+	require.NoError(t, err, "%s", buf.String())
+	assert.Exactly(t, "main", f.Name.Name)
+}
+
+func TestStorage(t *testing.T) {
+	t.Parallel()
+
+	s := cfgembed.MustNewStorage(mustEncode(t))
+	assert.Exactly(t, 8, s.Len())
+
+	v, err := s.Get(cfgpath.MustNewByParts("web/ratelimit/storage"))
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, "memstore", v)
+
+	v, err = s.Get(cfgpath.MustNewByParts("web/ratelimit/storage").BindStore(2))
+	assert.Nil(t, v)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+
+	v, err = s.Get(cfgpath.MustNewByParts("web/cors/comment"))
+	assert.Nil(t, v)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+
+	err = s.Set(cfgpath.MustNewByParts("web/cors/enabled"), false)
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+
+	keys, err := s.AllKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 8)
+	assert.Exactly(t, "default/0/web/cors/allowed_origins", keys[0].String())
+
+	assert.Panics(t, func() { cfgembed.MustNewStorage([]byte("x")) })
+}
+
+// flakyStorage simulates a database which goes down after the boot process.
+type flakyStorage struct {
+	config.Storager
+	down bool
+}
+
+func (fs *flakyStorage) Set(key cfgpath.Path, value interface{}) error {
+	if fs.down {
+		return errors.NewAlreadyClosedf("[cfgembed_test] connection closed")
+	}
+	return fs.Storager.Set(key, value)
+}
+func (fs *flakyStorage) Get(key cfgpath.Path) (interface{}, error) {
+	if fs.down {
+		return nil, errors.NewAlreadyClosedf("[cfgembed_test] connection closed")
+	}
+	return fs.Storager.Get(key)
+}
+func (fs *flakyStorage) AllKeys() (cfgpath.PathSlice, error) {
+	if fs.down {
+		return nil, errors.NewAlreadyClosedf("[cfgembed_test] connection closed")
+	}
+	return fs.Storager.AllKeys()
+}
+
+func TestFallback(t *testing.T) {
+	t.Parallel()
+
+	defaults := cfgembed.MustNewStorage(mustEncode(t))
+
+	t.Run("primary available", func(t *testing.T) {
+		mem := config.NewInMemoryStore()
+		fb := cfgembed.NewFallback(mem, defaults)
+		p := cfgpath.MustNewByParts("web/ratelimit/burst")
+
+		v, err := fb.Get(p)
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, 20, v, "not found in primary")
+
+		require.NoError(t, fb.Set(p, 50))
+		v, err = fb.Get(p)
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, 50, v)
+
+		v, err = fb.Get(cfgpath.MustNewByParts("web/ratelimit/unknown"))
+		assert.Nil(t, v)
+		assert.True(t, errors.IsNotFound(err), "%+v", err)
+	})
+
+	t.Run("primary down", func(t *testing.T) {
+		var errCount int
+		primary := &flakyStorage{Storager: config.NewInMemoryStore()}
+		fb := cfgembed.NewFallback(primary, defaults)
+		fb.OnError = func(_ cfgpath.Path, err error) {
+			assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
+			errCount++
+		}
+
+		srv := config.MustNewService(fb)
+		defer func() { assert.NoError(t, srv.Close()) }()
+		primary.down = true
+
+		b, err := srv.Bool(cfgpath.MustNewByParts("web/cors/enabled"))
+		require.NoError(t, err, "%+v", err)
+		assert.True(t, b)
+
+		d, err := srv.Duration(cfgpath.MustNewByParts("web/cors/max_age"))
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, time.Hour, d)
+
+		_, err = srv.String(cfgpath.MustNewByParts("web/cors/unknown"))
+		assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
+
+		_, err = srv.String(cfgpath.MustNewByParts("web/cors/enabled").Bind(scope.Website.Pack(1)))
+		assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
+
+		keys, err := fb.AllKeys()
+		require.NoError(t, err)
+		assert.Len(t, keys, 8)
+		assert.Exactly(t, 5, errCount)
+	})
+}
+
+func mustEncode(t *testing.T) []byte {
+	blob, err := cfgembed.Encode(testSections)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	return blob
+}
@@ -0,0 +1,457 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/errors"
+)
+
+// Default values of a WriteBehindStorage.
+const (
+	DefaultWriteBehindBatchSize = 100
+	DefaultWriteBehindInterval  = time.Second
+)
+
+// BatchSetter can be implemented by a Storager to write many keys with one
+// round trip, for example with a multi row INSERT ... ON DUPLICATE KEY UPDATE
+// statement. The length of both slices is always equal.
+type BatchSetter interface {
+	SetBatch(keys cfgpath.PathSlice, values []interface{}) error
+}
+
+// WriteConflict describes a key whose value in the backend has been changed by
+// someone else after the first buffered write.
+type WriteConflict struct {
+	Key cfgpath.Path
+	// Base the backend value at the time of the first buffered Set. Nil if
+	// the key did not exist.
+	Base interface{}
+	// Current the backend value right before the flush.
+	Current interface{}
+	// Pending the buffered value which should be written.
+	Pending interface{}
+}
+
+// WriteBehindOption applies options to the NewWriteBehindStorage function.
+type WriteBehindOption func(*WriteBehindStorage)
+
+// WithWriteBehindBatchSize sets the number of entries written with one call to
+// the backend. Reaching the size while buffering triggers an early flush.
+func WithWriteBehindBatchSize(size int) WriteBehindOption {
+	return func(wb *WriteBehindStorage) {
+		if size > 0 {
+			wb.batchSize = size
+		}
+	}
+}
+
+// WithWriteBehindInterval sets the duration between two flushes of the
+// background goroutine started with Start.
+func WithWriteBehindInterval(d time.Duration) WriteBehindOption {
+	return func(wb *WriteBehindStorage) {
+		if d > 0 {
+			wb.interval = d
+		}
+	}
+}
+
+// WithWriteBehindConflictHandler enables conflict detection. Before the first
+// write of a key gets buffered, its backend value gets loaded. During the
+// flush the backend value gets compared again and on a difference the handler
+// gets called. Returning true overwrites the backend value, false discards
+// the pending value. A nil handler discards all conflicting values.
+func WithWriteBehindConflictHandler(fn func(WriteConflict) bool) WriteBehindOption {
+	return func(wb *WriteBehindStorage) {
+		wb.detectConflicts = true
+		wb.onConflict = fn
+	}
+}
+
+// WriteBehindStorage wraps a Storager and accepts Set calls into memory. The
+// pending values get written to the backend in batches either when the batch
+// size has been reached, periodically after Start or when calling Flush or
+// Close. Get returns pending values before asking the backend, so a Service
+// reads its own writes, also while a flush is in progress. Use it to reduce the write latency during bulk
+// imports.
+//
+// Close must be called during shutdown to persist the remaining values.
+type WriteBehindStorage struct {
+	// Backend the wrapped Storager.
+	Backend Storager
+
+	batchSize       int
+	interval        time.Duration
+	detectConflicts bool
+	onConflict      func(WriteConflict) bool
+
+	mu      sync.Mutex
+	pending map[uint32]*pendingWrite
+	order   []uint32 // insertion order of pending keys
+	// inflight contains the entries of the running flush until the backend
+	// has acknowledged them or they have been requeued.
+	inflight map[uint32]*pendingWrite
+
+	flushMu sync.Mutex // serializes flushes
+	full    chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+	closed  bool
+}
+
+type pendingWrite struct {
+	key     cfgpath.Path
+	value   interface{}
+	base    interface{}
+	hasBase bool
+}
+
+// NewWriteBehindStorage creates a new write buffer in front of backend.
+// Default batch size is DefaultWriteBehindBatchSize and the flush interval
+// DefaultWriteBehindInterval. Conflict detection is disabled by default.
+func NewWriteBehindStorage(backend Storager, opts ...WriteBehindOption) *WriteBehindStorage {
+	wb := &WriteBehindStorage{
+		Backend:   backend,
+		batchSize: DefaultWriteBehindBatchSize,
+		interval:  DefaultWriteBehindInterval,
+		pending:   make(map[uint32]*pendingWrite),
+		full:      make(chan struct{}, 1),
+	}
+	for _, o := range opts {
+		if o != nil {
+			o(wb)
+		}
+	}
+	return wb
+}
+
+// WithWriteBehind wraps the current Storager of the Service with the
+// WriteBehindStorage. The Backend field of wb gets set to the current Storager
+// if it is nil. The option does not start the background flushing, call
+// wb.Start for that. Service.Close closes wb and flushes the pending values.
+func WithWriteBehind(wb *WriteBehindStorage) Option {
+	return func(s *Service) error {
+		if wb.Backend == nil {
+			wb.Backend = s.backend
+		}
+		s.backend = wb
+		s.closers = append(s.closers, wb.Close)
+		return nil
+	}
+}
+
+// Start starts the background goroutine which flushes periodically and
+// whenever a batch is full. Flush errors get passed to errFn which can be nil.
+// Calling Start more than once has no effect.
+func (wb *WriteBehindStorage) Start(errFn func(error)) *WriteBehindStorage {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	if wb.done != nil || wb.closed {
+		return wb
+	}
+	wb.done = make(chan struct{})
+	wb.wg.Add(1)
+	go wb.run(errFn)
+	return wb
+}
+
+func (wb *WriteBehindStorage) run(errFn func(error)) {
+	defer wb.wg.Done()
+	t := time.NewTicker(wb.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-wb.done:
+			return
+		case <-t.C:
+		case <-wb.full:
+		}
+		if err := wb.Flush(); err != nil && errFn != nil {
+			errFn(err)
+		}
+	}
+}
+
+// Close stops the background goroutine and flushes all pending values.
+// Writes after Close go directly to the backend.
+func (wb *WriteBehindStorage) Close() error {
+	wb.mu.Lock()
+	if wb.closed {
+		wb.mu.Unlock()
+		return nil
+	}
+	wb.closed = true
+	if wb.done != nil {
+		close(wb.done)
+	}
+	wb.mu.Unlock()
+	wb.wg.Wait()
+	return errors.Wrap(wb.Flush(), "[config] WriteBehindStorage.Close.Flush")
+}
+
+// Set implements the Storager interface and buffers the value. If conflict
+// detection has been enabled, the first Set of a key loads the current
+// backend value or takes the value of a running flush as base.
+func (wb *WriteBehindStorage) Set(key cfgpath.Path, value interface{}) error {
+	h32, err := key.Hash(-1)
+	if err != nil {
+		return errors.Wrap(err, "[config] WriteBehindStorage.key.Hash")
+	}
+
+	wb.mu.Lock()
+	if wb.closed {
+		wb.mu.Unlock()
+		return errors.Wrap(wb.Backend.Set(key, value), "[config] WriteBehindStorage.Backend.Set")
+	}
+	if pw, ok := wb.pending[h32]; ok {
+		pw.value = value
+		wb.mu.Unlock()
+		return nil
+	}
+	pw := &pendingWrite{key: key, value: value}
+	if ip, ok := wb.inflight[h32]; ok && wb.detectConflicts {
+		// the running flush writes our own value, the backend value is
+		// outdated.
+		pw.base, pw.hasBase = ip.value, true
+	}
+	wb.mu.Unlock()
+
+	if wb.detectConflicts && !pw.hasBase {
+		// load outside of the lock to not block readers on a slow backend.
+		base, err := wb.Backend.Get(key)
+		switch {
+		case err == nil:
+			pw.base, pw.hasBase = base, true
+		case !errors.IsNotFound(err):
+			return errors.Wrap(err, "[config] WriteBehindStorage.Backend.Get")
+		}
+	}
+
+	wb.mu.Lock()
+	if prev, ok := wb.pending[h32]; ok {
+		// a concurrent Set won the race and keeps its base value.
+		prev.value = value
+		wb.mu.Unlock()
+		return nil
+	}
+	wb.pending[h32] = pw
+	wb.order = append(wb.order, h32)
+	isFull := len(wb.order) >= wb.batchSize
+	wb.mu.Unlock()
+
+	if isFull {
+		select {
+		case wb.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Get implements the Storager interface and returns a pending value or a value
+// of the running flush before asking the backend.
+func (wb *WriteBehindStorage) Get(key cfgpath.Path) (interface{}, error) {
+	h32, err := key.Hash(-1)
+	if err != nil {
+		return nil, errors.Wrap(err, "[config] WriteBehindStorage.key.Hash")
+	}
+	wb.mu.Lock()
+	pw, ok := wb.pending[h32]
+	if !ok {
+		pw, ok = wb.inflight[h32]
+	}
+	var v interface{}
+	if ok {
+		v = pw.value
+	}
+	wb.mu.Unlock()
+	if ok {
+		return v, nil
+	}
+	return wb.Backend.Get(key)
+}
+
+// AllKeys implements the Storager interface and merges the backend keys with
+// the keys of the pending values and of the running flush.
+func (wb *WriteBehindStorage) AllKeys() (cfgpath.PathSlice, error) {
+	keys, err := wb.Backend.AllKeys()
+	if err != nil {
+		return nil, errors.Wrap(err, "[config] WriteBehindStorage.Backend.AllKeys")
+	}
+	seen := make(map[uint32]bool, len(keys))
+	for _, k := range keys {
+		if h32, err := k.Hash(-1); err == nil {
+			seen[h32] = true
+		}
+	}
+	wb.mu.Lock()
+	for _, h32 := range wb.order {
+		if !seen[h32] {
+			seen[h32] = true
+			keys = append(keys, wb.pending[h32].key)
+		}
+	}
+	for h32, pw := range wb.inflight {
+		if !seen[h32] {
+			keys = append(keys, pw.key)
+		}
+	}
+	wb.mu.Unlock()
+	return keys, nil
+}
+
+// Len returns the number of pending values.
+func (wb *WriteBehindStorage) Len() int {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	return len(wb.order)
+}
+
+// Flush writes all pending values in batches to the backend. Values which
+// fail to write stay pending for the next flush, unless a newer value has been
+// set meanwhile. Discarded conflicting values get reported with an error
+// behaviour Mismatch after all batches have been written. Until the backend
+// has acknowledged a batch, Get still returns its values.
+func (wb *WriteBehindStorage) Flush() error {
+	wb.flushMu.Lock()
+	defer wb.flushMu.Unlock()
+
+	wb.mu.Lock()
+	batch := make([]*pendingWrite, len(wb.order))
+	for i, h32 := range wb.order {
+		batch[i] = wb.pending[h32]
+	}
+	wb.inflight = wb.pending
+	wb.pending = make(map[uint32]*pendingWrite, len(batch))
+	wb.order = wb.order[:0]
+	wb.mu.Unlock()
+
+	var conflicts cfgpath.PathSlice
+	for len(batch) > 0 {
+		n := wb.batchSize
+		if n > len(batch) {
+			n = len(batch)
+		}
+		chunk := batch[:n]
+		batch = batch[n:]
+
+		if wb.detectConflicts {
+			var discarded cfgpath.PathSlice
+			chunk, discarded = wb.resolveConflicts(chunk)
+			conflicts = append(conflicts, discarded...)
+		}
+		if err := wb.write(chunk); err != nil {
+			wb.requeue(append(chunk, batch...))
+			return errors.Wrap(err, "[config] WriteBehindStorage.Flush")
+		}
+	}
+
+	wb.mu.Lock()
+	wb.inflight = nil
+	wb.mu.Unlock()
+
+	if len(conflicts) > 0 {
+		return errors.NewMismatchf("[config] WriteBehindStorage.Flush discarded conflicting writes: %v", conflicts)
+	}
+	return nil
+}
+
+// resolveConflicts returns the entries which can be written and the keys of
+// the discarded entries.
+func (wb *WriteBehindStorage) resolveConflicts(chunk []*pendingWrite) ([]*pendingWrite, cfgpath.PathSlice) {
+	var discarded cfgpath.PathSlice
+	ok := chunk[:0]
+	for _, pw := range chunk {
+		cur, err := wb.Backend.Get(pw.key)
+		hasCur := err == nil
+		if hasCur == pw.hasBase && (!hasCur || reflect.DeepEqual(cur, pw.base)) {
+			ok = append(ok, pw)
+			continue
+		}
+		if wb.onConflict != nil && wb.onConflict(WriteConflict{Key: pw.key, Base: pw.base, Current: cur, Pending: pw.value}) {
+			ok = append(ok, pw)
+			continue
+		}
+		discarded = append(discarded, pw.key)
+	}
+	return ok, discarded
+}
+
+func (wb *WriteBehindStorage) write(chunk []*pendingWrite) error {
+	if len(chunk) == 0 {
+		return nil
+	}
+	if bs, ok := wb.Backend.(BatchSetter); ok {
+		keys := make(cfgpath.PathSlice, len(chunk))
+		values := make([]interface{}, len(chunk))
+		for i, pw := range chunk {
+			keys[i] = pw.key
+			values[i] = pw.value
+		}
+		return errors.Wrap(bs.SetBatch(keys, values), "[config] WriteBehindStorage.Backend.SetBatch")
+	}
+	for i, pw := range chunk {
+		if err := wb.Backend.Set(pw.key, pw.value); err != nil {
+			return errors.Wrapf(err, "[config] WriteBehindStorage.Backend.Set Key %q", pw.key)
+		}
+		chunk[i] = nil // written, do not requeue
+	}
+	return nil
+}
+
+// requeue puts not yet written entries back into the buffer and ends the
+// flush. Entries which have been set again in the meantime keep their newer
+// value.
+func (wb *WriteBehindStorage) requeue(entries []*pendingWrite) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	wb.inflight = nil
+	var order []uint32
+	for _, pw := range entries {
+		if pw == nil {
+			continue
+		}
+		h32, err := pw.key.Hash(-1)
+		if err != nil {
+			continue
+		}
+		if newer, ok := wb.pending[h32]; ok {
+			newer.base, newer.hasBase = pw.base, pw.hasBase
+			continue
+		}
+		wb.pending[h32] = pw
+		order = append(order, h32)
+	}
+	// keep the original order in front of the newer writes
+	for _, h32 := range wb.order {
+		if !containsUint32(order, h32) {
+			order = append(order, h32)
+		}
+	}
+	wb.order = order
+}
+
+func containsUint32(s []uint32, v uint32) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,308 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ Storager = (*WriteBehindStorage)(nil)
+
+type batchStorage struct {
+	Storager
+	mu      sync.Mutex
+	batches []int
+	sets    int
+	err     error
+}
+
+func (bs *batchStorage) Set(key cfgpath.Path, value interface{}) error {
+	bs.mu.Lock()
+	bs.sets++
+	err := bs.err
+	bs.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return bs.Storager.Set(key, value)
+}
+
+func (bs *batchStorage) SetBatch(keys cfgpath.PathSlice, values []interface{}) error {
+	bs.mu.Lock()
+	bs.batches = append(bs.batches, len(keys))
+	err := bs.err
+	bs.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for i, k := range keys {
+		if err := bs.Storager.Set(k, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bs *batchStorage) setErr(err error) {
+	bs.mu.Lock()
+	bs.err = err
+	bs.mu.Unlock()
+}
+
+func TestWriteBehindStorage_Flush(t *testing.T) {
+	be := &batchStorage{Storager: NewInMemoryStore()}
+	wb := NewWriteBehindStorage(be, WithWriteBehindBatchSize(2))
+
+	p := cfgpath.MustNewByParts("web/secure/base_url")
+	require.NoError(t, wb.Set(p, "https://corestore.io"))
+	require.NoError(t, wb.Set(p.BindStore(2), "https://store2.corestore.io"))
+	require.NoError(t, wb.Set(p.BindStore(3), "https://store3.corestore.io"))
+	require.NoError(t, wb.Set(p, "https://www.corestore.io"))
+	assert.Exactly(t, 3, wb.Len())
+
+	_, err := be.Get(p)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+
+	v, err := wb.Get(p)
+	require.NoError(t, err)
+	assert.Exactly(t, "https://www.corestore.io", v)
+
+	keys, err := wb.AllKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 3)
+
+	require.NoError(t, wb.Flush())
+	assert.Exactly(t, 0, wb.Len())
+	assert.Exactly(t, []int{2, 1}, be.batches)
+	assert.Exactly(t, 0, be.sets)
+
+	v, err = be.Get(p)
+	require.NoError(t, err)
+	assert.Exactly(t, "https://www.corestore.io", v)
+	v, err = be.Get(p.BindStore(3))
+	require.NoError(t, err)
+	assert.Exactly(t, "https://store3.corestore.io", v)
+}
+
+func TestWriteBehindStorage_FlushError(t *testing.T) {
+	be := &batchStorage{Storager: NewInMemoryStore()}
+	wb := NewWriteBehindStorage(be, WithWriteBehindBatchSize(1))
+
+	p := cfgpath.MustNewByParts("web/unsecure/base_url")
+	require.NoError(t, wb.Set(p, "a"))
+	require.NoError(t, wb.Set(p.BindWebsite(1), "b"))
+
+	be.setErr(errors.NewConnectionFailedf("DB gone"))
+	err := wb.Flush()
+	assert.True(t, errors.IsConnectionFailed(err), "%+v", err)
+	assert.Exactly(t, 2, wb.Len())
+
+	// newer value wins over the requeued one
+	require.NoError(t, wb.Set(p, "c"))
+	be.setErr(nil)
+	require.NoError(t, wb.Flush())
+	assert.Exactly(t, 0, wb.Len())
+
+	v, err := be.Get(p)
+	require.NoError(t, err)
+	assert.Exactly(t, "c", v)
+}
+
+// slowStorage signals entered and blocks each Set until release gets closed.
+type slowStorage struct {
+	Storager
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (ss slowStorage) Set(key cfgpath.Path, value interface{}) error {
+	select {
+	case ss.entered <- struct{}{}:
+	default:
+	}
+	<-ss.release
+	return ss.Storager.Set(key, value)
+}
+
+func TestWriteBehindStorage_GetDuringFlush(t *testing.T) {
+	be := slowStorage{
+		Storager: NewInMemoryStore(),
+		entered:  make(chan struct{}, 1),
+		release:  make(chan struct{}),
+	}
+	wb := NewWriteBehindStorage(be)
+
+	p := cfgpath.MustNewByParts("web/cookie/cookie_lifetime")
+	require.NoError(t, wb.Set(p, 3600))
+
+	errc := make(chan error)
+	go func() { errc <- wb.Flush() }()
+	<-be.entered // backend Set is running
+
+	v, err := wb.Get(p)
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, 3600, v)
+	keys, err := wb.AllKeys()
+	require.NoError(t, err)
+	assert.Exactly(t, cfgpath.PathSlice{p}, keys)
+
+	close(be.release)
+	require.NoError(t, <-errc)
+	assert.Nil(t, wb.inflight)
+
+	v, err = wb.Get(p)
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, 3600, v)
+}
+
+func TestWriteBehindStorage_SetDuringFlush(t *testing.T) {
+	be := slowStorage{
+		Storager: NewInMemoryStore(),
+		entered:  make(chan struct{}, 1),
+		release:  make(chan struct{}),
+	}
+	wb := NewWriteBehindStorage(be, WithWriteBehindConflictHandler(nil))
+
+	p := cfgpath.MustNewByParts("web/cookie/cookie_lifetime")
+	require.NoError(t, wb.Set(p, 3600))
+
+	errc := make(chan error)
+	go func() { errc <- wb.Flush() }()
+	<-be.entered // backend Set is running
+
+	// our own in-flight write must not be detected as a conflict
+	require.NoError(t, wb.Set(p, 7200))
+	close(be.release)
+	require.NoError(t, <-errc)
+	require.NoError(t, wb.Flush())
+
+	v, err := be.Get(p)
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, 7200, v)
+}
+
+func TestWriteBehindStorage_Conflict(t *testing.T) {
+	p := cfgpath.MustNewByParts("general/locale/code").BindStore(1)
+
+	t.Run("discard", func(t *testing.T) {
+		be := NewInMemoryStore()
+		require.NoError(t, be.Set(p, "de_DE"))
+		wb := NewWriteBehindStorage(be, WithWriteBehindConflictHandler(nil))
+
+		require.NoError(t, wb.Set(p, "en_US"))
+		require.NoError(t, be.Set(p, "fr_FR")) // written by someone else
+
+		err := wb.Flush()
+		assert.True(t, errors.IsMismatch(err), "%+v", err)
+		v, err := be.Get(p)
+		require.NoError(t, err)
+		assert.Exactly(t, "fr_FR", v)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		be := NewInMemoryStore()
+		var got WriteConflict
+		wb := NewWriteBehindStorage(be, WithWriteBehindConflictHandler(func(wc WriteConflict) bool {
+			got = wc
+			return true
+		}))
+
+		require.NoError(t, wb.Set(p, "en_US"))
+		require.NoError(t, be.Set(p, "fr_FR"))
+
+		require.NoError(t, wb.Flush())
+		assert.Nil(t, got.Base)
+		assert.Exactly(t, "fr_FR", got.Current)
+		assert.Exactly(t, "en_US", got.Pending)
+		v, err := be.Get(p)
+		require.NoError(t, err)
+		assert.Exactly(t, "en_US", v)
+	})
+
+	t.Run("no conflict", func(t *testing.T) {
+		be := NewInMemoryStore()
+		require.NoError(t, be.Set(p, "de_DE"))
+		wb := NewWriteBehindStorage(be, WithWriteBehindConflictHandler(func(WriteConflict) bool {
+			t.Fatal("Should not get called")
+			return false
+		}))
+		require.NoError(t, wb.Set(p, "en_US"))
+		require.NoError(t, wb.Flush())
+		v, err := be.Get(p)
+		require.NoError(t, err)
+		assert.Exactly(t, "en_US", v)
+	})
+}
+
+func TestWriteBehindStorage_StartClose(t *testing.T) {
+	be := &batchStorage{Storager: NewInMemoryStore()}
+	wb := NewWriteBehindStorage(be,
+		WithWriteBehindBatchSize(2),
+		WithWriteBehindInterval(time.Hour),
+	).Start(func(err error) {
+		t.Errorf("%+v", err)
+	})
+
+	p := cfgpath.MustNewByParts("carriers/freeshipping/active")
+	require.NoError(t, wb.Set(p.BindStore(1), 1))
+	require.NoError(t, wb.Set(p.BindStore(2), 1)) // full batch triggers flush
+
+	for i := 0; i < 100 && wb.Len() > 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Exactly(t, 0, wb.Len())
+
+	require.NoError(t, wb.Set(p.BindStore(3), 0))
+	require.NoError(t, wb.Close())
+	require.NoError(t, wb.Close())
+	assert.Exactly(t, 0, wb.Len())
+
+	v, err := be.Get(p.BindStore(3))
+	require.NoError(t, err)
+	assert.Exactly(t, 0, v)
+
+	// after Close writes go directly to the backend
+	require.NoError(t, wb.Set(p.BindStore(4), 1))
+	assert.Exactly(t, 0, wb.Len())
+	assert.Exactly(t, 1, be.sets)
+}
+
+func TestWithWriteBehind(t *testing.T) {
+	be := NewInMemoryStore()
+	wb := NewWriteBehindStorage(nil)
+	s := MustNewService(be, WithWriteBehind(wb))
+
+	p := cfgpath.MustNewByParts("web/cookie/cookie_lifetime")
+	require.NoError(t, s.Write(p, 3600))
+
+	v, err := s.Int(p)
+	require.NoError(t, err)
+	assert.Exactly(t, 3600, v)
+
+	_, err = be.Get(p)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+
+	require.NoError(t, s.Close(), "Service.Close must flush the buffered values")
+	v2, err := be.Get(p)
+	require.NoError(t, err)
+	assert.Exactly(t, 3600, v2)
+}
@@ -158,7 +158,7 @@ type PkgBackend struct {
 	// GeneralLocaleWeightUnit => Weight Unit.
 	// Path: general/locale/weight_unit
 	// SourceModel: Magento\Directory\Model\Config\Source\WeightUnit
-	GeneralLocaleWeightUnit cfgmodel.Str
+	GeneralLocaleWeightUnit ConfigWeightUnit
 }
 
 // NewBackend initializes the global configuration models containing the
@@ -201,7 +201,7 @@ func (pp *PkgBackend) Load(cfgStruct element.SectionSlice) *PkgBackend {
 	pp.GeneralLocaleWeekend = cfgmodel.NewStringCSV(`general/locale/weekend`, opt)
 	pp.GeneralRegionStateRequired = cfgmodel.NewStringCSV(`general/region/state_required`, opt)
 	pp.GeneralRegionDisplayAll = cfgmodel.NewBool(`general/region/display_all`, opt)
-	pp.GeneralLocaleWeightUnit = cfgmodel.NewStr(`general/locale/weight_unit`, opt)
+	pp.GeneralLocaleWeightUnit = NewConfigWeightUnit(`general/locale/weight_unit`, opt)
 
 	return pp
 }
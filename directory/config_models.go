@@ -17,6 +17,7 @@ package directory
 import (
 	"github.com/corestoreio/csfw/config"
 	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/csfw/i18n"
 	"github.com/corestoreio/csfw/store/scope"
 	"github.com/juju/errors"
 	"golang.org/x/text/currency"
@@ -76,3 +77,47 @@ func (cc ConfigCurrency) Write(w config.Writer, v Currency, s scope.Type, id int
 	}
 	return cc.Str.Write(w, cur, s, id)
 }
+
+// ConfigWeightUnit weight unit type for the configuration. Parses the Magento
+// values kgs and lbs of the path general/locale/weight_unit into an i18n.Unit.
+type ConfigWeightUnit struct {
+	cfgmodel.Str
+}
+
+// NewConfigWeightUnit creates a new weight unit configuration type.
+func NewConfigWeightUnit(path string, opts ...cfgmodel.Option) ConfigWeightUnit {
+	return ConfigWeightUnit{
+		Str: cfgmodel.NewStr(path, opts...),
+	}
+}
+
+// Get returns the weight unit considering the scope. An empty value falls
+// back to pounds, the Magento default.
+func (cw ConfigWeightUnit) Get(sg config.Scoped) (i18n.Unit, error) {
+	raw, err := cw.Str.Get(sg)
+	if err != nil {
+		return i18n.UnitUnknown, errors.Mask(err)
+	}
+	if raw == "" {
+		return i18n.UnitPound, nil
+	}
+	u, err := i18n.ParseUnit(raw)
+	if err != nil {
+		return i18n.UnitUnknown, errors.Mask(err)
+	}
+	if !u.IsMass() {
+		scp, scpID := sg.ScopeID()
+		return i18n.UnitUnknown, errors.Errorf("Unit %q is not a weight unit for path: %q, scope: %q, scopeID: %d", raw, cw.String(), scp, scpID)
+	}
+	return u, nil
+}
+
+// GetLength returns the unit for product dimensions matching the configured
+// weight unit: inch for lbs and centimeter for kgs.
+func (cw ConfigWeightUnit) GetLength(sg config.Scoped) (i18n.Unit, error) {
+	u, err := cw.Get(sg)
+	if err != nil {
+		return i18n.UnitUnknown, errors.Mask(err)
+	}
+	return u.LengthUnit(), nil
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/corestoreio/csfw/config/cfgmodel"
 	"github.com/corestoreio/csfw/config/cfgpath"
 	"github.com/corestoreio/csfw/directory"
+	"github.com/corestoreio/csfw/i18n"
 	"github.com/corestoreio/csfw/store/scope"
 	"github.com/stretchr/testify/assert"
 )
@@ -149,3 +150,46 @@ func TestNewConfigCurrencyWrite(t *testing.T) {
 		"The value 'XXX' cannot be found within the allowed Options():\n[{\"Value\":\"EUR\",\"Label\":\"Euro\"},{\"Value\":\"CHF\",\"Label\":\"Swiss Franc\"},{\"Value\":\"AUD\",\"Label\":\"Australian Dinar ;-)\"}]\n",
 	)
 }
+
+func TestConfigWeightUnitGet(t *testing.T) {
+	t.Parallel()
+
+	wuPath, err := backend.GeneralLocaleWeightUnit.ToPath(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cr := cfgmock.NewService(cfgmock.PathValue{
+		wuPath.Bind(scope.Store, 1).String(): "kgs",
+		wuPath.Bind(scope.Store, 2).String(): "lbs",
+		wuPath.Bind(scope.Store, 3).String(): "cm",
+		wuPath.Bind(scope.Store, 4).String(): "stones",
+	})
+
+	tests := []struct {
+		storeID    int64
+		wantWeight i18n.Unit
+		wantLength i18n.Unit
+		wantErr    bool
+	}{
+		{1, i18n.UnitKilogram, i18n.UnitCentimeter, false},
+		{2, i18n.UnitPound, i18n.UnitInch, false},
+		{3, i18n.UnitUnknown, i18n.UnitUnknown, true},
+		{4, i18n.UnitUnknown, i18n.UnitUnknown, true},
+		{5, i18n.UnitPound, i18n.UnitInch, false}, // default lbs
+	}
+	for _, test := range tests {
+		sg := cr.NewScoped(1, test.storeID)
+		wu, err := backend.GeneralLocaleWeightUnit.Get(sg)
+		lu, lErr := backend.GeneralLocaleWeightUnit.GetLength(sg)
+		if test.wantErr {
+			assert.Error(t, err, "Store %d", test.storeID)
+			assert.Error(t, lErr, "Store %d", test.storeID)
+		} else {
+			assert.NoError(t, err, "Store %d", test.storeID)
+			assert.NoError(t, lErr, "Store %d", test.storeID)
+		}
+		assert.Exactly(t, test.wantWeight, wu, "Store %d", test.storeID)
+		assert.Exactly(t, test.wantLength, lu, "Store %d", test.storeID)
+	}
+}
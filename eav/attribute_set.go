@@ -0,0 +1,394 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eav
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+)
+
+// Table names used by the attribute set and group API.
+const (
+	TableNameEntityType      = "eav_entity_type"
+	TableNameAttributeSet    = "eav_attribute_set"
+	TableNameAttributeGroup  = "eav_attribute_group"
+	TableNameEntityAttribute = "eav_entity_attribute"
+)
+
+// maxNameLength defines the maximum length of the set and group names as
+// defined in the varchar(255) columns.
+const maxNameLength = 255
+
+// AttributeSet represents a row of table eav_attribute_set. An attribute set
+// groups the attributes of an entity type, for example all attributes of a
+// T-Shirt product.
+type AttributeSet struct {
+	AttributeSetID   int64  `db:"attribute_set_id"`
+	EntityTypeID     int64  `db:"entity_type_id"`
+	AttributeSetName string `db:"attribute_set_name"`
+	SortOrder        int64  `db:"sort_order"`
+}
+
+// Validate checks the fields before saving. Errors have the behaviour Empty
+// or NotValid.
+func (s *AttributeSet) Validate() error {
+	if s.EntityTypeID < 1 {
+		return errors.NewNotValidf("[eav] AttributeSet %q: Invalid EntityTypeID %d", s.AttributeSetName, s.EntityTypeID)
+	}
+	if err := validateName(s.AttributeSetName); err != nil {
+		return errors.Wrapf(err, "[eav] AttributeSet ID %d", s.AttributeSetID)
+	}
+	if s.SortOrder < 0 {
+		return errors.NewNotValidf("[eav] AttributeSet %q: Negative SortOrder %d", s.AttributeSetName, s.SortOrder)
+	}
+	return nil
+}
+
+// AttributeSets a list of attribute sets.
+type AttributeSets []*AttributeSet
+
+// ByID returns an attribute set by its ID or a NotFound error.
+func (as AttributeSets) ByID(id int64) (*AttributeSet, error) {
+	for _, s := range as {
+		if s.AttributeSetID == id {
+			return s, nil
+		}
+	}
+	return nil, errors.NewNotFoundf("[eav] AttributeSet ID %d not found", id)
+}
+
+// ByName returns an attribute set by its name or a NotFound error. Names are
+// unique per entity type.
+func (as AttributeSets) ByName(entityTypeID int64, name string) (*AttributeSet, error) {
+	for _, s := range as {
+		if s.EntityTypeID == entityTypeID && s.AttributeSetName == name {
+			return s, nil
+		}
+	}
+	return nil, errors.NewNotFoundf("[eav] AttributeSet %q for entity type %d not found", name, entityTypeID)
+}
+
+// AttributeGroup represents a row of table eav_attribute_group. A group
+// defines a section within an attribute set. The fields AttributeGroupCode and
+// TabGroupCode are only available in Magento 2 and only get written when not
+// empty.
+type AttributeGroup struct {
+	AttributeGroupID   int64  `db:"attribute_group_id"`
+	AttributeSetID     int64  `db:"attribute_set_id"`
+	AttributeGroupName string `db:"attribute_group_name"`
+	SortOrder          int64  `db:"sort_order"`
+	// DefaultID is 1 when the group is the default group of the set.
+	// Attributes without a group get assigned to the default group.
+	DefaultID          int64  `db:"default_id"`
+	AttributeGroupCode string `db:"attribute_group_code"`
+	TabGroupCode       string `db:"tab_group_code"`
+}
+
+// IsDefault returns true if the group is the default group of its set.
+func (g *AttributeGroup) IsDefault() bool {
+	return g.DefaultID == 1
+}
+
+// Validate checks the fields before saving. Errors have the behaviour Empty
+// or NotValid.
+func (g *AttributeGroup) Validate() error {
+	if g.AttributeSetID < 1 {
+		return errors.NewNotValidf("[eav] AttributeGroup %q: Invalid AttributeSetID %d", g.AttributeGroupName, g.AttributeSetID)
+	}
+	if err := validateName(g.AttributeGroupName); err != nil {
+		return errors.Wrapf(err, "[eav] AttributeGroup ID %d", g.AttributeGroupID)
+	}
+	if g.SortOrder < 0 {
+		return errors.NewNotValidf("[eav] AttributeGroup %q: Negative SortOrder %d", g.AttributeGroupName, g.SortOrder)
+	}
+	if g.DefaultID != 0 && g.DefaultID != 1 {
+		return errors.NewNotValidf("[eav] AttributeGroup %q: DefaultID must be 0 or 1, have %d", g.AttributeGroupName, g.DefaultID)
+	}
+	return nil
+}
+
+// AttributeGroupCodeFromName generates the group code like Magento 2 does: the
+// name gets converted to lower case and each run of characters other than a-z
+// and 0-9 gets replaced by a single dash.
+func AttributeGroupCodeFromName(name string) string {
+	var buf bytes.Buffer
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && buf.Len() > 0 {
+				buf.WriteByte('-')
+			}
+			buf.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return buf.String()
+}
+
+// AttributeGroups a list of attribute groups.
+type AttributeGroups []*AttributeGroup
+
+// BySet returns all groups of an attribute set.
+func (ag AttributeGroups) BySet(attributeSetID int64) AttributeGroups {
+	var ret AttributeGroups
+	for _, g := range ag {
+		if g.AttributeSetID == attributeSetID {
+			ret = append(ret, g)
+		}
+	}
+	return ret
+}
+
+// Default returns the default group of an attribute set or a NotFound error.
+func (ag AttributeGroups) Default(attributeSetID int64) (*AttributeGroup, error) {
+	for _, g := range ag {
+		if g.AttributeSetID == attributeSetID && g.IsDefault() {
+			return g, nil
+		}
+	}
+	return nil, errors.NewNotFoundf("[eav] Default AttributeGroup for set %d not found", attributeSetID)
+}
+
+// Sort sorts the groups by set, sort order and ID.
+func (ag AttributeGroups) Sort() AttributeGroups {
+	sort.Slice(ag, func(i, j int) bool {
+		switch a, b := ag[i], ag[j]; {
+		case a.AttributeSetID != b.AttributeSetID:
+			return a.AttributeSetID < b.AttributeSetID
+		case a.SortOrder != b.SortOrder:
+			return a.SortOrder < b.SortOrder
+		default:
+			return a.AttributeGroupID < b.AttributeGroupID
+		}
+	})
+	return ag
+}
+
+func validateName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return errors.NewEmptyf("[eav] Name cannot be empty")
+	case utf8.RuneCountInString(name) > maxNameLength:
+		return errors.NewNotValidf("[eav] Name %q exceeds %d characters", name, maxNameLength)
+	}
+	return nil
+}
+
+// LoadAttributeSets loads all attribute sets of an entity type ordered by sort
+// order and name.
+func LoadAttributeSets(ctx context.Context, db dbr.Querier, entityTypeID int64) (AttributeSets, error) {
+	sb := dbr.NewSelect("*").From(TableNameAttributeSet).
+		Where(dbr.Condition("entity_type_id", dbr.ArgInt64(entityTypeID))).
+		OrderBy("sort_order", "attribute_set_name")
+	sb.DB.Querier = db
+	var as AttributeSets
+	if _, err := sb.LoadStructs(ctx, &as); err != nil {
+		return nil, errors.Wrapf(err, "[eav] LoadAttributeSets for entity type %d", entityTypeID)
+	}
+	return as, nil
+}
+
+// LoadAttributeGroups loads the groups of the provided attribute sets ordered
+// by set and sort order.
+func LoadAttributeGroups(ctx context.Context, db dbr.Querier, attributeSetIDs ...int64) (AttributeGroups, error) {
+	if len(attributeSetIDs) == 0 {
+		return nil, errors.NewEmptyf("[eav] LoadAttributeGroups: Attribute set IDs missing")
+	}
+	sb := dbr.NewSelect("*").From(TableNameAttributeGroup).
+		Where(dbr.Condition("attribute_set_id", dbr.ArgInt64(attributeSetIDs...).Operator(dbr.In))).
+		OrderBy("attribute_set_id", "sort_order", "attribute_group_id")
+	sb.DB.Querier = db
+	var ag AttributeGroups
+	if _, err := sb.LoadStructs(ctx, &ag); err != nil {
+		return nil, errors.Wrapf(err, "[eav] LoadAttributeGroups for sets %v", attributeSetIDs)
+	}
+	return ag, nil
+}
+
+// SaveAttributeSet validates and inserts or updates an attribute set. A new
+// set (ID zero) gets its ID assigned and, when the SortOrder is zero, it gets
+// appended to the end of the sets of its entity type. The entity type of an
+// existing set cannot be changed.
+func SaveAttributeSet(ctx context.Context, db dbr.DBer, s *AttributeSet) error {
+	if err := s.Validate(); err != nil {
+		return errors.Wrap(err, "[eav] SaveAttributeSet.Validate")
+	}
+
+	if s.AttributeSetID > 0 {
+		up := dbr.NewUpdate(TableNameAttributeSet).
+			Set("attribute_set_name", dbr.ArgString(s.AttributeSetName)).
+			Set("sort_order", dbr.ArgInt64(s.SortOrder)).
+			Where(dbr.Condition("attribute_set_id", dbr.ArgInt64(s.AttributeSetID)))
+		up.DB.Execer = db
+		_, err := up.Exec(ctx)
+		return errors.Wrapf(err, "[eav] SaveAttributeSet.Update ID %d", s.AttributeSetID)
+	}
+
+	if s.SortOrder == 0 {
+		so, err := nextSortOrder(ctx, db, TableNameAttributeSet, "entity_type_id", s.EntityTypeID)
+		if err != nil {
+			return errors.Wrap(err, "[eav] SaveAttributeSet.nextSortOrder")
+		}
+		s.SortOrder = so
+	}
+
+	ins := dbr.NewInsert(TableNameAttributeSet).
+		AddColumns("entity_type_id", "attribute_set_name", "sort_order").
+		AddValues(dbr.ArgInt64(s.EntityTypeID), dbr.ArgString(s.AttributeSetName), dbr.ArgInt64(s.SortOrder))
+	ins.DB.Execer = db
+	id, err := execInsert(ctx, ins)
+	if err != nil {
+		return errors.Wrapf(err, "[eav] SaveAttributeSet.Insert %q", s.AttributeSetName)
+	}
+	s.AttributeSetID = id
+	return nil
+}
+
+// DeleteAttributeSet deletes an attribute set including its groups and
+// attribute assignments via foreign keys. The default attribute set of an
+// entity type, see eav_entity_type.default_attribute_set_id, cannot be deleted
+// and returns a NotSupported error.
+func DeleteAttributeSet(ctx context.Context, db dbr.DBer, s *AttributeSet) error {
+	var defaultSetID int64
+	err := db.QueryRowContext(ctx,
+		"SELECT `default_attribute_set_id` FROM `"+TableNameEntityType+"` WHERE `entity_type_id` = ?",
+		s.EntityTypeID).Scan(&defaultSetID)
+	if err != nil {
+		return errors.Wrapf(err, "[eav] DeleteAttributeSet.DefaultAttributeSetID for entity type %d", s.EntityTypeID)
+	}
+	if defaultSetID == s.AttributeSetID {
+		return errors.NewNotSupportedf("[eav] AttributeSet %q (ID %d) is the default set of entity type %d and cannot be deleted", s.AttributeSetName, s.AttributeSetID, s.EntityTypeID)
+	}
+
+	del := dbr.NewDelete(TableNameAttributeSet).
+		Where(dbr.Condition("attribute_set_id", dbr.ArgInt64(s.AttributeSetID)))
+	del.DB.Execer = db
+	_, err = del.Exec(ctx)
+	return errors.Wrapf(err, "[eav] DeleteAttributeSet ID %d", s.AttributeSetID)
+}
+
+// SaveAttributeGroup validates and inserts or updates an attribute group. A
+// new group (ID zero) gets its ID assigned and, when the SortOrder is zero,
+// appended to the end of the groups of its set. Saving a default group resets
+// the default flag of all other groups in the same set.
+func SaveAttributeGroup(ctx context.Context, db dbr.DBer, g *AttributeGroup) error {
+	if err := g.Validate(); err != nil {
+		return errors.Wrap(err, "[eav] SaveAttributeGroup.Validate")
+	}
+
+	if g.AttributeGroupID > 0 {
+		up := dbr.NewUpdate(TableNameAttributeGroup).
+			Set("attribute_group_name", dbr.ArgString(g.AttributeGroupName)).
+			Set("sort_order", dbr.ArgInt64(g.SortOrder)).
+			Set("default_id", dbr.ArgInt64(g.DefaultID))
+		if g.AttributeGroupCode != "" {
+			up.Set("attribute_group_code", dbr.ArgString(g.AttributeGroupCode))
+		}
+		if g.TabGroupCode != "" {
+			up.Set("tab_group_code", dbr.ArgString(g.TabGroupCode))
+		}
+		up.Where(dbr.Condition("attribute_group_id", dbr.ArgInt64(g.AttributeGroupID)))
+		up.DB.Execer = db
+		if _, err := up.Exec(ctx); err != nil {
+			return errors.Wrapf(err, "[eav] SaveAttributeGroup.Update ID %d", g.AttributeGroupID)
+		}
+		return errors.Wrap(resetDefaultGroup(ctx, db, g), "[eav] SaveAttributeGroup.resetDefaultGroup")
+	}
+
+	if g.SortOrder == 0 {
+		so, err := nextSortOrder(ctx, db, TableNameAttributeGroup, "attribute_set_id", g.AttributeSetID)
+		if err != nil {
+			return errors.Wrap(err, "[eav] SaveAttributeGroup.nextSortOrder")
+		}
+		g.SortOrder = so
+	}
+
+	ins := dbr.NewInsert(TableNameAttributeGroup).
+		AddColumns("attribute_set_id", "attribute_group_name", "sort_order", "default_id")
+	vals := []dbr.Argument{dbr.ArgInt64(g.AttributeSetID), dbr.ArgString(g.AttributeGroupName), dbr.ArgInt64(g.SortOrder), dbr.ArgInt64(g.DefaultID)}
+	if g.AttributeGroupCode != "" {
+		ins.AddColumns("attribute_group_code")
+		vals = append(vals, dbr.ArgString(g.AttributeGroupCode))
+	}
+	if g.TabGroupCode != "" {
+		ins.AddColumns("tab_group_code")
+		vals = append(vals, dbr.ArgString(g.TabGroupCode))
+	}
+	ins.AddValues(vals...)
+	ins.DB.Execer = db
+	id, err := execInsert(ctx, ins)
+	if err != nil {
+		return errors.Wrapf(err, "[eav] SaveAttributeGroup.Insert %q", g.AttributeGroupName)
+	}
+	g.AttributeGroupID = id
+	return errors.Wrap(resetDefaultGroup(ctx, db, g), "[eav] SaveAttributeGroup.resetDefaultGroup")
+}
+
+// DeleteAttributeGroup deletes an attribute group. The attribute assignments
+// of the group get removed via foreign keys. The default group of a set cannot
+// be deleted and returns a NotSupported error.
+func DeleteAttributeGroup(ctx context.Context, db dbr.Execer, g *AttributeGroup) error {
+	if g.IsDefault() {
+		return errors.NewNotSupportedf("[eav] AttributeGroup %q (ID %d) is the default group of set %d and cannot be deleted", g.AttributeGroupName, g.AttributeGroupID, g.AttributeSetID)
+	}
+	del := dbr.NewDelete(TableNameAttributeGroup).
+		Where(dbr.Condition("attribute_group_id", dbr.ArgInt64(g.AttributeGroupID)))
+	del.DB.Execer = db
+	_, err := del.Exec(ctx)
+	return errors.Wrapf(err, "[eav] DeleteAttributeGroup ID %d", g.AttributeGroupID)
+}
+
+// resetDefaultGroup makes sure that a set has only one default group.
+func resetDefaultGroup(ctx context.Context, db dbr.Execer, g *AttributeGroup) error {
+	if !g.IsDefault() {
+		return nil
+	}
+	up := dbr.NewUpdate(TableNameAttributeGroup).
+		Set("default_id", dbr.ArgInt64(0)).
+		Where(
+			dbr.Condition("attribute_set_id", dbr.ArgInt64(g.AttributeSetID)),
+			dbr.Condition("attribute_group_id", dbr.ArgInt64(g.AttributeGroupID).Operator(dbr.NotEqual)),
+		)
+	up.DB.Execer = db
+	_, err := up.Exec(ctx)
+	return err
+}
+
+// nextSortOrder returns the highest sort order plus one of all rows matching
+// the parent column.
+func nextSortOrder(ctx context.Context, db dbr.QueryRower, table, parentColumn string, parentID int64) (int64, error) {
+	var so int64
+	err := db.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(`sort_order`),0)+1 FROM `"+table+"` WHERE `"+parentColumn+"` = ?",
+		parentID).Scan(&so)
+	return so, errors.Wrapf(err, "[eav] nextSortOrder for %s.%s = %d", table, parentColumn, parentID)
+}
+
+func execInsert(ctx context.Context, ins *dbr.Insert) (int64, error) {
+	res, err := ins.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return id, errors.Wrap(err, "[eav] LastInsertId")
+}
@@ -0,0 +1,242 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eav_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/eav"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributeSet_Validate(t *testing.T) {
+	tests := []struct {
+		set     eav.AttributeSet
+		wantErr errors.BehaviourFunc
+	}{
+		{eav.AttributeSet{EntityTypeID: 4, AttributeSetName: "Default"}, nil},
+		{eav.AttributeSet{EntityTypeID: 0, AttributeSetName: "Default"}, errors.IsNotValid},
+		{eav.AttributeSet{EntityTypeID: 4, AttributeSetName: " "}, errors.IsEmpty},
+		{eav.AttributeSet{EntityTypeID: 4, AttributeSetName: strings.Repeat("ä", 256)}, errors.IsNotValid},
+		{eav.AttributeSet{EntityTypeID: 4, AttributeSetName: "Shirts", SortOrder: -1}, errors.IsNotValid},
+	}
+	for i, test := range tests {
+		err := test.set.Validate()
+		if test.wantErr == nil {
+			assert.NoError(t, err, "Index %d", i)
+			continue
+		}
+		assert.True(t, test.wantErr(err), "Index %d: %+v", i, err)
+	}
+}
+
+func TestAttributeGroup_Validate(t *testing.T) {
+	tests := []struct {
+		group   eav.AttributeGroup
+		wantErr errors.BehaviourFunc
+	}{
+		{eav.AttributeGroup{AttributeSetID: 4, AttributeGroupName: "General", DefaultID: 1}, nil},
+		{eav.AttributeGroup{AttributeSetID: 0, AttributeGroupName: "General"}, errors.IsNotValid},
+		{eav.AttributeGroup{AttributeSetID: 4}, errors.IsEmpty},
+		{eav.AttributeGroup{AttributeSetID: 4, AttributeGroupName: "Prices", SortOrder: -3}, errors.IsNotValid},
+		{eav.AttributeGroup{AttributeSetID: 4, AttributeGroupName: "Prices", DefaultID: 2}, errors.IsNotValid},
+	}
+	for i, test := range tests {
+		err := test.group.Validate()
+		if test.wantErr == nil {
+			assert.NoError(t, err, "Index %d", i)
+			continue
+		}
+		assert.True(t, test.wantErr(err), "Index %d: %+v", i, err)
+	}
+}
+
+func TestAttributeGroupCodeFromName(t *testing.T) {
+	assert.Exactly(t, "search-engine-optimization", eav.AttributeGroupCodeFromName("Search Engine Optimization"))
+	assert.Exactly(t, "images-2", eav.AttributeGroupCodeFromName(" Images & 2 "))
+}
+
+func TestAttributeGroups(t *testing.T) {
+	ag := eav.AttributeGroups{
+		{AttributeGroupID: 3, AttributeSetID: 2, SortOrder: 1, DefaultID: 1},
+		{AttributeGroupID: 2, AttributeSetID: 1, SortOrder: 2},
+		{AttributeGroupID: 1, AttributeSetID: 1, SortOrder: 2, DefaultID: 1},
+		{AttributeGroupID: 4, AttributeSetID: 1, SortOrder: 1},
+	}.Sort()
+
+	var ids []int64
+	for _, g := range ag {
+		ids = append(ids, g.AttributeGroupID)
+	}
+	assert.Exactly(t, []int64{4, 1, 2, 3}, ids)
+	assert.Len(t, ag.BySet(1), 3)
+
+	g, err := ag.Default(2)
+	require.NoError(t, err)
+	assert.Exactly(t, int64(3), g.AttributeGroupID)
+	_, err = ag.Default(5)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+}
+
+func TestLoadAttributeSets(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT * FROM `eav_attribute_set` WHERE (`entity_type_id` = 4) ORDER BY sort_order, attribute_set_name")).
+		WillReturnRows(sqlmock.NewRows([]string{"attribute_set_id", "entity_type_id", "attribute_set_name", "sort_order"}).
+			AddRow(4, 4, "Default", 1).AddRow(9, 4, "Shirts", 2))
+
+	as, err := eav.LoadAttributeSets(context.TODO(), dbc.DB, 4)
+	require.NoError(t, err, "%+v", err)
+	require.Len(t, as, 2)
+	s, err := as.ByName(4, "Shirts")
+	require.NoError(t, err)
+	assert.Exactly(t, &eav.AttributeSet{AttributeSetID: 9, EntityTypeID: 4, AttributeSetName: "Shirts", SortOrder: 2}, s)
+	_, err = as.ByID(10)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+
+	dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT * FROM `eav_attribute_group` WHERE (`attribute_set_id` IN (4,9)) ORDER BY attribute_set_id, sort_order, attribute_group_id")).
+		WillReturnRows(sqlmock.NewRows([]string{"attribute_group_id", "attribute_set_id", "attribute_group_name", "sort_order", "default_id"}).
+			AddRow(7, 4, "General", 1, 1).AddRow(8, 9, "General", 1, 1))
+	ag, err := eav.LoadAttributeGroups(context.TODO(), dbc.DB, 4, 9)
+	require.NoError(t, err, "%+v", err)
+	assert.Len(t, ag, 2)
+
+	_, err = eav.LoadAttributeGroups(context.TODO(), dbc.DB)
+	assert.True(t, errors.IsEmpty(err), "%+v", err)
+}
+
+func TestSaveAttributeSet(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	t.Run("insert with next sort order", func(t *testing.T) {
+		dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT COALESCE(MAX(`sort_order`),0)+1 FROM `eav_attribute_set` WHERE `entity_type_id` = ?")).
+			WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"so"}).AddRow(3))
+		dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("INSERT INTO `eav_attribute_set` (`entity_type_id`,`attribute_set_name`,`sort_order`) VALUES (4,'Shirts',3)")).
+			WillReturnResult(sqlmock.NewResult(11, 1))
+
+		s := &eav.AttributeSet{EntityTypeID: 4, AttributeSetName: "Shirts"}
+		require.NoError(t, eav.SaveAttributeSet(context.TODO(), dbc.DB, s))
+		assert.Exactly(t, int64(11), s.AttributeSetID)
+		assert.Exactly(t, int64(3), s.SortOrder)
+	})
+	t.Run("update", func(t *testing.T) {
+		dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("UPDATE `eav_attribute_set` SET `attribute_set_name`='Shirts & Tops', `sort_order`=5 WHERE (`attribute_set_id` = 11)")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		s := &eav.AttributeSet{AttributeSetID: 11, EntityTypeID: 4, AttributeSetName: "Shirts & Tops", SortOrder: 5}
+		require.NoError(t, eav.SaveAttributeSet(context.TODO(), dbc.DB, s))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		err := eav.SaveAttributeSet(context.TODO(), dbc.DB, &eav.AttributeSet{EntityTypeID: 4})
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+	})
+}
+
+func TestDeleteAttributeSet(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	defaultSetQuery := cstesting.SQLMockQuoteMeta("SELECT `default_attribute_set_id` FROM `eav_entity_type` WHERE `entity_type_id` = ?")
+
+	dbMock.ExpectQuery(defaultSetQuery).WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"default_attribute_set_id"}).AddRow(4))
+	err := eav.DeleteAttributeSet(context.TODO(), dbc.DB, &eav.AttributeSet{AttributeSetID: 4, EntityTypeID: 4, AttributeSetName: "Default"})
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+
+	dbMock.ExpectQuery(defaultSetQuery).WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"default_attribute_set_id"}).AddRow(4))
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("DELETE FROM `eav_attribute_set` WHERE (`attribute_set_id` = 11)")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, eav.DeleteAttributeSet(context.TODO(), dbc.DB, &eav.AttributeSet{AttributeSetID: 11, EntityTypeID: 4, AttributeSetName: "Shirts"}))
+}
+
+func TestSaveAttributeGroup(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	t.Run("insert default group", func(t *testing.T) {
+		dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("INSERT INTO `eav_attribute_group` (`attribute_set_id`,`attribute_group_name`,`sort_order`,`default_id`,`attribute_group_code`) VALUES (11,'Product Details',1,1,'product-details')")).
+			WillReturnResult(sqlmock.NewResult(21, 1))
+		dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("UPDATE `eav_attribute_group` SET `default_id`=0 WHERE (`attribute_set_id` = 11) AND (`attribute_group_id` != 21)")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		g := &eav.AttributeGroup{
+			AttributeSetID:     11,
+			AttributeGroupName: "Product Details",
+			SortOrder:          1,
+			DefaultID:          1,
+			AttributeGroupCode: eav.AttributeGroupCodeFromName("Product Details"),
+		}
+		require.NoError(t, eav.SaveAttributeGroup(context.TODO(), dbc.DB, g))
+		assert.Exactly(t, int64(21), g.AttributeGroupID)
+	})
+	t.Run("update with next sort order", func(t *testing.T) {
+		dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("UPDATE `eav_attribute_group` SET `attribute_group_name`='Prices', `sort_order`=4, `default_id`=0 WHERE (`attribute_group_id` = 22)")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		g := &eav.AttributeGroup{AttributeGroupID: 22, AttributeSetID: 11, AttributeGroupName: "Prices", SortOrder: 4}
+		require.NoError(t, eav.SaveAttributeGroup(context.TODO(), dbc.DB, g))
+	})
+	t.Run("insert with next sort order", func(t *testing.T) {
+		dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT COALESCE(MAX(`sort_order`),0)+1 FROM `eav_attribute_group` WHERE `attribute_set_id` = ?")).
+			WithArgs(11).WillReturnRows(sqlmock.NewRows([]string{"so"}).AddRow(5))
+		dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("INSERT INTO `eav_attribute_group` (`attribute_set_id`,`attribute_group_name`,`sort_order`,`default_id`) VALUES (11,'Images',5,0)")).
+			WillReturnResult(sqlmock.NewResult(23, 1))
+
+		g := &eav.AttributeGroup{AttributeSetID: 11, AttributeGroupName: "Images"}
+		require.NoError(t, eav.SaveAttributeGroup(context.TODO(), dbc.DB, g))
+		assert.Exactly(t, int64(5), g.SortOrder)
+	})
+}
+
+func TestDeleteAttributeGroup(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	err := eav.DeleteAttributeGroup(context.TODO(), dbc.DB, &eav.AttributeGroup{AttributeGroupID: 21, AttributeSetID: 11, DefaultID: 1})
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("DELETE FROM `eav_attribute_group` WHERE (`attribute_group_id` = 22)")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, eav.DeleteAttributeGroup(context.TODO(), dbc.DB, &eav.AttributeGroup{AttributeGroupID: 22, AttributeSetID: 11}))
+}
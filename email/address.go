@@ -0,0 +1,338 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"context"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/corestoreio/errors"
+)
+
+// Length limits of RFC 5321 section 4.5.3.1. An address can be at most 254
+// octets long because the path including the angle brackets must not exceed
+// 256 octets.
+const (
+	maxLocalPartLength = 64
+	maxDomainLength    = 253
+	maxLabelLength     = 63
+	maxAddressLength   = 254
+)
+
+// NormalizeAddress checks the syntax of an email address according to RFC 5321
+// and RFC 5322 and returns the address in its normalized form. The domain gets
+// lower cased and an internationalized domain gets converted into punycode.
+// The local part stays untouched because it might be case sensitive. A display
+// name like "Gopher <gopher@example.com>" gets removed. Domain literals like
+// user@[192.0.2.1] are supported, comments and obsolete syntax are not. UTF-8
+// in the local part is allowed as defined in RFC 6532. Error behaviour:
+// NotValid
+func NormalizeAddress(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if strings.ContainsRune(addr, '<') {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return "", errors.NewNotValid(err, "[email] NormalizeAddress %q", addr)
+		}
+		addr = a.Address
+	}
+
+	at := strings.LastIndexByte(addr, '@')
+	if at < 1 || at == len(addr)-1 {
+		return "", errors.NewNotValidf("[email] NormalizeAddress: Address %q must contain a local part and a domain", addr)
+	}
+	local, domain := addr[:at], addr[at+1:]
+
+	if err := validateLocalPart(local); err != nil {
+		return "", errors.Wrapf(err, "[email] NormalizeAddress %q", addr)
+	}
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return "", errors.Wrapf(err, "[email] NormalizeAddress %q", addr)
+	}
+	if l := len(local) + 1 + len(domain); l > maxAddressLength {
+		return "", errors.NewNotValidf("[email] NormalizeAddress: Address %q exceeds %d octets", addr, maxAddressLength)
+	}
+	return local + "@" + domain, nil
+}
+
+// IsValidAddress returns true if the syntax of the address is valid. See
+// NormalizeAddress.
+func IsValidAddress(addr string) bool {
+	_, err := NormalizeAddress(addr)
+	return err == nil
+}
+
+// isAtext reports whether c is allowed in a dot-atom of RFC 5322 section
+// 3.2.3. Bytes of UTF-8 sequences are allowed, see RFC 6532.
+func isAtext(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c >= utf8.RuneSelf:
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-/=?^_`{|}~", c) >= 0
+}
+
+func validateLocalPart(local string) error {
+	if len(local) > maxLocalPartLength {
+		return errors.NewNotValidf("[email] Local part exceeds %d octets", maxLocalPartLength)
+	}
+	if !utf8.ValidString(local) {
+		return errors.NewNotValidf("[email] Local part contains invalid UTF-8")
+	}
+
+	if local[0] == '"' {
+		// quoted-string of RFC 5322 section 3.2.4
+		if len(local) < 2 || local[len(local)-1] != '"' {
+			return errors.NewNotValidf("[email] Quoted local part %q is not terminated", local)
+		}
+		for i := 1; i < len(local)-1; i++ {
+			switch c := local[i]; {
+			case c == '\\':
+				i++
+				if i == len(local)-1 || local[i] < ' ' || local[i] == 0x7f {
+					return errors.NewNotValidf("[email] Invalid quoted pair in local part %q", local)
+				}
+			case c == '"', c < ' ', c == 0x7f:
+				return errors.NewNotValidf("[email] Invalid character %q in quoted local part", c)
+			}
+		}
+		return nil
+	}
+
+	// dot-atom
+	for i := 0; i < len(local); i++ {
+		c := local[i]
+		if c == '.' {
+			if i == 0 || i == len(local)-1 || local[i-1] == '.' {
+				return errors.NewNotValidf("[email] Misplaced dot in local part %q", local)
+			}
+			continue
+		}
+		if !isAtext(c) {
+			return errors.NewNotValidf("[email] Invalid character %q in local part %q", c, local)
+		}
+	}
+	return nil
+}
+
+// normalizeDomain validates a domain or a domain literal and returns the lower
+// cased ASCII form.
+func normalizeDomain(domain string) (string, error) {
+	if strings.HasPrefix(domain, "[") && strings.HasSuffix(domain, "]") {
+		lit := domain[1 : len(domain)-1]
+		if len(lit) > 5 && strings.EqualFold(lit[:5], "IPv6:") {
+			if ip := net.ParseIP(lit[5:]); ip != nil && ip.To4() == nil {
+				return "[IPv6:" + ip.String() + "]", nil
+			}
+		} else if ip := net.ParseIP(lit); ip != nil && ip.To4() != nil && !strings.Contains(lit, ":") {
+			return "[" + ip.String() + "]", nil
+		}
+		return "", errors.NewNotValidf("[email] Invalid domain literal %q", domain)
+	}
+
+	d, err := domainToASCII(domain)
+	if err != nil {
+		return "", errors.NewNotValid(err, "[email] Invalid domain %q", domain)
+	}
+	if len(d) > maxDomainLength {
+		return "", errors.NewNotValidf("[email] Domain %q exceeds %d octets", domain, maxDomainLength)
+	}
+	labels := strings.Split(d, ".")
+	if len(labels) < 2 {
+		return "", errors.NewNotValidf("[email] Domain %q must contain at least two labels", domain)
+	}
+	for _, l := range labels {
+		if l == "" || len(l) > maxLabelLength || l[0] == '-' || l[len(l)-1] == '-' {
+			return "", errors.NewNotValidf("[email] Invalid label %q in domain %q", l, domain)
+		}
+		for i := 0; i < len(l); i++ {
+			if c := l[i]; !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return "", errors.NewNotValidf("[email] Invalid character %q in domain %q", c, domain)
+			}
+		}
+	}
+	if tld := labels[len(labels)-1]; strings.Trim(tld, "0123456789") == "" {
+		return "", errors.NewNotValidf("[email] Numeric top level domain in %q", domain)
+	}
+	return d, nil
+}
+
+// MXResolver looks up the DNS records to check whether a domain accepts
+// mails. *net.Resolver implements this interface.
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DisposableDomains returns a hook for AddressValidator.IsDisposable which
+// matches the provided domains and all of their sub domains.
+func DisposableDomains(domains ...string) func(domain string) bool {
+	dm := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		dm[strings.ToLower(strings.TrimSuffix(d, "."))] = true
+	}
+	return func(domain string) bool {
+		for {
+			if dm[domain] {
+				return true
+			}
+			i := strings.IndexByte(domain, '.')
+			if i < 0 {
+				return false
+			}
+			domain = domain[i+1:]
+		}
+	}
+}
+
+type mxResult struct {
+	err     error
+	expires time.Time
+}
+
+// AddressValidator validates and normalizes email addresses, for example in
+// a form or before the Daemon enqueues a message. The zero value checks only
+// the syntax. Safe for concurrent use.
+type AddressValidator struct {
+	// CheckMX enables the DNS lookup whether the domain accepts mails. A
+	// domain without MX records falls back to its A or AAAA records, see RFC
+	// 5321 section 5.1, and a null MX of RFC 7505 rejects all mails.
+	CheckMX bool
+	// Resolver defaults to net.DefaultResolver.
+	Resolver MXResolver
+	// MXTimeout maximum duration of a lookup. Defaults to three seconds.
+	MXTimeout time.Duration
+	// MXCacheTTL duration how long the result of a lookup gets cached.
+	// Temporary DNS failures won't be cached. Defaults to one hour, a negative
+	// value disables the cache.
+	MXCacheTTL time.Duration
+	// IsDisposable optional hook which reports whether the normalized domain
+	// belongs to a provider of disposable addresses. See DisposableDomains.
+	IsDisposable func(domain string) bool
+
+	mu      sync.Mutex
+	mxCache map[string]mxResult
+}
+
+// NewAddressValidator creates a new validator which checks the MX records.
+func NewAddressValidator() *AddressValidator {
+	return &AddressValidator{
+		CheckMX: true,
+	}
+}
+
+// Validate checks the address and returns its normalized form. Error
+// behaviour: NotValid, NotAllowed for disposable domains, Temporary or Timeout
+// if the DNS lookup failed.
+func (v *AddressValidator) Validate(ctx context.Context, addr string) (string, error) {
+	norm, err := NormalizeAddress(addr)
+	if err != nil {
+		return "", errors.Wrap(err, "[email] AddressValidator.Validate")
+	}
+	domain := norm[strings.LastIndexByte(norm, '@')+1:]
+
+	if v.IsDisposable != nil && v.IsDisposable(domain) {
+		return "", errors.NewNotAllowedf("[email] AddressValidator.Validate: Domain %q of address %q is disposable", domain, addr)
+	}
+	if v.CheckMX && domain[0] != '[' {
+		if err := v.lookupMX(ctx, domain); err != nil {
+			return "", errors.Wrapf(err, "[email] AddressValidator.Validate %q", addr)
+		}
+	}
+	return norm, nil
+}
+
+func (v *AddressValidator) lookupMX(ctx context.Context, domain string) error {
+	ttl := v.MXCacheTTL
+	if ttl == 0 {
+		ttl = time.Hour
+	}
+	now := time.Now()
+	if ttl > 0 {
+		v.mu.Lock()
+		r, ok := v.mxCache[domain]
+		v.mu.Unlock()
+		if ok && now.Before(r.expires) {
+			return r.err
+		}
+	}
+
+	err := v.resolveMX(ctx, domain)
+	if ttl > 0 && (err == nil || errors.IsNotValid(err)) {
+		v.mu.Lock()
+		if v.mxCache == nil {
+			v.mxCache = make(map[string]mxResult)
+		}
+		v.mxCache[domain] = mxResult{err: err, expires: now.Add(ttl)}
+		v.mu.Unlock()
+	}
+	return err
+}
+
+func (v *AddressValidator) resolveMX(ctx context.Context, domain string) error {
+	res := v.Resolver
+	if res == nil {
+		res = net.DefaultResolver
+	}
+	timeout := v.MXTimeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	mxs, err := res.LookupMX(ctx, domain)
+	if err != nil && !isDNSNotFound(err) {
+		return dnsError(err, domain)
+	}
+	if len(mxs) == 1 && (mxs[0].Host == "." || mxs[0].Host == "") {
+		return errors.NewNotValidf("[email] Domain %q does not accept mails (null MX)", domain)
+	}
+	if len(mxs) > 0 {
+		return nil
+	}
+
+	hosts, err := res.LookupHost(ctx, domain)
+	if err != nil && !isDNSNotFound(err) {
+		return dnsError(err, domain)
+	}
+	if len(hosts) == 0 {
+		return errors.NewNotValidf("[email] Domain %q has neither MX nor A records", domain)
+	}
+	return nil
+}
+
+// isDNSNotFound reports whether the DNS server answered definitively that the
+// records do not exist.
+func isDNSNotFound(err error) bool {
+	de, ok := err.(*net.DNSError)
+	return ok && !de.Timeout() && !de.Temporary()
+}
+
+func dnsError(err error, domain string) error {
+	if de, ok := err.(*net.DNSError); ok && de.Timeout() {
+		return errors.NewTimeout(err, "[email] DNS lookup of %q", domain)
+	}
+	if err == context.DeadlineExceeded {
+		return errors.NewTimeout(err, "[email] DNS lookup of %q", domain)
+	}
+	return errors.NewTemporary(err, "[email] DNS lookup of %q", domain)
+}
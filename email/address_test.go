@@ -0,0 +1,199 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/email"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		want    string
+		wantErr bool
+	}{
+		{"gopher@Example.COM", "gopher@example.com", false},
+		{" Gopher@example.com. ", "Gopher@example.com", false},
+		{"Go Pher <go.pher+tag@example.com>", "go.pher+tag@example.com", false},
+		{"!#$%&'*+-/=?^_`{|}~@example.com", "!#$%&'*+-/=?^_`{|}~@example.com", false},
+		{`"john doe"@example.com`, `"john doe"@example.com`, false},
+		{`"john\"doe"@example.com`, `"john\"doe"@example.com`, false},
+		{"user@münchen.de", "user@xn--mnchen-3ya.de", false},
+		{"user@MÜNCHEN.de", "user@xn--mnchen-3ya.de", false},
+		{"user@bücher.example", "user@xn--bcher-kva.example", false},
+		{"user@例え.テスト", "user@xn--r8jz45g.xn--zckzah", false},
+		{"jürgen@example.com", "jürgen@example.com", false},
+		{"user@[192.0.2.1]", "user@[192.0.2.1]", false},
+		{"user@[IPv6:2001:DB8::1]", "user@[IPv6:2001:db8::1]", false},
+		{"user@sub-domain.example.co.uk", "user@sub-domain.example.co.uk", false},
+
+		{"", "", true},
+		{"example.com", "", true},
+		{"@example.com", "", true},
+		{"user@", "", true},
+		{".user@example.com", "", true},
+		{"user.@example.com", "", true},
+		{"us..er@example.com", "", true},
+		{"us er@example.com", "", true},
+		{"us(er)@example.com", "", true},
+		{`"unterminated@example.com`, "", true},
+		{`"bad"quote"@example.com`, "", true},
+		{"user@localhost", "", true},
+		{"user@example..com", "", true},
+		{"user@-example.com", "", true},
+		{"user@example-.com", "", true},
+		{"user@exa_mple.com", "", true},
+		{"user@example.123", "", true},
+		{"user@[192.0.2.256]", "", true},
+		{"user@[2001:db8::1]", "", true},
+		{"user@[IPv6:192.0.2.1]", "", true},
+		{"Gopher <gopher@>", "", true},
+		{"a@" + repeat("a", 64) + ".com", "", true},
+		{repeat("a", 65) + "@example.com", "", true},
+		{repeat("a", 64) + "@" + repeat(repeat("a", 60)+".", 4) + "com", "", true},
+		{"user@example.com\xff", "", true},
+	}
+	for _, test := range tests {
+		have, err := email.NormalizeAddress(test.addr)
+		if test.wantErr {
+			assert.True(t, errors.IsNotValid(err), "Address %q: %+v", test.addr, err)
+			assert.Empty(t, have, "Address %q", test.addr)
+			assert.False(t, email.IsValidAddress(test.addr), "Address %q", test.addr)
+			continue
+		}
+		require.NoError(t, err, "Address %q: %+v", test.addr, err)
+		assert.Exactly(t, test.want, have, "Address %q", test.addr)
+	}
+}
+
+func repeat(s string, n int) (r string) {
+	for i := 0; i < n; i++ {
+		r += s
+	}
+	return
+}
+
+type mockResolver struct {
+	calls int32
+	mx    map[string][]*net.MX
+	hosts map[string][]string
+	err   error
+}
+
+func (mr *mockResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	atomic.AddInt32(&mr.calls, 1)
+	if mr.err != nil {
+		return nil, mr.err
+	}
+	if mx, ok := mr.mx[name]; ok {
+		return mx, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name}
+}
+
+func (mr *mockResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if h, ok := mr.hosts[host]; ok {
+		return h, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host}
+}
+
+func TestAddressValidator(t *testing.T) {
+	res := &mockResolver{
+		mx: map[string][]*net.MX{
+			"example.com":          {{Host: "mx1.example.com.", Pref: 10}},
+			"xn--mnchen-3ya.de":    {{Host: "mx.xn--mnchen-3ya.de.", Pref: 10}},
+			"nullmx.example":       {{Host: ".", Pref: 0}},
+			"mailinator.com":       {{Host: "mx.mailinator.com.", Pref: 10}},
+			"eu.guerrillamail.com": {{Host: "mx.guerrillamail.com.", Pref: 10}},
+		},
+		hosts: map[string][]string{
+			"implicit.example": {"192.0.2.10"},
+		},
+	}
+	v := email.NewAddressValidator()
+	v.Resolver = res
+	v.IsDisposable = email.DisposableDomains("Mailinator.com", "guerrillamail.com.")
+
+	tests := []struct {
+		addr    string
+		want    string
+		errBhf  errors.BehaviourFunc
+		wantErr bool
+	}{
+		{"Gopher <gopher@EXAMPLE.com>", "gopher@example.com", nil, false},
+		{"user@münchen.de", "user@xn--mnchen-3ya.de", nil, false},
+		{"user@implicit.example", "user@implicit.example", nil, false},
+		{"user@[192.0.2.1]", "user@[192.0.2.1]", nil, false},
+		{"user@nullmx.example", "", errors.IsNotValid, true},
+		{"user@nowhere.example", "", errors.IsNotValid, true},
+		{"user@@example.com", "", errors.IsNotValid, true},
+		{"user@mailinator.com", "", errors.IsNotAllowed, true},
+		{"user@eu.guerrillamail.com", "", errors.IsNotAllowed, true},
+	}
+	for _, test := range tests {
+		have, err := v.Validate(context.TODO(), test.addr)
+		if test.wantErr {
+			assert.True(t, test.errBhf(err), "Address %q: %+v", test.addr, err)
+			continue
+		}
+		require.NoError(t, err, "Address %q: %+v", test.addr, err)
+		assert.Exactly(t, test.want, have, "Address %q", test.addr)
+	}
+
+	// cached, including the negative result
+	calls := atomic.LoadInt32(&res.calls)
+	_, err := v.Validate(context.TODO(), "other@example.com")
+	require.NoError(t, err)
+	_, err = v.Validate(context.TODO(), "other@nowhere.example")
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	assert.Exactly(t, calls, atomic.LoadInt32(&res.calls))
+}
+
+func TestAddressValidator_DNSErrors(t *testing.T) {
+	res := &mockResolver{
+		err: &net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true},
+	}
+	v := &email.AddressValidator{
+		CheckMX:    true,
+		Resolver:   res,
+		MXTimeout:  time.Second,
+		MXCacheTTL: time.Hour,
+	}
+	_, err := v.Validate(context.TODO(), "user@example.com")
+	assert.True(t, errors.IsTemporary(err), "%+v", err)
+	_, err = v.Validate(context.TODO(), "user@example.com")
+	assert.True(t, errors.IsTemporary(err), "%+v", err)
+	assert.Exactly(t, int32(2), atomic.LoadInt32(&res.calls), "Temporary errors must not be cached")
+
+	res.err = &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}
+	_, err = v.Validate(context.TODO(), "user@example.com")
+	assert.True(t, errors.IsTimeout(err), "%+v", err)
+
+	// syntax check only
+	v = new(email.AddressValidator)
+	have, err := v.Validate(context.TODO(), "user@nowhere.example")
+	require.NoError(t, err)
+	assert.Exactly(t, "user@nowhere.example", have)
+}
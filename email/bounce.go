@@ -0,0 +1,185 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corestoreio/errors"
+)
+
+// BounceType classifies a failed delivery.
+type BounceType uint8
+
+// Bounce types. A soft bounce is a temporary failure, the address stays
+// deliverable. Hard bounces and complaints lead to a suppression of the
+// address.
+const (
+	BounceUndetermined BounceType = iota
+	BounceSoft
+	BounceHard
+	BounceComplaint
+)
+
+var bounceTypeNames = [...]string{"undetermined", "soft", "hard", "complaint"}
+
+// String returns the lower case name of the bounce type.
+func (bt BounceType) String() string {
+	if int(bt) < len(bounceTypeNames) {
+		return bounceTypeNames[bt]
+	}
+	return "BounceType(" + strconv.Itoa(int(bt)) + ")"
+}
+
+// Bounce describes a single failed delivery to one recipient. It gets created
+// from a synchronous SMTP error or from a webhook of an email provider.
+type Bounce struct {
+	Recipient string
+	Type      BounceType
+	// Code SMTP reply code, e.g. 550. Zero if unknown.
+	Code int
+	// Status enhanced mail system status code (RFC 3463), e.g. 5.1.1.
+	Status string
+	// Reason human readable diagnostic message.
+	Reason string
+	// Provider name of the source, e.g. smtp, ses or mailgun.
+	Provider string
+	Time     time.Time
+}
+
+// IsPermanent returns true for hard bounces and complaints. Such addresses
+// should not be mailed anymore.
+func (b Bounce) IsPermanent() bool {
+	return b.Type == BounceHard || b.Type == BounceComplaint
+}
+
+var (
+	regexSMTPCode     = regexp.MustCompile(`^([2-5][0-9]{2})(?:[ -]|$)`)
+	regexSMTPEnhanced = regexp.MustCompile(`\b([245])\.[0-9]{1,3}\.[0-9]{1,3}\b`)
+)
+
+// ParseSMTPReply extracts the reply code and the enhanced status code from an
+// SMTP server response or a diagnostic code as used in DSNs and provider
+// webhooks and classifies the bounce. The enhanced status code takes
+// precedence over the reply code.
+//		ParseSMTPReply("550 5.1.1 <a@b.c>: Recipient address rejected") // 550, "5.1.1", BounceHard
+//		ParseSMTPReply("smtp; 452 4.2.2 Mailbox full")                 // 452, "4.2.2", BounceSoft
+func ParseSMTPReply(reply string) (code int, status string, typ BounceType) {
+	reply = strings.TrimSpace(reply)
+	if i := strings.IndexByte(reply, ';'); i > 0 && strings.EqualFold(reply[:i], "smtp") {
+		reply = strings.TrimSpace(reply[i+1:])
+	}
+
+	class := byte(0)
+	if m := regexSMTPCode.FindStringSubmatch(reply); m != nil {
+		code, _ = strconv.Atoi(m[1])
+		class = m[1][0]
+	}
+	if m := regexSMTPEnhanced.FindStringSubmatch(reply); m != nil {
+		status = m[0]
+		class = m[1][0]
+	}
+
+	switch class {
+	case '5':
+		typ = BounceHard
+	case '4':
+		typ = BounceSoft
+	}
+	return code, status, typ
+}
+
+// BounceParser extracts the bounces from a webhook request of an email
+// provider. Requests which do not contain bounces must return an empty
+// slice and no error.
+type BounceParser interface {
+	ParseBounces(r *http.Request) ([]Bounce, error)
+}
+
+// BounceParserFunc is an adapter to use a function as a BounceParser.
+type BounceParserFunc func(r *http.Request) ([]Bounce, error)
+
+// ParseBounces calls bp(r).
+func (bp BounceParserFunc) ParseBounces(r *http.Request) ([]Bounce, error) {
+	return bp(r)
+}
+
+// SuppressionList stores addresses which must not be mailed anymore. See
+// TableSuppressionList for a database backed implementation.
+type SuppressionList interface {
+	// Suppress adds or updates the recipient of the bounce.
+	Suppress(ctx context.Context, b Bounce) error
+	// IsSuppressed reports whether an address has been suppressed.
+	IsSuppressed(ctx context.Context, address string) (bool, error)
+}
+
+// BounceProcessor ingests bounces, adds the permanent ones to the suppression
+// list and calls the OnBounce hook for each bounce. It implements a
+// http.Handler for provider webhooks.
+type BounceProcessor struct {
+	// Parser required when used as http.Handler.
+	Parser BounceParser
+	// Suppression optional list to store permanent bounces.
+	Suppression SuppressionList
+	// OnBounce optional hook, called for each bounce, also soft ones.
+	OnBounce func(Bounce)
+}
+
+// Process handles the bounces. It continues on error and returns the first
+// error which occurred.
+func (bp *BounceProcessor) Process(ctx context.Context, bounces ...Bounce) error {
+	var firstErr error
+	for _, b := range bounces {
+		b.Recipient = strings.ToLower(strings.TrimSpace(b.Recipient))
+		if b.Recipient == "" {
+			continue
+		}
+		if b.Time.IsZero() {
+			b.Time = time.Now()
+		}
+		if bp.Suppression != nil && b.IsPermanent() {
+			if err := bp.Suppression.Suppress(ctx, b); err != nil && firstErr == nil {
+				firstErr = errors.Wrapf(err, "[email] BounceProcessor.Suppress %q", b.Recipient)
+			}
+		}
+		if bp.OnBounce != nil {
+			bp.OnBounce(b)
+		}
+	}
+	return firstErr
+}
+
+// ServeHTTP parses the webhook request and processes the bounces. Invalid
+// requests get a 400 and storage errors a 500 status code, so that the
+// provider retries the delivery of the notification.
+func (bp *BounceProcessor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bounces, err := bp.Parser.ParseBounces(r)
+	if err != nil {
+		PkgLog.Info("email.BounceProcessor.ParseBounces", "err", err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := bp.Process(r.Context(), bounces...); err != nil {
+		PkgLog.Info("email.BounceProcessor.Process", "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
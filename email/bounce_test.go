@@ -0,0 +1,267 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/email"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ email.BounceParser = (*email.SESBounceParser)(nil)
+var _ email.BounceParser = (*email.MailgunBounceParser)(nil)
+var _ email.SuppressionList = (*email.TableSuppressionList)(nil)
+var _ http.Handler = (*email.BounceProcessor)(nil)
+
+func TestParseSMTPReply(t *testing.T) {
+	tests := []struct {
+		reply      string
+		wantCode   int
+		wantStatus string
+		wantType   email.BounceType
+	}{
+		{"550 5.1.1 <a@b.c>: Recipient address rejected", 550, "5.1.1", email.BounceHard},
+		{"smtp; 452 4.2.2 Mailbox full", 452, "4.2.2", email.BounceSoft},
+		{"SMTP; 550-5.7.1 Blocked", 550, "5.7.1", email.BounceHard},
+		{"421 Try again later", 421, "", email.BounceSoft},
+		{"550 4.4.7 Delivery time expired", 550, "4.4.7", email.BounceSoft},
+		{"5.1.1 user unknown", 0, "5.1.1", email.BounceHard},
+		{"250 OK", 250, "", email.BounceUndetermined},
+		{"connection reset by peer", 0, "", email.BounceUndetermined},
+		{"", 0, "", email.BounceUndetermined},
+	}
+	for i, test := range tests {
+		code, status, typ := email.ParseSMTPReply(test.reply)
+		assert.Exactly(t, test.wantCode, code, "Index %d", i)
+		assert.Exactly(t, test.wantStatus, status, "Index %d", i)
+		assert.Exactly(t, test.wantType, typ, "Index %d %s", i, typ)
+	}
+}
+
+func TestBounceType_String(t *testing.T) {
+	assert.Exactly(t, "hard", email.BounceHard.String())
+	assert.Exactly(t, "complaint", email.BounceComplaint.String())
+	assert.Exactly(t, "BounceType(9)", email.BounceType(9).String())
+}
+
+const sesBounceNotification = `{
+  "Type" : "Notification",
+  "MessageId" : "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+  "Message" : "{\"notificationType\":\"Bounce\",\"bounce\":{\"bounceType\":\"Permanent\",\"timestamp\":\"2016-01-27T14:59:38.237Z\",\"bouncedRecipients\":[{\"emailAddress\":\"Jane@Example.com\",\"status\":\"5.1.1\",\"diagnosticCode\":\"smtp; 550 5.1.1 user unknown\"},{\"emailAddress\":\"richard@example.com\"}]}}"
+}`
+
+const sesComplaintNotification = `{
+  "Type" : "Notification",
+  "Message" : "{\"notificationType\":\"Complaint\",\"complaint\":{\"timestamp\":\"2016-01-27T14:59:38.237Z\",\"complainedRecipients\":[{\"emailAddress\":\"spam@example.com\"}]}}"
+}`
+
+func TestSESBounceParser(t *testing.T) {
+	p := email.SESBounceParser{}
+
+	t.Run("bounce", func(t *testing.T) {
+		bs, err := p.ParseBounces(httptest.NewRequest("POST", "/ses", strings.NewReader(sesBounceNotification)))
+		require.NoError(t, err)
+		require.Len(t, bs, 2)
+		assert.Exactly(t, "Jane@Example.com", bs[0].Recipient)
+		assert.Exactly(t, email.BounceHard, bs[0].Type)
+		assert.Exactly(t, 550, bs[0].Code)
+		assert.Exactly(t, "5.1.1", bs[0].Status)
+		assert.Exactly(t, "ses", bs[0].Provider)
+		assert.Exactly(t, 2016, bs[0].Time.Year())
+		assert.Exactly(t, email.BounceHard, bs[1].Type)
+	})
+	t.Run("complaint", func(t *testing.T) {
+		bs, err := p.ParseBounces(httptest.NewRequest("POST", "/ses", strings.NewReader(sesComplaintNotification)))
+		require.NoError(t, err)
+		require.Len(t, bs, 1)
+		assert.Exactly(t, email.BounceComplaint, bs[0].Type)
+		assert.True(t, bs[0].IsPermanent())
+	})
+	t.Run("subscription confirmation ignored", func(t *testing.T) {
+		bs, err := p.ParseBounces(httptest.NewRequest("POST", "/ses", strings.NewReader(`{"Type":"SubscriptionConfirmation"}`)))
+		assert.NoError(t, err)
+		assert.Empty(t, bs)
+	})
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := p.ParseBounces(httptest.NewRequest("POST", "/ses", strings.NewReader(`{"Type`)))
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+}
+
+func mailgunBody(key, event, severity string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("1529006854" + "a8ce0edb2dd8301dee6c2405235584e45aa91d1e9f979f3de0"))
+	return `{"signature":{"timestamp":"1529006854","token":"a8ce0edb2dd8301dee6c2405235584e45aa91d1e9f979f3de0","signature":"` +
+		hex.EncodeToString(mac.Sum(nil)) + `"},"event-data":{"event":"` + event + `","severity":"` + severity +
+		`","recipient":"alice@example.com","timestamp":1521233195.375624,"delivery-status":{"code":550,"message":"5.1.1 The email account does not exist","description":""}}}`
+}
+
+func TestMailgunBounceParser(t *testing.T) {
+	p := email.MailgunBounceParser{SigningKey: "key-secret"}
+
+	t.Run("failed permanent", func(t *testing.T) {
+		bs, err := p.ParseBounces(httptest.NewRequest("POST", "/mg", strings.NewReader(mailgunBody("key-secret", "failed", "permanent"))))
+		require.NoError(t, err)
+		require.Len(t, bs, 1)
+		assert.Exactly(t, "alice@example.com", bs[0].Recipient)
+		assert.Exactly(t, email.BounceHard, bs[0].Type)
+		assert.Exactly(t, 550, bs[0].Code)
+		assert.Exactly(t, "5.1.1", bs[0].Status)
+		assert.Exactly(t, int64(1521233195), bs[0].Time.Unix())
+	})
+	t.Run("failed temporary", func(t *testing.T) {
+		bs, err := p.ParseBounces(httptest.NewRequest("POST", "/mg", strings.NewReader(mailgunBody("key-secret", "failed", "temporary"))))
+		require.NoError(t, err)
+		assert.Exactly(t, email.BounceSoft, bs[0].Type)
+	})
+	t.Run("complained", func(t *testing.T) {
+		bs, err := p.ParseBounces(httptest.NewRequest("POST", "/mg", strings.NewReader(mailgunBody("key-secret", "complained", ""))))
+		require.NoError(t, err)
+		assert.Exactly(t, email.BounceComplaint, bs[0].Type)
+	})
+	t.Run("delivered ignored", func(t *testing.T) {
+		bs, err := p.ParseBounces(httptest.NewRequest("POST", "/mg", strings.NewReader(mailgunBody("key-secret", "delivered", ""))))
+		assert.NoError(t, err)
+		assert.Empty(t, bs)
+	})
+	t.Run("invalid signature", func(t *testing.T) {
+		_, err := p.ParseBounces(httptest.NewRequest("POST", "/mg", strings.NewReader(mailgunBody("key-wrong", "failed", "permanent"))))
+		assert.True(t, errors.IsUnauthorized(err), "%+v", err)
+	})
+}
+
+type suppressionMock struct {
+	mu    sync.Mutex
+	addrs map[string]email.Bounce
+	err   error
+}
+
+func (sm *suppressionMock) Suppress(_ context.Context, b email.Bounce) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.err != nil {
+		return sm.err
+	}
+	if sm.addrs == nil {
+		sm.addrs = make(map[string]email.Bounce)
+	}
+	sm.addrs[b.Recipient] = b
+	return nil
+}
+
+func (sm *suppressionMock) IsSuppressed(_ context.Context, address string) (bool, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	_, ok := sm.addrs[address]
+	return ok, sm.err
+}
+
+func TestBounceProcessor_ServeHTTP(t *testing.T) {
+
+	t.Run("suppress permanent bounces", func(t *testing.T) {
+		sl := new(suppressionMock)
+		var hooked []email.Bounce
+		bp := &email.BounceProcessor{
+			Parser:      email.SESBounceParser{},
+			Suppression: sl,
+			OnBounce: func(b email.Bounce) {
+				hooked = append(hooked, b)
+			},
+		}
+		rec := httptest.NewRecorder()
+		bp.ServeHTTP(rec, httptest.NewRequest("POST", "/ses", strings.NewReader(sesBounceNotification)))
+		assert.Exactly(t, http.StatusOK, rec.Code)
+		assert.Len(t, hooked, 2)
+
+		ok, err := sl.IsSuppressed(context.Background(), "jane@example.com")
+		assert.NoError(t, err)
+		assert.True(t, ok, "Address must be lower cased")
+	})
+
+	t.Run("soft bounce not suppressed", func(t *testing.T) {
+		sl := new(suppressionMock)
+		bp := &email.BounceProcessor{Suppression: sl}
+		require.NoError(t, bp.Process(context.Background(), email.Bounce{Recipient: "x@y.z", Type: email.BounceSoft}))
+		assert.Empty(t, sl.addrs)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		bp := &email.BounceProcessor{Parser: email.SESBounceParser{}}
+		rec := httptest.NewRecorder()
+		bp.ServeHTTP(rec, httptest.NewRequest("POST", "/ses", strings.NewReader(`[`)))
+		assert.Exactly(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		bp := &email.BounceProcessor{
+			Parser:      email.SESBounceParser{},
+			Suppression: &suppressionMock{err: errors.NewFatalf("DB down")},
+		}
+		rec := httptest.NewRecorder()
+		bp.ServeHTTP(rec, httptest.NewRequest("POST", "/ses", strings.NewReader(sesComplaintNotification)))
+		assert.Exactly(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestTableSuppressionList(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, db.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+	sl := email.NewTableSuppressionList(db)
+	now := time.Now()
+
+	dbMock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS `email_suppression`")).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `email_suppression` (address,bounce_type,smtp_code,smtp_status,reason,provider,created_at,updated_at) VALUES (?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE")).
+		WithArgs("jane@example.com", "hard", 550, "5.1.1", "user unknown", "ses", now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT 1 FROM `email_suppression` WHERE address = ?")).
+		WithArgs("jane@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT 1 FROM `email_suppression` WHERE address = ?")).
+		WithArgs("john@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+
+	require.NoError(t, sl.CreateTable(context.Background()))
+	require.NoError(t, sl.Suppress(context.Background(), email.Bounce{
+		Recipient: "Jane@example.com", Type: email.BounceHard, Code: 550, Status: "5.1.1",
+		Reason: "user unknown", Provider: "ses", Time: now,
+	}))
+
+	ok, err := sl.IsSuppressed(context.Background(), "JANE@example.com")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = sl.IsSuppressed(context.Background(), "john@example.com")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
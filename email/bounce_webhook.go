@@ -0,0 +1,202 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/corestoreio/errors"
+)
+
+// maxWebhookBodySize limits the request body of a provider webhook.
+const maxWebhookBodySize = 1 << 20
+
+func readWebhookBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, errors.NewEmptyf("[email] Webhook request body is empty")
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	return data, errors.Wrap(err, "[email] Webhook ReadAll")
+}
+
+// SESBounceParser parses Amazon SES bounce and complaint notifications which
+// have been delivered via an SNS HTTP(S) subscription. The subscription must be
+// confirmed manually. Other SNS message types get ignored.
+type SESBounceParser struct{}
+
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	Bounce           struct {
+		BounceType        string    `json:"bounceType"`
+		Timestamp         time.Time `json:"timestamp"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			Status         string `json:"status"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		Timestamp            time.Time `json:"timestamp"`
+		ComplainedRecipients []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+}
+
+// ParseBounces implements BounceParser.
+func (SESBounceParser) ParseBounces(r *http.Request) ([]Bounce, error) {
+	data, err := readWebhookBody(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "[email] SESBounceParser")
+	}
+	var envelope struct {
+		Type    string
+		Message string
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, errors.NewNotValid(err, "[email] SESBounceParser SNS envelope")
+	}
+	if envelope.Type != "Notification" {
+		return nil, nil
+	}
+	var n sesNotification
+	if err := json.Unmarshal([]byte(envelope.Message), &n); err != nil {
+		return nil, errors.NewNotValid(err, "[email] SESBounceParser notification")
+	}
+
+	var bounces []Bounce
+	switch n.NotificationType {
+	case "Bounce":
+		for _, br := range n.Bounce.BouncedRecipients {
+			b := Bounce{
+				Recipient: br.EmailAddress,
+				Reason:    br.DiagnosticCode,
+				Provider:  "ses",
+				Time:      n.Bounce.Timestamp,
+			}
+			b.Code, b.Status, b.Type = ParseSMTPReply(br.DiagnosticCode)
+			if br.Status != "" {
+				b.Status = br.Status
+			}
+			// SES knows better than the diagnostic code, e.g. for suppressed
+			// addresses without SMTP conversation.
+			switch n.Bounce.BounceType {
+			case "Permanent":
+				b.Type = BounceHard
+			case "Transient":
+				b.Type = BounceSoft
+			}
+			bounces = append(bounces, b)
+		}
+	case "Complaint":
+		for _, cr := range n.Complaint.ComplainedRecipients {
+			bounces = append(bounces, Bounce{
+				Recipient: cr.EmailAddress,
+				Type:      BounceComplaint,
+				Provider:  "ses",
+				Time:      n.Complaint.Timestamp,
+			})
+		}
+	}
+	return bounces, nil
+}
+
+// MailgunBounceParser parses the JSON webhooks of Mailgun for the events
+// failed and complained. If SigningKey has been set, the signature of the
+// request gets verified.
+type MailgunBounceParser struct {
+	// SigningKey the HTTP webhook signing key of the Mailgun account.
+	SigningKey string
+}
+
+type mailgunWebhook struct {
+	Signature struct {
+		Timestamp string `json:"timestamp"`
+		Token     string `json:"token"`
+		Signature string `json:"signature"`
+	} `json:"signature"`
+	EventData struct {
+		Event          string  `json:"event"`
+		Severity       string  `json:"severity"`
+		Recipient      string  `json:"recipient"`
+		Timestamp      float64 `json:"timestamp"`
+		DeliveryStatus struct {
+			Code        int    `json:"code"`
+			Message     string `json:"message"`
+			Description string `json:"description"`
+		} `json:"delivery-status"`
+	} `json:"event-data"`
+}
+
+// ParseBounces implements BounceParser. Returns an Unauthorized error
+// behaviour if the signature does not match.
+func (mp MailgunBounceParser) ParseBounces(r *http.Request) ([]Bounce, error) {
+	data, err := readWebhookBody(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "[email] MailgunBounceParser")
+	}
+	var wh mailgunWebhook
+	if err := json.Unmarshal(data, &wh); err != nil {
+		return nil, errors.NewNotValid(err, "[email] MailgunBounceParser")
+	}
+
+	if mp.SigningKey != "" {
+		mac := hmac.New(sha256.New, []byte(mp.SigningKey))
+		mac.Write([]byte(wh.Signature.Timestamp + wh.Signature.Token))
+		sig, _ := hex.DecodeString(wh.Signature.Signature)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return nil, errors.NewUnauthorizedf("[email] MailgunBounceParser invalid signature")
+		}
+	}
+
+	ed := wh.EventData
+	b := Bounce{
+		Recipient: ed.Recipient,
+		Code:      ed.DeliveryStatus.Code,
+		Provider:  "mailgun",
+	}
+	if ed.Timestamp > 0 {
+		sec := int64(ed.Timestamp)
+		b.Time = time.Unix(sec, int64((ed.Timestamp-float64(sec))*1e9))
+	}
+
+	switch ed.Event {
+	case "failed":
+		b.Reason = ed.DeliveryStatus.Description
+		if b.Reason == "" {
+			b.Reason = ed.DeliveryStatus.Message
+		}
+		_, b.Status, b.Type = ParseSMTPReply(strconv.Itoa(b.Code) + " " + ed.DeliveryStatus.Message)
+		switch ed.Severity {
+		case "permanent":
+			b.Type = BounceHard
+		case "temporary":
+			b.Type = BounceSoft
+		}
+	case "complained":
+		b.Type = BounceComplaint
+	default:
+		return nil, nil
+	}
+	return []Bounce{b}, nil
+}
@@ -75,6 +75,16 @@ type Daemon struct {
 	dialerIsCustom bool   // protects the custom dialer set via Option func
 	sendFunc       gomail.SendFunc
 	closed         bool
+	// hooks and the suppression list, see SetOn*() and SetSuppressionList()
+	onSend      func(*gomail.Message)
+	onError     func(*gomail.Message, error)
+	onBounce    func(Bounce)
+	suppression SuppressionList
+	// dkim signs all messages before submission, see SetDKIM()
+	dkim *DKIMSigner
+	// addrValidator checks the recipients before enqueueing, see
+	// SetAddressValidator()
+	addrValidator *AddressValidator
 	// Config contains the config.Service
 	Config config.Scoped
 	// SmtpTimeout sets the time when the daemon should closes the connection
@@ -119,10 +129,8 @@ func (dm *Daemon) workerSendFunc() error {
 				return nil
 			}
 
-			if err := gomail.Send(dm.sendFunc, m); err != nil {
-				// dont terminate this for loop
-				PkgLog.Info("mail.daemon.Start.Send", "err", err, "message", m)
-			}
+			// dont terminate this for loop on error
+			dm.send(dm.sendFunc, m)
 		}
 	}
 }
@@ -145,9 +153,7 @@ func (dm *Daemon) workerDial() error {
 				}
 				open = true
 			}
-			if err := gomail.Send(s, m); err != nil {
-				PkgLog.Info("mail.daemon.workerDial.Send", "err", err, "message", m)
-			}
+			dm.send(s, m)
 		// Close the connection to the SMTP server if no email was sent in
 		// the last n seconds.
 		case <-time.After(dm.SmtpTimeout):
@@ -183,11 +189,15 @@ func (dm *Daemon) Stop() error {
 	return nil
 }
 
-// Send sends a mail
+// Send sends a mail. If an AddressValidator has been set, all recipients get
+// validated before the message gets enqueued.
 func (dm *Daemon) Send(m *gomail.Message) error {
 	if dm.closed {
 		return ErrMailChannelClosed
 	}
+	if err := dm.validateRecipients(m); err != nil {
+		return err
+	}
 	dm.msgChan <- m
 	return nil
 }
@@ -0,0 +1,64 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import "errors"
+
+// SetDKIM signs all outgoing messages with the DKIMSigner before they get
+// submitted to the SMTP server or the SendFunc. A nil signer disables
+// signing.
+func SetDKIM(ds *DKIMSigner) DaemonOption {
+	return func(da *Daemon) DaemonOption {
+		previous := da.dkim
+		da.dkim = ds
+		return SetDKIM(previous)
+	}
+}
+
+// SetDKIMFromConfig creates the DKIMSigner from the scoped configuration
+// paths PathSmtpDKIM*. Signing stays disabled if no domain has been
+// configured.
+func SetDKIMFromConfig() DaemonOption {
+	return func(da *Daemon) DaemonOption {
+		previous := da.dkim
+		if da.Config == nil {
+			da.lastErrs = append(da.lastErrs, errors.New("DKIM requires a configuration"))
+			return SetDKIM(previous)
+		}
+		domain := da.Config.String(PathSmtpDKIMDomain)
+		if domain == "" {
+			return SetDKIM(previous)
+		}
+
+		key, err := ParseDKIMPrivateKey([]byte(da.Config.String(PathSmtpDKIMPrivateKey)))
+		if err != nil {
+			da.lastErrs = append(da.lastErrs, err)
+			return SetDKIM(previous)
+		}
+		ds, err := NewDKIMSigner(DKIMOptions{
+			Domain:                 domain,
+			Selector:               da.Config.String(PathSmtpDKIMSelector),
+			PrivateKey:             key,
+			HeaderCanonicalization: DKIMCanonicalization(da.Config.String(PathSmtpDKIMHeaderCanon)),
+			BodyCanonicalization:   DKIMCanonicalization(da.Config.String(PathSmtpDKIMBodyCanon)),
+		})
+		if err != nil {
+			da.lastErrs = append(da.lastErrs, err)
+			return SetDKIM(previous)
+		}
+		da.dkim = ds
+		return SetDKIM(previous)
+	}
+}
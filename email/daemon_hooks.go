@@ -0,0 +1,210 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/go-gomail/gomail"
+)
+
+// ErrRecipientsSuppressed gets passed to the OnError hook when all recipients
+// of a message are on the suppression list.
+var ErrRecipientsSuppressed = errors.New("All recipients have been suppressed.")
+
+// recipientHeaders contains the header fields which can contain recipients.
+var recipientHeaders = [...]string{"To", "Cc", "Bcc"}
+
+// SetOnSend sets a hook which gets called after a message has been
+// successfully handed over to the SMTP server or the SendFunc.
+func SetOnSend(fn func(*gomail.Message)) DaemonOption {
+	return func(da *Daemon) DaemonOption {
+		previous := da.onSend
+		da.onSend = fn
+		return SetOnSend(previous)
+	}
+}
+
+// SetOnError sets a hook which gets called when a message could not be sent.
+// The worker continues with the next message.
+func SetOnError(fn func(*gomail.Message, error)) DaemonOption {
+	return func(da *Daemon) DaemonOption {
+		previous := da.onError
+		da.onError = fn
+		return SetOnError(previous)
+	}
+}
+
+// SetOnBounce sets a hook which gets called for each bounce, either detected
+// while sending or received via the BounceHandler.
+func SetOnBounce(fn func(Bounce)) DaemonOption {
+	return func(da *Daemon) DaemonOption {
+		previous := da.onBounce
+		da.onBounce = fn
+		return SetOnBounce(previous)
+	}
+}
+
+// SetSuppressionList sets the list of addresses which must not be mailed.
+// Suppressed recipients get removed from a message before sending and
+// permanent bounces get added to the list.
+func SetSuppressionList(sl SuppressionList) DaemonOption {
+	return func(da *Daemon) DaemonOption {
+		previous := da.suppression
+		da.suppression = sl
+		return SetSuppressionList(previous)
+	}
+}
+
+// SetAddressValidator sets a validator which checks all recipients in Send
+// before the message gets enqueued. A message with an invalid recipient gets
+// rejected and Send returns the error.
+func SetAddressValidator(v *AddressValidator) DaemonOption {
+	return func(da *Daemon) DaemonOption {
+		previous := da.addrValidator
+		da.addrValidator = v
+		return SetAddressValidator(previous)
+	}
+}
+
+// BounceHandler returns a handler for the webhook of an email provider. The
+// parsed bounces update the suppression list and trigger the OnBounce hook.
+func (dm *Daemon) BounceHandler(bp BounceParser) http.Handler {
+	return dm.bounceProcessor(bp)
+}
+
+func (dm *Daemon) bounceProcessor(bp BounceParser) *BounceProcessor {
+	return &BounceProcessor{
+		Parser:      bp,
+		Suppression: dm.suppression,
+		OnBounce:    dm.onBounce,
+	}
+}
+
+// send removes the suppressed recipients, signs and sends the message and
+// calls the hooks.
+func (dm *Daemon) send(s gomail.Sender, m *gomail.Message) {
+	if !dm.removeSuppressed(m) {
+		dm.callOnError(m, ErrRecipientsSuppressed)
+		return
+	}
+	if dm.dkim != nil {
+		s = dm.dkim.Sender(s)
+	}
+	if err := gomail.Send(s, m); err != nil {
+		PkgLog.Info("mail.daemon.send", "err", err, "message", m)
+		dm.callOnError(m, err)
+		if bs := smtpErrorBounces(m, err); len(bs) > 0 {
+			if err := dm.bounceProcessor(nil).Process(context.Background(), bs...); err != nil {
+				PkgLog.Info("mail.daemon.send.Bounce", "err", err, "message", m)
+			}
+		}
+		return
+	}
+	if dm.onSend != nil {
+		dm.onSend(m)
+	}
+}
+
+// validateRecipients checks all addresses of the recipient headers with the
+// AddressValidator.
+func (dm *Daemon) validateRecipients(m *gomail.Message) error {
+	if dm.addrValidator == nil {
+		return nil
+	}
+	for _, h := range recipientHeaders {
+		for _, a := range m.GetHeader(h) {
+			if _, err := dm.addrValidator.Validate(context.Background(), a); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (dm *Daemon) callOnError(m *gomail.Message, err error) {
+	if dm.onError != nil {
+		dm.onError(m, err)
+	}
+}
+
+// removeSuppressed deletes all suppressed addresses from the recipient
+// headers. Returns false if no recipient is left. On lookup errors the
+// address will be kept.
+func (dm *Daemon) removeSuppressed(m *gomail.Message) bool {
+	if dm.suppression == nil {
+		return true
+	}
+	var left int
+	for _, h := range recipientHeaders {
+		addrs := m.GetHeader(h)
+		if len(addrs) == 0 {
+			continue
+		}
+		keep := addrs[:0]
+		for _, a := range addrs {
+			suppressed, err := dm.suppression.IsSuppressed(context.Background(), parseAddress(a))
+			if err != nil {
+				PkgLog.Info("mail.daemon.removeSuppressed", "err", err, "address", a)
+			}
+			if !suppressed {
+				keep = append(keep, a)
+			}
+		}
+		m.SetHeader(h, keep...)
+		left += len(keep)
+	}
+	return left > 0
+}
+
+// smtpErrorBounces creates a bounce if a message with exactly one recipient
+// has been rejected permanently. With more recipients it is unknown which
+// address caused the error.
+func smtpErrorBounces(m *gomail.Message, err error) []Bounce {
+	var rcpts []string
+	for _, h := range recipientHeaders {
+		rcpts = append(rcpts, m.GetHeader(h)...)
+	}
+	if len(rcpts) != 1 {
+		return nil
+	}
+	// gomail prefixes the SMTP reply with its own message.
+	reply := err.Error()
+	if i := strings.LastIndex(reply, ": "); i >= 0 {
+		reply = reply[i+2:]
+	}
+	b := Bounce{
+		Recipient: parseAddress(rcpts[0]),
+		Reason:    reply,
+		Provider:  "smtp",
+	}
+	b.Code, b.Status, b.Type = ParseSMTPReply(reply)
+	if b.Type != BounceHard {
+		return nil
+	}
+	return []Bounce{b}
+}
+
+// parseAddress returns the plain address of "Name <a@b.c>".
+func parseAddress(a string) string {
+	if addr, err := mail.ParseAddress(a); err == nil {
+		return addr.Address
+	}
+	return a
+}
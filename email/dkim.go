@@ -0,0 +1,348 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corestoreio/errors"
+	"github.com/go-gomail/gomail"
+)
+
+// DKIMCanonicalization defines the algorithm to normalize the header or the
+// body of a message before hashing, see RFC 6376 section 3.4.
+type DKIMCanonicalization string
+
+// DKIMSimple tolerates almost no modification of the message, DKIMRelaxed
+// tolerates common modifications like whitespace replacement and header field
+// line rewrapping.
+const (
+	DKIMSimple  DKIMCanonicalization = "simple"
+	DKIMRelaxed DKIMCanonicalization = "relaxed"
+)
+
+// DKIMDefaultHeaders lists the header fields which get signed if
+// DKIMOptions.Headers is empty. Only the header fields present in a message
+// are part of the signature.
+var DKIMDefaultHeaders = []string{
+	"From", "Reply-To", "Subject", "Date", "To", "Cc", "Message-ID",
+	"MIME-Version", "Content-Type", "Content-Transfer-Encoding",
+}
+
+// DKIMOptions configures a DKIMSigner.
+type DKIMOptions struct {
+	// Domain the signing domain, the d= tag. Required.
+	Domain string
+	// Selector the s= tag, the public key must be published in the DNS TXT
+	// record <Selector>._domainkey.<Domain>. Required.
+	Selector string
+	// PrivateKey an RSA key with at least 1024 bits. Required.
+	PrivateKey *rsa.PrivateKey
+	// HeaderCanonicalization defaults to DKIMRelaxed.
+	HeaderCanonicalization DKIMCanonicalization
+	// BodyCanonicalization defaults to DKIMRelaxed.
+	BodyCanonicalization DKIMCanonicalization
+	// Headers which should be signed. The From header is always signed.
+	// Defaults to DKIMDefaultHeaders.
+	Headers []string
+	// Expiration sets the x= tag relative to the signing time, if greater
+	// zero.
+	Expiration time.Duration
+	// Now returns the signing time. Defaults to time.Now, mainly used for
+	// testing.
+	Now func() time.Time
+}
+
+// DKIMSigner adds a DKIM-Signature header to outgoing messages using the
+// rsa-sha256 algorithm. A DKIMSigner is safe for concurrent use.
+type DKIMSigner struct {
+	o       DKIMOptions
+	headers []string
+}
+
+// NewDKIMSigner validates the options and creates a new signer. Error
+// behaviour: Empty or NotValid.
+func NewDKIMSigner(o DKIMOptions) (*DKIMSigner, error) {
+	switch {
+	case o.Domain == "":
+		return nil, errors.NewEmptyf("[email] DKIM domain is empty")
+	case o.Selector == "":
+		return nil, errors.NewEmptyf("[email] DKIM selector is empty")
+	case o.PrivateKey == nil:
+		return nil, errors.NewEmptyf("[email] DKIM private key is empty")
+	case o.PrivateKey.N.BitLen() < 1024:
+		return nil, errors.NewNotValidf("[email] DKIM private key must have at least 1024 bits, got %d", o.PrivateKey.N.BitLen())
+	}
+	if o.HeaderCanonicalization == "" {
+		o.HeaderCanonicalization = DKIMRelaxed
+	}
+	if o.BodyCanonicalization == "" {
+		o.BodyCanonicalization = DKIMRelaxed
+	}
+	for _, c := range [...]DKIMCanonicalization{o.HeaderCanonicalization, o.BodyCanonicalization} {
+		if c != DKIMSimple && c != DKIMRelaxed {
+			return nil, errors.NewNotValidf("[email] Unknown DKIM canonicalization %q", c)
+		}
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+
+	ds := &DKIMSigner{o: o}
+	hs := o.Headers
+	if len(hs) == 0 {
+		hs = DKIMDefaultHeaders
+	}
+	ds.headers = append(ds.headers, "from")
+	for _, h := range hs {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" && h != "from" && h != "dkim-signature" {
+			ds.headers = append(ds.headers, h)
+		}
+	}
+	return ds, nil
+}
+
+// ParseDKIMPrivateKey parses a PEM encoded RSA private key in PKCS#1 or
+// PKCS#8 format. Error behaviour: NotValid.
+func ParseDKIMPrivateKey(pemKey []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.NewNotValidf("[email] DKIM private key: no PEM data found")
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.NewNotValid(err, "[email] DKIM private key")
+	}
+	rk, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.NewNotValidf("[email] DKIM private key: expecting RSA key, got %T", k)
+	}
+	return rk, nil
+}
+
+// Sign returns the raw RFC 5322 message with a prepended DKIM-Signature
+// header. LF line endings get converted to CRLF. Error behaviour: NotValid
+// or Fatal.
+func (ds *DKIMSigner) Sign(msg []byte) ([]byte, error) {
+	msg = toCRLF(msg)
+	header, body := msg, []byte(nil)
+	if i := bytes.Index(msg, []byte("\r\n\r\n")); i >= 0 {
+		header, body = msg[:i+2], msg[i+4:]
+	}
+	fields := splitHeaderFields(header)
+
+	bh := sha256.Sum256(dkimCanonicalBody(ds.o.BodyCanonicalization, body))
+
+	h := sha256.New()
+	var signed []string
+	used := make(map[int]bool, len(ds.headers))
+	for _, name := range ds.headers {
+		// the last occurrence of a header field gets signed first, RFC 6376
+		// section 5.4.2.
+		for i := len(fields) - 1; i >= 0; i-- {
+			if used[i] || !strings.EqualFold(fields[i].name(), name) {
+				continue
+			}
+			used[i] = true
+			_, _ = io.WriteString(h, dkimCanonicalHeader(ds.o.HeaderCanonicalization, fields[i].raw))
+			signed = append(signed, name)
+			break
+		}
+	}
+	if len(signed) == 0 || signed[0] != "from" {
+		return nil, errors.NewNotValidf("[email] DKIM requires a From header")
+	}
+
+	now := ds.o.Now()
+	var buf bytes.Buffer
+	buf.WriteString("DKIM-Signature: v=1; a=rsa-sha256; c=")
+	buf.WriteString(string(ds.o.HeaderCanonicalization))
+	buf.WriteByte('/')
+	buf.WriteString(string(ds.o.BodyCanonicalization))
+	buf.WriteString("; d=")
+	buf.WriteString(ds.o.Domain)
+	buf.WriteString("; s=")
+	buf.WriteString(ds.o.Selector)
+	buf.WriteString(";\r\n\tt=")
+	buf.WriteString(strconv.FormatInt(now.Unix(), 10))
+	if ds.o.Expiration > 0 {
+		buf.WriteString("; x=")
+		buf.WriteString(strconv.FormatInt(now.Add(ds.o.Expiration).Unix(), 10))
+	}
+	buf.WriteString("; h=")
+	buf.WriteString(strings.Join(signed, ":"))
+	buf.WriteString(";\r\n\tbh=")
+	buf.WriteString(base64.StdEncoding.EncodeToString(bh[:]))
+	buf.WriteString(";\r\n\tb=")
+
+	// The signature header field gets hashed with an empty b= tag and
+	// without the trailing CRLF.
+	sigHeader := dkimCanonicalHeader(ds.o.HeaderCanonicalization, buf.String()+"\r\n")
+	_, _ = io.WriteString(h, strings.TrimSuffix(sigHeader, "\r\n"))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, ds.o.PrivateKey, crypto.SHA256, h.Sum(nil))
+	if err != nil {
+		return nil, errors.NewFatal(err, "[email] DKIM SignPKCS1v15")
+	}
+	buf.WriteString(base64.StdEncoding.EncodeToString(sig))
+	buf.WriteString("\r\n")
+	buf.Write(msg)
+	return buf.Bytes(), nil
+}
+
+// Sender wraps s and signs each message before handing it over to s.
+func (ds *DKIMSigner) Sender(s gomail.Sender) gomail.Sender {
+	return gomail.SendFunc(func(from string, to []string, msg io.WriterTo) error {
+		var buf bytes.Buffer
+		if _, err := msg.WriteTo(&buf); err != nil {
+			return errors.Wrap(err, "[email] DKIMSigner.Sender.WriteTo")
+		}
+		signed, err := ds.Sign(buf.Bytes())
+		if err != nil {
+			return errors.Wrap(err, "[email] DKIMSigner.Sender.Sign")
+		}
+		return s.Send(from, to, bytes.NewReader(signed))
+	})
+}
+
+type headerField struct {
+	// raw contains the complete field including folded lines and the
+	// trailing CRLF.
+	raw string
+}
+
+func (hf headerField) name() string {
+	if i := strings.IndexByte(hf.raw, ':'); i >= 0 {
+		return strings.TrimRight(hf.raw[:i], " \t")
+	}
+	return ""
+}
+
+// splitHeaderFields splits the header block into fields. Continuation lines
+// starting with a WSP belong to the previous field.
+func splitHeaderFields(header []byte) []headerField {
+	var fields []headerField
+	for _, line := range strings.SplitAfter(string(header), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1].raw += line
+			continue
+		}
+		fields = append(fields, headerField{raw: line})
+	}
+	return fields
+}
+
+// dkimCanonicalHeader canonicalizes a header field including its trailing
+// CRLF, RFC 6376 section 3.4.1 and 3.4.2.
+func dkimCanonicalHeader(c DKIMCanonicalization, raw string) string {
+	if c == DKIMSimple {
+		return raw
+	}
+	i := strings.IndexByte(raw, ':')
+	if i < 0 {
+		return raw
+	}
+	name := strings.ToLower(strings.TrimRight(raw[:i], " \t"))
+	value := strings.Replace(raw[i+1:], "\r\n", "", -1)
+	value = strings.TrimSpace(compressWSP(value))
+	return name + ":" + value + "\r\n"
+}
+
+// dkimCanonicalBody canonicalizes the body, RFC 6376 section 3.4.3 and 3.4.4.
+func dkimCanonicalBody(c DKIMCanonicalization, body []byte) []byte {
+	lines := strings.SplitAfter(string(body), "\r\n")
+	var buf bytes.Buffer
+	buf.Grow(len(body) + 2)
+	var emptyLines int
+	for _, l := range lines {
+		if l == "" {
+			continue
+		}
+		if c == DKIMRelaxed {
+			l = strings.TrimRight(compressWSP(strings.TrimSuffix(l, "\r\n")), " ") + "\r\n"
+		} else if !strings.HasSuffix(l, "\r\n") {
+			l += "\r\n"
+		}
+		if l == "\r\n" {
+			// empty lines at the end of the body get ignored.
+			emptyLines++
+			continue
+		}
+		for ; emptyLines > 0; emptyLines-- {
+			buf.WriteString("\r\n")
+		}
+		buf.WriteString(l)
+	}
+	if buf.Len() == 0 && c == DKIMSimple {
+		return []byte("\r\n")
+	}
+	return buf.Bytes()
+}
+
+// compressWSP replaces all sequences of spaces and tabs with one space.
+func compressWSP(s string) string {
+	var buf bytes.Buffer
+	buf.Grow(len(s))
+	wsp := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' || s[i] == '\t' {
+			wsp = true
+			continue
+		}
+		if wsp {
+			buf.WriteByte(' ')
+			wsp = false
+		}
+		buf.WriteByte(s[i])
+	}
+	if wsp {
+		buf.WriteByte(' ')
+	}
+	return buf.String()
+}
+
+// toCRLF converts bare LF line endings into CRLF.
+func toCRLF(msg []byte) []byte {
+	if bytes.Count(msg, []byte("\n")) == bytes.Count(msg, []byte("\r\n")) {
+		return msg
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(msg) + len(msg)/40)
+	for i, b := range msg {
+		if b == '\n' && (i == 0 || msg[i-1] != '\r') {
+			buf.WriteByte('\r')
+		}
+		buf.WriteByte(b)
+	}
+	return buf.Bytes()
+}
@@ -48,6 +48,71 @@ request: AmazonSES, Mailgun, MailJet, SendGrid, PostMark, etc.
 
 The API key must be stored in path: mail.PathSmtpMandrillAPIKey
 
+Hooks and bounces
+
+The functions SetOnSend, SetOnError and SetOnBounce register callbacks for
+the delivery events. Permanent bounces and complaints get stored in a
+SuppressionList, e.g. the TableSuppressionList, and suppressed addresses
+won't be mailed anymore. Bounce notifications of SES or Mailgun can be
+received with the handler returned by Daemon.BounceHandler:
+
+	sl := mail.NewTableSuppressionList(db)
+	d := mail.NewDaemon(cfg, mail.SetSuppressionList(sl), mail.SetOnBounce(fn))
+	mux.Handle("/webhook/ses", d.BounceHandler(mail.SESBounceParser{}))
+
+Address validation
+
+NormalizeAddress checks the syntax of an address and converts international
+domains into punycode. The AddressValidator additionally looks up the MX
+records with a cache and rejects disposable domains. Form handlers can use it
+directly and the daemon rejects messages with invalid recipients in Send:
+
+	v := mail.NewAddressValidator()
+	v.IsDisposable = mail.DisposableDomains("mailinator.com")
+	d := mail.NewDaemon(cfg, mail.SetAddressValidator(v))
+
+Persistent queue
+
+The TableQueue stores messages per store in a MySQL table, so transactional
+mails survive process restarts. A QueueWorker claims the due messages ordered
+by priority, sends them and retries failed messages with an exponential back
+off. After TableQueue.MaxAttempts or a permanent SMTP error the message moves
+into the dead letter status and can be requeued later.
+
+	q := mail.NewTableQueue(db)
+	id, err := q.Enqueue(ctx, storeID, priority, time.Time{}, msg)
+	w := &mail.QueueWorker{Queue: q, Sender: sender, StoreIDs: []int64{storeID}}
+	go w.Run(ctx)
+
+Template preview and test emails
+
+The TemplateAdmin provides two handlers for content teams. The preview renders
+a registered template with sample data for a store and a locale and returns
+the subject, HTML and text body as JSON. The test send handler sends the
+rendered template directly through the daemon, bypassing the queue. Each call
+gets written to the audit log. Mount both handlers behind an authentication
+middleware:
+
+	ts := mail.NewTemplates()
+	ts.Register("order_new", "de", &mail.Template{Subject: subj, HTML: body})
+	ta := &mail.TemplateAdmin{Templates: ts, Sender: d, From: "shop@example.com"}
+	mux.Handle("/admin/email/preview", ta.PreviewHandler())
+	mux.Handle("/admin/email/test", ta.TestSendHandler())
+
+DKIM
+
+Messages can be signed with DKIM before submission to pass the DMARC checks
+of the receiving servers. SetDKIMFromConfig reads the domain, selector, the
+PEM encoded RSA key and the canonicalization from the PathSmtpDKIM* paths of
+the current scope, SetDKIM accepts a custom DKIMSigner:
+
+	ds, err := mail.NewDKIMSigner(mail.DKIMOptions{
+		Domain:     "example.com",
+		Selector:   "mail2017",
+		PrivateKey: key,
+	})
+	d := mail.NewDaemon(cfg, mail.SetDKIM(ds))
+
 Offline sending
 
 If SMTP has been disabled via config key mail.PathSmtpDisable all emails will
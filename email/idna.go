@@ -0,0 +1,160 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/corestoreio/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// idnaPrefix ACE prefix of a punycode encoded domain label.
+const idnaPrefix = "xn--"
+
+// punycode parameters of RFC 3492 section 5.
+const (
+	pcBase        = 36
+	pcTMin        = 1
+	pcTMax        = 26
+	pcSkew        = 38
+	pcDamp        = 700
+	pcInitialBias = 72
+	pcInitialN    = 128
+)
+
+// domainToASCII converts an internationalized domain name into its ASCII
+// compatible encoding. Each label gets NFC normalized, lower cased and, if it
+// contains non ASCII characters, punycode encoded. A trailing dot gets
+// removed. This is the part of IDNA2008 required to deliver mails and does
+// not validate the characters against the IDNA tables.
+func domainToASCII(domain string) (string, error) {
+	if !utf8.ValidString(domain) {
+		return "", errors.NewNotValidf("[email] domainToASCII: Invalid UTF-8 in %q", domain)
+	}
+	domain = strings.TrimSuffix(domain, ".")
+	labels := strings.Split(domain, ".")
+	for i, l := range labels {
+		l = strings.ToLower(norm.NFC.String(l))
+		if isASCII(l) {
+			labels[i] = l
+			continue
+		}
+		enc, err := punyEncode(l)
+		if err != nil {
+			return "", errors.Wrapf(err, "[email] domainToASCII label %q", l)
+		}
+		labels[i] = idnaPrefix + enc
+	}
+	return strings.Join(labels, "."), nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func punyAdapt(delta, numPoints int32, first bool) int32 {
+	if first {
+		delta /= pcDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := int32(0)
+	for delta > ((pcBase-pcTMin)*pcTMax)/2 {
+		delta /= pcBase - pcTMin
+		k += pcBase
+	}
+	return k + (pcBase-pcTMin+1)*delta/(delta+pcSkew)
+}
+
+func punyDigit(d int32) byte {
+	if d < 26 {
+		return byte(d) + 'a'
+	}
+	return byte(d-26) + '0'
+}
+
+// punyEncode encodes a label according to RFC 3492 without the ACE prefix.
+// Error behaviour: NotValid
+func punyEncode(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", errors.NewNotValidf("[email] punyEncode: Invalid UTF-8 in %q", s)
+	}
+	runes := []rune(s)
+	out := make([]byte, 0, len(s)+8)
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := int32(len(out))
+	h := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := int32(pcInitialN), int32(0), int32(pcInitialBias)
+	total := int32(len(runes))
+	for h < total {
+		m := int32(utf8.MaxRune + 1)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		if (m - n) > (1<<31-1-delta)/(h+1) {
+			return "", errors.NewNotValidf("[email] punyEncode: Overflow in %q", s)
+		}
+		delta += (m - n) * (h + 1)
+		n = m
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := int32(pcBase); ; k += pcBase {
+				t := k - bias
+				switch {
+				case t < pcTMin:
+					t = pcTMin
+				case t > pcTMax:
+					t = pcTMax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, punyDigit(t+(q-t)%(pcBase-t)))
+				q = (q - t) / (pcBase - t)
+			}
+			out = append(out, punyDigit(q))
+			bias = punyAdapt(delta, h+1, h == basic)
+			delta = 0
+			h++
+		}
+		delta++
+		n++
+	}
+	return string(out), nil
+}
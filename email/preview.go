@@ -0,0 +1,262 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"bytes"
+	"encoding/json"
+	htemplate "html/template"
+	"net/http"
+	"strings"
+	"sync"
+	ttemplate "text/template"
+
+	"github.com/corestoreio/errors"
+	"github.com/go-gomail/gomail"
+)
+
+// MessageSender sends a message immediately. Implemented by *Daemon.
+type MessageSender interface {
+	Send(m *gomail.Message) error
+}
+
+// Template defines an email template with its subject, an HTML and a plain
+// text body. All parts are optional but at least one body must be set.
+type Template struct {
+	Subject *ttemplate.Template
+	HTML    *htemplate.Template
+	Text    *ttemplate.Template
+	// SampleData gets used for previews and test emails when a request
+	// contains no data.
+	SampleData interface{}
+}
+
+// TemplateData gets passed to the execution of a Template.
+type TemplateData struct {
+	StoreID int64
+	Locale  string
+	Data    interface{}
+}
+
+// RenderedTemplate contains the executed parts of a Template.
+type RenderedTemplate struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html,omitempty"`
+	Text    string `json:"text,omitempty"`
+}
+
+// Render executes all parts of the template with the data.
+func (t *Template) Render(d TemplateData) (RenderedTemplate, error) {
+	var rt RenderedTemplate
+	if t.HTML == nil && t.Text == nil {
+		return rt, errors.NewEmptyf("[email] Template: HTML and Text body are empty")
+	}
+	var buf bytes.Buffer
+	if t.Subject != nil {
+		if err := t.Subject.Execute(&buf, d); err != nil {
+			return rt, errors.NewFatalf("[email] Template.Subject: %s", err)
+		}
+		rt.Subject = strings.TrimSpace(buf.String())
+		buf.Reset()
+	}
+	if t.HTML != nil {
+		if err := t.HTML.Execute(&buf, d); err != nil {
+			return rt, errors.NewFatalf("[email] Template.HTML: %s", err)
+		}
+		rt.HTML = buf.String()
+		buf.Reset()
+	}
+	if t.Text != nil {
+		if err := t.Text.Execute(&buf, d); err != nil {
+			return rt, errors.NewFatalf("[email] Template.Text: %s", err)
+		}
+		rt.Text = buf.String()
+	}
+	return rt, nil
+}
+
+// Templates a thread safe registry of templates identified by an ID and a
+// locale.
+type Templates struct {
+	mu sync.RWMutex
+	m  map[string]*Template
+}
+
+// NewTemplates creates a new empty template registry.
+func NewTemplates() *Templates {
+	return &Templates{m: make(map[string]*Template)}
+}
+
+func templateKey(id, locale string) string {
+	if locale == "" {
+		return id
+	}
+	return id + "/" + locale
+}
+
+// Register adds a template for an ID and a locale. An empty locale defines
+// the fallback template for all locales.
+func (ts *Templates) Register(id, locale string, t *Template) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.m[templateKey(id, locale)] = t
+}
+
+// Find returns the template for the ID and the locale. It falls back from
+// e.g. "de_CH" to "de" and then to the template without a locale.
+// Error behaviour: NotFound
+func (ts *Templates) Find(id, locale string) (*Template, error) {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	for {
+		if t, ok := ts.m[templateKey(id, locale)]; ok {
+			return t, nil
+		}
+		if locale == "" {
+			return nil, errors.NewNotFoundf("[email] Template %q not found", id)
+		}
+		if pos := strings.IndexAny(locale, "_-"); pos > 0 {
+			locale = locale[:pos]
+		} else {
+			locale = ""
+		}
+	}
+}
+
+// TemplateRequest defines the JSON body of the preview and test send
+// handlers.
+type TemplateRequest struct {
+	Template string          `json:"template"`
+	StoreID  int64           `json:"store_id"`
+	Locale   string          `json:"locale"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	// To recipient of the test email, ignored by the preview.
+	To string `json:"to,omitempty"`
+}
+
+// TemplateAdmin provides HTTP handlers for content teams to preview
+// templates with sample data and to send test emails. Both handlers should
+// only be mounted behind an authentication middleware.
+type TemplateAdmin struct {
+	// Templates required registry of all templates.
+	Templates *Templates
+	// Sender required for test emails, usually a *Daemon. Test emails do not
+	// pass the persistent TableQueue.
+	Sender MessageSender
+	// From address of test emails.
+	From string
+	// Actor optional function to extract the user name for the audit log.
+	// Defaults to the remote address of the request.
+	Actor func(*http.Request) string
+}
+
+func (ta *TemplateAdmin) actor(r *http.Request) string {
+	if ta.Actor != nil {
+		return ta.Actor(r)
+	}
+	return r.RemoteAddr
+}
+
+// render decodes the request and renders the requested template. The returned
+// status code is only valid in case of an error.
+func (ta *TemplateAdmin) render(r *http.Request) (TemplateRequest, RenderedTemplate, int, error) {
+	var tr TemplateRequest
+	if r.Method != "POST" {
+		return tr, RenderedTemplate{}, http.StatusMethodNotAllowed, errors.NewNotSupportedf("[email] Method %q not supported", r.Method)
+	}
+	if err := json.NewDecoder(r.Body).Decode(&tr); err != nil {
+		return tr, RenderedTemplate{}, http.StatusBadRequest, errors.NewNotValidf("[email] TemplateRequest: %s", err)
+	}
+	t, err := ta.Templates.Find(tr.Template, tr.Locale)
+	if err != nil {
+		return tr, RenderedTemplate{}, http.StatusNotFound, errors.Wrap(err, "[email] TemplateAdmin.Find")
+	}
+	td := TemplateData{StoreID: tr.StoreID, Locale: tr.Locale, Data: t.SampleData}
+	if len(tr.Data) > 0 {
+		var data interface{}
+		if err := json.Unmarshal(tr.Data, &data); err != nil {
+			return tr, RenderedTemplate{}, http.StatusBadRequest, errors.NewNotValidf("[email] TemplateRequest.Data: %s", err)
+		}
+		td.Data = data
+	}
+	rt, err := t.Render(td)
+	if err != nil {
+		return tr, RenderedTemplate{}, http.StatusUnprocessableEntity, errors.Wrap(err, "[email] TemplateAdmin.Render")
+	}
+	return tr, rt, 0, nil
+}
+
+// PreviewHandler renders the template of a POSTed TemplateRequest and
+// responds with the rendered parts as JSON.
+func (ta *TemplateAdmin) PreviewHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tr, rt, code, err := ta.render(r)
+		if err != nil {
+			PkgLog.Info("email.TemplateAdmin.Preview", "err", err, "template", tr.Template)
+			http.Error(w, err.Error(), code)
+			return
+		}
+		PkgLog.Info("email.TemplateAdmin.Preview", "template", tr.Template, "store_id", tr.StoreID, "locale", tr.Locale, "actor", ta.actor(r))
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(rt); err != nil {
+			PkgLog.Info("email.TemplateAdmin.Preview.Encode", "err", err)
+		}
+	})
+}
+
+// TestSendHandler renders the template of a POSTed TemplateRequest and sends
+// it immediately to the address in field To. The subject gets prefixed with
+// "[TEST]". Each test email gets recorded in the audit log.
+func (ta *TemplateAdmin) TestSendHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tr, rt, code, err := ta.render(r)
+		if err != nil {
+			PkgLog.Info("email.TemplateAdmin.TestSend", "err", err, "template", tr.Template)
+			http.Error(w, err.Error(), code)
+			return
+		}
+		to, err := NormalizeAddress(tr.To)
+		if err != nil {
+			PkgLog.Info("email.TemplateAdmin.TestSend.NormalizeAddress", "err", err, "to", tr.To)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		m := gomail.NewMessage()
+		m.SetHeader("From", ta.From)
+		m.SetHeader("To", to)
+		m.SetHeader("Subject", "[TEST] "+rt.Subject)
+		switch {
+		case rt.HTML != "" && rt.Text != "":
+			m.SetBody("text/plain", rt.Text)
+			m.AddAlternative("text/html", rt.HTML)
+		case rt.HTML != "":
+			m.SetBody("text/html", rt.HTML)
+		default:
+			m.SetBody("text/plain", rt.Text)
+		}
+
+		actor := ta.actor(r)
+		if err := ta.Sender.Send(m); err != nil {
+			PkgLog.Info("email.TemplateAdmin.TestSend.Send", "err", err, "template", tr.Template, "store_id", tr.StoreID, "locale", tr.Locale, "to", to, "actor", actor)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		PkgLog.Info("email.TemplateAdmin.TestSend", "template", tr.Template, "store_id", tr.StoreID, "locale", tr.Locale, "to", to, "actor", actor)
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email_test
+
+import (
+	htemplate "html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	ttemplate "text/template"
+
+	"github.com/corestoreio/csfw/email"
+	"github.com/corestoreio/errors"
+	"github.com/go-gomail/gomail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSender struct {
+	err  error
+	msgs []*gomail.Message
+}
+
+func (ms *mockSender) Send(m *gomail.Message) error {
+	ms.msgs = append(ms.msgs, m)
+	return ms.err
+}
+
+func newTestTemplates() *email.Templates {
+	ts := email.NewTemplates()
+	ts.Register("order_new", "", &email.Template{
+		Subject:    ttemplate.Must(ttemplate.New("s").Parse(`Order {{.Data.id}}`)),
+		HTML:       htemplate.Must(htemplate.New("h").Parse(`<p>Store {{.StoreID}}: {{.Data.name}}</p>`)),
+		Text:       ttemplate.Must(ttemplate.New("t").Parse(`Store {{.StoreID}}: {{.Data.name}}`)),
+		SampleData: map[string]interface{}{"id": "100001", "name": "Gopher"},
+	})
+	ts.Register("order_new", "de", &email.Template{
+		Subject: ttemplate.Must(ttemplate.New("s").Parse(`Bestellung {{.Data.id}}`)),
+		Text:    ttemplate.Must(ttemplate.New("t").Parse(`Sprache {{.Locale}}`)),
+	})
+	return ts
+}
+
+func TestTemplates_Find(t *testing.T) {
+	ts := newTestTemplates()
+	tests := []struct {
+		id, locale  string
+		wantSubject string
+		wantErrBhf  errors.BehaviourFunc
+	}{
+		{"order_new", "", "Order 1", nil},
+		{"order_new", "en_US", "Order 1", nil},
+		{"order_new", "de_CH", "Bestellung 1", nil},
+		{"order_new", "de-AT", "Bestellung 1", nil},
+		{"order_new", "de", "Bestellung 1", nil},
+		{"invoice", "de_DE", "", errors.IsNotFound},
+	}
+	for i, test := range tests {
+		tpl, err := ts.Find(test.id, test.locale)
+		if test.wantErrBhf != nil {
+			assert.True(t, test.wantErrBhf(err), "Index %d => %+v", i, err)
+			continue
+		}
+		require.NoError(t, err, "Index %d", i)
+		rt, err := tpl.Render(email.TemplateData{Data: map[string]string{"id": "1"}})
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantSubject, rt.Subject, "Index %d", i)
+	}
+}
+
+func TestTemplateAdmin_PreviewHandler(t *testing.T) {
+	ta := &email.TemplateAdmin{Templates: newTestTemplates()}
+
+	t.Run("sample data", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/preview", strings.NewReader(`{"template":"order_new","store_id":3,"locale":"en_GB"}`))
+		rec := httptest.NewRecorder()
+		ta.PreviewHandler().ServeHTTP(rec, req)
+		assert.Exactly(t, http.StatusOK, rec.Code)
+		assert.Exactly(t, `{"subject":"Order 100001","html":"<p>Store 3: Gopher</p>","text":"Store 3: Gopher"}`+"\n", rec.Body.String())
+	})
+
+	t.Run("request data", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/preview", strings.NewReader(`{"template":"order_new","store_id":3,"data":{"id":"7","name":"<b>"}}`))
+		rec := httptest.NewRecorder()
+		ta.PreviewHandler().ServeHTTP(rec, req)
+		assert.Exactly(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"subject":"Order 7"`)
+		assert.Contains(t, rec.Body.String(), `Store 3: &lt;b&gt;`)
+	})
+
+	tests := []struct {
+		method   string
+		body     string
+		wantCode int
+	}{
+		{"GET", ``, http.StatusMethodNotAllowed},
+		{"POST", `{"template":`, http.StatusBadRequest},
+		{"POST", `{"template":"invoice"}`, http.StatusNotFound},
+		{"POST", `{"template":"order_new","data":{"id":`, http.StatusBadRequest},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest(test.method, "/preview", strings.NewReader(test.body))
+		rec := httptest.NewRecorder()
+		ta.PreviewHandler().ServeHTTP(rec, req)
+		assert.Exactly(t, test.wantCode, rec.Code, "Index %d", i)
+	}
+}
+
+func TestTemplateAdmin_TestSendHandler(t *testing.T) {
+	ms := &mockSender{}
+	ta := &email.TemplateAdmin{
+		Templates: newTestTemplates(),
+		Sender:    ms,
+		From:      "shop@example.com",
+		Actor:     func(*http.Request) string { return "content-team" },
+	}
+
+	req := httptest.NewRequest("POST", "/test-send", strings.NewReader(`{"template":"order_new","locale":"de_DE","to":"Gopher <Gopher@Example.COM>","data":{"id":"9"}}`))
+	rec := httptest.NewRecorder()
+	ta.TestSendHandler().ServeHTTP(rec, req)
+	assert.Exactly(t, http.StatusAccepted, rec.Code)
+	require.Len(t, ms.msgs, 1)
+	assert.Exactly(t, []string{"Gopher@example.com"}, ms.msgs[0].GetHeader("To"))
+	assert.Exactly(t, []string{"[TEST] Bestellung 9"}, ms.msgs[0].GetHeader("Subject"))
+
+	t.Run("invalid address", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/test-send", strings.NewReader(`{"template":"order_new","to":"gopher"}`))
+		rec := httptest.NewRecorder()
+		ta.TestSendHandler().ServeHTTP(rec, req)
+		assert.Exactly(t, http.StatusBadRequest, rec.Code)
+		assert.Len(t, ms.msgs, 1)
+	})
+
+	t.Run("send error", func(t *testing.T) {
+		ms.err = errors.NewAlreadyClosedf("channel closed")
+		req := httptest.NewRequest("POST", "/test-send", strings.NewReader(`{"template":"order_new","to":"gopher@example.com"}`))
+		rec := httptest.NewRecorder()
+		ta.TestSendHandler().ServeHTTP(rec, req)
+		assert.Exactly(t, http.StatusBadGateway, rec.Code)
+	})
+}
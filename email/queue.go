@@ -0,0 +1,370 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/go-gomail/gomail"
+)
+
+// QueueTableName default name of the table used by TableQueue.
+const QueueTableName = "email_queue"
+
+// Status of a message in the queue table.
+const (
+	QueueStatusPending = "pending"
+	QueueStatusSending = "sending"
+	QueueStatusSent    = "sent"
+	QueueStatusDead    = "dead"
+)
+
+// QueueEntry represents a row in the queue table.
+type QueueEntry struct {
+	ID      int64
+	StoreID int64
+	// Priority higher values get sent first.
+	Priority int
+	SendAt   time.Time
+	Attempts int
+	// From and To contain the envelope addresses.
+	From string
+	To   []string
+	// Message the raw RFC 5322 message including all headers.
+	Message []byte
+}
+
+// WriteTo implements the io.WriterTo interface and writes the raw message,
+// so a QueueEntry can be passed to a gomail.Sender.
+func (qe QueueEntry) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(qe.Message)
+	return int64(n), err
+}
+
+// TableQueue persists messages in a MySQL table, so transactional mails
+// survive process restarts. The table gets created with CreateTable. Several
+// workers can process the same table because each batch gets claimed with a
+// unique token before sending.
+type TableQueue struct {
+	DB interface {
+		dbr.Execer
+		dbr.Querier
+	}
+	// Table defines the schema and name. Defaults to QueueTableName.
+	Table *csdb.Table
+	// MaxAttempts after this number of failed attempts a message gets moved
+	// into the dead letter status. Defaults to 5.
+	MaxAttempts int
+	// RetryDelay base delay before a failed message gets retried. The delay
+	// doubles with each attempt. Defaults to one minute.
+	RetryDelay time.Duration
+	// ClaimTimeout after this duration a claimed message, whose worker has
+	// died, gets claimed again. Defaults to 10 minutes.
+	ClaimTimeout time.Duration
+}
+
+// NewTableQueue creates a new queue using the default table name.
+func NewTableQueue(db *sql.DB) *TableQueue {
+	return &TableQueue{
+		DB:           db,
+		Table:        csdb.NewTable(QueueTableName),
+		MaxAttempts:  5,
+		RetryDelay:   time.Minute,
+		ClaimTimeout: 10 * time.Minute,
+	}
+}
+
+func (q *TableQueue) tableName() string {
+	if q.Table == nil {
+		return dbr.Quoter.Quote(QueueTableName)
+	}
+	return dbr.Quoter.QuoteQualified(q.Table.Schema, q.Table.Name)
+}
+
+// CreateTable creates the queue table if it does not exist.
+func (q *TableQueue) CreateTable(ctx context.Context) error {
+	_, err := q.DB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+q.tableName()+` (
+  id bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  store_id int(10) unsigned NOT NULL DEFAULT 0,
+  priority smallint(6) NOT NULL DEFAULT 0,
+  status varchar(16) NOT NULL DEFAULT 'pending',
+  send_at datetime NOT NULL,
+  attempts smallint(5) unsigned NOT NULL DEFAULT 0,
+  last_error text,
+  claim_token varchar(32) NOT NULL DEFAULT '',
+  claimed_at datetime DEFAULT NULL,
+  sender varchar(255) NOT NULL,
+  recipients text NOT NULL,
+  message mediumblob NOT NULL,
+  created_at datetime NOT NULL,
+  sent_at datetime DEFAULT NULL,
+  PRIMARY KEY (id),
+  KEY IDX_EMAIL_QUEUE_STATUS_SEND_AT (status,send_at),
+  KEY IDX_EMAIL_QUEUE_CLAIM_TOKEN (claim_token)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`)
+	return errors.Wrapf(err, "[email] TableQueue.CreateTable %s", q.tableName())
+}
+
+// Enqueue serializes the message and stores it for the store ID. The message
+// gets sent at or after sendAt; a zero sendAt means now. Returns the ID of the
+// new queue entry.
+func (q *TableQueue) Enqueue(ctx context.Context, storeID int64, priority int, sendAt time.Time, m *gomail.Message) (int64, error) {
+	from, to := envelope(m)
+	if from == "" || len(to) == 0 {
+		return 0, errors.NewEmptyf("[email] TableQueue.Enqueue: Sender %q or recipients %v missing", from, to)
+	}
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		return 0, errors.Wrap(err, "[email] TableQueue.Enqueue.WriteTo")
+	}
+	now := time.Now()
+	if sendAt.IsZero() {
+		sendAt = now
+	}
+	res, err := q.DB.ExecContext(ctx, "INSERT INTO "+q.tableName()+
+		" (store_id,priority,status,send_at,sender,recipients,message,created_at) VALUES (?,?,?,?,?,?,?,?)",
+		storeID, priority, QueueStatusPending, sendAt, from, strings.Join(to, "\n"), buf.Bytes(), now,
+	)
+	if err != nil {
+		return 0, errors.Wrapf(err, "[email] TableQueue.Enqueue Store %d", storeID)
+	}
+	id, err := res.LastInsertId()
+	return id, errors.Wrap(err, "[email] TableQueue.Enqueue.LastInsertId")
+}
+
+// Claim marks up to limit due messages as being sent and returns them ordered
+// by priority and due date. Messages whose claim has timed out get claimed
+// again. An empty storeIDs slice claims messages of all stores.
+func (q *TableQueue) Claim(ctx context.Context, limit int, storeIDs ...int64) ([]QueueEntry, error) {
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "[email] TableQueue.Claim.Token")
+	}
+	now := time.Now()
+	claimTimeout := q.ClaimTimeout
+	if claimTimeout <= 0 {
+		claimTimeout = 10 * time.Minute
+	}
+
+	var where bytes.Buffer
+	where.WriteString(" WHERE send_at <= ? AND (status = ? OR (status = ? AND claimed_at < ?))")
+	args := []interface{}{token, QueueStatusSending, now, now, QueueStatusPending, QueueStatusSending, now.Add(-claimTimeout)}
+	if len(storeIDs) > 0 {
+		where.WriteString(" AND store_id IN (")
+		for i, id := range storeIDs {
+			if i > 0 {
+				where.WriteByte(',')
+			}
+			where.WriteByte('?')
+			args = append(args, id)
+		}
+		where.WriteByte(')')
+	}
+	args = append(args, limit)
+
+	if _, err := q.DB.ExecContext(ctx, "UPDATE "+q.tableName()+
+		" SET claim_token = ?, status = ?, claimed_at = ?"+where.String()+
+		" ORDER BY priority DESC, send_at ASC, id ASC LIMIT ?", args...); err != nil {
+		return nil, errors.Wrapf(err, "[email] TableQueue.Claim.Update %s", q.tableName())
+	}
+
+	rows, err := q.DB.QueryContext(ctx, "SELECT id,store_id,priority,send_at,attempts,sender,recipients,message FROM "+q.tableName()+
+		" WHERE claim_token = ? AND status = ? ORDER BY priority DESC, id ASC", token, QueueStatusSending)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[email] TableQueue.Claim.Query %s", q.tableName())
+	}
+	defer rows.Close()
+
+	var entries []QueueEntry
+	for rows.Next() {
+		var qe QueueEntry
+		var rcpts string
+		if err := rows.Scan(&qe.ID, &qe.StoreID, &qe.Priority, &qe.SendAt, &qe.Attempts, &qe.From, &rcpts, &qe.Message); err != nil {
+			return nil, errors.Wrap(err, "[email] TableQueue.Claim.Scan")
+		}
+		qe.To = strings.Split(rcpts, "\n")
+		entries = append(entries, qe)
+	}
+	return entries, errors.Wrap(rows.Err(), "[email] TableQueue.Claim.Rows")
+}
+
+// MarkSent sets the status of a claimed message to sent.
+func (q *TableQueue) MarkSent(ctx context.Context, id int64) error {
+	_, err := q.DB.ExecContext(ctx, "UPDATE "+q.tableName()+" SET status = ?, sent_at = ?, claim_token = '' WHERE id = ?",
+		QueueStatusSent, time.Now(), id)
+	return errors.Wrapf(err, "[email] TableQueue.MarkSent ID %d", id)
+}
+
+// MarkFailed increments the attempts of a claimed message and schedules a
+// retry with exponential back off. If the maximum attempts have been reached
+// or the error is permanent, the message moves into the dead letter status.
+// Returns true if the message is dead.
+func (q *TableQueue) MarkFailed(ctx context.Context, qe QueueEntry, sendErr error, permanent bool) (dead bool, err error) {
+	maxAttempts := q.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 5
+	}
+	attempts := qe.Attempts + 1
+	status := QueueStatusPending
+	if permanent || attempts >= maxAttempts {
+		status = QueueStatusDead
+	}
+	retryAt := time.Now().Add(q.retryDelay(attempts))
+
+	var errMsg string
+	if sendErr != nil {
+		errMsg = sendErr.Error()
+	}
+	_, err = q.DB.ExecContext(ctx, "UPDATE "+q.tableName()+
+		" SET status = ?, attempts = ?, last_error = ?, send_at = ?, claim_token = '' WHERE id = ?",
+		status, attempts, errMsg, retryAt, qe.ID)
+	return status == QueueStatusDead, errors.Wrapf(err, "[email] TableQueue.MarkFailed ID %d", qe.ID)
+}
+
+func (q *TableQueue) retryDelay(attempts int) time.Duration {
+	d := q.RetryDelay
+	if d <= 0 {
+		d = time.Minute
+	}
+	for i := 1; i < attempts && d < 24*time.Hour; i++ {
+		d *= 2
+	}
+	return d
+}
+
+// Requeue moves dead messages of a store back into the pending status, e.g.
+// after a misconfigured SMTP server has been fixed. Returns the number of
+// affected messages.
+func (q *TableQueue) Requeue(ctx context.Context, storeID int64) (int64, error) {
+	res, err := q.DB.ExecContext(ctx, "UPDATE "+q.tableName()+
+		" SET status = ?, attempts = 0, send_at = ? WHERE status = ? AND store_id = ?",
+		QueueStatusPending, time.Now(), QueueStatusDead, storeID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "[email] TableQueue.Requeue Store %d", storeID)
+	}
+	n, err := res.RowsAffected()
+	return n, errors.Wrap(err, "[email] TableQueue.Requeue.RowsAffected")
+}
+
+// QueueWorker sends the due messages of a TableQueue.
+type QueueWorker struct {
+	Queue *TableQueue
+	// Sender delivers the raw messages, e.g. a gomail.SendCloser of a
+	// gomail.Dialer or OfflineSend.
+	Sender gomail.Sender
+	// StoreIDs restricts the worker to messages of these stores. Useful when
+	// stores use different SMTP servers. Empty processes all stores.
+	StoreIDs []int64
+	// BatchSize number of messages claimed at once. Defaults to 50.
+	BatchSize int
+	// Interval between two polls of the table. Defaults to 10 seconds.
+	Interval time.Duration
+	// OnDead gets called when a message has been moved to the dead letter
+	// status. Optional.
+	OnDead func(QueueEntry, error)
+}
+
+// Run processes the queue until the context gets cancelled. Database errors
+// get logged and the worker tries again after the Interval.
+func (w *QueueWorker) Run(ctx context.Context) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := w.ProcessDue(ctx)
+			if err != nil {
+				PkgLog.Info("mail.QueueWorker.Run.ProcessDue", "err", err)
+			}
+			if err != nil || n < w.batchSize() {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *QueueWorker) batchSize() int {
+	if w.BatchSize > 0 {
+		return w.BatchSize
+	}
+	return 50
+}
+
+// ProcessDue claims one batch of due messages and sends them. Returns the
+// number of claimed messages.
+func (w *QueueWorker) ProcessDue(ctx context.Context) (int, error) {
+	entries, err := w.Queue.Claim(ctx, w.batchSize(), w.StoreIDs...)
+	if err != nil {
+		return 0, errors.Wrap(err, "[email] QueueWorker.Claim")
+	}
+	for _, qe := range entries {
+		if sendErr := w.Sender.Send(qe.From, qe.To, qe); sendErr != nil {
+			_, _, typ := ParseSMTPReply(sendErr.Error())
+			dead, err := w.Queue.MarkFailed(ctx, qe, sendErr, typ == BounceHard)
+			if err != nil {
+				return len(entries), errors.Wrap(err, "[email] QueueWorker.MarkFailed")
+			}
+			if dead && w.OnDead != nil {
+				w.OnDead(qe, sendErr)
+			}
+			continue
+		}
+		if err := w.Queue.MarkSent(ctx, qe.ID); err != nil {
+			return len(entries), errors.Wrap(err, "[email] QueueWorker.MarkSent")
+		}
+	}
+	return len(entries), nil
+}
+
+// envelope extracts the sender and all recipients of a message.
+func envelope(m *gomail.Message) (from string, to []string) {
+	if s := m.GetHeader("Sender"); len(s) > 0 {
+		from = parseAddress(s[0])
+	} else if f := m.GetHeader("From"); len(f) > 0 {
+		from = parseAddress(f[0])
+	}
+	for _, h := range recipientHeaders {
+		for _, a := range m.GetHeader(h) {
+			to = append(to, parseAddress(a))
+		}
+	}
+	return from, to
+}
+
+func newClaimToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
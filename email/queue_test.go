@@ -0,0 +1,160 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/email"
+	"github.com/go-gomail/gomail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ io.WriterTo = (*email.QueueEntry)(nil)
+
+func TestTableQueue_Enqueue(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, db.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+	q := email.NewTableQueue(db)
+	sendAt := time.Now().Add(time.Hour)
+
+	dbMock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS `email_queue`")).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `email_queue` (store_id,priority,status,send_at,sender,recipients,message,created_at) VALUES (?,?,?,?,?,?,?,?)")).
+		WithArgs(2, 10, "pending", sendAt, "shop@example.com", "jane@example.com\njohn@example.com", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(33, 1))
+
+	require.NoError(t, q.CreateTable(context.Background()))
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", "Shop <shop@example.com>")
+	m.SetHeader("To", "jane@example.com")
+	m.SetHeader("Bcc", "John <john@example.com>")
+	m.SetHeader("Subject", "Your order")
+	m.SetBody("text/plain", "Thanks!")
+
+	id, err := q.Enqueue(context.Background(), 2, 10, sendAt, m)
+	require.NoError(t, err)
+	assert.Exactly(t, int64(33), id)
+
+	_, err = q.Enqueue(context.Background(), 2, 10, sendAt, gomail.NewMessage())
+	assert.Error(t, err, "Sender and recipients missing")
+}
+
+func TestQueueWorker_ProcessDue(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, db.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+	q := email.NewTableQueue(db)
+	q.MaxAttempts = 3
+
+	dbMock.ExpectExec(regexp.QuoteMeta("UPDATE `email_queue` SET claim_token = ?, status = ?, claimed_at = ? WHERE send_at <= ? AND (status = ? OR (status = ? AND claimed_at < ?)) AND store_id IN (?,?) ORDER BY priority DESC, send_at ASC, id ASC LIMIT ?")).
+		WithArgs(sqlmock.AnyArg(), "sending", sqlmock.AnyArg(), sqlmock.AnyArg(), "pending", "sending", sqlmock.AnyArg(), 1, 2, 10).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT id,store_id,priority,send_at,attempts,sender,recipients,message FROM `email_queue` WHERE claim_token = ? AND status = ? ORDER BY priority DESC, id ASC")).
+		WithArgs(sqlmock.AnyArg(), "sending").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "store_id", "priority", "send_at", "attempts", "sender", "recipients", "message"}).
+			AddRow(1, 1, 5, time.Now(), 0, "shop@example.com", "jane@example.com", []byte("Subject: A\r\n\r\nA")).
+			AddRow(2, 2, 0, time.Now(), 2, "shop@example.com", "john@example.com\nmax@example.com", []byte("Subject: B\r\n\r\nB")).
+			AddRow(3, 1, 0, time.Now(), 0, "shop@example.com", "unknown@example.com", []byte("Subject: C\r\n\r\nC")))
+
+	dbMock.ExpectExec(regexp.QuoteMeta("UPDATE `email_queue` SET status = ?, sent_at = ?, claim_token = '' WHERE id = ?")).
+		WithArgs("sent", sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	// third attempt reaches MaxAttempts
+	dbMock.ExpectExec(regexp.QuoteMeta("UPDATE `email_queue` SET status = ?, attempts = ?, last_error = ?, send_at = ?, claim_token = '' WHERE id = ?")).
+		WithArgs("dead", 3, "421 Try again later", sqlmock.AnyArg(), 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	// permanent error
+	dbMock.ExpectExec(regexp.QuoteMeta("UPDATE `email_queue` SET status = ?, attempts = ?, last_error = ?, send_at = ?, claim_token = '' WHERE id = ?")).
+		WithArgs("dead", 1, "550 5.1.1 user unknown", sqlmock.AnyArg(), 3).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var sent []string
+	var dead []int64
+	w := &email.QueueWorker{
+		Queue:     q,
+		StoreIDs:  []int64{1, 2},
+		BatchSize: 10,
+		Sender: gomail.SendFunc(func(from string, to []string, msg io.WriterTo) error {
+			switch to[0] {
+			case "john@example.com":
+				assert.Exactly(t, []string{"john@example.com", "max@example.com"}, to)
+				return errors.New("421 Try again later")
+			case "unknown@example.com":
+				return errors.New("550 5.1.1 user unknown")
+			}
+			body, err := ioutil.ReadAll(readerFromWriterTo(msg))
+			require.NoError(t, err)
+			sent = append(sent, from+" => "+string(body))
+			return nil
+		}),
+		OnDead: func(qe email.QueueEntry, _ error) {
+			dead = append(dead, qe.ID)
+		},
+	}
+
+	n, err := w.ProcessDue(context.Background())
+	require.NoError(t, err)
+	assert.Exactly(t, 3, n)
+	assert.Exactly(t, []string{"shop@example.com => Subject: A\r\n\r\nA"}, sent)
+	assert.Exactly(t, []int64{2, 3}, dead)
+}
+
+func TestTableQueue_MarkFailedRetry(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, db.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+	q := email.NewTableQueue(db)
+
+	dbMock.ExpectExec(regexp.QuoteMeta("UPDATE `email_queue` SET status = ?, attempts = ?, last_error = ?")).
+		WithArgs("pending", 2, "timeout", sqlmock.AnyArg(), 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(regexp.QuoteMeta("UPDATE `email_queue` SET status = ?, attempts = 0, send_at = ? WHERE status = ? AND store_id = ?")).
+		WithArgs("pending", sqlmock.AnyArg(), "dead", 4).WillReturnResult(sqlmock.NewResult(0, 12))
+
+	isDead, err := q.MarkFailed(context.Background(), email.QueueEntry{ID: 7, Attempts: 1}, errors.New("timeout"), false)
+	require.NoError(t, err)
+	assert.False(t, isDead)
+
+	n, err := q.Requeue(context.Background(), 4)
+	require.NoError(t, err)
+	assert.Exactly(t, int64(12), n)
+}
+
+func readerFromWriterTo(wt io.WriterTo) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := wt.WriteTo(pw)
+		_ = pw.CloseWithError(err)
+	}()
+	return pr
+}
@@ -34,6 +34,11 @@ const (
 	PathSmtpSetReturnPath   = PathSmtp + "/set_return_path"   // Scope: Default; 0 = no, 1 = yes, 2 = specified in PathSmtpReturnPathEmail
 	PathSmtpReturnPathEmail = PathSmtp + "/return_path_email" // Scope: Default; email address
 	PathSmtpMandrillAPIKey  = PathSmtp + "/mandrill_api_key"  // Scope: Default, Website, Store @todo
+	PathSmtpDKIMDomain      = PathSmtp + "/dkim_domain"       // Scope: Default, Website, Store
+	PathSmtpDKIMSelector    = PathSmtp + "/dkim_selector"     // Scope: Default, Website, Store
+	PathSmtpDKIMPrivateKey  = PathSmtp + "/dkim_private_key"  // Scope: Default, Website, Store; PEM encoded RSA key
+	PathSmtpDKIMHeaderCanon = PathSmtp + "/dkim_header_canon" // Scope: Default, Website, Store; simple or relaxed
+	PathSmtpDKIMBodyCanon   = PathSmtp + "/dkim_body_canon"   // Scope: Default, Website, Store; simple or relaxed
 )
 
 // TODO(cs) implement config paths and options for TLS certificates and its configuration.
@@ -0,0 +1,98 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+)
+
+// SuppressionTableName default name of the table used by
+// TableSuppressionList.
+const SuppressionTableName = "email_suppression"
+
+// TableSuppressionList stores the suppressed addresses in a MySQL table. The
+// table gets created with CreateTable.
+type TableSuppressionList struct {
+	DB interface {
+		dbr.Execer
+		dbr.QueryRower
+	}
+	// Table defines the schema and name. Defaults to SuppressionTableName.
+	Table *csdb.Table
+}
+
+// NewTableSuppressionList creates a new suppression list using the default
+// table name.
+func NewTableSuppressionList(db *sql.DB) *TableSuppressionList {
+	return &TableSuppressionList{
+		DB:    db,
+		Table: csdb.NewTable(SuppressionTableName),
+	}
+}
+
+func (sl *TableSuppressionList) tableName() string {
+	if sl.Table == nil {
+		return dbr.Quoter.Quote(SuppressionTableName)
+	}
+	return dbr.Quoter.QuoteQualified(sl.Table.Schema, sl.Table.Name)
+}
+
+// CreateTable creates the suppression table if it does not exist.
+func (sl *TableSuppressionList) CreateTable(ctx context.Context) error {
+	_, err := sl.DB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+sl.tableName()+` (
+  address varchar(255) NOT NULL,
+  bounce_type varchar(16) NOT NULL,
+  smtp_code smallint(5) unsigned NOT NULL DEFAULT 0,
+  smtp_status varchar(16) NOT NULL DEFAULT '',
+  reason text,
+  provider varchar(32) NOT NULL DEFAULT '',
+  bounce_count int(10) unsigned NOT NULL DEFAULT 1,
+  created_at datetime NOT NULL,
+  updated_at datetime NOT NULL,
+  PRIMARY KEY (address)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`)
+	return errors.Wrapf(err, "[email] TableSuppressionList.CreateTable %s", sl.tableName())
+}
+
+// Suppress inserts the recipient or updates an existing entry and increases
+// its bounce counter.
+func (sl *TableSuppressionList) Suppress(ctx context.Context, b Bounce) error {
+	_, err := sl.DB.ExecContext(ctx, "INSERT INTO "+sl.tableName()+
+		" (address,bounce_type,smtp_code,smtp_status,reason,provider,created_at,updated_at) VALUES (?,?,?,?,?,?,?,?)"+
+		" ON DUPLICATE KEY UPDATE bounce_type=VALUES(bounce_type), smtp_code=VALUES(smtp_code), smtp_status=VALUES(smtp_status),"+
+		" reason=VALUES(reason), provider=VALUES(provider), bounce_count=bounce_count+1, updated_at=VALUES(updated_at)",
+		strings.ToLower(b.Recipient), b.Type.String(), b.Code, b.Status, b.Reason, b.Provider, b.Time, b.Time,
+	)
+	return errors.Wrapf(err, "[email] TableSuppressionList.Suppress %q", b.Recipient)
+}
+
+// IsSuppressed checks if the address exists in the table.
+func (sl *TableSuppressionList) IsSuppressed(ctx context.Context, address string) (bool, error) {
+	var found int
+	err := sl.DB.QueryRowContext(ctx, "SELECT 1 FROM "+sl.tableName()+" WHERE address = ?", strings.ToLower(address)).Scan(&found)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, nil
+	case err != nil:
+		return false, errors.Wrapf(err, "[email] TableSuppressionList.IsSuppressed %q", address)
+	}
+	return found == 1, nil
+}
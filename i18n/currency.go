@@ -58,6 +58,9 @@ type (
 		// Maybe one day that will get extended in text/currency package ...
 		ISO currency.Unit
 		sgn []byte // € or USD or ...
+		// roundMode and roundCash see SetCurrencyRounding
+		roundMode RoundingMode
+		roundCash bool
 	}
 
 	// CurrencyFractions element contains any number of info elements.
@@ -79,7 +82,8 @@ type (
 		// increment of 5, numeric values are rounded to the nearest 0.05 units
 		// in formatting. With fraction digits of 0 and rounding increment of
 		// 50, numeric values are rounded to the nearest 50.
-		// ⚠ Warning: Rounding must be applied in the package money ⚠ @todo
+		// Rounding gets applied by Currency.Round and FmtFloat64, see
+		// SetCurrencyRounding.
 		Rounding int
 		// CashDigits the number of decimal digits to be used when formatting
 		// quantities used in cash transactions (as opposed to a quantity that
@@ -93,7 +97,8 @@ type (
 		// to be used when formatting quantities used in cash transactions (as
 		// opposed to a quantity that would appear in a more formal setting,
 		// such as on a bank statement). If absent, the value of "rounding"
-		// should be used as a default. @todo
+		// should be used as a default. Gets applied when cash rounding has been
+		// enabled with SetCurrencyRounding.
 		CashRounding int
 	}
 
@@ -255,11 +260,17 @@ func (c *Currency) FmtInt64(w io.Writer, i int64) (int, error) {
 }
 
 // FmtCurrencyFloat64 formats a float value, does internal maybe incorrect rounding.
+// A rounding mode or increment set via SetCurrencyRounding or the currency
+// fractions gets applied before formatting, see Round.
 // Returns the number bytes written or an error. Thread safe.
 func (c *Currency) FmtFloat64(w io.Writer, f float64) (int, error) {
 	buf := bufferpool.Get()
 	defer bufferpool.Put(buf)
 
+	if c.roundingEnabled() {
+		f = c.Round(f)
+	}
+
 	if _, err := c.Number.FmtFloat64(buf, f); err != nil {
 		return 0, errors.Wrapf(err, "[i18n] FmtFloat64. Buffer %q; Float %.6f", buf.String(), f)
 	}
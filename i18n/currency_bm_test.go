@@ -148,3 +148,22 @@ func benchmarkFmtCurrencyFloat64_Singleton(b *testing.B, fl float64, format, wan
 		buf.Reset()
 	}
 }
+
+// BenchmarkCurrencyRegistry_Parallel-4	20000000	        73.6 ns/op	       0 B/op	       0 allocs/op
+func BenchmarkCurrencyRegistry_Parallel(b *testing.B) {
+	cr := i18n.NewCurrencyRegistry(i18n.SetCurrencyFormat("#,##0.00 ¤", testDefaultNumberSymbols))
+	cr.Register("de_DE", "EUR", i18n.SetCurrencySign(bmCurrencySign))
+	cr.Get("de_CH", "CHF")
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if c := cr.Get("de_DE", "EUR"); c == nil {
+				b.Fatal("Currency de_DE EUR not found")
+			}
+			if c := cr.Get("de_CH", "CHF"); c == nil {
+				b.Fatal("Currency de_CH CHF not found")
+			}
+		}
+	})
+}
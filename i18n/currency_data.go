@@ -0,0 +1,146 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"strings"
+
+	"github.com/corestoreio/errors"
+)
+
+//go:generate go run internal/gencurrency/main.go -cldr $CLDR_JSON -o currency_data_gen.go
+
+// currencyName contains the locale specific name and symbol of a currency.
+type currencyName struct {
+	name   string
+	symbol string
+}
+
+// currencyLocale contains the generated CLDR currency data of a locale. The
+// generator resolves the format and the symbols with the parent locales,
+// missing names will be inherited from the parent locale.
+type currencyLocale struct {
+	format  string
+	symbols Symbols
+	names   map[string]currencyName
+}
+
+// CurrencyData contains the resolved CLDR information about a currency in a
+// locale. Use LookupCurrency to retrieve it.
+type CurrencyData struct {
+	// Locale the requested locale, e.g. de_CH.
+	Locale string
+	// ISO contains the 3-letter ISO 4217 currency code.
+	ISO string
+	// Name the localized display name, e.g. Schweizer Franken.
+	Name string
+	// Symbol the localized currency symbol. Falls back to the ISO code.
+	Symbol string
+	// Format the CLDR standard currency format pattern of the locale.
+	Format string
+	// Symbols the CLDR number symbols of the locale.
+	Symbols Symbols
+	// Fractions the CLDR supplemental fraction data of the currency.
+	Fractions CurrencyFractions
+}
+
+// parentLocale returns the CLDR parent of a locale. Without an explicit parent
+// in the CLDR supplemental data the last part gets stripped. de_CH returns de,
+// en_150 returns en_001 and de returns an empty string.
+func parentLocale(locale string) string {
+	if p, ok := localeParentTable[locale]; ok {
+		return p
+	}
+	if i := strings.LastIndexAny(locale, "_-"); i > 0 {
+		return locale[:i]
+	}
+	return ""
+}
+
+// LookupCurrency returns the CLDR currency data for a locale (e.g. de_CH or
+// de-CH) and a 3-letter ISO 4217 code. Missing data falls back to the parent
+// locale. Error behaviour: NotFound.
+func LookupCurrency(locale, iso string) (CurrencyData, error) {
+	locale = strings.Replace(locale, "-", LocaleSeparator, -1)
+	iso = strings.ToUpper(iso)
+
+	cd := CurrencyData{
+		Locale: locale,
+		ISO:    iso,
+	}
+
+	// collect the locale chain from the parent to the child to apply the
+	// child data last.
+	var chain []currencyLocale
+	for l := locale; l != ""; l = parentLocale(l) {
+		if cl, ok := currencyLocaleTable[l]; ok {
+			chain = append(chain, cl)
+		}
+	}
+	if len(chain) == 0 {
+		return CurrencyData{}, errors.NewNotFoundf("[i18n] Currency data for locale %q not found", locale)
+	}
+
+	cd.Format = chain[0].format
+	cd.Symbols = chain[0].symbols
+
+	foundName := false
+	for i := len(chain) - 1; i >= 0; i-- {
+		cl := chain[i]
+		if cn, ok := cl.names[iso]; ok {
+			foundName = true
+			if cn.name != "" {
+				cd.Name = cn.name
+			}
+			if cn.symbol != "" {
+				cd.Symbol = cn.symbol
+			}
+		}
+	}
+	if !foundName {
+		return CurrencyData{}, errors.NewNotFoundf("[i18n] Currency %q for locale %q not found", iso, locale)
+	}
+	if cd.Symbol == "" {
+		cd.Symbol = iso
+	}
+
+	cd.Fractions = CurrencyFractionsByISO(iso)
+	return cd, nil
+}
+
+// CurrencyFractionsByISO returns the CLDR fraction data for a 3-letter ISO
+// 4217 code. If the code cannot be found the CLDR default gets returned.
+func CurrencyFractionsByISO(iso string) CurrencyFractions {
+	if cf, ok := currencyFractionTable[strings.ToUpper(iso)]; ok {
+		return cf
+	}
+	return currencyFractionTable["DEFAULT"]
+}
+
+// NewCurrencyByLocale creates a new Currency formatter pre-configured with the
+// CLDR data of a locale and a 3-letter ISO 4217 code. Additional options
+// gets applied afterwards. Error behaviour: NotFound.
+func NewCurrencyByLocale(locale, iso string, opts ...CurrencyOptions) (*Currency, error) {
+	cd, err := LookupCurrency(locale, iso)
+	if err != nil {
+		return nil, errors.Wrap(err, "[i18n] NewCurrencyByLocale.LookupCurrency")
+	}
+	return NewCurrency(append([]CurrencyOptions{
+		SetCurrencyISO(cd.ISO),
+		SetCurrencyFormat(cd.Format, cd.Symbols),
+		SetCurrencySign([]byte(cd.Symbol)),
+		SetCurrencyFraction(cd.Fractions.Digits, cd.Fractions.Rounding, cd.Fractions.CashDigits, cd.Fractions.CashRounding),
+	}, opts...)...), nil
+}
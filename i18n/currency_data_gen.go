@@ -0,0 +1,89 @@
+// Code generated by internal/gencurrency. DO NOT EDIT.
+
+package i18n
+
+// defaultSymbols contains the CLDR number symbols of the root locale.
+var defaultSymbols = Symbols{Decimal: '.', Group: ',', List: ';', PercentSign: '%', CurrencySign: '¤', PlusSign: '+', MinusSign: '-', Exponential: 'E', SuperscriptingExponent: '×', PerMille: '‰', Infinity: '∞', Nan: []byte("NaN")}
+
+// localeParentTable contains the CLDR parent locales which differ from
+// stripping the last part of a locale. An empty parent means root.
+var localeParentTable = map[string]string{
+	"az_Cyrl": "",
+	"en_001":  "en",
+	"en_150":  "en_001",
+	"en_GB":   "en_001",
+}
+
+// currencyFractionTable contains the CLDR supplemental currency fractions.
+var currencyFractionTable = map[string]CurrencyFractions{
+	"CHF":     {Digits: 2, Rounding: 0, CashDigits: 2, CashRounding: 5},
+	"DEFAULT": {Digits: 2, Rounding: 0, CashDigits: 2, CashRounding: 0},
+	"JPY":     {Digits: 0, Rounding: 0, CashDigits: 0, CashRounding: 0},
+}
+
+// currencyLocaleTable contains the CLDR currency data per locale.
+var currencyLocaleTable = map[string]currencyLocale{
+	"de": {
+		format:  "#,##0.00\u00a0¤",
+		symbols: Symbols{Decimal: ',', Group: '.', List: ';', PercentSign: '%', CurrencySign: '¤', PlusSign: '+', MinusSign: '-', Exponential: 'E', SuperscriptingExponent: '·', PerMille: '‰', Infinity: '∞', Nan: []byte("NaN")},
+		names: map[string]currencyName{
+			"CHF": {"Schweizer Franken", "CHF"},
+			"EUR": {"Euro", "€"},
+			"GBP": {"Britisches Pfund", "£"},
+			"JPY": {"Japanischer Yen", "¥"},
+			"USD": {"US-Dollar", "$"},
+		},
+	},
+	"de_CH": {
+		format:  "¤\u00a0#,##0.00;¤-#,##0.00",
+		symbols: Symbols{Decimal: '.', Group: '’', List: ';', PercentSign: '%', CurrencySign: '¤', PlusSign: '+', MinusSign: '-', Exponential: 'E', SuperscriptingExponent: '·', PerMille: '‰', Infinity: '∞', Nan: []byte("NaN")},
+		names: map[string]currencyName{
+			"EUR": {"Euro", "EUR"},
+		},
+	},
+	"en": {
+		format:  "¤#,##0.00",
+		symbols: Symbols{Decimal: '.', Group: ',', List: ';', PercentSign: '%', CurrencySign: '¤', PlusSign: '+', MinusSign: '-', Exponential: 'E', SuperscriptingExponent: '×', PerMille: '‰', Infinity: '∞', Nan: []byte("NaN")},
+		names: map[string]currencyName{
+			"CHF": {"Swiss Franc", "CHF"},
+			"EUR": {"Euro", "€"},
+			"GBP": {"British Pound", "£"},
+			"JPY": {"Japanese Yen", "¥"},
+			"USD": {"US Dollar", "$"},
+		},
+	},
+	"en_001": {
+		format:  "¤#,##0.00",
+		symbols: Symbols{Decimal: '.', Group: ',', List: ';', PercentSign: '%', CurrencySign: '¤', PlusSign: '+', MinusSign: '-', Exponential: 'E', SuperscriptingExponent: '×', PerMille: '‰', Infinity: '∞', Nan: []byte("NaN")},
+		names: map[string]currencyName{
+			"USD": {"US Dollar", "US$"},
+		},
+	},
+	"en_150": {
+		format:  "#,##0.00 ¤",
+		symbols: Symbols{Decimal: ',', Group: '.', List: ';', PercentSign: '%', CurrencySign: '¤', PlusSign: '+', MinusSign: '-', Exponential: 'E', SuperscriptingExponent: '×', PerMille: '‰', Infinity: '∞', Nan: []byte("NaN")},
+		names:   map[string]currencyName{},
+	},
+	"fr": {
+		format:  "#,##0.00\u00a0¤",
+		symbols: Symbols{Decimal: ',', Group: '\u00a0', List: ';', PercentSign: '%', CurrencySign: '¤', PlusSign: '+', MinusSign: '-', Exponential: 'E', SuperscriptingExponent: '×', PerMille: '‰', Infinity: '∞', Nan: []byte("NaN")},
+		names: map[string]currencyName{
+			"CHF": {"franc suisse", "CHF"},
+			"EUR": {"euro", "€"},
+			"GBP": {"livre sterling", "£GB"},
+			"JPY": {"yen japonais", "JPY"},
+			"USD": {"dollar des États-Unis", "$US"},
+		},
+	},
+	"ja": {
+		format:  "¤#,##0.00",
+		symbols: Symbols{Decimal: '.', Group: ',', List: ';', PercentSign: '%', CurrencySign: '¤', PlusSign: '+', MinusSign: '-', Exponential: 'E', SuperscriptingExponent: '×', PerMille: '‰', Infinity: '∞', Nan: []byte("NaN")},
+		names: map[string]currencyName{
+			"CHF": {"スイス フラン", "CHF"},
+			"EUR": {"ユーロ", "€"},
+			"GBP": {"英国ポンド", "£"},
+			"JPY": {"日本円", "￥"},
+			"USD": {"米ドル", "$"},
+		},
+	},
+}
@@ -0,0 +1,89 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/corestoreio/csfw/i18n"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCurrency(t *testing.T) {
+	tests := []struct {
+		locale, iso string
+		wantName    string
+		wantSymbol  string
+		wantFormat  string
+		wantDecimal rune
+		wantGroup   rune
+		wantFrac    i18n.CurrencyFractions
+		wantErrBhf  errors.BehaviourFunc
+	}{
+		{"de_DE", "EUR", "Euro", "€", "#,##0.00 ¤", ',', '.', i18n.CurrencyFractions{Digits: 2, CashDigits: 2}, nil},
+		{"de-CH", "chf", "Schweizer Franken", "CHF", "¤ #,##0.00;¤-#,##0.00", '.', '’', i18n.CurrencyFractions{Digits: 2, CashDigits: 2, CashRounding: 5}, nil},
+		{"de_CH", "EUR", "Euro", "EUR", "¤ #,##0.00;¤-#,##0.00", '.', '’', i18n.CurrencyFractions{Digits: 2, CashDigits: 2}, nil},
+		{"en_150", "USD", "US Dollar", "US$", "#,##0.00 ¤", ',', '.', i18n.CurrencyFractions{Digits: 2, CashDigits: 2}, nil},
+		{"en-GB", "USD", "US Dollar", "US$", "¤#,##0.00", '.', ',', i18n.CurrencyFractions{Digits: 2, CashDigits: 2}, nil},
+		{"en_US", "USD", "US Dollar", "$", "¤#,##0.00", '.', ',', i18n.CurrencyFractions{Digits: 2, CashDigits: 2}, nil},
+		{"ja", "JPY", "日本円", "￥", "¤#,##0.00", '.', ',', i18n.CurrencyFractions{}, nil},
+		{"en", "XYZ", "", "", "", 0, 0, i18n.CurrencyFractions{}, errors.IsNotFound},
+		{"tlh", "EUR", "", "", "", 0, 0, i18n.CurrencyFractions{}, errors.IsNotFound},
+	}
+	for i, test := range tests {
+		cd, err := i18n.LookupCurrency(test.locale, test.iso)
+		if test.wantErrBhf != nil {
+			assert.True(t, test.wantErrBhf(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantName, cd.Name, "Index %d", i)
+		assert.Exactly(t, test.wantSymbol, cd.Symbol, "Index %d", i)
+		assert.Exactly(t, test.wantFormat, cd.Format, "Index %d", i)
+		assert.Exactly(t, test.wantDecimal, cd.Symbols.Decimal, "Index %d", i)
+		assert.Exactly(t, test.wantGroup, cd.Symbols.Group, "Index %d", i)
+		// no default symbols: the CLDR minus sign instead of the em dash
+		assert.Exactly(t, '-', cd.Symbols.MinusSign, "Index %d", i)
+		assert.Exactly(t, '¤', cd.Symbols.CurrencySign, "Index %d", i)
+		assert.Exactly(t, test.wantFrac, cd.Fractions, "Index %d", i)
+	}
+}
+
+func TestNewCurrencyByLocale(t *testing.T) {
+	tests := []struct {
+		locale, iso string
+		have        int64
+		want        string
+	}{
+		{"de", "EUR", 1234, "1.234,00 €"},
+		{"en", "USD", 1234, "$1,234.00"},
+		{"fr_FR", "GBP", -1234, "-1 234,00 £GB"},
+	}
+	for i, test := range tests {
+		c, err := i18n.NewCurrencyByLocale(test.locale, test.iso)
+		if err != nil {
+			t.Fatalf("Index %d => %+v", i, err)
+		}
+		var buf bytes.Buffer
+		_, err = c.FmtInt64(&buf, test.have)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, buf.String(), "Index %d", i)
+	}
+
+	_, err := i18n.NewCurrencyByLocale("xx", "EUR")
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+}
@@ -0,0 +1,147 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// currencyKey identifies a formatter within a CurrencyRegistry.
+type currencyKey struct {
+	locale string
+	iso    string
+}
+
+type currencyMap map[currencyKey]*Currency
+
+// CurrencyRegistry caches Currency formatters keyed by locale and 3-letter
+// ISO 4217 code. Creating a Currency via NewCurrency is expensive because the
+// format patterns must be parsed, so a registry should be created once and
+// shared between all requests.
+//
+// Lookups run lock free against an immutable map. Registering, deleting or
+// lazily creating a formatter copies the map and swaps it atomically
+// (copy-on-write), hence readers never see a half configured Currency.
+//
+// A returned *Currency must be treated as read only. To change its
+// configuration call Register again.
+type CurrencyRegistry struct {
+	// cache holds a currencyMap. Must be accessed via atomic Load/Store.
+	cache atomic.Value
+	// mu serializes all writers.
+	mu sync.Mutex
+	// defaults get applied before the options of Register and for lazily
+	// created formatters.
+	defaults []CurrencyOptions
+}
+
+// NewCurrencyRegistry creates a new empty registry. The default options will
+// be applied to each new Currency before any per locale options.
+func NewCurrencyRegistry(defaults ...CurrencyOptions) *CurrencyRegistry {
+	cr := &CurrencyRegistry{
+		defaults: defaults,
+	}
+	cr.cache.Store(make(currencyMap))
+	return cr
+}
+
+func (cr *CurrencyRegistry) load() currencyMap {
+	return cr.cache.Load().(currencyMap)
+}
+
+// storeCopy creates a copy of the current map, applies fn and swaps the
+// maps. Caller must hold the mutex.
+func (cr *CurrencyRegistry) storeCopy(fn func(currencyMap)) {
+	old := cr.load()
+	nm := make(currencyMap, len(old)+1)
+	for k, v := range old {
+		nm[k] = v
+	}
+	fn(nm)
+	cr.cache.Store(nm)
+}
+
+func (cr *CurrencyRegistry) newCurrency(iso string, opts []CurrencyOptions) *Currency {
+	all := make([]CurrencyOptions, 0, len(cr.defaults)+len(opts)+1)
+	all = append(all, SetCurrencyISO(iso))
+	all = append(all, cr.defaults...)
+	all = append(all, opts...)
+	return NewCurrency(all...)
+}
+
+// Register creates a new Currency for the locale and the ISO code and
+// replaces a previously registered one. The ISO code gets applied first,
+// then the registry defaults and then opts, so a SetCurrencySign in opts
+// overwrites the sign derived from the ISO code. Already returned
+// formatters are not affected.
+func (cr *CurrencyRegistry) Register(locale, iso string, opts ...CurrencyOptions) *Currency {
+	c := cr.newCurrency(iso, opts)
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.storeCopy(func(m currencyMap) {
+		m[currencyKey{locale: locale, iso: iso}] = c
+	})
+	return c
+}
+
+// Lookup returns a registered or previously created Currency. The second
+// return argument reports whether a Currency has been found. Lookup does not
+// allocate.
+func (cr *CurrencyRegistry) Lookup(locale, iso string) (*Currency, bool) {
+	c, ok := cr.load()[currencyKey{locale: locale, iso: iso}]
+	return c, ok
+}
+
+// Get returns the Currency for the locale and the ISO code. If not found a
+// new Currency gets created with the registry defaults and stored for all
+// further calls. Get is safe for concurrent use and does not allocate once
+// the Currency has been cached.
+func (cr *CurrencyRegistry) Get(locale, iso string) *Currency {
+	if c, ok := cr.Lookup(locale, iso); ok {
+		return c
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	// another goroutine might have been faster
+	k := currencyKey{locale: locale, iso: iso}
+	if c, ok := cr.load()[k]; ok {
+		return c
+	}
+	c := cr.newCurrency(iso, nil)
+	cr.storeCopy(func(m currencyMap) {
+		m[k] = c
+	})
+	return c
+}
+
+// Delete removes the formatter for the locale and the ISO code.
+func (cr *CurrencyRegistry) Delete(locale, iso string) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	k := currencyKey{locale: locale, iso: iso}
+	if _, ok := cr.load()[k]; !ok {
+		return
+	}
+	cr.storeCopy(func(m currencyMap) {
+		delete(m, k)
+	})
+}
+
+// Len returns the number of cached formatters.
+func (cr *CurrencyRegistry) Len() int {
+	return len(cr.load())
+}
@@ -0,0 +1,99 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/corestoreio/csfw/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrencyRegistry_Register(t *testing.T) {
+	cr := i18n.NewCurrencyRegistry(i18n.SetCurrencyFormat("#,##0.00 ¤", testDefaultNumberSymbols))
+
+	de := cr.Register("de_DE", "EUR", i18n.SetCurrencySign([]byte("€")))
+	assert.Exactly(t, 1, cr.Len())
+	assert.Exactly(t, "EUR", de.ISO.String())
+
+	have, ok := cr.Lookup("de_DE", "EUR")
+	assert.True(t, ok)
+	assert.True(t, de == have, "Pointers must be equal")
+
+	var buf bytes.Buffer
+	_, err := have.FmtFloat64(&buf, 1234.567)
+	assert.NoError(t, err)
+	assert.Exactly(t, "1,234.57 €", buf.String())
+
+	_, ok = cr.Lookup("de_CH", "EUR")
+	assert.False(t, ok)
+
+	// replace the configuration, the old formatter stays untouched
+	de2 := cr.Register("de_DE", "EUR", i18n.SetCurrencySign([]byte("Euro")))
+	assert.Exactly(t, 1, cr.Len())
+	assert.False(t, de == de2, "Pointers must differ")
+	assert.Exactly(t, "€", string(de.Sign()))
+	assert.Exactly(t, "Euro", string(cr.Get("de_DE", "EUR").Sign()))
+
+	cr.Delete("de_DE", "EUR")
+	cr.Delete("de_DE", "CHF")
+	assert.Exactly(t, 0, cr.Len())
+}
+
+func TestCurrencyRegistry_Get(t *testing.T) {
+	cr := i18n.NewCurrencyRegistry(i18n.SetCurrencyFormat("¤ #,##0.00", testDefaultNumberSymbols))
+
+	chf := cr.Get("de_CH", "CHF")
+	assert.Exactly(t, "CHF", string(chf.Sign()))
+	assert.True(t, chf == cr.Get("de_CH", "CHF"), "Pointers must be equal")
+	assert.Exactly(t, 1, cr.Len())
+
+	var buf bytes.Buffer
+	_, err := chf.FmtInt64(&buf, -1234)
+	assert.NoError(t, err)
+	assert.Exactly(t, "CHF -1,234.00", buf.String())
+}
+
+func TestCurrencyRegistry_Concurrent(t *testing.T) {
+	cr := i18n.NewCurrencyRegistry()
+	tests := []struct {
+		locale, iso string
+	}{
+		{"de_DE", "EUR"},
+		{"de_CH", "CHF"},
+		{"en_US", "USD"},
+		{"en_GB", "GBP"},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, test := range tests {
+			wg.Add(1)
+			go func(locale, iso string) {
+				defer wg.Done()
+				c := cr.Get(locale, iso)
+				assert.Exactly(t, iso, c.ISO.String())
+				if locale == "en_GB" {
+					cr.Register(locale, iso, i18n.SetCurrencySign([]byte("£")))
+				}
+			}(test.locale, test.iso)
+		}
+	}
+	wg.Wait()
+	assert.Exactly(t, len(tests), cr.Len())
+	assert.Exactly(t, "£", string(cr.Get("en_GB", "GBP").Sign()))
+}
@@ -79,6 +79,20 @@ Currency Format
 
 The currency symbol ¤ specifies where the currency sign will be placed.
 
+Currency Data
+
+The currency symbols, names, formats and fraction digits per locale are
+generated from the CLDR JSON distribution with the command in
+internal/gencurrency. To update the file currency_data_gen.go run go generate
+in this package with the environment variable CLDR_JSON pointing to a checkout
+of https://github.com/unicode-org/cldr-json. The default number symbols come
+from the CLDR root locale and a locale inherits missing data from its CLDR
+parent locales, e.g. en_150 from en_001 and en. To retrieve the data or a
+pre-configured formatter:
+
+	cd, err := i18n.LookupCurrency("de_CH", "CHF")
+	cf, err := i18n.NewCurrencyByLocale("de_CH", "CHF")
+
 https://github.com/theplant/cldr
 https://github.com/vube/i18n
 */
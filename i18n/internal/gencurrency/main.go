@@ -0,0 +1,443 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command gencurrency generates the CLDR currency tables of package i18n. It
+// reads the CLDR JSON distribution (cldr-numbers-full and cldr-core) from a
+// directory and writes the Go source code containing the currency symbols,
+// names, formats and number symbols per locale plus the supplemental fraction
+// digits.
+//
+// Usage:
+//
+//	go run internal/gencurrency/main.go -cldr path/to/cldr-json -locales en,de,de-CH -o currency_data_gen.go
+//
+// The -cldr flag defaults to the environment variable CLDR_JSON which must
+// point to a checkout of https://github.com/unicode-org/cldr-json, the
+// directory containing cldr-numbers-full and cldr-core. A flat directory with
+// main/<locale>/numbers.json, main/<locale>/currencies.json and
+// supplemental/currencyData.json works too. If the locales flag is empty all
+// locales found in the main directory will be generated.
+//
+// The format and the number symbols of a locale get resolved with the data of
+// its parent locales, e.g. de-CH inherits the missing symbols from de. The
+// parent of a locale comes from supplemental/parentLocales.json, e.g. en-150
+// inherits from en-001, otherwise the last part of the locale gets stripped.
+// The root locale completes the chain and provides the default symbols of
+// package i18n.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+func main() {
+	cldrDir := flag.String("cldr", os.Getenv("CLDR_JSON"), "Path to the CLDR JSON root directory, defaults to $CLDR_JSON")
+	locales := flag.String("locales", "", "Comma separated list of locales. Empty for all.")
+	out := flag.String("o", "currency_data_gen.go", "Output file")
+	flag.Parse()
+
+	if *cldrDir == "" {
+		fmt.Fprintln(os.Stderr, "gencurrency: -cldr flag or CLDR_JSON environment variable is required")
+		os.Exit(2)
+	}
+
+	var ls []string
+	if *locales != "" {
+		ls = strings.Split(*locales, ",")
+	}
+
+	var buf bytes.Buffer
+	if err := generate(&buf, *cldrDir, ls); err != nil {
+		fmt.Fprintf(os.Stderr, "gencurrency: %s\n", err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "gencurrency: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+type cldrNumbers struct {
+	Main map[string]struct {
+		Numbers map[string]json.RawMessage `json:"numbers"`
+	} `json:"main"`
+}
+
+type cldrCurrencies struct {
+	Main map[string]struct {
+		Numbers struct {
+			Currencies map[string]struct {
+				DisplayName string `json:"displayName"`
+				Symbol      string `json:"symbol"`
+			} `json:"currencies"`
+		} `json:"numbers"`
+	} `json:"main"`
+}
+
+type cldrCurrencyData struct {
+	Supplemental struct {
+		CurrencyData struct {
+			Fractions map[string]map[string]string `json:"fractions"`
+		} `json:"currencyData"`
+	} `json:"supplemental"`
+}
+
+type cldrParentLocales struct {
+	Supplemental struct {
+		ParentLocales struct {
+			ParentLocale map[string]string `json:"parentLocale"`
+		} `json:"parentLocales"`
+	} `json:"supplemental"`
+}
+
+// parentLocales maps a locale ID to its explicit CLDR parent. A parent root
+// gets mapped to an empty string.
+type parentLocales map[string]string
+
+// parent returns the CLDR parent of a locale. Without an explicit parent the
+// last part of the locale gets stripped. An empty string means root.
+func (pl parentLocales) parent(id string) string {
+	if p, ok := pl[id]; ok {
+		return p
+	}
+	return parentLocale(id)
+}
+
+type genName struct {
+	ISO, Name, Symbol string
+}
+
+type genLocale struct {
+	ID      string
+	Format  string
+	Symbols []genSymbol
+	Names   []genName
+
+	syms map[string]string // Symbols field name => Go literal
+}
+
+type genSymbol struct {
+	Field string
+	Value string // Go literal
+}
+
+type genParent struct {
+	ID, Parent string
+}
+
+type genFraction struct {
+	ISO                                        string
+	Digits, Rounding, CashDigits, CashRounding int
+}
+
+// rootLocale is the CLDR locale at the end of each parent chain.
+const rootLocale = "root"
+
+// symbolFields maps the CLDR JSON symbol keys to the fields of i18n.Symbols in
+// the order of the struct. CLDR has no currency sign symbol because the
+// patterns always use ¤.
+var symbolFields = [...][2]string{
+	{"decimal", "Decimal"},
+	{"group", "Group"},
+	{"list", "List"},
+	{"percentSign", "PercentSign"},
+	{"", "CurrencySign"},
+	{"plusSign", "PlusSign"},
+	{"minusSign", "MinusSign"},
+	{"exponential", "Exponential"},
+	{"superscriptingExponent", "SuperscriptingExponent"},
+	{"perMille", "PerMille"},
+	{"infinity", "Infinity"},
+	{"nan", "Nan"},
+}
+
+func readJSON(file string, v interface{}) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
+	return nil
+}
+
+// cldrDirs returns the directories containing the locales and the
+// supplemental data. The packages of the cldr-json repository take precedence
+// over the flat layout.
+func cldrDirs(cldrDir string) (mainDir, supplementalDir string) {
+	mainDir = filepath.Join(cldrDir, "main")
+	if fi, err := os.Stat(filepath.Join(cldrDir, "cldr-numbers-full", "main")); err == nil && fi.IsDir() {
+		mainDir = filepath.Join(cldrDir, "cldr-numbers-full", "main")
+	}
+	supplementalDir = filepath.Join(cldrDir, "supplemental")
+	if fi, err := os.Stat(filepath.Join(cldrDir, "cldr-core", "supplemental")); err == nil && fi.IsDir() {
+		supplementalDir = filepath.Join(cldrDir, "cldr-core", "supplemental")
+	}
+	return mainDir, supplementalDir
+}
+
+func generate(w io.Writer, cldrDir string, locales []string) error {
+	mainDir, supplementalDir := cldrDirs(cldrDir)
+	if len(locales) == 0 {
+		dirs, err := ioutil.ReadDir(mainDir)
+		if err != nil {
+			return err
+		}
+		for _, d := range dirs {
+			if d.IsDir() && d.Name() != rootLocale {
+				locales = append(locales, d.Name())
+			}
+		}
+	}
+	sort.Strings(locales)
+
+	var cpl cldrParentLocales
+	if err := readJSON(filepath.Join(supplementalDir, "parentLocales.json"), &cpl); err != nil {
+		return err
+	}
+	pl := make(parentLocales, len(cpl.Supplemental.ParentLocales.ParentLocale))
+	gps := make([]genParent, 0, len(pl))
+	for id, p := range cpl.Supplemental.ParentLocales.ParentLocale {
+		if p == rootLocale {
+			p = ""
+		}
+		id, p = strings.Replace(id, "-", "_", -1), strings.Replace(p, "-", "_", -1)
+		pl[id] = p
+		gps = append(gps, genParent{ID: id, Parent: p})
+	}
+	sort.Slice(gps, func(i, j int) bool { return gps[i].ID < gps[j].ID })
+
+	root, err := readLocale(mainDir, rootLocale)
+	if err != nil {
+		return err
+	}
+	resolve(&root, nil, nil, genLocale{})
+
+	gls := make([]genLocale, 0, len(locales))
+	byID := make(map[string]genLocale, len(locales))
+	for _, loc := range locales {
+		gl, err := readLocale(mainDir, loc)
+		if err != nil {
+			return err
+		}
+		gls = append(gls, gl)
+		byID[gl.ID] = gl
+	}
+	for i := range gls {
+		resolve(&gls[i], byID, pl, root)
+	}
+
+	var cd cldrCurrencyData
+	if err := readJSON(filepath.Join(supplementalDir, "currencyData.json"), &cd); err != nil {
+		return err
+	}
+	gfs, err := fractions(cd.Supplemental.CurrencyData.Fractions)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, map[string]interface{}{
+		"Root":      root,
+		"Parents":   gps,
+		"Locales":   gls,
+		"Fractions": gfs,
+	}); err != nil {
+		return err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("format.Source: %s\n%s", err, buf.String())
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+func readLocale(mainDir, loc string) (genLocale, error) {
+	gl := genLocale{
+		ID:   strings.Replace(loc, "-", "_", -1),
+		syms: make(map[string]string, len(symbolFields)),
+	}
+
+	var nums cldrNumbers
+	if err := readJSON(filepath.Join(mainDir, loc, "numbers.json"), &nums); err != nil {
+		return gl, err
+	}
+	for _, m := range nums.Main {
+		ns := "latn"
+		if raw, ok := m.Numbers["defaultNumberingSystem"]; ok {
+			if err := json.Unmarshal(raw, &ns); err != nil {
+				return gl, err
+			}
+		}
+		var cf map[string]string
+		if raw, ok := m.Numbers["currencyFormats-numberSystem-"+ns]; ok {
+			if err := json.Unmarshal(raw, &cf); err != nil {
+				return gl, err
+			}
+			gl.Format = cf["standard"]
+		}
+		var syms map[string]string
+		if raw, ok := m.Numbers["symbols-numberSystem-"+ns]; ok {
+			if err := json.Unmarshal(raw, &syms); err != nil {
+				return gl, err
+			}
+		}
+		for _, sf := range symbolFields {
+			v, ok := syms[sf[0]]
+			if !ok || v == "" {
+				continue
+			}
+			if sf[1] == "Nan" {
+				gl.syms[sf[1]] = "[]byte(" + strconv.Quote(v) + ")"
+				continue
+			}
+			r, _ := utf8.DecodeRuneInString(v)
+			gl.syms[sf[1]] = strconv.QuoteRune(r)
+		}
+	}
+
+	var curs cldrCurrencies
+	if err := readJSON(filepath.Join(mainDir, loc, "currencies.json"), &curs); err != nil {
+		return gl, err
+	}
+	for _, m := range curs.Main {
+		for iso, c := range m.Numbers.Currencies {
+			gl.Names = append(gl.Names, genName{ISO: iso, Name: c.DisplayName, Symbol: c.Symbol})
+		}
+	}
+	sort.Slice(gl.Names, func(i, j int) bool { return gl.Names[i].ISO < gl.Names[j].ISO })
+	return gl, nil
+}
+
+// resolve fills the empty format and the missing symbols of a locale with the
+// data of its parent locales, ending with the root locale, and sets the
+// currency sign. The generated Symbols are complete, so package i18n does not
+// need any hand written symbols.
+func resolve(gl *genLocale, byID map[string]genLocale, pl parentLocales, root genLocale) {
+	gl.syms["CurrencySign"] = strconv.QuoteRune('¤')
+	inherit := func(parent genLocale) {
+		if gl.Format == "" {
+			gl.Format = parent.Format
+		}
+		for f, v := range parent.syms {
+			if _, ok := gl.syms[f]; !ok {
+				gl.syms[f] = v
+			}
+		}
+	}
+	for id := pl.parent(gl.ID); id != ""; id = pl.parent(id) {
+		if parent, ok := byID[id]; ok {
+			inherit(parent)
+		}
+	}
+	inherit(root)
+	gl.Symbols = gl.Symbols[:0]
+	for _, sf := range symbolFields {
+		if v, ok := gl.syms[sf[1]]; ok {
+			gl.Symbols = append(gl.Symbols, genSymbol{Field: sf[1], Value: v})
+		}
+	}
+}
+
+// parentLocale returns the parent of a locale by stripping the last part.
+func parentLocale(id string) string {
+	if i := strings.LastIndexAny(id, "_-"); i > 0 {
+		return id[:i]
+	}
+	return ""
+}
+
+func fractions(fr map[string]map[string]string) ([]genFraction, error) {
+	atoi := func(m map[string]string, key, def string) (int, error) {
+		v, ok := m[key]
+		if !ok {
+			v = def
+		}
+		return strconv.Atoi(v)
+	}
+	gfs := make([]genFraction, 0, len(fr))
+	for iso, f := range fr {
+		var gf genFraction
+		var err error
+		gf.ISO = iso
+		if gf.Digits, err = atoi(f, "_digits", "2"); err != nil {
+			return nil, err
+		}
+		if gf.Rounding, err = atoi(f, "_rounding", "0"); err != nil {
+			return nil, err
+		}
+		if gf.CashDigits, err = atoi(f, "_cashDigits", strconv.Itoa(gf.Digits)); err != nil {
+			return nil, err
+		}
+		if gf.CashRounding, err = atoi(f, "_cashRounding", strconv.Itoa(gf.Rounding)); err != nil {
+			return nil, err
+		}
+		gfs = append(gfs, gf)
+	}
+	sort.Slice(gfs, func(i, j int) bool { return gfs[i].ISO < gfs[j].ISO })
+	return gfs, nil
+}
+
+var tpl = template.Must(template.New("currency").Parse(`// Code generated by internal/gencurrency. DO NOT EDIT.
+
+package i18n
+
+// defaultSymbols contains the CLDR number symbols of the root locale.
+var defaultSymbols = Symbols{ {{- range .Root.Symbols}}{{.Field}}: {{.Value}}, {{end -}} }
+
+// localeParentTable contains the CLDR parent locales which differ from
+// stripping the last part of a locale. An empty parent means root.
+var localeParentTable = map[string]string{
+{{- range .Parents}}
+	{{printf "%q" .ID}}: {{printf "%q" .Parent}},
+{{- end}}
+}
+
+// currencyFractionTable contains the CLDR supplemental currency fractions.
+var currencyFractionTable = map[string]CurrencyFractions{
+{{- range .Fractions}}
+	{{printf "%q" .ISO}}: {Digits: {{.Digits}}, Rounding: {{.Rounding}}, CashDigits: {{.CashDigits}}, CashRounding: {{.CashRounding}}},
+{{- end}}
+}
+
+// currencyLocaleTable contains the CLDR currency data per locale.
+var currencyLocaleTable = map[string]currencyLocale{
+{{- range .Locales}}
+	{{printf "%q" .ID}}: {
+		format: {{printf "%q" .Format}},
+		symbols: Symbols{ {{- range .Symbols}}{{.Field}}: {{.Value}}, {{end -}} },
+		names: map[string]currencyName{
+		{{- range .Names}}
+			{{printf "%q" .ISO}}: { {{- printf "%q" .Name}}, {{printf "%q" .Symbol -}} },
+		{{- end}}
+		},
+	},
+{{- end}}
+}
+`))
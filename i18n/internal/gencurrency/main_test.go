@@ -0,0 +1,96 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Update the golden file")
+
+// TestGenerate_Golden generates the tables from the CLDR fixture directory
+// and compares them with the golden file. The fixture contains only a few
+// locales and currencies and must not be used to generate the package data.
+func TestGenerate_Golden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := generate(&buf, "testdata/cldr-json", nil); err != nil {
+		t.Fatal(err)
+	}
+	const golden = "testdata/currency_data_gen.golden"
+	if *update {
+		if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, buf.Bytes()) {
+		t.Errorf("%s is outdated. Please run go test -update.\n%s", golden, buf.String())
+	}
+}
+
+func TestCLDRDirs(t *testing.T) {
+	mainDir, supplementalDir := cldrDirs("testdata/cldr-json")
+	if want := filepath.Join("testdata", "cldr-json", "main"); mainDir != want {
+		t.Errorf("Have %q Want %q", mainDir, want)
+	}
+	if want := filepath.Join("testdata", "cldr-json", "supplemental"); supplementalDir != want {
+		t.Errorf("Have %q Want %q", supplementalDir, want)
+	}
+
+	dir, err := ioutil.TempDir("", "gencurrency")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, d := range []string{"cldr-numbers-full/main", "cldr-core/supplemental"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mainDir, supplementalDir = cldrDirs(dir)
+	if want := filepath.Join(dir, "cldr-numbers-full", "main"); mainDir != want {
+		t.Errorf("Have %q Want %q", mainDir, want)
+	}
+	if want := filepath.Join(dir, "cldr-core", "supplemental"); supplementalDir != want {
+		t.Errorf("Have %q Want %q", supplementalDir, want)
+	}
+}
+
+func TestParentLocales_Parent(t *testing.T) {
+	pl := parentLocales{"en_150": "en_001", "en_001": "en", "az_Cyrl": ""}
+	tests := []struct {
+		id, want string
+	}{
+		{"en_150", "en_001"},
+		{"en_001", "en"},
+		{"en", ""},
+		{"de_CH", "de"},
+		{"az_Cyrl", ""},
+		{"az_Cyrl_AZ", "az_Cyrl"},
+	}
+	for i, test := range tests {
+		if have := pl.parent(test.id); have != test.want {
+			t.Errorf("Index %d: Have %q Want %q", i, have, test.want)
+		}
+	}
+}
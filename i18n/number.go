@@ -84,6 +84,12 @@ type (
 		// format if different and fracValid is true
 		frac      CurrencyFractions
 		fracValid bool
+
+		// pctFmt contains the pattern for FmtPercent and FmtPerMille and pct
+		// the formatter created from the pattern.
+		pctFmt   string
+		pct      *Number
+		pctScale PercentScale
 	}
 
 	// NumberOptions applies options to the Number struct. To read more
@@ -110,6 +116,7 @@ func SetNumberSymbols(s Symbols) NumberOptions {
 	return func(n *Number) NumberOptions {
 		previous := n.sym
 		n.sym = NewSymbols(s)
+		n.initPercent()
 		return SetNumberSymbols(previous)
 	}
 }
@@ -161,6 +168,7 @@ func SetNumberFormat(f string, s ...Symbols) NumberOptions {
 		n.fneg.pattern = negFmt
 		n.fneg.isNegative = true
 		if len(s) == 1 {
+			n.initPercent()
 			return SetNumberFormat(previousF, previousS)
 		}
 		return SetNumberFormat(previousF)
@@ -172,9 +180,11 @@ func SetNumberFormat(f string, s ...Symbols) NumberOptions {
 // formatter anywhere else.
 func NewNumber(opts ...NumberOptions) *Number {
 	n := &Number{
-		sym: NewSymbols(),
+		sym:    NewSymbols(),
+		pctFmt: DefaultPercentFormat,
 	}
 	SetNumberFormat(DefaultNumberFormat)(n) // normally that should come from golang.org/x/text package
+	n.initPercent()
 	//	NumberTag("en-US")(n)
 	n.NSetOptions(opts...)
 	return n
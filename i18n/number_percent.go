@@ -0,0 +1,117 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/errors"
+)
+
+// DefaultPercentFormat 19%. The percent character in the pattern marks the
+// position of the PercentSign or PerMille symbol.
+const DefaultPercentFormat = `#,##0%`
+
+// percentPlaceholder gets replaced with the PercentSign or PerMille symbol.
+const percentPlaceholder = '%'
+
+// PercentScale defines how FmtPercent and FmtPerMille treat their argument.
+type PercentScale uint8
+
+const (
+	// PercentScaleRatio multiplies the argument by 100 respective by 1000.
+	// 0.196 gets formatted as 19.6% or 196‰. Default.
+	PercentScaleRatio PercentScale = iota
+	// PercentScaleNone uses the argument as it is. 19.6 gets formatted as
+	// 19.6% or 19.6‰.
+	PercentScaleNone
+)
+
+// SetNumberPercentFormat applies the format for FmtPercent and FmtPerMille.
+// The pattern supports the same features as SetNumberFormat. A percent
+// character defines the position of the locale specific symbol, for example
+// "#,##0.## %" or "%#,##0". If format is empty, fallback to the default
+// percent format.
+func SetNumberPercentFormat(f string) NumberOptions {
+	if f == "" {
+		f = DefaultPercentFormat
+	}
+	return func(n *Number) NumberOptions {
+		previous := n.pctFmt
+		n.pctFmt = f
+		n.initPercent()
+		return SetNumberPercentFormat(previous)
+	}
+}
+
+// SetNumberPercentScale defines whether FmtPercent and FmtPerMille multiply
+// their argument.
+func SetNumberPercentScale(s PercentScale) NumberOptions {
+	return func(n *Number) NumberOptions {
+		previous := n.pctScale
+		n.pctScale = s
+		return SetNumberPercentScale(previous)
+	}
+}
+
+// initPercent creates the internal formatter for the percent pattern with the
+// current symbols. Must be called whenever the symbols or the percent pattern
+// change.
+func (no *Number) initPercent() {
+	if no.pctFmt == "" {
+		return
+	}
+	p := &Number{sym: no.sym}
+	SetNumberFormat(no.pctFmt)(p)
+	no.pct = p
+}
+
+// FmtPercent formats a float value as percentage with the PercentSign of the
+// Symbols. Depending on the PercentScale the value gets multiplied by 100.
+// Thread safe.
+func (no *Number) FmtPercent(w io.Writer, f float64) (int, error) {
+	return no.fmtScaled(w, f, 100, no.sym.PercentSign)
+}
+
+// FmtPerMille formats a float value as per mille with the PerMille symbol of
+// the Symbols. Depending on the PercentScale the value gets multiplied by
+// 1000. Thread safe.
+func (no *Number) FmtPerMille(w io.Writer, f float64) (int, error) {
+	return no.fmtScaled(w, f, 1000, no.sym.PerMille)
+}
+
+func (no *Number) fmtScaled(w io.Writer, f float64, factor float64, symbol rune) (int, error) {
+	if no.pct == nil {
+		return 0, errors.NewNotValidf("[i18n] Percent format not set. Please use option SetNumberPercentFormat")
+	}
+	if no.pctScale == PercentScaleRatio {
+		f *= factor
+	}
+
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if _, err := no.pct.FmtFloat64(buf, f); err != nil {
+		return 0, errors.Wrapf(err, "[i18n] FmtFloat64. Buffer %q; Float %f", buf.String(), f)
+	}
+
+	var sBuf [4]byte
+	sWritten := utf8.EncodeRune(sBuf[:], symbol)
+	var pBuf [4]byte
+	pWritten := utf8.EncodeRune(pBuf[:], percentPlaceholder)
+	return w.Write(bytes.Replace(buf.Bytes(), pBuf[:pWritten], sBuf[:sWritten], 1))
+}
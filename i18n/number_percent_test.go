@@ -0,0 +1,96 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/corestoreio/csfw/i18n"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberFmtPercent(t *testing.T) {
+	tests := []struct {
+		opts []i18n.NumberOptions
+		f    float64
+		want string
+	}{
+		{nil, 0.19, "19%"},
+		{nil, 0.196, "20%"},
+		{nil, 12.5, "1,250%"},
+		{nil, -0.07, "-7%"},
+		{[]i18n.NumberOptions{i18n.SetNumberPercentFormat("#,##0.0 %")}, 0.196, "19.6 %"},
+		{[]i18n.NumberOptions{i18n.SetNumberPercentFormat("%#,##0.00")}, 0.0725, "%7.25"},
+		{[]i18n.NumberOptions{i18n.SetNumberPercentScale(i18n.PercentScaleNone)}, 19, "19%"},
+		{[]i18n.NumberOptions{
+			i18n.SetNumberPercentFormat("#,##0.## %"),
+			i18n.SetNumberSymbols(i18n.Symbols{Decimal: ',', Group: '.'}),
+		}, 0.075, "7,50 %"},
+		{[]i18n.NumberOptions{i18n.SetNumberSymbols(i18n.Symbols{PercentSign: '٪'})}, 0.5, "50٪"},
+	}
+	for i, test := range tests {
+		no := i18n.NewNumber(test.opts...)
+		var buf bytes.Buffer
+		_, err := no.FmtPercent(&buf, test.f)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, buf.String(), "Index %d", i)
+	}
+}
+
+func TestNumberFmtPerMille(t *testing.T) {
+	tests := []struct {
+		opts []i18n.NumberOptions
+		f    float64
+		want string
+	}{
+		{nil, 0.0125, "13‰"},
+		{[]i18n.NumberOptions{i18n.SetNumberPercentFormat("#,##0.0%")}, 0.0125, "12.5‰"},
+		{[]i18n.NumberOptions{i18n.SetNumberPercentScale(i18n.PercentScaleNone)}, 3, "3‰"},
+		{[]i18n.NumberOptions{i18n.SetNumberSymbols(i18n.Symbols{PerMille: '؉'})}, 0.002, "2؉"},
+	}
+	for i, test := range tests {
+		no := i18n.NewNumber(test.opts...)
+		var buf bytes.Buffer
+		_, err := no.FmtPerMille(&buf, test.f)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, buf.String(), "Index %d", i)
+	}
+}
+
+func TestNumberFmtPercent_Previous(t *testing.T) {
+	no := i18n.NewNumber()
+	prev := no.NSetOptions(i18n.SetNumberPercentFormat("#,##0.00 %"))
+
+	var buf bytes.Buffer
+	_, err := no.FmtPercent(&buf, 0.5)
+	assert.NoError(t, err)
+	assert.Exactly(t, "50.00 %", buf.String())
+
+	no.NSetOptions(prev)
+	buf.Reset()
+	_, err = no.FmtPercent(&buf, 0.5)
+	assert.NoError(t, err)
+	assert.Exactly(t, "50%", buf.String())
+}
+
+func TestNumberFmtPercent_NotSet(t *testing.T) {
+	var no i18n.Number
+	var buf bytes.Buffer
+	_, err := no.FmtPercent(&buf, 0.5)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	assert.Empty(t, buf.String())
+}
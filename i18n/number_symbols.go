@@ -17,24 +17,6 @@ package i18n
 // @TODO move symbols and formatting string into locale package
 
 var (
-	// defaultSymbols contains all important default characters for
-	// formatting a number and or a currency.
-	defaultSymbols = Symbols{
-		// normally that all should come from golang.org/x/text package
-		Decimal:                '.',
-		Group:                  ',',
-		List:                   ';',
-		PercentSign:            '%',
-		CurrencySign:           '¤',
-		PlusSign:               '+',
-		MinusSign:              '—', // em dash \u2014 ;-)
-		Exponential:            'E',
-		SuperscriptingExponent: '×',
-		PerMille:               '‰',
-		Infinity:               '∞',
-		Nan:                    []byte(`NaN`),
-	}
-
 	minusSign  = []byte(`-`)
 	symbolSign = []byte(`¤`)
 )
@@ -56,8 +38,8 @@ type Symbols struct {
 }
 
 // NewSymbols creates a new non-pointer Symbols type with the
-// pre-filled default symbol table. Use arguments to override the default
-// symbols.
+// pre-filled default symbol table, generated from the CLDR root locale. Use
+// arguments to override the default symbols.
 func NewSymbols(syms ...Symbols) Symbols {
 	s := defaultSymbols
 	for _, sym := range syms {
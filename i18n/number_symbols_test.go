@@ -23,7 +23,7 @@ import (
 
 func TestSymbolsString(t *testing.T) {
 	assert.Equal(t,
-		"Decimal\t\t\t\t\t.\nGroup\t\t\t\t\t,\nList\t\t\t\t\t;\nPercentSign\t\t\t\t%\nCurrencySign\t\t\t¤\nPlusSign\t\t\t\t+\nMinusSign\t\t\t\t—\nExponential\t\t\t\tE\nSuperscriptingExponent\t×\nPerMille\t\t\t\t‰\nInfinity\t\t\t\t∞\nNaN\t\t\t\t\t\tNaN\n",
+		"Decimal\t\t\t\t\t.\nGroup\t\t\t\t\t,\nList\t\t\t\t\t;\nPercentSign\t\t\t\t%\nCurrencySign\t\t\t¤\nPlusSign\t\t\t\t+\nMinusSign\t\t\t\t-\nExponential\t\t\t\tE\nSuperscriptingExponent\t×\nPerMille\t\t\t\t‰\nInfinity\t\t\t\t∞\nNaN\t\t\t\t\t\tNaN\n",
 		i18n.NewSymbols().String())
 }
 
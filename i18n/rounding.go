@@ -0,0 +1,183 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"math"
+
+	"github.com/corestoreio/errors"
+)
+
+// RoundingMode defines how a value gets rounded to a rounding increment.
+type RoundingMode uint8
+
+// List of supported rounding modes. The zero value RoundHalfUp is the
+// default and matches the rounding of the number formatter.
+const (
+	// RoundHalfUp rounds to the nearest increment, ties away from zero.
+	// 0.125 → 0.13 and -0.125 → -0.13
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds to the nearest increment, ties to the even
+	// neighbour, also known as banker's rounding. 0.125 → 0.12 and 0.135 →
+	// 0.14
+	RoundHalfEven
+	// RoundHalfDown rounds to the nearest increment, ties towards zero.
+	// 0.125 → 0.12
+	RoundHalfDown
+	// RoundUp rounds away from zero. 0.121 → 0.13
+	RoundUp
+	// RoundDown rounds towards zero, truncates. 0.129 → 0.12
+	RoundDown
+)
+
+// roundingModeNames used as configuration values, see ParseRoundingMode.
+var roundingModeNames = [...]string{
+	RoundHalfUp:   "half_up",
+	RoundHalfEven: "half_even",
+	RoundHalfDown: "half_down",
+	RoundUp:       "up",
+	RoundDown:     "down",
+}
+
+// roundingEpsilon compensates the float64 representation error when a value
+// gets divided by its increment. E.g. 1.025/0.05 = 20.499999999999996.
+const roundingEpsilon = 1e-9
+
+// ParseRoundingMode parses a rounding mode from its configuration value like
+// half_up, half_even, half_down, up or down. The value usually gets stored in
+// the tax calculation configuration of a store. An empty string returns
+// RoundHalfUp. Error behaviour: NotSupported.
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	if s == "" {
+		return RoundHalfUp, nil
+	}
+	for i, n := range roundingModeNames {
+		if n == s {
+			return RoundingMode(i), nil
+		}
+	}
+	return RoundHalfUp, errors.NewNotSupportedf("[i18n] Unknown rounding mode %q", s)
+}
+
+// String returns the configuration value of the rounding mode.
+func (m RoundingMode) String() string {
+	if int(m) < len(roundingModeNames) {
+		return roundingModeNames[m]
+	}
+	return "unknown"
+}
+
+// Round rounds value to a multiple of increment. E.g. an increment of 0.05
+// applies the Swiss cash rounding, where 1.025 rounds to 1.05 with
+// RoundHalfUp and to 1.00 with RoundHalfEven. An increment <= 0 returns the
+// value unchanged, as do NaN and infinite values.
+func (m RoundingMode) Round(value, increment float64) float64 {
+	if increment <= 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+
+	q := math.Abs(value) / increment
+	i, frac := math.Modf(q)
+	if frac > 1-roundingEpsilon {
+		i++
+		frac = 0
+	}
+
+	switch m {
+	case RoundHalfEven:
+		switch {
+		case frac > 0.5+roundingEpsilon:
+			i++
+		case frac > 0.5-roundingEpsilon && math.Mod(i, 2) != 0:
+			i++
+		}
+	case RoundHalfDown:
+		if frac > 0.5+roundingEpsilon {
+			i++
+		}
+	case RoundUp:
+		if frac > roundingEpsilon {
+			i++
+		}
+	case RoundDown:
+		// truncated by Modf
+	default:
+		if frac > 0.5-roundingEpsilon {
+			i++
+		}
+	}
+
+	// removes the representation error of the multiplication, for example
+	// 3*0.05 = 0.15000000000000002
+	pow := math.Pow10(incrementPlaces(increment))
+	r := math.Floor(i*increment*pow+0.5) / pow
+	return math.Copysign(r, value)
+}
+
+// incrementPlaces returns the number of decimal places of an increment.
+func incrementPlaces(increment float64) int {
+	places := 0
+	for p := increment; places < 15; places++ {
+		if _, frac := math.Modf(p + roundingEpsilon); frac < 2*roundingEpsilon {
+			break
+		}
+		p *= 10
+	}
+	return places
+}
+
+// SetCurrencyRounding sets the rounding mode and whether the cash fractions
+// should be used. Cash rounding uses CashDigits and CashRounding of the
+// CurrencyFractions, e.g. the Swiss 0.05 rounding for CHF.
+func SetCurrencyRounding(mode RoundingMode, cash bool) CurrencyOptions {
+	return func(c *Currency) CurrencyOptions {
+		prevM := c.roundMode
+		prevC := c.roundCash
+		c.roundMode = mode
+		c.roundCash = cash
+		return SetCurrencyRounding(prevM, prevC)
+	}
+}
+
+// RoundingIncrement returns the increment derived from the currency
+// fractions. With cash enabled CashDigits and CashRounding get used, where a
+// CashRounding of zero falls back to Rounding.
+func (c *Currency) RoundingIncrement() float64 {
+	digits, rounding := c.frac.Digits, c.frac.Rounding
+	if c.roundCash {
+		digits = c.frac.CashDigits
+		if c.frac.CashRounding > 0 {
+			rounding = c.frac.CashRounding
+		}
+	}
+	if rounding < 1 {
+		rounding = 1
+	}
+	return float64(rounding) / math.Pow10(digits)
+}
+
+// Round rounds value to the rounding increment of the currency with the
+// configured rounding mode. FmtFloat64 applies Round before formatting when
+// the mode or the increment differs from the default half up rounding to the
+// fraction digits.
+func (c *Currency) Round(value float64) float64 {
+	return c.roundMode.Round(value, c.RoundingIncrement())
+}
+
+// roundingEnabled reports whether Round changes a value differently than the
+// number formatter does.
+func (c *Currency) roundingEnabled() bool {
+	return c.roundMode != RoundHalfUp || c.RoundingIncrement() != 1/math.Pow10(c.frac.Digits)
+}
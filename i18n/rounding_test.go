@@ -0,0 +1,137 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/corestoreio/csfw/i18n"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundingMode_Round(t *testing.T) {
+	tests := []struct {
+		mode      i18n.RoundingMode
+		value     float64
+		increment float64
+		want      float64
+	}{
+		{i18n.RoundHalfUp, 0.125, 0.01, 0.13},
+		{i18n.RoundHalfUp, -0.125, 0.01, -0.13},
+		{i18n.RoundHalfUp, 1.025, 0.05, 1.05},
+		{i18n.RoundHalfUp, 1.024, 0.05, 1.0},
+		{i18n.RoundHalfUp, 3.175, 0.05, 3.2},
+		{i18n.RoundHalfUp, 0.15, 0.05, 0.15},
+		{i18n.RoundHalfUp, 1234.5, 50, 1250},
+		{i18n.RoundHalfEven, 0.125, 0.01, 0.12},
+		{i18n.RoundHalfEven, 0.135, 0.01, 0.14},
+		{i18n.RoundHalfEven, -0.125, 0.01, -0.12},
+		{i18n.RoundHalfEven, 1.025, 0.05, 1.0},
+		{i18n.RoundHalfEven, 1.075, 0.05, 1.1},
+		{i18n.RoundHalfEven, 2.5, 1, 2},
+		{i18n.RoundHalfEven, 3.5, 1, 4},
+		{i18n.RoundHalfDown, 0.125, 0.01, 0.12},
+		{i18n.RoundHalfDown, 0.1251, 0.01, 0.13},
+		{i18n.RoundUp, 0.121, 0.01, 0.13},
+		{i18n.RoundUp, -0.121, 0.01, -0.13},
+		{i18n.RoundUp, 0.12, 0.01, 0.12},
+		{i18n.RoundDown, 0.129, 0.01, 0.12},
+		{i18n.RoundDown, 1.04, 0.05, 1.0},
+		{i18n.RoundDown, 0.3, 0.1, 0.3},
+		{i18n.RoundHalfUp, 1.234, 0, 1.234},
+		{i18n.RoundHalfUp, 1.234, -1, 1.234},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, test.mode.Round(test.value, test.increment), "Index %d: %s %v", i, test.mode, test.value)
+	}
+	assert.True(t, math.IsNaN(i18n.RoundHalfUp.Round(math.NaN(), 0.05)))
+	assert.True(t, math.IsInf(i18n.RoundHalfUp.Round(math.Inf(1), 0.05), 1))
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    i18n.RoundingMode
+		wantErr errors.BehaviourFunc
+	}{
+		{"", i18n.RoundHalfUp, nil},
+		{"half_up", i18n.RoundHalfUp, nil},
+		{"half_even", i18n.RoundHalfEven, nil},
+		{"half_down", i18n.RoundHalfDown, nil},
+		{"up", i18n.RoundUp, nil},
+		{"down", i18n.RoundDown, nil},
+		{"bankers", i18n.RoundHalfUp, errors.IsNotSupported},
+	}
+	for i, test := range tests {
+		have, err := i18n.ParseRoundingMode(test.raw)
+		if test.wantErr != nil {
+			assert.True(t, test.wantErr(err), "Index %d => %s", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+		assert.Exactly(t, test.raw != "", have.String() == test.raw, "Index %d", i)
+	}
+	assert.Exactly(t, "unknown", i18n.RoundingMode(200).String())
+}
+
+func TestCurrency_Round(t *testing.T) {
+	chf := func(opts ...i18n.CurrencyOptions) *i18n.Currency {
+		return i18n.NewCurrency(append([]i18n.CurrencyOptions{
+			i18n.SetCurrencyISO("CHF"),
+			i18n.SetCurrencyFormat("#,##0.00 ¤", testDefCurSym),
+			i18n.SetCurrencyFraction(2, 0, 2, 5),
+		}, opts...)...)
+	}
+
+	tests := []struct {
+		c         *i18n.Currency
+		value     float64
+		wantRound float64
+		wantFmt   string
+	}{
+		{chf(), 12.34, 12.34, "12,34 CHF"},
+		{chf(), 12.345, 12.35, "12,35 CHF"},
+		{chf(i18n.SetCurrencyRounding(i18n.RoundHalfUp, true)), 12.34, 12.35, "12,35 CHF"},
+		{chf(i18n.SetCurrencyRounding(i18n.RoundHalfUp, true)), 12.32, 12.3, "12,30 CHF"},
+		{chf(i18n.SetCurrencyRounding(i18n.RoundHalfUp, true)), -12.375, -12.4, "-12,40 CHF"},
+		{chf(i18n.SetCurrencyRounding(i18n.RoundHalfEven, true)), 12.325, 12.3, "12,30 CHF"},
+		{chf(i18n.SetCurrencyRounding(i18n.RoundHalfEven, false)), 12.345, 12.34, "12,34 CHF"},
+		{chf(i18n.SetCurrencyRounding(i18n.RoundDown, false)), 12.349, 12.34, "12,34 CHF"},
+		{i18n.NewCurrency(i18n.SetCurrencyFormat("#,##0 ¤", testDefCurSym), i18n.SetCurrencyFraction(0, 50, 0, 0), i18n.SetCurrencyISO("JPY")), 1234, 1250, "1.250 JPY"},
+	}
+	var buf bytes.Buffer
+	for i, test := range tests {
+		assert.Exactly(t, test.wantRound, test.c.Round(test.value), "Index %d", i)
+		_, err := test.c.FmtFloat64(&buf, test.value)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantFmt, buf.String(), "Index %d", i)
+		buf.Reset()
+	}
+}
+
+func TestSetCurrencyRounding_Previous(t *testing.T) {
+	c := i18n.NewCurrency(i18n.SetCurrencyFraction(2, 0, 2, 5))
+	prev := c.CSetOptions(i18n.SetCurrencyRounding(i18n.RoundUp, true))
+	assert.Exactly(t, 0.05, c.RoundingIncrement())
+	assert.Exactly(t, 1.05, c.Round(1.01))
+
+	c.CSetOptions(prev)
+	assert.Exactly(t, 0.01, c.RoundingIncrement())
+	assert.Exactly(t, 1.01, c.Round(1.01))
+}
@@ -0,0 +1,288 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"io"
+	"strings"
+
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/errors"
+)
+
+// Unit defines a measurement unit for weights and dimensions.
+type Unit uint8
+
+// Supported measurement units. Mass units get converted via kilogram and
+// length units via meter.
+const (
+	UnitUnknown Unit = iota
+	UnitKilogram
+	UnitGram
+	UnitPound
+	UnitOunce
+	UnitMeter
+	UnitCentimeter
+	UnitMillimeter
+	UnitInch
+	UnitFoot
+	unitMax
+)
+
+const (
+	dimensionMass   = 'm'
+	dimensionLength = 'l'
+)
+
+var unitDefinitions = [unitMax]struct {
+	symbol    string
+	dimension byte
+	factor    float64 // to kilogram or meter
+	imperial  bool
+}{
+	UnitKilogram:   {"kg", dimensionMass, 1, false},
+	UnitGram:       {"g", dimensionMass, 0.001, false},
+	UnitPound:      {"lb", dimensionMass, 0.45359237, true},
+	UnitOunce:      {"oz", dimensionMass, 0.028349523125, true},
+	UnitMeter:      {"m", dimensionLength, 1, false},
+	UnitCentimeter: {"cm", dimensionLength, 0.01, false},
+	UnitMillimeter: {"mm", dimensionLength, 0.001, false},
+	UnitInch:       {"in", dimensionLength, 0.0254, true},
+	UnitFoot:       {"ft", dimensionLength, 0.3048, true},
+}
+
+// unitLocaleSymbols contains the CLDR short unit symbols for languages which
+// differ from the English symbols. Key is the language part of the locale.
+var unitLocaleSymbols = map[string][unitMax]string{
+	"fr": {UnitKilogram: "kg", UnitGram: "g", UnitPound: "lb", UnitOunce: "oz", UnitMeter: "m", UnitCentimeter: "cm", UnitMillimeter: "mm", UnitInch: "po", UnitFoot: "pi"},
+	"ru": {UnitKilogram: "кг", UnitGram: "г", UnitPound: "фнт", UnitOunce: "унц", UnitMeter: "м", UnitCentimeter: "см", UnitMillimeter: "мм", UnitInch: "дюйм", UnitFoot: "фт"},
+}
+
+// ParseUnit parses a unit symbol or name. Next to the symbols the Magento
+// configuration values "kgs" and "lbs" of general/locale/weight_unit are
+// supported. Returns a NotFound error for unknown units.
+func ParseUnit(s string) (Unit, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "kg", "kgs", "kilogram", "kilograms":
+		return UnitKilogram, nil
+	case "g", "gram", "grams":
+		return UnitGram, nil
+	case "lb", "lbs", "pound", "pounds":
+		return UnitPound, nil
+	case "oz", "ounce", "ounces":
+		return UnitOunce, nil
+	case "m", "meter", "meters", "metre", "metres":
+		return UnitMeter, nil
+	case "cm", "centimeter", "centimeters", "centimetre", "centimetres":
+		return UnitCentimeter, nil
+	case "mm", "millimeter", "millimeters", "millimetre", "millimetres":
+		return UnitMillimeter, nil
+	case "in", "inch", "inches":
+		return UnitInch, nil
+	case "ft", "foot", "feet":
+		return UnitFoot, nil
+	}
+	return UnitUnknown, errors.NewNotFoundf("[i18n] Unknown unit %q", s)
+}
+
+// String returns the English short symbol of the unit.
+func (u Unit) String() string {
+	if u >= unitMax {
+		return ""
+	}
+	return unitDefinitions[u].symbol
+}
+
+// Symbol returns the short symbol of the unit for a locale, e.g. de_CH or
+// ru-RU. Falls back to the English symbol.
+func (u Unit) Symbol(locale string) string {
+	if u >= unitMax {
+		return ""
+	}
+	if i := strings.IndexAny(locale, "_-"); i > 0 {
+		locale = locale[:i]
+	}
+	if syms, ok := unitLocaleSymbols[strings.ToLower(locale)]; ok && syms[u] != "" {
+		return syms[u]
+	}
+	return unitDefinitions[u].symbol
+}
+
+// IsMass returns true for weight units.
+func (u Unit) IsMass() bool { return u < unitMax && unitDefinitions[u].dimension == dimensionMass }
+
+// IsLength returns true for dimension units.
+func (u Unit) IsLength() bool { return u < unitMax && unitDefinitions[u].dimension == dimensionLength }
+
+// IsImperial returns true for units of the imperial system.
+func (u Unit) IsImperial() bool { return u < unitMax && unitDefinitions[u].imperial }
+
+// LengthUnit returns the unit commonly used for product dimensions in the
+// same measurement system as the weight unit u: inch for pound and ounce,
+// otherwise centimeter. A length unit returns itself.
+func (u Unit) LengthUnit() Unit {
+	switch {
+	case u.IsLength():
+		return u
+	case u.IsImperial():
+		return UnitInch
+	}
+	return UnitCentimeter
+}
+
+// ConvertUnit converts the value v from one unit into another. Returns a
+// NotValid error if both units measure different dimensions.
+//		kg, _ := ConvertUnit(2.5, UnitPound, UnitKilogram) // 1.13398...
+//		in, _ := ConvertUnit(30, UnitCentimeter, UnitInch) // 11.81102...
+func ConvertUnit(v float64, from, to Unit) (float64, error) {
+	if from == UnitUnknown || from >= unitMax || to == UnitUnknown || to >= unitMax {
+		return 0, errors.NewNotValidf("[i18n] Unknown unit in conversion from %d to %d", from, to)
+	}
+	if from == to {
+		return v, nil
+	}
+	df, dt := unitDefinitions[from], unitDefinitions[to]
+	if df.dimension != dt.dimension {
+		return 0, errors.NewNotValidf("[i18n] Cannot convert %q into %q", df.symbol, dt.symbol)
+	}
+	return v * df.factor / dt.factor, nil
+}
+
+// Measurement formats a value together with the locale specific unit symbol,
+// e.g. 1,234.5 kg. Create it with NewMeasurement. The number format and its
+// symbols can be changed via the embedded Number.
+type Measurement struct {
+	*Number
+	Unit Unit
+	// Locale selects the unit symbol, see Unit.Symbol.
+	Locale string
+}
+
+// MeasurementOptions applies options to the Measurement struct. To read more
+// about the recursion pattern:
+// http://commandcenter.blogspot.com/2014/01/self-referential-functions-and-design.html
+type MeasurementOptions func(*Measurement) MeasurementOptions
+
+// SetMeasurementUnit sets the unit in which the values get printed.
+func SetMeasurementUnit(u Unit) MeasurementOptions {
+	return func(m *Measurement) MeasurementOptions {
+		previous := m.Unit
+		m.Unit = u
+		return SetMeasurementUnit(previous)
+	}
+}
+
+// SetMeasurementLocale sets the locale for the unit symbols.
+func SetMeasurementLocale(locale string) MeasurementOptions {
+	return func(m *Measurement) MeasurementOptions {
+		previous := m.Locale
+		m.Locale = locale
+		return SetMeasurementLocale(previous)
+	}
+}
+
+// SetMeasurementFormat applies a number format (e.g.: #,##0.##) and optional
+// Symbols, see SetNumberFormat.
+func SetMeasurementFormat(f string, s ...Symbols) MeasurementOptions {
+	return func(m *Measurement) MeasurementOptions {
+		return measurementNumberOption(m.NSetOptions(SetNumberFormat(f, s...)))
+	}
+}
+
+func measurementNumberOption(no NumberOptions) MeasurementOptions {
+	return func(m *Measurement) MeasurementOptions {
+		return measurementNumberOption(m.NSetOptions(no))
+	}
+}
+
+// NewMeasurement creates a new Measurement with the default number format
+// and kilogram as unit.
+func NewMeasurement(opts ...MeasurementOptions) *Measurement {
+	m := &Measurement{
+		Number: NewNumber(),
+		Unit:   UnitKilogram,
+		Locale: LocaleDefault,
+	}
+	m.MSetOptions(opts...)
+	return m
+}
+
+// MSetOptions applies measurement options and returns the last applied
+// previous option function.
+func (m *Measurement) MSetOptions(opts ...MeasurementOptions) (previous MeasurementOptions) {
+	for _, o := range opts {
+		if o != nil {
+			previous = o(m)
+		}
+	}
+	return
+}
+
+// FmtNumber formats a number and appends the unit symbol. For more details
+// please see the NumberFormatter interface documentation.
+func (m *Measurement) FmtNumber(w io.Writer, sign int, intgr int64, prec int, frac int64) (int, error) {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+
+	if _, err := m.Number.FmtNumber(buf, sign, intgr, prec, frac); err != nil {
+		return 0, errors.Wrapf(err, "[i18n] Measurement.FmtNumber. Buffer %q; Sign %d; Int %d; Prec %d; Frac %d", buf.String(), sign, intgr, prec, frac)
+	}
+	return m.flushBuf(buf.Bytes(), w)
+}
+
+// FmtInt64 formats an integer and appends the unit symbol.
+func (m *Measurement) FmtInt64(w io.Writer, i int64) (int, error) {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+
+	if _, err := m.Number.FmtInt64(buf, i); err != nil {
+		return 0, errors.Wrapf(err, "[i18n] Measurement.FmtInt64. Buffer %q; Int %d", buf.String(), i)
+	}
+	return m.flushBuf(buf.Bytes(), w)
+}
+
+// FmtFloat64 formats a float and appends the unit symbol.
+func (m *Measurement) FmtFloat64(w io.Writer, f float64) (int, error) {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+
+	if _, err := m.Number.FmtFloat64(buf, f); err != nil {
+		return 0, errors.Wrapf(err, "[i18n] Measurement.FmtFloat64. Buffer %q; Float %.6f", buf.String(), f)
+	}
+	return m.flushBuf(buf.Bytes(), w)
+}
+
+// FmtUnit converts the value v from unit `from` into the unit of the
+// Measurement and formats it. Use it to print a weight stored in kilogram
+// in the unit configured for a store.
+//		m := NewMeasurement(SetMeasurementUnit(UnitPound))
+//		m.FmtUnit(w, 2, UnitKilogram) // 4.409 lb
+func (m *Measurement) FmtUnit(w io.Writer, v float64, from Unit) (int, error) {
+	cv, err := ConvertUnit(v, from, m.Unit)
+	if err != nil {
+		return 0, errors.Wrap(err, "[i18n] Measurement.FmtUnit")
+	}
+	return m.FmtFloat64(w, cv)
+}
+
+// flushBuf writes the number, a no-break space and the unit symbol.
+func (m *Measurement) flushBuf(num []byte, w io.Writer) (int, error) {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	buf.Write(num)
+	buf.WriteString("\u00a0")
+	buf.WriteString(m.Unit.Symbol(m.Locale))
+	return w.Write(buf.Bytes())
+}
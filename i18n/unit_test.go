@@ -0,0 +1,132 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/corestoreio/csfw/i18n"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUnit(t *testing.T) {
+	tests := []struct {
+		have    string
+		want    i18n.Unit
+		wantErr bool
+	}{
+		{"kgs", i18n.UnitKilogram, false},
+		{"lbs", i18n.UnitPound, false},
+		{" KG ", i18n.UnitKilogram, false},
+		{"inch", i18n.UnitInch, false},
+		{"cm", i18n.UnitCentimeter, false},
+		{"feet", i18n.UnitFoot, false},
+		{"stone", i18n.UnitUnknown, true},
+	}
+	for _, test := range tests {
+		u, err := i18n.ParseUnit(test.have)
+		if test.wantErr {
+			assert.True(t, errors.IsNotFound(err), "%q: %+v", test.have, err)
+		} else {
+			assert.NoError(t, err, "%q", test.have)
+		}
+		assert.Exactly(t, test.want, u, "%q", test.have)
+	}
+}
+
+func TestConvertUnit(t *testing.T) {
+	tests := []struct {
+		v        float64
+		from, to i18n.Unit
+		want     float64
+	}{
+		{1, i18n.UnitPound, i18n.UnitKilogram, 0.45359237},
+		{1, i18n.UnitKilogram, i18n.UnitPound, 2.2046226218},
+		{16, i18n.UnitOunce, i18n.UnitPound, 1},
+		{1500, i18n.UnitGram, i18n.UnitKilogram, 1.5},
+		{1, i18n.UnitInch, i18n.UnitCentimeter, 2.54},
+		{30, i18n.UnitCentimeter, i18n.UnitInch, 11.8110236220},
+		{1, i18n.UnitFoot, i18n.UnitInch, 12},
+		{3, i18n.UnitMeter, i18n.UnitMeter, 3},
+	}
+	for _, test := range tests {
+		have, err := i18n.ConvertUnit(test.v, test.from, test.to)
+		assert.NoError(t, err)
+		assert.True(t, math.Abs(test.want-have) < 1e-9, "%v %s => %s: want %v have %v", test.v, test.from, test.to, test.want, have)
+	}
+
+	_, err := i18n.ConvertUnit(1, i18n.UnitKilogram, i18n.UnitInch)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	_, err = i18n.ConvertUnit(1, i18n.UnitUnknown, i18n.UnitInch)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestUnit_Symbol(t *testing.T) {
+	assert.Exactly(t, "in", i18n.UnitInch.Symbol("en_US"))
+	assert.Exactly(t, "po", i18n.UnitInch.Symbol("fr_CA"))
+	assert.Exactly(t, "кг", i18n.UnitKilogram.Symbol("ru-RU"))
+	assert.Exactly(t, "kg", i18n.UnitKilogram.Symbol("de_CH"))
+	assert.Exactly(t, "lb", i18n.UnitPound.String())
+
+	assert.Exactly(t, i18n.UnitInch, i18n.UnitPound.LengthUnit())
+	assert.Exactly(t, i18n.UnitCentimeter, i18n.UnitKilogram.LengthUnit())
+	assert.Exactly(t, i18n.UnitMillimeter, i18n.UnitMillimeter.LengthUnit())
+	assert.True(t, i18n.UnitOunce.IsMass())
+	assert.True(t, i18n.UnitFoot.IsLength())
+	assert.False(t, i18n.UnitGram.IsImperial())
+}
+
+func TestMeasurement_FmtUnit(t *testing.T) {
+	tests := []struct {
+		m    *i18n.Measurement
+		v    float64
+		from i18n.Unit
+		want string
+	}{
+		{i18n.NewMeasurement(), 1234.5, i18n.UnitKilogram, "1,234.500\u00a0kg"},
+		{i18n.NewMeasurement(i18n.SetMeasurementUnit(i18n.UnitPound)), 2, i18n.UnitKilogram, "4.409\u00a0lb"},
+		{i18n.NewMeasurement(i18n.SetMeasurementUnit(i18n.UnitInch), i18n.SetMeasurementLocale("fr_FR"),
+			i18n.SetMeasurementFormat("#,##0.0", i18n.Symbols{Decimal: ',', Group: '\u00a0'})),
+			30, i18n.UnitCentimeter, "11,8\u00a0po"},
+		{i18n.NewMeasurement(i18n.SetMeasurementUnit(i18n.UnitCentimeter), i18n.SetMeasurementLocale("ru_RU")),
+			12, i18n.UnitInch, "30.480\u00a0см"},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		_, err := test.m.FmtUnit(&buf, test.v, test.from)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, buf.String(), "Index %d", i)
+	}
+
+	var buf bytes.Buffer
+	_, err := i18n.NewMeasurement().FmtUnit(&buf, 1, i18n.UnitInch)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+
+	buf.Reset()
+	_, err = i18n.NewMeasurement(i18n.SetMeasurementUnit(i18n.UnitGram)).FmtInt64(&buf, 500)
+	assert.NoError(t, err)
+	assert.Exactly(t, "500.000\u00a0g", buf.String())
+}
+
+func TestMeasurement_SetOptionsPrevious(t *testing.T) {
+	m := i18n.NewMeasurement()
+	prev := m.MSetOptions(i18n.SetMeasurementUnit(i18n.UnitOunce))
+	assert.Exactly(t, i18n.UnitOunce, m.Unit)
+	m.MSetOptions(prev)
+	assert.Exactly(t, i18n.UnitKilogram, m.Unit)
+}
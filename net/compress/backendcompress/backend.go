@@ -0,0 +1,80 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backendcompress
+
+import (
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/csfw/config/cfgsource"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/net/compress"
+)
+
+// Configuration just exported for the sake of documentation. See fields for
+// more information. Please call the New() function for creating a new
+// Configuration object.
+type Configuration struct {
+	*compress.OptionFactories
+
+	// Disabled set to true to disable the response compression.
+	//
+	// Path: net/compress/disabled
+	Disabled cfgmodel.Bool
+
+	// Encodings list of Content-Encoding names in the order of preference.
+	// Separate via line break (\n).
+	//
+	// Path: net/compress/encodings
+	Encodings cfgmodel.StringCSV
+
+	// ContentTypes list of media types which should be compressed. An entry
+	// ending with "/*" matches all sub types. Separate via line break (\n).
+	//
+	// Path: net/compress/content_types
+	ContentTypes cfgmodel.StringCSV
+
+	// MinSize minimum size of the response body in bytes to start the
+	// compression.
+	//
+	// Path: net/compress/min_size
+	MinSize cfgmodel.Int
+
+	// Level compression level from 1 (best speed) to 9 (best compression).
+	// -1 applies the default level of the encoder.
+	//
+	// Path: net/compress/level
+	Level cfgmodel.Int
+}
+
+// New initializes the backend configuration models containing the cfgpath.Route
+// variable to the appropriate entries. The function Load() will be executed to
+// apply the SectionSlice to all models. See Load() for more details.
+func New(cfgStruct element.SectionSlice, opts ...cfgmodel.Option) *Configuration {
+	be := &Configuration{
+		OptionFactories: compress.NewOptionFactories(),
+	}
+
+	opts = append(opts, cfgmodel.WithFieldFromSectionSlice(cfgStruct))
+	optsCSV := append([]cfgmodel.Option{}, opts...)
+	optsCSV = append(optsCSV, cfgmodel.WithCSVComma('\n'))
+	optsYN := append([]cfgmodel.Option{}, opts...)
+	optsYN = append(optsYN, cfgmodel.WithSource(cfgsource.YesNo))
+
+	be.Disabled = cfgmodel.NewBool(`net/compress/disabled`, optsYN...)
+	be.Encodings = cfgmodel.NewStringCSV(`net/compress/encodings`, optsCSV...)
+	be.ContentTypes = cfgmodel.NewStringCSV(`net/compress/content_types`, optsCSV...)
+	be.MinSize = cfgmodel.NewInt(`net/compress/min_size`, opts...)
+	be.Level = cfgmodel.NewInt(`net/compress/level`, opts...)
+	return be
+}
@@ -0,0 +1,72 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backendcompress
+
+import (
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/net/compress"
+	"github.com/corestoreio/errors"
+)
+
+// PrepareOptionFactory creates a closure around the type Configuration. The
+// closure will be used during a scoped request to figure out the configuration
+// depending on the incoming scope. An option array will be returned by the
+// closure.
+func (be *Configuration) PrepareOptionFactory() compress.OptionFactoryFunc {
+	return func(sg config.Scoped) []compress.Option {
+		var (
+			opts     [3]compress.Option
+			settings compress.Settings
+			err      error
+		)
+
+		// DISABLED
+		isDisabled, err := be.Disabled.Get(sg)
+		if err != nil {
+			return compress.OptionsError(errors.Wrap(err, "[backendcompress] Disabled.Get"))
+		}
+
+		// ENCODINGS
+		settings.Encodings, err = be.Encodings.Get(sg)
+		if err != nil {
+			return compress.OptionsError(errors.Wrap(err, "[backendcompress] Encodings.Get"))
+		}
+
+		// CONTENT TYPES
+		settings.ContentTypes, err = be.ContentTypes.Get(sg)
+		if err != nil {
+			return compress.OptionsError(errors.Wrap(err, "[backendcompress] ContentTypes.Get"))
+		}
+
+		// MIN SIZE
+		settings.MinSize, err = be.MinSize.Get(sg)
+		if err != nil {
+			return compress.OptionsError(errors.Wrap(err, "[backendcompress] MinSize.Get"))
+		}
+
+		// LEVEL
+		settings.Level, err = be.Level.Get(sg)
+		if err != nil {
+			return compress.OptionsError(errors.Wrap(err, "[backendcompress] Level.Get"))
+		}
+
+		// in case someone marks the config as partially applied now it's time to revert
+		// it.
+		opts[0] = compress.WithMarkPartiallyApplied(false, sg.ScopeIDs()...)
+		opts[1] = compress.WithSettings(settings, sg.ScopeIDs()...)
+		opts[2] = compress.WithDisable(isDisabled, sg.ScopeIDs()...)
+		return opts[:]
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backendcompress_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	csnet "github.com/corestoreio/csfw/net"
+	"github.com/corestoreio/csfw/net/compress"
+	"github.com/corestoreio/csfw/net/compress/backendcompress"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var backend *backendcompress.Configuration
+
+func init() {
+	cfgStruct, err := backendcompress.NewConfigStructure()
+	if err != nil {
+		panic(err)
+	}
+	backend = backendcompress.New(cfgStruct)
+}
+
+func TestConfiguration_HierarchicalConfig(t *testing.T) {
+	scpCfgSrv := cfgmock.NewService(cfgmock.PathValue{
+		backend.Encodings.MustFQWebsite(3): "deflate",
+		backend.MinSize.MustFQ():           256,
+	}).NewScoped(3, 0)
+
+	srv := compress.MustNew(
+		compress.WithOptionFactory(backend.PrepareOptionFactory()),
+	)
+	scpCfg, err := srv.ConfigByScopedGetter(scpCfgSrv)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, []string{"deflate"}, scpCfg.Encodings)
+	assert.Exactly(t, 256, scpCfg.MinSize)
+	assert.Exactly(t, -1, scpCfg.Level)
+	assert.Contains(t, scpCfg.ContentTypes, "application/json")
+	assert.False(t, scpCfg.Disabled)
+}
+
+func TestConfiguration_Disabled(t *testing.T) {
+	srv := compress.MustNew(
+		compress.WithRootConfig(cfgmock.NewService(cfgmock.PathValue{
+			backend.Disabled.MustFQWebsite(2): 1,
+		})),
+		compress.WithOptionFactory(backend.PrepareOptionFactory()),
+		compress.WithServiceErrorHandler(mw.ErrorWithPanic),
+	)
+	req := httptest.NewRequest("GET", "https://corestore.io/api/v1/products", nil)
+	req.Header.Set(csnet.AcceptEncoding, "gzip")
+	req = req.WithContext(scope.WithContext(req.Context(), 2, 4))
+
+	body := strings.Repeat(`{"sku":"4711"}`, 200)
+	rec := httptest.NewRecorder()
+	srv.WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(csnet.ContentType, csnet.ApplicationJSON)
+		_, _ = w.Write([]byte(body))
+	})).ServeHTTP(rec, req)
+
+	assert.Empty(t, rec.Header().Get(csnet.ContentEncoding))
+	assert.Exactly(t, body, rec.Body.String())
+}
+
+func TestBackend_Path_Errors(t *testing.T) {
+	tests := []struct {
+		toPath func(int64) string
+		val    interface{}
+		errBhf errors.BehaviourFunc
+	}{
+		{backend.Disabled.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.Encodings.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.Encodings.MustFQWebsite, "lzw", errors.IsNotFound},
+		{backend.ContentTypes.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.MinSize.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.Level.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.Level.MustFQWebsite, 33, errors.IsNotValid},
+	}
+	for i, test := range tests {
+		scpFnc := backend.PrepareOptionFactory()
+		cfgSrv := cfgmock.NewService(cfgmock.PathValue{
+			test.toPath(2): test.val,
+		})
+		cfgScp := cfgSrv.NewScoped(2, 0)
+
+		_, err := compress.New(scpFnc(cfgScp)...)
+		assert.True(t, test.errBhf(err), "Index %d Error: %+v", i, err)
+	}
+}
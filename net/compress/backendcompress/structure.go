@@ -0,0 +1,103 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backendcompress
+
+import (
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/storage/text"
+	"github.com/corestoreio/csfw/store/scope"
+)
+
+// NewConfigStructure global configuration structure for this package.
+// Used in frontend (to display the user all the settings) and in
+// backend (scope checks and default values). See the source code
+// of this function for the overall available sections, groups and fields.
+func NewConfigStructure() (element.SectionSlice, error) {
+	return element.NewConfiguration(
+		element.Section{
+			ID: cfgpath.NewRoute(`net`),
+			Groups: element.NewGroupSlice(
+				element.Group{
+					ID:    cfgpath.NewRoute(`compress`),
+					Label: text.Chars(`Response Compression`),
+					Comment: text.Chars(`Compresses the response body with the first
+encoding accepted by the client.`),
+					SortOrder: 170,
+					Scopes:    scope.PermWebsite,
+					Fields: element.NewFieldSlice(
+						element.Field{
+							// Path: `net/compress/disabled`,
+							ID:        cfgpath.NewRoute(`disabled`),
+							Label:     text.Chars(`Disable compression`),
+							Type:      element.TypeSelect,
+							SortOrder: 10,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   `false`,
+						},
+						element.Field{
+							// Path: `net/compress/encodings`,
+							ID:    cfgpath.NewRoute(`encodings`),
+							Label: text.Chars(`Encodings`),
+							Comment: text.Chars(`List of Content-Encoding names in the order
+of preference. Separate via line break (\n)`),
+							Type:      element.TypeTextarea,
+							SortOrder: 20,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   "gzip\ndeflate",
+						},
+						element.Field{
+							// Path: `net/compress/content_types`,
+							ID:    cfgpath.NewRoute(`content_types`),
+							Label: text.Chars(`Content Types`),
+							Comment: text.Chars(`List of media types which should be
+compressed. An entry ending with "/*" matches all sub types. An empty list
+compresses all types. Separate via line break (\n)`),
+							Type:      element.TypeTextarea,
+							SortOrder: 30,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   "text/*\napplication/json\napplication/javascript\napplication/xml\nimage/svg+xml",
+						},
+						element.Field{
+							// Path: `net/compress/min_size`,
+							ID:        cfgpath.NewRoute(`min_size`),
+							Label:     text.Chars(`Minimum size in bytes`),
+							Comment:   text.Chars(`Responses smaller than this size won't get compressed.`),
+							Type:      element.TypeText,
+							SortOrder: 40,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   1024,
+						},
+						element.Field{
+							// Path: `net/compress/level`,
+							ID:        cfgpath.NewRoute(`level`),
+							Label:     text.Chars(`Compression level`),
+							Comment:   text.Chars(`From 1 (best speed) to 9 (best compression). -1 applies the default level.`),
+							Type:      element.TypeText,
+							SortOrder: 50,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   -1,
+						},
+					),
+				},
+			),
+		},
+	)
+}
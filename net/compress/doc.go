@@ -0,0 +1,27 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compress provides a scope based HTTP middleware to compress the
+// response body.
+//
+// The Service negotiates the encoding via the Accept-Encoding request header
+// and compresses only responses whose Content-Type matches the configured list
+// and whose body size reaches a minimum threshold. The compression writers are
+// pooled per encoding and compression level. GZIP and deflate are registered by
+// default, further algorithms like brotli can be added with RegisterEncoder.
+//
+// Each website scope can have its own settings or disable compression
+// entirely. The package backendcompress loads the settings from the
+// configuration.
+package compress
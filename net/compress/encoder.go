@@ -0,0 +1,133 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+
+	csnet "github.com/corestoreio/csfw/net"
+	"github.com/corestoreio/errors"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+)
+
+// Encoder defines the functions needed by the Service to compress a response
+// body. The gzip and flate writers of the klauspost/compress package satisfy
+// this interface and so does for example the brotli writer of
+// github.com/andybalholm/brotli.
+type Encoder interface {
+	io.WriteCloser
+	// Flush writes any pending data to the underlying writer.
+	Flush() error
+	// Reset discards the state and switches to the new writer.
+	Reset(w io.Writer)
+}
+
+// EncoderFactory creates a new Encoder with the provided compression level.
+// The Encoder will be reset to the http.ResponseWriter before its first usage.
+type EncoderFactory func(level int) (Encoder, error)
+
+type encoderKey struct {
+	name  string
+	level int
+}
+
+// encoders global registry of all available compression algorithms. The key
+// of the factories map equals the Content-Encoding name.
+var encoders = struct {
+	sync.RWMutex
+	factories map[string]EncoderFactory
+	pools     map[encoderKey]*sync.Pool
+}{
+	factories: map[string]EncoderFactory{
+		csnet.CompressGZIP: func(level int) (Encoder, error) {
+			return gzip.NewWriterLevel(ioutil.Discard, level)
+		},
+		csnet.CompressDeflate: func(level int) (Encoder, error) {
+			return flate.NewWriter(ioutil.Discard, level)
+		},
+	},
+	pools: make(map[encoderKey]*sync.Pool),
+}
+
+// RegisterEncoder adds a new compression algorithm identified by its
+// Content-Encoding name, for example "br". An already registered name gets
+// overwritten. This function should be called during the init process of an
+// application. To enable the algorithm the name must be added to
+// Settings.Encodings.
+func RegisterEncoder(name string, ef EncoderFactory) {
+	encoders.Lock()
+	defer encoders.Unlock()
+	encoders.factories[name] = ef
+	for k := range encoders.pools {
+		if k.name == name {
+			delete(encoders.pools, k)
+		}
+	}
+}
+
+// encoderPool returns the pool for an encoding and a compression level. A
+// returned pool creates a new Encoder or nil if the factory returns an error.
+func encoderPool(name string, level int) (*sync.Pool, error) {
+	key := encoderKey{name: name, level: level}
+	encoders.RLock()
+	p, ok := encoders.pools[key]
+	encoders.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	encoders.Lock()
+	defer encoders.Unlock()
+	if p, ok := encoders.pools[key]; ok {
+		return p, nil
+	}
+	ef, ok := encoders.factories[name]
+	if !ok {
+		return nil, errors.NewNotFoundf(errEncoderNotFound, name)
+	}
+	p = &sync.Pool{
+		New: func() interface{} {
+			enc, err := ef(level)
+			if err != nil {
+				return nil
+			}
+			return enc
+		},
+	}
+	encoders.pools[key] = p
+	return p, nil
+}
+
+// validateEncoder checks if the encoding has been registered and creates one
+// Encoder to figure out whether the compression level is supported. The
+// created Encoder gets put into the pool.
+func validateEncoder(name string, level int) error {
+	p, err := encoderPool(name, level)
+	if err != nil {
+		return errors.Wrap(err, "[compress] validateEncoder.encoderPool")
+	}
+	encoders.RLock()
+	ef := encoders.factories[name]
+	encoders.RUnlock()
+	enc, err := ef(level)
+	if err != nil {
+		return errors.NewNotValidf(errEncoderLevelInvalid, name, level, err)
+	}
+	p.Put(enc)
+	return nil
+}
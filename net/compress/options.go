@@ -0,0 +1,111 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"strings"
+
+	csnet "github.com/corestoreio/csfw/net"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/klauspost/compress/flate"
+)
+
+// DefaultMinSize defines the minimum body size in bytes before the response
+// gets compressed. Smaller bodies won't benefit from compression.
+const DefaultMinSize = 1024
+
+// Settings general settings for the compression service. Those settings will
+// be applied via functional options on a per scope basis.
+type Settings struct {
+	// Encodings list of Content-Encoding names in the order of preference. The
+	// first encoding accepted by the client wins. Each encoding must have been
+	// registered with RegisterEncoder. Default value is ["gzip","deflate"].
+	Encodings []string
+	// ContentTypes list of media types which should be compressed. An entry
+	// ending with "/*" matches all sub types, e.g. "text/*". An empty list
+	// compresses all content types.
+	ContentTypes []string
+	// MinSize minimum size of the response body in bytes to start the
+	// compression. Default value is DefaultMinSize.
+	MinSize int
+	// Level compression level applied to all encoders. Default value is -1,
+	// the default compression level of the encoder.
+	Level int
+}
+
+// WithDefaultConfig applies the default compression settings for a specific
+// scope. This function overwrites any previous set options. Default values
+// are:
+//		- Encodings: gzip, deflate
+//		- ContentTypes: text/*, application/json, application/javascript, application/xml, image/svg+xml
+//		- MinSize: 1024 bytes
+//		- Level: -1
+func WithDefaultConfig(h scope.TypeID) Option {
+	return withDefaultConfig(h)
+}
+
+// WithSettings applies the Settings struct to a specific scope. Empty
+// Encodings and ContentTypes fields won't overwrite the previous values. The
+// encodings get validated against the registered encoders and the
+// compression level.
+func WithSettings(stng Settings, scopeIDs ...scope.TypeID) Option {
+	encs := make([]string, 0, len(stng.Encodings))
+	for _, e := range stng.Encodings {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" {
+			encs = append(encs, e)
+		}
+	}
+	cts := make([]string, 0, len(stng.ContentTypes))
+	for _, ct := range stng.ContentTypes {
+		if ct = strings.ToLower(strings.TrimSpace(ct)); ct != "" {
+			cts = append(cts, ct)
+		}
+	}
+
+	return func(s *Service) error {
+		sc := s.findScopedConfig(scopeIDs...)
+		if len(encs) > 0 {
+			sc.Encodings = encs
+		}
+		for _, e := range sc.Encodings {
+			if err := validateEncoder(e, stng.Level); err != nil {
+				s.rwmu.Unlock() // acquired in findScopedConfig
+				return errors.Wrap(err, "[compress] WithSettings")
+			}
+		}
+		if len(cts) > 0 {
+			sc.ContentTypes = cts
+		}
+		sc.MinSize = stng.MinSize
+		sc.Level = stng.Level
+		return s.updateScopedConfig(sc)
+	}
+}
+
+func defaultSettings() Settings {
+	return Settings{
+		Encodings: []string{csnet.CompressGZIP, csnet.CompressDeflate},
+		ContentTypes: []string{
+			"text/*",
+			csnet.ApplicationJSON,
+			csnet.ApplicationJavaScript,
+			csnet.ApplicationXML,
+			"image/svg+xml",
+		},
+		MinSize: DefaultMinSize,
+		Level:   flate.DefaultCompression,
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+
+	csnet "github.com/corestoreio/csfw/net"
+	"github.com/corestoreio/errors"
+)
+
+// responseWriter buffers the first bytes of the body until Settings.MinSize
+// has been reached. Afterwards it decides, depending on the size and the
+// Content-Type, whether the body gets compressed or written as it is.
+type responseWriter struct {
+	http.ResponseWriter
+	sc       *ScopedConfig
+	encoding string
+	pool     *sync.Pool
+	enc      Encoder
+	buf      []byte
+	code     int
+	started  bool
+}
+
+func newResponseWriter(w http.ResponseWriter, sc *ScopedConfig, encoding string, pool *sync.Pool) *responseWriter {
+	return &responseWriter{
+		ResponseWriter: w,
+		sc:             sc,
+		encoding:       encoding,
+		pool:           pool,
+	}
+}
+
+// WriteHeader delays writing the status code until it is known whether the
+// body gets compressed.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.started {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.started {
+		if w.enc != nil {
+			return w.enc.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) < w.sc.MinSize {
+		return len(b), nil
+	}
+	if err := w.start(); err != nil {
+		return 0, errors.Wrap(err, "[compress] responseWriter.Write")
+	}
+	return len(b), nil
+}
+
+// start writes the header and the buffered body. The Encoder gets taken out of
+// the pool if the body should be compressed.
+func (w *responseWriter) start() error {
+	w.started = true
+	h := w.Header()
+	if h.Get(csnet.ContentType) == "" && len(w.buf) > 0 {
+		h.Set(csnet.ContentType, http.DetectContentType(w.buf))
+	}
+
+	if w.shouldCompress() {
+		if enc, ok := w.pool.Get().(Encoder); ok && enc != nil {
+			enc.Reset(w.ResponseWriter)
+			w.enc = enc
+			h.Set(csnet.ContentEncoding, w.encoding)
+			h.Del(csnet.ContentLength)
+		}
+	}
+
+	if w.code != 0 {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *responseWriter) shouldCompress() bool {
+	switch {
+	case len(w.buf) == 0, len(w.buf) < w.sc.MinSize:
+		return false
+	case w.code == http.StatusNoContent, w.code == http.StatusNotModified:
+		return false
+	case w.Header().Get(csnet.ContentEncoding) != "":
+		return false // already encoded by the next handler
+	}
+	return w.sc.isContentTypeAllowed(w.Header().Get(csnet.ContentType))
+}
+
+// close flushes the buffer and puts the Encoder back into the pool.
+func (w *responseWriter) close() error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return errors.Wrap(err, "[compress] responseWriter.close.start")
+		}
+	}
+	if w.enc == nil {
+		return nil
+	}
+	err := w.enc.Close()
+	w.enc.Reset(ioutil.Discard)
+	w.pool.Put(w.enc)
+	w.enc = nil
+	return errors.Wrap(err, "[compress] responseWriter.close.Encoder")
+}
+
+// Flush implements the http.Flusher interface. A flush before reaching the
+// minimum size writes the body uncompressed.
+func (w *responseWriter) Flush() {
+	if !w.started {
+		_ = w.start()
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.NewNotImplementedf("[compress] ResponseWriter does not implement http.Hijacker")
+	}
+	return hj.Hijack()
+}
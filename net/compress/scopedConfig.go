@@ -0,0 +1,124 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// ScopedConfig scoped based configuration and should not be embedded into your
+// own types. Call ScopedConfig.ScopeID to know to which scope this
+// configuration has been bound to.
+type ScopedConfig struct {
+	scopedConfigGeneric
+
+	// Settings general compression settings
+	Settings
+}
+
+// isValid a configuration for a scope is only then valid when
+//	- ScopeID set
+//	- min 1x encoding set
+//	- MinSize not negative
+func (sc *ScopedConfig) isValid() error {
+	if err := sc.isValidPreCheck(); err != nil {
+		return errors.Wrap(err, "[compress] scopedConfig.isValid as an lastErr")
+	}
+	if sc.Disabled {
+		return nil
+	}
+	if sc.ScopeID > 0 && len(sc.Encodings) > 0 && sc.MinSize >= 0 {
+		return nil
+	}
+	return errors.NewNotValidf(errScopedConfigNotValid, sc.ScopeID, sc.Encodings, sc.MinSize)
+}
+
+// newScopedConfig creates a new object with the minimum needed configuration.
+func newScopedConfig(target, parent scope.TypeID) *ScopedConfig {
+	return &ScopedConfig{
+		scopedConfigGeneric: newScopedConfigGeneric(target, parent),
+		Settings:            defaultSettings(),
+	}
+}
+
+// negotiateEncoding returns the first configured encoding which the client
+// accepts. Returns an empty string if there is no match or the client sent no
+// Accept-Encoding header.
+func (sc *ScopedConfig) negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	accepted := parseAcceptEncoding(acceptEncoding)
+	for _, enc := range sc.Encodings {
+		q, ok := accepted[enc]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > 0 {
+			return enc
+		}
+	}
+	return ""
+}
+
+// isContentTypeAllowed checks if the media type of the Content-Type header
+// value matches one of the configured content types.
+func (sc *ScopedConfig) isContentTypeAllowed(contentType string) bool {
+	if len(sc.ContentTypes) == 0 {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, ct := range sc.ContentTypes {
+		if ct == mt {
+			return true
+		}
+		if strings.HasSuffix(ct, "/*") && strings.HasPrefix(mt, ct[:len(ct)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAcceptEncoding parses the Accept-Encoding header value into a map where
+// the key is the lower case encoding name and the value its quality.
+func parseAcceptEncoding(header string) map[string]float64 {
+	accepted := make(map[string]float64, 3)
+	for _, part := range strings.Split(header, ",") {
+		name, q := part, 1.0
+		if i := strings.IndexByte(part, ';'); i >= 0 {
+			name = part[:i]
+			param := strings.TrimSpace(part[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				f, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					f = 0
+				}
+				q = f
+			}
+		}
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			accepted[name] = q
+		}
+	}
+	return accepted
+}
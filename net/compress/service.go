@@ -0,0 +1,50 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:generate go run ../internal/scopedservice/main_copy.go "$GOPACKAGE"
+
+package compress
+
+import (
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// Service compresses the response body of a request depending on the scoped
+// configuration. The configuration can only be applied to the default and
+// website scope.
+type Service struct {
+	service
+}
+
+// New creates a new compression service with the provided options.
+func New(opts ...Option) (*Service, error) {
+	s, err := newService(opts...)
+	if s != nil {
+		s.useWebsite = true
+		s.optionAfterApply = func() error {
+			s.rwmu.RLock()
+			defer s.rwmu.RUnlock()
+			// validate that the applied functional options can only be set for
+			// scope website. scope store makes no sense.
+			for h := range s.scopeCache {
+				if scp, _ := h.Unpack(); scp > scope.Website {
+					return errors.NewNotSupportedf(errServiceUnsupportedScope, h)
+				}
+			}
+			return nil
+		}
+	}
+	return s, err
+}
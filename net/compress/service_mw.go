@@ -0,0 +1,68 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"net/http"
+
+	csnet "github.com/corestoreio/csfw/net"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+	loghttp "github.com/corestoreio/log/http"
+)
+
+// WithCompression to be used as a middleware. The response body gets
+// compressed with the first configured encoding which the client accepts.
+// This middleware expects to find a scope.FromContext().
+func (s *Service) WithCompression(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		scpCfg, err := s.configByContext(r.Context())
+		if err != nil {
+			s.Log.Info("compress.Service.WithCompression.configByContext.Error", log.Err(err))
+			if s.Log.IsDebug() {
+				s.Log.Debug("compress.Service.WithCompression.configByContext", log.Err(err), loghttp.Request("request", r))
+			}
+			s.ErrorHandler(errors.Wrap(err, "compress.Service.WithCompression.configFromContext")).ServeHTTP(w, r)
+			return
+		}
+		if scpCfg.Disabled || r.Method == csnet.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add(csnet.Vary, csnet.AcceptEncoding)
+
+		encoding := scpCfg.negotiateEncoding(r.Header.Get(csnet.AcceptEncoding))
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		pool, err := encoderPool(encoding, scpCfg.Level)
+		if err != nil {
+			s.ErrorHandler(errors.Wrap(err, "compress.Service.WithCompression.encoderPool")).ServeHTTP(w, r)
+			return
+		}
+		if s.Log.IsDebug() {
+			s.Log.Debug("compress.Service.WithCompression.encoding", log.String("encoding", encoding), log.Stringer("scope", scpCfg.ScopeID))
+		}
+
+		cw := newResponseWriter(w, &scpCfg, encoding, pool)
+		next.ServeHTTP(cw, r)
+		if err := cw.close(); err != nil && s.Log.IsInfo() {
+			s.Log.Info("compress.Service.WithCompression.close", log.Err(err), log.String("encoding", encoding))
+		}
+	})
+}
@@ -0,0 +1,164 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	csnet "github.com/corestoreio/csfw/net"
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/compress"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+)
+
+func reqWithWebsite(acceptEncoding string) *http.Request {
+	req := httptest.NewRequest("GET", "https://corestore.io/catalog/product/id/33454", nil)
+	if acceptEncoding != "" {
+		req.Header.Set(csnet.AcceptEncoding, acceptEncoding)
+	}
+	return req.WithContext(scope.WithContext(req.Context(), 2, 0))
+}
+
+func jsonHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(csnet.ContentType, csnet.ApplicationJSONCharsetUTF8)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(body))
+	})
+}
+
+func TestService_WithCompression_MWAdapter(t *testing.T) {
+	// checks if the middleware conforms to the mw.Middleware definition
+	srv := compress.MustNew()
+	_ = mw.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// noop
+	}), srv.WithCompression)
+}
+
+func TestNew_UnsupportedScope(t *testing.T) {
+	srv := compress.MustNew()
+	err := srv.Options(compress.WithDisable(true, scope.Store.Pack(1)))
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+}
+
+func TestWithSettings_Errors(t *testing.T) {
+	_, err := compress.New(compress.WithSettings(compress.Settings{Encodings: []string{"lzw"}}))
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+
+	_, err = compress.New(compress.WithSettings(compress.Settings{Level: 42}))
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestService_WithCompression(t *testing.T) {
+	largeBody := strings.Repeat(`{"sku":"4711","name":"Gopher"},`, 100)
+	srv := compress.MustNew(
+		compress.WithRootConfig(cfgmock.NewService()),
+		compress.WithServiceErrorHandler(mw.ErrorWithPanic),
+		compress.WithSettings(compress.Settings{
+			Encodings:    []string{"deflate", "gzip"},
+			ContentTypes: []string{"application/json"},
+			MinSize:      512,
+			Level:        5,
+		}, scope.Website.Pack(2)),
+		compress.WithDisable(true, scope.Website.Pack(3)),
+	)
+
+	t.Run("gzip", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		srv.WithCompression(jsonHandler(largeBody)).ServeHTTP(rec, reqWithWebsite("gzip, deflate;q=0"))
+
+		assert.Exactly(t, http.StatusCreated, rec.Code)
+		assert.Exactly(t, csnet.CompressGZIP, rec.Header().Get(csnet.ContentEncoding))
+		assert.Exactly(t, csnet.AcceptEncoding, rec.Header().Get(csnet.Vary))
+
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		have, err := ioutil.ReadAll(zr)
+		assert.NoError(t, err)
+		assert.Exactly(t, largeBody, string(have))
+	})
+	t.Run("deflate preferred", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		srv.WithCompression(jsonHandler(largeBody)).ServeHTTP(rec, reqWithWebsite("gzip, deflate"))
+		assert.Exactly(t, csnet.CompressDeflate, rec.Header().Get(csnet.ContentEncoding))
+		assert.True(t, rec.Body.Len() < len(largeBody))
+	})
+	t.Run("below min size", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		srv.WithCompression(jsonHandler(`{"sku":"4711"}`)).ServeHTTP(rec, reqWithWebsite("gzip"))
+		assert.Exactly(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(csnet.ContentEncoding))
+		assert.Exactly(t, `{"sku":"4711"}`, rec.Body.String())
+	})
+	t.Run("content type not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		srv.WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(bytes.Repeat([]byte{0xff, 0xd8, 0xff}, 400))
+		})).ServeHTTP(rec, reqWithWebsite("gzip"))
+		assert.Empty(t, rec.Header().Get(csnet.ContentEncoding))
+		assert.Exactly(t, 1200, rec.Body.Len())
+	})
+	t.Run("no accept encoding", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		srv.WithCompression(jsonHandler(largeBody)).ServeHTTP(rec, reqWithWebsite(""))
+		assert.Empty(t, rec.Header().Get(csnet.ContentEncoding))
+		assert.Exactly(t, largeBody, rec.Body.String())
+	})
+	t.Run("disabled website", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := reqWithWebsite("gzip")
+		req = req.WithContext(scope.WithContext(req.Context(), 3, 0))
+		srv.WithCompression(jsonHandler(largeBody)).ServeHTTP(rec, req)
+		assert.Empty(t, rec.Header().Get(csnet.ContentEncoding))
+		assert.Empty(t, rec.Header().Get(csnet.Vary))
+		assert.Exactly(t, largeBody, rec.Body.String())
+	})
+}
+
+func TestService_WithCompression_DefaultConfigWildcard(t *testing.T) {
+	srv := compress.MustNew(
+		compress.WithRootConfig(cfgmock.NewService()),
+		compress.WithServiceErrorHandler(mw.ErrorWithPanic),
+	)
+	body := strings.Repeat("Hello Gophers! ", 200)
+
+	rec := httptest.NewRecorder()
+	srv.WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(csnet.ContentType, csnet.TextPlainCharsetUTF8)
+		for i := 0; i < 200; i++ {
+			_, _ = w.Write([]byte("Hello Gophers! "))
+		}
+	})).ServeHTTP(rec, reqWithWebsite("br;q=1.0, *;q=0.5"))
+
+	assert.Exactly(t, csnet.CompressGZIP, rec.Header().Get(csnet.ContentEncoding))
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	have, err := ioutil.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Exactly(t, body, string(have))
+}
@@ -85,47 +85,69 @@ func WithDefaultConfig(h scope.TypeID) Option {
 // WithSettings applies the Settings struct to a specific scope. Internal
 // functions will optimize the internal structure of the Settings struct.
 func WithSettings(stng Settings, scopeIDs ...scope.TypeID) Option {
-	exposedHeaders := convert(stng.ExposedHeaders, http.CanonicalHeaderKey)
-	allowedOriginsAll, allowedOrigins, allowedWOrigins := convertAllowedOrigins(stng.AllowedOrigins...)
-	am := convert(stng.AllowedMethods, strings.ToUpper)
-
-	allowedHeadersAll, allowedHeaders := convertAllowedHeaders(stng.AllowedHeaders...)
-
 	return func(s *Service) error {
 		sc := s.findScopedConfig(scopeIDs...)
+		sc.Settings.apply(stng)
+		return s.updateScopedConfig(sc)
+	}
+}
 
-		sc.ExposedHeaders = exposedHeaders
-
-		// Note: for origins and methods matching, the spec requires a
-		// case-sensitive matching. As it may error prone, we chose to ignore
-		// the spec here.
-		sc.AllowedOriginsAll = allowedOriginsAll
-		if len(allowedOrigins) > 0 {
-			sc.AllowedOrigins = allowedOrigins
-		}
-		if len(allowedWOrigins) > 0 {
-			sc.allowedWOrigins = allowedWOrigins
-		}
+// WithRouteSettings applies the Settings to all requests whose URL path starts
+// with the route prefix. The route Settings override the Settings of the
+// scope, for example a public product API allows all origins while the admin
+// API stays restricted:
+//		cors.WithSettings(cors.Settings{AllowedOrigins: []string{"https://shop.io"}}, scope.DefaultTypeID),
+//		cors.WithRouteSettings("/api/catalog", cors.Settings{AllowedOrigins: []string{"*"}}, scope.DefaultTypeID),
+// A prefix matches whole path segments, so "/api/admin" matches
+// "/api/admin/orders" but not "/api/administrator". The longest matching
+// prefix wins. Empty fields of stng fall back to the default values and not to
+// the Settings of the scope. The routes get evaluated before the preflight or
+// the actual request gets processed.
+func WithRouteSettings(prefix string, stng Settings, scopeIDs ...scope.TypeID) Option {
+	return func(s *Service) error {
+		sc := s.findScopedConfig(scopeIDs...)
+		rs := newSettings()
+		rs.apply(stng)
+		// the tree might be shared with a parent scope, so never modify it.
+		sc.routes = sc.routes.clone()
+		sc.routes.insert(prefix, rs)
+		return s.updateScopedConfig(sc)
+	}
+}
 
-		sc.AllowOriginFunc = stng.AllowOriginFunc
+// apply normalizes and merges the fields of o into stng.
+func (stng *Settings) apply(o Settings) {
+	stng.ExposedHeaders = convert(o.ExposedHeaders, http.CanonicalHeaderKey)
+
+	// Note: for origins and methods matching, the spec requires a
+	// case-sensitive matching. As it may error prone, we chose to ignore
+	// the spec here.
+	allowedOriginsAll, allowedOrigins, allowedWOrigins := convertAllowedOrigins(o.AllowedOrigins...)
+	stng.AllowedOriginsAll = allowedOriginsAll
+	if len(allowedOrigins) > 0 {
+		stng.AllowedOrigins = allowedOrigins
+	}
+	if len(allowedWOrigins) > 0 {
+		stng.allowedWOrigins = allowedWOrigins
+	}
 
-		if len(am) > 0 {
-			sc.AllowedMethods = am
-		}
+	stng.AllowOriginFunc = o.AllowOriginFunc
 
-		sc.AllowedHeadersAll = allowedHeadersAll
-		if len(allowedHeaders) > 0 {
-			sc.AllowedHeaders = allowedHeaders
-		}
+	if am := convert(o.AllowedMethods, strings.ToUpper); len(am) > 0 {
+		stng.AllowedMethods = am
+	}
 
-		sc.AllowCredentials = stng.AllowCredentials
-		if stng.MaxAge != "" {
-			sc.MaxAge = stng.MaxAge
-		}
-		sc.OptionsPassthrough = stng.OptionsPassthrough
+	allowedHeadersAll, allowedHeaders := convertAllowedHeaders(o.AllowedHeaders...)
+	stng.AllowedHeadersAll = allowedHeadersAll
+	if len(allowedHeaders) > 0 {
+		stng.AllowedHeaders = allowedHeaders
+	}
 
-		return s.updateScopedConfig(sc)
+	stng.AllowCredentials = o.AllowCredentials
+	if o.MaxAge != "" {
+		stng.MaxAge = o.MaxAge
 	}
+	stng.OptionsPassthrough = o.OptionsPassthrough
 }
 
 func convertAllowedOrigins(domains ...string) (allowedOriginsAll bool, allowedOrigins []string, allowedWOrigins []wildcard) {
@@ -0,0 +1,97 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import "strings"
+
+// routeNode a node in the tree of URL path segments. Each node which has been
+// inserted via WithRouteSettings contains Settings.
+type routeNode struct {
+	// prefix normalized route prefix of this node, used for logging.
+	prefix   string
+	settings *Settings
+	children map[string]*routeNode
+}
+
+// clone creates a deep copy of the tree. The Settings are shared because they
+// never get modified after the insert. A nil node returns a new root.
+func (n *routeNode) clone() *routeNode {
+	if n == nil {
+		return &routeNode{prefix: "/"}
+	}
+	c := &routeNode{
+		prefix:   n.prefix,
+		settings: n.settings,
+	}
+	if len(n.children) > 0 {
+		c.children = make(map[string]*routeNode, len(n.children))
+		for seg, child := range n.children {
+			c.children[seg] = child.clone()
+		}
+	}
+	return c
+}
+
+// insert adds the Settings to the node of the prefix and overwrites previous
+// Settings of the same prefix.
+func (n *routeNode) insert(prefix string, stng Settings) {
+	cur := n
+	for _, seg := range strings.Split(strings.Trim(prefix, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		next, ok := cur.children[seg]
+		if !ok {
+			if cur.children == nil {
+				cur.children = make(map[string]*routeNode)
+			}
+			next = &routeNode{prefix: strings.TrimSuffix(cur.prefix, "/") + "/" + seg}
+			cur.children[seg] = next
+		}
+		cur = next
+	}
+	cur.settings = &stng
+}
+
+// match walks along the segments of the path and returns the deepest node
+// containing Settings. Returns nil if no route matches or the tree is nil.
+func (n *routeNode) match(path string) (string, *Settings) {
+	if n == nil {
+		return "", nil
+	}
+	var prefix string
+	var stng *Settings
+	cur := n
+	for {
+		if cur.settings != nil {
+			prefix, stng = cur.prefix, cur.settings
+		}
+		path = strings.TrimLeft(path, "/")
+		if path == "" || len(cur.children) == 0 {
+			return prefix, stng
+		}
+		seg := path
+		if i := strings.IndexByte(path, '/'); i >= 0 {
+			seg, path = path[:i], path[i:]
+		} else {
+			path = ""
+		}
+		next, ok := cur.children[seg]
+		if !ok {
+			return prefix, stng
+		}
+		cur = next
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteNode(t *testing.T) {
+	var root *routeNode
+	prefix, stng := root.match("/api/admin")
+	assert.Empty(t, prefix)
+	assert.Nil(t, stng)
+
+	root = root.clone()
+	root.insert("/api/catalog/", Settings{MaxAge: "catalog"})
+	root.insert("api/admin", Settings{MaxAge: "admin"})
+	root.insert("/api/admin/public", Settings{MaxAge: "admin public"})
+
+	shared := root.clone()
+	root.insert("/", Settings{MaxAge: "root"})
+
+	tests := []struct {
+		path       string
+		wantPrefix string
+		wantMaxAge string
+	}{
+		{"/api/catalog", "/api/catalog", "catalog"},
+		{"/api/catalog/", "/api/catalog", "catalog"},
+		{"/api/catalog/products/4711", "/api/catalog", "catalog"},
+		{"//api//catalog", "/api/catalog", "catalog"},
+		{"/api/catalogue", "/", "root"},
+		{"/api/admin/orders", "/api/admin", "admin"},
+		{"/api/admin/public/x", "/api/admin/public", "admin public"},
+		{"/api/administrator", "/", "root"},
+		{"/api", "/", "root"},
+		{"/", "/", "root"},
+		{"", "/", "root"},
+	}
+	for _, test := range tests {
+		prefix, stng := root.match(test.path)
+		assert.Exactly(t, test.wantPrefix, prefix, "Path %q", test.path)
+		if assert.NotNil(t, stng, "Path %q", test.path) {
+			assert.Exactly(t, test.wantMaxAge, stng.MaxAge, "Path %q", test.path)
+		}
+	}
+
+	// the clone has been taken before inserting the root route
+	prefix, stng = shared.match("/api/administrator")
+	assert.Empty(t, prefix)
+	assert.Nil(t, stng)
+	prefix, stng = shared.match("/api/admin/x")
+	assert.Exactly(t, "/api/admin", prefix)
+	assert.Exactly(t, "admin", stng.MaxAge)
+}
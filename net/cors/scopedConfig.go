@@ -52,6 +52,8 @@ type ScopedConfig struct {
 
 	// Settings general CORS settings
 	Settings
+	// routes optional Settings per URL path prefix. See WithRouteSettings.
+	routes *routeNode
 }
 
 // isValid a configuration for a scope is only then valid when
@@ -77,15 +79,31 @@ func newScopedConfig(target, parent scope.TypeID) *ScopedConfig {
 	return &ScopedConfig{
 		scopedConfigGeneric: newScopedConfigGeneric(target, parent),
 		log:                 log.BlackHole{}, // disabled info and debug logging
-		Settings: Settings{
-			// Default is spec's "simple" methods
-			AllowedMethods: []string{"GET", "POST"},
-			// Use sensible defaults
-			AllowedHeaders: []string{"Origin", "Accept", "Content-Type"},
-		},
+		Settings:            newSettings(),
 	}
 }
 
+// newSettings returns the default Settings.
+func newSettings() Settings {
+	return Settings{
+		// Default is spec's "simple" methods
+		AllowedMethods: []string{"GET", "POST"},
+		// Use sensible defaults
+		AllowedHeaders: []string{"Origin", "Accept", "Content-Type"},
+	}
+}
+
+// matchRoute returns a copy of the configuration with the Settings of the
+// longest route prefix matching the path. Returns the unchanged configuration
+// and an empty prefix if no route matches.
+func (sc ScopedConfig) matchRoute(path string) (ScopedConfig, string) {
+	prefix, stng := sc.routes.match(path)
+	if stng != nil {
+		sc.Settings = *stng
+	}
+	return sc, prefix
+}
+
 // handlePreflight handles pre-flight CORS requests
 func (sc *ScopedConfig) handlePreflight(w http.ResponseWriter, r *http.Request) {
 	sc.log = log.BlackHole{}
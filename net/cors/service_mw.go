@@ -37,6 +37,11 @@ func (s *Service) WithCORS(next http.Handler) http.Handler {
 			return
 		}
 
+		scpCfg, route := scpCfg.matchRoute(r.URL.Path)
+		if route != "" && s.Log.IsDebug() {
+			s.Log.Debug("cors.Service.WithCORS.matchRoute", log.String("route", route), log.String("path", r.URL.Path))
+		}
+
 		if s.Log.IsInfo() {
 			s.Log.Info("cors.Service.WithCORS.handleActualRequest", log.String("method", r.Method), log.Object("scopedConfig", scpCfg))
 		}
@@ -16,10 +16,14 @@ package cors_test
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/corestoreio/csfw/config/cfgmock"
 	"github.com/corestoreio/csfw/net/cors"
 	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestService_WithCORS_MWAdapter(t *testing.T) {
@@ -29,3 +33,106 @@ func TestService_WithCORS_MWAdapter(t *testing.T) {
 		// noop
 	}), srv.WithCORS)
 }
+
+func TestService_WithCORS_Routes(t *testing.T) {
+	srv := cors.MustNew(
+		cors.WithRootConfig(cfgmock.NewService()),
+		cors.WithSettings(cors.Settings{
+			AllowedOrigins: []string{"https://shop.io"},
+		}, scope.Website.Pack(2)),
+		cors.WithRouteSettings("/api/catalog", cors.Settings{
+			AllowedOrigins: []string{"*"},
+			MaxAge:         "600",
+		}, scope.Website.Pack(2)),
+		cors.WithRouteSettings("/api/admin", cors.Settings{
+			AllowedOrigins:   []string{"https://admin.shop.io"},
+			AllowedMethods:   []string{"GET", "DELETE"},
+			AllowCredentials: true,
+		}, scope.Website.Pack(2)),
+	)
+
+	var nextCalled int
+	hndlr := srv.WithCORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nextCalled++
+	}))
+
+	tests := []struct {
+		method, path, origin, reqMethod string
+		wantNext                        int
+		wantHeaders                     map[string]string
+	}{
+		// scope policy
+		{"GET", "/checkout", "https://shop.io", "", 1, map[string]string{
+			"Access-Control-Allow-Origin": "https://shop.io",
+		}},
+		{"GET", "/checkout", "https://evil.io", "", 1, map[string]string{
+			"Access-Control-Allow-Origin": "",
+		}},
+		// public route allows all origins
+		{"GET", "/api/catalog/products", "https://evil.io", "", 1, map[string]string{
+			"Access-Control-Allow-Origin": "https://evil.io",
+		}},
+		{"OPTIONS", "/api/catalog/products", "https://evil.io", "GET", 0, map[string]string{
+			"Access-Control-Allow-Origin":  "https://evil.io",
+			"Access-Control-Allow-Methods": "GET",
+			"Access-Control-Max-Age":       "600",
+		}},
+		// admin route restricts origins and enables DELETE
+		{"GET", "/api/admin/orders", "https://shop.io", "", 1, map[string]string{
+			"Access-Control-Allow-Origin": "",
+		}},
+		{"OPTIONS", "/api/admin/orders", "https://admin.shop.io", "DELETE", 0, map[string]string{
+			"Access-Control-Allow-Origin":      "https://admin.shop.io",
+			"Access-Control-Allow-Methods":     "DELETE",
+			"Access-Control-Allow-Credentials": "true",
+		}},
+		{"OPTIONS", "/api/admin/orders", "https://admin.shop.io", "PUT", 0, map[string]string{
+			"Access-Control-Allow-Origin":  "",
+			"Access-Control-Allow-Methods": "",
+		}},
+		// the scope policy does not allow DELETE
+		{"OPTIONS", "/api/administrator", "https://shop.io", "DELETE", 0, map[string]string{
+			"Access-Control-Allow-Origin":  "",
+			"Access-Control-Allow-Methods": "",
+		}},
+	}
+	for i, test := range tests {
+		nextCalled = 0
+		req := httptest.NewRequest(test.method, "http://corestore.io"+test.path, nil)
+		req = req.WithContext(scope.WithContext(req.Context(), 2, 5))
+		req.Header.Set("Origin", test.origin)
+		if test.reqMethod != "" {
+			req.Header.Set("Access-Control-Request-Method", test.reqMethod)
+		}
+		rec := httptest.NewRecorder()
+		hndlr.ServeHTTP(rec, req)
+
+		assert.Exactly(t, test.wantNext, nextCalled, "Index %d", i)
+		for k, v := range test.wantHeaders {
+			assert.Exactly(t, v, rec.Header().Get(k), "Index %d Header %q", i, k)
+		}
+	}
+}
+
+func TestWithRouteSettings_ParentScope(t *testing.T) {
+	srv := cors.MustNew(
+		cors.WithRootConfig(cfgmock.NewService()),
+		cors.WithRouteSettings("/api", cors.Settings{AllowedOrigins: []string{"https://default.io"}}, scope.DefaultTypeID),
+		cors.WithRouteSettings("/api/admin", cors.Settings{AllowedOrigins: []string{"https://admin.io"}}, scope.Website.Pack(2)),
+	)
+	hndlr := srv.WithCORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(websiteID int64, path, origin string) string {
+		req := httptest.NewRequest("GET", "http://corestore.io"+path, nil)
+		req = req.WithContext(scope.WithContext(req.Context(), websiteID, 5))
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		hndlr.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin")
+	}
+	assert.Exactly(t, "https://admin.io", serve(2, "/api/admin/x", "https://admin.io"))
+	assert.Exactly(t, "https://default.io", serve(2, "/api/x", "https://default.io"))
+	// the admin route of website 2 must not leak into the default scope
+	assert.Exactly(t, "", serve(1, "/api/admin/x", "https://admin.io"))
+	assert.Exactly(t, "https://default.io", serve(1, "/api/admin/x", "https://default.io"))
+}
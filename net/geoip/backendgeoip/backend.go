@@ -43,6 +43,13 @@ type Configuration struct {
 	// Path: net/geoip/alternative_redirect_code
 	AlternativeRedirectCode cfgmodel.Int
 
+	// StoreMapping maps countries, regions and cities to a suggested store
+	// code. Format: Country[/Region[/City]]=StoreCode separated via comma,
+	// e.g.: DE=de,DE/BY=de_by,AT=at
+	//
+	// Path: net/geoip/store_mapping
+	StoreMapping cfgmodel.Str
+
 	// DataSource defines to either load the Geo location data from a MaxMind
 	// "file" or from the MaxMind "webservice".
 	//
@@ -54,6 +61,23 @@ type Configuration struct {
 	// Path: net/geoip_maxmind/local_file
 	MaxmindLocalFile cfgmodel.Str
 
+	// MaxmindUpdateLicenseKey license key to download new databases into the
+	// local file.
+	//
+	// Path: net/geoip_maxmind/update_license_key
+	MaxmindUpdateLicenseKey cfgmodel.Str
+
+	// MaxmindUpdateEditionID the database edition to download, e.g.
+	// GeoLite2-Country or GeoIP2-Country.
+	//
+	// Path: net/geoip_maxmind/update_edition_id
+	MaxmindUpdateEditionID cfgmodel.Str
+
+	// MaxmindUpdateInterval duration between two checks for a new database.
+	//
+	// Path: net/geoip_maxmind/update_interval
+	MaxmindUpdateInterval cfgmodel.Duration
+
 	// MaxmindWebserviceUserID user id
 	//
 	// Path: net/geoip_maxmind/webservice_userid
@@ -90,12 +114,18 @@ func New(cfgStruct element.SectionSlice, opts ...cfgmodel.Option) *Configuration
 	be.AllowedCountries = cfgmodel.NewStringCSV(`net/geoip/allowed_countries`, opts...)
 	be.AlternativeRedirect = cfgmodel.NewURL(`net/geoip/alternative_redirect`, opts...)
 	be.AlternativeRedirectCode = cfgmodel.NewInt(`net/geoip/alternative_redirect_code`, optsRedir...)
+	be.StoreMapping = cfgmodel.NewStr(`net/geoip/store_mapping`, opts...)
 
 	be.DataSource = cfgmodel.NewStr(`net/geoip_maxmind/data_source`, append(opts, cfgmodel.WithSourceByString(
 		"file", "File on this server",
+		"file_update", "File on this server with automatic updates",
+		"file_city", "City database file on this server",
 		"webservice", "Maxmind web service",
 	))...)
 	be.MaxmindLocalFile = cfgmodel.NewStr(`net/geoip_maxmind/local_file`, opts...)
+	be.MaxmindUpdateLicenseKey = cfgmodel.NewStr(`net/geoip_maxmind/update_license_key`, opts...)
+	be.MaxmindUpdateEditionID = cfgmodel.NewStr(`net/geoip_maxmind/update_edition_id`, opts...)
+	be.MaxmindUpdateInterval = cfgmodel.NewDuration(`net/geoip_maxmind/update_interval`, opts...)
 	be.MaxmindWebserviceUserID = cfgmodel.NewStr(`net/geoip_maxmind/webservice_userid`, opts...)
 	be.MaxmindWebserviceLicense = cfgmodel.NewStr(`net/geoip_maxmind/webservice_license`, opts...)
 	be.MaxmindWebserviceTimeout = cfgmodel.NewDuration(`net/geoip_maxmind/webservice_timeout`, opts...)
@@ -52,6 +52,19 @@ func (be *Configuration) PrepareOptionFactory() geoip.OptionFactoryFunc {
 		}
 		i++
 
+		rawMapping, err := be.StoreMapping.Get(sg)
+		if err != nil {
+			return geoip.OptionsError(errors.Wrap(err, "[backendgeoip] NetGeoipStoreMapping.Get"))
+		}
+		if rawMapping != "" {
+			sms, err := geoip.ParseStoreMappings(rawMapping)
+			if err != nil {
+				return geoip.OptionsError(errors.Wrap(err, "[backendgeoip] NetGeoipStoreMapping.ParseStoreMappings"))
+			}
+			opts[i] = geoip.WithStoreMappings(sms, sg.ScopeIDs()...)
+		}
+		i++
+
 		source, err := be.DataSource.Get(sg)
 		if err != nil {
 			return geoip.OptionsError(errors.Wrap(err, "[backendgeoip] DataSource.Get"))
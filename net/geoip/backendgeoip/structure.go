@@ -72,6 +72,18 @@ e.g.: DE,CH,AT,AU,NZ`),
 							Scopes:    scope.PermStore,
 							Default:   301,
 						},
+						element.Field{
+							// Path: `net/geoip/store_mapping`,
+							ID:    cfgpath.NewRoute(`store_mapping`),
+							Label: text.Chars(`Store Mapping`),
+							Comment: text.Chars(`Maps a country, region or city to a suggested store code. Format
+Country[/Region[/City]]=StoreCode separated via comma, e.g.: DE=de,DE/BY=de_by,AT=at.
+Regions and cities require the data source "file_city".`),
+							Type:      element.TypeTextarea,
+							SortOrder: 50,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermStore,
+						},
 					),
 				},
 
@@ -110,6 +122,41 @@ from an IP address.`),
 							Scopes:    scope.PermDefault,
 						},
 
+						element.Field{
+							// Path: `net/geoip_maxmind/update_license_key`,
+							ID:    cfgpath.NewRoute(`update_license_key`),
+							Label: text.Chars(`Update License Key`),
+							Comment: text.Chars(`License key of your MaxMind account to download new databases into the local
+file. Only used when the data source "file_update" has been chosen.`),
+							Type:      element.TypeText,
+							SortOrder: 15,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermDefault,
+						},
+						element.Field{
+							// Path: `net/geoip_maxmind/update_edition_id`,
+							ID:    cfgpath.NewRoute(`update_edition_id`),
+							Label: text.Chars(`Update Database Edition`),
+							Comment: text.Chars(`Edition ID of the database to download, e.g. GeoLite2-Country or
+GeoIP2-Country.`),
+							Type:      element.TypeText,
+							SortOrder: 16,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermDefault,
+							Default:   `GeoLite2-Country`,
+						},
+						element.Field{
+							// Path: `net/geoip_maxmind/update_interval`,
+							ID:        cfgpath.NewRoute(`update_interval`),
+							Label:     text.Chars(`Update Interval`),
+							Comment:   text.Chars(`Duration between two checks for a new database, e.g. "24h".`),
+							Type:      element.TypeText,
+							SortOrder: 17,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermDefault,
+							Default:   time.Hour * 24,
+						},
+
 						element.Field{
 							// Path: `net/geoip_maxmind/webservice_userid`,
 							ID:    cfgpath.NewRoute(`webservice_userid`),
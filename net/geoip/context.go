@@ -43,3 +43,22 @@ func FromContextCountry(ctx context.Context) (*Country, bool) {
 	}
 	return wrp.Country, ok
 }
+
+// keyctxStoreSuggestion type is unexported to prevent collisions with context
+// keys defined in other packages.
+type keyctxStoreSuggestion struct{}
+
+// withContextStoreSuggestion creates a new context with the suggested store
+// code attached.
+func withContextStoreSuggestion(ctx context.Context, storeCode string) context.Context {
+	return context.WithValue(ctx, keyctxStoreSuggestion{}, storeCode)
+}
+
+// FromContextStoreSuggestion returns the store code suggested by the
+// StoreMappings for the country, region or city of the request. Redirect logic
+// can use the code to offer or force a switch to the store view. The second
+// return value is false if no mapping matched or the middleware has not run.
+func FromContextStoreSuggestion(ctx context.Context) (string, bool) {
+	code, ok := ctx.Value(keyctxStoreSuggestion{}).(string)
+	return code, ok
+}
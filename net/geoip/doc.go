@@ -18,4 +18,9 @@
 // Uses the MaxMind database, or MaxMind WebService or alternative country/city detectors.
 //
 // The detected country and all its attributes can be added to a context.
+//
+// StoreMappings map a country, region or city to a suggested store code. The
+// middleware WithStoreSuggestionByIP puts the code into the context for your
+// redirect logic. Regions and cities require a City database, see
+// maxmindfile.WithCityFinder.
 package geoip
@@ -18,4 +18,5 @@ const (
 	errCannotGetRemoteAddr  = `[geoip] Cannot get request.RemoteAddr`
 	errScopedConfigNotValid = `[geoip] ScopedConfig %s is invalid. IsNil(IsAllowedFunc=%t), IsNil(alternativeHandler=%t)`
 	errUnAuthorizedCountry  = `[geoip] Country %q not found in the list of allowed countries: %v`
+	errStoreMappingNotValid = `[geoip] Store mapping %q is invalid. Expecting format Country[/Region[/City]]=StoreCode`
 )
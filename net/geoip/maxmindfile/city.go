@@ -0,0 +1,82 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maxmindfile
+
+import (
+	"net"
+
+	"github.com/corestoreio/csfw/net/geoip"
+	"github.com/corestoreio/errors"
+)
+
+// mmdbCity reads from a GeoIP2/GeoLite2 City database and resolves
+// additionally the subdivisions, the city, the postal code and the location.
+type mmdbCity struct {
+	*mmdb
+}
+
+func (mm mmdbCity) FindCountry(ipAddress net.IP) (*geoip.Country, error) {
+	c, err := mm.r.City(ipAddress)
+	if err != nil {
+		return nil, errors.NewNotValid(err, "[geoip] mmdb.City")
+	}
+	c2 := &geoip.Country{
+		IP: ipAddress,
+	}
+	c2.City.GeoNameID = c.City.GeoNameID
+	c2.City.Names = c.City.Names
+
+	c2.Continent.Code = c.Continent.Code
+	c2.Continent.GeoNameID = c.Continent.GeoNameID
+	c2.Continent.Names = c.Continent.Names
+
+	c2.Country.GeoNameID = c.Country.GeoNameID
+	c2.Country.IsoCode = c.Country.IsoCode
+	c2.Country.Names = c.Country.Names
+
+	c2.Location.AccuracyRadius = int(c.Location.AccuracyRadius)
+	c2.Location.Latitude = c.Location.Latitude
+	c2.Location.Longitude = c.Location.Longitude
+	c2.Location.MetroCode = int(c.Location.MetroCode)
+	c2.Location.TimeZone = c.Location.TimeZone
+
+	c2.Postal.Code = c.Postal.Code
+
+	c2.Subdivision = make([]struct {
+		Confidence int               `json:"confidence,omitempty"`
+		GeoNameID  uint              `json:"geoname_id,omitempty"`
+		IsoCode    string            `json:"iso_code,omitempty"`
+		Names      map[string]string `json:"names,omitempty"`
+	}, len(c.Subdivisions))
+	for i, sd := range c.Subdivisions {
+		c2.Subdivision[i].GeoNameID = sd.GeoNameID
+		c2.Subdivision[i].IsoCode = sd.IsoCode
+		c2.Subdivision[i].Names = sd.Names
+	}
+
+	c2.RegisteredCountry.GeoNameID = c.RegisteredCountry.GeoNameID
+	c2.RegisteredCountry.IsoCode = c.RegisteredCountry.IsoCode
+	c2.RegisteredCountry.Names = c.RegisteredCountry.Names
+
+	c2.RepresentedCountry.GeoNameID = c.RepresentedCountry.GeoNameID
+	c2.RepresentedCountry.IsoCode = c.RepresentedCountry.IsoCode
+	c2.RepresentedCountry.Names = c.RepresentedCountry.Names
+	c2.RepresentedCountry.Type = c.RepresentedCountry.Type
+
+	c2.Traits.IsAnonymousProxy = c.Traits.IsAnonymousProxy
+	c2.Traits.IsSatelliteProvider = c.Traits.IsSatelliteProvider
+
+	return c2, nil
+}
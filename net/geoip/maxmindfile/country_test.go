@@ -70,3 +70,25 @@ func TestMmdb_Country(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Exactly(t, "FI", c.Country.IsoCode)
 }
+
+func TestMmdbCity_CountryDatabase(t *testing.T) {
+	r, err := newMMDBByFile(filepath.Join("../", "testdata", "GeoIP2-Country-Test.mmdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	var f geoip.Finder = mmdbCity{mmdb: r}
+	c, err := f.FindCountry(net.ParseIP("81.2.69.160"))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	// A Country database does not contain region and city data.
+	assert.Exactly(t, "GB", c.Country.IsoCode)
+	assert.Exactly(t, "", c.RegionCode())
+	assert.Exactly(t, "", c.CityName("en"))
+}
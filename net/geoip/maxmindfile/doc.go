@@ -15,5 +15,9 @@
 // Package maxmindfile provides an OptionFactoryFunc for the backendgeopip
 // package.
 //
+// The Updater downloads new MaxMind databases with your license key in the
+// background, verifies the SHA256 checksum and swaps the opened database
+// without blocking lookups longer than the swap itself.
+//
 // https://www.maxmind.com/en/geoip2-services-and-databases
 package maxmindfile
@@ -16,6 +16,7 @@ package maxmindfile
 
 import (
 	"os"
+	"sync"
 
 	"github.com/corestoreio/csfw/config"
 	"github.com/corestoreio/csfw/config/cfgmodel"
@@ -38,6 +39,24 @@ func WithCountryFinder(filename string) geoip.Option {
 	}
 }
 
+// WithCityFinder creates a new GeoIP2.Reader which reads the geo information
+// from a GeoIP2/GeoLite2 City database file stored on the server. Compared to
+// WithCountryFinder the subdivisions, the city, the postal code and the
+// location get resolved too, which allows region and city based
+// geoip.StoreMappings.
+func WithCityFinder(filename string) geoip.Option {
+	return func(s *geoip.Service) error {
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			return errors.NewNotFoundf("[maxmindfile] File %q not found", filename)
+		}
+		cr, err := newMMDBByFile(filename)
+		if err != nil {
+			return errors.NewNotValidf("[maxmindfile] Maxmind Open %s with file %q", err, filename)
+		}
+		return geoip.WithCountryFinder(mmdbCity{mmdb: cr})(s)
+	}
+}
+
 // OptionName identifies this package within the register of the
 // backendgeoip.Configuration type.
 const OptionName = `file`
@@ -60,3 +79,84 @@ func NewOptionFactory(maxmindLocalFile cfgmodel.Str) (optionName string, _ geoip
 		return geoip.OptionsError(errors.NewEmptyf("[backendgeoip] Geo source as file specified but path to file name not provided"))
 	}
 }
+
+// OptionNameCity identifies the City database file within the register of the
+// backendgeoip.Configuration type.
+const OptionNameCity = `file_city`
+
+// NewOptionFactoryCity specifies the City database file on the server to
+// retrieve geo information including the region and the city. This function
+// will be triggered when you choose in backendgeoip.Configuration.DataSource
+// the value `file_city`.
+func NewOptionFactoryCity(maxmindLocalFile cfgmodel.Str) (optionName string, _ geoip.OptionFactoryFunc) {
+	return OptionNameCity, func(sg config.Scoped) []geoip.Option {
+		mmlf, err := maxmindLocalFile.Get(sg)
+		if err != nil {
+			return geoip.OptionsError(errors.Wrap(err, "[maxmindfile] NetGeoipMaxmindLocalFile.Get"))
+		}
+		if mmlf != "" {
+			return []geoip.Option{
+				WithCityFinder(mmlf),
+			}
+		}
+		return geoip.OptionsError(errors.NewEmptyf("[maxmindfile] Geo source as city file specified but path to file name not provided"))
+	}
+}
+
+// OptionNameUpdater identifies the automatically updated database file
+// within the register of the backendgeoip.Configuration type.
+const OptionNameUpdater = `file_update`
+
+// NewOptionFactoryUpdater creates an Updater for the local file, opens an
+// existing database and starts the periodic download. The license key, the
+// edition and the interval are read from the configuration. One Updater gets
+// created per file name and shared across all scopes. This function will be
+// triggered when you choose in backendgeoip.Configuration.DataSource the value
+// `file_update`.
+func NewOptionFactoryUpdater(maxmindLocalFile, licenseKey, editionID cfgmodel.Str, interval cfgmodel.Duration) (optionName string, _ geoip.OptionFactoryFunc) {
+	var mu sync.Mutex
+	updaters := make(map[string]*Updater)
+
+	return OptionNameUpdater, func(sg config.Scoped) []geoip.Option {
+		mmlf, err := maxmindLocalFile.Get(sg)
+		if err != nil {
+			return geoip.OptionsError(errors.Wrap(err, "[maxmindfile] NetGeoipMaxmindLocalFile.Get"))
+		}
+		if mmlf == "" {
+			return geoip.OptionsError(errors.NewEmptyf("[maxmindfile] Geo source as updated file specified but path to file name not provided"))
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if u, ok := updaters[mmlf]; ok {
+			return []geoip.Option{geoip.WithCountryFinder(u)}
+		}
+
+		vLicense, err := licenseKey.Get(sg)
+		if err != nil {
+			return geoip.OptionsError(errors.Wrap(err, "[maxmindfile] NetGeoipMaxmindUpdateLicenseKey.Get"))
+		}
+		vEdition, err := editionID.Get(sg)
+		if err != nil {
+			return geoip.OptionsError(errors.Wrap(err, "[maxmindfile] NetGeoipMaxmindUpdateEditionID.Get"))
+		}
+		vInterval, err := interval.Get(sg)
+		if err != nil {
+			return geoip.OptionsError(errors.Wrap(err, "[maxmindfile] NetGeoipMaxmindUpdateInterval.Get"))
+		}
+
+		u := NewUpdater(vLicense, mmlf)
+		if vEdition != "" {
+			u.EditionID = vEdition
+		}
+		if vInterval > 0 {
+			u.Interval = vInterval
+		}
+		if err := u.Open(); err != nil {
+			return geoip.OptionsError(errors.Wrap(err, "[maxmindfile] Updater.Open"))
+		}
+		u.Start()
+		updaters[mmlf] = u
+		return []geoip.Option{geoip.WithCountryFinder(u)}
+	}
+}
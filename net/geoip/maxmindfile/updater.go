@@ -0,0 +1,408 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maxmindfile
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/csfw/net/geoip"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+)
+
+// DefaultDownloadURL points to the MaxMind download service which requires a
+// license key.
+const DefaultDownloadURL = `https://download.maxmind.com/app/geoip_download`
+
+// DefaultEditionID identifies the free country database.
+const DefaultEditionID = `GeoLite2-Country`
+
+// DefaultUpdateInterval MaxMind publishes new databases once per week, so
+// checking daily for a new checksum is sufficient.
+const DefaultUpdateInterval = 24 * time.Hour
+
+// UpdaterStatus reports the state of the currently used database and the
+// last update run.
+type UpdaterStatus struct {
+	// BuildDate of the currently opened database. Zero if no database has
+	// been loaded.
+	BuildDate time.Time
+	// Checksum SHA256 of the downloaded archive of the current database.
+	Checksum string
+	// LastCheck time of the last update run, even if it failed.
+	LastCheck time.Time
+	// LastUpdate time when the database has been swapped.
+	LastUpdate time.Time
+	// LastError contains the error of the last update run, if any.
+	LastError error
+}
+
+// Updater downloads the MaxMind database in the background, verifies the
+// SHA256 checksum of the archive and swaps the opened reader without
+// interrupting running lookups. Updater implements the geoip.Finder interface
+// and can be passed to geoip.WithCountryFinder.
+//
+// The exported fields must be set before calling Open or Start.
+type Updater struct {
+	// LicenseKey of your MaxMind account.
+	LicenseKey string
+	// EditionID of the database, defaults to DefaultEditionID.
+	EditionID string
+	// DownloadURL defaults to DefaultDownloadURL. The query parameters
+	// edition_id, license_key and suffix get appended.
+	DownloadURL string
+	// Filename local path where the extracted mmdb file gets stored. The
+	// directory must be writable because a temporary file gets created and
+	// renamed.
+	Filename string
+	// Interval between two update runs. Defaults to DefaultUpdateInterval.
+	Interval time.Duration
+	// Client used for downloading. Defaults to a client with a 5 minute
+	// timeout.
+	Client *http.Client
+	// Log defaults to log.BlackHole.
+	Log log.Logger
+
+	mu     sync.RWMutex
+	db     *mmdb
+	status UpdaterStatus
+
+	// updateMu prevents parallel runs of Update.
+	updateMu  sync.Mutex
+	stop      chan struct{}
+	done      chan struct{}
+	started   bool // guarded by startOnce
+	startOnce sync.Once
+	stopOnce  sync.Once
+}
+
+// NewUpdater creates a new Updater with default values. Call Open to load an
+// already downloaded database and Start to begin periodic updates.
+func NewUpdater(licenseKey, filename string) *Updater {
+	return &Updater{
+		LicenseKey:  licenseKey,
+		EditionID:   DefaultEditionID,
+		DownloadURL: DefaultDownloadURL,
+		Filename:    filename,
+		Interval:    DefaultUpdateInterval,
+		Client:      &http.Client{Timeout: 5 * time.Minute},
+		Log:         log.BlackHole{},
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Open loads the database from Filename, if it exists. A missing file is not
+// an error because the first Update run downloads it.
+func (u *Updater) Open() error {
+	if _, err := os.Stat(u.Filename); os.IsNotExist(err) {
+		return nil
+	}
+	db, err := newMMDBByFile(u.Filename)
+	if err != nil {
+		return errors.Wrapf(err, "[maxmindfile] Updater.Open %q", u.Filename)
+	}
+	var checksum string
+	if cs, err := ioutil.ReadFile(u.checksumFile()); err == nil {
+		checksum = strings.TrimSpace(string(cs))
+	}
+	u.swap(db, checksum, time.Time{})
+	return nil
+}
+
+// Start runs Update immediately and afterwards in the configured Interval
+// until Close gets called. Errors are reported via Status and the logger.
+// Calling Start more than once has no effect.
+func (u *Updater) Start() {
+	u.startOnce.Do(func() {
+		u.started = true
+		go u.run()
+	})
+}
+
+func (u *Updater) run() {
+	defer close(u.done)
+	interval := u.Interval
+	if interval <= 0 {
+		interval = DefaultUpdateInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-u.stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		if _, err := u.Update(ctx); err != nil && u.Log.IsInfo() {
+			u.Log.Info("maxmindfile.Updater.run.Update", log.Err(err), log.String("filename", u.Filename))
+		}
+		cancel()
+
+		select {
+		case <-u.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update checks the remote checksum and downloads the database if the
+// checksum differs from the current one. It returns true if a new database
+// has been swapped in.
+func (u *Updater) Update(ctx context.Context) (updated bool, err error) {
+	u.updateMu.Lock()
+	defer u.updateMu.Unlock()
+
+	checksum, db, err := u.update(ctx)
+
+	u.mu.Lock()
+	u.status.LastCheck = time.Now()
+	u.status.LastError = err
+	u.mu.Unlock()
+
+	if err != nil || db == nil {
+		return false, err
+	}
+	u.swap(db, checksum, time.Now())
+	if u.Log.IsDebug() {
+		u.Log.Debug("maxmindfile.Updater.Update.swapped", log.String("filename", u.Filename), log.String("checksum", checksum))
+	}
+	return true, nil
+}
+
+// update returns a nil database if the checksum has not changed.
+func (u *Updater) update(ctx context.Context) (string, *mmdb, error) {
+	body, err := u.download(ctx, "tar.gz.sha256")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "[maxmindfile] Updater.download checksum")
+	}
+	raw, err := ioutil.ReadAll(io.LimitReader(body, 1024))
+	_ = body.Close()
+	if err != nil {
+		return "", nil, errors.Wrap(err, "[maxmindfile] Updater.ReadAll checksum")
+	}
+	fields := strings.Fields(string(raw))
+	if len(fields) == 0 {
+		return "", nil, errors.NewNotValidf("[maxmindfile] Updater: Empty checksum received")
+	}
+	checksum := strings.ToLower(fields[0])
+
+	if checksum == u.Status().Checksum {
+		return "", nil, nil
+	}
+
+	body, err = u.download(ctx, "tar.gz")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "[maxmindfile] Updater.download archive")
+	}
+	defer body.Close()
+
+	tmpFile, err := u.extract(body, checksum)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "[maxmindfile] Updater.extract")
+	}
+	db, err := newMMDBByFile(tmpFile)
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return "", nil, errors.Wrap(err, "[maxmindfile] Updater.Open")
+	}
+	// the opened file stays valid after the rename
+	if err := os.Rename(tmpFile, u.Filename); err != nil {
+		_ = db.Close()
+		_ = os.Remove(tmpFile)
+		return "", nil, errors.Wrapf(err, "[maxmindfile] Updater.Rename %q", u.Filename)
+	}
+	if err := ioutil.WriteFile(u.checksumFile(), []byte(checksum+"\n"), 0644); err != nil && u.Log.IsInfo() {
+		u.Log.Info("maxmindfile.Updater.update.WriteFile", log.Err(err), log.String("filename", u.checksumFile()))
+	}
+	return checksum, db, nil
+}
+
+func (u *Updater) download(ctx context.Context, suffix string) (io.ReadCloser, error) {
+	if u.LicenseKey == "" {
+		return nil, errors.NewEmptyf("[maxmindfile] Updater: License key missing")
+	}
+	edition := u.EditionID
+	if edition == "" {
+		edition = DefaultEditionID
+	}
+	dlURL := u.DownloadURL
+	if dlURL == "" {
+		dlURL = DefaultDownloadURL
+	}
+	v := url.Values{}
+	v.Set("edition_id", edition)
+	v.Set("license_key", u.LicenseKey)
+	v.Set("suffix", suffix)
+
+	req, err := http.NewRequest("GET", dlURL+"?"+v.Encode(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "[maxmindfile] Updater.NewRequest")
+	}
+	hc := u.Client
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		// do not leak the license key via the URL in the error message.
+		return nil, errors.NewFatalf("[maxmindfile] Updater: Request for edition %q failed", edition)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		_ = resp.Body.Close()
+		return nil, errors.NewUnauthorizedf("[maxmindfile] Updater: License key rejected for edition %q", edition)
+	}
+	_ = resp.Body.Close()
+	return nil, errors.NewNotValidf("[maxmindfile] Updater: Unexpected status code %d for edition %q", resp.StatusCode, edition)
+}
+
+// extract writes the first mmdb file of the tar.gz archive into a temporary
+// file and verifies the checksum of the whole archive.
+func (u *Updater) extract(r io.Reader, checksum string) (tmpName string, err error) {
+	h := sha256.New()
+	tr := io.TeeReader(r, h)
+
+	gzr, err := gzip.NewReader(tr)
+	if err != nil {
+		return "", errors.NewNotValid(err, "[maxmindfile] gzip.NewReader")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(u.Filename), ".geoip-")
+	if err != nil {
+		return "", errors.Wrap(err, "[maxmindfile] ioutil.TempFile")
+	}
+	defer func() {
+		if cErr := tmp.Close(); err == nil && cErr != nil {
+			err = errors.Wrap(cErr, "[maxmindfile] TempFile.Close")
+		}
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+			tmpName = ""
+		}
+	}()
+
+	found := false
+	tarR := tar.NewReader(gzr)
+	for {
+		hdr, err := tarR.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", errors.NewNotValid(err, "[maxmindfile] tar.Next")
+		}
+		if found || !strings.HasSuffix(hdr.Name, ".mmdb") {
+			continue
+		}
+		if _, err := io.Copy(tmp, tarR); err != nil {
+			return "", errors.Wrap(err, "[maxmindfile] io.Copy mmdb")
+		}
+		found = true
+	}
+	// consume the gzip trailer and any padding to hash the complete archive.
+	if _, err := io.Copy(ioutil.Discard, tr); err != nil {
+		return "", errors.Wrap(err, "[maxmindfile] io.Copy remaining")
+	}
+	if have := hex.EncodeToString(h.Sum(nil)); have != checksum {
+		return "", errors.NewNotValidf("[maxmindfile] Checksum mismatch. Have %q Want %q", have, checksum)
+	}
+	if !found {
+		return "", errors.NewNotFoundf("[maxmindfile] No mmdb file found in archive")
+	}
+	return tmp.Name(), nil
+}
+
+func (u *Updater) checksumFile() string {
+	return u.Filename + ".sha256"
+}
+
+func (u *Updater) swap(db *mmdb, checksum string, updated time.Time) {
+	u.mu.Lock()
+	old := u.db
+	u.db = db
+	u.status.Checksum = checksum
+	u.status.BuildDate = time.Unix(int64(db.r.Metadata().BuildEpoch), 0).UTC()
+	if !updated.IsZero() {
+		u.status.LastUpdate = updated
+	}
+	u.mu.Unlock()
+
+	if old != nil {
+		if err := old.Close(); err != nil && u.Log.IsInfo() {
+			u.Log.Info("maxmindfile.Updater.swap.Close", log.Err(err))
+		}
+	}
+}
+
+// Status returns the current state of the Updater.
+func (u *Updater) Status() UpdaterStatus {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.status
+}
+
+// FindCountry implements the geoip.Finder interface. Returns a NotFound
+// error if no database has been loaded yet.
+func (u *Updater) FindCountry(ip net.IP) (*geoip.Country, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if u.db == nil {
+		return nil, errors.NewNotFoundf("[maxmindfile] Updater: Database %q not yet loaded", u.Filename)
+	}
+	return u.db.FindCountry(ip)
+}
+
+// Close stops the background updates and closes the database.
+func (u *Updater) Close() error {
+	u.stopOnce.Do(func() {
+		close(u.stop)
+	})
+	u.startOnce.Do(func() {}) // prevents a later Start
+	if u.started {
+		<-u.done
+	}
+
+	u.updateMu.Lock() // waits for a manually triggered update
+	defer u.updateMu.Unlock()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.db == nil {
+		return nil
+	}
+	err := u.db.Close()
+	u.db = nil
+	return errors.Wrap(err, "[maxmindfile] Updater.Close")
+}
@@ -0,0 +1,180 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maxmindfile
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/net/geoip"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ geoip.Finder = (*Updater)(nil)
+
+func newTestArchive(t *testing.T) (archive []byte, checksum string) {
+	mmdbData, err := ioutil.ReadFile(filepath.Join("../", "testdata", "GeoIP2-Country-Test.mmdb"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "GeoLite2-Country_20170103/LICENSE.txt", Mode: 0644, Size: 3}))
+	_, err = tw.Write([]byte("MIT"))
+	require.NoError(t, err)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "GeoLite2-Country_20170103/GeoLite2-Country.mmdb", Mode: 0644, Size: int64(len(mmdbData))}))
+	_, err = tw.Write(mmdbData)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+
+	h := sha256.Sum256(buf.Bytes())
+	return buf.Bytes(), hex.EncodeToString(h[:])
+}
+
+type testMaxMindServer struct {
+	archive   []byte
+	checksum  string
+	downloads int32
+}
+
+func (ts *testMaxMindServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("license_key") != "l1c3ns3" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch r.URL.Query().Get("suffix") {
+	case "tar.gz.sha256":
+		_, _ = w.Write([]byte(ts.checksum + "  GeoLite2-Country_20170103.tar.gz\n"))
+	case "tar.gz":
+		atomic.AddInt32(&ts.downloads, 1)
+		_, _ = w.Write(ts.archive)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestUpdater(t *testing.T, licenseKey string) (*Updater, *testMaxMindServer, func()) {
+	dir, err := ioutil.TempDir("", "geoip_updater")
+	require.NoError(t, err)
+
+	mms := &testMaxMindServer{}
+	mms.archive, mms.checksum = newTestArchive(t)
+	srv := httptest.NewServer(mms)
+
+	u := NewUpdater(licenseKey, filepath.Join(dir, "country.mmdb"))
+	u.DownloadURL = srv.URL
+	return u, mms, func() {
+		assert.NoError(t, u.Close())
+		srv.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestUpdater_Update(t *testing.T) {
+	u, mms, closer := newTestUpdater(t, "l1c3ns3")
+	defer closer()
+
+	require.NoError(t, u.Open()) // file does not yet exist
+	_, err := u.FindCountry(net.ParseIP("2a02:d200::"))
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+
+	updated, err := u.Update(context.Background())
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	c, err := u.FindCountry(net.ParseIP("2a02:d200::"))
+	require.NoError(t, err)
+	assert.Exactly(t, "FI", c.Country.IsoCode)
+
+	st := u.Status()
+	assert.NoError(t, st.LastError)
+	assert.Exactly(t, mms.checksum, st.Checksum)
+	assert.False(t, st.BuildDate.IsZero())
+	assert.False(t, st.LastUpdate.IsZero())
+
+	// same checksum, no download
+	updated, err = u.Update(context.Background())
+	require.NoError(t, err)
+	assert.False(t, updated)
+	assert.Exactly(t, int32(1), atomic.LoadInt32(&mms.downloads))
+
+	// a new Updater picks up the file and the checksum from disk
+	u2 := NewUpdater("l1c3ns3", u.Filename)
+	u2.DownloadURL = u.DownloadURL
+	require.NoError(t, u2.Open())
+	assert.Exactly(t, mms.checksum, u2.Status().Checksum)
+	updated, err = u2.Update(context.Background())
+	require.NoError(t, err)
+	assert.False(t, updated)
+	assert.NoError(t, u2.Close())
+}
+
+func TestUpdater_ChecksumMismatch(t *testing.T) {
+	u, mms, closer := newTestUpdater(t, "l1c3ns3")
+	defer closer()
+	mms.checksum = "f00ba4"
+
+	updated, err := u.Update(context.Background())
+	assert.False(t, updated)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	assert.True(t, errors.IsNotValid(u.Status().LastError))
+
+	_, err = os.Stat(u.Filename)
+	assert.True(t, os.IsNotExist(err), "%+v", err)
+	files, err := ioutil.ReadDir(filepath.Dir(u.Filename))
+	require.NoError(t, err)
+	assert.Len(t, files, 0, "temporary file must be removed")
+}
+
+func TestUpdater_Unauthorized(t *testing.T) {
+	u, _, closer := newTestUpdater(t, "wrong")
+	defer closer()
+
+	_, err := u.Update(context.Background())
+	assert.True(t, errors.IsUnauthorized(err), "%+v", err)
+	assert.NotContains(t, err.Error(), "wrong")
+}
+
+func TestUpdater_Start(t *testing.T) {
+	u, mms, closer := newTestUpdater(t, "l1c3ns3")
+	defer closer()
+
+	u.Start()
+	u.Start()
+	for i := 0; i < 100 && u.Status().Checksum == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, u.Close())
+	assert.Exactly(t, int32(1), atomic.LoadInt32(&mms.downloads))
+	assert.Exactly(t, mms.checksum, u.Status().Checksum)
+	_, err := u.FindCountry(net.ParseIP("2a02:d200::"))
+	assert.True(t, errors.IsNotFound(err), "database must be closed: %+v", err)
+}
@@ -82,6 +82,19 @@ func WithAllowedCountryCodes(isoCountryCodes []string, scopeIDs ...scope.TypeID)
 	}
 }
 
+// WithStoreMappings sets a list of country, region or city to store code
+// mappings. The middlewares WithIsCountryAllowedByIP and
+// WithStoreSuggestionByIP put the suggested store code into the context. The
+// Finder must resolve the subdivisions and the city to match regions and
+// cities, for example maxmindfile.WithCityFinder.
+func WithStoreMappings(sms StoreMappings, scopeIDs ...scope.TypeID) Option {
+	return func(s *Service) error {
+		sc := s.findScopedConfig(scopeIDs...)
+		sc.StoreMappings = sms
+		return s.updateScopedConfig(sc)
+	}
+}
+
 // WithCountryFinder applies a custom CountryRetriever. Sets the retriever atomically
 // and only once.
 func WithCountryFinder(cr Finder) Option {
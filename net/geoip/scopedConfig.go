@@ -15,6 +15,8 @@
 package geoip
 
 import (
+	"context"
+
 	"github.com/corestoreio/csfw/net/mw"
 	"github.com/corestoreio/csfw/store/scope"
 	"github.com/corestoreio/errors"
@@ -35,6 +37,9 @@ type ScopedConfig struct {
 	IsAllowedFunc // func(s scope.Hash, c *Country, allowedCountries []string) error
 	// AlternativeHandler if ip/country is denied we call this handler.
 	AlternativeHandler mw.ErrorHandler
+	// StoreMappings maps the resolved country, region or city to a suggested
+	// store code. Empty mappings disable the suggestion.
+	StoreMappings StoreMappings
 }
 
 func newScopedConfig(target, parent scope.TypeID) *ScopedConfig {
@@ -79,3 +84,13 @@ func (sc *ScopedConfig) IsAllowed(c *Country) error {
 	}
 	return sc.IsAllowedFunc(sc.ScopeID, c, sc.AllowedCountries)
 }
+
+// withStoreSuggestion adds the store code suggested by the StoreMappings for
+// the country to the context. Returns the unchanged context if no mapping
+// matches.
+func (sc *ScopedConfig) withStoreSuggestion(ctx context.Context, c *Country) context.Context {
+	if code, ok := sc.StoreMappings.Suggest(c); ok {
+		return withContextStoreSuggestion(ctx, code)
+	}
+	return ctx
+}
@@ -285,3 +285,44 @@ func TestService_WithIsCountryAllowedByIP_MultiScopes(t *testing.T) {
 	assert.Exactly(t, int32(240), atomic.LoadInt32(&calledErrorHandler), "calledErrorHandler")
 	// println("\n\n", logBuf.String(), "\n\n")
 }
+
+func TestService_WithStoreSuggestionByIP(t *testing.T) {
+	s, closeFn := mustGetTestService()
+	defer closeFn()
+
+	sms, err := geoip.ParseStoreMappings("FI=fi,DE=de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Options(
+		geoip.WithStoreMappings(sms, scope.Store.Pack(2)),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		storeID  int64
+		ip       string
+		wantCode string
+		wantOK   bool
+	}{
+		{2, "2a02:d200::", "fi", true},
+		{2, "81.2.69.160", "", false}, // GB has no mapping
+		{1, "2a02:d200::", "", false}, // store 1 has no mappings
+	}
+	for i, test := range tests {
+		var called bool
+		hndlr := s.WithStoreSuggestionByIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			code, ok := geoip.FromContextStoreSuggestion(r.Context())
+			assert.Exactly(t, test.wantCode, code, "Index %d", i)
+			assert.Exactly(t, test.wantOK, ok, "Index %d", i)
+			called = true
+		}))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "http://corestore.io", nil)
+		req.Header.Set("X-Forwarded-For", test.ip)
+		hndlr.ServeHTTP(rec, req.WithContext(scope.WithContext(req.Context(), 1, test.storeID)))
+		assert.Exactly(t, http.StatusOK, rec.Code, "Index %d", i)
+		assert.True(t, called, "Index %d", i)
+	}
+}
@@ -25,10 +25,12 @@ import (
 )
 
 // CountryByIP searches a country by an IP address and returns the found
-// country. It only needs the functional options WithGeoIP*().
+// country. It only needs the functional options WithGeoIP*(). The IP address
+// resolved by the request.ClientIP middleware takes precedence over the
+// forwarded headers.
 func (s *Service) CountryByIP(r *http.Request) (*Country, error) {
 
-	ip := request.RealIP(r, request.IPForwardedTrust) // todo make IPForwardedTrust configurable
+	ip := request.RealIP(r, request.IPForwardedTrust)
 	if ip == nil {
 		nf := errors.NewNotFoundf(errCannotGetRemoteAddr)
 		if s.Log.IsDebug() {
@@ -121,6 +123,42 @@ func (s *Service) WithIsCountryAllowedByIP(next http.Handler) http.Handler {
 		if s.Log.IsDebug() {
 			s.Log.Debug("Service.WithIsCountryAllowedByIP.checkAllow.true", log.Stringer("scope", scpCfg.ScopeID), log.String("countryISO", c.Country.IsoCode), log.Strings("allowedCountries", scpCfg.AllowedCountries...))
 		}
+		next.ServeHTTP(w, r.WithContext(scpCfg.withStoreSuggestion(ctx, c)))
+	})
+}
+
+// WithStoreSuggestionByIP detects the country, region and city via an IP
+// address and maps them with the StoreMappings of the current scope to a
+// suggested store code. The country and the store code get attached to the
+// context. Use FromContextStoreSuggestion() to extract the store code for
+// your redirect logic. The request does not get blocked.
+func (s *Service) WithStoreSuggestionByIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		scpCfg, err := s.configByContext(r.Context())
+		if err != nil {
+			if s.Log.IsDebug() {
+				s.Log.Debug("geoip.Service.WithStoreSuggestionByIP.configByContext", log.Err(err), loghttp.Request("request", r))
+			}
+			s.ErrorHandler(errors.Wrap(err, "geoip.Service.WithStoreSuggestionByIP.configFromContext")).ServeHTTP(w, r)
+			return
+		}
+		if scpCfg.Disabled || len(scpCfg.StoreMappings) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, c, err := s.newContextCountryByIP(r)
+		if err != nil {
+			scpCfg.ErrorHandler(errors.Wrap(err, "[geoip] newContextCountryByIP")).ServeHTTP(w, r)
+			return
+		}
+		ctx = scpCfg.withStoreSuggestion(ctx, c)
+		if s.Log.IsDebug() {
+			code, _ := FromContextStoreSuggestion(ctx)
+			s.Log.Debug("geoip.Service.WithStoreSuggestionByIP.Suggest", log.Stringer("scope", scpCfg.ScopeID), log.String("countryISO", c.Country.IsoCode),
+				log.String("regionISO", c.RegionCode()), log.String("city", c.CityName("en")), log.String("storeCode", code))
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,140 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geoip
+
+import (
+	"strings"
+
+	"github.com/corestoreio/errors"
+)
+
+// RegionCode returns the ISO code of the most specific subdivision, for
+// example "BY" for Bavaria in Germany. Empty if the Finder did not resolve the
+// subdivisions, for example with a country only database.
+func (c *Country) RegionCode() string {
+	if c == nil || len(c.Subdivision) == 0 {
+		return ""
+	}
+	return c.Subdivision[len(c.Subdivision)-1].IsoCode
+}
+
+// CityName returns the name of the city in the requested language and falls
+// back to English. Empty if the Finder did not resolve the city.
+func (c *Country) CityName(lang string) string {
+	if c == nil {
+		return ""
+	}
+	if n, ok := c.City.Names[lang]; ok {
+		return n
+	}
+	return c.City.Names["en"]
+}
+
+// StoreMapping maps a country, optionally narrowed down to a region and a
+// city, to a store code.
+type StoreMapping struct {
+	// CountryISO ISO 3166-1 code of the country, for example DE.
+	CountryISO string
+	// RegionISO optional ISO 3166-2 code of the subdivision without the
+	// country prefix, for example BY.
+	RegionISO string
+	// City optional English name of the city, for example Munich.
+	City string
+	// StoreCode the code of the suggested store view.
+	StoreCode string
+}
+
+// specificity returns the number of matching levels, the higher the more
+// specific. Returns zero if the mapping does not match the country.
+func (sm StoreMapping) specificity(country, region, city string) int {
+	if !strings.EqualFold(sm.CountryISO, country) {
+		return 0
+	}
+	lvl := 1
+	if sm.RegionISO != "" {
+		if !strings.EqualFold(sm.RegionISO, region) {
+			return 0
+		}
+		lvl++
+	}
+	if sm.City != "" {
+		if !strings.EqualFold(sm.City, city) {
+			return 0
+		}
+		lvl += 2
+	}
+	return lvl
+}
+
+// StoreMappings a list of mappings to find the suggested store view for a
+// resolved country.
+type StoreMappings []StoreMapping
+
+// ParseStoreMappings parses a comma or new line separated list of mappings in
+// the format Country[/Region[/City]]=StoreCode. For example:
+//		DE=de,DE/BY=de_by,DE/BY/Munich=de_muc,AT=at,CH=ch
+// Error behaviour: NotValid
+func ParseStoreMappings(s string) (StoreMappings, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+	sms := make(StoreMappings, 0, len(fields))
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		eq := strings.IndexByte(f, '=')
+		if eq < 1 || eq == len(f)-1 {
+			return nil, errors.NewNotValidf(errStoreMappingNotValid, f)
+		}
+		loc := strings.SplitN(strings.TrimSpace(f[:eq]), "/", 3)
+		sm := StoreMapping{
+			CountryISO: strings.TrimSpace(loc[0]),
+			StoreCode:  strings.TrimSpace(f[eq+1:]),
+		}
+		if len(loc) > 1 {
+			sm.RegionISO = strings.TrimSpace(loc[1])
+		}
+		if len(loc) > 2 {
+			sm.City = strings.TrimSpace(loc[2])
+		}
+		if sm.CountryISO == "" || sm.StoreCode == "" {
+			return nil, errors.NewNotValidf(errStoreMappingNotValid, f)
+		}
+		sms = append(sms, sm)
+	}
+	return sms, nil
+}
+
+// Suggest returns the store code of the most specific mapping for the country.
+// A city match wins over a region match and a region match wins over a
+// country match. On equal specificity the first mapping wins. The second
+// return value reports whether a mapping has been found.
+func (sms StoreMappings) Suggest(c *Country) (string, bool) {
+	if c == nil || c.Country.IsoCode == "" {
+		return "", false
+	}
+	region, city := c.RegionCode(), c.CityName("en")
+
+	var code string
+	var best int
+	for _, sm := range sms {
+		if lvl := sm.specificity(c.Country.IsoCode, region, city); lvl > best {
+			best, code = lvl, sm.StoreCode
+		}
+	}
+	return code, best > 0
+}
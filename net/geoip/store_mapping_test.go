@@ -0,0 +1,117 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geoip_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/corestoreio/csfw/net/geoip"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadResponseCountry(t *testing.T) *geoip.Country {
+	td, err := ioutil.ReadFile(filepath.Join("testdata", "response.json"))
+	require.NoError(t, err)
+	c := new(geoip.Country)
+	require.NoError(t, json.Unmarshal(td, c))
+	return c
+}
+
+func TestCountry_RegionCode_CityName(t *testing.T) {
+	c := loadResponseCountry(t)
+	assert.Exactly(t, "CA", c.RegionCode())
+	assert.Exactly(t, "Los Angeles", c.CityName("en"))
+	assert.Exactly(t, "Los Angeles", c.CityName("xx"), "Falls back to English")
+
+	var nilC *geoip.Country
+	assert.Empty(t, nilC.RegionCode())
+	assert.Empty(t, nilC.CityName("en"))
+	assert.Empty(t, new(geoip.Country).RegionCode())
+}
+
+func TestParseStoreMappings(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    geoip.StoreMappings
+		wantErr errors.BehaviourFunc
+	}{
+		{"", geoip.StoreMappings{}, nil},
+		{"DE=de, DE/BY = de_by,\nDE/BY/Munich=de_muc", geoip.StoreMappings{
+			{CountryISO: "DE", StoreCode: "de"},
+			{CountryISO: "DE", RegionISO: "BY", StoreCode: "de_by"},
+			{CountryISO: "DE", RegionISO: "BY", City: "Munich", StoreCode: "de_muc"},
+		}, nil},
+		{"US//Los Angeles=us_la", geoip.StoreMappings{
+			{CountryISO: "US", City: "Los Angeles", StoreCode: "us_la"},
+		}, nil},
+		{"DE", nil, errors.IsNotValid},
+		{"DE=", nil, errors.IsNotValid},
+		{"=de", nil, errors.IsNotValid},
+		{"/BY=de_by", nil, errors.IsNotValid},
+	}
+	for i, test := range tests {
+		have, err := geoip.ParseStoreMappings(test.raw)
+		if test.wantErr != nil {
+			assert.True(t, test.wantErr(err), "Index %d => %+v", i, err)
+			assert.Nil(t, have, "Index %d", i)
+			continue
+		}
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
+
+func TestStoreMappings_Suggest(t *testing.T) {
+	sms, err := geoip.ParseStoreMappings("US=us,US/CA=us_ca,US/CA/los angeles=us_la,US/NY=us_ny,DE=de")
+	require.NoError(t, err)
+
+	c := loadResponseCountry(t)
+	code, ok := sms.Suggest(c)
+	assert.True(t, ok)
+	assert.Exactly(t, "us_la", code, "City wins")
+
+	c.City.Names = nil
+	code, ok = sms.Suggest(c)
+	assert.True(t, ok)
+	assert.Exactly(t, "us_ca", code, "Region wins")
+
+	c.Subdivision = nil
+	code, ok = sms.Suggest(c)
+	assert.True(t, ok)
+	assert.Exactly(t, "us", code, "Country only")
+
+	c.Country.IsoCode = "AT"
+	code, ok = sms.Suggest(c)
+	assert.False(t, ok)
+	assert.Empty(t, code)
+
+	code, ok = sms.Suggest(nil)
+	assert.False(t, ok)
+	assert.Empty(t, code)
+}
+
+func TestFromContextStoreSuggestion(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://corestore.io", nil)
+	code, ok := geoip.FromContextStoreSuggestion(req.Context())
+	assert.False(t, ok)
+	assert.Empty(t, code)
+}
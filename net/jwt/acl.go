@@ -0,0 +1,100 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/csjwt"
+)
+
+// RolesClaimName defines the key in the claim where the roles of a token have
+// been stored. Can be overwritten with the scoped configuration.
+const RolesClaimName = `roles`
+
+// ACLResolver decides if the roles of a token grant access to a route group.
+// Services can plug in their own role model. Must be thread safe.
+type ACLResolver interface {
+	// IsGranted reports whether the roles grant all required permissions. An
+	// error aborts the request and gets passed to the Service.ErrorHandler.
+	IsGranted(roles []string, permissions ...string) (bool, error)
+}
+
+// ACLResolverFunc type is an adapter to allow the use of ordinary functions as
+// ACLResolver.
+type ACLResolverFunc func(roles []string, permissions ...string) (bool, error)
+
+// IsGranted calls f(roles, permissions...).
+func (f ACLResolverFunc) IsGranted(roles []string, permissions ...string) (bool, error) {
+	return f(roles, permissions...)
+}
+
+// ACLRoles maps a role name to its permissions and implements the
+// ACLResolver. Access gets granted if each required permission can be found in
+// at least one of the roles.
+type ACLRoles map[string][]string
+
+// IsGranted implements interface ACLResolver.
+func (ar ACLRoles) IsGranted(roles []string, permissions ...string) (bool, error) {
+	for _, p := range permissions {
+		if !ar.hasPermission(roles, p) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (ar ACLRoles) hasPermission(roles []string, permission string) bool {
+	for _, r := range roles {
+		for _, rp := range ar[r] {
+			if rp == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// aclMatchRoles default ACLResolver which treats the permissions as role
+// names. All permissions must be present in the roles.
+type aclMatchRoles struct{}
+
+func (aclMatchRoles) IsGranted(roles []string, permissions ...string) (bool, error) {
+	for _, p := range permissions {
+		var found bool
+		for _, r := range roles {
+			if r == p {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func rolesFromToken(token csjwt.Token, rolesClaimName string) []string {
+	key := RolesClaimName
+	if rolesClaimName != "" {
+		key = rolesClaimName
+	}
+	raw, err := token.Claims.Get(key)
+	if err != nil || raw == nil {
+		return nil
+	}
+	roles, _ := conv.ToStringSliceE(raw)
+	return roles
+}
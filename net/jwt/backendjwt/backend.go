@@ -47,6 +47,11 @@ type Configuration struct {
 	// Path: net/jwt/single_usage
 	SingleTokenUsage cfgmodel.Bool
 
+	// TokenSources defines comma separated in priority order where to look
+	// up the token in a request, e.g. "header,cookie:access_token,query:jwt".
+	// Path: net/jwt/token_sources
+	TokenSources cfgmodel.StringCSV
+
 	// HmacPassword handles the password. Will panic if you
 	// do not set the cfgmodel.Encryptor
 	// Path: net/jwt/hmac_password
@@ -94,6 +99,7 @@ func New(cfgStruct element.SectionSlice, opts ...cfgmodel.Option) *Configuration
 	be.Expiration = cfgmodel.NewDuration(`net/jwt/expiration`, opts...)
 	be.Skew = cfgmodel.NewDuration(`net/jwt/skew`, opts...)
 	be.SingleTokenUsage = cfgmodel.NewBool(`net/jwt/single_usage`, append(opts, cfgmodel.WithSource(cfgsource.EnableDisable))...)
+	be.TokenSources = cfgmodel.NewStringCSV(`net/jwt/token_sources`, opts...)
 	be.HmacPassword = cfgmodel.NewObscure(`net/jwt/hmac_password`, opts...)
 	be.HmacPasswordPerUser = cfgmodel.NewBool(`net/jwt/hmac_password_per_user`, append(opts, cfgmodel.WithSource(cfgsource.EnableDisable))...)
 	be.RSAKey = cfgmodel.NewObscure(`net/jwt/rsa_key`, opts...)
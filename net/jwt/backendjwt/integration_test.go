@@ -47,6 +47,7 @@ func TestConfiguration_HierarchicalConfig(t *testing.T) {
 		backend.Expiration.MustFQWebsite(3):   `66s`,
 		backend.Skew.MustFQ():                 `33s`,
 		backend.HmacPassword.MustFQWebsite(3): `This is a secure encrypted password.`,
+		backend.TokenSources.MustFQWebsite(3): `cookie:jwt, header`,
 	}).NewScoped(3, 0)
 
 	srv := jwt.MustNew(
@@ -59,6 +60,7 @@ func TestConfiguration_HierarchicalConfig(t *testing.T) {
 	assert.True(t, scpCfg.SingleTokenUsage)
 	assert.Exactly(t, time.Second*33, scpCfg.Skew, "Skew")
 	assert.Exactly(t, time.Second*66, scpCfg.Expire, "Expire")
+	assert.Exactly(t, []jwt.TokenSource{jwt.TokenFromCookie("jwt"), jwt.TokenFromHeader("")}, scpCfg.TokenSources)
 }
 
 func TestServiceWithBackend_HMACSHA_Website(t *testing.T) {
@@ -241,6 +243,18 @@ func TestServiceWithBackend_InvalidJTI(t *testing.T) {
 	assert.True(t, errors.IsNotValid(err), "Error: %+v", err)
 }
 
+func TestServiceWithBackend_InvalidTokenSources(t *testing.T) {
+
+	jwts, pb := getJwts()
+
+	cr := cfgmock.NewService(cfgmock.PathValue{
+		pb.TokenSources.MustFQWebsite(1): "header,session:id",
+	})
+
+	_, err := jwts.ConfigByScopedGetter(cr.NewScoped(1, 1))
+	assert.True(t, errors.IsNotSupported(err), "Error: %+v", err)
+}
+
 func TestServiceWithBackend_RSAFail(t *testing.T) {
 
 	jwts, pb := getJwts(
@@ -28,7 +28,7 @@ import (
 func (be *Configuration) PrepareOptionFactory() jwt.OptionFactoryFunc {
 	return func(sg config.Scoped) []jwt.Option {
 		var (
-			opts [7]jwt.Option
+			opts [8]jwt.Option
 			i    int // used as index in opts
 		)
 
@@ -63,6 +63,19 @@ func (be *Configuration) PrepareOptionFactory() jwt.OptionFactoryFunc {
 		opts[i] = jwt.WithSingleTokenUsage(isSU, sg.ScopeIDs()...)
 		i++
 
+		rawSources, err := be.TokenSources.Get(sg)
+		if err != nil {
+			return jwt.OptionsError(errors.Wrap(err, "[backendjwt] NetJwtTokenSources.Get"))
+		}
+		if len(rawSources) > 0 {
+			sources, err := jwt.ParseTokenSources(rawSources...)
+			if err != nil {
+				return jwt.OptionsError(errors.Wrap(err, "[backendjwt] NetJwtTokenSources.Parse"))
+			}
+			opts[i] = jwt.WithTokenSources(sources, sg.ScopeIDs()...)
+			i++
+		}
+
 		// todo: avoid the next code and use OptionFactories to apply a signing method. Example in ratelimit package.
 
 		signingMethod, err := be.SigningMethod.Get(sg)
@@ -116,6 +129,7 @@ func (be *Configuration) PrepareOptionFactory() jwt.OptionFactoryFunc {
 		opts[i] = jwt.WithSigningMethod(signingMethod, sg.ScopeIDs()...)
 		i++
 		opts[i] = jwt.WithMarkPartiallyApplied(false, sg.ScopeIDs()...) // remove error and we've loaded everything
-		return opts[:]
+		i++
+		return opts[:i]
 	}
 }
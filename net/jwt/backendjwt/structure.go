@@ -81,6 +81,16 @@ func NewConfigStructure() (element.SectionSlice, error) {
 							Scopes:    scope.PermWebsite,
 							Default:   `false`,
 						},
+						element.Field{
+							// Path: net/jwt/token_sources
+							ID:        cfgpath.NewRoute("token_sources"),
+							Label:     text.Chars(`Token sources`),
+							Comment:   text.Chars(`Comma separated list in priority order where to find the token in a request. Types: header, cookie, query and form with an optional name, e.g. header,cookie:access_token,query:jwt. If empty the Authorization header, the cookie and the form field access_token get used.`),
+							Type:      element.TypeText,
+							SortOrder: 32,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+						},
 						element.Field{
 							// Path: net/jwt/signing_method
 							ID:        cfgpath.NewRoute("signing_method"),
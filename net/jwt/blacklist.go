@@ -31,6 +31,14 @@ type Blacklister interface {
 	Has(id []byte) bool
 }
 
+// LogoutNotifier broadcasts the ID (jti) of a revoked token to other nodes of
+// a cluster, for example via a pub/sub system. The receiving nodes must add
+// the ID with the expiration duration to their Blacklister. Must be thread
+// safe.
+type LogoutNotifier interface {
+	NotifyLogout(id []byte, expires time.Duration) error
+}
+
 // nullBL is the black hole black list
 type nullBL struct{}
 
@@ -23,12 +23,13 @@ import (
 type keyCtxToken struct{}
 
 type ctxTokenWrapper struct {
-	t csjwt.Token
+	t   csjwt.Token
+	src TokenSource
 }
 
-// withContext creates a new context with csjwt.Token attached.
-func withContext(ctx context.Context, t csjwt.Token) context.Context {
-	return context.WithValue(ctx, keyCtxToken{}, ctxTokenWrapper{t: t})
+// withContext creates a new context with csjwt.Token and its source attached.
+func withContext(ctx context.Context, t csjwt.Token, src TokenSource) context.Context {
+	return context.WithValue(ctx, keyCtxToken{}, ctxTokenWrapper{t: t, src: src})
 }
 
 // FromContext returns the csjwt.Token in ctx if it exists or an error. If there
@@ -38,3 +39,11 @@ func FromContext(ctx context.Context) (csjwt.Token, bool) {
 	wrp, ok := ctx.Value(keyCtxToken{}).(ctxTokenWrapper)
 	return wrp.t, ok
 }
+
+// FromContextTokenSource returns the request source of the token in ctx, for
+// example to log whether a client sent the token via header or cookie. A newly
+// issued guest token has the zero TokenSource.
+func FromContextTokenSource(ctx context.Context) (TokenSource, bool) {
+	wrp, ok := ctx.Value(keyCtxToken{}).(ctxTokenWrapper)
+	return wrp.src, ok
+}
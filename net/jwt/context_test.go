@@ -25,7 +25,7 @@ import (
 
 func TestFromContext_Token(t *testing.T) {
 
-	ctx := withContext(context.Background(), csjwt.Token{})
+	ctx := withContext(context.Background(), csjwt.Token{}, TokenSource{})
 	assert.NotNil(t, ctx)
 
 	haveToken, ok := FromContext(ctx)
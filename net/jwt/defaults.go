@@ -29,3 +29,7 @@ const DefaultExpire = time.Hour
 
 // DefaultSkew duration of time skew we allow between signer and verifier.
 const DefaultSkew = time.Minute * 2
+
+// DefaultGuestExpire duration when a guest token expires. Applied by the
+// option WithGuestExpiration if the duration argument is zero.
+const DefaultGuestExpire = time.Minute * 30
@@ -27,6 +27,13 @@ const (
 	// ErrTokenBlacklisted returned by the middleware if the token can be found
 	// within the black list.
 	errTokenBlacklisted = "[jwt] Token has been black listed"
+
+	errTokenNotInContext = "[jwt] Token not found in context or invalid"
+	errPermissionDenied  = "[jwt] Permission denied. Required: %v"
+	errTokenNotGuest     = "[jwt] Token is not a valid guest token"
+
+	errUnknownTokenSource = "[jwt] Unknown token source %q. Want: header, cookie, query or form"
+	errTokenNotInRequest  = "[jwt] Token not found in request sources %v"
 )
 
 var (
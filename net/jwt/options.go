@@ -45,6 +45,24 @@ func WithBlacklist(bl Blacklister) Option {
 	}
 }
 
+// WithLogoutNotifier sets a global notifier which broadcasts revoked tokens
+// to other nodes of a cluster. See LogoutHandler.
+func WithLogoutNotifier(ln LogoutNotifier) Option {
+	return func(s *Service) error {
+		s.LogoutNotifier = ln
+		return nil
+	}
+}
+
+// WithACLResolver sets a global resolver which maps the roles of a token to
+// permissions. See WithPermissions.
+func WithACLResolver(acl ACLResolver) Option {
+	return func(s *Service) error {
+		s.ACL = acl
+		return nil
+	}
+}
+
 // WithTemplateToken set a custom csjwt.Header and csjwt.Claimer for each scope
 // when parsing a token in a request. Function f will generate a new base token
 // for each request. This allows you to choose using a slow map as a claim or a
@@ -64,6 +82,7 @@ func WithSigningMethod(sm csjwt.Signer, scopeIDs ...scope.TypeID) Option {
 		sc := s.findScopedConfig(scopeIDs...)
 		sc.SigningMethod = sm
 		sc.Verifier = csjwt.NewVerification(sm)
+		sc.Verifier.Decrypter = sc.Encryption
 		sc.initKeyFunc()
 		return s.updateScopedConfig(sc)
 	}
@@ -123,12 +142,32 @@ func WithKey(key csjwt.Key, scopeIDs ...scope.TypeID) Option {
 
 		sc.Key = key
 		sc.Verifier = csjwt.NewVerification(sc.SigningMethod)
+		sc.Verifier.Decrypter = sc.Encryption
 		sc.initKeyFunc()
 
 		return s.updateScopedConfig(sc)
 	}
 }
 
+// WithEncryption enables JSON Web Encryption (JWE) for a scope. Newly created
+// tokens get signed and then encrypted, so the claims cannot be read by the
+// client. Incoming encrypted tokens get decrypted before the signature
+// verification. The Encryption must contain the private key to decrypt tokens.
+// Plain signed tokens will still be accepted. A nil Encryption disables it.
+func WithEncryption(enc *csjwt.Encryption, scopeIDs ...scope.TypeID) Option {
+	return func(s *Service) error {
+		sc := s.findScopedConfig(scopeIDs...)
+		sc.Encryption = enc
+		if sc.Verifier == nil {
+			sc.Verifier = csjwt.NewVerification(sc.SigningMethod)
+		}
+		vf := *sc.Verifier
+		vf.Decrypter = enc
+		sc.Verifier = &vf
+		return s.updateScopedConfig(sc)
+	}
+}
+
 // WithStoreCodeFieldName sets the name of the key in the token claims section
 // to extract the store code.
 func WithStoreCodeFieldName(name string, scopeIDs ...scope.TypeID) Option {
@@ -150,6 +189,26 @@ func WithUnauthorizedHandler(uh mw.ErrorHandler, scopeIDs ...scope.TypeID) Optio
 	}
 }
 
+// WithRolesClaimName sets the name of the key in the token claims section to
+// extract the roles for the WithPermissions middleware.
+func WithRolesClaimName(name string, scopeIDs ...scope.TypeID) Option {
+	return func(s *Service) error {
+		sc := s.findScopedConfig(scopeIDs...)
+		sc.RolesClaimName = name
+		return s.updateScopedConfig(sc)
+	}
+}
+
+// WithForbiddenHandler adds a custom handler when the roles of a token do not
+// grant the required permissions.
+func WithForbiddenHandler(fh mw.ErrorHandler, scopeIDs ...scope.TypeID) Option {
+	return func(s *Service) error {
+		sc := s.findScopedConfig(scopeIDs...)
+		sc.ForbiddenHandler = fh
+		return s.updateScopedConfig(sc)
+	}
+}
+
 // WithSingleTokenUsage if set to true for each request a token can be only used
 // once. The JTI (JSON Token Identifier) gets added to the blacklist until it
 // expires.
@@ -160,3 +219,46 @@ func WithSingleTokenUsage(enable bool, scopeIDs ...scope.TypeID) Option {
 		return s.updateScopedConfig(sc)
 	}
 }
+
+// WithGuestExpiration enables the issuing of guest tokens in the WithToken
+// middleware for requests without a token. A zero duration applies
+// DefaultGuestExpire, a negative duration disables guest tokens.
+func WithGuestExpiration(d time.Duration, scopeIDs ...scope.TypeID) Option {
+	if d == 0 {
+		d = DefaultGuestExpire
+	}
+	if d < 0 {
+		d = 0
+	}
+	return func(s *Service) error {
+		sc := s.findScopedConfig(scopeIDs...)
+		sc.GuestExpire = d
+		return s.updateScopedConfig(sc)
+	}
+}
+
+// WithTokenSources defines in priority order where the middleware looks up
+// the token in a request. The first source containing a token gets parsed. See
+// TokenFromHeader, TokenFromCookie, TokenFromQuery and TokenFromForm.
+func WithTokenSources(sources []TokenSource, scopeIDs ...scope.TypeID) Option {
+	return func(s *Service) error {
+		for _, ts := range sources {
+			if ts.Type < TokenSourceHeader || ts.Type > TokenSourceForm || ts.Name == "" {
+				return errors.NewNotValidf("[jwt] WithTokenSources: Invalid token source %#v", ts)
+			}
+		}
+		sc := s.findScopedConfig(scopeIDs...)
+		sc.TokenSources = sources
+		return s.updateScopedConfig(sc)
+	}
+}
+
+// WithGuestCookie sets the cookie which transports a newly issued guest token
+// to the client. The cookie expires together with the guest token.
+func WithGuestCookie(c csjwt.Cookie, scopeIDs ...scope.TypeID) Option {
+	return func(s *Service) error {
+		sc := s.findScopedConfig(scopeIDs...)
+		sc.GuestCookie = &c
+		return s.updateScopedConfig(sc)
+	}
+}
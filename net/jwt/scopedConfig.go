@@ -45,6 +45,10 @@ type ScopedConfig struct {
 	// For Verification add Options for setting custom Unmarshaler, HTTP FORM
 	// input name and cookie name.
 	Verifier *csjwt.Verification
+	// Encryption optional JWE encryption of the signed token. If set, NewToken
+	// returns encrypted tokens and the Verifier decrypts a token before
+	// verifying its signature. Requires the private key for decryption.
+	Encryption *csjwt.Encryption
 	// KeyFunc will receive the parsed token and should return the key for
 	// validating.
 	KeyFunc csjwt.Keyfunc
@@ -54,6 +58,13 @@ type ScopedConfig struct {
 	// UnauthorizedHandler gets called for invalid tokens. Returns the code
 	// http.StatusUnauthorized
 	UnauthorizedHandler mw.ErrorHandler
+	// ForbiddenHandler gets called in the WithPermissions middleware when the
+	// roles of a token do not grant access. Returns the code
+	// http.StatusForbidden
+	ForbiddenHandler mw.ErrorHandler
+	// RolesClaimName optional custom key name used to lookup the roles in the
+	// claims section, defaults to constant RolesClaimName.
+	RolesClaimName string
 	// StoreCodeFieldName optional custom key name used to lookup the claims section
 	// to find the store code, defaults to constant store.CodeFieldName.
 	StoreCodeFieldName string
@@ -61,9 +72,23 @@ type ScopedConfig struct {
 	// once. The JTI (JSON Token Identifier) gets added to the blacklist until it
 	// expires.
 	SingleTokenUsage bool
+	// GuestExpire enables guest tokens if greater zero. The WithToken
+	// middleware issues for requests without a token a short lived guest token
+	// which expires after this duration. See Service.NewGuestToken.
+	GuestExpire time.Duration
+	// GuestCookie optional cookie to transport the guest token to the client.
+	// If nil, the guest token gets only written into the response header
+	// HTTPHeaderGuestToken.
+	GuestCookie *csjwt.Cookie
+	// TokenSources defines in priority order where to look up the token in a
+	// request. The first source containing a token wins. If empty, the
+	// Authorization header, the cookie and the form field of the Verifier get
+	// used.
+	TokenSources []TokenSource
 }
 
 var defaultUnauthorizedHandler = mw.ErrorWithStatusCode(http.StatusUnauthorized)
+var defaultForbiddenHandler = mw.ErrorWithStatusCode(http.StatusForbidden)
 
 // IsValid check if the scoped configuration is valid when:
 //		- Key
@@ -98,28 +123,50 @@ func (sc ScopedConfig) TemplateToken() (tk csjwt.Token) {
 }
 
 // ParseFromRequest parses a request to find a token in either the header, a
-// cookie or an HTML form.
+// cookie, a query parameter or an HTML form. See TokenSources.
 func (sc ScopedConfig) ParseFromRequest(bl Blacklister, r *http.Request) (csjwt.Token, error) {
+	tk, _, err := sc.ParseFromRequestSource(bl, r)
+	return tk, err
+}
+
+// ParseFromRequestSource same as ParseFromRequest but returns additionally the
+// source in which the token has been found. Only the first source containing
+// a token gets parsed. Error behaviour: NotFound if no source contains a
+// token, NotValid.
+func (sc ScopedConfig) ParseFromRequestSource(bl Blacklister, r *http.Request) (csjwt.Token, TokenSource, error) {
 	dst := sc.TemplateToken()
 
-	if err := sc.Verifier.ParseFromRequest(&dst, sc.KeyFunc, r); err != nil {
-		return dst, errors.Wrap(err, "[jwt] ScopedConfig.Verifier.ParseFromRequest")
+	sources := sc.tokenSources()
+	for _, ts := range sources {
+		raw := ts.extract(r)
+		if raw == nil {
+			continue
+		}
+		if err := sc.Verifier.Parse(&dst, raw, sc.KeyFunc); err != nil {
+			return dst, ts, errors.Wrapf(err, "[jwt] ScopedConfig.Verifier.Parse source %s", ts)
+		}
+		return dst, ts, sc.checkBlacklist(bl, dst)
 	}
+	return dst, TokenSource{}, errors.NewNotFoundf(errTokenNotInRequest, sources)
+}
 
-	kid, err := extractJTI(dst)
+// checkBlacklist returns a NotValid error if the token has been revoked and
+// blacklists it if SingleTokenUsage has been enabled.
+func (sc ScopedConfig) checkBlacklist(bl Blacklister, tk csjwt.Token) error {
+	kid, err := extractJTI(tk)
 	if err != nil {
-		return dst, errors.Wrap(err, "[jwt] ScopedConfig.ParseFromRequest.extractJTI")
+		return errors.Wrap(err, "[jwt] ScopedConfig.ParseFromRequest.extractJTI")
 	}
 
 	if bl.Has(kid) {
-		return dst, errors.NewNotValidf(errTokenBlacklisted)
+		return errors.NewNotValidf(errTokenBlacklisted)
 	}
 	if sc.SingleTokenUsage {
-		if err := bl.Set(kid, dst.Claims.Expires()); err != nil {
-			return dst, errors.Wrap(err, "[jwt] ScopedConfig.ParseFromRequest.Blacklist.Set")
+		if err := bl.Set(kid, tk.Claims.Expires()); err != nil {
+			return errors.Wrap(err, "[jwt] ScopedConfig.ParseFromRequest.Blacklist.Set")
 		}
 	}
-	return dst, nil
+	return nil
 }
 
 // Parse parses a raw token.
@@ -171,6 +218,7 @@ func newScopedConfig(target, parent scope.TypeID) *ScopedConfig {
 		SigningMethod:       hs256,
 		Verifier:            csjwt.NewVerification(hs256),
 		UnauthorizedHandler: defaultUnauthorizedHandler,
+		ForbiddenHandler:    defaultForbiddenHandler,
 	}
 	sc.initKeyFunc()
 	return sc
@@ -15,6 +15,8 @@
 package jwt
 
 import (
+	"time"
+
 	"github.com/corestoreio/csfw/store/scope"
 	"github.com/corestoreio/csfw/util/csjwt"
 	"github.com/corestoreio/errors"
@@ -39,6 +41,12 @@ type Service struct {
 	// Blacklist concurrent safe black list service which handles blocked
 	// tokens. Default black hole storage. Must be thread safe.
 	Blacklist Blacklister
+	// LogoutNotifier optional broadcaster to inform other nodes about a
+	// revoked token. Used in LogoutHandler. Can be nil.
+	LogoutNotifier LogoutNotifier
+	// ACL resolves the roles of a token to permissions in the WithPermissions
+	// middleware. Default resolver treats the permissions as role names.
+	ACL ACLResolver
 }
 
 // New creates a new token service.
@@ -68,6 +76,9 @@ func New(opts ...Option) (*Service, error) {
 	if s.Blacklist == nil {
 		s.Blacklist = nullBL{}
 	}
+	if s.ACL == nil {
+		s.ACL = aclMatchRoles{}
+	}
 	if err := s.optionAfterApply(); err != nil {
 		return nil, err
 	}
@@ -77,18 +88,24 @@ func New(opts ...Option) (*Service, error) {
 // NewToken creates a new signed JSON web token based on the predefined scoped
 // based template token function (WithTemplateToken) and merges the optional 3rd
 // argument into the template token claim. The returned token is owned by the
-// caller. The tokens Raw field contains the freshly signed byte slice.
+// caller. The tokens Raw field contains the freshly signed byte slice or the
+// encrypted token, if an Encryption has been set (WithEncryption).
 // ExpiresAt, IssuedAt and ID are already set and cannot be overwritten, but you
 // can access them. It panics if the provided template token has a nil Header or
 // Claimer field.
 func (s *Service) NewToken(scopeID scope.TypeID, claim ...csjwt.Claimer) (csjwt.Token, error) {
-	var empty csjwt.Token
-	now := csjwt.TimeFunc()
-
 	sc, err := s.ConfigByScopeID(scopeID, 0)
 	if err != nil {
-		return empty, errors.Wrap(err, "[jwt] NewToken.ConfigByScopeID")
+		return csjwt.Token{}, errors.Wrap(err, "[jwt] NewToken.ConfigByScopeID")
 	}
+	return s.newToken(sc, sc.Expire, false, claim...)
+}
+
+// newToken creates and signs a token with the expiration duration. The guest
+// argument adds the GuestClaimName to the claims.
+func (s *Service) newToken(sc ScopedConfig, expire time.Duration, guest bool, claim ...csjwt.Claimer) (csjwt.Token, error) {
+	var empty csjwt.Token
+	now := csjwt.TimeFunc()
 
 	var tk = sc.TemplateToken()
 
@@ -98,7 +115,12 @@ func (s *Service) NewToken(scopeID scope.TypeID, claim ...csjwt.Claimer) (csjwt.
 		}
 	}
 
-	if err := tk.Claims.Set(claimExpiresAt, now.Add(sc.Expire).Unix()); err != nil {
+	if guest {
+		if err := tk.Claims.Set(GuestClaimName, true); err != nil {
+			return empty, errors.Wrap(err, "[jwt] NewToken.Claims.Set Guest")
+		}
+	}
+	if err := tk.Claims.Set(claimExpiresAt, now.Add(expire).Unix()); err != nil {
 		return empty, errors.Wrap(err, "[jwt] NewToken.Claims.Set EXP")
 	}
 	if err := tk.Claims.Set(claimIssuedAt, now.Unix()); err != nil {
@@ -113,6 +135,10 @@ func (s *Service) NewToken(scopeID scope.TypeID, claim ...csjwt.Claimer) (csjwt.
 		return empty, errors.Wrapf(err, "[jwt] NewToken.Claims.Set KID: %q", jti)
 	}
 
+	if sc.Encryption != nil {
+		tk.Raw, err = tk.EncryptedString(sc.SigningMethod, sc.Key, sc.Encryption)
+		return tk, errors.Wrap(err, "[jwt] NewToken.EncryptedString")
+	}
 	tk.Raw, err = tk.SignedString(sc.SigningMethod, sc.Key)
 	return tk, errors.Wrap(err, "[jwt] NewToken.SignedString")
 }
@@ -0,0 +1,145 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"net/http"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/csjwt"
+	"github.com/corestoreio/csfw/util/csjwt/jwtclaim"
+	"github.com/corestoreio/errors"
+)
+
+// GuestClaimName defines the key in the claim which marks a token as guest
+// token. The claim of the template token must be able to store this key, for
+// example jwtclaim.Map.
+const GuestClaimName = `guest`
+
+// HTTPHeaderGuestToken response header which contains a newly issued guest
+// token.
+const HTTPHeaderGuestToken = `X-Guest-Token`
+
+// guestReservedClaims won't get copied from a guest token into the upgraded
+// token.
+var guestReservedClaims = map[string]bool{
+	GuestClaimName:        true,
+	claimExpiresAt:        true,
+	claimIssuedAt:         true,
+	claimKeyID:            true,
+	jwtclaim.KeyNotBefore: true,
+	jwtclaim.KeyTimeSkew:  true,
+}
+
+// IsGuest reports whether the token has been issued by NewGuestToken.
+func IsGuest(tk csjwt.Token) bool {
+	if tk.Claims == nil {
+		return false
+	}
+	v, err := tk.Claims.Get(GuestClaimName)
+	return err == nil && conv.ToBool(v)
+}
+
+// NewGuestToken creates a short lived token for anonymous users, for example
+// to keep track of a cart or a session. The expiration duration gets taken
+// from the scoped GuestExpire and falls back to DefaultGuestExpire. The claim
+// GuestClaimName will be set to true.
+func (s *Service) NewGuestToken(scopeID scope.TypeID, claim ...csjwt.Claimer) (csjwt.Token, error) {
+	sc, err := s.ConfigByScopeID(scopeID, 0)
+	if err != nil {
+		return csjwt.Token{}, errors.Wrap(err, "[jwt] NewGuestToken.ConfigByScopeID")
+	}
+	return s.newGuestToken(sc, claim...)
+}
+
+func (s *Service) newGuestToken(sc ScopedConfig, claim ...csjwt.Claimer) (csjwt.Token, error) {
+	exp := sc.GuestExpire
+	if exp <= 0 {
+		exp = DefaultGuestExpire
+	}
+	return s.newToken(sc, exp, true, claim...)
+}
+
+// UpgradeToken migrates a guest token into an authenticated token, usually
+// after a successful login. All claims of the guest token, except the
+// registered time claims, the ID and the guest marker, get copied into the
+// new token and the provided claims get merged on top. The guest token gets
+// blacklisted to prevent further usage. Error behaviour: NotValid or from the
+// token creation.
+func (s *Service) UpgradeToken(scopeID scope.TypeID, guest csjwt.Token, claim ...csjwt.Claimer) (csjwt.Token, error) {
+	var empty csjwt.Token
+	if !guest.Valid || !IsGuest(guest) {
+		return empty, errors.NewNotValidf(errTokenNotGuest)
+	}
+
+	sc, err := s.ConfigByScopeID(scopeID, 0)
+	if err != nil {
+		return empty, errors.Wrap(err, "[jwt] UpgradeToken.ConfigByScopeID")
+	}
+
+	carry := jwtclaim.Map{}
+	for _, k := range guest.Claims.Keys() {
+		if guestReservedClaims[k] {
+			continue
+		}
+		v, err := guest.Claims.Get(k)
+		if err != nil {
+			return empty, errors.Wrapf(err, "[jwt] UpgradeToken.Claims.Get %q", k)
+		}
+		if v != nil {
+			carry[k] = v
+		}
+	}
+
+	tk, err := s.newToken(sc, sc.Expire, false, append([]csjwt.Claimer{carry}, claim...)...)
+	if err != nil {
+		return empty, errors.Wrap(err, "[jwt] UpgradeToken.NewToken")
+	}
+	if err := s.Logout(guest); err != nil {
+		return empty, errors.Wrap(err, "[jwt] UpgradeToken.Logout")
+	}
+	return tk, nil
+}
+
+// guestFromRequest returns the guest token of the optional GuestCookie or
+// issues a new guest token and writes it to the response. A newly issued token
+// has the zero TokenSource.
+func (s *Service) guestFromRequest(sc ScopedConfig, w http.ResponseWriter, r *http.Request) (csjwt.Token, TokenSource, error) {
+	if sc.GuestCookie != nil {
+		tk := sc.TemplateToken()
+		src := TokenFromCookie(sc.GuestCookie.Name)
+		err := sc.GuestCookie.Parse(sc.Verifier, &tk, sc.KeyFunc, r)
+		switch {
+		case err == nil && IsGuest(tk):
+			return tk, src, sc.checkBlacklist(s.Blacklist, tk)
+		case err != nil && !errors.IsNotFound(err):
+			return tk, src, errors.Wrap(err, "[jwt] guestFromRequest.GuestCookie.Parse")
+		}
+	}
+
+	tk, err := s.newGuestToken(sc)
+	if err != nil {
+		return tk, TokenSource{}, errors.Wrap(err, "[jwt] guestFromRequest.newGuestToken")
+	}
+	// Valid gets only populated while parsing but the freshly signed token
+	// can be trusted.
+	tk.Valid = true
+	w.Header().Set(HTTPHeaderGuestToken, string(tk.Raw))
+	if sc.GuestCookie != nil {
+		sc.GuestCookie.WriteRaw(w, tk.Raw, tk.Claims.Expires())
+	}
+	return tk, TokenSource{}, nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/net/jwt"
+	"github.com/corestoreio/csfw/storage/containable"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/csjwt"
+	"github.com/corestoreio/csfw/util/csjwt/jwtclaim"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGuestRequest(websiteID int64) *http.Request {
+	req := httptest.NewRequest("GET", "http://guest.xyz/cart", nil)
+	return req.WithContext(scope.WithContext(req.Context(), websiteID, 0))
+}
+
+func TestService_WithToken_Guest(t *testing.T) {
+	jm := newLogoutService(t,
+		jwt.WithGuestExpiration(0, scope.Website.Pack(5)),
+		jwt.WithBlacklist(containable.NewInMemory()),
+	)
+
+	var ctxToken csjwt.Token
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tk, ok := jwt.FromContext(r.Context())
+		assert.True(t, ok)
+		ctxToken = tk
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	jm.WithToken(final).ServeHTTP(w, newGuestRequest(5))
+	assert.Exactly(t, http.StatusOK, w.Code)
+	assert.True(t, jwt.IsGuest(ctxToken))
+	assert.True(t, ctxToken.Valid)
+	assert.Exactly(t, string(ctxToken.Raw), w.Header().Get(jwt.HTTPHeaderGuestToken))
+	assert.Empty(t, w.Header().Get("Set-Cookie"))
+
+	exp := ctxToken.Claims.Expires()
+	assert.True(t, exp > jwt.DefaultGuestExpire-time.Minute && exp <= jwt.DefaultGuestExpire, "%s", exp)
+
+	// guest tokens are still tokens and get accepted in the next request
+	req := newGuestRequest(5)
+	jwt.SetHeaderAuthorization(req, ctxToken.Raw)
+	w = httptest.NewRecorder()
+	jm.WithToken(final).ServeHTTP(w, req)
+	assert.Exactly(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(jwt.HTTPHeaderGuestToken))
+
+	// guest tokens are disabled for website 6
+	w = httptest.NewRecorder()
+	jm.WithToken(final).ServeHTTP(w, newGuestRequest(6))
+	assert.Exactly(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestService_WithToken_GuestCookie(t *testing.T) {
+	jm := newLogoutService(t,
+		jwt.WithGuestExpiration(time.Minute*5, scope.Website.Pack(5)),
+		jwt.WithGuestCookie(csjwt.NewCookie(), scope.Website.Pack(5)),
+	)
+
+	var ctxToken csjwt.Token
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxToken, _ = jwt.FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	jm.WithToken(final).ServeHTTP(w, newGuestRequest(5))
+	assert.Exactly(t, http.StatusOK, w.Code)
+	cookies := (&http.Response{Header: w.Header()}).Cookies()
+	require.Len(t, cookies, 1)
+	assert.Exactly(t, string(ctxToken.Raw), cookies[0].Value)
+	assert.Exactly(t, 300, cookies[0].MaxAge)
+	firstRaw := ctxToken.Raw
+
+	// the cookie keeps the guest token for the next request
+	req := newGuestRequest(5)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	jm.WithToken(final).ServeHTTP(w, req)
+	assert.Exactly(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(jwt.HTTPHeaderGuestToken))
+	assert.Exactly(t, firstRaw, ctxToken.Raw)
+	assert.True(t, jwt.IsGuest(ctxToken))
+}
+
+func TestService_UpgradeToken(t *testing.T) {
+	jm := newLogoutService(t,
+		jwt.WithGuestExpiration(0, scope.Website.Pack(5)),
+		jwt.WithBlacklist(containable.NewInMemory()),
+	)
+
+	guest, err := jm.NewGuestToken(scope.Website.Pack(5), jwtclaim.Map{"cart": "c-4711"})
+	require.NoError(t, err)
+	guest, err = jm.ParseScoped(scope.Website.Pack(5), guest.Raw)
+	require.NoError(t, err)
+	assert.True(t, jwt.IsGuest(guest))
+
+	auth, err := jm.UpgradeToken(scope.Website.Pack(5), guest, jwtclaim.Map{jwtclaim.KeyUserID: "u-1"})
+	require.NoError(t, err)
+	auth, err = jm.ParseScoped(scope.Website.Pack(5), auth.Raw)
+	require.NoError(t, err)
+
+	assert.False(t, jwt.IsGuest(auth))
+	cart, _ := auth.Claims.Get("cart")
+	assert.Exactly(t, "c-4711", cart)
+	uid, _ := auth.Claims.Get(jwtclaim.KeyUserID)
+	assert.Exactly(t, "u-1", uid)
+	gID, _ := guest.Claims.Get("jti")
+	aID, _ := auth.Claims.Get("jti")
+	assert.NotEqual(t, gID, aID)
+	assert.True(t, auth.Claims.Expires() > jwt.DefaultGuestExpire)
+
+	// the guest token has been revoked
+	req := newGuestRequest(5)
+	jwt.SetHeaderAuthorization(req, guest.Raw)
+	w := httptest.NewRecorder()
+	jm.WithToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("Should not get called")
+	})).ServeHTTP(w, req)
+	assert.Exactly(t, http.StatusUnauthorized, w.Code)
+
+	// an authenticated token cannot be upgraded
+	_, err = jm.UpgradeToken(scope.Website.Pack(5), auth)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
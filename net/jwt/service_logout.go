@@ -0,0 +1,75 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"net/http"
+
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+	loghttp "github.com/corestoreio/log/http"
+)
+
+// LogoutHandler returns a handler which revokes the token found in the
+// request. The token gets searched in the Authorization header, the cookie or
+// the form body, see csjwt.Verification.ParseFromRequest. A valid token gets
+// added to the Blacklist until it expires and, if set, the LogoutNotifier
+// informs the other nodes. On success the status code http.StatusNoContent
+// gets written. Invalid tokens trigger the UnauthorizedHandler. Like WithToken
+// this handler depends on the runMode and its scope in the requests context.
+func (s *Service) LogoutHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scpCfg, err := s.configByContext(r.Context())
+		if err != nil {
+			s.Log.Info("jwt.Service.LogoutHandler.configByContext.Error", log.Err(err))
+			if s.Log.IsDebug() {
+				s.Log.Debug("jwt.Service.LogoutHandler.configByContext", log.Err(err), loghttp.Request("request", r))
+			}
+			s.ErrorHandler(errors.Wrap(err, "jwt.Service.LogoutHandler.configFromContext")).ServeHTTP(w, r)
+			return
+		}
+		if scpCfg.Disabled {
+			if s.Log.IsDebug() {
+				s.Log.Debug("jwt.Service.LogoutHandler.Disabled", log.Stringer("scope", scpCfg.ScopeID), loghttp.Request("request", r))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		token, err := scpCfg.ParseFromRequest(s.Blacklist, r)
+		if err != nil {
+			s.Log.Info("jwt.Service.LogoutHandler.ParseFromRequest.Error", log.Err(err))
+			scpCfg.UnauthorizedHandler(errors.Wrap(err, "[jwt] LogoutHandler.ParseFromRequest")).ServeHTTP(w, r)
+			return
+		}
+
+		if err := s.Logout(token); err != nil {
+			s.Log.Info("jwt.Service.LogoutHandler.Logout.Error", log.Err(err))
+			s.ErrorHandler(errors.Wrap(err, "[jwt] LogoutHandler.Logout")).ServeHTTP(w, r)
+			return
+		}
+
+		if s.LogoutNotifier != nil {
+			kid, _ := extractJTI(token) // error already checked in ParseFromRequest
+			if err := s.LogoutNotifier.NotifyLogout(kid, token.Claims.Expires()); err != nil {
+				s.Log.Info("jwt.Service.LogoutHandler.NotifyLogout.Error", log.Err(err))
+				s.ErrorHandler(errors.Wrap(err, "[jwt] LogoutHandler.LogoutNotifier.NotifyLogout")).ServeHTTP(w, r)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/jwt"
+	"github.com/corestoreio/csfw/storage/containable"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type logoutNotifierMock struct {
+	mu  sync.Mutex
+	ids []string
+	err error
+}
+
+func (ln *logoutNotifierMock) NotifyLogout(id []byte, expires time.Duration) error {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	ln.ids = append(ln.ids, string(id))
+	return ln.err
+}
+
+func newLogoutService(t *testing.T, opts ...jwt.Option) *jwt.Service {
+	jm, err := jwt.New(append(opts, jwt.WithRootConfig(cfgmock.NewService()))...)
+	require.NoError(t, err)
+	jm.Log = log.BlackHole{EnableDebug: true, EnableInfo: true}
+	return jm
+}
+
+func TestService_LogoutHandler(t *testing.T) {
+	ln := &logoutNotifierMock{}
+	jm := newLogoutService(t,
+		jwt.WithDisable(false, scope.Website.Pack(77)),
+		jwt.WithBlacklist(containable.NewInMemory()),
+		jwt.WithLogoutNotifier(ln),
+		jwt.WithServiceErrorHandler(func(err error) http.Handler {
+			panic("Should not get called")
+		}),
+	)
+	token, err := jm.NewToken(scope.Website.Pack(77))
+	require.NoError(t, err)
+
+	newReq := func() *http.Request {
+		req := httptest.NewRequest("POST", "http://auth.xyz/logout", nil)
+		req = req.WithContext(scope.WithContext(req.Context(), 77, 0))
+		jwt.SetHeaderAuthorization(req, token.Raw)
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	jm.LogoutHandler().ServeHTTP(w, newReq())
+	assert.Exactly(t, http.StatusNoContent, w.Code)
+	assert.Len(t, ln.ids, 1)
+	assert.NotEmpty(t, ln.ids[0])
+
+	// token has been revoked and cannot be used anymore
+	w = httptest.NewRecorder()
+	jm.WithToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("Should not get called")
+	})).ServeHTTP(w, newReq())
+	assert.Exactly(t, http.StatusUnauthorized, w.Code)
+
+	// second logout fails because the token is already black listed
+	w = httptest.NewRecorder()
+	jm.LogoutHandler().ServeHTTP(w, newReq())
+	assert.Exactly(t, http.StatusUnauthorized, w.Code)
+	assert.Len(t, ln.ids, 1)
+}
+
+func TestService_LogoutHandler_MissingToken(t *testing.T) {
+	jm := newLogoutService(t, jwt.WithDisable(false, scope.Website.Pack(78)))
+
+	req := httptest.NewRequest("POST", "http://auth.xyz/logout", nil)
+	req = req.WithContext(scope.WithContext(req.Context(), 78, 0))
+	w := httptest.NewRecorder()
+	jm.LogoutHandler().ServeHTTP(w, req)
+	assert.Exactly(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestService_LogoutHandler_NotifierError(t *testing.T) {
+	ln := &logoutNotifierMock{err: errors.NewFatalf("pub/sub down")}
+	jm := newLogoutService(t,
+		jwt.WithDisable(false, scope.Website.Pack(79)),
+		jwt.WithLogoutNotifier(ln),
+		jwt.WithServiceErrorHandler(func(err error) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.True(t, errors.IsFatal(err), "%+v", err)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			})
+		}),
+	)
+	token, err := jm.NewToken(scope.Website.Pack(79))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "http://auth.xyz/logout", nil)
+	req = req.WithContext(scope.WithContext(req.Context(), 79, 0))
+	jwt.SetHeaderAuthorization(req, token.Raw)
+	w := httptest.NewRecorder()
+	jm.LogoutHandler().ServeHTTP(w, req)
+	assert.Exactly(t, http.StatusServiceUnavailable, w.Code)
+	assert.Len(t, ln.ids, 1)
+}
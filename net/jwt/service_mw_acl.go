@@ -0,0 +1,72 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"net/http"
+
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+	loghttp "github.com/corestoreio/log/http"
+)
+
+// WithPermissions returns a middleware which checks the roles of the token in
+// the context against the required permissions using the ACLResolver. The
+// roles get read from the claim configured via WithRolesClaimName. Use one
+// middleware per route group. WithToken must run before this middleware. A
+// missing token triggers the UnauthorizedHandler, missing permissions the
+// ForbiddenHandler with an error of behaviour Unauthorized.
+func (s *Service) WithPermissions(permissions ...string) mw.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scpCfg, err := s.configByContext(r.Context())
+			if err != nil {
+				s.Log.Info("jwt.Service.WithPermissions.configByContext.Error", log.Err(err))
+				if s.Log.IsDebug() {
+					s.Log.Debug("jwt.Service.WithPermissions.configByContext", log.Err(err), loghttp.Request("request", r))
+				}
+				s.ErrorHandler(errors.Wrap(err, "jwt.Service.WithPermissions.configFromContext")).ServeHTTP(w, r)
+				return
+			}
+			if scpCfg.Disabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := FromContext(r.Context())
+			if !ok || !token.Valid {
+				scpCfg.UnauthorizedHandler(errors.NewUnauthorizedf(errTokenNotInContext)).ServeHTTP(w, r)
+				return
+			}
+
+			roles := rolesFromToken(token, scpCfg.RolesClaimName)
+			granted, err := s.ACL.IsGranted(roles, permissions...)
+			if err != nil {
+				s.Log.Info("jwt.Service.WithPermissions.ACL.IsGranted.Error", log.Err(err))
+				s.ErrorHandler(errors.Wrap(err, "[jwt] WithPermissions.ACL.IsGranted")).ServeHTTP(w, r)
+				return
+			}
+			if !granted {
+				if s.Log.IsDebug() {
+					s.Log.Debug("jwt.Service.WithPermissions.Denied", log.Strings("roles", roles...), log.Strings("permissions", permissions...), loghttp.Request("request", r))
+				}
+				scpCfg.ForbiddenHandler(errors.NewUnauthorizedf(errPermissionDenied, permissions)).ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/jwt"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/csjwt/jwtclaim"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACLRoles_IsGranted(t *testing.T) {
+	t.Parallel()
+	acl := jwt.ACLRoles{
+		"editor": {"catalog.read", "catalog.write"},
+		"viewer": {"catalog.read"},
+		"sales":  {"order.read"},
+	}
+	tests := []struct {
+		roles       []string
+		permissions []string
+		want        bool
+	}{
+		{[]string{"viewer"}, []string{"catalog.read"}, true},
+		{[]string{"viewer"}, []string{"catalog.read", "catalog.write"}, false},
+		{[]string{"editor"}, []string{"catalog.read", "catalog.write"}, true},
+		{[]string{"viewer", "sales"}, []string{"catalog.read", "order.read"}, true},
+		{[]string{"unknown"}, []string{"catalog.read"}, false},
+		{nil, []string{"catalog.read"}, false},
+		{nil, nil, true},
+	}
+	for i, test := range tests {
+		have, err := acl.IsGranted(test.roles, test.permissions...)
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
+
+func newACLHandler(t *testing.T, claim jwtclaim.Map, permissions []string, opts ...jwt.Option) (http.Handler, []byte) {
+	jm, err := jwt.New(append(opts,
+		jwt.WithRootConfig(cfgmock.NewService()),
+		jwt.WithDisable(false, scope.Website.Pack(88)),
+	)...)
+	require.NoError(t, err)
+	jm.Log = log.BlackHole{EnableDebug: true, EnableInfo: true}
+
+	token, err := jm.NewToken(scope.Website.Pack(88), claim)
+	require.NoError(t, err)
+
+	final := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mw.Chain(final, jm.WithToken, jm.WithPermissions(permissions...)), token.Raw
+}
+
+func serveACL(h http.Handler, token []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "http://acl.xyz/admin", nil)
+	req = req.WithContext(scope.WithContext(req.Context(), 88, 0))
+	if token != nil {
+		jwt.SetHeaderAuthorization(req, token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestService_WithPermissions(t *testing.T) {
+
+	t.Run("default resolver granted", func(t *testing.T) {
+		h, token := newACLHandler(t, jwtclaim.Map{"roles": []string{"admin", "editor"}}, []string{"admin"})
+		assert.Exactly(t, http.StatusOK, serveACL(h, token).Code)
+	})
+
+	t.Run("default resolver denied", func(t *testing.T) {
+		h, token := newACLHandler(t, jwtclaim.Map{"roles": "editor viewer"}, []string{"admin"})
+		assert.Exactly(t, http.StatusForbidden, serveACL(h, token).Code)
+	})
+
+	t.Run("missing roles claim", func(t *testing.T) {
+		h, token := newACLHandler(t, jwtclaim.Map{"xfoo": "bar"}, []string{"admin"})
+		assert.Exactly(t, http.StatusForbidden, serveACL(h, token).Code)
+	})
+
+	t.Run("custom claim and resolver", func(t *testing.T) {
+		h, token := newACLHandler(t, jwtclaim.Map{"grp": []interface{}{"editor"}}, []string{"catalog.write"},
+			jwt.WithRolesClaimName("grp", scope.Website.Pack(88)),
+			jwt.WithACLResolver(jwt.ACLRoles{"editor": {"catalog.write"}}),
+		)
+		assert.Exactly(t, http.StatusOK, serveACL(h, token).Code)
+	})
+
+	t.Run("custom forbidden handler", func(t *testing.T) {
+		h, token := newACLHandler(t, jwtclaim.Map{"roles": "viewer"}, []string{"catalog.write"},
+			jwt.WithForbiddenHandler(func(err error) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.True(t, errors.IsUnauthorized(err), "%+v", err)
+					w.WriteHeader(http.StatusTeapot)
+				})
+			}, scope.Website.Pack(88)),
+		)
+		assert.Exactly(t, http.StatusTeapot, serveACL(h, token).Code)
+	})
+
+	t.Run("resolver error", func(t *testing.T) {
+		h, token := newACLHandler(t, jwtclaim.Map{"roles": "viewer"}, []string{"catalog.write"},
+			jwt.WithACLResolver(jwt.ACLResolverFunc(func(roles []string, permissions ...string) (bool, error) {
+				return false, errors.NewFatalf("ACL backend down")
+			})),
+			jwt.WithServiceErrorHandler(func(err error) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.True(t, errors.IsFatal(err), "%+v", err)
+					w.WriteHeader(http.StatusServiceUnavailable)
+				})
+			}),
+		)
+		assert.Exactly(t, http.StatusServiceUnavailable, serveACL(h, token).Code)
+	})
+
+	t.Run("token missing in context", func(t *testing.T) {
+		jm, err := jwt.New(jwt.WithRootConfig(cfgmock.NewService()), jwt.WithDisable(false, scope.Website.Pack(88)))
+		require.NoError(t, err)
+		h := jm.WithPermissions("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("Should not get called")
+		}))
+		assert.Exactly(t, http.StatusUnauthorized, serveACL(h, nil).Code)
+	})
+}
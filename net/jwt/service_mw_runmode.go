@@ -83,11 +83,11 @@ func (s *Service) WithRunMode(rm scope.RunModeCalculater, sf StoreFinder) mw.Mid
 				return
 			}
 
-			token, err := defaultScpCfg.ParseFromRequest(s.Blacklist, r)
-			ctx := withContext(r.Context(), token)
+			token, src, err := defaultScpCfg.ParseFromRequestSource(s.Blacklist, r)
+			ctx := withContext(r.Context(), token, src)
 			if err != nil {
 				if s.Log.IsDebug() {
-					s.Log.Debug("jwt.Service.WithToken.ParseFromRequest", log.Err(err), log.Marshal("token", token), log.Stringer("scope", defaultScpCfg.ScopeID), log.Object("scpCfg", defaultScpCfg), loghttp.Request("request", r))
+					s.Log.Debug("jwt.Service.WithToken.ParseFromRequest", log.Err(err), log.Marshal("token", token), log.Stringer("token_source", src), log.Stringer("scope", defaultScpCfg.ScopeID), log.Object("scpCfg", defaultScpCfg), loghttp.Request("request", r))
 				}
 				// todo what should be done when the token has expired?
 				r = r.WithContext(scope.WithContext(r.Context(), websiteID, storeID))
@@ -26,7 +26,9 @@ import (
 // blacklist will be performed. The token gets added to the context for further
 // processing for the next middlewares. This function depends on the runMode and
 // its scope which must exists in the requests context. WithToken() does not
-// change the scope of the previously initialized runMode and its scope.
+// change the scope of the previously initialized runMode and its scope. If
+// guest tokens have been enabled (WithGuestExpiration), requests without a
+// token receive a new guest token instead of the UnauthorizedHandler.
 func (s *Service) WithToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		scpCfg, err := s.configByContext(r.Context())
@@ -46,19 +48,26 @@ func (s *Service) WithToken(next http.Handler) http.Handler {
 			return
 		}
 
-		token, err := scpCfg.ParseFromRequest(s.Blacklist, r)
+		token, src, err := scpCfg.ParseFromRequestSource(s.Blacklist, r)
+		if err != nil && scpCfg.GuestExpire > 0 && errors.IsNotFound(err) {
+			token, src, err = s.guestFromRequest(scpCfg, w, r)
+		}
 		if err != nil {
-			s.Log.Info("jwt.Service.WithToken.ParseFromRequest.Error", log.Err(err))
+			s.Log.Info("jwt.Service.WithToken.ParseFromRequest.Error", log.Err(err), log.Stringer("token_source", src))
 			if s.Log.IsDebug() {
-				s.Log.Debug("jwt.Service.WithToken.ParseFromRequest", log.Err(err), log.Marshal("token", token), log.Stringer("scope", scpCfg.ScopeID), log.Object("scpCfg", scpCfg), loghttp.Request("request", r))
+				s.Log.Debug("jwt.Service.WithToken.ParseFromRequest", log.Err(err), log.Marshal("token", token), log.Stringer("token_source", src), log.Stringer("scope", scpCfg.ScopeID), log.Object("scpCfg", scpCfg), loghttp.Request("request", r))
 			}
 			// todo what should be done when the token has expired?
 			scpCfg.UnauthorizedHandler(errors.Wrap(err, "[jwt] WithToken.ParseFromRequest")).ServeHTTP(w, r)
 			return
 		}
 
-		// add token to the context
-		ctx := withContext(r.Context(), token)
+		if s.Log.IsDebug() {
+			s.Log.Debug("jwt.Service.WithToken.TokenSource", log.Stringer("token_source", src), log.Stringer("scope", scpCfg.ScopeID))
+		}
+
+		// add token and its source to the context
+		ctx := withContext(r.Context(), token, src)
 
 		// continue without changing the scope
 		next.ServeHTTP(w, r.WithContext(ctx))
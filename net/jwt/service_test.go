@@ -15,6 +15,7 @@
 package jwt_test
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
@@ -80,6 +81,39 @@ func TestServiceNewDefault(t *testing.T) {
 	assert.False(t, failedToken.Valid)
 }
 
+func TestService_WithEncryption(t *testing.T) {
+
+	enc, err := csjwt.NewEncryption(csjwt.RSAOAEP256, csjwt.A256GCM, csjwt.WithRSAGenerated())
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	jwts := jwt.MustNew(
+		jwt.WithEncryption(enc, scope.DefaultTypeID),
+		jwt.WithKey(csjwt.WithPasswordRandom(), scope.DefaultTypeID),
+	)
+
+	theToken, err := jwts.NewToken(scope.DefaultTypeID, jwtclaim.Map{"customer_id": "4711"})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, 4, bytes.Count(theToken.Raw, []byte(".")), "Expecting a JWE with five segments")
+
+	haveToken, err := jwts.Parse(theToken.Raw)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.True(t, haveToken.Valid)
+	cid, err := haveToken.Claims.Get("customer_id")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, "4711", cid)
+
+	// a service without the decryption key cannot read the token
+	_, err = jwt.MustNew().Parse(theToken.Raw)
+	assert.True(t, errors.IsNotSupported(err), "Error: %+v", err)
+}
+
 func TestServiceNewDefaultRSAError(t *testing.T) {
 
 	jmRSA, err := jwt.New(jwt.WithKey(csjwt.WithRSAPrivateKeyFromFile("invalid.key")))
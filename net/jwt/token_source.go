@@ -0,0 +1,186 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/corestoreio/csfw/util/csjwt"
+	"github.com/corestoreio/errors"
+)
+
+// TokenSourceType defines the location of a token in a request.
+type TokenSourceType uint8
+
+// List of supported token source types.
+const (
+	// TokenSourceHeader reads the token from a header. For the Authorization
+	// header the value must start with "Bearer ".
+	TokenSourceHeader TokenSourceType = iota + 1
+	// TokenSourceCookie reads the token from a cookie.
+	TokenSourceCookie
+	// TokenSourceQuery reads the token from a URL query parameter.
+	TokenSourceQuery
+	// TokenSourceForm reads the token from a form field. Like in package
+	// csjwt the URL query gets also considered.
+	TokenSourceForm
+)
+
+var tokenSourceTypeNames = [...]string{"", "header", "cookie", "query", "form"}
+
+// String returns the name of the type.
+func (t TokenSourceType) String() string {
+	if int(t) < len(tokenSourceTypeNames) {
+		return tokenSourceTypeNames[t]
+	}
+	return "unknown"
+}
+
+// TokenSource defines a location of the token in a request and the name of
+// the header, cookie, query parameter or form field. The zero value represents
+// a newly issued guest token.
+type TokenSource struct {
+	Type TokenSourceType
+	Name string
+}
+
+// String returns type and name, e.g. "cookie:access_token".
+func (ts TokenSource) String() string {
+	if ts.Type == 0 {
+		return ""
+	}
+	return ts.Type.String() + ":" + ts.Name
+}
+
+// TokenFromHeader returns a source which reads the token from the header
+// name. An empty name defaults to csjwt.HTTPHeaderAuthorization.
+func TokenFromHeader(name string) TokenSource {
+	if name == "" {
+		name = csjwt.HTTPHeaderAuthorization
+	}
+	return TokenSource{Type: TokenSourceHeader, Name: name}
+}
+
+// TokenFromCookie returns a source which reads the token from the cookie
+// name. An empty name defaults to csjwt.HTTPFormInputName.
+func TokenFromCookie(name string) TokenSource {
+	if name == "" {
+		name = csjwt.HTTPFormInputName
+	}
+	return TokenSource{Type: TokenSourceCookie, Name: name}
+}
+
+// TokenFromQuery returns a source which reads the token from the URL query
+// parameter name. An empty name defaults to csjwt.HTTPFormInputName. Tokens in
+// URLs might end up in access logs and browser histories.
+func TokenFromQuery(name string) TokenSource {
+	if name == "" {
+		name = csjwt.HTTPFormInputName
+	}
+	return TokenSource{Type: TokenSourceQuery, Name: name}
+}
+
+// TokenFromForm returns a source which reads the token from the form field
+// name. An empty name defaults to csjwt.HTTPFormInputName.
+func TokenFromForm(name string) TokenSource {
+	if name == "" {
+		name = csjwt.HTTPFormInputName
+	}
+	return TokenSource{Type: TokenSourceForm, Name: name}
+}
+
+// ParseTokenSources parses a list of sources in the format "type" or
+// "type:name", for example "header", "cookie:access_token" or "query:jwt".
+// Supported types are header, cookie, query and form. Used to read the token
+// sources from the configuration. Error behaviour: NotSupported.
+func ParseTokenSources(sources ...string) ([]TokenSource, error) {
+	ret := make([]TokenSource, 0, len(sources))
+	for _, s := range sources {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		typ, name := s, ""
+		if pos := strings.IndexByte(s, ':'); pos >= 0 {
+			typ, name = s[:pos], strings.TrimSpace(s[pos+1:])
+		}
+		switch strings.ToLower(strings.TrimSpace(typ)) {
+		case "header":
+			ret = append(ret, TokenFromHeader(name))
+		case "cookie":
+			ret = append(ret, TokenFromCookie(name))
+		case "query":
+			ret = append(ret, TokenFromQuery(name))
+		case "form":
+			ret = append(ret, TokenFromForm(name))
+		default:
+			return nil, errors.NewNotSupportedf(errUnknownTokenSource, s)
+		}
+	}
+	return ret, nil
+}
+
+// extract returns the raw token or nil if the source does not contain a token.
+func (ts TokenSource) extract(r *http.Request) []byte {
+	switch ts.Type {
+	case TokenSourceHeader:
+		v := r.Header.Get(ts.Name)
+		const bearer = "bearer "
+		hasBearer := len(v) > len(bearer) && strings.EqualFold(v[:len(bearer)], bearer)
+		switch {
+		case hasBearer:
+			v = v[len(bearer):]
+		case strings.EqualFold(ts.Name, csjwt.HTTPHeaderAuthorization):
+			return nil // other authorization schemes
+		}
+		if v == "" {
+			return nil
+		}
+		return []byte(v)
+	case TokenSourceCookie:
+		if c, err := r.Cookie(ts.Name); err == nil && c.Value != "" {
+			return []byte(c.Value)
+		}
+	case TokenSourceQuery:
+		if v := r.URL.Query().Get(ts.Name); v != "" {
+			return []byte(v)
+		}
+	case TokenSourceForm:
+		_ = r.ParseMultipartForm(10e6) // ignore errors
+		if v := r.Form.Get(ts.Name); v != "" {
+			return []byte(v)
+		}
+	}
+	return nil
+}
+
+// tokenSources returns the configured sources in priority order or the
+// default sources of the Verifier: the Authorization header, the cookie and
+// the form field.
+func (sc ScopedConfig) tokenSources() []TokenSource {
+	if len(sc.TokenSources) > 0 {
+		return sc.TokenSources
+	}
+	ts := make([]TokenSource, 1, 3)
+	ts[0] = TokenFromHeader(csjwt.HTTPHeaderAuthorization)
+	if sc.Verifier != nil && sc.Verifier.CookieName != "" {
+		ts = append(ts, TokenFromCookie(sc.Verifier.CookieName))
+	}
+	if sc.Verifier != nil && sc.Verifier.FormInputName != "" {
+		ts = append(ts, TokenFromForm(sc.Verifier.FormInputName))
+	}
+	return ts
+}
@@ -0,0 +1,120 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/jwt"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/csjwt/jwtclaim"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTokenSources(t *testing.T) {
+	ts, err := jwt.ParseTokenSources("header", " cookie:jwt", "", "QUERY:token", "form", "header:X-Auth-Token")
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, []jwt.TokenSource{
+		{Type: jwt.TokenSourceHeader, Name: "Authorization"},
+		{Type: jwt.TokenSourceCookie, Name: "jwt"},
+		{Type: jwt.TokenSourceQuery, Name: "token"},
+		{Type: jwt.TokenSourceForm, Name: "access_token"},
+		{Type: jwt.TokenSourceHeader, Name: "X-Auth-Token"},
+	}, ts)
+	assert.Exactly(t, "cookie:jwt", ts[1].String())
+
+	_, err = jwt.ParseTokenSources("header", "session:id")
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+}
+
+func TestWithTokenSources_Invalid(t *testing.T) {
+	_, err := jwt.New(jwt.WithTokenSources([]jwt.TokenSource{{Type: jwt.TokenSourceCookie}}))
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestService_WithToken_TokenSources(t *testing.T) {
+	jm, err := jwt.New(
+		jwt.WithRootConfig(cfgmock.NewService()),
+		jwt.WithTokenSources([]jwt.TokenSource{
+			jwt.TokenFromCookie("jwt"),
+			jwt.TokenFromQuery("token"),
+			jwt.TokenFromHeader("X-Auth-Token"),
+		}, scope.Website.Pack(77)),
+		jwt.WithErrorHandler(func(err error) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+		}, scope.Website.Pack(77)),
+	)
+	require.NoError(t, err, "%+v", err)
+	jm.Log = log.BlackHole{EnableDebug: true, EnableInfo: true}
+
+	tk, err := jm.NewToken(scope.DefaultTypeID, jwtclaim.Map{"xfoo": "bar"})
+	require.NoError(t, err, "%+v", err)
+
+	var haveSource jwt.TokenSource
+	authHandler := jm.WithToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ok bool
+		haveSource, ok = jwt.FromContextTokenSource(r.Context())
+		assert.True(t, ok)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(req *http.Request) int {
+		haveSource = jwt.TokenSource{}
+		req = req.WithContext(scope.WithContext(req.Context(), 77, 0))
+		w := httptest.NewRecorder()
+		authHandler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("cookie wins over query", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "http://auth.xyz/?token=invalid", nil)
+		req.AddCookie(&http.Cookie{Name: "jwt", Value: string(tk.Raw)})
+		assert.Exactly(t, http.StatusOK, serve(req))
+		assert.Exactly(t, jwt.TokenFromCookie("jwt"), haveSource)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "http://auth.xyz/?token="+string(tk.Raw), nil)
+		assert.Exactly(t, http.StatusOK, serve(req))
+		assert.Exactly(t, jwt.TokenFromQuery("token"), haveSource)
+	})
+
+	t.Run("custom header with bearer", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "http://auth.xyz/", nil)
+		req.Header.Set("X-Auth-Token", "Bearer "+string(tk.Raw))
+		assert.Exactly(t, http.StatusOK, serve(req))
+		assert.Exactly(t, "header:X-Auth-Token", haveSource.String())
+	})
+
+	t.Run("authorization header not configured", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "http://auth.xyz/", nil)
+		jwt.SetHeaderAuthorization(req, tk.Raw)
+		assert.Exactly(t, http.StatusUnauthorized, serve(req))
+	})
+
+	t.Run("invalid token in first source", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "http://auth.xyz/?token="+string(tk.Raw), nil)
+		req.AddCookie(&http.Cookie{Name: "jwt", Value: "invalid"})
+		assert.Exactly(t, http.StatusUnauthorized, serve(req))
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mw
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// WithHEAD answers HEAD requests with the GET handlers of a route group. The
+// request method gets changed to GET, the response body gets discarded and
+// the Content-Length header reflects the number of bytes the GET handler
+// would have written, unless the handler sets the header itself. If no
+// Content-Type has been set, it gets detected from the first written bytes.
+// All other methods pass unchanged. Apply it only to route groups whose GET
+// handlers have no side effects.
+func WithHEAD() Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodHead {
+				h.ServeHTTP(w, r)
+				return
+			}
+			r.Method = http.MethodGet
+			hw := &headWriter{ResponseWriter: w}
+			h.ServeHTTP(hw, r)
+			hw.flushHeader()
+		})
+	}
+}
+
+// headWriter delays writing the header until the GET handler has finished to
+// know the length of the discarded body.
+type headWriter struct {
+	http.ResponseWriter
+	code    int
+	written int64
+}
+
+// WriteHeader stores the first status code.
+func (hw *headWriter) WriteHeader(code int) {
+	if hw.code == 0 {
+		hw.code = code
+	}
+}
+
+// Write discards the body and counts the bytes.
+func (hw *headWriter) Write(p []byte) (int, error) {
+	if hw.code == 0 {
+		hw.code = http.StatusOK
+	}
+	if hw.written == 0 && len(p) > 0 && hw.Header().Get("Content-Type") == "" {
+		hw.Header().Set("Content-Type", http.DetectContentType(p))
+	}
+	hw.written += int64(len(p))
+	return len(p), nil
+}
+
+func (hw *headWriter) flushHeader() {
+	if hw.code == 0 {
+		hw.code = http.StatusOK
+	}
+	hdr := hw.Header()
+	if hdr.Get("Content-Length") == "" && bodyAllowedForStatus(hw.code) && hdr.Get("Transfer-Encoding") == "" {
+		hdr.Set("Content-Length", strconv.FormatInt(hw.written, 10))
+	}
+	hw.ResponseWriter.WriteHeader(hw.code)
+}
+
+// bodyAllowedForStatus reports whether a given response status code permits a
+// body. See RFC 2616, section 4.4.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mw_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHEAD(t *testing.T) {
+	const body = `<html><body>Product 3452</body></html>`
+
+	tests := []struct {
+		name       string
+		method     string
+		handler    http.HandlerFunc
+		wantCode   int
+		wantBody   string
+		wantLength string
+		wantType   string
+	}{
+		{
+			"HEAD from GET", "HEAD",
+			func(w http.ResponseWriter, r *http.Request) {
+				assert.Exactly(t, "GET", r.Method)
+				_, _ = io.WriteString(w, body[:10])
+				_, _ = io.WriteString(w, body[10:])
+			},
+			http.StatusOK, "", "38", "text/html; charset=utf-8",
+		},
+		{
+			"HEAD keeps Content-Length and status", "HEAD",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "4711")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				w.WriteHeader(http.StatusTeapot) // superfluous
+			},
+			http.StatusCreated, "", "4711", "application/json",
+		},
+		{
+			"HEAD empty body", "HEAD",
+			func(w http.ResponseWriter, r *http.Request) {},
+			http.StatusOK, "", "0", "",
+		},
+		{
+			"HEAD not modified", "HEAD",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotModified)
+			},
+			http.StatusNotModified, "", "", "",
+		},
+		{
+			"GET passes", "GET",
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, body)
+			},
+			http.StatusOK, body, "", "text/html; charset=utf-8",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mw.WithHEAD()(test.handler).ServeHTTP(rec, httptest.NewRequest(test.method, "http://corestore.io/catalog/product/id/3452", nil))
+			assert.Exactly(t, test.wantCode, rec.Code)
+			assert.Exactly(t, test.wantBody, rec.Body.String())
+			assert.Exactly(t, test.wantLength, rec.Header().Get("Content-Length"))
+			assert.Exactly(t, test.wantType, rec.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -17,6 +17,7 @@ package mw
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/corestoreio/errors"
@@ -97,19 +98,33 @@ const (
 // HTTPMethodOverrideFormKey to any form will take precedence before
 // HTTP header. If an unknown method will be submitted it gets logged as an
 // Info log. This function is chainable.
-// Suported options are: SetMethodOverrideFormKey() and SetLogger().
+//
+// Legacy clients should only be allowed to tunnel other methods through POST
+// requests, otherwise a simple link can trigger a DELETE. Create for each route
+// group its own middleware:
+//		api := mw.WithXHTTPMethodOverride(
+//			mw.SetMethodOverrideFrom("POST"),
+//			mw.SetMethodOverrideMethods("PUT", "PATCH", "DELETE"),
+//		)
+// Suported options are: SetMethodOverrideFormKey(), SetMethodOverrideFrom(),
+// SetMethodOverrideMethods() and SetLogger().
 func WithXHTTPMethodOverride(opts ...Option) Middleware {
 	ob := newOptionBox(opts...)
 	errUnknownMethod := errors.NewNotValidf("[mw] Unknown http method")
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(ob.methodOverrideFrom) > 0 && !containsMethod(ob.methodOverrideFrom, r.Method) {
+				h.ServeHTTP(w, r)
+				return
+			}
 			mo := r.FormValue(ob.methodOverrideFormKey)
 			if mo == "" {
 				mo = r.Header.Get(MethodOverrideHeader)
 			}
-			switch mo {
-			case "": // do nothing
-			case "HEAD", "GET", "POST", "PUT", "PATCH", "DELETE", "TRACE", "OPTIONS":
+			mo = strings.ToUpper(mo)
+			switch {
+			case mo == "": // do nothing
+			case containsMethod(ob.methodOverrideMethods, mo):
 				r.Method = mo
 			default:
 				// not sure if an error is here really needed ...
@@ -127,6 +142,15 @@ func WithXHTTPMethodOverride(opts ...Option) Middleware {
 	}
 }
 
+func containsMethod(methods []string, m string) bool {
+	for _, mm := range methods {
+		if mm == m {
+			return true
+		}
+	}
+	return false
+}
+
 // WithCloseNotify returns a net.Handler cancelling the context when the client
 // connection close unexpectedly. Supported options are: SetLogger().
 func WithCloseNotify(opts ...Option) Middleware {
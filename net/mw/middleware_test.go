@@ -158,3 +158,33 @@ func TestWithXHTTPMethodOverrideNone(t *testing.T) {
 	}
 	finalCH.ServeHTTP(w, r)
 }
+
+func TestWithXHTTPMethodOverride_RouteGroup(t *testing.T) {
+	override := mw.WithXHTTPMethodOverride(
+		mw.SetMethodOverrideFrom("POST"),
+		mw.SetMethodOverrideMethods("PUT", "DELETE"),
+	)
+
+	tests := []struct {
+		method   string
+		override string
+		want     string
+	}{
+		{"POST", "DELETE", "DELETE"},
+		{"POST", "delete", "DELETE"},
+		{"POST", "PUT", "PUT"},
+		{"POST", "PATCH", "POST"}, // not allowed
+		{"POST", "", "POST"},
+		{"GET", "DELETE", "GET"}, // only POST can be overridden
+	}
+	for i, test := range tests {
+		var have string
+		h := override(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			have = r.Method
+		}))
+		r := httptest.NewRequest(test.method, "http://corestore.io/api/product/3452", nil)
+		r.Header.Set(mw.MethodOverrideHeader, test.override)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
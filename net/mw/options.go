@@ -19,6 +19,10 @@ import "github.com/corestoreio/log"
 type optionBox struct {
 	log                   log.Logger
 	methodOverrideFormKey string
+	methodOverrideFrom    []string
+	methodOverrideMethods []string
+	reporter              Reporter
+	recoveryHandler       ErrorHandler
 }
 
 // Option contains multiple functional options for middlewares.
@@ -28,6 +32,8 @@ func newOptionBox(opts ...Option) *optionBox {
 	ob := &optionBox{
 		log: log.BlackHole{}, // disabled info and debug logging
 		methodOverrideFormKey: MethodOverrideFormKey,
+		methodOverrideMethods: []string{"HEAD", "GET", "POST", "PUT", "PATCH", "DELETE", "TRACE", "OPTIONS"},
+		recoveryHandler:       defaultRecoveryHandler,
 	}
 	for _, o := range opts {
 		if o != nil {
@@ -50,3 +56,38 @@ func SetMethodOverrideFormKey(k string) Option {
 		ob.methodOverrideFormKey = k
 	}
 }
+
+// SetMethodOverrideFrom restricts the method override to requests with one of
+// the provided methods, for example POST. Default: all methods can be
+// overridden.
+func SetMethodOverrideFrom(methods ...string) Option {
+	return func(ob *optionBox) {
+		ob.methodOverrideFrom = methods
+	}
+}
+
+// SetMethodOverrideMethods sets the methods to which a request can be
+// changed. Default: HEAD, GET, POST, PUT, PATCH, DELETE, TRACE and OPTIONS.
+func SetMethodOverrideMethods(methods ...string) Option {
+	return func(ob *optionBox) {
+		ob.methodOverrideMethods = methods
+	}
+}
+
+// SetReporter sets a Reporter which receives the recovered panics of
+// WithRecovery.
+func SetReporter(r Reporter) Option {
+	return func(ob *optionBox) {
+		ob.reporter = r
+	}
+}
+
+// SetRecoveryHandler sets a custom handler which writes the response after a
+// panic has been recovered in WithRecovery. The error has a Fatal behaviour.
+func SetRecoveryHandler(eh ErrorHandler) Option {
+	return func(ob *optionBox) {
+		if eh != nil {
+			ob.recoveryHandler = eh
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mw
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+	loghttp "github.com/corestoreio/log/http"
+)
+
+// PanicReport contains the details of a recovered panic.
+type PanicReport struct {
+	// Err the recovered value. Has a Fatal error behaviour.
+	Err error
+	// Stack trace of the panicking goroutine.
+	Stack   []byte
+	Request *http.Request
+	// WebsiteID and StoreID are only valid if HasScope is true. They are
+	// extracted from the request context, see scope.FromContext.
+	WebsiteID int64
+	StoreID   int64
+	HasScope  bool
+}
+
+// Reporter sends a recovered panic to an error tracking service, for example
+// Sentry. The Report function gets called synchronously in the request
+// goroutine and should return fast.
+type Reporter interface {
+	Report(ctx context.Context, pr PanicReport)
+}
+
+// ReporterFunc is an adapter to use a function as Reporter.
+type ReporterFunc func(ctx context.Context, pr PanicReport)
+
+// Report calls rf(ctx, pr).
+func (rf ReporterFunc) Report(ctx context.Context, pr PanicReport) {
+	rf(ctx, pr)
+}
+
+// defaultRecoveryHandler writes only the status text to avoid leaking
+// internals.
+func defaultRecoveryHandler(_ error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	})
+}
+
+// WithRecovery returns a middleware which recovers from panics in the next
+// handlers. The panic gets converted into an error with a Fatal behaviour,
+// logged together with the stack trace and passed to the optional Reporter.
+// The default response is a 500 Internal Server Error. A panic with the value
+// http.ErrAbortHandler gets re-panicked to abort the response.
+// Supported options are: SetLogger(), SetReporter() and
+// SetRecoveryHandler().
+func WithRecovery(opts ...Option) Middleware {
+	ob := newOptionBox(opts...)
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				pr := PanicReport{
+					Err:     panicToError(rec),
+					Stack:   debug.Stack(),
+					Request: r,
+				}
+				pr.WebsiteID, pr.StoreID, pr.HasScope = scope.FromContext(r.Context())
+
+				if ob.log.IsInfo() {
+					ob.log.Info("mw.WithRecovery.recover",
+						log.Err(pr.Err),
+						log.String("stack", string(pr.Stack)),
+						log.Int64("website_id", pr.WebsiteID),
+						log.Int64("store_id", pr.StoreID),
+						loghttp.Request("request", r),
+					)
+				}
+				if ob.reporter != nil {
+					ob.reporter.Report(r.Context(), pr)
+				}
+				ob.recoveryHandler(pr.Err).ServeHTTP(w, r)
+			}()
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+func panicToError(rec interface{}) error {
+	switch v := rec.(type) {
+	case error:
+		return errors.NewFatal(v, "[mw] Recovered from panic")
+	case fmt.Stringer:
+		return errors.NewFatalf("[mw] Recovered from panic: %s", v.String())
+	default:
+		return errors.NewFatalf("[mw] Recovered from panic: %v", v)
+	}
+}
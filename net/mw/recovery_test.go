@@ -0,0 +1,107 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mw_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func panicHandler(v interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(v)
+	})
+}
+
+func TestWithRecovery(t *testing.T) {
+
+	t.Run("no panic", func(t *testing.T) {
+		var called bool
+		h := mw.WithRecovery(mw.SetReporter(mw.ReporterFunc(func(_ context.Context, _ mw.PanicReport) {
+			called = true
+		})))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		assert.Exactly(t, http.StatusTeapot, rec.Code)
+		assert.False(t, called, "Reporter should not be called")
+	})
+
+	t.Run("default 500 without details", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mw.WithRecovery()(panicHandler("secret database password")).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		assert.Exactly(t, http.StatusInternalServerError, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "secret")
+		assert.Contains(t, rec.Body.String(), http.StatusText(http.StatusInternalServerError))
+	})
+
+	t.Run("reporter with scope", func(t *testing.T) {
+		var pr mw.PanicReport
+		h := mw.WithRecovery(mw.SetReporter(mw.ReporterFunc(func(_ context.Context, have mw.PanicReport) {
+			pr = have
+		})))(panicHandler(errors.New("Upps")))
+
+		req := httptest.NewRequest("GET", "/checkout", nil)
+		req = req.WithContext(scope.WithContext(req.Context(), 1, 4))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		assert.Exactly(t, http.StatusInternalServerError, rec.Code)
+		assert.True(t, errors.IsFatal(pr.Err), "%+v", pr.Err)
+		assert.Contains(t, pr.Err.Error(), "Upps")
+		assert.Contains(t, string(pr.Stack), "recovery_test.go")
+		assert.Exactly(t, "/checkout", pr.Request.URL.Path)
+		assert.True(t, pr.HasScope)
+		assert.Exactly(t, int64(1), pr.WebsiteID)
+		assert.Exactly(t, int64(4), pr.StoreID)
+	})
+
+	t.Run("reporter without scope", func(t *testing.T) {
+		var pr mw.PanicReport
+		h := mw.WithRecovery(mw.SetReporter(mw.ReporterFunc(func(_ context.Context, have mw.PanicReport) {
+			pr = have
+		})))(panicHandler(42))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		assert.False(t, pr.HasScope)
+		assert.Contains(t, pr.Err.Error(), "42")
+	})
+
+	t.Run("custom recovery handler", func(t *testing.T) {
+		var haveErr error
+		h := mw.WithRecovery(mw.SetRecoveryHandler(func(err error) http.Handler {
+			haveErr = err
+			return mw.ErrorWithStatusCode(http.StatusServiceUnavailable)(err)
+		}))(panicHandler("boom"))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		assert.Exactly(t, http.StatusServiceUnavailable, rec.Code)
+		assert.True(t, errors.IsFatal(haveErr), "%+v", haveErr)
+	})
+
+	t.Run("abort handler re-panics", func(t *testing.T) {
+		h := mw.WithRecovery()(panicHandler(http.ErrAbortHandler))
+		assert.Panics(t, func() {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		})
+	})
+}
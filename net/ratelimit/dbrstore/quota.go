@@ -0,0 +1,163 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dbrstore persists the quota accounting of the ratelimit package in
+// MySQL tables.
+package dbrstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/corestoreio/csfw/net/ratelimit"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+)
+
+// Default names of the tables used by QuotaStore.
+const (
+	TableQuotaUsage = "ratelimit_quota_usage"
+	TableQuotaLimit = "ratelimit_quota_limit"
+)
+
+var _ ratelimit.QuotaStore = (*QuotaStore)(nil)
+
+// QuotaStore persists the usage per key and window and the custom limits in
+// two MySQL tables. The tables get created with CreateTables. Expired windows
+// stay in the usage table until DeleteExpired gets called, for example by a
+// cron job. QuotaStore is safe for concurrent use.
+type QuotaStore struct {
+	DB interface {
+		dbr.Execer
+		dbr.QueryRower
+	}
+	// UsageTable defaults to TableQuotaUsage.
+	UsageTable string
+	// LimitTable defaults to TableQuotaLimit.
+	LimitTable string
+}
+
+// NewQuotaStore creates a new MySQL backed ratelimit.QuotaStore with the
+// default table names.
+func NewQuotaStore(db *sql.DB) *QuotaStore {
+	return &QuotaStore{
+		DB:         db,
+		UsageTable: TableQuotaUsage,
+		LimitTable: TableQuotaLimit,
+	}
+}
+
+func (qs *QuotaStore) usageTable() string {
+	if qs.UsageTable == "" {
+		return dbr.Quoter.Quote(TableQuotaUsage)
+	}
+	return dbr.Quoter.Quote(qs.UsageTable)
+}
+
+func (qs *QuotaStore) limitTable() string {
+	if qs.LimitTable == "" {
+		return dbr.Quoter.Quote(TableQuotaLimit)
+	}
+	return dbr.Quoter.Quote(qs.LimitTable)
+}
+
+// CreateTables creates the usage and the limit table if they do not exist.
+func (qs *QuotaStore) CreateTables(ctx context.Context) error {
+	if _, err := qs.DB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+qs.usageTable()+` (
+  quota_key varchar(255) NOT NULL,
+  window_start datetime NOT NULL,
+  used bigint(20) NOT NULL DEFAULT 0,
+  expires_at datetime NOT NULL,
+  PRIMARY KEY (quota_key,window_start),
+  KEY IDX_RATELIMIT_QUOTA_USAGE_EXPIRES_AT (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`); err != nil {
+		return errors.Wrapf(err, "[dbrstore] QuotaStore.CreateTables %s", qs.usageTable())
+	}
+	_, err := qs.DB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+qs.limitTable()+` (
+  quota_key varchar(255) NOT NULL,
+  quota_limit bigint(20) NOT NULL,
+  PRIMARY KEY (quota_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`)
+	return errors.Wrapf(err, "[dbrstore] QuotaStore.CreateTables %s", qs.limitTable())
+}
+
+// Increment inserts or updates the usage row. The new usage gets returned via
+// LAST_INSERT_ID, so concurrent requests never see the same value.
+func (qs *QuotaStore) Increment(ctx context.Context, key string, windowStart time.Time, n int64, expires time.Time) (int64, error) {
+	res, err := qs.DB.ExecContext(ctx, "INSERT INTO "+qs.usageTable()+
+		" (`quota_key`,`window_start`,`used`,`expires_at`) VALUES (?,?,LAST_INSERT_ID(?),?)"+
+		" ON DUPLICATE KEY UPDATE `used`=LAST_INSERT_ID(`used`+?), `expires_at`=VALUES(`expires_at`)",
+		key, windowStart.UTC(), n, expires.UTC(), n)
+	if err != nil {
+		return 0, errors.Wrapf(err, "[dbrstore] QuotaStore.Increment key %q", key)
+	}
+	used, err := res.LastInsertId()
+	return used, errors.Wrapf(err, "[dbrstore] QuotaStore.Increment.LastInsertId key %q", key)
+}
+
+// Usage returns zero if no row exists.
+func (qs *QuotaStore) Usage(ctx context.Context, key string, windowStart time.Time) (int64, error) {
+	var used int64
+	err := qs.DB.QueryRowContext(ctx, "SELECT `used` FROM "+qs.usageTable()+" WHERE `quota_key`=? AND `window_start`=?",
+		key, windowStart.UTC()).Scan(&used)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return used, errors.Wrapf(err, "[dbrstore] QuotaStore.Usage key %q", key)
+}
+
+// ResetUsage deletes the usage row.
+func (qs *QuotaStore) ResetUsage(ctx context.Context, key string, windowStart time.Time) error {
+	_, err := qs.DB.ExecContext(ctx, "DELETE FROM "+qs.usageTable()+" WHERE `quota_key`=? AND `window_start`=?",
+		key, windowStart.UTC())
+	return errors.Wrapf(err, "[dbrstore] QuotaStore.ResetUsage key %q", key)
+}
+
+// Limit reads the custom limit.
+func (qs *QuotaStore) Limit(ctx context.Context, key string) (int64, bool, error) {
+	var limit int64
+	err := qs.DB.QueryRowContext(ctx, "SELECT `quota_limit` FROM "+qs.limitTable()+" WHERE `quota_key`=?", key).Scan(&limit)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, errors.Wrapf(err, "[dbrstore] QuotaStore.Limit key %q", key)
+	}
+	return limit, true, nil
+}
+
+// SetLimit inserts or updates the custom limit or deletes it if limit is
+// negative.
+func (qs *QuotaStore) SetLimit(ctx context.Context, key string, limit int64) error {
+	var err error
+	if limit < 0 {
+		_, err = qs.DB.ExecContext(ctx, "DELETE FROM "+qs.limitTable()+" WHERE `quota_key`=?", key)
+	} else {
+		_, err = qs.DB.ExecContext(ctx, "INSERT INTO "+qs.limitTable()+
+			" (`quota_key`,`quota_limit`) VALUES (?,?) ON DUPLICATE KEY UPDATE `quota_limit`=VALUES(`quota_limit`)", key, limit)
+	}
+	return errors.Wrapf(err, "[dbrstore] QuotaStore.SetLimit key %q", key)
+}
+
+// DeleteExpired removes all usage rows whose window has ended before now and
+// returns the number of deleted rows.
+func (qs *QuotaStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res, err := qs.DB.ExecContext(ctx, "DELETE FROM "+qs.usageTable()+" WHERE `expires_at`<?", now.UTC())
+	if err != nil {
+		return 0, errors.Wrap(err, "[dbrstore] QuotaStore.DeleteExpired")
+	}
+	n, err := res.RowsAffected()
+	return n, errors.Wrap(err, "[dbrstore] QuotaStore.DeleteExpired.RowsAffected")
+}
@@ -0,0 +1,147 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbrstore_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/net/ratelimit"
+	"github.com/corestoreio/csfw/net/ratelimit/dbrstore"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() {
+		mock.ExpectClose()
+		assert.NoError(t, db.Close())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	}()
+
+	qs := dbrstore.NewQuotaStore(db)
+	ctx := context.TODO()
+	start := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	t.Run("CreateTables", func(t *testing.T) {
+		mock.ExpectExec(cstesting.SQLMockQuoteMeta("CREATE TABLE IF NOT EXISTS `ratelimit_quota_usage`")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(cstesting.SQLMockQuoteMeta("CREATE TABLE IF NOT EXISTS `ratelimit_quota_limit`")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		require.NoError(t, qs.CreateTables(ctx))
+	})
+
+	t.Run("Increment", func(t *testing.T) {
+		mock.ExpectExec(cstesting.SQLMockQuoteMeta("INSERT INTO `ratelimit_quota_usage` (`quota_key`,`window_start`,`used`,`expires_at`) VALUES (?,?,LAST_INSERT_ID(?),?) ON DUPLICATE KEY UPDATE `used`=LAST_INSERT_ID(`used`+?), `expires_at`=VALUES(`expires_at`)")).
+			WithArgs("client1", start, 1, end, 1).
+			WillReturnResult(sqlmock.NewResult(7, 2))
+		used, err := qs.Increment(ctx, "client1", start, 1, end)
+		require.NoError(t, err)
+		assert.Exactly(t, int64(7), used)
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		mock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT `used` FROM `ratelimit_quota_usage` WHERE `quota_key`=? AND `window_start`=?")).
+			WithArgs("client1", start).
+			WillReturnRows(sqlmock.NewRows([]string{"used"}).AddRow(7))
+		used, err := qs.Usage(ctx, "client1", start)
+		require.NoError(t, err)
+		assert.Exactly(t, int64(7), used)
+
+		mock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT `used` FROM `ratelimit_quota_usage`")).
+			WillReturnError(sql.ErrNoRows)
+		used, err = qs.Usage(ctx, "client2", start)
+		require.NoError(t, err)
+		assert.Exactly(t, int64(0), used)
+	})
+
+	t.Run("ResetUsage", func(t *testing.T) {
+		mock.ExpectExec(cstesting.SQLMockQuoteMeta("DELETE FROM `ratelimit_quota_usage` WHERE `quota_key`=? AND `window_start`=?")).
+			WithArgs("client1", start).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, qs.ResetUsage(ctx, "client1", start))
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		mock.ExpectExec(cstesting.SQLMockQuoteMeta("INSERT INTO `ratelimit_quota_limit` (`quota_key`,`quota_limit`) VALUES (?,?) ON DUPLICATE KEY UPDATE `quota_limit`=VALUES(`quota_limit`)")).
+			WithArgs("client1", 500).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, qs.SetLimit(ctx, "client1", 500))
+
+		mock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT `quota_limit` FROM `ratelimit_quota_limit` WHERE `quota_key`=?")).
+			WithArgs("client1").
+			WillReturnRows(sqlmock.NewRows([]string{"quota_limit"}).AddRow(500))
+		limit, ok, err := qs.Limit(ctx, "client1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Exactly(t, int64(500), limit)
+
+		mock.ExpectExec(cstesting.SQLMockQuoteMeta("DELETE FROM `ratelimit_quota_limit` WHERE `quota_key`=?")).
+			WithArgs("client1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, qs.SetLimit(ctx, "client1", -1))
+
+		mock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT `quota_limit` FROM `ratelimit_quota_limit`")).
+			WillReturnError(sql.ErrNoRows)
+		_, ok, err = qs.Limit(ctx, "client1")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("DeleteExpired", func(t *testing.T) {
+		mock.ExpectExec(cstesting.SQLMockQuoteMeta("DELETE FROM `ratelimit_quota_usage` WHERE `expires_at`<?")).
+			WithArgs(end).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		n, err := qs.DeleteExpired(ctx, end)
+		require.NoError(t, err)
+		assert.Exactly(t, int64(3), n)
+	})
+}
+
+func TestQuotaStore_Quota(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	q := &ratelimit.Quota{
+		Window:   ratelimit.QuotaDaily,
+		Limit:    10,
+		Store:    dbrstore.NewQuotaStore(db),
+		VaryByer: ratelimit.JWTClaim("client_id"),
+	}
+	require.NoError(t, q.Validate())
+
+	mock.ExpectExec("INSERT INTO `ratelimit_quota_usage`").
+		WillReturnResult(sqlmock.NewResult(11, 2))
+	mock.ExpectQuery("SELECT `quota_limit` FROM `ratelimit_quota_limit`").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO `ratelimit_quota_usage`").
+		WithArgs("client1", sqlmock.AnyArg(), -1, sqlmock.AnyArg(), -1).
+		WillReturnResult(sqlmock.NewResult(10, 2))
+
+	limited, qr, err := q.Take(context.TODO(), "client1", 1)
+	require.NoError(t, err)
+	assert.True(t, limited)
+	assert.Exactly(t, int64(10), qr.Used)
+	assert.Exactly(t, int64(0), qr.Remaining)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -19,4 +19,9 @@
 // and their storage possibilities. Both packages should be used as either
 // functional options to a ratelimit service or as functional option factories
 // to the backend type.
+//
+// Additionally to the rate limiting a Quota accounts the requests per key,
+// e.g. a JWT claim, within daily or monthly windows. The usage gets persisted
+// in the QuotaMemStore, in Redis via `redigostore.QuotaStore` or in MySQL via
+// `dbrstore.QuotaStore`.
 package ratelimit
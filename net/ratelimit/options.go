@@ -56,6 +56,25 @@ func WithRateLimiter(rl throttled.RateLimiter, scopeIDs ...scope.TypeID) Option
 	}
 }
 
+// WithQuota enables the quota accounting for a specific scope. The quota gets
+// checked by the middleware Service.WithQuota. Error behaviour: NotValid.
+//		s := MustNewService(WithQuota(&Quota{
+//			Window:   QuotaMonthly,
+//			Limit:    10000,
+//			Store:    NewQuotaMemStore(),
+//			VaryByer: JWTClaim("client_id"),
+//		}, scope.MakeTypeID(scope.Website, 1)))
+func WithQuota(q *Quota, scopeIDs ...scope.TypeID) Option {
+	return func(s *Service) error {
+		if err := q.Validate(); err != nil {
+			return errors.Wrap(err, "[ratelimit] WithQuota")
+		}
+		sc := s.findScopedConfig(scopeIDs...)
+		sc.Quota = q
+		return s.updateScopedConfig(sc)
+	}
+}
+
 // WithDeniedHandler sets a custom denied handler for a specific scope. The
 // default denied handler returns a simple:
 //		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
@@ -0,0 +1,293 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/errors"
+)
+
+// QuotaWindow defines the calendar period after which the used quota of a key
+// resets. All windows are calculated in UTC.
+type QuotaWindow uint8
+
+// QuotaDaily resets at midnight UTC and QuotaMonthly on the first day of each
+// month.
+const (
+	QuotaDaily QuotaWindow = iota + 1
+	QuotaMonthly
+)
+
+// String returns the name of the window.
+func (qw QuotaWindow) String() string {
+	switch qw {
+	case QuotaDaily:
+		return "daily"
+	case QuotaMonthly:
+		return "monthly"
+	}
+	return "unknown"
+}
+
+// Bounds returns the start and the end of the window containing t.
+func (qw QuotaWindow) Bounds(t time.Time) (start, end time.Time) {
+	t = t.UTC()
+	switch qw {
+	case QuotaDaily:
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(0, 0, 1)
+	case QuotaMonthly:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(0, 1, 0)
+	}
+	return
+}
+
+// QuotaStore persists the used quota per key and window and the custom limits
+// per key. Implementations must be safe for concurrent use. See
+// QuotaMemStore, redigostore.QuotaStore and dbrstore.QuotaStore.
+type QuotaStore interface {
+	// Increment adds n, which can be negative, to the usage of key in the
+	// window starting at windowStart and returns the new usage. The usage is
+	// not needed after expires and may be deleted.
+	Increment(ctx context.Context, key string, windowStart time.Time, n int64, expires time.Time) (int64, error)
+	// Usage returns the usage of key in the window starting at windowStart.
+	Usage(ctx context.Context, key string, windowStart time.Time) (int64, error)
+	// ResetUsage sets the usage of key in the window to zero.
+	ResetUsage(ctx context.Context, key string, windowStart time.Time) error
+	// Limit returns the custom limit of key. If ok is false, no custom limit
+	// has been set and the default limit applies.
+	Limit(ctx context.Context, key string) (limit int64, ok bool, err error)
+	// SetLimit sets the custom limit of key. A negative limit removes the
+	// custom limit.
+	SetLimit(ctx context.Context, key string, limit int64) error
+}
+
+// Quota accounts the requests per key, for example the API client ID of a
+// JWT claim, within a daily or monthly window. Other than the RateLimiter,
+// which smooths the request rate, a Quota limits the total amount of requests
+// of a client within the window. Use WithQuota to enable it for a scope and
+// Service.WithQuota as middleware.
+type Quota struct {
+	// Window defaults to QuotaDaily.
+	Window QuotaWindow
+	// Limit default maximum requests per key and window. Can be overwritten
+	// per key with QuotaStore.SetLimit or the AdminHandler.
+	Limit int64
+	// Store persists the usage. Required.
+	Store QuotaStore
+	// VaryByer generates the key of a request, usually a JWTClaim. Requests
+	// with an empty key are not accounted. Required.
+	VaryByer
+	// now returns the current time, used for testing.
+	now func() time.Time
+}
+
+// QuotaResult represents the state of a quota for a key.
+type QuotaResult struct {
+	Key       string    `json:"key"`
+	Window    string    `json:"window"`
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	// ResetAfter duration until the window resets.
+	ResetAfter time.Duration `json:"-"`
+}
+
+// Validate checks if all required fields have been set. Error behaviour:
+// NotValid.
+func (q *Quota) Validate() error {
+	switch {
+	case q == nil:
+		return errors.NewNotValidf("[ratelimit] Quota cannot be nil")
+	case q.Store == nil:
+		return errors.NewNotValidf("[ratelimit] Quota.Store cannot be nil")
+	case q.VaryByer == nil:
+		return errors.NewNotValidf("[ratelimit] Quota.VaryByer cannot be nil")
+	case q.Limit < 0:
+		return errors.NewNotValidf("[ratelimit] Quota.Limit %d cannot be negative", q.Limit)
+	case q.Window > QuotaMonthly:
+		return errors.NewNotValidf("[ratelimit] Unknown QuotaWindow %d", q.Window)
+	}
+	return nil
+}
+
+func (q *Quota) window() QuotaWindow {
+	if q.Window == 0 {
+		return QuotaDaily
+	}
+	return q.Window
+}
+
+func (q *Quota) clock() time.Time {
+	if q.now != nil {
+		return q.now()
+	}
+	return time.Now()
+}
+
+func (q *Quota) result(ctx context.Context, key string, used int64, t time.Time) (QuotaResult, error) {
+	_, end := q.window().Bounds(t)
+
+	limit, ok, err := q.Store.Limit(ctx, key)
+	if err != nil {
+		return QuotaResult{}, errors.Wrapf(err, "[ratelimit] Quota.Store.Limit for key %q", key)
+	}
+	if !ok {
+		limit = q.Limit
+	}
+	qr := QuotaResult{
+		Key:        key,
+		Window:     q.window().String(),
+		Limit:      limit,
+		Used:       used,
+		Reset:      end,
+		ResetAfter: end.Sub(t),
+	}
+	if qr.Remaining = limit - used; qr.Remaining < 0 {
+		qr.Remaining = 0
+	}
+	return qr, nil
+}
+
+// Take consumes n units of the quota of key. If the quota has been exceeded,
+// limited is true and the usage stays unchanged.
+func (q *Quota) Take(ctx context.Context, key string, n int64) (limited bool, _ QuotaResult, err error) {
+	t := q.clock()
+	start, end := q.window().Bounds(t)
+	used, err := q.Store.Increment(ctx, key, start, n, end)
+	if err != nil {
+		return false, QuotaResult{}, errors.Wrapf(err, "[ratelimit] Quota.Store.Increment for key %q", key)
+	}
+	qr, err := q.result(ctx, key, used, t)
+	if err != nil {
+		return false, QuotaResult{}, errors.Wrap(err, "[ratelimit] Quota.Take")
+	}
+	if used <= qr.Limit {
+		return false, qr, nil
+	}
+
+	// denied requests do not count
+	if qr.Used, err = q.Store.Increment(ctx, key, start, -n, end); err != nil {
+		return true, qr, errors.Wrapf(err, "[ratelimit] Quota.Store.Increment for key %q", key)
+	}
+	return true, qr, nil
+}
+
+// Status returns the current state of the quota of key.
+func (q *Quota) Status(ctx context.Context, key string) (QuotaResult, error) {
+	t := q.clock()
+	start, _ := q.window().Bounds(t)
+	used, err := q.Store.Usage(ctx, key, start)
+	if err != nil {
+		return QuotaResult{}, errors.Wrapf(err, "[ratelimit] Quota.Store.Usage for key %q", key)
+	}
+	return q.result(ctx, key, used, t)
+}
+
+// Reset sets the used quota of key in the current window to zero.
+func (q *Quota) Reset(ctx context.Context, key string) error {
+	start, _ := q.window().Bounds(q.clock())
+	return errors.Wrapf(q.Store.ResetUsage(ctx, key, start), "[ratelimit] Quota.Store.ResetUsage for key %q", key)
+}
+
+// SetLimit overwrites the default limit for key. A negative limit restores
+// the default limit.
+func (q *Quota) SetLimit(ctx context.Context, key string, limit int64) error {
+	return errors.Wrapf(q.Store.SetLimit(ctx, key, limit), "[ratelimit] Quota.Store.SetLimit for key %q", key)
+}
+
+// QuotaMemStore an in-memory QuotaStore for testing or for single server
+// deployments where a loss of the usage after a restart is acceptable.
+type QuotaMemStore struct {
+	mu     sync.Mutex
+	usage  map[quotaMemKey]quotaMemUsage
+	limits map[string]int64
+}
+
+type quotaMemKey struct {
+	key   string
+	start int64
+}
+
+type quotaMemUsage struct {
+	used    int64
+	expires time.Time
+}
+
+// NewQuotaMemStore creates a new in-memory QuotaStore.
+func NewQuotaMemStore() *QuotaMemStore {
+	return &QuotaMemStore{
+		usage:  make(map[quotaMemKey]quotaMemUsage),
+		limits: make(map[string]int64),
+	}
+}
+
+// Increment see QuotaStore. Removes all expired windows.
+func (qs *QuotaMemStore) Increment(_ context.Context, key string, windowStart time.Time, n int64, expires time.Time) (int64, error) {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	now := time.Now()
+	for k, u := range qs.usage {
+		if u.expires.Before(now) {
+			delete(qs.usage, k)
+		}
+	}
+	mk := quotaMemKey{key: key, start: windowStart.Unix()}
+	u := qs.usage[mk]
+	u.used += n
+	u.expires = expires
+	qs.usage[mk] = u
+	return u.used, nil
+}
+
+// Usage see QuotaStore.
+func (qs *QuotaMemStore) Usage(_ context.Context, key string, windowStart time.Time) (int64, error) {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	return qs.usage[quotaMemKey{key: key, start: windowStart.Unix()}].used, nil
+}
+
+// ResetUsage see QuotaStore.
+func (qs *QuotaMemStore) ResetUsage(_ context.Context, key string, windowStart time.Time) error {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	delete(qs.usage, quotaMemKey{key: key, start: windowStart.Unix()})
+	return nil
+}
+
+// Limit see QuotaStore.
+func (qs *QuotaMemStore) Limit(_ context.Context, key string) (int64, bool, error) {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	l, ok := qs.limits[key]
+	return l, ok, nil
+}
+
+// SetLimit see QuotaStore.
+func (qs *QuotaMemStore) SetLimit(_ context.Context, key string, limit int64) error {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+	if limit < 0 {
+		delete(qs.limits, key)
+		return nil
+	}
+	qs.limits[key] = limit
+	return nil
+}
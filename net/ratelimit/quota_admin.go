@@ -0,0 +1,73 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// AdminHandler returns an HTTP API to inspect and to adjust the quota of a
+// key. The key gets read from the query parameter "key" and each successful
+// request responds with the QuotaResult as JSON.
+//		GET    ?key=client1           returns the current state
+//		PUT    ?key=client1&limit=500 sets a custom limit, -1 restores the default
+//		DELETE ?key=client1           resets the used quota of the current window
+// The handler has no own access control and must be wrapped with an
+// authentication middleware.
+func (q *Quota) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		if key == "" {
+			http.Error(w, "Missing query parameter key", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			limit, err := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid query parameter limit", http.StatusBadRequest)
+				return
+			}
+			if err := q.SetLimit(ctx, key, limit); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		case http.MethodDelete:
+			if err := q.Reset(ctx, key); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		qr, err := q.Status(ctx, key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(qr); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/ratelimit"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ ratelimit.QuotaStore = (*ratelimit.QuotaMemStore)(nil)
+var _ ratelimit.VaryByer = ratelimit.JWTClaim("")
+
+func TestQuotaWindow_Bounds(t *testing.T) {
+	t.Parallel()
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	tests := []struct {
+		window     ratelimit.QuotaWindow
+		now        time.Time
+		start, end time.Time
+	}{
+		{ratelimit.QuotaDaily, time.Date(2017, 3, 15, 13, 4, 5, 0, time.UTC),
+			time.Date(2017, 3, 15, 0, 0, 0, 0, time.UTC), time.Date(2017, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{ratelimit.QuotaDaily, time.Date(2017, 12, 31, 23, 59, 59, 0, time.UTC),
+			time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ratelimit.QuotaDaily, time.Date(2017, 3, 15, 0, 30, 0, 0, berlin),
+			time.Date(2017, 3, 14, 0, 0, 0, 0, time.UTC), time.Date(2017, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{ratelimit.QuotaMonthly, time.Date(2017, 2, 28, 13, 4, 5, 0, time.UTC),
+			time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{ratelimit.QuotaMonthly, time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for i, test := range tests {
+		start, end := test.window.Bounds(test.now)
+		assert.Exactly(t, test.start, start, "Index %d", i)
+		assert.Exactly(t, test.end, end, "Index %d", i)
+	}
+	assert.Exactly(t, "daily", ratelimit.QuotaDaily.String())
+	assert.Exactly(t, "monthly", ratelimit.QuotaMonthly.String())
+}
+
+func TestQuota_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []*ratelimit.Quota{
+		nil,
+		{VaryByer: pathGetter{}},
+		{Store: ratelimit.NewQuotaMemStore()},
+		{Store: ratelimit.NewQuotaMemStore(), VaryByer: pathGetter{}, Limit: -1},
+		{Store: ratelimit.NewQuotaMemStore(), VaryByer: pathGetter{}, Window: 3},
+	}
+	for i, q := range tests {
+		err := q.Validate()
+		assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+	}
+	_, err := ratelimit.New(ratelimit.WithQuota(&ratelimit.Quota{}, scope.DefaultTypeID))
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestQuota_Take(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+	q := &ratelimit.Quota{
+		Window:   ratelimit.QuotaMonthly,
+		Limit:    3,
+		Store:    ratelimit.NewQuotaMemStore(),
+		VaryByer: pathGetter{},
+	}
+	for i := int64(1); i <= 3; i++ {
+		limited, qr, err := q.Take(ctx, "client1", 1)
+		require.NoError(t, err)
+		assert.False(t, limited, "Request %d", i)
+		assert.Exactly(t, i, qr.Used)
+		assert.Exactly(t, 3-i, qr.Remaining)
+		assert.Exactly(t, "monthly", qr.Window)
+		assert.True(t, qr.ResetAfter > 0 && qr.Reset.After(time.Now()))
+	}
+
+	limited, qr, err := q.Take(ctx, "client1", 1)
+	require.NoError(t, err)
+	assert.True(t, limited)
+	assert.Exactly(t, int64(3), qr.Used, "Denied requests must not be counted")
+	assert.Exactly(t, int64(0), qr.Remaining)
+
+	// other keys have their own quota
+	limited, qr, err = q.Take(ctx, "client2", 2)
+	require.NoError(t, err)
+	assert.False(t, limited)
+	assert.Exactly(t, int64(1), qr.Remaining)
+
+	require.NoError(t, q.SetLimit(ctx, "client1", 5))
+	limited, qr, err = q.Take(ctx, "client1", 1)
+	require.NoError(t, err)
+	assert.False(t, limited)
+	assert.Exactly(t, int64(5), qr.Limit)
+	assert.Exactly(t, int64(1), qr.Remaining)
+
+	require.NoError(t, q.Reset(ctx, "client1"))
+	require.NoError(t, q.SetLimit(ctx, "client1", -1))
+	qr, err = q.Status(ctx, "client1")
+	require.NoError(t, err)
+	assert.Exactly(t, int64(0), qr.Used)
+	assert.Exactly(t, int64(3), qr.Limit)
+	assert.Exactly(t, int64(3), qr.Remaining)
+}
+
+func TestService_WithQuota(t *testing.T) {
+	srv, err := ratelimit.New(
+		ratelimit.WithRootConfig(cfgmock.NewService()),
+		ratelimit.WithVaryBy(pathGetter{}, scope.DefaultTypeID),
+		ratelimit.WithRateLimiter(stubLimiter{}, scope.DefaultTypeID),
+		ratelimit.WithQuota(&ratelimit.Quota{
+			Limit:    2,
+			Store:    ratelimit.NewQuotaMemStore(),
+			VaryByer: pathGetter{},
+		}, scope.DefaultTypeID),
+	)
+	require.NoError(t, err)
+
+	handler := srv.WithQuota(finalHandler(t))
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/"+path, nil)
+		req = req.WithContext(scope.WithContext(req.Context(), 1, 1))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, want := range []string{"1", "0"} {
+		rec := serve("client1")
+		assert.Exactly(t, http.StatusOK, rec.Code, "Request %d", i)
+		assert.Exactly(t, "2", rec.Header().Get("X-Ratelimit-Limit"), "Request %d", i)
+		assert.Exactly(t, want, rec.Header().Get("X-Ratelimit-Remaining"), "Request %d", i)
+		reset, err := strconv.Atoi(rec.Header().Get("X-Ratelimit-Reset"))
+		assert.NoError(t, err)
+		assert.True(t, reset > 0 && reset <= 86400, "Reset %d", reset)
+		assert.Empty(t, rec.Header().Get("Retry-After"))
+	}
+
+	rec := serve("client1")
+	assert.Exactly(t, http.StatusTooManyRequests, rec.Code)
+	assert.Exactly(t, "0", rec.Header().Get("X-Ratelimit-Remaining"))
+	assert.Exactly(t, rec.Header().Get("X-Ratelimit-Reset"), rec.Header().Get("Retry-After"))
+
+	// empty key passes through without accounting
+	rec = serve("")
+	assert.Exactly(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("X-Ratelimit-Limit"))
+}
+
+func TestQuota_AdminHandler(t *testing.T) {
+	t.Parallel()
+	q := &ratelimit.Quota{
+		Limit:    100,
+		Store:    ratelimit.NewQuotaMemStore(),
+		VaryByer: ratelimit.JWTClaim("client_id"),
+	}
+	_, _, err := q.Take(context.TODO(), "client1", 10)
+	require.NoError(t, err)
+
+	h := q.AdminHandler()
+	serve := func(method, query string) (*httptest.ResponseRecorder, ratelimit.QuotaResult) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/admin/quota?"+query, nil))
+		var qr ratelimit.QuotaResult
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&qr))
+		}
+		return rec, qr
+	}
+
+	rec, qr := serve("GET", "key=client1")
+	assert.Exactly(t, http.StatusOK, rec.Code)
+	assert.Exactly(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Exactly(t, "client1", qr.Key)
+	assert.Exactly(t, "daily", qr.Window)
+	assert.Exactly(t, int64(10), qr.Used)
+	assert.Exactly(t, int64(90), qr.Remaining)
+
+	rec, qr = serve("PUT", "key=client1&limit=1000")
+	assert.Exactly(t, http.StatusOK, rec.Code)
+	assert.Exactly(t, int64(1000), qr.Limit)
+	assert.Exactly(t, int64(990), qr.Remaining)
+
+	rec, qr = serve("DELETE", "key=client1")
+	assert.Exactly(t, http.StatusOK, rec.Code)
+	assert.Exactly(t, int64(0), qr.Used)
+	assert.Exactly(t, int64(1000), qr.Remaining)
+
+	rec, _ = serve("GET", "")
+	assert.Exactly(t, http.StatusBadRequest, rec.Code)
+	rec, _ = serve("PUT", "key=client1&limit=x")
+	assert.Exactly(t, http.StatusBadRequest, rec.Code)
+	rec, _ = serve("POST", "key=client1")
+	assert.Exactly(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Exactly(t, "GET, HEAD, PUT, DELETE", rec.Header().Get("Allow"))
+}
+
+func TestJWTClaim_Key(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest("GET", "/", nil)
+	assert.Exactly(t, "", ratelimit.JWTClaim("client_id").Key(req), "Request without token")
+}
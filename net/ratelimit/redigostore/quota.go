@@ -0,0 +1,133 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redigostore
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/corestoreio/csfw/net/ratelimit"
+	"github.com/corestoreio/errors"
+	"github.com/garyburd/redigo/redis"
+)
+
+var _ ratelimit.QuotaStore = (*QuotaStore)(nil)
+
+// QuotaStore persists the used quota in Redis. The usage of a key gets stored
+// in <KeyPrefix>:<key>:<window start> and expires at the end of the window.
+// The custom limits are stored in the hash <KeyPrefix>:limits.
+type QuotaStore struct {
+	Pool      *redis.Pool
+	KeyPrefix string
+}
+
+// NewQuotaStore creates a new Redis backed ratelimit.QuotaStore. An empty key
+// prefix defaults to "ratelimit_quota".
+func NewQuotaStore(pool *redis.Pool, keyPrefix string) *QuotaStore {
+	if keyPrefix == "" {
+		keyPrefix = "ratelimit_quota"
+	}
+	return &QuotaStore{
+		Pool:      pool,
+		KeyPrefix: keyPrefix,
+	}
+}
+
+func (qs *QuotaStore) usageKey(key string, windowStart time.Time) string {
+	return qs.KeyPrefix + ":" + key + ":" + strconv.FormatInt(windowStart.Unix(), 10)
+}
+
+func (qs *QuotaStore) limitsKey() string {
+	return qs.KeyPrefix + ":limits"
+}
+
+// Increment increments the usage and sets the expiration within one
+// transaction.
+func (qs *QuotaStore) Increment(_ context.Context, key string, windowStart time.Time, n int64, expires time.Time) (int64, error) {
+	conn := qs.Pool.Get()
+	defer conn.Close()
+
+	uk := qs.usageKey(key, windowStart)
+	if err := conn.Send("MULTI"); err != nil {
+		return 0, errors.Wrap(err, "[redigostore] QuotaStore.Increment.MULTI")
+	}
+	if err := conn.Send("INCRBY", uk, n); err != nil {
+		return 0, errors.Wrap(err, "[redigostore] QuotaStore.Increment.INCRBY")
+	}
+	if err := conn.Send("EXPIREAT", uk, expires.Unix()); err != nil {
+		return 0, errors.Wrap(err, "[redigostore] QuotaStore.Increment.EXPIREAT")
+	}
+	vals, err := redis.Values(conn.Do("EXEC"))
+	if err != nil {
+		return 0, errors.Wrapf(err, "[redigostore] QuotaStore.Increment.EXEC key %q", uk)
+	}
+	if len(vals) != 2 {
+		return 0, errors.NewFatalf("[redigostore] QuotaStore.Increment.EXEC: Expected two results, got %d", len(vals))
+	}
+	used, err := redis.Int64(vals[0], nil)
+	return used, errors.Wrapf(err, "[redigostore] QuotaStore.Increment key %q", uk)
+}
+
+// Usage returns zero if the key does not exist.
+func (qs *QuotaStore) Usage(_ context.Context, key string, windowStart time.Time) (int64, error) {
+	conn := qs.Pool.Get()
+	defer conn.Close()
+
+	used, err := redis.Int64(conn.Do("GET", qs.usageKey(key, windowStart)))
+	if err == redis.ErrNil {
+		return 0, nil
+	}
+	return used, errors.Wrapf(err, "[redigostore] QuotaStore.Usage key %q", key)
+}
+
+// ResetUsage deletes the usage key.
+func (qs *QuotaStore) ResetUsage(_ context.Context, key string, windowStart time.Time) error {
+	conn := qs.Pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("DEL", qs.usageKey(key, windowStart))
+	return errors.Wrapf(err, "[redigostore] QuotaStore.ResetUsage key %q", key)
+}
+
+// Limit reads the custom limit from the limits hash.
+func (qs *QuotaStore) Limit(_ context.Context, key string) (int64, bool, error) {
+	conn := qs.Pool.Get()
+	defer conn.Close()
+
+	l, err := redis.Int64(conn.Do("HGET", qs.limitsKey(), key))
+	switch {
+	case err == redis.ErrNil:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, errors.Wrapf(err, "[redigostore] QuotaStore.Limit key %q", key)
+	}
+	return l, true, nil
+}
+
+// SetLimit writes the custom limit into the limits hash or deletes it if
+// limit is negative.
+func (qs *QuotaStore) SetLimit(_ context.Context, key string, limit int64) error {
+	conn := qs.Pool.Get()
+	defer conn.Close()
+
+	var err error
+	if limit < 0 {
+		_, err = conn.Do("HDEL", qs.limitsKey(), key)
+	} else {
+		_, err = conn.Do("HSET", qs.limitsKey(), key, limit)
+	}
+	return errors.Wrapf(err, "[redigostore] QuotaStore.SetLimit key %q", key)
+}
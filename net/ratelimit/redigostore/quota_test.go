@@ -0,0 +1,83 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redigostore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/corestoreio/csfw/net/ratelimit/redigostore"
+	"github.com/garyburd/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaStore(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", mr.Addr())
+		},
+	}
+	defer pool.Close()
+
+	qs := redigostore.NewQuotaStore(pool, "")
+	ctx := context.TODO()
+	start := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	expires := time.Now().Add(time.Hour)
+
+	used, err := qs.Usage(ctx, "client1", start)
+	require.NoError(t, err)
+	assert.Exactly(t, int64(0), used)
+
+	for i := int64(1); i <= 3; i++ {
+		used, err = qs.Increment(ctx, "client1", start, 1, expires)
+		require.NoError(t, err)
+		assert.Exactly(t, i, used)
+	}
+	used, err = qs.Increment(ctx, "client1", start, -1, expires)
+	require.NoError(t, err)
+	assert.Exactly(t, int64(2), used)
+	assert.True(t, mr.TTL("ratelimit_quota:client1:1488326400") > 0, "Usage key must expire")
+
+	used, err = qs.Usage(ctx, "client1", start)
+	require.NoError(t, err)
+	assert.Exactly(t, int64(2), used)
+
+	require.NoError(t, qs.ResetUsage(ctx, "client1", start))
+	used, err = qs.Usage(ctx, "client1", start)
+	require.NoError(t, err)
+	assert.Exactly(t, int64(0), used)
+
+	_, ok, err := qs.Limit(ctx, "client1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, qs.SetLimit(ctx, "client1", 500))
+	limit, ok, err := qs.Limit(ctx, "client1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Exactly(t, int64(500), limit)
+
+	require.NoError(t, qs.SetLimit(ctx, "client1", -1))
+	_, ok, err = qs.Limit(ctx, "client1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
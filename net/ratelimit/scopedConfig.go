@@ -38,6 +38,9 @@ type ScopedConfig struct {
 	// it is nil, the middleware panics. The default VaryByer returns an empty
 	// string so that all requests uses the same key.
 	VaryByer
+	// Quota optional request accounting per key within a daily or monthly
+	// window, used by the WithQuota middleware. Set via WithQuota().
+	Quota *Quota
 }
 
 // DefaultDeniedHandler defines the service wide denied handler.
@@ -66,6 +69,9 @@ func (sc ScopedConfig) isValid() error {
 	if sc.RateLimiter == nil || sc.DeniedHandler == nil || sc.VaryByer == nil {
 		return errors.NewNotValidf(errScopedConfigNotValid, sc.ScopeID, sc.DeniedHandler == nil, sc.RateLimiter == nil, sc.VaryByer == nil)
 	}
+	if sc.Quota != nil {
+		return errors.Wrap(sc.Quota.Validate(), "[ratelimit] scopedConfig.isValid")
+	}
 	return nil
 }
 
@@ -75,6 +75,70 @@ func (s *Service) WithRateLimit(next http.Handler) http.Handler {
 	})
 }
 
+// WithQuota wraps an http.Handler to account the requests per key of the
+// scoped Quota, for example per API client. Requests exceeding the quota of
+// the current window get passed to the DeniedHandler. The headers
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset contain the
+// state of the quota and overwrite the headers of WithRateLimit. Requests are
+// passed unchanged to next if no Quota has been configured or the key is
+// empty.
+func (s *Service) WithQuota(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scpCfg, err := s.configByContext(r.Context())
+		if err != nil {
+			if s.Log.IsDebug() {
+				s.Log.Debug("ratelimit.Service.WithQuota.configByContext", log.Err(err), loghttp.Request("request", r))
+			}
+			s.ErrorHandler(errors.Wrap(err, "ratelimit.Service.WithQuota.configFromContext")).ServeHTTP(w, r)
+			return
+		}
+		if scpCfg.Disabled || scpCfg.Quota == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := scpCfg.Quota.Key(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		isLimited, qr, err := scpCfg.Quota.Take(r.Context(), key, 1)
+		if s.Log.IsDebug() {
+			s.Log.Debug("ratelimit.Service.WithQuota.Take",
+				log.Err(err),
+				log.Bool("is_limited", isLimited),
+				log.String("key", key),
+				log.Int64("used", qr.Used),
+				log.Int64("limit", qr.Limit),
+				log.Stringer("requested_scope", scpCfg.ScopeID),
+				loghttp.Request("request", r),
+			)
+		}
+		if err != nil {
+			scpCfg.ErrorHandler(errors.Wrap(err, "[ratelimit] scpCfg.Quota.Take")).ServeHTTP(w, r)
+			return
+		}
+
+		setQuotaHeaders(w, qr, isLimited)
+
+		if isLimited {
+			scpCfg.DeniedHandler.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func setQuotaHeaders(w http.ResponseWriter, qr QuotaResult, isLimited bool) {
+	reset := strconv.Itoa(int(math.Ceil(qr.ResetAfter.Seconds())))
+	w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(qr.Limit, 10))
+	w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(qr.Remaining, 10))
+	w.Header().Set("X-RateLimit-Reset", reset)
+	if isLimited {
+		w.Header().Set("Retry-After", reset)
+	}
+}
+
 func setRateLimitHeaders(w http.ResponseWriter, rlr throttled.RateLimitResult) {
 	if v := rlr.Limit; v >= 0 {
 		w.Header().Add("X-RateLimit-Limit", strconv.Itoa(v))
@@ -18,8 +18,10 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/corestoreio/csfw/net/jwt"
 	"github.com/corestoreio/csfw/net/request"
 	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/csfw/util/conv"
 )
 
 // VaryByer is called for each request to generate a key for the limiter. If it
@@ -35,9 +37,30 @@ func (emptyVaryBy) Key(_ *http.Request) string {
 	return ""
 }
 
+// JWTClaim uses the value of a claim of the token in the request context as
+// key, for example the ID of an API client. Returns an empty key if the
+// request has no token or the claim does not exists. The jwt middleware must
+// run before the rate limiter.
+type JWTClaim string
+
+// Key returns the claim value of the token in the request context.
+func (c JWTClaim) Key(r *http.Request) string {
+	tk, ok := jwt.FromContext(r.Context())
+	if !ok || tk.Claims == nil {
+		return ""
+	}
+	v, err := tk.Claims.Get(string(c))
+	if err != nil || v == nil {
+		return ""
+	}
+	return conv.ToString(v)
+}
+
 // VaryBy defines the criteria to use to group requests.
 type VaryBy struct {
-	// Vary by the RemoteAddr as specified by the net/http.Request field.
+	// Vary by the RemoteAddr as specified by the net/http.Request field. An
+	// IP address resolved by the request.ClientIP middleware takes
+	// precedence.
 	RemoteAddr bool
 
 	// Vary by the HTTP Method as specified by the net/http.Request field.
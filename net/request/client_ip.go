@@ -0,0 +1,164 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package request
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	csnet "github.com/corestoreio/csfw/net"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/log"
+	loghttp "github.com/corestoreio/log/http"
+)
+
+// ClientIPHeaders default list of headers checked by the ClientIP middleware.
+// The first header found in a request gets used.
+var ClientIPHeaders = []string{csnet.Forwarded, csnet.XForwardedFor, csnet.XRealIP}
+
+type keyCtxClientIP struct{}
+
+// WithContextClientIP adds the resolved client IP address to the context.
+func WithContextClientIP(ctx context.Context, ip net.IP) context.Context {
+	return context.WithValue(ctx, keyCtxClientIP{}, ip)
+}
+
+// FromContextClientIP returns the client IP address resolved by the ClientIP
+// middleware.
+func FromContextClientIP(ctx context.Context) (net.IP, bool) {
+	ip, ok := ctx.Value(keyCtxClientIP{}).(net.IP)
+	return ip, ok && ip != nil
+}
+
+// ClientIP represents a middleware which determines the real IP address of
+// the client and stores it in the request context. The forwarding headers get
+// only evaluated when the request comes from a trusted proxy. The hops get
+// walked from right to left and the first address which is not a trusted proxy
+// becomes the client IP. Without any trusted proxies the remote address of the
+// connection will always be used.
+//
+// RealIP, and therefore the geoip and ratelimit packages, prefer the IP stored
+// in the context over parsing the headers themselves.
+type ClientIP struct {
+	// TrustedProxies contains the IP ranges of the load balancers and reverse
+	// proxies in front of the application.
+	TrustedProxies csnet.IPRanges
+	// TrustPrivateRanges treats all addresses in csnet.PrivateIPRanges as
+	// trusted proxies.
+	TrustPrivateRanges bool
+	// Headers to check for forwarded addresses. Can be empty and falls back
+	// to ClientIPHeaders. Supported are the RFC 7239 Forwarded header and the
+	// comma separated X-Forwarded-For style headers.
+	Headers []string
+	log.Logger
+}
+
+func (ci *ClientIP) isTrusted(ip net.IP) bool {
+	return ci.TrustedProxies.In(ip) || (ci.TrustPrivateRanges && csnet.PrivateIPRanges.In(ip))
+}
+
+// Resolve determines the client IP address of a request. Returns nil if the
+// remote address cannot be parsed.
+func (ci *ClientIP) Resolve(r *http.Request) net.IP {
+	ip := parseHostIP(r.RemoteAddr)
+	if ip == nil || !ci.isTrusted(ip) {
+		return ip
+	}
+
+	headers := ci.Headers
+	if len(headers) == 0 {
+		headers = ClientIPHeaders
+	}
+	for _, h := range headers {
+		// a client can send its own header line which a proxy does not merge
+		// but appends as a new line, hence all lines must be considered.
+		v := strings.Join(r.Header[http.CanonicalHeaderKey(h)], ",")
+		if v == "" {
+			continue
+		}
+		var hops []string
+		if http.CanonicalHeaderKey(h) == csnet.Forwarded {
+			hops = forwardedFor(v)
+		} else {
+			hops = strings.Split(v, ",")
+		}
+		// march from right to left until we reach an address which is not
+		// one of our proxies.
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := parseHostIP(strings.TrimSpace(hops[i]))
+			if hop == nil {
+				// unknown or obfuscated identifier, cannot go further
+				return ip
+			}
+			ip = hop
+			if !ci.isTrusted(ip) {
+				return ip
+			}
+		}
+		return ip
+	}
+	return ip
+}
+
+// With is a middleware which resolves the client IP address and adds it to
+// the request context. Retrieve it using:
+//		request.FromContextClientIP(r.Context())
+func (ci *ClientIP) With() mw.Middleware {
+	if ci.Logger == nil {
+		ci.Logger = log.BlackHole{}
+	}
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ci.Resolve(r)
+			if ci.IsDebug() {
+				ci.Debug("request.ClientIP.With", log.Stringer("client_ip", ip), loghttp.Request("request", r))
+			}
+			if ip != nil {
+				r = r.WithContext(WithContextClientIP(r.Context(), ip))
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedFor extracts the for= parameters of a RFC 7239 Forwarded header.
+//		Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"
+func forwardedFor(v string) []string {
+	elems := strings.Split(v, ",")
+	hops := make([]string, 0, len(elems))
+	for _, elem := range elems {
+		var hop string
+		for _, pair := range strings.Split(elem, ";") {
+			pair = strings.TrimSpace(pair)
+			if len(pair) > 4 && strings.EqualFold(pair[:4], "for=") {
+				hop = strings.Trim(pair[4:], `"`)
+			}
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// parseHostIP parses an IP address with an optional port. IPv6 addresses can
+// be enclosed in square brackets.
+func parseHostIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.ParseIP(strings.Trim(host, "[]"))
+}
@@ -0,0 +1,112 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package request_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	csnet "github.com/corestoreio/csfw/net"
+	"github.com/corestoreio/csfw/net/request"
+	"github.com/corestoreio/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP_Resolve(t *testing.T) {
+	proxies := csnet.MakeIPRanges("203.0.113.1", "203.0.113.10")
+	newReq := func(remote string, kv ...string) *http.Request {
+		r := httptest.NewRequest("GET", "http://corestore.io", nil)
+		r.RemoteAddr = remote
+		for i := 0; i < len(kv); i += 2 {
+			r.Header.Add(kv[i], kv[i+1])
+		}
+		return r
+	}
+
+	tests := []struct {
+		ci     request.ClientIP
+		r      *http.Request
+		wantIP net.IP
+	}{
+		// untrusted remote address ignores all headers
+		{request.ClientIP{}, newReq("198.51.100.7:1234", "X-Forwarded-For", "1.2.3.4"), net.ParseIP("198.51.100.7")},
+		{request.ClientIP{TrustedProxies: proxies}, newReq("198.51.100.7:1234", "X-Forwarded-For", "1.2.3.4"), net.ParseIP("198.51.100.7")},
+		// trusted proxy without headers
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234"), net.ParseIP("203.0.113.2")},
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234", "X-Forwarded-For", "1.2.3.4"), net.ParseIP("1.2.3.4")},
+		// spoofed left most entry gets ignored
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234", "X-Forwarded-For", "6.6.6.6, 1.2.3.4, 203.0.113.5"), net.ParseIP("1.2.3.4")},
+		// spoofed first header line gets ignored when a proxy adds a second line
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234", "X-Forwarded-For", "6.6.6.6", "X-Forwarded-For", "1.2.3.4"), net.ParseIP("1.2.3.4")},
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234", "Forwarded", "for=6.6.6.6", "Forwarded", "for=1.2.3.4;proto=https"), net.ParseIP("1.2.3.4")},
+		// all hops trusted returns the left most
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234", "X-Forwarded-For", "203.0.113.3, 203.0.113.5"), net.ParseIP("203.0.113.3")},
+		// garbage stops the walk
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234", "X-Forwarded-For", "1.2.3.4, garbage, 203.0.113.5"), net.ParseIP("203.0.113.5")},
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234", "X-Real-IP", "1.2.3.4"), net.ParseIP("1.2.3.4")},
+		// Forwarded has precedence over X-Forwarded-For
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234",
+			"Forwarded", `for=192.0.2.60;proto=http;by=203.0.113.43, For="[2001:db8:cafe::17]:4711"`,
+			"X-Forwarded-For", "1.2.3.4"), net.ParseIP("2001:db8:cafe::17")},
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234", "Forwarded", `for=192.0.2.60, for=_hidden`), net.ParseIP("203.0.113.2")},
+		{request.ClientIP{TrustedProxies: proxies}, newReq("203.0.113.2:1234", "Forwarded", `for=192.0.2.60;proto=https`), net.ParseIP("192.0.2.60")},
+		// custom headers
+		{request.ClientIP{TrustedProxies: proxies, Headers: []string{"CF-Connecting-IP"}}, newReq("203.0.113.2:1234", "X-Forwarded-For", "1.2.3.4", "CF-Connecting-IP", "5.6.7.8"), net.ParseIP("5.6.7.8")},
+		// private ranges
+		{request.ClientIP{TrustPrivateRanges: true}, newReq("10.0.0.3:1234", "X-Forwarded-For", "1.2.3.4, 192.168.1.1"), net.ParseIP("1.2.3.4")},
+		{request.ClientIP{TrustPrivateRanges: true}, newReq("[::1]:1234"), net.ParseIP("::1")},
+		{request.ClientIP{}, newReq("invalid"), nil},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.wantIP.String(), test.ci.Resolve(test.r).String(), "Index %d", i)
+	}
+}
+
+func TestClientIP_With(t *testing.T) {
+	ci := &request.ClientIP{
+		TrustedProxies: csnet.MakeIPRanges("203.0.113.1", "203.0.113.10"),
+		Logger:         log.BlackHole{EnableDebug: true},
+	}
+
+	var called bool
+	h := ci.With()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		ip, ok := request.FromContextClientIP(r.Context())
+		assert.True(t, ok)
+		assert.Exactly(t, "1.2.3.4", ip.String())
+		// RealIP must not parse the spoofed header again
+		assert.Exactly(t, "1.2.3.4", request.RealIP(r, request.IPForwardedTrust).String())
+	}))
+
+	r := httptest.NewRequest("GET", "http://corestore.io", nil)
+	r.RemoteAddr = "203.0.113.2:1234"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	r.Header.Set("X-Real-IP", "8.8.8.8")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.True(t, called)
+}
+
+func TestFromContextClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://corestore.io", nil)
+	ip, ok := request.FromContextClientIP(r.Context())
+	assert.False(t, ok)
+	assert.Nil(t, ip)
+
+	ctx := request.WithContextClientIP(r.Context(), nil)
+	_, ok = request.FromContextClientIP(ctx)
+	assert.False(t, ok)
+}
@@ -70,8 +70,13 @@ const (
 // headers in which an IP address can be stored. Checks if the IP in one of the
 // header fields lies in net.PrivateIPRanges. For the second argument opts
 // please see the constants IPForwarded*. Return value can be nil. A check for
-// the RealIP costs 8 allocs, for now.
+// the RealIP costs 8 allocs, for now. An IP address resolved by the ClientIP
+// middleware takes precedence and ignores the options.
 func RealIP(r *http.Request, opts int) net.IP {
+	if ip, ok := FromContextClientIP(r.Context()); ok {
+		return ip
+	}
+
 	// Courtesy https://husobee.github.io/golang/ip-address/2015/12/17/remote-ip-go.html
 
 	// The reason for providing an int field as option instead of e.g.
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/backendstore"
 	"github.com/corestoreio/csfw/store/scope"
 )
 
@@ -41,16 +42,46 @@ type ProcessStoreCodeCookie struct {
 	// code. Expiration time and value will get overwritten.
 	CookieTemplate func(*http.Request) *http.Cookie
 	// CookieExpiresSet defaults to one year expiration for the store code.
+	// Takes precedence over CookieLifetime.
 	CookieExpiresSet time.Time
 	// CookieExpiresDelete defaults to minus ten years to delete the store code
 	// cookie.
 	CookieExpiresDelete time.Time
+	// CookieLifetime optional duration how long the store code cookie stays
+	// valid, counted from the time the cookie gets set.
+	CookieLifetime time.Duration
+	// CookieDomain optional domain of the cookie, defaults to the host of the
+	// request. See function store.CodeCookieDomain to derive the domain from a
+	// base URL.
+	CookieDomain string
+	// CookiePath optional path of the cookie, defaults to "/".
+	CookiePath string
+	// CookieAllowJS set to true to disable the HttpOnly flag of the cookie.
+	CookieAllowJS bool
+	// HMACKey optional secret key to sign the store code in the cookie with
+	// HMAC-SHA256. If set, cookies without a valid signature will be ignored,
+	// which protects against a manipulated store code.
+	HMACKey []byte
 
 	// pre-calculated keys for strings.Contain
 	keyFieldName    string
 	keyURLFieldName string
 }
 
+// NewProcessStoreCodeCookie creates a new store code processor whose cookie
+// settings have been loaded from the configuration. The hmacKey argument can
+// be nil to disable the signing of the store code. Use the returned processor
+// in the field CodeProcessor of type Options.
+func NewProcessStoreCodeCookie(sc *backendstore.StoreCookie, hmacKey []byte) *ProcessStoreCodeCookie {
+	return &ProcessStoreCodeCookie{
+		CookieLifetime: sc.Lifetime,
+		CookieDomain:   sc.Domain,
+		CookiePath:     sc.Path,
+		CookieAllowJS:  !sc.HTTPOnly,
+		HMACKey:        hmacKey,
+	}
+}
+
 func (e *ProcessStoreCodeCookie) keyURLFN() (string, string) {
 	if e.URLFieldName == "" {
 		e.URLFieldName = store.CodeURLFieldName
@@ -94,8 +125,8 @@ func (e *ProcessStoreCodeCookie) fromCookie(req *http.Request) string {
 	if c := req.Header.Get("Cookie"); c != "" && strings.Contains(c, fnK) {
 		// move cookie parsing after the check for the code in the cookie string
 		if keks, err := req.Cookie(fn); err == nil {
-			if err := store.CodeIsValid(keks.Value); err == nil {
-				return keks.Value
+			if code, err := store.CodeVerify(e.HMACKey, keks.Value); err == nil {
+				return code
 			}
 		}
 	}
@@ -107,32 +138,44 @@ func (a *ProcessStoreCodeCookie) newCookie(r *http.Request) *http.Cookie {
 	if a.CookieTemplate != nil {
 		return a.CookieTemplate(r)
 	}
-	d, _, err := net.SplitHostPort(r.Host)
-	if err != nil {
-		d = r.Host // might be a bug ...
+	d := a.CookieDomain
+	if d == "" {
+		var err error
+		if d, _, err = net.SplitHostPort(r.Host); err != nil {
+			d = r.Host // might be a bug ...
+		}
+	}
+	p := a.CookiePath
+	if p == "" {
+		p = "/"
 	}
 	var isSecure bool
 	if r.TLS != nil {
 		isSecure = true
 	}
+	fn, _ := a.keyFN()
 	return &http.Cookie{
-		Name:     store.CodeFieldName,
-		Path:     "/", // we can sit behind a proxy, so path must be configurable
+		Name:     fn,
+		Path:     p, // we can sit behind a proxy, so path must be configurable
 		Domain:   d,
 		Secure:   isSecure,
-		HttpOnly: true, // disable for JavaScript access
+		HttpOnly: !a.CookieAllowJS, // disable for JavaScript access
 	}
 }
 
 func (a *ProcessStoreCodeCookie) setStoreCookie(storeCode string, w http.ResponseWriter, r *http.Request) {
 	t := a.CookieExpiresSet
-	if a.CookieExpiresSet.IsZero() {
+	switch {
+	case !t.IsZero():
+	case a.CookieLifetime > 0:
+		t = time.Now().Add(a.CookieLifetime)
+	default:
 		t = time.Now().AddDate(1, 0, 0) // one year valid
 	}
 
 	keks := a.newCookie(r)
 	keks.Expires = t
-	keks.Value = storeCode
+	keks.Value = store.CodeSign(a.HMACKey, storeCode)
 	http.SetCookie(w, keks)
 }
 
@@ -23,6 +23,7 @@ import (
 
 	"github.com/corestoreio/csfw/net/runmode"
 	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/backendstore"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -184,3 +185,39 @@ func TestProcessStoreCode_ProcessAllowed(t *testing.T) {
 		assert.Empty(t, rec.Header().Get("Set-Cookie"))
 	})
 }
+
+func TestProcessStoreCode_HMAC(t *testing.T) {
+	key := []byte(`0123456789abcdef`)
+	c := runmode.NewProcessStoreCodeCookie(&backendstore.StoreCookie{
+		Lifetime: time.Hour,
+		Domain:   "example.com",
+		Path:     "/shop/",
+		HTTPOnly: true,
+	}, key)
+
+	t.Run("SetSigned", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "https://www.example.com/shop/", nil)
+		rec := httptest.NewRecorder()
+		c.ProcessAllowed(0, 1, 2, "de", rec, req)
+		assert.Regexp(t, `^store=de\.[A-Za-z0-9_-]{43}; Path=/shop/; Domain=example.com; Expires=[^;]+; HttpOnly; Secure$`, rec.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("ValidSignature", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "https://www.example.com/shop/", nil)
+		req.AddCookie(&http.Cookie{Name: store.CodeFieldName, Value: store.CodeSign(key, "de")})
+		assert.Exactly(t, "de", c.FromRequest(0, req))
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		sig := store.CodeSign(key, "de")
+		req := httptest.NewRequest("GET", "https://www.example.com/shop/", nil)
+		req.AddCookie(&http.Cookie{Name: store.CodeFieldName, Value: "at" + sig[2:]})
+		assert.Exactly(t, "", c.FromRequest(0, req))
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "https://www.example.com/shop/", nil)
+		req.AddCookie(&http.Cookie{Name: store.CodeFieldName, Value: "de"})
+		assert.Exactly(t, "", c.FromRequest(0, req))
+	})
+}
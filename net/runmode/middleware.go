@@ -117,7 +117,7 @@ type Options struct {
 // will be performed:
 //	1. Call to AppRunMode.RunMode.CalculateMode to get the default run mode.
 //	2a. Parse Request GET parameter for the store code key (___store).
-//	2b. If GET is empty, check cookie for key "store"
+//	2b. If GET is empty, check cookie for key "store" and verify its signature, if configured.
 //	2c. Lookup CodeToIDMapper.IDbyCode() to get the website/store ID from a website/store code.
 //	3. Retrieve all AllowedStoreIDs based on the runMode
 //	4. Check if the website/store ID
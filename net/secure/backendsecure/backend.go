@@ -0,0 +1,112 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backendsecure
+
+import (
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/csfw/config/cfgsource"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/net/secure"
+)
+
+// Configuration just exported for the sake of documentation. See fields for
+// more information. Please call the New() function for creating a new
+// Configuration object.
+type Configuration struct {
+	*secure.OptionFactories
+
+	// Disabled set to true to disable the security headers.
+	//
+	// Path: web/security/disabled
+	Disabled cfgmodel.Bool
+
+	// HSTSMaxAge max-age in seconds of the Strict-Transport-Security header.
+	// Zero disables the header.
+	//
+	// Path: web/security/hsts_max_age
+	HSTSMaxAge cfgmodel.Int
+
+	// HSTSIncludeSubdomains appends includeSubDomains to the HSTS header.
+	//
+	// Path: web/security/hsts_include_subdomains
+	HSTSIncludeSubdomains cfgmodel.Bool
+
+	// HSTSPreload appends preload to the HSTS header.
+	//
+	// Path: web/security/hsts_preload
+	HSTSPreload cfgmodel.Bool
+
+	// HSTSForce sends the HSTS header also for non TLS requests.
+	//
+	// Path: web/security/hsts_force
+	HSTSForce cfgmodel.Bool
+
+	// FrameOptions either DENY or SAMEORIGIN. Empty disables the header.
+	//
+	// Path: web/security/frame_options
+	FrameOptions cfgmodel.Str
+
+	// ContentTypeNosniff sets the header X-Content-Type-Options: nosniff.
+	//
+	// Path: web/security/content_type_nosniff
+	ContentTypeNosniff cfgmodel.Bool
+
+	// XSSProtection value of the X-XSS-Protection header.
+	//
+	// Path: web/security/xss_protection
+	XSSProtection cfgmodel.Str
+
+	// ReferrerPolicy value of the Referrer-Policy header.
+	//
+	// Path: web/security/referrer_policy
+	ReferrerPolicy cfgmodel.Str
+
+	// ContentSecurityPolicy value of the CSP header. The placeholder $NONCE
+	// gets replaced with a random nonce for each request.
+	//
+	// Path: web/security/csp
+	ContentSecurityPolicy cfgmodel.Str
+
+	// CSPReportOnly sends the CSP in report only mode.
+	//
+	// Path: web/security/csp_report_only
+	CSPReportOnly cfgmodel.Bool
+}
+
+// New initializes the backend configuration models containing the cfgpath.Route
+// variable to the appropriate entries. The function Load() will be executed to
+// apply the SectionSlice to all models. See Load() for more details.
+func New(cfgStruct element.SectionSlice, opts ...cfgmodel.Option) *Configuration {
+	be := &Configuration{
+		OptionFactories: secure.NewOptionFactories(),
+	}
+
+	opts = append(opts, cfgmodel.WithFieldFromSectionSlice(cfgStruct))
+	optsYN := append([]cfgmodel.Option{}, opts...)
+	optsYN = append(optsYN, cfgmodel.WithSource(cfgsource.YesNo))
+
+	be.Disabled = cfgmodel.NewBool(`web/security/disabled`, optsYN...)
+	be.HSTSMaxAge = cfgmodel.NewInt(`web/security/hsts_max_age`, opts...)
+	be.HSTSIncludeSubdomains = cfgmodel.NewBool(`web/security/hsts_include_subdomains`, optsYN...)
+	be.HSTSPreload = cfgmodel.NewBool(`web/security/hsts_preload`, optsYN...)
+	be.HSTSForce = cfgmodel.NewBool(`web/security/hsts_force`, optsYN...)
+	be.FrameOptions = cfgmodel.NewStr(`web/security/frame_options`, opts...)
+	be.ContentTypeNosniff = cfgmodel.NewBool(`web/security/content_type_nosniff`, optsYN...)
+	be.XSSProtection = cfgmodel.NewStr(`web/security/xss_protection`, opts...)
+	be.ReferrerPolicy = cfgmodel.NewStr(`web/security/referrer_policy`, opts...)
+	be.ContentSecurityPolicy = cfgmodel.NewStr(`web/security/csp`, opts...)
+	be.CSPReportOnly = cfgmodel.NewBool(`web/security/csp_report_only`, optsYN...)
+	return be
+}
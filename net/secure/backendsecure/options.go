@@ -0,0 +1,100 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backendsecure
+
+import (
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/net/secure"
+	"github.com/corestoreio/errors"
+)
+
+// PrepareOptionFactory creates a closure around the type Configuration. The
+// closure will be used during a scoped request to figure out the configuration
+// depending on the incoming scope. An option array will be returned by the
+// closure.
+func (be *Configuration) PrepareOptionFactory() secure.OptionFactoryFunc {
+	return func(sg config.Scoped) []secure.Option {
+		var (
+			opts     [3]secure.Option
+			settings secure.Settings
+			err      error
+		)
+
+		// DISABLED
+		isDisabled, err := be.Disabled.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] Disabled.Get"))
+		}
+
+		// HSTS
+		settings.HSTSMaxAge, err = be.HSTSMaxAge.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] HSTSMaxAge.Get"))
+		}
+		settings.HSTSIncludeSubdomains, err = be.HSTSIncludeSubdomains.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] HSTSIncludeSubdomains.Get"))
+		}
+		settings.HSTSPreload, err = be.HSTSPreload.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] HSTSPreload.Get"))
+		}
+		settings.HSTSForce, err = be.HSTSForce.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] HSTSForce.Get"))
+		}
+
+		// FRAME OPTIONS
+		settings.FrameOptions, err = be.FrameOptions.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] FrameOptions.Get"))
+		}
+
+		// CONTENT TYPE NOSNIFF
+		settings.ContentTypeNosniff, err = be.ContentTypeNosniff.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] ContentTypeNosniff.Get"))
+		}
+
+		// XSS PROTECTION
+		settings.XSSProtection, err = be.XSSProtection.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] XSSProtection.Get"))
+		}
+
+		// REFERRER POLICY
+		settings.ReferrerPolicy, err = be.ReferrerPolicy.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] ReferrerPolicy.Get"))
+		}
+
+		// CONTENT SECURITY POLICY
+		settings.ContentSecurityPolicy, err = be.ContentSecurityPolicy.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] ContentSecurityPolicy.Get"))
+		}
+		settings.CSPReportOnly, err = be.CSPReportOnly.Get(sg)
+		if err != nil {
+			return secure.OptionsError(errors.Wrap(err, "[backendsecure] CSPReportOnly.Get"))
+		}
+
+		// in case someone marks the config as partially applied now it's time to revert
+		// it.
+		opts[0] = secure.WithMarkPartiallyApplied(false, sg.ScopeIDs()...)
+		opts[1] = secure.WithSettings(settings, sg.ScopeIDs()...)
+		opts[2] = secure.WithDisable(isDisabled, sg.ScopeIDs()...)
+		return opts[:]
+	}
+}
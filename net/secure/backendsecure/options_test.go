@@ -0,0 +1,100 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backendsecure_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/net/secure"
+	"github.com/corestoreio/csfw/net/secure/backendsecure"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var backend *backendsecure.Configuration
+
+func init() {
+	cfgStruct, err := backendsecure.NewConfigStructure()
+	if err != nil {
+		panic(err)
+	}
+	backend = backendsecure.New(cfgStruct)
+}
+
+func TestConfiguration_WithHeaders(t *testing.T) {
+	srv := secure.MustNew(
+		secure.WithRootConfig(cfgmock.NewService(cfgmock.PathValue{
+			backend.HSTSMaxAge.MustFQWebsite(2):            31536000,
+			backend.HSTSForce.MustFQWebsite(2):             1,
+			backend.FrameOptions.MustFQWebsite(2):          "deny",
+			backend.ContentSecurityPolicy.MustFQWebsite(2): "default-src 'self'; script-src 'nonce-$NONCE'",
+			backend.CSPReportOnly.MustFQWebsite(2):         1,
+		})),
+		secure.WithOptionFactory(backend.PrepareOptionFactory()),
+		secure.WithServiceErrorHandler(mw.ErrorWithPanic),
+	)
+	req := httptest.NewRequest("GET", "http://corestore.io/checkout", nil)
+	req = req.WithContext(scope.WithContext(req.Context(), 2, 4))
+
+	var nonce string
+	rec := httptest.NewRecorder()
+	srv.WithHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce = secure.FromContextNonce(r.Context())
+	})).ServeHTTP(rec, req)
+
+	assert.NotEmpty(t, nonce)
+	assert.Exactly(t, "max-age=31536000", rec.Header().Get(secure.HeaderStrictTransportSecurity))
+	assert.Exactly(t, secure.FrameOptionsDeny, rec.Header().Get(secure.HeaderFrameOptions))
+	assert.Exactly(t, "nosniff", rec.Header().Get(secure.HeaderContentTypeOptions))
+	assert.Exactly(t, "1; mode=block", rec.Header().Get(secure.HeaderXSSProtection))
+	assert.Empty(t, rec.Header().Get(secure.HeaderCSP))
+	assert.Exactly(t, "default-src 'self'; script-src 'nonce-"+nonce+"'", rec.Header().Get(secure.HeaderCSPReportOnly))
+}
+
+func TestBackend_Path_Errors(t *testing.T) {
+	tests := []struct {
+		toPath func(int64) string
+		val    interface{}
+		errBhf errors.BehaviourFunc
+	}{
+		{backend.Disabled.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.HSTSMaxAge.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.HSTSIncludeSubdomains.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.HSTSPreload.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.HSTSForce.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.FrameOptions.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.FrameOptions.MustFQWebsite, "ALLOW-FROM x", errors.IsNotValid},
+		{backend.ContentTypeNosniff.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.XSSProtection.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.ReferrerPolicy.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.ContentSecurityPolicy.MustFQWebsite, struct{}{}, errors.IsNotValid},
+		{backend.CSPReportOnly.MustFQWebsite, struct{}{}, errors.IsNotValid},
+	}
+	for i, test := range tests {
+		scpFnc := backend.PrepareOptionFactory()
+		cfgSrv := cfgmock.NewService(cfgmock.PathValue{
+			test.toPath(2): test.val,
+		})
+		cfgScp := cfgSrv.NewScoped(2, 0)
+
+		_, err := secure.New(scpFnc(cfgScp)...)
+		assert.True(t, test.errBhf(err), "Index %d Error: %+v", i, err)
+	}
+}
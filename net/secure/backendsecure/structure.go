@@ -0,0 +1,160 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backendsecure
+
+import (
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/storage/text"
+	"github.com/corestoreio/csfw/store/scope"
+)
+
+// NewConfigStructure global configuration structure for this package.
+// Used in frontend (to display the user all the settings) and in
+// backend (scope checks and default values). See the source code
+// of this function for the overall available sections, groups and fields.
+func NewConfigStructure() (element.SectionSlice, error) {
+	return element.NewConfiguration(
+		element.Section{
+			ID: cfgpath.NewRoute(`web`),
+			Groups: element.NewGroupSlice(
+				element.Group{
+					ID:        cfgpath.NewRoute(`security`),
+					Label:     text.Chars(`Security Headers`),
+					Comment:   text.Chars(`Sets HTTP response headers for quick security wins.`),
+					MoreURL:   text.Chars(`https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers#Security`),
+					SortOrder: 180,
+					Scopes:    scope.PermWebsite,
+					Fields: element.NewFieldSlice(
+						element.Field{
+							// Path: `web/security/disabled`,
+							ID:        cfgpath.NewRoute(`disabled`),
+							Label:     text.Chars(`Disable security headers`),
+							Type:      element.TypeSelect,
+							SortOrder: 10,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   `false`,
+						},
+						element.Field{
+							// Path: `web/security/hsts_max_age`,
+							ID:        cfgpath.NewRoute(`hsts_max_age`),
+							Label:     text.Chars(`HSTS Max Age`),
+							Comment:   text.Chars(`Max age in seconds of the Strict-Transport-Security header. 0 disables the header.`),
+							Type:      element.TypeText,
+							SortOrder: 20,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   0,
+						},
+						element.Field{
+							// Path: `web/security/hsts_include_subdomains`,
+							ID:        cfgpath.NewRoute(`hsts_include_subdomains`),
+							Label:     text.Chars(`HSTS include sub domains`),
+							Type:      element.TypeSelect,
+							SortOrder: 30,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   `false`,
+						},
+						element.Field{
+							// Path: `web/security/hsts_preload`,
+							ID:        cfgpath.NewRoute(`hsts_preload`),
+							Label:     text.Chars(`HSTS preload`),
+							Type:      element.TypeSelect,
+							SortOrder: 40,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   `false`,
+						},
+						element.Field{
+							// Path: `web/security/hsts_force`,
+							ID:    cfgpath.NewRoute(`hsts_force`),
+							Label: text.Chars(`HSTS force`),
+							Comment: text.Chars(`Sends the HSTS header also for non TLS
+requests. Enable when the TLS connection terminates at a proxy.`),
+							Type:      element.TypeSelect,
+							SortOrder: 50,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   `false`,
+						},
+						element.Field{
+							// Path: `web/security/frame_options`,
+							ID:        cfgpath.NewRoute(`frame_options`),
+							Label:     text.Chars(`X-Frame-Options`),
+							Comment:   text.Chars(`Either DENY or SAMEORIGIN. Empty disables the header.`),
+							Type:      element.TypeText,
+							SortOrder: 60,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   `SAMEORIGIN`,
+						},
+						element.Field{
+							// Path: `web/security/content_type_nosniff`,
+							ID:        cfgpath.NewRoute(`content_type_nosniff`),
+							Label:     text.Chars(`X-Content-Type-Options nosniff`),
+							Type:      element.TypeSelect,
+							SortOrder: 70,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   `true`,
+						},
+						element.Field{
+							// Path: `web/security/xss_protection`,
+							ID:        cfgpath.NewRoute(`xss_protection`),
+							Label:     text.Chars(`X-XSS-Protection`),
+							Type:      element.TypeText,
+							SortOrder: 80,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   `1; mode=block`,
+						},
+						element.Field{
+							// Path: `web/security/referrer_policy`,
+							ID:        cfgpath.NewRoute(`referrer_policy`),
+							Label:     text.Chars(`Referrer-Policy`),
+							Type:      element.TypeText,
+							SortOrder: 90,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+						},
+						element.Field{
+							// Path: `web/security/csp`,
+							ID:    cfgpath.NewRoute(`csp`),
+							Label: text.Chars(`Content-Security-Policy`),
+							Comment: text.Chars(`The placeholder $NONCE gets replaced with a
+random nonce for each request, e.g. script-src 'self' 'nonce-$NONCE'.`),
+							Type:      element.TypeTextarea,
+							SortOrder: 100,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+						},
+						element.Field{
+							// Path: `web/security/csp_report_only`,
+							ID:        cfgpath.NewRoute(`csp_report_only`),
+							Label:     text.Chars(`CSP report only mode`),
+							Type:      element.TypeSelect,
+							SortOrder: 110,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   `false`,
+						},
+					),
+				},
+			),
+		},
+	)
+}
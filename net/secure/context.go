@@ -0,0 +1,47 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secure
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/corestoreio/errors"
+)
+
+type keyCtxNonce struct{}
+
+// WithContextNonce adds the CSP nonce to the context.
+func WithContextNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, keyCtxNonce{}, nonce)
+}
+
+// FromContextNonce returns the CSP nonce of the current request. Returns an
+// empty string if the Content-Security-Policy does not contain the
+// NoncePlaceholder.
+func FromContextNonce(ctx context.Context) string {
+	n, _ := ctx.Value(keyCtxNonce{}).(string)
+	return n
+}
+
+// newNonce creates a base64 encoded random nonce with 128 bits.
+func newNonce() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", errors.NewFatalf("[secure] newNonce rand.Read: %s", err)
+	}
+	return base64.StdEncoding.EncodeToString(b[:]), nil
+}
@@ -1,4 +1,30 @@
-// Package secure adds a middleware for quick security wins to response HTTP headers
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
 //
-// TODO(cs) https://github.com/unrolled/secure/blob/v1/secure.go
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secure adds a middleware for quick security wins to response HTTP
+// headers.
+//
+// The Service sets the headers Strict-Transport-Security, X-Frame-Options,
+// X-Content-Type-Options, X-XSS-Protection, Referrer-Policy and
+// Content-Security-Policy depending on the website scope. The CSP can be sent
+// in report only mode. If the CSP contains the placeholder $NONCE a new random
+// nonce gets generated for each request, replaces the placeholder and gets
+// stored in the request context. Templates can retrieve the nonce with
+// FromContextNonce to add it to inline script and style tags.
+//
+// The package backendsecure loads the settings from the configuration paths
+// web/security/*.
+//
+// Inspired by https://github.com/unrolled/secure/blob/v1/secure.go
 package secure
@@ -0,0 +1,21 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secure
+
+const (
+	errServiceUnsupportedScope = "[secure] Service does not support this: %s. Only default or website scope are allowed."
+	errScopedConfigNotValid    = `[secure] ScopedConfig %s is invalid. FrameOptions: %q`
+	errFrameOptionsNotValid    = `[secure] FrameOptions %q not supported. Allowed values are DENY or SAMEORIGIN.`
+)
@@ -0,0 +1,111 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secure
+
+import (
+	"strings"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// Header names and values set by the Service.
+const (
+	HeaderStrictTransportSecurity = "Strict-Transport-Security"
+	HeaderFrameOptions            = "X-Frame-Options"
+	HeaderContentTypeOptions      = "X-Content-Type-Options"
+	HeaderXSSProtection           = "X-XSS-Protection"
+	HeaderReferrerPolicy          = "Referrer-Policy"
+	HeaderCSP                     = "Content-Security-Policy"
+	HeaderCSPReportOnly           = "Content-Security-Policy-Report-Only"
+
+	FrameOptionsDeny       = "DENY"
+	FrameOptionsSameOrigin = "SAMEORIGIN"
+)
+
+// NoncePlaceholder gets replaced in the ContentSecurityPolicy with a random
+// nonce generated for each request. Example:
+//		script-src 'self' 'nonce-$NONCE'
+const NoncePlaceholder = "$NONCE"
+
+// Settings general settings for the secure service. Those settings will be
+// applied via functional options on a per scope basis. Empty values disable
+// the appropriate header.
+type Settings struct {
+	// HSTSMaxAge sets the max-age in seconds of the Strict-Transport-Security
+	// header. The header gets only sent for TLS requests. Zero disables it.
+	HSTSMaxAge int
+	// HSTSIncludeSubdomains appends includeSubDomains to the HSTS header.
+	HSTSIncludeSubdomains bool
+	// HSTSPreload appends preload to the HSTS header.
+	HSTSPreload bool
+	// HSTSForce sends the HSTS header also for non TLS requests. Useful when
+	// the TLS connection terminates at a proxy.
+	HSTSForce bool
+	// FrameOptions sets the X-Frame-Options header to either DENY or
+	// SAMEORIGIN.
+	FrameOptions string
+	// ContentTypeNosniff sets the header X-Content-Type-Options: nosniff.
+	ContentTypeNosniff bool
+	// XSSProtection value of the X-XSS-Protection header, for example
+	// "1; mode=block".
+	XSSProtection string
+	// ReferrerPolicy value of the Referrer-Policy header, for example
+	// "same-origin".
+	ReferrerPolicy string
+	// ContentSecurityPolicy value of the CSP header. May contain the
+	// NoncePlaceholder.
+	ContentSecurityPolicy string
+	// CSPReportOnly sends the CSP as Content-Security-Policy-Report-Only
+	// header. The browser reports violations but does not enforce the policy.
+	CSPReportOnly bool
+}
+
+// WithDefaultConfig applies the default secure header settings for a specific
+// scope. This function overwrites any previous set options. Default values
+// are:
+//		- X-Frame-Options: SAMEORIGIN
+//		- X-Content-Type-Options: nosniff
+//		- X-XSS-Protection: 1; mode=block
+func WithDefaultConfig(h scope.TypeID) Option {
+	return withDefaultConfig(h)
+}
+
+// WithSettings applies the Settings struct to a specific scope and overwrites
+// all previous set values.
+func WithSettings(stng Settings, scopeIDs ...scope.TypeID) Option {
+	stng.FrameOptions = strings.ToUpper(strings.TrimSpace(stng.FrameOptions))
+	stng.ContentSecurityPolicy = strings.TrimSpace(stng.ContentSecurityPolicy)
+
+	return func(s *Service) error {
+		switch stng.FrameOptions {
+		case "", FrameOptionsDeny, FrameOptionsSameOrigin:
+		default:
+			return errors.NewNotValidf(errFrameOptionsNotValid, stng.FrameOptions)
+		}
+		sc := s.findScopedConfig(scopeIDs...)
+		sc.Settings = stng
+		sc.initHSTS()
+		return s.updateScopedConfig(sc)
+	}
+}
+
+func defaultSettings() Settings {
+	return Settings{
+		FrameOptions:       FrameOptionsSameOrigin,
+		ContentTypeNosniff: true,
+		XSSProtection:      "1; mode=block",
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secure
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// ScopedConfig scoped based configuration and should not be embedded into your
+// own types. Call ScopedConfig.ScopeID to know to which scope this
+// configuration has been bound to.
+type ScopedConfig struct {
+	scopedConfigGeneric
+
+	// Settings general secure header settings
+	Settings
+	// hsts pre-rendered Strict-Transport-Security header value.
+	hsts string
+	// hasNonce true if the CSP contains the NoncePlaceholder.
+	hasNonce bool
+}
+
+// isValid a configuration for a scope is only then valid when
+//	- ScopeID set
+//	- FrameOptions empty, DENY or SAMEORIGIN
+func (sc *ScopedConfig) isValid() error {
+	if err := sc.isValidPreCheck(); err != nil {
+		return errors.Wrap(err, "[secure] scopedConfig.isValid as an lastErr")
+	}
+	if sc.Disabled {
+		return nil
+	}
+	switch sc.FrameOptions {
+	case "", FrameOptionsDeny, FrameOptionsSameOrigin:
+		if sc.ScopeID > 0 {
+			return nil
+		}
+	}
+	return errors.NewNotValidf(errScopedConfigNotValid, sc.ScopeID, sc.FrameOptions)
+}
+
+// newScopedConfig creates a new object with the minimum needed configuration.
+func newScopedConfig(target, parent scope.TypeID) *ScopedConfig {
+	return &ScopedConfig{
+		scopedConfigGeneric: newScopedConfigGeneric(target, parent),
+		Settings:            defaultSettings(),
+	}
+}
+
+// initHSTS renders the Strict-Transport-Security header value and checks for
+// the NoncePlaceholder.
+func (sc *ScopedConfig) initHSTS() {
+	sc.hasNonce = strings.Contains(sc.ContentSecurityPolicy, NoncePlaceholder)
+	sc.hsts = ""
+	if sc.HSTSMaxAge <= 0 {
+		return
+	}
+	sc.hsts = "max-age=" + strconv.Itoa(sc.HSTSMaxAge)
+	if sc.HSTSIncludeSubdomains {
+		sc.hsts += "; includeSubDomains"
+	}
+	if sc.HSTSPreload {
+		sc.hsts += "; preload"
+	}
+}
+
+// applyHeaders writes the security headers. A non empty nonce replaces the
+// NoncePlaceholder in the CSP.
+func (sc *ScopedConfig) applyHeaders(h http.Header, isTLS bool, nonce string) {
+	if sc.hsts != "" && (isTLS || sc.HSTSForce) {
+		h.Set(HeaderStrictTransportSecurity, sc.hsts)
+	}
+	if sc.FrameOptions != "" {
+		h.Set(HeaderFrameOptions, sc.FrameOptions)
+	}
+	if sc.ContentTypeNosniff {
+		h.Set(HeaderContentTypeOptions, "nosniff")
+	}
+	if sc.XSSProtection != "" {
+		h.Set(HeaderXSSProtection, sc.XSSProtection)
+	}
+	if sc.ReferrerPolicy != "" {
+		h.Set(HeaderReferrerPolicy, sc.ReferrerPolicy)
+	}
+	if sc.ContentSecurityPolicy != "" {
+		csp := sc.ContentSecurityPolicy
+		if nonce != "" {
+			csp = strings.Replace(csp, NoncePlaceholder, nonce, -1)
+		}
+		hdr := HeaderCSP
+		if sc.CSPReportOnly {
+			hdr = HeaderCSPReportOnly
+		}
+		h.Set(hdr, csp)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secure
+
+import (
+	"net/http"
+
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+	loghttp "github.com/corestoreio/log/http"
+)
+
+// WithHeaders to be used as a middleware. Sets the security headers before
+// calling the next handler. If the CSP contains the NoncePlaceholder, the
+// generated nonce can be retrieved in the next handler via FromContextNonce.
+// This middleware expects to find a scope.FromContext().
+func (s *Service) WithHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		scpCfg, err := s.configByContext(r.Context())
+		if err != nil {
+			s.Log.Info("secure.Service.WithHeaders.configByContext.Error", log.Err(err))
+			if s.Log.IsDebug() {
+				s.Log.Debug("secure.Service.WithHeaders.configByContext", log.Err(err), loghttp.Request("request", r))
+			}
+			s.ErrorHandler(errors.Wrap(err, "secure.Service.WithHeaders.configFromContext")).ServeHTTP(w, r)
+			return
+		}
+		if scpCfg.Disabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var nonce string
+		if scpCfg.hasNonce {
+			if nonce, err = newNonce(); err != nil {
+				scpCfg.ErrorHandler(errors.Wrap(err, "secure.Service.WithHeaders.newNonce")).ServeHTTP(w, r)
+				return
+			}
+			r = r.WithContext(WithContextNonce(r.Context(), nonce))
+		}
+
+		scpCfg.applyHeaders(w.Header(), r.TLS != nil, nonce)
+		if s.Log.IsDebug() {
+			s.Log.Debug("secure.Service.WithHeaders.applyHeaders", log.Stringer("scope", scpCfg.ScopeID), log.Object("headers", w.Header()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
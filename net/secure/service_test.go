@@ -0,0 +1,122 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secure_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/net/secure"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func reqWithWebsite(websiteID int64) *http.Request {
+	req := httptest.NewRequest("GET", "https://corestore.io/customer/account", nil)
+	return req.WithContext(scope.WithContext(req.Context(), websiteID, 0))
+}
+
+func TestService_WithHeaders_MWAdapter(t *testing.T) {
+	// checks if the middleware conforms to the mw.Middleware definition
+	srv := secure.MustNew()
+	_ = mw.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// noop
+	}), srv.WithHeaders)
+}
+
+func TestWithSettings_FrameOptionsError(t *testing.T) {
+	_, err := secure.New(secure.WithSettings(secure.Settings{FrameOptions: "ALLOW-FROM https://x.com"}))
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestService_WithHeaders(t *testing.T) {
+	srv := secure.MustNew(
+		secure.WithRootConfig(cfgmock.NewService()),
+		secure.WithServiceErrorHandler(mw.ErrorWithPanic),
+		secure.WithSettings(secure.Settings{
+			HSTSMaxAge:            3600,
+			HSTSIncludeSubdomains: true,
+			HSTSPreload:           true,
+			FrameOptions:          "sameorigin",
+			ReferrerPolicy:        "same-origin",
+			ContentSecurityPolicy: "default-src 'self'",
+		}, scope.Website.Pack(2)),
+		secure.WithDisable(true, scope.Website.Pack(3)),
+	)
+
+	t.Run("default scope", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		srv.WithHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, secure.FromContextNonce(r.Context()))
+		})).ServeHTTP(rec, reqWithWebsite(1))
+
+		assert.Exactly(t, secure.FrameOptionsSameOrigin, rec.Header().Get(secure.HeaderFrameOptions))
+		assert.Exactly(t, "nosniff", rec.Header().Get(secure.HeaderContentTypeOptions))
+		assert.Exactly(t, "1; mode=block", rec.Header().Get(secure.HeaderXSSProtection))
+		assert.Empty(t, rec.Header().Get(secure.HeaderCSP))
+	})
+	t.Run("website without TLS", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := reqWithWebsite(2)
+		req.TLS = nil
+		srv.WithHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, req)
+
+		assert.Empty(t, rec.Header().Get(secure.HeaderStrictTransportSecurity))
+		assert.Empty(t, rec.Header().Get(secure.HeaderContentTypeOptions))
+		assert.Empty(t, rec.Header().Get(secure.HeaderXSSProtection))
+		assert.Exactly(t, "same-origin", rec.Header().Get(secure.HeaderReferrerPolicy))
+		assert.Exactly(t, "default-src 'self'", rec.Header().Get(secure.HeaderCSP))
+	})
+	t.Run("website with TLS", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := reqWithWebsite(2)
+		req.TLS = &tls.ConnectionState{}
+		srv.WithHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, req)
+		assert.Exactly(t, "max-age=3600; includeSubDomains; preload", rec.Header().Get(secure.HeaderStrictTransportSecurity))
+	})
+	t.Run("disabled website", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		srv.WithHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, reqWithWebsite(3))
+		assert.Len(t, rec.Header(), 0)
+	})
+}
+
+func TestService_WithHeaders_Nonce(t *testing.T) {
+	srv := secure.MustNew(
+		secure.WithRootConfig(cfgmock.NewService()),
+		secure.WithServiceErrorHandler(mw.ErrorWithPanic),
+		secure.WithSettings(secure.Settings{
+			ContentSecurityPolicy: "script-src 'nonce-$NONCE'; style-src 'nonce-$NONCE'",
+		}),
+	)
+	var nonces []string
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		var nonce string
+		srv.WithHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nonce = secure.FromContextNonce(r.Context())
+		})).ServeHTTP(rec, reqWithWebsite(1))
+
+		assert.Len(t, nonce, 24)
+		assert.Exactly(t, "script-src 'nonce-"+nonce+"'; style-src 'nonce-"+nonce+"'", rec.Header().Get(secure.HeaderCSP))
+		nonces = append(nonces, nonce)
+	}
+	assert.NotEqual(t, nonces[0], nonces[1])
+}
@@ -31,6 +31,16 @@
 // backend. The backend can verify the request body by recalculating the hash
 // found in the header.
 //
+// Outgoing requests, for example webhooks, can be signed with the
+// http.RoundTripper Transport. The receiving side verifies them with the
+// middleware WithRequestSignatureValidation or, if the handler knows the scope,
+// with Service.ValidateRequest. Both sides share the scoped HMAC configuration.
+//
+// A signature alone can be replayed. The option WithReplayProtection adds a
+// signed timestamp and a nonce to each request. The receiver rejects
+// timestamps outside the allowed clock skew and nonces already stored in the
+// NonceCacher.
+//
 // TODO(CyS) create a flowchart to demonstrate the usage.
 //
 // https://tools.ietf.org/html/draft-thomson-http-content-signature-00
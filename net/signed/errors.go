@@ -19,6 +19,11 @@ const (
 	errScopedConfigMethodNotAllowed = `[signed] ValidateBody HTTP Method %q not allowed in list: %q`
 	errScopedConfigSignatureNoMatch = `[signed] ValidateBody. Signatures do not match. Have: %q Want: %q`
 	errScopedConfigCacheNotFound    = `[signed] ValidateBody. Signature %q not found in cache`
+	errScopedConfigNonceCacherNil   = `[signed] ScopedConfig %s requires a NonceCacher for the MaxClockSkew %s`
+	errReplayHeaderNotFound         = `[signed] ValidateBody. Header %q or %q not found or empty`
+	errReplayTimestampNotValid      = `[signed] ValidateBody. Timestamp %q not valid`
+	errReplayTimestampSkew          = `[signed] ValidateBody. Timestamp %q deviates %s, allowed %s`
+	errReplayNonceUsed              = `[signed] ValidateBody. Nonce %q already used`
 	errSignatureParseNotFound       = `[signed] Signature not found or empty`
 	errSignatureParseInvalidHeader  = `[signed] Invalid signature header: %q`
 	errSignatureParseInvalidKeyID   = `[signed] KeyID %q does not match required %q in header: %q`
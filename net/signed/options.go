@@ -88,3 +88,17 @@ func WithTransparent(c Cacher, ttl time.Duration, scopeIDs ...scope.TypeID) Opti
 		return s.updateScopedConfig(sc)
 	}
 }
+
+// WithReplayProtection requires a timestamp and a nonce in the header of
+// incoming requests. A request gets rejected when its timestamp deviates more
+// than maxClockSkew from the current time or when its nonce has already been
+// seen within the validity window. The Transport and SignRequest add both
+// headers automatically. A maxClockSkew of zero disables the protection.
+func WithReplayProtection(maxClockSkew time.Duration, nc NonceCacher, scopeIDs ...scope.TypeID) Option {
+	return func(s *Service) error {
+		sc := s.findScopedConfig(scopeIDs...)
+		sc.MaxClockSkew = maxClockSkew
+		sc.NonceCacher = nc
+		return s.updateScopedConfig(sc)
+	}
+}
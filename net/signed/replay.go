@@ -0,0 +1,217 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signed
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/csfw/storage/transcache"
+	"github.com/corestoreio/errors"
+)
+
+// Header* constants are used as HTTP header key names for the replay
+// protection.
+const (
+	HeaderTimestamp = "X-Signature-Timestamp"
+	HeaderNonce     = "X-Signature-Nonce"
+)
+
+// nonceLength defines the amount of random bytes in a nonce. Gets hex encoded.
+const nonceLength = 16
+
+// NonceCacher stores already seen nonces for a limited time to detect replayed
+// requests. The implementation must be safe for concurrent use.
+type NonceCacher interface {
+	// SetNX adds the nonce with the time to live to the cache if it does not
+	// exists. The returned bool reports if the nonce has been added. False
+	// means that the nonce has already been used.
+	SetNX(nonce []byte, ttl time.Duration) (bool, error)
+}
+
+// NonceMemCache implements the NonceCacher interface for a single process.
+// Expired nonces get purged while adding new ones. Use a shared key value store
+// when running several instances behind a load balancer, see NonceTranscache.
+type NonceMemCache struct {
+	mu      sync.Mutex
+	nonces  map[string]time.Time
+	nextGC  time.Time
+	timeNow func() time.Time
+}
+
+// NewNonceMemCache creates a new in-memory nonce cache.
+func NewNonceMemCache() *NonceMemCache {
+	return &NonceMemCache{
+		nonces:  make(map[string]time.Time),
+		timeNow: time.Now,
+	}
+}
+
+// SetNX adds a nonce if it does not exist or has expired. Never returns an
+// error.
+func (c *NonceMemCache) SetNX(nonce []byte, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.timeNow()
+	if now.After(c.nextGC) {
+		for n, exp := range c.nonces {
+			if now.After(exp) {
+				delete(c.nonces, n)
+			}
+		}
+		c.nextGC = now.Add(ttl)
+	}
+	if exp, ok := c.nonces[string(nonce)]; ok && !now.After(exp) {
+		return false, nil
+	}
+	c.nonces[string(nonce)] = now.Add(ttl)
+	return true, nil
+}
+
+// Len returns the number of stored nonces including the expired but not yet
+// purged ones.
+func (c *NonceMemCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.nonces)
+}
+
+// NonceTranscache implements the NonceCacher interface with a
+// transcache.Cacher, for example Redis, to share the used nonces between
+// several instances. If the Cacher implements transcache.SetNXer the nonce
+// gets added atomically with the time to live by the cache. Otherwise the
+// expiry time gets stored as value and the nonce gets checked with Get and Set
+// guarded by a mutex, which is only atomic within one process and relies on
+// the cache to remove old entries.
+type NonceTranscache struct {
+	// Prefix gets prepended to each nonce to create the cache key.
+	Prefix  []byte
+	cache   transcache.Cacher
+	mu      sync.Mutex
+	timeNow func() time.Time
+}
+
+// NewNonceTranscache creates a new nonce cache on top of a transcache.Cacher.
+// Argument prefix separates the nonces from other keys in the cache.
+func NewNonceTranscache(c transcache.Cacher, prefix string) *NonceTranscache {
+	return &NonceTranscache{
+		Prefix:  []byte(prefix),
+		cache:   c,
+		timeNow: time.Now,
+	}
+}
+
+// SetNX adds a nonce if it does not exist or has expired. Returns the errors
+// of the underlying cache.
+func (c *NonceTranscache) SetNX(nonce []byte, ttl time.Duration) (bool, error) {
+	key := make([]byte, 0, len(c.Prefix)+len(nonce))
+	key = append(append(key, c.Prefix...), nonce...)
+
+	if nx, ok := c.cache.(transcache.SetNXer); ok {
+		added, err := nx.SetNX(key, []byte{'1'}, ttl)
+		return added, errors.Wrap(err, "[signed] NonceTranscache.SetNX")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.timeNow()
+	v, err := c.cache.Get(key)
+	switch {
+	case err == nil && len(v) == 8 && now.UnixNano() <= int64(binary.BigEndian.Uint64(v)):
+		return false, nil
+	case err != nil && !errors.IsNotFound(err):
+		return false, errors.Wrap(err, "[signed] NonceTranscache.SetNX.Get")
+	}
+	var exp [8]byte
+	binary.BigEndian.PutUint64(exp[:], uint64(now.Add(ttl).UnixNano()))
+	if err := c.cache.Set(key, exp[:]); err != nil {
+		return false, errors.Wrap(err, "[signed] NonceTranscache.SetNX.Set")
+	}
+	return true, nil
+}
+
+// replayProtected reports if requests must contain a timestamp and a nonce.
+func (sc *ScopedConfig) replayProtected() bool {
+	return sc.MaxClockSkew > 0 && sc.TransparentCacher == nil
+}
+
+// writeReplayHeader sets the current timestamp and a new random nonce into the
+// request header and returns both values as prefix for the hash calculation.
+func (sc *ScopedConfig) writeReplayHeader(h http.Header) ([]byte, error) {
+	var nonce [nonceLength]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, errors.Wrap(err, "[signed] ScopedConfig.writeReplayHeader crypto/rand.Read")
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	n := hex.EncodeToString(nonce[:])
+	h.Set(HeaderTimestamp, ts)
+	h.Set(HeaderNonce, n)
+	return replayPrefix(ts, n), nil
+}
+
+// parseReplayHeader extracts timestamp and nonce from the request header and
+// checks the clock skew. Returns the prefix for the hash calculation and the
+// raw nonce.
+func (sc *ScopedConfig) parseReplayHeader(h http.Header) (prefix, nonce []byte, _ error) {
+	ts := h.Get(HeaderTimestamp)
+	n := h.Get(HeaderNonce)
+	if ts == "" || n == "" {
+		return nil, nil, errors.NewNotFoundf(errReplayHeaderNotFound, HeaderTimestamp, HeaderNonce)
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, nil, errors.NewNotValidf(errReplayTimestampNotValid, ts)
+	}
+	skew := time.Since(time.Unix(unix, 0))
+	if math.Abs(float64(skew)) > float64(sc.MaxClockSkew) {
+		return nil, nil, errors.NewNotValidf(errReplayTimestampSkew, ts, skew, sc.MaxClockSkew)
+	}
+	return replayPrefix(ts, n), []byte(n), nil
+}
+
+// checkNonce adds the nonce to the cache. The nonce must be kept at least
+// twice the clock skew because a timestamp is accepted from the past and from
+// the future.
+func (sc *ScopedConfig) checkNonce(nonce []byte) error {
+	ok, err := sc.NonceCacher.SetNX(nonce, 2*sc.MaxClockSkew)
+	if err != nil {
+		return errors.Wrap(err, "[signed] ScopedConfig.checkNonce.SetNX")
+	}
+	if !ok {
+		return errors.NewNotValidf(errReplayNonceUsed, nonce)
+	}
+	return nil
+}
+
+// replayPrefix gets hashed before the body so that the timestamp and the
+// nonce cannot be altered.
+func replayPrefix(ts, nonce string) []byte {
+	var buf bytes.Buffer
+	buf.Grow(len(ts) + len(nonce) + 2)
+	buf.WriteString(ts)
+	buf.WriteByte('\n')
+	buf.WriteString(nonce)
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
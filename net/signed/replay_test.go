@@ -0,0 +1,286 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signed_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/signed"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReplayTestService(nc signed.NonceCacher) *signed.Service {
+	key := []byte(`My guinea p1g run5 acro55 my keyb0ard`)
+	return signed.MustNew(
+		signed.WithDebugLog(ioutil.Discard),
+		signed.WithRootConfig(cfgmock.NewService()),
+		signed.WithHeaderHandler(signed.NewContentHMAC("sha256"), scope.Website.Pack(1)),
+		signed.WithHash("sha256", key, scope.Website.Pack(1)),
+		signed.WithReplayProtection(time.Minute, nc, scope.Website.Pack(1)),
+	)
+}
+
+func newSignedReplayRequest(t *testing.T, srv *signed.Service) *http.Request {
+	scpCfg, err := srv.ConfigByScope(1, 0)
+	require.NoError(t, err, "%+v", err)
+	req := httptest.NewRequest("POST", "http://corestore.io/webhook", bytes.NewReader(testData))
+	require.NoError(t, scpCfg.SignRequest(req))
+	return req
+}
+
+// replayRequest copies the headers and the body of a request to simulate a
+// captured and resent request.
+func replayRequest(r *http.Request) *http.Request {
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r2 := httptest.NewRequest(r.Method, r.URL.String(), bytes.NewReader(body))
+	for k, v := range r.Header {
+		r2.Header[k] = append([]string(nil), v...)
+	}
+	return r2
+}
+
+func TestWithReplayProtection_NonceCacherRequired(t *testing.T) {
+	srv := newReplayTestService(nil)
+	_, err := srv.ConfigByScope(1, 0)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestService_ValidateRequest_Replay(t *testing.T) {
+	nc := signed.NewNonceMemCache()
+	srv := newReplayTestService(nc)
+
+	req := newSignedReplayRequest(t, srv)
+	assert.NotEmpty(t, req.Header.Get(signed.HeaderTimestamp))
+	assert.Len(t, req.Header.Get(signed.HeaderNonce), 32)
+	replayed := replayRequest(req)
+
+	assert.NoError(t, srv.ValidateRequest(req, 1, 0))
+	err := srv.ValidateRequest(replayed, 1, 0)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	assert.Contains(t, err.Error(), "already used")
+	assert.Exactly(t, 1, nc.Len())
+
+	// a new signature with a new nonce passes
+	assert.NoError(t, srv.ValidateRequest(newSignedReplayRequest(t, srv), 1, 0))
+	assert.Exactly(t, 2, nc.Len())
+}
+
+func TestService_ValidateRequest_ReplayHeader(t *testing.T) {
+	nc := signed.NewNonceMemCache()
+	srv := newReplayTestService(nc)
+
+	tests := []struct {
+		name    string
+		modify  func(h http.Header)
+		wantErr errors.BehaviourFunc
+	}{
+		{"missing timestamp", func(h http.Header) { h.Del(signed.HeaderTimestamp) }, errors.IsNotFound},
+		{"missing nonce", func(h http.Header) { h.Del(signed.HeaderNonce) }, errors.IsNotFound},
+		{"timestamp malformed", func(h http.Header) { h.Set(signed.HeaderTimestamp, "yesterday") }, errors.IsNotValid},
+		{"timestamp too old", func(h http.Header) {
+			h.Set(signed.HeaderTimestamp, strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10))
+		}, errors.IsNotValid},
+		{"timestamp too far in the future", func(h http.Header) {
+			h.Set(signed.HeaderTimestamp, strconv.FormatInt(time.Now().Add(2*time.Minute).Unix(), 10))
+		}, errors.IsNotValid},
+		{"timestamp altered", func(h http.Header) {
+			ts, _ := strconv.ParseInt(h.Get(signed.HeaderTimestamp), 10, 64)
+			h.Set(signed.HeaderTimestamp, strconv.FormatInt(ts-1, 10))
+		}, errors.IsNotValid},
+		{"nonce altered", func(h http.Header) { h.Set(signed.HeaderNonce, "0123456789abcdef0123456789abcdef") }, errors.IsNotValid},
+	}
+	for _, test := range tests {
+		req := newSignedReplayRequest(t, srv)
+		test.modify(req.Header)
+		err := srv.ValidateRequest(req, 1, 0)
+		assert.True(t, test.wantErr(err), "%s: %+v", test.name, err)
+	}
+	assert.Exactly(t, 0, nc.Len(), "Rejected requests must not store a nonce")
+}
+
+func TestTransport_RoundTrip_Replay(t *testing.T) {
+	srv := newReplayTestService(signed.NewNonceMemCache())
+
+	var lastReq *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastReq = replayRequest(r)
+		if err := srv.ValidateRequest(r, 1, 0); err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	cl := &http.Client{Transport: &signed.Transport{Service: srv, WebsiteID: 1}}
+	for i := 0; i < 3; i++ {
+		resp, err := cl.Post(ts.URL, "text/plain", bytes.NewReader(testData))
+		require.NoError(t, err, "%+v", err)
+		resp.Body.Close()
+		assert.Exactly(t, http.StatusAccepted, resp.StatusCode, "Request %d", i)
+	}
+
+	err := srv.ValidateRequest(lastReq, 1, 0)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestNonceMemCache_SetNX(t *testing.T) {
+	nc := signed.NewNonceMemCache()
+
+	ok, err := nc.SetNX([]byte("a"), time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = nc.SetNX([]byte("a"), time.Millisecond)
+	assert.False(t, ok)
+
+	time.Sleep(3 * time.Millisecond)
+	ok, _ = nc.SetNX([]byte("b"), time.Minute)
+	assert.True(t, ok)
+	assert.Exactly(t, 1, nc.Len(), "Expired nonce a should have been purged")
+	ok, _ = nc.SetNX([]byte("a"), time.Minute)
+	assert.True(t, ok)
+}
+
+// mapCacher implements the transcache.Cacher interface without a time to live.
+type mapCacher struct {
+	mu     sync.Mutex
+	data   map[string][]byte
+	getErr error
+}
+
+func newMapCacher() *mapCacher {
+	return &mapCacher{data: make(map[string][]byte)}
+}
+
+func (mc *mapCacher) Set(key, value []byte) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.data[string(key)] = value
+	return nil
+}
+
+func (mc *mapCacher) Get(key []byte) ([]byte, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if mc.getErr != nil {
+		return nil, mc.getErr
+	}
+	v, ok := mc.data[string(key)]
+	if !ok {
+		return nil, errors.NewNotFoundf("[signed_test] Key %q not found", key)
+	}
+	return v, nil
+}
+
+func (mc *mapCacher) Close() error { return nil }
+
+// nxCacher implements additionally the transcache.SetNXer interface.
+type nxCacher struct {
+	*mapCacher
+	ttls []time.Duration
+}
+
+func (nc *nxCacher) SetNX(key, value []byte, ttl time.Duration) (bool, error) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.ttls = append(nc.ttls, ttl)
+	if _, ok := nc.data[string(key)]; ok {
+		return false, nil
+	}
+	nc.data[string(key)] = value
+	return true, nil
+}
+
+func TestNonceTranscache_SetNX(t *testing.T) {
+	var _ signed.NonceCacher = (*signed.NonceTranscache)(nil)
+
+	t.Run("Get and Set", func(t *testing.T) {
+		mc := newMapCacher()
+		nc := signed.NewNonceTranscache(mc, "nonce_")
+
+		ok, err := nc.SetNX([]byte("a"), time.Millisecond)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = nc.SetNX([]byte("a"), time.Millisecond)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Contains(t, mc.data, "nonce_a")
+
+		time.Sleep(3 * time.Millisecond)
+		ok, err = nc.SetNX([]byte("a"), time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, ok, "Expired nonce can be added again")
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		nc := signed.NewNonceTranscache(newMapCacher(), "")
+		var wg sync.WaitGroup
+		var added int32
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if ok, _ := nc.SetNX([]byte("a"), time.Minute); ok {
+					atomic.AddInt32(&added, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Exactly(t, int32(1), added)
+	})
+
+	t.Run("Get error", func(t *testing.T) {
+		mc := newMapCacher()
+		mc.getErr = errors.NewFatalf("[signed_test] connection refused")
+		ok, err := signed.NewNonceTranscache(mc, "").SetNX([]byte("a"), time.Minute)
+		assert.False(t, ok)
+		assert.True(t, errors.IsFatal(err), "%+v", err)
+	})
+
+	t.Run("SetNXer", func(t *testing.T) {
+		nxc := &nxCacher{mapCacher: newMapCacher()}
+		nc := signed.NewNonceTranscache(nxc, "n:")
+		ok, err := nc.SetNX([]byte("a"), time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = nc.SetNX([]byte("a"), time.Minute)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Exactly(t, []time.Duration{time.Minute, time.Minute}, nxc.ttls)
+		assert.Contains(t, nxc.data, "n:a")
+	})
+
+	t.Run("replay protection", func(t *testing.T) {
+		srv := newReplayTestService(signed.NewNonceTranscache(newMapCacher(), "nonce_"))
+		req := newSignedReplayRequest(t, srv)
+		replayed := replayRequest(req)
+		assert.NoError(t, srv.ValidateRequest(req, 1, 0))
+		err := srv.ValidateRequest(replayed, 1, 0)
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+}
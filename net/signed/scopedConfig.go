@@ -61,6 +61,15 @@ type ScopedConfig struct {
 	// TransparentTTL defines the time to live for a hash within the Cacher
 	// interface.
 	TransparentTTL time.Duration
+	// MaxClockSkew enables the replay protection when greater zero. Requests
+	// must then contain the headers HeaderTimestamp and HeaderNonce. Both
+	// values are part of the signature. A timestamp which deviates more than
+	// MaxClockSkew from the current time or an already used nonce gets
+	// rejected. Not supported with transparent hashing.
+	MaxClockSkew time.Duration
+	// NonceCacher stores the used nonces. Must be set when MaxClockSkew is
+	// greater zero.
+	NonceCacher NonceCacher
 }
 
 // newScopedConfig creates a new object with the minimum needed configuration.
@@ -103,6 +112,9 @@ func (sc *ScopedConfig) isValid() error {
 	if sc.HeaderParseWriter == nil || len(sc.AllowedMethods) == 0 {
 		return errors.NewNotValidf(errScopedConfigNotValid, sc.ScopeID, sc.HeaderParseWriter == nil, sc.AllowedMethods)
 	}
+	if sc.MaxClockSkew > 0 && sc.NonceCacher == nil {
+		return errors.NewNotValidf(errScopedConfigNonceCacherNil, sc.ScopeID, sc.MaxClockSkew)
+	}
 	return nil
 }
 
@@ -156,26 +168,39 @@ func (sc *ScopedConfig) writeBuffered(next http.Handler, w http.ResponseWriter,
 // gets read into a buffer. This buffer gets assigned to the r.Body to make a
 // read possible for the next consumer.
 func (sc *ScopedConfig) CalculateHash(r *http.Request) ([]byte, error) {
+	return sc.calculateHash(r, nil)
+}
+
+// calculateHash writes the optional prefix into the hash before the body.
+func (sc *ScopedConfig) calculateHash(r *http.Request, prefix []byte) ([]byte, error) {
 
 	h := sc.hashPool.Get()
 	defer sc.hashPool.Put(h)
 	defer r.Body.Close()
 
+	if _, err := h.Write(prefix); err != nil {
+		return nil, errors.Wrap(err, "[signed] ValidateBody Hash.Write")
+	}
+
 	// copy the body so that the next consumer can read it.
 	body := new(bytes.Buffer)
 	buf := make([]byte, 4096) // maybe make it configurable ...
 	for {
 		n, err := r.Body.Read(buf)
+		// a reader might return data together with io.EOF, e.g. a body
+		// received from the network.
+		if n > 0 {
+			if _, err := h.Write(buf[:n]); err != nil {
+				return nil, errors.Wrap(err, "[signed] ValidateBody Hash.Write")
+			}
+			_, _ = body.Write(buf[:n])
+		}
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
 			return nil, errors.Wrap(err, "[signed] ValidateBody HTTP.Body.Read")
 		}
-		if _, err := h.Write(buf[:n]); err != nil {
-			return nil, errors.Wrap(err, "[signed] ValidateBody Hash.Write")
-		}
-		_, _ = body.Write(buf[:n])
 	}
 
 	r.Body = ioutil.NopCloser(body)
@@ -195,14 +220,25 @@ func (sc *ScopedConfig) isMethodAllowed(reqMethod string) bool {
 
 // ValidateBody uses the HTTPParser to extract the hash signature. It then
 // hashes the body and compares the hash of the body with the hash value found
-// in the HTTP header. Hash comparison via constant time.
+// in the HTTP header. Hash comparison via constant time. With enabled replay
+// protection the timestamp and the nonce get checked before and the nonce gets
+// stored after a successful comparison.
 func (sc *ScopedConfig) ValidateBody(r *http.Request) error {
 
 	if !sc.isMethodAllowed(r.Method) {
 		return errors.NewNotValidf(errScopedConfigMethodNotAllowed, r.Method, sc.AllowedMethods)
 	}
 
-	hashSum, err := sc.CalculateHash(r)
+	var prefix, nonce []byte
+	if sc.replayProtected() {
+		var err error
+		if prefix, nonce, err = sc.parseReplayHeader(r.Header); err != nil {
+			closeBody(r)
+			return errors.Wrap(err, "[signed] ScopedConfig.ValidateBody.parseReplayHeader")
+		}
+	}
+
+	hashSum, err := sc.calculateHash(r, prefix)
 	if err != nil {
 		return errors.Wrap(err, "[signed] ScopedConfig.ValidateBody.calculateHash")
 	}
@@ -226,5 +262,8 @@ func (sc *ScopedConfig) ValidateBody(r *http.Request) error {
 		return errors.NewNotValidf(errScopedConfigSignatureNoMatch, reqHashSum, hashSum)
 	}
 
+	if nonce != nil {
+		return errors.Wrap(sc.checkNonce(nonce), "[signed] ScopedConfig.ValidateBody.checkNonce")
+	}
 	return nil
 }
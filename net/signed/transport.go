@@ -0,0 +1,161 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signed
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// Transport implements the http.RoundTripper interface and signs outgoing
+// HTTP requests, for example webhooks sent to a 3rd party. The key, the hash
+// algorithm, the header scheme and the allowed methods are taken from the
+// scoped configuration of the Service, the same configuration the middleware
+// WithRequestSignatureValidation uses to verify incoming requests.
+//
+// The scope gets extracted from the request context via scope.FromContext. If
+// the context does not contain a scope, the fields WebsiteID and StoreID are
+// used. Requests with a method not listed in AllowedMethods or for a disabled
+// scope are passed unchanged to the Base transport.
+//
+// The signature gets always written into the HTTP header, the InTrailer
+// setting is ignored because the body must be buffered anyway to be replayed.
+type Transport struct {
+	// Service provides the scoped configuration. Required.
+	Service *Service
+	// Base (optional) the underlying transport. Defaults to
+	// http.DefaultTransport.
+	Base http.RoundTripper
+	// WebsiteID and StoreID are used as scope when the request context does
+	// not contain one.
+	WebsiteID, StoreID int64
+}
+
+// NewTransport creates a new signing transport for the default scope. Use the
+// returned type as http.Client.Transport.
+func NewTransport(s *Service, base http.RoundTripper) *Transport {
+	return &Transport{
+		Service: s,
+		Base:    base,
+	}
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip signs the request body and executes the request with the Base
+// transport. The original request won't be modified, as required by the
+// http.RoundTripper contract.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	websiteID, storeID, ok := scope.FromContext(r.Context())
+	if !ok {
+		websiteID, storeID = t.WebsiteID, t.StoreID
+	}
+
+	scpCfg, err := t.Service.ConfigByScope(websiteID, storeID)
+	if err != nil {
+		closeBody(r)
+		return nil, errors.Wrapf(err, "[signed] Transport.RoundTrip.ConfigByScope Website %d Store %d", websiteID, storeID)
+	}
+	if scpCfg.Disabled || !scpCfg.isMethodAllowed(r.Method) {
+		return t.base().RoundTrip(r)
+	}
+
+	r2 := cloneRequest(r)
+	if err := scpCfg.SignRequest(r2); err != nil {
+		return nil, errors.Wrap(err, "[signed] Transport.RoundTrip.SignRequest")
+	}
+	return t.base().RoundTrip(r2)
+}
+
+// SignRequest calculates the hash of the request body and writes the
+// signature with the HeaderParseWriter into the request header. The body gets
+// buffered and reassigned to r.Body so that it can be sent afterwards. A nil
+// body gets hashed as an empty body. With enabled replay protection the
+// headers HeaderTimestamp and HeaderNonce get set and signed too.
+func (sc *ScopedConfig) SignRequest(r *http.Request) error {
+	if err := sc.isValid(); err != nil {
+		closeBody(r)
+		return errors.Wrap(err, "[signed] ScopedConfig.SignRequest.isValid")
+	}
+	if r.Body == nil {
+		r.Body = ioutil.NopCloser(bytes.NewReader(nil))
+	}
+	var prefix []byte
+	if sc.replayProtected() {
+		var err error
+		if prefix, err = sc.writeReplayHeader(r.Header); err != nil {
+			closeBody(r)
+			return errors.Wrap(err, "[signed] ScopedConfig.SignRequest.writeReplayHeader")
+		}
+	}
+	sum, err := sc.calculateHash(r, prefix)
+	if err != nil {
+		return errors.Wrap(err, "[signed] ScopedConfig.SignRequest.CalculateHash")
+	}
+	sc.HeaderParseWriter.Write(requestHeaderWriter(r.Header), sum)
+	return nil
+}
+
+// ValidateRequest verifies the signature of an incoming request, for example
+// a webhook sent by a 3rd party, against the configuration of the provided
+// scope. Use it in handlers which know the scope on their own, otherwise the
+// middleware WithRequestSignatureValidation is the better choice. Errors have
+// the behaviour NotValid or NotFound.
+func (s *Service) ValidateRequest(r *http.Request, websiteID, storeID int64) error {
+	scpCfg, err := s.ConfigByScope(websiteID, storeID)
+	if err != nil {
+		return errors.Wrapf(err, "[signed] Service.ValidateRequest.ConfigByScope Website %d Store %d", websiteID, storeID)
+	}
+	if scpCfg.Disabled {
+		return nil
+	}
+	return errors.Wrap(scpCfg.ValidateBody(r), "[signed] Service.ValidateRequest.ValidateBody")
+}
+
+// requestHeaderWriter adapts a request header to the http.ResponseWriter
+// interface so that the HeaderParseWriter types can write into a request.
+type requestHeaderWriter http.Header
+
+func (h requestHeaderWriter) Header() http.Header         { return http.Header(h) }
+func (h requestHeaderWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (h requestHeaderWriter) WriteHeader(int)             {}
+
+// cloneRequest returns a shallow copy of r with a deep copy of the header.
+func cloneRequest(r *http.Request) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = make(http.Header, len(r.Header)+1)
+	for k, v := range r.Header {
+		r2.Header[k] = append([]string(nil), v...)
+	}
+	return r2
+}
+
+func closeBody(r *http.Request) {
+	if r.Body != nil {
+		_ = r.Body.Close()
+	}
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
@@ -0,0 +1,144 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signed_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/net/signed"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTransportTestService(t *testing.T) *signed.Service {
+	key := []byte(`My guinea p1g run5 acro55 my keyb0ard`)
+	return signed.MustNew(
+		signed.WithDebugLog(ioutil.Discard),
+		signed.WithRootConfig(cfgmock.NewService()),
+		signed.WithHeaderHandler(signed.NewContentHMAC("sha256"), scope.Website.Pack(1)),
+		signed.WithHash("sha256", key, scope.Website.Pack(1)),
+		signed.WithHeaderHandler(signed.NewContentSignature("hmac-key-2", "sha256"), scope.Website.Pack(2)),
+		signed.WithHash("sha256", key, scope.Website.Pack(2)),
+		signed.WithDisable(true, scope.Website.Pack(3)),
+	)
+}
+
+func TestTransport_RoundTrip(t *testing.T) {
+	srv := newTransportTestService(t)
+
+	tests := []struct {
+		websiteID  int64
+		headerKey  string
+		wantHeader string
+	}{
+		{1, signed.HeaderContentHMAC, `sha256 7dace9827fd7aa3c83eee3776a81d03653ba1e272c98809f0752d9ded4561419`},
+		{2, signed.HeaderContentSignature, `keyId="hmac-key-2",algorithm="sha256",signature="7dace9827fd7aa3c83eee3776a81d03653ba1e272c98809f0752d9ded4561419"`},
+	}
+	for _, test := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Exactly(t, test.wantHeader, r.Header.Get(test.headerKey), "Website %d", test.websiteID)
+			if err := srv.ValidateRequest(r, test.websiteID, 0); err != nil {
+				t.Errorf("Website %d: %+v", test.websiteID, err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.Exactly(t, testData, body)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+
+		cl := &http.Client{
+			Transport: &signed.Transport{Service: srv, WebsiteID: test.websiteID},
+		}
+		req, err := http.NewRequest("POST", ts.URL, bytes.NewReader(testData))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := cl.Do(req)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		resp.Body.Close()
+		assert.Exactly(t, http.StatusAccepted, resp.StatusCode, "Website %d", test.websiteID)
+		assert.Empty(t, req.Header.Get(test.headerKey), "Original request must not be modified")
+		ts.Close()
+	}
+}
+
+func TestTransport_RoundTrip_Skipped(t *testing.T) {
+	srv := newTransportTestService(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(signed.HeaderContentHMAC))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	t.Run("method not allowed", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", ts.URL, nil)
+		req = req.WithContext(scope.WithContext(req.Context(), 1, 0))
+		resp, err := (&http.Client{Transport: signed.NewTransport(srv, nil)}).Do(req)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		resp.Body.Close()
+		assert.Exactly(t, http.StatusOK, resp.StatusCode)
+	})
+	t.Run("scope disabled", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", ts.URL, bytes.NewReader(testData))
+		req = req.WithContext(scope.WithContext(req.Context(), 3, 0))
+		resp, err := (&http.Client{Transport: signed.NewTransport(srv, nil)}).Do(req)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		resp.Body.Close()
+		assert.Exactly(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestService_ValidateRequest(t *testing.T) {
+	srv := newTransportTestService(t)
+
+	t.Run("missing signature", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "http://corestore.io/webhook", bytes.NewReader(testData))
+		err := srv.ValidateRequest(req, 1, 0)
+		assert.True(t, errors.IsNotFound(err), "%+v", err)
+	})
+	t.Run("wrong signature", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "http://corestore.io/webhook", bytes.NewReader(testData))
+		req.Header.Set(signed.HeaderContentHMAC, `sha256 7dace9827fd7aa3c83eee3776a81d03653ba1e272c98809f0752d9ded4561418`)
+		err := srv.ValidateRequest(req, 1, 0)
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "http://corestore.io/webhook", bytes.NewReader(testData))
+		assert.NoError(t, srv.ValidateRequest(req, 3, 0))
+	})
+	t.Run("signed via ScopedConfig", func(t *testing.T) {
+		scpCfg, err := srv.ConfigByScope(1, 0)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		req := httptest.NewRequest("PUT", "http://corestore.io/webhook", bytes.NewReader(testData))
+		assert.NoError(t, scpCfg.SignRequest(req))
+		assert.NoError(t, srv.ValidateRequest(req, 1, 0))
+	})
+}
@@ -0,0 +1,289 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+)
+
+// BulkDMLOptions configures ExecBulkDML.
+type BulkDMLOptions struct {
+	// BatchSize number of statements executed within one transaction.
+	// Defaults to 100.
+	BatchSize int
+	// MaxRetries defines how often a batch gets repeated after a dead lock or
+	// a lock wait timeout. Defaults to 3, a negative value disables retries.
+	MaxRetries int
+	// RetryWait duration to wait before a batch gets repeated. Multiplied by
+	// the retry attempt. Defaults to 50ms.
+	RetryWait time.Duration
+	// IsolationLevel of each transaction. Default level of the driver.
+	IsolationLevel sql.IsolationLevel
+	// Log reports for each batch the timing on level info. Can be nil.
+	Log log.Logger
+}
+
+// BulkDMLBatch contains the outcome of one transaction.
+type BulkDMLBatch struct {
+	// Statements number of statements in this batch.
+	Statements   int
+	RowsAffected int64
+	// Retries number of repetitions due to dead locks.
+	Retries  int
+	Duration time.Duration
+}
+
+// BulkDMLResult aggregates the outcome of all batches.
+type BulkDMLResult struct {
+	Batches      []BulkDMLBatch
+	RowsAffected int64
+}
+
+type bulkStmt struct {
+	key  []interface{} // nil if the primary key could not be detected
+	sql  string
+	args []interface{}
+}
+
+// ExecBulkDML executes many UPDATE and DELETE statements for this table. To
+// minimize dead locks with concurrent writers, the statements get ordered by
+// the primary key values found in their WHERE equality conditions, so all
+// writers lock the rows in the same order. Statements without a detectable
+// primary key run last in their original order. The statements get grouped
+// into transactions of BatchSize. A batch which fails due to a dead lock or a
+// lock wait timeout gets rolled back and repeated up to MaxRetries times.
+//
+// Only *dbr.Update and *dbr.Delete builders targeting this table are
+// supported. Already committed batches won't be rolled back on error; the
+// result contains all finished batches. Error behaviour: NotSupported,
+// NotValid or the error of the failed batch.
+func (t *Table) ExecBulkDML(ctx context.Context, db dbr.TxBeginner, o BulkDMLOptions, stmts ...dbr.QueryBuilder) (BulkDMLResult, error) {
+	var res BulkDMLResult
+	if o.BatchSize < 1 {
+		o.BatchSize = 100
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = 3
+	}
+	if o.RetryWait <= 0 {
+		o.RetryWait = 50 * time.Millisecond
+	}
+
+	bs, err := t.bulkStatements(stmts)
+	if err != nil {
+		return res, errors.Wrapf(err, "[csdb] ExecBulkDML for table %q", t.Name)
+	}
+
+	for start, batch := 0, 0; start < len(bs); start, batch = start+o.BatchSize, batch+1 {
+		end := start + o.BatchSize
+		if end > len(bs) {
+			end = len(bs)
+		}
+
+		b := BulkDMLBatch{Statements: end - start}
+		now := time.Now()
+		for {
+			b.RowsAffected, err = execBulkBatch(ctx, db, o.IsolationLevel, bs[start:end])
+			if err == nil || b.Retries >= o.MaxRetries || !isLockError(err) {
+				break
+			}
+			b.Retries++
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-time.After(time.Duration(b.Retries) * o.RetryWait):
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		b.Duration = time.Since(now)
+
+		if o.Log != nil && o.Log.IsInfo() {
+			o.Log.Info("csdb.Table.ExecBulkDML.Batch", log.String("table", t.Name), log.Int("batch", batch),
+				log.Int("statements", b.Statements), log.Int64("rows_affected", b.RowsAffected),
+				log.Int("retries", b.Retries), log.Duration("duration", b.Duration), log.Err(err))
+		}
+		if err != nil {
+			return res, errors.Wrapf(err, "[csdb] ExecBulkDML batch %d failed for table %q after %d retries", batch, t.Name, b.Retries)
+		}
+		res.Batches = append(res.Batches, b)
+		res.RowsAffected += b.RowsAffected
+	}
+	return res, nil
+}
+
+// bulkStatements generates the SQL of all statements and sorts them by their
+// primary key values.
+func (t *Table) bulkStatements(stmts []dbr.QueryBuilder) ([]bulkStmt, error) {
+	pks := t.Columns.PrimaryKeys().FieldNames()
+	bs := make([]bulkStmt, len(stmts))
+	for i, qb := range stmts {
+		var table string
+		var wfs dbr.WhereFragments
+		switch s := qb.(type) {
+		case *dbr.Update:
+			table, wfs = s.Table.Expression, s.WhereFragments
+		case *dbr.Delete:
+			table, wfs = s.From.Expression, s.WhereFragments
+		default:
+			return nil, errors.NewNotSupportedf("[csdb] Statement %T at index %d not supported", qb, i)
+		}
+		if table != t.Name {
+			return nil, errors.NewNotValidf("[csdb] Statement at index %d targets table %q", i, table)
+		}
+
+		sqlStr, args, err := qb.ToSQL()
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] Statement at index %d", i)
+		}
+		bs[i] = bulkStmt{
+			key:  primaryKeyValues(pks, wfs),
+			sql:  sqlStr,
+			args: args.Interfaces(),
+		}
+	}
+
+	sort.SliceStable(bs, func(i, j int) bool {
+		switch {
+		case bs[i].key == nil:
+			return false
+		case bs[j].key == nil:
+			return true
+		}
+		return compareKeys(bs[i].key, bs[j].key) < 0
+	})
+	return bs, nil
+}
+
+// primaryKeyValues returns the values of the equality conditions for all
+// primary key columns or nil if one of the columns cannot be found.
+func primaryKeyValues(pks []string, wfs dbr.WhereFragments) []interface{} {
+	if len(pks) == 0 {
+		return nil
+	}
+	key := make([]interface{}, 0, len(pks))
+	for _, pk := range pks {
+		var found bool
+		for _, wf := range wfs {
+			col := wf.Condition
+			if i := strings.LastIndexByte(col, '.'); i >= 0 {
+				col = col[i+1:]
+			}
+			if col != pk || wf.Logical == 'o' || len(wf.Arguments) != 1 {
+				continue
+			}
+			if vals := wf.Arguments.Interfaces(); len(vals) == 1 {
+				key = append(key, vals[0])
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return key
+}
+
+// compareKeys compares the values of both keys in order. Values of different
+// types are treated as equal.
+func compareKeys(a, b []interface{}) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareValue(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+func compareValue(a, b interface{}) int {
+	switch av := a.(type) {
+	case int64:
+		if bv, ok := b.(int64); ok {
+			return cmpOrder(av < bv, av > bv)
+		}
+	case uint64:
+		if bv, ok := b.(uint64); ok {
+			return cmpOrder(av < bv, av > bv)
+		}
+	case float64:
+		if bv, ok := b.(float64); ok {
+			return cmpOrder(av < bv, av > bv)
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv)
+		}
+	case []byte:
+		if bv, ok := b.([]byte); ok {
+			return bytes.Compare(av, bv)
+		}
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			return cmpOrder(av.Before(bv), av.After(bv))
+		}
+	}
+	return 0
+}
+
+func cmpOrder(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// execBulkBatch runs the statements within one transaction.
+func execBulkBatch(ctx context.Context, db dbr.TxBeginner, il sql.IsolationLevel, stmts []bulkStmt) (rows int64, err error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: il})
+	if err != nil {
+		return 0, errors.Wrap(err, "[csdb] execBulkBatch.BeginTx")
+	}
+	for _, s := range stmts {
+		res, err := tx.ExecContext(ctx, s.sql, s.args...)
+		if err != nil {
+			if rErr := tx.Rollback(); rErr != nil {
+				return 0, errors.Wrapf(rErr, "[csdb] execBulkBatch.Rollback after: %s", err)
+			}
+			return 0, errors.Wrapf(err, "[csdb] execBulkBatch.ExecContext with query %q", s.sql)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, errors.Wrap(err, "[csdb] execBulkBatch.RowsAffected")
+		}
+		rows += n
+	}
+	return rows, errors.Wrap(tx.Commit(), "[csdb] execBulkBatch.Commit")
+}
+
+// isLockError checks if the MySQL error reports a dead lock or a lock wait
+// timeout. Both allow to repeat the transaction.
+func isLockError(err error) bool {
+	return dbr.IsDeadlock(err) || dbr.IsLockWaitTimeout(err)
+}
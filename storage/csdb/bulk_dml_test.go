@@ -0,0 +1,137 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBulkTestTable() *csdb.Table {
+	return csdb.NewTable("catalog_product_entity",
+		&csdb.Column{Field: "entity_id", ColumnType: "int(10) unsigned", Key: "PRI"},
+		&csdb.Column{Field: "sku", ColumnType: "varchar(64)"},
+	)
+}
+
+func bulkUpdate(id int64, sku string) *dbr.Update {
+	return dbr.NewUpdate("catalog_product_entity").Set("sku", dbr.ArgString(sku)).
+		Where(dbr.Condition("entity_id", dbr.ArgInt64(id)))
+}
+
+func TestTable_ExecBulkDML(t *testing.T) {
+	const updateSQL = "UPDATE `catalog_product_entity` SET `sku`=? WHERE (`entity_id` = ?)"
+
+	t.Run("ordered by primary key and batched", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Error("there were unfulfilled expections", err)
+			}
+		}()
+
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(regexp.QuoteMeta(updateSQL)).WithArgs("b", int64(10)).WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec(regexp.QuoteMeta(updateSQL)).WithArgs("c", int64(20)).WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectCommit()
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(regexp.QuoteMeta(updateSQL)).WithArgs("a", int64(30)).WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `catalog_product_entity` WHERE (`sku` = ?)")).WithArgs("x").WillReturnResult(sqlmock.NewResult(0, 3))
+		dbMock.ExpectCommit()
+
+		res, err := newBulkTestTable().ExecBulkDML(context.TODO(), dbc.DB, csdb.BulkDMLOptions{BatchSize: 2},
+			bulkUpdate(30, "a"),
+			dbr.NewDelete("catalog_product_entity").Where(dbr.Condition("sku", dbr.ArgString("x"))),
+			bulkUpdate(10, "b"),
+			bulkUpdate(20, "c"),
+		)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, int64(6), res.RowsAffected)
+		assert.Len(t, res.Batches, 2)
+		assert.Exactly(t, 2, res.Batches[0].Statements)
+		assert.Exactly(t, int64(4), res.Batches[1].RowsAffected)
+	})
+
+	t.Run("dead lock retried", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Error("there were unfulfilled expections", err)
+			}
+		}()
+
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(regexp.QuoteMeta(updateSQL)).WithArgs("a", int64(1)).
+			WillReturnError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+		dbMock.ExpectRollback()
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(regexp.QuoteMeta(updateSQL)).WithArgs("a", int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectCommit()
+
+		res, err := newBulkTestTable().ExecBulkDML(context.TODO(), dbc.DB, csdb.BulkDMLOptions{RetryWait: time.Millisecond},
+			bulkUpdate(1, "a"),
+		)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, 1, res.Batches[0].Retries)
+		assert.Exactly(t, int64(1), res.RowsAffected)
+	})
+
+	t.Run("other errors not retried", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Error("there were unfulfilled expections", err)
+			}
+		}()
+
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(regexp.QuoteMeta(updateSQL)).WithArgs("a", int64(1)).
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+		dbMock.ExpectRollback()
+
+		res, err := newBulkTestTable().ExecBulkDML(context.TODO(), dbc.DB, csdb.BulkDMLOptions{}, bulkUpdate(1, "a"))
+		assert.Error(t, err)
+		assert.Empty(t, res.Batches)
+	})
+
+	t.Run("invalid statements", func(t *testing.T) {
+		tbl := newBulkTestTable()
+		_, err := tbl.ExecBulkDML(context.TODO(), nil, csdb.BulkDMLOptions{}, dbr.NewSelect("a").From("catalog_product_entity"))
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+
+		_, err = tbl.ExecBulkDML(context.TODO(), nil, csdb.BulkDMLOptions{}, dbr.NewDelete("sales_order"))
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+}
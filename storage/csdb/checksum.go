@@ -0,0 +1,266 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+)
+
+// ChecksumOptions configures Table.Checksum.
+type ChecksumOptions struct {
+	// ChunkSize if greater zero, calculates a CRC for each range of ChunkSize
+	// rows ordered by the primary key instead of running CHECKSUM TABLE. The
+	// chunks allow to find the differing key ranges and avoid the full table
+	// lock of CHECKSUM TABLE on MyISAM tables.
+	ChunkSize int
+	// Columns optional list of columns included in the chunked CRC. Defaults
+	// to all loaded columns of the table.
+	Columns []string
+}
+
+// ChecksumChunk contains the CRC of the rows within the primary key range
+// (Lower, Upper]. A nil bound means unbounded.
+type ChecksumChunk struct {
+	Lower interface{}
+	Upper interface{}
+	Rows  int64
+	CRC   uint64
+}
+
+// TableChecksum result of Table.Checksum.
+type TableChecksum struct {
+	Table string
+	// Sum the value of CHECKSUM TABLE or the combined checksum of all chunks.
+	Sum uint64
+	// Rows total number of rows, only available in chunked mode.
+	Rows   int64
+	Chunks []ChecksumChunk
+}
+
+// Equal compares both checksums. In chunked mode the chunks must match too.
+func (tc TableChecksum) Equal(other TableChecksum) bool {
+	if tc.Sum != other.Sum || tc.Rows != other.Rows || len(tc.Chunks) != len(other.Chunks) {
+		return false
+	}
+	for i, c := range tc.Chunks {
+		if c.Rows != other.Chunks[i].Rows || c.CRC != other.Chunks[i].CRC {
+			return false
+		}
+	}
+	return true
+}
+
+// ChecksumDiff result of CompareChecksum.
+type ChecksumDiff struct {
+	Source TableChecksum
+	Target TableChecksum
+	// Chunks contains the indexes of the differing chunks of Source.Chunks and
+	// Target.Chunks.
+	Chunks []int
+}
+
+// Equal returns true if both tables contain the same data.
+func (cd ChecksumDiff) Equal() bool {
+	return cd.Source.Equal(cd.Target)
+}
+
+// Checksum calculates the checksum of the table data. Without a ChunkSize
+// CHECKSUM TABLE gets executed, which requires the same row format and
+// MySQL version on both servers when comparing the results. With a ChunkSize
+// the table needs exactly one primary key column. Each chunk computes
+//		SELECT COUNT(*), BIT_XOR(CRC32(CONCAT_WS('#', cols..., CONCAT(ISNULL(cols)...))))
+// for a primary key range, similar to pt-table-checksum.
+func (t *Table) Checksum(ctx context.Context, db dbr.Querier, o ChecksumOptions) (TableChecksum, error) {
+	if o.ChunkSize < 1 {
+		return t.checksumTable(ctx, db)
+	}
+	cs := TableChecksum{Table: t.Name}
+	cols, err := t.checksumColumns(o)
+	if err != nil {
+		return cs, errors.Wrap(err, "[csdb] Checksum")
+	}
+
+	var lower interface{} // nil marks the first chunk
+	for {
+		upper, err := t.checksumBoundary(ctx, db, lower, o.ChunkSize)
+		if err != nil {
+			return cs, errors.Wrap(err, "[csdb] Checksum")
+		}
+		c, err := t.checksumChunk(ctx, db, cols, lower, upper)
+		if err != nil {
+			return cs, errors.Wrap(err, "[csdb] Checksum")
+		}
+		cs.Chunks = append(cs.Chunks, c)
+		if upper == nil {
+			break
+		}
+		lower = upper
+	}
+	cs.sumChunks()
+	return cs, nil
+}
+
+// CompareChecksum calculates the checksum of the table on the source and on
+// the target connection, for example a master and its replica after a
+// migration. In chunked mode the chunk boundaries of the source get applied
+// to the target, so the differing primary key ranges can be found in
+// ChecksumDiff.Chunks.
+func (t *Table) CompareChecksum(ctx context.Context, source, target dbr.Querier, o ChecksumOptions) (ChecksumDiff, error) {
+	var cd ChecksumDiff
+	var err error
+	if cd.Source, err = t.Checksum(ctx, source, o); err != nil {
+		return cd, errors.Wrap(err, "[csdb] CompareChecksum.Source")
+	}
+	if o.ChunkSize < 1 {
+		cd.Target, err = t.checksumTable(ctx, target)
+		return cd, errors.Wrap(err, "[csdb] CompareChecksum.Target")
+	}
+
+	cols, err := t.checksumColumns(o)
+	if err != nil {
+		return cd, errors.Wrap(err, "[csdb] CompareChecksum")
+	}
+	cd.Target.Table = t.Name
+	for i, sc := range cd.Source.Chunks {
+		c, err := t.checksumChunk(ctx, target, cols, sc.Lower, sc.Upper)
+		if err != nil {
+			return cd, errors.Wrap(err, "[csdb] CompareChecksum.Target")
+		}
+		cd.Target.Chunks = append(cd.Target.Chunks, c)
+		if c.Rows != sc.Rows || c.CRC != sc.CRC {
+			cd.Chunks = append(cd.Chunks, i)
+		}
+	}
+	cd.Target.sumChunks()
+	return cd, nil
+}
+
+func (tc *TableChecksum) sumChunks() {
+	h := fnv.New64a()
+	var buf [16]byte
+	tc.Rows = 0
+	for _, c := range tc.Chunks {
+		tc.Rows += c.Rows
+		binary.BigEndian.PutUint64(buf[:8], uint64(c.Rows))
+		binary.BigEndian.PutUint64(buf[8:], c.CRC)
+		_, _ = h.Write(buf[:])
+	}
+	tc.Sum = h.Sum64()
+}
+
+func (t *Table) checksumTable(ctx context.Context, db dbr.Querier) (TableChecksum, error) {
+	cs := TableChecksum{Table: t.Name}
+	rows, err := db.QueryContext(ctx, "CHECKSUM TABLE "+dbr.Quoter.QuoteAs(t.Name))
+	if err != nil {
+		return cs, errors.Wrapf(err, "[csdb] CHECKSUM TABLE %q", t.Name)
+	}
+	defer rows.Close()
+
+	var name string
+	var sum sql.NullString
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return cs, errors.Wrapf(err, "[csdb] CHECKSUM TABLE %q", t.Name)
+		}
+		return cs, errors.NewNotFoundf("[csdb] CHECKSUM TABLE %q returned no rows", t.Name)
+	}
+	if err := rows.Scan(&name, &sum); err != nil {
+		return cs, errors.Wrapf(err, "[csdb] CHECKSUM TABLE %q Scan", t.Name)
+	}
+	if !sum.Valid {
+		// MySQL returns NULL if the table does not exist.
+		return cs, errors.NewNotFoundf("[csdb] Table %q not found", t.Name)
+	}
+	if cs.Sum, err = strconv.ParseUint(sum.String, 10, 64); err != nil {
+		return cs, errors.NewNotValid(err, "[csdb] CHECKSUM TABLE ParseUint")
+	}
+	return cs, errors.Wrap(rows.Err(), "[csdb] CHECKSUM TABLE Rows.Err")
+}
+
+func (t *Table) checksumColumns(o ChecksumOptions) ([]string, error) {
+	if t.IsView {
+		return nil, errors.NewNotSupportedf("[csdb] Checksum: %q is a view", t.Name)
+	}
+	if len(t.fieldsPK) != 1 {
+		return nil, errors.NewNotSupportedf("[csdb] Checksum: Table %q requires exactly one primary key column, have %d", t.Name, len(t.fieldsPK))
+	}
+	cols := o.Columns
+	if len(cols) == 0 {
+		cols = t.Columns.FieldNames()
+	}
+	if len(cols) == 0 {
+		return nil, errors.NewEmptyf("[csdb] Checksum: Table %q has no columns", t.Name)
+	}
+	return cols, nil
+}
+
+// checksumBoundary returns the upper primary key value of the chunk starting
+// after lower or nil for the last chunk.
+func (t *Table) checksumBoundary(ctx context.Context, db dbr.Querier, lower interface{}, chunkSize int) (interface{}, error) {
+	qPK := dbr.Quoter.QuoteAs(t.fieldsPK[0])
+	query, args := oscChunkSQL("SELECT "+qPK+" FROM "+dbr.Quoter.QuoteAs(t.Name), qPK, lower, nil)
+	query += " ORDER BY " + qPK + " LIMIT 1 OFFSET ?"
+	args = append(args, chunkSize-1)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[csdb] Failed to query chunk boundary %q", query)
+	}
+	defer rows.Close()
+	var upper interface{}
+	if rows.Next() {
+		if err := rows.Scan(&upper); err != nil {
+			return nil, errors.Wrapf(err, "[csdb] Failed to scan chunk boundary %q", query)
+		}
+		if b, ok := upper.([]byte); ok {
+			upper = string(b) // the driver reuses the byte slice
+		}
+	}
+	return upper, errors.Wrap(rows.Err(), "[csdb] Rows.Err")
+}
+
+func (t *Table) checksumChunk(ctx context.Context, db dbr.Querier, cols []string, lower, upper interface{}) (ChecksumChunk, error) {
+	c := ChecksumChunk{Lower: lower, Upper: upper}
+
+	qCols := make([]string, len(cols))
+	nullCols := make([]string, len(cols))
+	for i, col := range cols {
+		qCols[i] = dbr.Quoter.QuoteAs(col)
+		nullCols[i] = "ISNULL(" + qCols[i] + ")"
+	}
+	qPK := dbr.Quoter.QuoteAs(t.fieldsPK[0])
+	query, args := oscChunkSQL("SELECT COUNT(*), COALESCE(BIT_XOR(CRC32(CONCAT_WS('#',"+strings.Join(qCols, ",")+
+		",CONCAT("+strings.Join(nullCols, ",")+")))),0) FROM "+dbr.Quoter.QuoteAs(t.Name), qPK, lower, upper)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return c, errors.Wrapf(err, "[csdb] Failed to query chunk checksum %q", query)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(&c.Rows, &c.CRC); err != nil {
+			return c, errors.Wrapf(err, "[csdb] Failed to scan chunk checksum %q", query)
+		}
+	}
+	return c, errors.Wrap(rows.Err(), "[csdb] Rows.Err")
+}
@@ -0,0 +1,147 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const checksumChunkSQL = "SELECT COUNT(*), COALESCE(BIT_XOR(CRC32(CONCAT_WS('#',`entity_id`,`sku`,CONCAT(ISNULL(`entity_id`),ISNULL(`sku`))))),0) FROM `catalog_product_entity`"
+
+func expectChecksumChunks(dbMock sqlmock.Sqlmock, lastCRC int64) {
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT `entity_id` FROM `catalog_product_entity` ORDER BY `entity_id` LIMIT 1 OFFSET ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"entity_id"}).AddRow(int64(2)))
+	dbMock.ExpectQuery(regexp.QuoteMeta(checksumChunkSQL + " WHERE `entity_id` <= ?")).
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"cnt", "crc"}).AddRow(int64(2), int64(123456)))
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT `entity_id` FROM `catalog_product_entity` WHERE `entity_id` > ? ORDER BY `entity_id` LIMIT 1 OFFSET ?")).
+		WithArgs(int64(2), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"entity_id"}))
+	dbMock.ExpectQuery(regexp.QuoteMeta(checksumChunkSQL + " WHERE `entity_id` > ?")).
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"cnt", "crc"}).AddRow(int64(1), lastCRC))
+}
+
+func TestTable_Checksum(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CHECKSUM TABLE", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectQuery(regexp.QuoteMeta("CHECKSUM TABLE `catalog_product_entity`")).
+			WillReturnRows(sqlmock.NewRows([]string{"Table", "Checksum"}).AddRow("magento.catalog_product_entity", "3017336893"))
+
+		cs, err := newBulkTestTable().Checksum(context.TODO(), dbc.DB, csdb.ChecksumOptions{})
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, uint64(3017336893), cs.Sum)
+		assert.Empty(t, cs.Chunks)
+	})
+
+	t.Run("CHECKSUM TABLE not found", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectQuery(regexp.QuoteMeta("CHECKSUM TABLE `catalog_product_entity`")).
+			WillReturnRows(sqlmock.NewRows([]string{"Table", "Checksum"}).AddRow("magento.catalog_product_entity", nil))
+
+		_, err := newBulkTestTable().Checksum(context.TODO(), dbc.DB, csdb.ChecksumOptions{})
+		assert.True(t, errors.IsNotFound(err), "%+v", err)
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		expectChecksumChunks(dbMock, 789)
+
+		cs, err := newBulkTestTable().Checksum(context.TODO(), dbc.DB, csdb.ChecksumOptions{ChunkSize: 2})
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, int64(3), cs.Rows)
+		assert.NotZero(t, cs.Sum)
+		assert.Exactly(t, []csdb.ChecksumChunk{
+			{Lower: nil, Upper: int64(2), Rows: 2, CRC: 123456},
+			{Lower: int64(2), Upper: nil, Rows: 1, CRC: 789},
+		}, cs.Chunks)
+	})
+
+	t.Run("chunked without single primary key", func(t *testing.T) {
+		tbl := csdb.NewTable("sales_order_grid", &csdb.Column{Field: "a"}, &csdb.Column{Field: "b"})
+		_, err := tbl.Checksum(context.TODO(), nil, csdb.ChecksumOptions{ChunkSize: 2})
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+}
+
+func TestTable_CompareChecksum(t *testing.T) {
+	t.Parallel()
+
+	newChunkRows := func(cnt, crc int64) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"cnt", "crc"}).AddRow(cnt, crc)
+	}
+
+	runCompare := func(t *testing.T, targetCRC int64) csdb.ChecksumDiff {
+		srcDB, srcMock := cstesting.MockDB(t)
+		trgDB, trgMock := cstesting.MockDB(t)
+		defer func() {
+			srcMock.ExpectClose()
+			trgMock.ExpectClose()
+			assert.NoError(t, srcDB.Close())
+			assert.NoError(t, trgDB.Close())
+			assert.NoError(t, srcMock.ExpectationsWereMet())
+			assert.NoError(t, trgMock.ExpectationsWereMet())
+		}()
+		expectChecksumChunks(srcMock, 789)
+		// the target reuses the boundaries of the source
+		trgMock.ExpectQuery(regexp.QuoteMeta(checksumChunkSQL + " WHERE `entity_id` <= ?")).
+			WithArgs(int64(2)).WillReturnRows(newChunkRows(2, 123456))
+		trgMock.ExpectQuery(regexp.QuoteMeta(checksumChunkSQL + " WHERE `entity_id` > ?")).
+			WithArgs(int64(2)).WillReturnRows(newChunkRows(1, targetCRC))
+
+		cd, err := newBulkTestTable().CompareChecksum(context.TODO(), srcDB.DB, trgDB.DB, csdb.ChecksumOptions{ChunkSize: 2})
+		require.NoError(t, err, "%+v", err)
+		return cd
+	}
+
+	t.Run("equal", func(t *testing.T) {
+		cd := runCompare(t, 789)
+		assert.True(t, cd.Equal())
+		assert.Empty(t, cd.Chunks)
+		assert.Exactly(t, cd.Source.Sum, cd.Target.Sum)
+	})
+	t.Run("different", func(t *testing.T) {
+		cd := runCompare(t, 790)
+		assert.False(t, cd.Equal())
+		assert.Exactly(t, []int{1}, cd.Chunks)
+	})
+}
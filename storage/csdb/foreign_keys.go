@@ -0,0 +1,264 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+)
+
+// ForeignKey contains one column of a foreign key constraint retrieved from
+// information_schema.KEY_COLUMN_USAGE. A composite foreign key consists of
+// several ForeignKey entries with the same ConstraintName.
+type ForeignKey struct {
+	ConstraintName       string `db:"CONSTRAINT_NAME"`        // `CONSTRAINT_NAME` varchar(64) NOT NULL DEFAULT '',
+	TableName            string `db:"TABLE_NAME"`             // `TABLE_NAME` varchar(64) NOT NULL DEFAULT '',
+	ColumnName           string `db:"COLUMN_NAME"`            // `COLUMN_NAME` varchar(64) NOT NULL DEFAULT '',
+	ReferencedTableName  string `db:"REFERENCED_TABLE_NAME"`  // `REFERENCED_TABLE_NAME` varchar(64) DEFAULT NULL,
+	ReferencedColumnName string `db:"REFERENCED_COLUMN_NAME"` // `REFERENCED_COLUMN_NAME` varchar(64) DEFAULT NULL,
+}
+
+// ForeignKeys contains a slice of foreign key columns.
+type ForeignKeys []*ForeignKey
+
+const selTablesForeignKeys = `SELECT
+	CONSTRAINT_NAME, TABLE_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+	 FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA=DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL
+	 AND TABLE_NAME IN (?)
+	 ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION`
+
+const selAllTablesForeignKeys = `SELECT
+	CONSTRAINT_NAME, TABLE_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+	 FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA=DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL
+	 ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION`
+
+// LoadForeignKeys returns the foreign keys defined on a list of tables in the
+// current database. All foreign keys get selected when you don't provide the
+// argument `tables`.
+func LoadForeignKeys(ctx context.Context, db dbr.Querier, tables ...string) (ForeignKeys, error) {
+	var rows *sql.Rows
+
+	if len(tables) == 0 {
+		var err error
+		rows, err = db.QueryContext(ctx, selAllTablesForeignKeys)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadForeignKeys QueryContext for tables %v", tables)
+		}
+	} else {
+		sqlStr, args, err := dbr.Repeat(selTablesForeignKeys, dbr.ArgString(tables...))
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadForeignKeys dbr.Repeat for tables %v", tables)
+		}
+		rows, err = db.QueryContext(ctx, sqlStr, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadForeignKeys QueryContext for tables %v", tables)
+		}
+	}
+	defer rows.Close()
+
+	var fks ForeignKeys
+	for rows.Next() {
+		fk := new(ForeignKey)
+		if err := rows.Scan(&fk.ConstraintName, &fk.TableName, &fk.ColumnName, &fk.ReferencedTableName, &fk.ReferencedColumnName); err != nil {
+			return nil, errors.Wrap(err, "[csdb] LoadForeignKeys Scan Query")
+		}
+		fks = append(fks, fk)
+	}
+	return fks, errors.Wrap(rows.Err(), "[csdb] LoadForeignKeys rows.Err Query")
+}
+
+// DependencyOrder sorts the table names so that a referenced table comes
+// before all tables referencing it. Insert data in the returned order and
+// delete data in the reverse order. Foreign keys pointing to tables outside
+// the list and self references get ignored. Independent tables keep their
+// order from the argument list.
+//
+// Tables which reference each other in a cycle cannot be ordered. They get
+// appended to the end of the returned list and the error has the behaviour
+// NotSupported. Such tables can only be purged with disabled
+// foreign_key_checks.
+func (fks ForeignKeys) DependencyOrder(tables ...string) ([]string, error) {
+	parents := make(map[string]map[string]bool, len(tables))
+	for _, tn := range tables {
+		parents[tn] = make(map[string]bool)
+	}
+	for _, fk := range fks {
+		p, ok := parents[fk.TableName]
+		if _, isListed := parents[fk.ReferencedTableName]; !ok || !isListed || fk.TableName == fk.ReferencedTableName {
+			continue
+		}
+		p[fk.ReferencedTableName] = true
+	}
+
+	order := make([]string, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+	for len(order) < len(tables) {
+		added := false
+		for _, tn := range tables {
+			if done[tn] || !allDone(parents[tn], done) {
+				continue
+			}
+			done[tn] = true
+			order = append(order, tn)
+			added = true
+		}
+		if !added {
+			break
+		}
+	}
+	if len(order) == len(tables) {
+		return order, nil
+	}
+
+	var cyclic []string
+	for _, tn := range tables {
+		if !done[tn] {
+			cyclic = append(cyclic, tn)
+		}
+	}
+	order = append(order, cyclic...)
+	return order, errors.NewNotSupportedf("[csdb] DependencyOrder: Tables %s contain cyclic foreign keys", strings.Join(cyclic, ", "))
+}
+
+func allDone(tables map[string]bool, done map[string]bool) bool {
+	for tn := range tables {
+		if !done[tn] {
+			return false
+		}
+	}
+	return true
+}
+
+// PurgeOptions configures Tables.Purge.
+type PurgeOptions struct {
+	// Truncate uses TRUNCATE TABLE instead of DELETE FROM. TRUNCATE resets the
+	// auto increment value but it is a DDL statement and causes an implicit
+	// commit, it cannot be rolled back. MySQL refuses to truncate a table
+	// which is referenced by a foreign key, hence the foreign_key_checks get
+	// always disabled for the session.
+	Truncate bool
+	// DisableForeignKeyChecks sets foreign_key_checks to 0 for the DELETE
+	// statements within the transaction. Required for tables with cyclic
+	// foreign keys.
+	DisableForeignKeyChecks bool
+}
+
+// Purge removes all rows from the tables identified by their index or from
+// all tables if no index has been provided. Views get skipped. The foreign
+// keys get loaded to delete the rows of the referencing tables before the rows
+// of the referenced tables. By default all DELETE statements run within one
+// transaction. foreign_key_checks, if disabled, get enabled again before the
+// connection returns into the pool, even in the error case. Useful for test
+// fixtures and re-import jobs.
+func (tm *Tables) Purge(ctx context.Context, db Conner, o PurgeOptions, idxs ...int) (err error) {
+	names, err := tm.purgeNames(idxs...)
+	if err != nil || len(names) == 0 {
+		return errors.Wrap(err, "[csdb] Tables.Purge.purgeNames")
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] Tables.Purge.Conn")
+	}
+	defer func() {
+		if cErr := conn.Close(); err == nil && cErr != nil {
+			err = errors.Wrap(cErr, "[csdb] Tables.Purge.Conn.Close")
+		}
+	}()
+
+	fks, err := LoadForeignKeys(ctx, conn, names...)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] Tables.Purge.LoadForeignKeys")
+	}
+	order, err := fks.DependencyOrder(names...)
+	if err != nil && !o.Truncate && !o.DisableForeignKeyChecks {
+		return errors.Wrap(err, "[csdb] Tables.Purge.DependencyOrder")
+	}
+	// referencing tables first
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+
+	if o.Truncate || o.DisableForeignKeyChecks {
+		if _, err = conn.ExecContext(ctx, "SET foreign_key_checks = 0"); err != nil {
+			return errors.Wrap(err, "[csdb] Tables.Purge.DisableChecks")
+		}
+		defer func() {
+			// use a new context because the old one might be canceled and the
+			// connection goes back into the pool.
+			if _, rErr := conn.ExecContext(context.Background(), "SET foreign_key_checks = 1"); err == nil && rErr != nil {
+				err = errors.Wrap(rErr, "[csdb] Tables.Purge.EnableChecks")
+			}
+		}()
+	}
+
+	if o.Truncate {
+		for _, tn := range order {
+			if err = NewTable(tn).Truncate(ctx, conn); err != nil {
+				return errors.Wrap(err, "[csdb] Tables.Purge.Truncate")
+			}
+		}
+		return nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] Tables.Purge.BeginTx")
+	}
+	for _, tn := range order {
+		qName, err := dbr.Quoter.ValidateAndQuote(tn)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, "DELETE FROM "+qName)
+		}
+		if err != nil {
+			if rErr := tx.Rollback(); rErr != nil {
+				return errors.Wrapf(rErr, "[csdb] Tables.Purge.Rollback after error: %s", err)
+			}
+			return errors.Wrapf(err, "[csdb] Tables.Purge failed to delete from table %q", tn)
+		}
+	}
+	return errors.Wrap(tx.Commit(), "[csdb] Tables.Purge.Commit")
+}
+
+// purgeNames returns the names of the tables for Purge in the order of the
+// indexes or sorted by index when no index has been provided.
+func (tm *Tables) purgeNames(idxs ...int) ([]string, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	if len(idxs) == 0 {
+		idxs = make([]int, 0, len(tm.ts))
+		for idx := range tm.ts {
+			idxs = append(idxs, idx)
+		}
+		sort.Ints(idxs)
+	}
+	names := make([]string, 0, len(idxs))
+	for _, idx := range idxs {
+		t, ok := tm.ts[idx]
+		if !ok {
+			return nil, errors.NewNotFoundf("[csdb] Table at index %d not found.", idx)
+		}
+		if !t.IsView {
+			names = append(names, t.Name)
+		}
+	}
+	return names, nil
+}
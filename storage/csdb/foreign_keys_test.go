@@ -0,0 +1,174 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFKTestRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"CONSTRAINT_NAME", "TABLE_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"}).
+		AddRow("FK_CPEI_ENTITY_ID", "catalog_product_entity_int", "entity_id", "catalog_product_entity", "entity_id").
+		AddRow("FK_CPEI_STORE_ID", "catalog_product_entity_int", "store_id", "store", "store_id").
+		AddRow("FK_CPW_PRODUCT_ID", "catalog_product_website", "product_id", "catalog_product_entity", "entity_id").
+		AddRow("FK_CPW_WEBSITE_ID", "catalog_product_website", "website_id", "store_website", "website_id")
+}
+
+func TestForeignKeys_DependencyOrder(t *testing.T) {
+	fks := csdb.ForeignKeys{
+		{TableName: "c", ReferencedTableName: "b"},
+		{TableName: "b", ReferencedTableName: "a"},
+		{TableName: "b", ReferencedTableName: "b"}, // self reference
+		{TableName: "d", ReferencedTableName: "x"}, // outside of the list
+		{TableName: "e", ReferencedTableName: "f"},
+		{TableName: "f", ReferencedTableName: "e"},
+	}
+
+	tests := []struct {
+		tables  []string
+		want    []string
+		wantErr errors.BehaviourFunc
+	}{
+		{[]string{"c", "b", "a"}, []string{"a", "b", "c"}, nil},
+		{[]string{"d", "c", "a", "b"}, []string{"d", "a", "b", "c"}, nil},
+		{[]string{"c", "d"}, []string{"c", "d"}, nil},
+		{[]string{"e", "a", "f", "b"}, []string{"a", "b", "e", "f"}, errors.IsNotSupported},
+		{nil, []string{}, nil},
+	}
+	for i, test := range tests {
+		have, err := fks.DependencyOrder(test.tables...)
+		if test.wantErr != nil {
+			assert.True(t, test.wantErr(err), "Index %d: %+v", i, err)
+		} else {
+			assert.NoError(t, err, "Index %d", i)
+		}
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
+
+func TestLoadForeignKeys(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	dbMock.ExpectQuery("SELECT.+FROM information_schema.KEY_COLUMN_USAGE.+TABLE_NAME IN \\(\\?,\\?\\)").
+		WithArgs("catalog_product_entity_int", "catalog_product_website").
+		WillReturnRows(newFKTestRows())
+
+	fks, err := csdb.LoadForeignKeys(context.TODO(), dbc.DB, "catalog_product_entity_int", "catalog_product_website")
+	require.NoError(t, err, "%+v", err)
+	require.Len(t, fks, 4)
+	assert.Exactly(t, &csdb.ForeignKey{
+		ConstraintName:       "FK_CPW_PRODUCT_ID",
+		TableName:            "catalog_product_website",
+		ColumnName:           "product_id",
+		ReferencedTableName:  "catalog_product_entity",
+		ReferencedColumnName: "entity_id",
+	}, fks[2])
+}
+
+func TestTables_Purge(t *testing.T) {
+	newTables := func() *csdb.Tables {
+		return csdb.MustNewTables(csdb.WithTableNames(
+			[]int{0, 1, 2},
+			[]string{"catalog_product_entity", "catalog_product_entity_int", "catalog_product_website"},
+		))
+	}
+	const fkQuery = "SELECT.+FROM information_schema.KEY_COLUMN_USAGE"
+
+	t.Run("delete within transaction", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectQuery(fkQuery).WillReturnRows(newFKTestRows())
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `catalog_product_website`")).WillReturnResult(sqlmock.NewResult(0, 3))
+		dbMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `catalog_product_entity_int`")).WillReturnResult(sqlmock.NewResult(0, 9))
+		dbMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `catalog_product_entity`")).WillReturnResult(sqlmock.NewResult(0, 3))
+		dbMock.ExpectCommit()
+
+		assert.NoError(t, newTables().Purge(context.TODO(), dbc.DB, csdb.PurgeOptions{}))
+	})
+
+	t.Run("delete fails and rolls back with checks enabled again", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectQuery(fkQuery).WillReturnRows(newFKTestRows())
+		dbMock.ExpectExec(regexp.QuoteMeta("SET foreign_key_checks = 0")).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `catalog_product_website`")).WillReturnResult(sqlmock.NewResult(0, 3))
+		dbMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `catalog_product_entity`")).WillReturnError(errors.New("Lock wait timeout"))
+		dbMock.ExpectRollback()
+		dbMock.ExpectExec(regexp.QuoteMeta("SET foreign_key_checks = 1")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := newTables().Purge(context.TODO(), dbc.DB, csdb.PurgeOptions{DisableForeignKeyChecks: true}, 2, 0)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "catalog_product_entity")
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectQuery(fkQuery).WillReturnRows(newFKTestRows())
+		dbMock.ExpectExec(regexp.QuoteMeta("SET foreign_key_checks = 0")).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(regexp.QuoteMeta("TRUNCATE TABLE `catalog_product_website`")).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(regexp.QuoteMeta("TRUNCATE TABLE `catalog_product_entity`")).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(regexp.QuoteMeta("SET foreign_key_checks = 1")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, newTables().Purge(context.TODO(), dbc.DB, csdb.PurgeOptions{Truncate: true}, 0, 2))
+	})
+
+	t.Run("cyclic foreign keys", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectQuery(fkQuery).WillReturnRows(newFKTestRows().
+			AddRow("FK_CPE_CYCLE", "catalog_product_entity", "entity_id", "catalog_product_website", "product_id"))
+
+		err := newTables().Purge(context.TODO(), dbc.DB, csdb.PurgeOptions{})
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+
+	t.Run("table index not found", func(t *testing.T) {
+		err := newTables().Purge(context.TODO(), nil, csdb.PurgeOptions{}, 7)
+		assert.True(t, errors.IsNotFound(err), "%+v", err)
+	})
+}
@@ -0,0 +1,140 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/corestoreio/csfw/util"
+	"github.com/corestoreio/errors"
+)
+
+// GenerateOptions configures GenerateColumnsGo.
+type GenerateOptions struct {
+	// Package name of the generated file. Required.
+	Package string
+	// Unexported generates lower case identifiers, e.g. tblStore instead of
+	// TblStore, to keep the helpers private to the package.
+	Unexported bool
+}
+
+// GenerateColumnsGo writes a formatted Go source file into w which declares
+// for each table a constant with the table name, a struct type with one field
+// per column and a variable combining both. The columns must have been loaded,
+// see LoadColumns. The table names do not contain the prefix. Use the
+// generated code in the dbr builders to catch typos in column names at
+// compile time. The column names have the type dbr.SQLUnsafe because they get
+// passed to the builder functions accepting an expression:
+//		dbc.Select(TblStore.Col.StoreID, TblStore.Col.Code).From(TblStore.Name).
+//			Where(dbr.Condition(TblStore.Col.IsActive, dbr.ArgInt(1)))
+// Run it via go:generate whenever the database schema changes. Error
+// behaviour: Empty, NotValid, Fatal.
+func GenerateColumnsGo(w io.Writer, o GenerateOptions, tables ...*Table) error {
+	if o.Package == "" {
+		return errors.NewEmptyf("[csdb] GenerateColumnsGo: Package name cannot be empty")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by csdb.GenerateColumnsGo. DO NOT EDIT.\n\n")
+	buf.WriteString("package ")
+	buf.WriteString(o.Package)
+	buf.WriteString("\n\nimport \"github.com/corestoreio/csfw/storage/dbr\"\n")
+
+	for _, t := range tables {
+		if err := t.generateColumnsGo(&buf, o.Unexported); err != nil {
+			return errors.Wrap(err, "[csdb] GenerateColumnsGo")
+		}
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.NewFatal(err, "[csdb] GenerateColumnsGo.format.Source")
+	}
+	_, err = w.Write(src)
+	return errors.Wrap(err, "[csdb] GenerateColumnsGo.Write")
+}
+
+func (t *Table) generateColumnsGo(buf *bytes.Buffer, unexported bool) error {
+	if len(t.Columns) == 0 {
+		return errors.NewEmptyf("[csdb] Table %q: Columns not loaded", t.Name)
+	}
+	name := t.unprefixedName()
+	goName := goIdentifier(name)
+	ident := func(prefix string) string {
+		if unexported {
+			return strings.ToLower(prefix[:1]) + prefix[1:] + goName
+		}
+		return prefix + goName
+	}
+	constName := ident("TableName")
+	typeName := ident("Table") + "Columns"
+	varName := ident("Tbl")
+
+	fields := make([]string, len(t.Columns))
+	seen := make(map[string]string, len(t.Columns))
+	for i, c := range t.Columns {
+		f := goIdentifier(c.Field)
+		if prev, ok := seen[f]; ok {
+			return errors.NewNotValidf("[csdb] Table %q: Columns %q and %q result in the same Go identifier %q", t.Name, prev, c.Field, f)
+		}
+		seen[f] = c.Field
+		fields[i] = f
+	}
+
+	fmt.Fprintf(buf, "\n// %s name of the table %s without prefix.\n", constName, name)
+	fmt.Fprintf(buf, "const %s = %s\n", constName, strconv.Quote(name))
+
+	fmt.Fprintf(buf, "\n// %s contains the column names of the table %s.\n", typeName, name)
+	fmt.Fprintf(buf, "type %s struct {\n", typeName)
+	for i, c := range t.Columns {
+		fmt.Fprintf(buf, "\t%s dbr.SQLUnsafe %s\n", fields[i], strings.TrimSpace(c.GoComment()))
+	}
+	buf.WriteString("}\n")
+
+	fmt.Fprintf(buf, "\n// All returns all column names in the order of the table definition.\n")
+	fmt.Fprintf(buf, "func (c %s) All() []dbr.SQLUnsafe {\n\treturn []dbr.SQLUnsafe{", typeName)
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString("c.")
+		buf.WriteString(f)
+	}
+	buf.WriteString("}\n}\n")
+
+	fmt.Fprintf(buf, "\n// %s provides the name and the column names of the table %s.\n", varName, name)
+	fmt.Fprintf(buf, "var %s = struct {\n\tName string\n\tCol %s\n}{\n\tName: %s,\n\tCol: %s{\n", varName, typeName, constName, typeName)
+	for i, c := range t.Columns {
+		fmt.Fprintf(buf, "\t\t%s: %s,\n", fields[i], strconv.Quote(c.Field))
+	}
+	buf.WriteString("\t},\n}\n")
+	return nil
+}
+
+// goIdentifier converts a table or column name into an exported Go
+// identifier. Names starting with a digit get the prefix "X".
+func goIdentifier(name string) string {
+	id := util.UnderscoreCamelize(name)
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "X" + id
+	}
+	return id
+}
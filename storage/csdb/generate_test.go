@@ -0,0 +1,84 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGenerateTestTable() *csdb.Table {
+	return csdb.NewTable("store",
+		&csdb.Column{Field: "store_id", Pos: 1, Null: "NO", DataType: "smallint", ColumnType: "smallint(5) unsigned", Key: "PRI", Extra: "auto_increment"},
+		&csdb.Column{Field: "code", Pos: 2, Null: "YES", DataType: "varchar", ColumnType: "varchar(32)", Key: "UNI"},
+		&csdb.Column{Field: "is_active", Pos: 3, Default: dbr.MakeNullString("0"), Null: "NO", DataType: "smallint", ColumnType: "smallint(5) unsigned"},
+	)
+}
+
+func TestGenerateColumnsGo(t *testing.T) {
+	t.Run("exported", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, csdb.GenerateColumnsGo(&buf, csdb.GenerateOptions{Package: "storedb"}, newGenerateTestTable()))
+
+		_, err := parser.ParseFile(token.NewFileSet(), "", buf.Bytes(), 0)
+		require.NoError(t, err)
+
+		src := buf.String()
+		assert.Contains(t, src, "// Code generated by csdb.GenerateColumnsGo. DO NOT EDIT.\n\npackage storedb\n\nimport \"github.com/corestoreio/csfw/storage/dbr\"\n")
+		assert.Contains(t, src, "const TableNameStore = \"store\"\n")
+		assert.Contains(t, src, "type TableStoreColumns struct {\n\tStoreID  dbr.SQLUnsafe // store_id smallint(5) unsigned NOT NULL PRI  auto_increment \"\"\n")
+		assert.Contains(t, src, "\tIsActive dbr.SQLUnsafe // is_active smallint(5) unsigned NOT NULL  DEFAULT '0'  \"\"\n")
+		assert.Contains(t, src, "func (c TableStoreColumns) All() []dbr.SQLUnsafe {\n\treturn []dbr.SQLUnsafe{c.StoreID, c.Code, c.IsActive}\n}")
+		assert.Contains(t, src, "var TblStore = struct {\n\tName string\n\tCol  TableStoreColumns\n}{\n\tName: TableNameStore,\n\tCol: TableStoreColumns{\n\t\tStoreID:  \"store_id\",\n\t\tCode:     \"code\",\n\t\tIsActive: \"is_active\",\n\t},\n}\n")
+	})
+
+	t.Run("unexported with prefix", func(t *testing.T) {
+		tbl := newGenerateTestTable()
+		tbl.Name = "mage_store"
+		tbl.Prefix = "mage_"
+
+		var buf bytes.Buffer
+		require.NoError(t, csdb.GenerateColumnsGo(&buf, csdb.GenerateOptions{Package: "storedb", Unexported: true}, tbl))
+		src := buf.String()
+		assert.Contains(t, src, "const tableNameStore = \"store\"\n")
+		assert.Contains(t, src, "type tableStoreColumns struct {")
+		assert.Contains(t, src, "var tblStore = struct {")
+	})
+
+	t.Run("columns not loaded", func(t *testing.T) {
+		err := csdb.GenerateColumnsGo(new(bytes.Buffer), csdb.GenerateOptions{Package: "storedb"}, csdb.NewTable("store"))
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+	})
+
+	t.Run("identifier collision", func(t *testing.T) {
+		err := csdb.GenerateColumnsGo(new(bytes.Buffer), csdb.GenerateOptions{Package: "storedb"}, csdb.NewTable("store",
+			&csdb.Column{Field: "store_id"}, &csdb.Column{Field: "Store_ID"},
+		))
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+
+	t.Run("empty package", func(t *testing.T) {
+		err := csdb.GenerateColumnsGo(new(bytes.Buffer), csdb.GenerateOptions{})
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+	})
+}
@@ -0,0 +1,308 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+)
+
+// OnlineAlterOptions configures Table.OnlineAlter.
+type OnlineAlterOptions struct {
+	// ChunkSize number of rows copied with one INSERT ... SELECT statement.
+	// Defaults to 1000.
+	ChunkSize int
+	// ChunkPause duration to sleep after each copied chunk to reduce the load
+	// on the server and the replication.
+	ChunkPause time.Duration
+	// Throttle gets called before each chunk. It can block, for example until
+	// the replication lag drops below a threshold. A returned error aborts the
+	// schema change.
+	Throttle func(ctx context.Context) error
+	// Columns optional list of columns to copy. Defaults to the columns which
+	// exist in both tables after the ALTER has been applied to the shadow
+	// table.
+	Columns []string
+	// KeepOldTable does not drop the old table after the swap. The old table
+	// has then the name of the shadow table.
+	KeepOldTable bool
+	// Log reports the progress on level info. Can be nil.
+	Log log.Logger
+}
+
+// OnlineAlterResult contains the statistics of an online schema change.
+type OnlineAlterResult struct {
+	// ShadowTable name of the table which received the ALTER and after the
+	// swap the name of the old table.
+	ShadowTable string
+	Chunks      int
+	// RowsCopied number of rows copied in chunks. Changes applied by the
+	// triggers are not included.
+	RowsCopied int64
+	Duration   time.Duration
+}
+
+// oscTriggerNames returns the names of the AFTER INSERT, UPDATE and DELETE
+// triggers which sync the changes to the shadow table.
+func (t *Table) oscTriggerNames() [3]string {
+	n := "osc_" + t.Name
+	return [3]string{
+		TriggerName(n, "after", "insert"),
+		TriggerName(n, "after", "update"),
+		TriggerName(n, "after", "delete"),
+	}
+}
+
+// OnlineAlter performs an ALTER TABLE on a huge table without blocking writes,
+// in the style of pt-online-schema-change. The alter argument contains the
+// specification after ALTER TABLE `name`, for example "ADD COLUMN `x` INT".
+// The steps are:
+//	1. CREATE TABLE `shadow` LIKE `table` and apply the ALTER to the shadow.
+//	2. Create AFTER INSERT, UPDATE and DELETE triggers on the table which
+//	   replay all changes into the shadow table.
+//	3. Copy the rows in chunks, ordered by the primary key, with INSERT
+//	   LOW_PRIORITY IGNORE ... SELECT ... LOCK IN SHARE MODE. Throttle and
+//	   ChunkPause get applied between the chunks.
+//	4. Swap both tables atomically with Table.Swap, drop the triggers and the
+//	   old table.
+// The table must have exactly one primary key column and the Columns must be
+// loaded. On error the triggers and the shadow table get removed, the
+// original table stays untouched. The db argument should be a *sql.DB and not
+// a transaction because every chunk should commit on its own.
+func (t *Table) OnlineAlter(ctx context.Context, db dbr.DBer, alter string, o OnlineAlterOptions) (res OnlineAlterResult, err error) {
+	start := time.Now()
+	if t.IsView {
+		return res, errors.NewNotSupportedf("[csdb] OnlineAlter: %q is a view", t.Name)
+	}
+	if len(t.fieldsPK) != 1 {
+		return res, errors.NewNotSupportedf("[csdb] OnlineAlter: Table %q requires exactly one primary key column, have %d", t.Name, len(t.fieldsPK))
+	}
+	if strings.TrimSpace(alter) == "" {
+		return res, errors.NewEmptyf("[csdb] OnlineAlter: Alter specification for table %q cannot be empty", t.Name)
+	}
+	if o.ChunkSize < 1 {
+		o.ChunkSize = 1000
+	}
+	if o.Log == nil {
+		o.Log = log.BlackHole{}
+	}
+
+	shadow := TableName("", t.Name, "osc")
+	if err := IsValidIdentifier(t.Name, shadow); err != nil {
+		return res, errors.Wrap(err, "[csdb] OnlineAlter table name")
+	}
+	res.ShadowTable = shadow
+	qTable := dbr.Quoter.QuoteAs(t.Name)
+	qShadow := dbr.Quoter.QuoteAs(shadow)
+	trgNames := t.oscTriggerNames()
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		// best effort cleanup, the original error wins.
+		for _, n := range trgNames {
+			_ = t.DropTrigger(ctx, db, n)
+		}
+		_, _ = db.ExecContext(ctx, "DROP TABLE IF EXISTS "+qShadow)
+	}()
+
+	if _, err = db.ExecContext(ctx, "CREATE TABLE "+qShadow+" LIKE "+qTable); err != nil {
+		return res, errors.Wrapf(err, "[csdb] OnlineAlter failed to create shadow table %q", shadow)
+	}
+	if _, err = db.ExecContext(ctx, "ALTER TABLE "+qShadow+" "+alter); err != nil {
+		return res, errors.Wrapf(err, "[csdb] OnlineAlter failed to alter shadow table %q", shadow)
+	}
+
+	cols := o.Columns
+	if len(cols) == 0 {
+		if cols, err = t.oscCommonColumns(ctx, db, shadow); err != nil {
+			return res, errors.Wrap(err, "[csdb] OnlineAlter.commonColumns")
+		}
+	}
+
+	for _, tr := range t.oscTriggers(trgNames, shadow, cols) {
+		if err = t.CreateTrigger(ctx, db, tr); err != nil {
+			return res, errors.Wrap(err, "[csdb] OnlineAlter.CreateTrigger")
+		}
+	}
+
+	if err = t.oscCopyChunks(ctx, db, shadow, cols, o, &res); err != nil {
+		return res, errors.Wrap(err, "[csdb] OnlineAlter.copyChunks")
+	}
+
+	if err = t.Swap(ctx, db, shadow); err != nil {
+		return res, errors.Wrap(err, "[csdb] OnlineAlter.Swap")
+	}
+	// From here on the new table is live. Errors during the cleanup must not
+	// drop the shadow table, which now contains the old data.
+	for _, n := range trgNames {
+		if dErr := t.DropTrigger(ctx, db, n); dErr != nil {
+			return res, errors.Wrap(dErr, "[csdb] OnlineAlter.DropTrigger")
+		}
+	}
+	if !o.KeepOldTable {
+		if _, dErr := db.ExecContext(ctx, "DROP TABLE IF EXISTS "+qShadow); dErr != nil {
+			return res, errors.Wrapf(dErr, "[csdb] OnlineAlter failed to drop old table %q", shadow)
+		}
+	}
+	res.Duration = time.Since(start)
+	if o.Log.IsInfo() {
+		o.Log.Info("csdb.Table.OnlineAlter.done", log.String("table", t.Name), log.Int("chunks", res.Chunks),
+			log.Int64("rows_copied", res.RowsCopied), log.Duration("duration", res.Duration))
+	}
+	return res, nil
+}
+
+// oscCommonColumns returns the columns of this table which also exist in the
+// shadow table.
+func (t *Table) oscCommonColumns(ctx context.Context, db dbr.Querier, shadow string) ([]string, error) {
+	tc, err := LoadColumns(ctx, db, shadow)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[csdb] LoadColumns for table %q", shadow)
+	}
+	var cols []string
+	for _, c := range t.Columns {
+		if tc[shadow].ByField(c.Field) != nil {
+			cols = append(cols, c.Field)
+		}
+	}
+	if len(cols) == 0 {
+		return nil, errors.NewNotFoundf("[csdb] Table %q and %q have no columns in common", t.Name, shadow)
+	}
+	return cols, nil
+}
+
+// oscTriggers creates the triggers which replay the changes of this table in
+// the shadow table. REPLACE and DELETE IGNORE make the triggers idempotent to
+// the chunk copying.
+func (t *Table) oscTriggers(names [3]string, shadow string, cols []string) Triggers {
+	qShadow := dbr.Quoter.QuoteAs(shadow)
+	qPK := dbr.Quoter.QuoteAs(t.fieldsPK[0])
+	qCols := make([]string, len(cols))
+	newCols := make([]string, len(cols))
+	for i, c := range cols {
+		qCols[i] = dbr.Quoter.QuoteAs(c)
+		newCols[i] = "NEW." + qCols[i]
+	}
+	replace := "REPLACE INTO " + qShadow + " (" + strings.Join(qCols, ",") + ") VALUES (" + strings.Join(newCols, ",") + ")"
+	del := "DELETE IGNORE FROM " + qShadow + " WHERE " + qPK + " = OLD." + qPK
+
+	return Triggers{
+		NewTrigger(names[0]).After().OnInsert().Do(replace),
+		NewTrigger(names[1]).After().OnUpdate().Do(del, replace),
+		NewTrigger(names[2]).After().OnDelete().Do(del),
+	}
+}
+
+// oscCopyChunks copies all rows in chunks into the shadow table. The upper
+// bound of each chunk gets determined before the copy, so each chunk locks
+// a fixed primary key range.
+func (t *Table) oscCopyChunks(ctx context.Context, db dbr.DBer, shadow string, cols []string, o OnlineAlterOptions, res *OnlineAlterResult) error {
+	qTable := dbr.Quoter.QuoteAs(t.Name)
+	qPK := dbr.Quoter.QuoteAs(t.fieldsPK[0])
+	qColSl := make([]string, len(cols))
+	for i, c := range cols {
+		qColSl[i] = dbr.Quoter.QuoteAs(c)
+	}
+	qCols := strings.Join(qColSl, ",")
+
+	var lower interface{} // nil marks the first chunk
+	for {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "[csdb] Context")
+		}
+		if o.Throttle != nil {
+			if err := o.Throttle(ctx); err != nil {
+				return errors.Wrap(err, "[csdb] Throttle")
+			}
+		}
+
+		var upper interface{}
+		boundSQL, boundArgs := oscChunkSQL("SELECT "+qPK+" FROM "+qTable, qPK, lower, nil)
+		boundSQL += " ORDER BY " + qPK + " LIMIT 1 OFFSET ?"
+		boundArgs = append(boundArgs, o.ChunkSize-1)
+		switch err := db.QueryRowContext(ctx, boundSQL, boundArgs...).Scan(&upper); {
+		case err == sql.ErrNoRows:
+			upper = nil // last chunk
+		case err != nil:
+			return errors.Wrapf(err, "[csdb] Failed to query chunk boundary %q", boundSQL)
+		}
+
+		buf := bufferpool.Get()
+		buf.WriteString("INSERT LOW_PRIORITY IGNORE INTO ")
+		buf.WriteString(dbr.Quoter.QuoteAs(shadow))
+		buf.WriteString(" (")
+		buf.WriteString(qCols)
+		buf.WriteString(") SELECT ")
+		buf.WriteString(qCols)
+		buf.WriteString(" FROM ")
+		buf.WriteString(qTable)
+		copySQL, copyArgs := oscChunkSQL(buf.String(), qPK, lower, upper)
+		bufferpool.Put(buf)
+		copySQL += " LOCK IN SHARE MODE"
+
+		r, err := db.ExecContext(ctx, copySQL, copyArgs...)
+		if err != nil {
+			return errors.Wrapf(err, "[csdb] Failed to copy chunk %q", copySQL)
+		}
+		n, err := r.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, "[csdb] RowsAffected")
+		}
+		res.Chunks++
+		res.RowsCopied += n
+		if o.Log.IsInfo() {
+			o.Log.Info("csdb.Table.OnlineAlter.chunk", log.String("table", t.Name), log.Int("chunk", res.Chunks), log.Int64("rows", n))
+		}
+
+		if upper == nil {
+			return nil
+		}
+		lower = upper
+		if o.ChunkPause > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Wrap(ctx.Err(), "[csdb] Context")
+			case <-time.After(o.ChunkPause):
+			}
+		}
+	}
+}
+
+// oscChunkSQL appends the WHERE condition for the primary key range
+// (lower, upper] to query. A nil bound gets omitted.
+func oscChunkSQL(query, qPK string, lower, upper interface{}) (string, []interface{}) {
+	var args []interface{}
+	switch {
+	case lower != nil && upper != nil:
+		query += " WHERE " + qPK + " > ? AND " + qPK + " <= ?"
+		args = append(args, lower, upper)
+	case lower != nil:
+		query += " WHERE " + qPK + " > ?"
+		args = append(args, lower)
+	case upper != nil:
+		query += " WHERE " + qPK + " <= ?"
+		args = append(args, upper)
+	}
+	return query, args
+}
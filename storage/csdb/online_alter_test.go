@@ -0,0 +1,153 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectOSCCleanup(dbMock sqlmock.Sqlmock) {
+	for i := 0; i < 3; i++ {
+		dbMock.ExpectExec(regexp.QuoteMeta("DROP TRIGGER IF EXISTS ")).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+}
+
+func TestTable_OnlineAlter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+
+		dbMock.ExpectExec(regexp.QuoteMeta("CREATE TABLE `catalog_product_entity_osc` LIKE `catalog_product_entity`")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `catalog_product_entity_osc` ADD COLUMN `x` INT")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(regexp.QuoteMeta("AFTER INSERT ON `catalog_product_entity` FOR EACH ROW REPLACE INTO `catalog_product_entity_osc` (`entity_id`,`sku`) VALUES (NEW.`entity_id`,NEW.`sku`)")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(regexp.QuoteMeta("AFTER UPDATE ON `catalog_product_entity` FOR EACH ROW BEGIN\nDELETE IGNORE FROM `catalog_product_entity_osc` WHERE `entity_id` = OLD.`entity_id`;\nREPLACE INTO")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(regexp.QuoteMeta("AFTER DELETE ON `catalog_product_entity` FOR EACH ROW DELETE IGNORE FROM `catalog_product_entity_osc` WHERE `entity_id` = OLD.`entity_id`")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		dbMock.ExpectQuery(regexp.QuoteMeta("SELECT `entity_id` FROM `catalog_product_entity` ORDER BY `entity_id` LIMIT 1 OFFSET ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"entity_id"}).AddRow(int64(2)))
+		dbMock.ExpectExec(regexp.QuoteMeta("INSERT LOW_PRIORITY IGNORE INTO `catalog_product_entity_osc` (`entity_id`,`sku`) SELECT `entity_id`,`sku` FROM `catalog_product_entity` WHERE `entity_id` <= ? LOCK IN SHARE MODE")).
+			WithArgs(int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		dbMock.ExpectQuery(regexp.QuoteMeta("SELECT `entity_id` FROM `catalog_product_entity` WHERE `entity_id` > ? ORDER BY `entity_id` LIMIT 1 OFFSET ?")).
+			WithArgs(int64(2), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"entity_id"}))
+		dbMock.ExpectExec(regexp.QuoteMeta("SELECT `entity_id`,`sku` FROM `catalog_product_entity` WHERE `entity_id` > ? LOCK IN SHARE MODE")).
+			WithArgs(int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		dbMock.ExpectExec(regexp.QuoteMeta("RENAME TABLE `catalog_product_entity` TO ")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		expectOSCCleanup(dbMock)
+		dbMock.ExpectExec(regexp.QuoteMeta("DROP TABLE IF EXISTS `catalog_product_entity_osc`")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		var throttled int
+		res, err := newBulkTestTable().OnlineAlter(context.TODO(), dbc.DB, "ADD COLUMN `x` INT", csdb.OnlineAlterOptions{
+			ChunkSize: 2,
+			Columns:   []string{"entity_id", "sku"},
+			Throttle: func(context.Context) error {
+				throttled++
+				return nil
+			},
+		})
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "catalog_product_entity_osc", res.ShadowTable)
+		assert.Exactly(t, 2, res.Chunks)
+		assert.Exactly(t, int64(3), res.RowsCopied)
+		assert.Exactly(t, 2, throttled)
+	})
+
+	t.Run("alter fails and cleans up", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+
+		dbMock.ExpectExec(regexp.QuoteMeta("CREATE TABLE `catalog_product_entity_osc` LIKE")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `catalog_product_entity_osc` ADD COLUMN")).
+			WillReturnError(errors.New("Syntax error"))
+		expectOSCCleanup(dbMock)
+		dbMock.ExpectExec(regexp.QuoteMeta("DROP TABLE IF EXISTS `catalog_product_entity_osc`")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := newBulkTestTable().OnlineAlter(context.TODO(), dbc.DB, "ADD COLUMN", csdb.OnlineAlterOptions{})
+		assert.EqualError(t, errors.Cause(err), "Syntax error")
+	})
+
+	t.Run("throttle aborts", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+
+		dbMock.ExpectExec("CREATE TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec("ALTER TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+		for i := 0; i < 3; i++ {
+			dbMock.ExpectExec("CREATE TRIGGER").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		expectOSCCleanup(dbMock)
+		dbMock.ExpectExec("DROP TABLE IF EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := newBulkTestTable().OnlineAlter(context.TODO(), dbc.DB, "DROP COLUMN `sku`", csdb.OnlineAlterOptions{
+			Columns: []string{"entity_id"},
+			Throttle: func(context.Context) error {
+				return errors.NewTemporaryf("replication lag too high")
+			},
+		})
+		assert.True(t, errors.IsTemporary(err), "%+v", err)
+	})
+
+	t.Run("invalid table", func(t *testing.T) {
+		_, err := csdb.NewTable("x",
+			&csdb.Column{Field: "a", Key: "PRI"},
+			&csdb.Column{Field: "b", Key: "PRI"},
+		).OnlineAlter(context.TODO(), nil, "ADD COLUMN `c` INT", csdb.OnlineAlterOptions{})
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+
+		_, err = newBulkTestTable().OnlineAlter(context.TODO(), nil, " ", csdb.OnlineAlterOptions{})
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+
+		tbl := newBulkTestTable()
+		tbl.IsView = true
+		_, err = tbl.OnlineAlter(context.TODO(), nil, "ADD COLUMN `c` INT", csdb.OnlineAlterOptions{})
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+}
@@ -0,0 +1,282 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+)
+
+// Default character set and collation used by CreateDatabase.
+const (
+	DefaultCharacterSet = "utf8"
+	DefaultCollation    = "utf8_general_ci"
+)
+
+// Privileges as reported by SHOW GRANTS. Use them as arguments to
+// CheckPrivileges.
+const (
+	PrivAll              = "ALL PRIVILEGES"
+	PrivAlter            = "ALTER"
+	PrivCreate           = "CREATE"
+	PrivCreateTemporary  = "CREATE TEMPORARY TABLES"
+	PrivCreateView       = "CREATE VIEW"
+	PrivDelete           = "DELETE"
+	PrivDrop             = "DROP"
+	PrivFile             = "FILE"
+	PrivIndex            = "INDEX"
+	PrivInsert           = "INSERT"
+	PrivLockTables       = "LOCK TABLES"
+	PrivReferences       = "REFERENCES"
+	PrivReplicationSlave = "REPLICATION SLAVE"
+	PrivSelect           = "SELECT"
+	PrivShowView         = "SHOW VIEW"
+	PrivTrigger          = "TRIGGER"
+	PrivUpdate           = "UPDATE"
+)
+
+// DatabaseOptions used in CreateDatabase.
+type DatabaseOptions struct {
+	// CharacterSet defaults to DefaultCharacterSet.
+	CharacterSet string
+	// Collation defaults to DefaultCollation if CharacterSet is empty,
+	// otherwise the server default of the character set applies.
+	Collation string
+}
+
+// CreateDatabase creates the database, if it does not exist, with the
+// provided character set and collation. The name gets validated, character
+// set and collation must be alpha numeric.
+func CreateDatabase(ctx context.Context, db dbr.Execer, name string, o DatabaseOptions) error {
+	qName, err := dbr.Quoter.ValidateAndQuote(name)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] CreateDatabase database name")
+	}
+	if o.CharacterSet == "" {
+		o.CharacterSet = DefaultCharacterSet
+		if o.Collation == "" {
+			o.Collation = DefaultCollation
+		}
+	}
+	if err := isValidVarName(o.CharacterSet, false); err != nil {
+		return errors.Wrap(err, "[csdb] CreateDatabase.CharacterSet")
+	}
+	if err := isValidVarName(o.Collation, false); err != nil {
+		return errors.Wrap(err, "[csdb] CreateDatabase.Collation")
+	}
+
+	ddl := "CREATE DATABASE IF NOT EXISTS " + qName + " DEFAULT CHARACTER SET " + o.CharacterSet
+	if o.Collation != "" {
+		ddl += " DEFAULT COLLATE " + o.Collation
+	}
+	_, err = db.ExecContext(ctx, ddl)
+	return errors.Wrapf(err, "[csdb] failed to create database %q", ddl)
+}
+
+// Grant represents one parsed line of SHOW GRANTS.
+type Grant struct {
+	// Privileges in upper case, e.g. SELECT or ALL PRIVILEGES. Column
+	// privileges are not supported and get skipped.
+	Privileges []string
+	// Database name or pattern with the SQL wildcards % and _. An asterisk
+	// applies to all databases.
+	Database string
+	// Table name or an asterisk for all tables.
+	Table string
+}
+
+// Grants contains all grants of a user.
+type Grants []Grant
+
+// LoadGrants loads the grants of the currently connected user via SHOW
+// GRANTS. Roles and proxy grants get ignored.
+func LoadGrants(ctx context.Context, db dbr.Querier) (Grants, error) {
+	rows, err := db.QueryContext(ctx, "SHOW GRANTS FOR CURRENT_USER()")
+	if err != nil {
+		return nil, errors.Wrap(err, "[csdb] LoadGrants.QueryContext")
+	}
+	defer rows.Close()
+
+	var gs Grants
+	var line string
+	for rows.Next() {
+		if err := rows.Scan(&line); err != nil {
+			return nil, errors.Wrap(err, "[csdb] LoadGrants.Scan")
+		}
+		if g, ok := parseGrant(line); ok {
+			gs = append(gs, g)
+		}
+	}
+	return gs, errors.Wrap(rows.Err(), "[csdb] LoadGrants.Rows.Err")
+}
+
+// parseGrant parses a line like:
+//		GRANT SELECT, INSERT ON `magento\_%`.* TO 'shop'@'%'
+func parseGrant(line string) (Grant, bool) {
+	const prefix = "GRANT "
+	if len(line) < len(prefix) || !strings.EqualFold(line[:len(prefix)], prefix) {
+		return Grant{}, false
+	}
+	line = line[len(prefix):]
+	on := strings.Index(line, " ON ")
+	if on < 0 {
+		return Grant{}, false // role grant
+	}
+	target := line[on+4:]
+	if to := strings.Index(target, " TO "); to > 0 {
+		target = target[:to]
+	}
+	target = strings.TrimSpace(target)
+	if strings.HasPrefix(target, "PROCEDURE ") || strings.HasPrefix(target, "FUNCTION ") || strings.HasPrefix(target, "PROXY") {
+		return Grant{}, false
+	}
+
+	var g Grant
+	if dot := strings.LastIndex(target, "."); dot > 0 {
+		g.Database = strings.Trim(target[:dot], "`")
+		g.Table = strings.Trim(target[dot+1:], "`")
+	} else {
+		g.Database = strings.Trim(target, "`")
+		g.Table = "*"
+	}
+
+	privs := line[:on]
+	depth, start := 0, 0
+	for i := 0; i <= len(privs); i++ {
+		switch {
+		case i < len(privs) && privs[i] == '(':
+			depth++
+		case i < len(privs) && privs[i] == ')':
+			depth--
+		case i == len(privs) || (privs[i] == ',' && depth == 0):
+			p := strings.ToUpper(strings.TrimSpace(privs[start:i]))
+			start = i + 1
+			if p == "" || strings.ContainsRune(p, '(') {
+				continue // column privilege
+			}
+			if p == "ALL" {
+				p = PrivAll
+			}
+			g.Privileges = append(g.Privileges, p)
+		}
+	}
+	return g, true
+}
+
+// matchDatabase checks if the database name matches the grant. Table level
+// grants are not sufficient for a whole database.
+func (g Grant) matchDatabase(database string) bool {
+	if g.Table != "*" {
+		return false
+	}
+	return g.Database == "*" || likeMatch(g.Database, database)
+}
+
+// Has reports whether the privilege has been granted on all tables of the
+// database. ALL PRIVILEGES includes any privilege. The database "*" matches
+// only global grants.
+func (gs Grants) Has(database, privilege string) bool {
+	privilege = strings.ToUpper(privilege)
+	for _, g := range gs {
+		if !g.matchDatabase(database) {
+			continue
+		}
+		for _, p := range g.Privileges {
+			if p == privilege || p == PrivAll {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Missing returns the privileges which have not been granted on the database.
+// Grants ON *.* apply to all databases. Global privileges like FILE should be
+// checked with database "*".
+func (gs Grants) Missing(database string, privileges ...string) []string {
+	var missing []string
+	for _, p := range privileges {
+		if !gs.Has(database, p) {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// CheckPrivileges verifies that the connected user has been granted all
+// privileges on the database. Call it at startup before long running imports.
+// Returns a PermissionDenied error which lists all missing privileges.
+func CheckPrivileges(ctx context.Context, db dbr.Querier, database string, privileges ...string) error {
+	gs, err := LoadGrants(ctx, db)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] CheckPrivileges.LoadGrants")
+	}
+	if missing := gs.Missing(database, privileges...); len(missing) > 0 {
+		return errors.NewPermissionDeniedf("[csdb] Missing privileges on database %q: %s", database, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// CheckLocalInfile verifies that the server allows LOAD DATA LOCAL INFILE,
+// which is additionally to the INSERT privilege required by
+// Table.LoadDataInfile. Returns a NotSupported error if local_infile has been
+// disabled.
+func CheckLocalInfile(db dbr.QueryRower) error {
+	var v Variable
+	if err := v.LoadOne(db, "local_infile"); err != nil {
+		return errors.Wrap(err, "[csdb] CheckLocalInfile.LoadOne")
+	}
+	switch strings.ToUpper(v.Value) {
+	case "ON", "1":
+		return nil
+	}
+	return errors.NewNotSupportedf("[csdb] Server variable local_infile is %q", v.Value)
+}
+
+// likeMatch matches name against a SQL LIKE pattern as used in grants. An
+// escaped \_ or \% matches the literal character.
+func likeMatch(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch c := pattern[0]; {
+		case c == '\\' && len(pattern) > 1:
+			if len(name) == 0 || name[0] != pattern[1] {
+				return false
+			}
+			pattern, name = pattern[2:], name[1:]
+		case c == '%':
+			pattern = pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if likeMatch(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		case c == '_':
+			if len(name) == 0 {
+				return false
+			}
+			pattern, name = pattern[1:], name[1:]
+		default:
+			if len(name) == 0 || name[0] != c {
+				return false
+			}
+			pattern, name = pattern[1:], name[1:]
+		}
+	}
+	return len(name) == 0
+}
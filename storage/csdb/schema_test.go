@@ -0,0 +1,161 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGrant(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		line   string
+		want   Grant
+		wantOK bool
+	}{
+		{"GRANT USAGE ON *.* TO 'shop'@'%'", Grant{Privileges: []string{"USAGE"}, Database: "*", Table: "*"}, true},
+		{"GRANT ALL PRIVILEGES ON `magento`.* TO 'shop'@'localhost' WITH GRANT OPTION", Grant{Privileges: []string{PrivAll}, Database: "magento", Table: "*"}, true},
+		{"GRANT ALL ON *.* TO 'root'@'localhost'", Grant{Privileges: []string{PrivAll}, Database: "*", Table: "*"}, true},
+		{"GRANT SELECT, INSERT, CREATE TEMPORARY TABLES, LOCK TABLES ON `magento\\_%`.* TO 'shop'@'%'",
+			Grant{Privileges: []string{PrivSelect, PrivInsert, PrivCreateTemporary, PrivLockTables}, Database: "magento\\_%", Table: "*"}, true},
+		{"GRANT SELECT (entity_id, sku), UPDATE ON `magento`.`catalog_product_entity` TO 'shop'@'%'",
+			Grant{Privileges: []string{PrivUpdate}, Database: "magento", Table: "catalog_product_entity"}, true},
+		{"GRANT `app_role`@`%` TO `shop`@`%`", Grant{}, false},
+		{"GRANT EXECUTE ON PROCEDURE `magento`.`p1` TO 'shop'@'%'", Grant{}, false},
+		{"REVOKE ALL", Grant{}, false},
+	}
+	for i, test := range tests {
+		have, ok := parseGrant(test.line)
+		assert.Exactly(t, test.wantOK, ok, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
+
+func TestLikeMatch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"magento", "magento", true},
+		{"magento", "magento2", false},
+		{"magento%", "magento2", true},
+		{"magento%", "magento", true},
+		{"mag%to", "magento", true},
+		{"magent_", "magento", true},
+		{"magent_", "magent", false},
+		{"magento\\_%", "magento_test", true},
+		{"magento\\_%", "magentoXtest", false},
+		{"%", "", true},
+		{"", "a", false},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, likeMatch(test.pattern, test.name), "Index %d", i)
+	}
+}
+
+func TestGrants_Missing(t *testing.T) {
+	t.Parallel()
+	gs := Grants{
+		{Privileges: []string{"USAGE", PrivFile}, Database: "*", Table: "*"},
+		{Privileges: []string{PrivSelect, PrivInsert}, Database: "magento\\_%", Table: "*"},
+		{Privileges: []string{PrivAll}, Database: "import", Table: "*"},
+		{Privileges: []string{PrivTrigger}, Database: "magento_shop", Table: "catalog_product_entity"},
+	}
+	assert.Nil(t, gs.Missing("magento_shop", PrivSelect, "insert"))
+	assert.Exactly(t, []string{PrivTrigger, PrivCreate}, gs.Missing("magento_shop", PrivSelect, PrivTrigger, PrivCreate))
+	assert.Nil(t, gs.Missing("import", PrivTrigger, PrivCreate))
+	assert.Nil(t, gs.Missing("*", PrivFile))
+	assert.Nil(t, gs.Missing("magento_shop", PrivFile), "Global grants apply to all databases")
+	assert.Exactly(t, []string{PrivSelect}, gs.Missing("*", PrivSelect), "SELECT has not been granted globally")
+	assert.Exactly(t, []string{PrivSelect}, gs.Missing("other", PrivSelect))
+}
+
+func TestCheckPrivileges(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	grantRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"Grants for shop@%"}).
+			AddRow("GRANT USAGE ON *.* TO 'shop'@'%'").
+			AddRow("GRANT SELECT, INSERT, UPDATE, DELETE ON `magento`.* TO 'shop'@'%'")
+	}
+	dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("SHOW GRANTS FOR CURRENT_USER()")).WillReturnRows(grantRows())
+	dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("SHOW GRANTS FOR CURRENT_USER()")).WillReturnRows(grantRows())
+	dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("SHOW GRANTS FOR CURRENT_USER()")).WillReturnError(errors.NewAlreadyClosedf("Connection closed"))
+
+	assert.NoError(t, CheckPrivileges(context.TODO(), dbc.DB, "magento", PrivSelect, PrivInsert))
+
+	err := CheckPrivileges(context.TODO(), dbc.DB, "magento", PrivSelect, PrivTrigger, PrivCreateView)
+	assert.True(t, errors.IsPermissionDenied(err), "%+v", err)
+	assert.Contains(t, err.Error(), "TRIGGER, CREATE VIEW")
+
+	err = CheckPrivileges(context.TODO(), dbc.DB, "magento", PrivSelect)
+	assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
+}
+
+func TestCreateDatabase(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("CREATE DATABASE IF NOT EXISTS `magento` DEFAULT CHARACTER SET utf8 DEFAULT COLLATE utf8_general_ci")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("CREATE DATABASE IF NOT EXISTS `magento` DEFAULT CHARACTER SET utf8mb4 DEFAULT COLLATE utf8mb4_unicode_ci")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("CREATE DATABASE IF NOT EXISTS `magento` DEFAULT CHARACTER SET latin1")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, CreateDatabase(context.TODO(), dbc.DB, "magento", DatabaseOptions{}))
+	require.NoError(t, CreateDatabase(context.TODO(), dbc.DB, "magento", DatabaseOptions{CharacterSet: "utf8mb4", Collation: "utf8mb4_unicode_ci"}))
+	require.NoError(t, CreateDatabase(context.TODO(), dbc.DB, "magento", DatabaseOptions{CharacterSet: "latin1"}))
+
+	err := CreateDatabase(context.TODO(), dbc.DB, "mag`ento", DatabaseOptions{})
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	err = CreateDatabase(context.TODO(), dbc.DB, "magento", DatabaseOptions{CharacterSet: "utf8; DROP"})
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestCheckLocalInfile(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	dbMock.ExpectQuery("SHOW SESSION VARIABLES LIKE").WithArgs("local_infile").
+		WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).AddRow("local_infile", "ON"))
+	dbMock.ExpectQuery("SHOW SESSION VARIABLES LIKE").WithArgs("local_infile").
+		WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).AddRow("local_infile", "OFF"))
+
+	assert.NoError(t, CheckLocalInfile(dbc.DB))
+	err := CheckLocalInfile(dbc.DB)
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+}
@@ -0,0 +1,160 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/errors"
+)
+
+// DefaultSequenceTable defines the name of the table which stores the counters
+// of all sequences.
+const DefaultSequenceTable = "csdb_sequence"
+
+// IDGenerator generates unique IDs for entities which need to know their ID
+// before they get inserted into the database.
+type IDGenerator interface {
+	NextID(ctx context.Context) (int64, error)
+}
+
+// IDGeneratorFunc type is an adapter to allow the use of ordinary functions as
+// IDGenerator.
+type IDGeneratorFunc func(ctx context.Context) (int64, error)
+
+// NextID calls f(ctx).
+func (f IDGeneratorFunc) NextID(ctx context.Context) (int64, error) { return f(ctx) }
+
+// Sequence emulates a database sequence with a row in a counter table. A
+// counter gets incremented atomically with
+//		UPDATE `csdb_sequence` SET `value`=LAST_INSERT_ID(`value`+?) WHERE `name`=?
+// and MySQL returns the new value in the last insert ID of the result, so no
+// second query is needed and the statement works with a connection pool.
+//
+// BlockSize defines how many IDs get reserved with one UPDATE. The IDs of a
+// block are handed out from memory which reduces the round trips for high
+// throughput. IDs of an unused block get lost when the application stops, so
+// a sequence may have gaps but never duplicates. Sequence is safe for
+// concurrent use.
+type Sequence struct {
+	// Name identifies the counter row in the table.
+	Name string
+	// Table (optional) name of the counter table. Defaults to
+	// DefaultSequenceTable.
+	Table string
+	// BlockSize (optional) number of IDs to reserve per database round trip.
+	// Values below one are treated as one.
+	BlockSize int64
+	// DB executes the UPDATE statement. Must not be a prepared statement.
+	DB dbr.Execer
+
+	mu   sync.Mutex
+	next int64 // next free ID in the current block
+	last int64 // last ID of the current block
+}
+
+// NewSequence creates a new sequence with the default table name.
+func NewSequence(db dbr.Execer, name string, blockSize int64) *Sequence {
+	return &Sequence{
+		Name:      name,
+		BlockSize: blockSize,
+		DB:        db,
+	}
+}
+
+func (s *Sequence) tableName() string {
+	if s.Table != "" {
+		return s.Table
+	}
+	return DefaultSequenceTable
+}
+
+func (s *Sequence) blockSize() int64 {
+	if s.BlockSize < 1 {
+		return 1
+	}
+	return s.BlockSize
+}
+
+// Create creates the counter table, if it does not exists, and inserts the
+// counter row for the sequence starting at value start. An existing counter
+// stays untouched.
+func (s *Sequence) Create(ctx context.Context, start int64) error {
+	qTable, err := dbr.Quoter.ValidateAndQuote(s.tableName())
+	if err != nil {
+		return errors.Wrap(err, "[csdb] Sequence.Create table name")
+	}
+
+	ddl := "CREATE TABLE IF NOT EXISTS " + qTable + " (`name` VARCHAR(64) NOT NULL PRIMARY KEY, `value` BIGINT NOT NULL DEFAULT 0) ENGINE=InnoDB"
+	if _, err := s.DB.ExecContext(ctx, ddl); err != nil {
+		return errors.Wrapf(err, "[csdb] Sequence.Create failed to create table %q", s.tableName())
+	}
+	ins := "INSERT IGNORE INTO " + qTable + " (`name`,`value`) VALUES (?,?)"
+	if _, err := s.DB.ExecContext(ctx, ins, s.Name, start); err != nil {
+		return errors.Wrapf(err, "[csdb] Sequence.Create failed to insert sequence %q", s.Name)
+	}
+	return nil
+}
+
+// NextID returns the next ID of the sequence. A new block gets reserved when
+// the current block has been exhausted. Returns a NotFound error if the
+// counter row does not exist.
+func (s *Sequence) NextID(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next == 0 || s.next > s.last {
+		last, err := s.reserve(ctx, s.blockSize())
+		if err != nil {
+			return 0, errors.Wrap(err, "[csdb] Sequence.NextID")
+		}
+		s.next = last - s.blockSize() + 1
+		s.last = last
+	}
+	id := s.next
+	s.next++
+	return id, nil
+}
+
+// reserve increments the counter by n and returns the new counter value which
+// is the last ID of the reserved block.
+func (s *Sequence) reserve(ctx context.Context, n int64) (int64, error) {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	buf.WriteString("UPDATE ")
+	dbr.Quoter.FquoteAs(buf, s.tableName())
+	buf.WriteString(" SET `value`=LAST_INSERT_ID(`value`+?) WHERE `name`=?")
+
+	res, err := s.DB.ExecContext(ctx, buf.String(), n, s.Name)
+	if err != nil {
+		return 0, errors.Wrapf(err, "[csdb] Sequence %q failed to reserve %d IDs", s.Name, n)
+	}
+	if aff, err := res.RowsAffected(); err != nil {
+		return 0, errors.Wrapf(err, "[csdb] Sequence %q RowsAffected", s.Name)
+	} else if aff == 0 {
+		return 0, errors.NewNotFoundf("[csdb] Sequence %q not found in table %q", s.Name, s.tableName())
+	}
+	last, err := res.LastInsertId()
+	return last, errors.Wrapf(err, "[csdb] Sequence %q LastInsertId", s.Name)
+}
+
+var (
+	_ IDGenerator = (*Sequence)(nil)
+	_ IDGenerator = (*Snowflake)(nil)
+	_ IDGenerator = (IDGeneratorFunc)(nil)
+)
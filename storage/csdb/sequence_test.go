@@ -0,0 +1,167 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequence_NextID(t *testing.T) {
+	t.Parallel()
+
+	t.Run("block allocation", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Error("there were unfulfilled expections", err)
+			}
+		}()
+
+		upd := cstesting.SQLMockQuoteMeta("UPDATE `csdb_sequence` SET `value`=LAST_INSERT_ID(`value`+?) WHERE `name`=?")
+		dbMock.ExpectExec(upd).WithArgs(3, "sales_order").WillReturnResult(sqlmock.NewResult(13, 1))
+		dbMock.ExpectExec(upd).WithArgs(3, "sales_order").WillReturnResult(sqlmock.NewResult(19, 1))
+
+		seq := csdb.NewSequence(dbc.DB, "sales_order", 3)
+		var have []int64
+		for i := 0; i < 5; i++ {
+			id, err := seq.NextID(context.TODO())
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			have = append(have, id)
+		}
+		// another instance has reserved 14-16
+		assert.Exactly(t, []int64{11, 12, 13, 17, 18}, have)
+	})
+
+	t.Run("sequence not found", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Error("there were unfulfilled expections", err)
+			}
+		}()
+
+		dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("UPDATE `my_seq` SET `value`=LAST_INSERT_ID(`value`+?) WHERE `name`=?")).
+			WithArgs(1, "unknown").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		seq := csdb.NewSequence(dbc.DB, "unknown", 0)
+		seq.Table = "my_seq"
+		id, err := seq.NextID(context.TODO())
+		assert.Exactly(t, int64(0), id)
+		assert.True(t, errors.IsNotFound(err), "%+v", err)
+	})
+}
+
+func TestSequence_Create(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("CREATE TABLE IF NOT EXISTS `csdb_sequence` (`name` VARCHAR(64) NOT NULL PRIMARY KEY, `value` BIGINT NOT NULL DEFAULT 0) ENGINE=InnoDB")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("INSERT IGNORE INTO `csdb_sequence` (`name`,`value`) VALUES (?,?)")).
+		WithArgs("sales_order", 100000).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, csdb.NewSequence(dbc.DB, "sales_order", 10).Create(context.TODO(), 100000))
+}
+
+func TestSnowflake(t *testing.T) {
+	t.Parallel()
+
+	_, err := csdb.NewSnowflake(1024)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+
+	sf, err := csdb.NewSnowflake(7)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	now := csdb.DefaultSnowflakeEpoch.Add(time.Hour)
+	sf.Now = func() time.Time { return now }
+
+	id1, err := sf.NextID(context.TODO())
+	assert.NoError(t, err)
+	id2, err := sf.NextID(context.TODO())
+	assert.NoError(t, err)
+	assert.True(t, id2 > id1, "id2 %d must be greater than id1 %d", id2, id1)
+
+	ts, node, seq := sf.Parse(id2)
+	assert.True(t, now.Equal(ts), "%s != %s", now, ts)
+	assert.Exactly(t, int64(7), node)
+	assert.Exactly(t, int64(1), seq)
+
+	now = now.Add(-time.Millisecond)
+	_, err = sf.NextID(context.TODO())
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestSnowflake_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	sf, err := csdb.NewSnowflake(1)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var gen csdb.IDGenerator = sf
+
+	const goroutines, perG = 8, 2000
+	ids := make(chan int64, goroutines*perG)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perG; j++ {
+				id, err := gen.NextID(context.TODO())
+				if err != nil {
+					t.Errorf("%+v", err)
+					return
+				}
+				ids <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]bool, goroutines*perG)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("Duplicate ID %d", id)
+		}
+		seen[id] = true
+	}
+	assert.Len(t, seen, goroutines*perG)
+}
@@ -0,0 +1,113 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/errors"
+)
+
+// Snowflake bit layout: 41 bits milliseconds since the epoch, 10 bits node ID
+// and 12 bits sequence within the same millisecond.
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeMaxNode      = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+// DefaultSnowflakeEpoch defines the start time of the Snowflake time stamps.
+var DefaultSnowflakeEpoch = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflake generates roughly time ordered unique 63 bit IDs without asking
+// the database. Each running instance needs its own node ID between 0 and
+// 1023. Up to 4096 IDs per millisecond and node can be generated, after that
+// NextID waits for the next millisecond. Snowflake is safe for concurrent use.
+type Snowflake struct {
+	// Epoch defines the start of the time stamp. Must not be changed once
+	// IDs have been generated.
+	Epoch time.Time
+	// Now (optional) returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	node   int64
+	mu     sync.Mutex
+	lastMS int64
+	seq    int64
+}
+
+// NewSnowflake creates a new Snowflake generator with the DefaultSnowflakeEpoch.
+// Returns a NotValid error if nodeID is out of range.
+func NewSnowflake(nodeID int64) (*Snowflake, error) {
+	if nodeID < 0 || nodeID > snowflakeMaxNode {
+		return nil, errors.NewNotValidf("[csdb] Snowflake node ID %d must be between 0 and %d", nodeID, snowflakeMaxNode)
+	}
+	return &Snowflake{
+		Epoch: DefaultSnowflakeEpoch,
+		node:  nodeID,
+	}, nil
+}
+
+func (s *Snowflake) millis() int64 {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	return int64(now().Sub(s.Epoch) / time.Millisecond)
+}
+
+// NextID generates a new ID. Returns a NotValid error if the clock runs
+// backwards or the time is before the Epoch. The context gets checked while
+// waiting for the next millisecond.
+func (s *Snowflake) NextID(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := s.millis()
+	switch {
+	case ms < 0:
+		return 0, errors.NewNotValidf("[csdb] Snowflake current time is before the epoch %s", s.Epoch)
+	case ms < s.lastMS:
+		return 0, errors.NewNotValidf("[csdb] Snowflake clock moved backwards by %d ms", s.lastMS-ms)
+	case ms == s.lastMS:
+		s.seq = (s.seq + 1) & snowflakeMaxSequence
+		if s.seq == 0 {
+			// sequence exhausted, wait for the next millisecond
+			for ms <= s.lastMS {
+				if err := ctx.Err(); err != nil {
+					return 0, errors.Wrap(err, "[csdb] Snowflake.NextID")
+				}
+				time.Sleep(100 * time.Microsecond)
+				ms = s.millis()
+			}
+		}
+	default:
+		s.seq = 0
+	}
+	s.lastMS = ms
+	return ms<<(snowflakeNodeBits+snowflakeSequenceBits) | s.node<<snowflakeSequenceBits | s.seq, nil
+}
+
+// Parse splits an ID into its time stamp, node ID and sequence.
+func (s *Snowflake) Parse(id int64) (ts time.Time, nodeID, seq int64) {
+	ms := id >> (snowflakeNodeBits + snowflakeSequenceBits)
+	ts = s.Epoch.Add(time.Duration(ms) * time.Millisecond)
+	nodeID = (id >> snowflakeSequenceBits) & snowflakeMaxNode
+	seq = id & snowflakeMaxSequence
+	return
+}
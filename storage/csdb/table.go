@@ -19,6 +19,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/corestoreio/csfw/storage/dbr"
@@ -31,8 +32,11 @@ import (
 type Table struct {
 	// Schema represents the name of the database. Might be empty.
 	Schema string
-	// Name of the table
+	// Name of the table including the Prefix.
 	Name string
+	// Prefix has been prepended to the Name and gets also applied to the
+	// table names passed to Rename and Swap. Set by Tables.
+	Prefix string
 	// Columns all table columns
 	Columns Columns
 	// CountPK number of primary keys. Auto updated.
@@ -62,6 +66,28 @@ func NewTable(tableName string, cs ...*Column) *Table {
 	return ts.update()
 }
 
+// prefixTableName prepends the prefix if the name does not yet start with it.
+func prefixTableName(prefix, name string) string {
+	if prefix == "" || name == "" || strings.HasPrefix(name, prefix) {
+		return name
+	}
+	return prefix + name
+}
+
+// applyPrefix sets the prefix and rebuilds the SELECT cache, if the name
+// changes.
+func (t *Table) applyPrefix(prefix string) *Table {
+	if prefix == "" {
+		return t
+	}
+	t.Prefix = prefix
+	if n := prefixTableName(prefix, t.Name); n != t.Name {
+		t.Name = n
+		t.update()
+	}
+	return t
+}
+
 // update recalculates the internal cached fields
 func (t *Table) update() *Table {
 	if len(t.Columns) == 0 {
@@ -140,11 +166,12 @@ func (t *Table) Truncate(ctx context.Context, execer dbr.Execer) error {
 	if t.IsView {
 		return nil
 	}
-	if err := IsValidIdentifier(t.Name); err != nil {
+	qName, err := dbr.Quoter.ValidateAndQuote(t.Name)
+	if err != nil {
 		return errors.Wrap(err, "[csdb] Truncate table name")
 	}
-	ddl := "TRUNCATE TABLE " + dbr.Quoter.QuoteAs(t.Name)
-	_, err := execer.ExecContext(ctx, ddl)
+	ddl := "TRUNCATE TABLE " + qName
+	_, err = execer.ExecContext(ctx, ddl)
 	return errors.Wrapf(err, "[csdb] failed to truncate table %q", ddl)
 }
 
@@ -152,21 +179,29 @@ func (t *Table) Truncate(ctx context.Context, execer dbr.Execer) error {
 // operation in the database. As long as two databases are on the same file
 // system, you can use RENAME TABLE to move a table from one database to
 // another. RENAME TABLE also works for views, as long as you do not try to
-// rename a view into a different database.
+// rename a view into a different database. The table Prefix gets applied to
+// the new name.
 func (t *Table) Rename(ctx context.Context, execer dbr.Execer, new string) error {
-	if err := IsValidIdentifier(t.Name, new); err != nil {
+	qOld, err := dbr.Quoter.ValidateAndQuote(t.Name)
+	if err != nil {
 		return errors.Wrap(err, "[csdb] Rename table name")
 	}
-	ddl := "RENAME TABLE " + dbr.Quoter.QuoteAs(t.Name) + " TO " + dbr.Quoter.QuoteAs(new)
-	_, err := execer.ExecContext(ctx, ddl)
+	qNew, err := dbr.Quoter.ValidateAndQuote(prefixTableName(t.Prefix, new))
+	if err != nil {
+		return errors.Wrap(err, "[csdb] Rename new table name")
+	}
+	ddl := "RENAME TABLE " + qOld + " TO " + qNew
+	_, err = execer.ExecContext(ctx, ddl)
 	return errors.Wrapf(err, "[csdb] failed to rename table %q", ddl)
 }
 
 // Swap swaps the current table with the other table of the same structure.
 // Renaming is an atomic operation in the database. Note: indexes won't get
 // swapped! As long as two databases are on the same file system, you can use
-// RENAME TABLE to move a table from one database to another.
+// RENAME TABLE to move a table from one database to another. The table
+// Prefix gets applied to the other table name.
 func (t *Table) Swap(ctx context.Context, execer dbr.Execer, other string) error {
+	other = prefixTableName(t.Prefix, other)
 	if err := IsValidIdentifier(t.Name, other); err != nil {
 		return errors.Wrap(err, "[csdb] Swap table name")
 	}
@@ -201,10 +236,11 @@ func (t *Table) Drop(ctx context.Context, execer dbr.Execer) error {
 	if t.IsView {
 		typ = "VIEW"
 	}
-	if err := IsValidIdentifier(t.Name); err != nil {
+	qName, err := dbr.Quoter.ValidateAndQuote(t.Name)
+	if err != nil {
 		return errors.Wrap(err, "[csdb] Drop table name")
 	}
-	_, err := execer.ExecContext(ctx, "DROP "+typ+" IF EXISTS "+dbr.Quoter.QuoteAs(t.Name))
+	_, err = execer.ExecContext(ctx, "DROP "+typ+" IF EXISTS "+qName)
 	return errors.Wrapf(err, "[csdb] failed to drop table %q", t.Name)
 }
 
@@ -287,6 +323,17 @@ func (t *Table) LoadDataInfile(ctx context.Context, execer dbr.Execer, filePath
 		o.Log = log.BlackHole{}
 	}
 
+	query := t.infileSQL(filePath, o)
+	if o.Log.IsDebug() {
+		o.Log.Debug("csdb.Table.Infile.SQL", log.String("sql", query))
+	}
+
+	_, err := execer.ExecContext(ctx, query)
+	return errors.NewFatal(err, "[csb] Infile for table %q failed with query: %q", t.Name, query)
+}
+
+// infileSQL builds the LOAD DATA INFILE statement.
+func (t *Table) infileSQL(filePath string, o InfileOptions) string {
 	var buf bytes.Buffer
 	buf.WriteString("LOAD DATA ")
 	if !o.IsNotLocal {
@@ -302,7 +349,7 @@ func (t *Table) LoadDataInfile(ctx context.Context, execer dbr.Execer, filePath
 		buf.WriteString(" IGNORE ")
 	}
 	buf.WriteString(" INTO TABLE ")
-	buf.WriteString(dbr.Quoter.Quote(t.Schema, t.Name))
+	buf.WriteString(dbr.Quoter.QuoteQualified(t.Schema, t.Name))
 
 	var hasFields bool
 	if o.FieldsEscapedBy > 0 || o.FieldsTerminatedBy != "" || o.FieldsEnclosedBy > 0 {
@@ -383,11 +430,5 @@ func (t *Table) LoadDataInfile(ctx context.Context, execer dbr.Execer, filePath
 		}
 	}
 	buf.WriteRune(';')
-
-	if o.Log.IsDebug() {
-		o.Log.Debug("csdb.Table.Infile.SQL", log.String("sql", buf.String()))
-	}
-
-	_, err := execer.ExecContext(ctx, buf.String())
-	return errors.NewFatal(err, "[csb] Infile for table %q failed with query: %q", t.Name, buf.String())
+	return buf.String()
 }
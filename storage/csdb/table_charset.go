@@ -0,0 +1,219 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+)
+
+// TableCharset describes the storage engine and the character set of a table
+// as reported by information_schema.
+type TableCharset struct {
+	Name      string
+	Engine    string
+	Collation string
+	Charset   string
+	// Columns number of text columns whose character set differs from the
+	// target character set of the ConvertOptions.
+	Columns int
+}
+
+// ConvertOptions configures the conversion of the storage engine and the
+// character set of tables.
+type ConvertOptions struct {
+	// Engine target storage engine. Defaults to InnoDB.
+	Engine string
+	// Charset target character set. Defaults to utf8mb4.
+	Charset string
+	// Collation target collation. Defaults to utf8mb4_unicode_ci.
+	Collation string
+	// BatchSize number of ALTER TABLE statements executed in one batch before
+	// Throttle and BatchPause gets applied. Defaults to 1.
+	BatchSize int
+	// BatchPause duration to sleep after each batch to reduce the load on the
+	// server and the replication.
+	BatchPause time.Duration
+	// Throttle gets called before each batch. It can block, for example until
+	// the replication lag drops below a threshold. A returned error aborts the
+	// conversion.
+	Throttle func(ctx context.Context) error
+	// DryRun if not nil, the ALTER TABLE statements get written to this writer
+	// instead of being executed.
+	DryRun io.Writer
+	// Log reports the progress on level info. Can be nil.
+	Log log.Logger
+}
+
+func (o *ConvertOptions) setDefaults() {
+	if o.Engine == "" {
+		o.Engine = "InnoDB"
+	}
+	if o.Charset == "" {
+		o.Charset = "utf8mb4"
+	}
+	if o.Collation == "" {
+		o.Collation = "utf8mb4_unicode_ci"
+	}
+	if o.BatchSize < 1 {
+		o.BatchSize = 1
+	}
+	if o.Log == nil {
+		o.Log = log.BlackHole{}
+	}
+}
+
+// needsEngine reports whether the storage engine differs from the target.
+func (tc TableCharset) needsEngine(o ConvertOptions) bool {
+	return !strings.EqualFold(tc.Engine, o.Engine)
+}
+
+// needsCharset reports whether the table or one of its columns uses a
+// different character set or collation than the target.
+func (tc TableCharset) needsCharset(o ConvertOptions) bool {
+	return !strings.EqualFold(tc.Charset, o.Charset) || !strings.EqualFold(tc.Collation, o.Collation) || tc.Columns > 0
+}
+
+// AlterSQL returns the ALTER TABLE statement which converts the table to the
+// target engine and character set. Returns an empty string if the table
+// already conforms.
+func (tc TableCharset) AlterSQL(o ConvertOptions) string {
+	o.setDefaults()
+	var specs []string
+	if tc.needsEngine(o) {
+		specs = append(specs, "ENGINE="+o.Engine)
+	}
+	if tc.needsCharset(o) {
+		specs = append(specs, "CONVERT TO CHARACTER SET "+o.Charset+" COLLATE "+o.Collation)
+	}
+	if len(specs) == 0 {
+		return ""
+	}
+	return "ALTER TABLE " + dbr.Quoter.QuoteAs(tc.Name) + " " + strings.Join(specs, ", ")
+}
+
+const selTableCharsets = `SELECT t.TABLE_NAME, t.ENGINE, t.TABLE_COLLATION, ccsa.CHARACTER_SET_NAME,
+	(SELECT COUNT(*) FROM information_schema.COLUMNS c WHERE c.TABLE_SCHEMA=t.TABLE_SCHEMA AND c.TABLE_NAME=t.TABLE_NAME
+		AND c.CHARACTER_SET_NAME IS NOT NULL AND (c.CHARACTER_SET_NAME<>? OR c.COLLATION_NAME<>?)) AS DIFF_COLUMNS
+	FROM information_schema.TABLES t
+	LEFT JOIN information_schema.COLLATION_CHARACTER_SET_APPLICABILITY ccsa ON ccsa.COLLATION_NAME=t.TABLE_COLLATION
+	WHERE t.TABLE_SCHEMA=DATABASE() AND t.TABLE_TYPE='BASE TABLE'`
+
+// LoadUnconvertedTables returns all tables of the current database which use
+// a different storage engine, character set or collation than defined in the
+// options. A table gets also reported when only some of its columns use a
+// different character set. All tables get checked when you don't provide the
+// argument `tables`. The returned slice is ordered by the table name.
+func LoadUnconvertedTables(ctx context.Context, db dbr.Querier, o ConvertOptions, tables ...string) ([]TableCharset, error) {
+	o.setDefaults()
+
+	sqlStr := selTableCharsets + " ORDER BY t.TABLE_NAME"
+	args := []interface{}{o.Charset, o.Collation}
+	if len(tables) > 0 {
+		var err error
+		sqlStr, args, err = dbr.Repeat(selTableCharsets+" AND t.TABLE_NAME IN (?) ORDER BY t.TABLE_NAME",
+			dbr.ArgString(o.Charset), dbr.ArgString(o.Collation), dbr.ArgString(tables...))
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadUnconvertedTables dbr.Repeat for tables %v", tables)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[csdb] LoadUnconvertedTables QueryContext for tables %v", tables)
+	}
+	defer rows.Close()
+
+	var tcs []TableCharset
+	for rows.Next() {
+		var tc TableCharset
+		var engine, collation, charset dbr.NullString
+		if err := rows.Scan(&tc.Name, &engine, &collation, &charset, &tc.Columns); err != nil {
+			return nil, errors.Wrap(err, "[csdb] LoadUnconvertedTables Scan Query")
+		}
+		tc.Engine, tc.Collation, tc.Charset = engine.String, collation.String, charset.String
+		if tc.AlterSQL(o) != "" {
+			tcs = append(tcs, tc)
+		}
+	}
+	return tcs, errors.Wrap(rows.Err(), "[csdb] LoadUnconvertedTables rows.Err Query")
+}
+
+// ConvertTables converts the tables, which do not conform to the options, to
+// the target storage engine and character set. The ALTER TABLE statements get
+// executed in batches of BatchSize. Throttle and BatchPause get applied between
+// the batches. With DryRun set, the statements get written to the writer and
+// nothing gets executed. Returns the executed or written statements. The db
+// argument should be a *sql.DB and not a transaction because an ALTER TABLE
+// causes an implicit commit.
+func ConvertTables(ctx context.Context, db interface {
+	dbr.Querier
+	dbr.Execer
+}, o ConvertOptions, tables ...string) ([]string, error) {
+	o.setDefaults()
+
+	tcs, err := LoadUnconvertedTables(ctx, db, o, tables...)
+	if err != nil {
+		return nil, errors.Wrap(err, "[csdb] ConvertTables")
+	}
+	stmts := make([]string, 0, len(tcs))
+	for _, tc := range tcs {
+		stmts = append(stmts, tc.AlterSQL(o))
+	}
+
+	for i := 0; i < len(stmts); i += o.BatchSize {
+		end := i + o.BatchSize
+		if end > len(stmts) {
+			end = len(stmts)
+		}
+		batch := i/o.BatchSize + 1
+
+		if o.DryRun != nil {
+			if _, err := fmt.Fprintf(o.DryRun, "-- Batch %d\n%s;\n", batch, strings.Join(stmts[i:end], ";\n")); err != nil {
+				return stmts[:i], errors.Wrap(err, "[csdb] ConvertTables.DryRun.Write")
+			}
+			continue
+		}
+
+		if i > 0 && o.BatchPause > 0 {
+			select {
+			case <-ctx.Done():
+				return stmts[:i], errors.Wrap(ctx.Err(), "[csdb] ConvertTables")
+			case <-time.After(o.BatchPause):
+			}
+		}
+		if o.Throttle != nil {
+			if err := o.Throttle(ctx); err != nil {
+				return stmts[:i], errors.Wrapf(err, "[csdb] ConvertTables.Throttle before batch %d", batch)
+			}
+		}
+		for j := i; j < end; j++ {
+			if _, err := db.ExecContext(ctx, stmts[j]); err != nil {
+				return stmts[:j], errors.Wrapf(err, "[csdb] ConvertTables.ExecContext: %q", stmts[j])
+			}
+		}
+		if o.Log.IsInfo() {
+			o.Log.Info("csdb.ConvertTables.Batch", log.Int("batch", batch), log.Strings("statements", stmts[i:end]...))
+		}
+	}
+	return stmts, nil
+}
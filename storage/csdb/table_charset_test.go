@@ -0,0 +1,173 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tableCharsetCols = []string{"TABLE_NAME", "ENGINE", "TABLE_COLLATION", "CHARACTER_SET_NAME", "DIFF_COLUMNS"}
+
+func tableCharsetRows() *sqlmock.Rows {
+	return sqlmock.NewRows(tableCharsetCols).
+		AddRow("catalog_product_entity", "InnoDB", "utf8mb4_unicode_ci", "utf8mb4", 0).
+		AddRow("core_config_data", "InnoDB", "utf8_general_ci", "utf8", 2).
+		AddRow("customer_visitor", "MyISAM", "utf8mb4_unicode_ci", "utf8mb4", 0).
+		AddRow("sales_order", "MyISAM", "latin1_swedish_ci", "latin1", 5).
+		AddRow("store", "InnoDB", "utf8mb4_unicode_ci", "utf8mb4", 1)
+}
+
+func TestTableCharset_AlterSQL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		tc   csdb.TableCharset
+		o    csdb.ConvertOptions
+		want string
+	}{
+		{csdb.TableCharset{Name: "a", Engine: "InnoDB", Collation: "utf8mb4_unicode_ci", Charset: "utf8mb4"}, csdb.ConvertOptions{}, ""},
+		{csdb.TableCharset{Name: "a", Engine: "innodb", Collation: "UTF8MB4_UNICODE_CI", Charset: "utf8mb4"}, csdb.ConvertOptions{}, ""},
+		{csdb.TableCharset{Name: "a", Engine: "MyISAM", Collation: "utf8mb4_unicode_ci", Charset: "utf8mb4"}, csdb.ConvertOptions{}, "ALTER TABLE `a` ENGINE=InnoDB"},
+		{csdb.TableCharset{Name: "a", Engine: "InnoDB", Collation: "utf8_general_ci", Charset: "utf8"}, csdb.ConvertOptions{}, "ALTER TABLE `a` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci"},
+		{csdb.TableCharset{Name: "a", Engine: "InnoDB", Collation: "utf8mb4_unicode_ci", Charset: "utf8mb4", Columns: 1}, csdb.ConvertOptions{}, "ALTER TABLE `a` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci"},
+		{csdb.TableCharset{Name: "a", Engine: "MyISAM", Collation: "latin1_swedish_ci", Charset: "latin1"}, csdb.ConvertOptions{}, "ALTER TABLE `a` ENGINE=InnoDB, CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci"},
+		{csdb.TableCharset{Name: "a", Engine: "InnoDB", Collation: "utf8mb4_unicode_ci", Charset: "utf8mb4"}, csdb.ConvertOptions{Collation: "utf8mb4_general_ci"}, "ALTER TABLE `a` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci"},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, test.tc.AlterSQL(test.o), "Index %d", i)
+	}
+}
+
+func TestLoadUnconvertedTables(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	dbMock.ExpectQuery(regexp.QuoteMeta("WHERE t.TABLE_SCHEMA=DATABASE() AND t.TABLE_TYPE='BASE TABLE' ORDER BY t.TABLE_NAME")).
+		WithArgs("utf8mb4", "utf8mb4_unicode_ci").
+		WillReturnRows(tableCharsetRows())
+	dbMock.ExpectQuery(regexp.QuoteMeta("WHERE t.TABLE_SCHEMA=DATABASE() AND t.TABLE_TYPE='BASE TABLE' AND t.TABLE_NAME IN (?,?) ORDER BY t.TABLE_NAME")).
+		WithArgs("utf8mb4", "utf8mb4_unicode_ci", "store", "catalog_product_entity").
+		WillReturnRows(sqlmock.NewRows(tableCharsetCols).
+			AddRow("catalog_product_entity", "InnoDB", "utf8mb4_unicode_ci", "utf8mb4", 0).
+			AddRow("store", "InnoDB", "utf8mb4_unicode_ci", "utf8mb4", 1))
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES t")).
+		WillReturnError(errors.New("Ups"))
+
+	tcs, err := csdb.LoadUnconvertedTables(context.TODO(), dbc.DB, csdb.ConvertOptions{})
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, []csdb.TableCharset{
+		{Name: "core_config_data", Engine: "InnoDB", Collation: "utf8_general_ci", Charset: "utf8", Columns: 2},
+		{Name: "customer_visitor", Engine: "MyISAM", Collation: "utf8mb4_unicode_ci", Charset: "utf8mb4"},
+		{Name: "sales_order", Engine: "MyISAM", Collation: "latin1_swedish_ci", Charset: "latin1", Columns: 5},
+		{Name: "store", Engine: "InnoDB", Collation: "utf8mb4_unicode_ci", Charset: "utf8mb4", Columns: 1},
+	}, tcs)
+
+	tcs, err = csdb.LoadUnconvertedTables(context.TODO(), dbc.DB, csdb.ConvertOptions{}, "store", "catalog_product_entity")
+	require.NoError(t, err, "%+v", err)
+	require.Len(t, tcs, 1)
+	assert.Exactly(t, "store", tcs[0].Name)
+
+	tcs, err = csdb.LoadUnconvertedTables(context.TODO(), dbc.DB, csdb.ConvertOptions{})
+	assert.Nil(t, tcs)
+	assert.EqualError(t, errors.Cause(err), "Ups")
+}
+
+func TestConvertTables(t *testing.T) {
+	t.Parallel()
+
+	wantStmts := []string{
+		"ALTER TABLE `core_config_data` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci",
+		"ALTER TABLE `customer_visitor` ENGINE=InnoDB",
+		"ALTER TABLE `sales_order` ENGINE=InnoDB, CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci",
+		"ALTER TABLE `store` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci",
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES t")).
+			WillReturnRows(tableCharsetRows())
+
+		buf := new(bytes.Buffer)
+		stmts, err := csdb.ConvertTables(context.TODO(), dbc.DB, csdb.ConvertOptions{BatchSize: 3, DryRun: buf})
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, wantStmts, stmts)
+		assert.Exactly(t, "-- Batch 1\n"+wantStmts[0]+";\n"+wantStmts[1]+";\n"+wantStmts[2]+";\n-- Batch 2\n"+wantStmts[3]+";\n", buf.String())
+	})
+
+	t.Run("execute in batches", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES t")).
+			WillReturnRows(tableCharsetRows())
+		for _, s := range wantStmts {
+			dbMock.ExpectExec(regexp.QuoteMeta(s)).WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+
+		var throttled int
+		stmts, err := csdb.ConvertTables(context.TODO(), dbc.DB, csdb.ConvertOptions{
+			BatchSize: 2,
+			Throttle: func(context.Context) error {
+				throttled++
+				return nil
+			},
+		})
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, wantStmts, stmts)
+		assert.Exactly(t, 2, throttled)
+	})
+
+	t.Run("exec error returns converted tables", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES t")).
+			WillReturnRows(tableCharsetRows())
+		dbMock.ExpectExec(regexp.QuoteMeta(wantStmts[0])).WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(regexp.QuoteMeta(wantStmts[1])).WillReturnError(errors.NewAlreadyClosedf("Connection gone"))
+
+		stmts, err := csdb.ConvertTables(context.TODO(), dbc.DB, csdb.ConvertOptions{})
+		assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
+		assert.Exactly(t, wantStmts[:1], stmts)
+	})
+}
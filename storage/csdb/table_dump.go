@@ -0,0 +1,359 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/errors"
+)
+
+// DumpVersion defines the current version of the JSON format written by Dump.
+// Restore refuses files with a higher version.
+const DumpVersion = 1
+
+// restoreBatchSize number of rows per INSERT statement in Restore.
+const restoreBatchSize = 200
+
+// TableDump represents the serialized content of a table. All values get
+// stored as strings, NULL values as null. The table name does not contain the
+// prefix, so a dump can be restored into an installation with another prefix.
+// Designed for small lookup tables like countries, currencies or tax classes.
+// Binary data which is not valid UTF-8 cannot be dumped.
+type TableDump struct {
+	Version int         `json:"version"`
+	Table   string      `json:"table"`
+	Columns []string    `json:"columns"`
+	Rows    [][]*string `json:"rows"`
+}
+
+// unprefixedName returns the name of the table without the prefix.
+func (t *Table) unprefixedName() string {
+	return strings.TrimPrefix(t.Name, t.Prefix)
+}
+
+// Dump writes all rows of the table as indented JSON into w. The rows get
+// sorted by the primary key columns, if loaded, or by the first column so that
+// the output stays stable and can be committed into a VCS.
+func (t *Table) Dump(ctx context.Context, db dbr.Querier, w io.Writer) error {
+	qName, err := dbr.Quoter.ValidateAndQuote(t.Name)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] Dump table name")
+	}
+	orderBy := "1"
+	if len(t.fieldsPK) > 0 {
+		orderBy = strings.Join(quoteColumns(t.fieldsPK), ", ")
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT * FROM "+qName+" ORDER BY "+orderBy)
+	if err != nil {
+		return errors.Wrapf(err, "[csdb] Dump.QueryContext for table %q", t.Name)
+	}
+	defer rows.Close()
+
+	d := TableDump{
+		Version: DumpVersion,
+		Table:   t.unprefixedName(),
+		Rows:    [][]*string{},
+	}
+	if d.Columns, err = rows.Columns(); err != nil {
+		return errors.Wrapf(err, "[csdb] Dump.Columns for table %q", t.Name)
+	}
+	raw := make([]sql.RawBytes, len(d.Columns))
+	dest := make([]interface{}, len(d.Columns))
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return errors.Wrapf(err, "[csdb] Dump.Scan for table %q", t.Name)
+		}
+		row := make([]*string, len(raw))
+		for i, rb := range raw {
+			if rb != nil {
+				s := string(rb)
+				row[i] = &s
+			}
+		}
+		d.Rows = append(d.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrapf(err, "[csdb] Dump.Rows for table %q", t.Name)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.Wrapf(enc.Encode(d), "[csdb] Dump.Encode for table %q", t.Name)
+}
+
+// Restore deletes all rows of the table and inserts the rows of the dump read
+// from r. Run it within a transaction to restore the table atomically. Tables
+// with foreign keys should be restored with Tables.Restore. Returns the number
+// of inserted rows.
+func (t *Table) Restore(ctx context.Context, db dbr.Execer, r io.Reader) (int64, error) {
+	d, err := t.decodeDump(r)
+	if err != nil {
+		return 0, errors.Wrap(err, "[csdb] Restore.decodeDump")
+	}
+	if err := t.deleteAll(ctx, db); err != nil {
+		return 0, errors.Wrap(err, "[csdb] Restore.deleteAll")
+	}
+	return t.insertDump(ctx, db, d)
+}
+
+// decodeDump reads the JSON and checks version, table name and columns.
+func (t *Table) decodeDump(r io.Reader) (TableDump, error) {
+	var d TableDump
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return d, errors.NewNotValidf("[csdb] Table %q: Failed to decode dump: %s", t.Name, err)
+	}
+	switch {
+	case d.Version < 1 || d.Version > DumpVersion:
+		return d, errors.NewNotSupportedf("[csdb] Table %q: Dump version %d not supported", t.Name, d.Version)
+	case d.Table != t.unprefixedName():
+		return d, errors.NewNotValidf("[csdb] Table %q: Dump belongs to table %q", t.Name, d.Table)
+	case len(d.Columns) == 0:
+		return d, errors.NewEmptyf("[csdb] Table %q: Dump contains no columns", t.Name)
+	}
+	if err := IsValidIdentifier(d.Columns...); err != nil {
+		return d, errors.Wrapf(err, "[csdb] Table %q: Invalid column in dump", t.Name)
+	}
+	for i, row := range d.Rows {
+		if len(row) != len(d.Columns) {
+			return d, errors.NewNotValidf("[csdb] Table %q: Row %d has %d values but dump has %d columns", t.Name, i, len(row), len(d.Columns))
+		}
+	}
+	return d, nil
+}
+
+func (t *Table) deleteAll(ctx context.Context, db dbr.Execer) error {
+	qName, err := dbr.Quoter.ValidateAndQuote(t.Name)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] deleteAll table name")
+	}
+	_, err = db.ExecContext(ctx, "DELETE FROM "+qName)
+	return errors.Wrapf(err, "[csdb] failed to delete from table %q", t.Name)
+}
+
+// insertDump inserts the rows in batches of restoreBatchSize.
+func (t *Table) insertDump(ctx context.Context, db dbr.Execer, d TableDump) (int64, error) {
+	qName, err := dbr.Quoter.ValidateAndQuote(t.Name)
+	if err != nil {
+		return 0, errors.Wrap(err, "[csdb] insertDump table name")
+	}
+
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+
+	var inserted int64
+	for start := 0; start < len(d.Rows); start += restoreBatchSize {
+		end := start + restoreBatchSize
+		if end > len(d.Rows) {
+			end = len(d.Rows)
+		}
+		buf.Reset()
+		buf.WriteString("INSERT INTO ")
+		buf.WriteString(qName)
+		buf.WriteString(" (")
+		buf.WriteString(strings.Join(quoteColumns(d.Columns), ","))
+		buf.WriteString(") VALUES ")
+		args := make([]interface{}, 0, (end-start)*len(d.Columns))
+		for i, row := range d.Rows[start:end] {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writePlaceholders(buf, len(row))
+			for _, v := range row {
+				if v == nil {
+					args = append(args, nil)
+					continue
+				}
+				args = append(args, *v)
+			}
+		}
+		res, err := db.ExecContext(ctx, buf.String(), args...)
+		if err != nil {
+			return inserted, errors.Wrapf(err, "[csdb] Failed to insert rows %d-%d into table %q", start, end, t.Name)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return inserted, errors.Wrapf(err, "[csdb] RowsAffected for table %q", t.Name)
+		}
+		inserted += n
+	}
+	return inserted, nil
+}
+
+func quoteColumns(cols []string) []string {
+	qCols := make([]string, len(cols))
+	for i, col := range cols {
+		qCols[i] = dbr.Quoter.QuoteAs(col)
+	}
+	return qCols
+}
+
+func writePlaceholders(buf *bytes.Buffer, n int) {
+	buf.WriteByte('(')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('?')
+	}
+	buf.WriteByte(')')
+}
+
+// dumpFile returns the path of the JSON file of a table in directory dir.
+func dumpFile(dir string, t *Table) string {
+	return filepath.Join(dir, t.unprefixedName()+".json")
+}
+
+// Dump writes the rows of the tables identified by their index, or of all
+// tables if no index has been provided, into the directory dir. Each table
+// gets its own file named after the table without the prefix and the suffix
+// ".json". Views get skipped.
+func (tm *Tables) Dump(ctx context.Context, db dbr.Querier, dir string, idxs ...int) error {
+	ts, err := tm.dumpTables(idxs...)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] Tables.Dump.dumpTables")
+	}
+	for _, t := range ts {
+		if err := t.dumpFile(ctx, db, dumpFile(dir, t)); err != nil {
+			return errors.Wrap(err, "[csdb] Tables.Dump")
+		}
+	}
+	return nil
+}
+
+func (t *Table) dumpFile(ctx context.Context, db dbr.Querier, file string) (err error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return errors.Wrapf(err, "[csdb] Failed to create dump file %q", file)
+	}
+	defer func() {
+		if cErr := f.Close(); err == nil && cErr != nil {
+			err = errors.Wrapf(cErr, "[csdb] Failed to close dump file %q", file)
+		}
+	}()
+	return t.Dump(ctx, db, f)
+}
+
+// Restore loads the dump files of the tables identified by their index, or of
+// all tables if no index has been provided, from the directory dir. All files
+// get validated before the transaction starts. The foreign keys get loaded
+// within the transaction to delete the rows of the referencing tables first
+// and to insert the rows of the referenced tables first. Any error rolls back
+// the whole transaction. Tables with cyclic foreign keys return an error with
+// behaviour NotSupported.
+func (tm *Tables) Restore(ctx context.Context, db dbr.TxBeginner, dir string, idxs ...int) error {
+	ts, err := tm.dumpTables(idxs...)
+	if err != nil || len(ts) == 0 {
+		return errors.Wrap(err, "[csdb] Tables.Restore.dumpTables")
+	}
+
+	dumps := make(map[string]TableDump, len(ts))
+	byName := make(map[string]*Table, len(ts))
+	names := make([]string, 0, len(ts))
+	for _, t := range ts {
+		d, err := t.readDumpFile(dumpFile(dir, t))
+		if err != nil {
+			return errors.Wrap(err, "[csdb] Tables.Restore.readDumpFile")
+		}
+		dumps[t.Name] = d
+		byName[t.Name] = t
+		names = append(names, t.Name)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] Tables.Restore.BeginTx")
+	}
+	if err := restoreTables(ctx, tx, names, byName, dumps); err != nil {
+		if rErr := tx.Rollback(); rErr != nil {
+			return errors.Wrapf(rErr, "[csdb] Tables.Restore.Rollback after error: %s", err)
+		}
+		return errors.Wrap(err, "[csdb] Tables.Restore")
+	}
+	return errors.Wrap(tx.Commit(), "[csdb] Tables.Restore.Commit")
+}
+
+func restoreTables(ctx context.Context, tx *sql.Tx, names []string, byName map[string]*Table, dumps map[string]TableDump) error {
+	fks, err := LoadForeignKeys(ctx, tx, names...)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] restoreTables.LoadForeignKeys")
+	}
+	order, err := fks.DependencyOrder(names...)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] restoreTables.DependencyOrder")
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		if err := byName[order[i]].deleteAll(ctx, tx); err != nil {
+			return errors.Wrap(err, "[csdb] restoreTables.deleteAll")
+		}
+	}
+	for _, tn := range order {
+		if _, err := byName[tn].insertDump(ctx, tx, dumps[tn]); err != nil {
+			return errors.Wrap(err, "[csdb] restoreTables.insertDump")
+		}
+	}
+	return nil
+}
+
+func (t *Table) readDumpFile(file string) (TableDump, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return TableDump{}, errors.Wrapf(err, "[csdb] Failed to open dump file %q", file)
+	}
+	defer f.Close()
+	d, err := t.decodeDump(f)
+	return d, errors.Wrapf(err, "[csdb] Dump file %q", file)
+}
+
+// dumpTables returns the tables for Dump and Restore in the order of the
+// indexes or sorted by index when no index has been provided. Views get
+// skipped.
+func (tm *Tables) dumpTables(idxs ...int) ([]*Table, error) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	if len(idxs) == 0 {
+		idxs = make([]int, 0, len(tm.ts))
+		for idx := range tm.ts {
+			idxs = append(idxs, idx)
+		}
+		sort.Ints(idxs)
+	}
+	ts := make([]*Table, 0, len(idxs))
+	for _, idx := range idxs {
+		t, ok := tm.ts[idx]
+		if !ok {
+			return nil, errors.NewNotFoundf("[csdb] Table at index %d not found.", idx)
+		}
+		if !t.IsView {
+			ts = append(ts, t)
+		}
+	}
+	return ts, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const currencyDump = `{
+  "version": 1,
+  "table": "directory_currency",
+  "columns": [
+    "code",
+    "symbol"
+  ],
+  "rows": [
+    [
+      "CHF",
+      null
+    ],
+    [
+      "EUR",
+      "€"
+    ]
+  ]
+}
+`
+
+func TestTable_Dump(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `directory_currency` ORDER BY 1")).
+		WillReturnRows(sqlmock.NewRows([]string{"code", "symbol"}).AddRow("CHF", nil).AddRow("EUR", "€"))
+
+	var buf bytes.Buffer
+	require.NoError(t, csdb.NewTable("directory_currency").Dump(context.TODO(), dbc.DB, &buf))
+	assert.Exactly(t, currencyDump, buf.String())
+}
+
+func TestTable_Restore(t *testing.T) {
+	t.Run("delete and insert", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `directory_currency`")).WillReturnResult(sqlmock.NewResult(0, 5))
+		dbMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `directory_currency` (`code`,`symbol`) VALUES (?,?),(?,?)")).
+			WithArgs("CHF", nil, "EUR", "€").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		n, err := csdb.NewTable("directory_currency").Restore(context.TODO(), dbc.DB, strings.NewReader(currencyDump))
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, int64(2), n)
+	})
+
+	t.Run("wrong table", func(t *testing.T) {
+		_, err := csdb.NewTable("directory_country").Restore(context.TODO(), nil, strings.NewReader(currencyDump))
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := csdb.NewTable("directory_currency").Restore(context.TODO(), nil, strings.NewReader(`{"version":2,"table":"directory_currency","columns":["code"]}`))
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+
+	t.Run("row length mismatch", func(t *testing.T) {
+		_, err := csdb.NewTable("directory_currency").Restore(context.TODO(), nil, strings.NewReader(`{"version":1,"table":"directory_currency","columns":["code"],"rows":[["EUR","€"]]}`))
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+}
+
+func TestTables_Dump_Restore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "csdb_dump")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tm := csdb.MustNewTables(csdb.WithTableNames(
+		[]int{0, 1},
+		[]string{"store", "store_website"},
+	))
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `store` ORDER BY 1")).
+		WillReturnRows(sqlmock.NewRows([]string{"store_id", "website_id"}).AddRow("1", "1"))
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `store_website` ORDER BY 1")).
+		WillReturnRows(sqlmock.NewRows([]string{"website_id", "code"}).AddRow("1", "base"))
+	require.NoError(t, tm.Dump(context.TODO(), dbc.DB, dir))
+
+	_, err = os.Stat(filepath.Join(dir, "store_website.json"))
+	require.NoError(t, err)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectQuery("SELECT.+FROM information_schema.KEY_COLUMN_USAGE").
+		WillReturnRows(sqlmock.NewRows([]string{"CONSTRAINT_NAME", "TABLE_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"}).
+			AddRow("FK_STORE_WEBSITE_ID", "store", "website_id", "store_website", "website_id"))
+	dbMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `store`")).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(regexp.QuoteMeta("DELETE FROM `store_website`")).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `store_website` (`website_id`,`code`) VALUES (?,?)")).
+		WithArgs("1", "base").WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec(regexp.QuoteMeta("INSERT INTO `store` (`store_id`,`website_id`) VALUES (?,?)")).
+		WithArgs("1", "1").WillReturnError(errors.New("Duplicate entry"))
+	dbMock.ExpectRollback()
+
+	err = tm.Restore(context.TODO(), dbc.DB, dir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Duplicate entry")
+
+	t.Run("missing file", func(t *testing.T) {
+		err := tm.Restore(context.TODO(), nil, filepath.Join(dir, "missing"))
+		assert.True(t, os.IsNotExist(errors.Cause(err)), "%+v", err)
+	})
+}
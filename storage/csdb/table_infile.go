@@ -0,0 +1,281 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+)
+
+// Conner opens a dedicated session to the database. Session variables like
+// foreign_key_checks only apply to the connection they have been set on. The
+// type *sql.DB implements this interface.
+type Conner interface {
+	Conn(ctx context.Context) (*sql.Conn, error)
+}
+
+// InfileParallelOptions configures LoadDataInfileParallel.
+type InfileParallelOptions struct {
+	InfileOptions
+	// Parts defines the number of temporary files into which the source file
+	// gets split. Defaults to runtime.NumCPU().
+	Parts int
+	// Concurrency defines the number of parallel sessions. Defaults to Parts.
+	Concurrency int
+	// TempDir directory for the temporary files. Defaults to os.TempDir().
+	// When using the non-LOCAL mode the directory must be readable by the
+	// MySQL server.
+	TempDir string
+	// KeepFiles does not delete the temporary files after loading. Useful for
+	// debugging.
+	KeepFiles bool
+	// DisableChecks sets foreign_key_checks and unique_checks to 0 for each
+	// session before loading a part and back to 1 afterwards.
+	DisableChecks bool
+	// RegisterLocalFile gets called for each temporary file before it will be
+	// loaded, for example with mysql.RegisterLocalFile.
+	RegisterLocalFile func(filePath string)
+}
+
+// InfilePart contains the outcome of loading one temporary file.
+type InfilePart struct {
+	File         string
+	RowsAffected int64
+	Duration     time.Duration
+	Err          error
+}
+
+// InfileResult aggregates the outcome of all parts.
+type InfileResult struct {
+	Parts        []InfilePart
+	RowsAffected int64
+	// Failed number of parts which returned an error.
+	Failed int
+}
+
+// LoadDataInfileParallel splits a huge CSV file into Parts temporary files at
+// the line terminator and loads them concurrently via LOAD DATA INFILE, each
+// part over its own database session. Lines which should be ignored at the
+// start, e.g. a header, get only removed once from the source file. Records
+// must not contain the line terminator within enclosed fields.
+//
+// The returned result contains the statistics for each part, even in the
+// error case. The error contains the first failed part. Already loaded parts
+// won't be rolled back.
+func (t *Table) LoadDataInfileParallel(ctx context.Context, db Conner, filePath string, o InfileParallelOptions) (InfileResult, error) {
+	var res InfileResult
+	if t.IsView {
+		return res, nil
+	}
+	if o.Log == nil {
+		o.Log = log.BlackHole{}
+	}
+	if o.Parts < 1 {
+		o.Parts = runtime.NumCPU()
+	}
+	if o.Concurrency < 1 || o.Concurrency > o.Parts {
+		o.Concurrency = o.Parts
+	}
+	terminator := o.LinesTerminatedBy
+	if terminator == "" {
+		terminator = "\n"
+	}
+
+	files, err := splitInfile(filePath, o.TempDir, o.Parts, []byte(terminator), o.IgnoreLinesAtStart)
+	if !o.KeepFiles {
+		defer func() {
+			for _, f := range files {
+				_ = os.Remove(f)
+			}
+		}()
+	}
+	if err != nil {
+		return res, errors.Wrapf(err, "[csdb] LoadDataInfileParallel.splitInfile for table %q", t.Name)
+	}
+
+	partOpt := o.InfileOptions
+	partOpt.IgnoreLinesAtStart = 0
+
+	res.Parts = make([]InfilePart, len(files))
+	sem := make(chan struct{}, o.Concurrency)
+	var wg sync.WaitGroup
+	for i, f := range files {
+		res.Parts[i].File = f
+		if o.RegisterLocalFile != nil {
+			o.RegisterLocalFile(f)
+		}
+		wg.Add(1)
+		go func(p *InfilePart) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			now := time.Now()
+			p.RowsAffected, p.Err = t.loadInfilePart(ctx, db, p.File, partOpt, o.DisableChecks)
+			p.Duration = time.Since(now)
+		}(&res.Parts[i])
+	}
+	wg.Wait()
+
+	var firstErr error
+	for _, p := range res.Parts {
+		res.RowsAffected += p.RowsAffected
+		if p.Err != nil {
+			res.Failed++
+			if firstErr == nil {
+				firstErr = p.Err
+			}
+		}
+		if o.Log.IsDebug() {
+			o.Log.Debug("csdb.Table.LoadDataInfileParallel.Part", log.String("table", t.Name), log.String("file", p.File),
+				log.Int64("rows_affected", p.RowsAffected), log.Duration("duration", p.Duration), log.Err(p.Err))
+		}
+	}
+	if firstErr != nil {
+		return res, errors.Wrapf(firstErr, "[csdb] LoadDataInfileParallel %d of %d parts failed for table %q", res.Failed, len(res.Parts), t.Name)
+	}
+	return res, nil
+}
+
+// loadInfilePart loads one file over a dedicated connection.
+func (t *Table) loadInfilePart(ctx context.Context, db Conner, file string, o InfileOptions, disableChecks bool) (_ int64, err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "[csdb] loadInfilePart.Conn")
+	}
+	defer func() {
+		if cErr := conn.Close(); err == nil && cErr != nil {
+			err = errors.Wrap(cErr, "[csdb] loadInfilePart.Conn.Close")
+		}
+	}()
+
+	if disableChecks {
+		if _, err = conn.ExecContext(ctx, "SET foreign_key_checks = 0, unique_checks = 0"); err != nil {
+			return 0, errors.Wrap(err, "[csdb] loadInfilePart.DisableChecks")
+		}
+		defer func() {
+			// use a new context because the old one might be canceled and the
+			// connection goes back into the pool.
+			if _, rErr := conn.ExecContext(context.Background(), "SET foreign_key_checks = 1, unique_checks = 1"); err == nil && rErr != nil {
+				err = errors.Wrap(rErr, "[csdb] loadInfilePart.EnableChecks")
+			}
+		}()
+	}
+
+	query := t.infileSQL(file, o)
+	if o.Log.IsDebug() {
+		o.Log.Debug("csdb.Table.Infile.SQL", log.String("sql", query))
+	}
+	res, err := conn.ExecContext(ctx, query)
+	if err != nil {
+		return 0, errors.NewFatal(err, "[csdb] Infile for table %q failed with query: %q", t.Name, query)
+	}
+	rows, err := res.RowsAffected()
+	return rows, errors.Wrap(err, "[csdb] loadInfilePart.RowsAffected")
+}
+
+// splitInfile copies the records of filePath into at most `parts` temporary
+// files of roughly equal size. A record ends with the terminator. The first
+// skipLines records get dropped.
+func splitInfile(filePath, dir string, parts int, terminator []byte, skipLines int) (files []string, err error) {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "[csdb] splitInfile.Open")
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return nil, errors.Wrap(err, "[csdb] splitInfile.Stat")
+	}
+	partSize := fi.Size()/int64(parts) + 1
+
+	var (
+		dst     *os.File
+		dstBuf  *bufio.Writer
+		written int64
+	)
+	closeDst := func() error {
+		if dst == nil {
+			return nil
+		}
+		err := dstBuf.Flush()
+		if cErr := dst.Close(); err == nil {
+			err = cErr
+		}
+		dst = nil
+		return errors.Wrap(err, "[csdb] splitInfile.Close")
+	}
+	defer func() {
+		if cErr := closeDst(); err == nil {
+			err = cErr
+		}
+	}()
+
+	sc := bufio.NewScanner(src)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<30)
+	sc.Split(scanRecords(terminator))
+	for sc.Scan() {
+		if skipLines > 0 {
+			skipLines--
+			continue
+		}
+		if dst != nil && written >= partSize && len(files) < parts {
+			if err := closeDst(); err != nil {
+				return files, err
+			}
+		}
+		if dst == nil {
+			if dst, err = ioutil.TempFile(dir, "csdb_infile_"); err != nil {
+				return files, errors.Wrap(err, "[csdb] splitInfile.TempFile")
+			}
+			files = append(files, dst.Name())
+			dstBuf = bufio.NewWriter(dst)
+			written = 0
+		}
+		n, err := dstBuf.Write(sc.Bytes())
+		if err != nil {
+			return files, errors.Wrap(err, "[csdb] splitInfile.Write")
+		}
+		written += int64(n)
+	}
+	return files, errors.Wrap(sc.Err(), "[csdb] splitInfile.Scan")
+}
+
+// scanRecords returns a split function which returns each record including
+// its terminator.
+func scanRecords(terminator []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, terminator); i >= 0 {
+			return i + len(terminator), data[:i+len(terminator)], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeInfileFixture(t *testing.T, dir string, lines int) string {
+	var buf strings.Builder
+	buf.WriteString("user_id,email,username\n")
+	for i := 0; i < lines; i++ {
+		buf.WriteString("1,a@b.c,username\n")
+	}
+	fName := filepath.Join(dir, "admin_user.csv")
+	require.NoError(t, ioutil.WriteFile(fName, []byte(buf.String()), 0600))
+	return fName
+}
+
+func TestTable_LoadDataInfileParallel(t *testing.T) {
+
+	t.Run("split and load with disabled checks", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "csdb_infile_test")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		fName := writeInfileFixture(t, dir, 30)
+
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Error("there were unfulfilled expections", err)
+			}
+		}()
+		dbMock.MatchExpectationsInOrder(false)
+		for i := 0; i < 3; i++ {
+			dbMock.ExpectExec(regexp.QuoteMeta("SET foreign_key_checks = 0, unique_checks = 0")).WillReturnResult(sqlmock.NewResult(0, 0))
+			dbMock.ExpectExec("LOAD DATA LOCAL INFILE '.+csdb_infile_.+' INTO TABLE `admin_user` FIELDS TERMINATED BY ','").WillReturnResult(sqlmock.NewResult(0, 10))
+			dbMock.ExpectExec(regexp.QuoteMeta("SET foreign_key_checks = 1, unique_checks = 1")).WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+
+		var registered []string
+		res, err := tableMap.MustTable(table4).LoadDataInfileParallel(context.TODO(), dbc.DB, fName, csdb.InfileParallelOptions{
+			InfileOptions: csdb.InfileOptions{
+				FieldsTerminatedBy: ",",
+				IgnoreLinesAtStart: 1,
+			},
+			Parts: 3,
+			// sqlmock shares one connection, more sessions would trigger
+			// unexpected Close calls.
+			Concurrency:   1,
+			TempDir:       dir,
+			KeepFiles:     true,
+			DisableChecks: true,
+			RegisterLocalFile: func(f string) {
+				registered = append(registered, f)
+			},
+		})
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, int64(30), res.RowsAffected)
+		assert.Exactly(t, 0, res.Failed)
+		require.Len(t, res.Parts, 3)
+		assert.Len(t, registered, 3)
+
+		var lines int
+		for _, p := range res.Parts {
+			data, err := ioutil.ReadFile(p.File)
+			require.NoError(t, err)
+			assert.NotContains(t, string(data), "user_id", "Header must be removed")
+			assert.True(t, strings.HasSuffix(string(data), "\n"), "Split only at line terminator")
+			lines += strings.Count(string(data), "\n")
+		}
+		assert.Exactly(t, 30, lines)
+	})
+
+	t.Run("part fails", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "csdb_infile_test")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		fName := writeInfileFixture(t, dir, 4)
+
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+		}()
+		dbMock.MatchExpectationsInOrder(false)
+		dbMock.ExpectExec("LOAD DATA LOCAL INFILE").WillReturnResult(sqlmock.NewResult(0, 2))
+		dbMock.ExpectExec("LOAD DATA LOCAL INFILE").WillReturnError(errors.New("Duplicate entry"))
+
+		res, err := tableMap.MustTable(table4).LoadDataInfileParallel(context.TODO(), dbc.DB, fName, csdb.InfileParallelOptions{
+			Parts:       2,
+			Concurrency: 1,
+			TempDir:     dir,
+		})
+		assert.True(t, errors.IsFatal(err), "%+v", err)
+		assert.Exactly(t, 1, res.Failed)
+		assert.Exactly(t, int64(2), res.RowsAffected)
+		for _, p := range res.Parts {
+			_, err := os.Stat(p.File)
+			assert.True(t, os.IsNotExist(err), "Temporary file %q should be removed", p.File)
+		}
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		res, err := tableMap.MustTable(table4).LoadDataInfileParallel(context.TODO(), nil, "non-existent.csv", csdb.InfileParallelOptions{})
+		assert.Error(t, err)
+		assert.Empty(t, res.Parts)
+	})
+}
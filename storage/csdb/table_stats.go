@@ -0,0 +1,346 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+)
+
+// TableStat contains the statistics of a table retrieved from
+// information_schema.TABLES.
+type TableStat struct {
+	Name   string `db:"TABLE_NAME"` // `TABLE_NAME` varchar(64) NOT NULL DEFAULT '',
+	Engine string `db:"ENGINE"`     // `ENGINE` varchar(64) DEFAULT NULL,
+	// Rows the estimated number of rows. For InnoDB the estimation might
+	// differ by 40 to 50 percent from the real number of rows.
+	Rows         int64 `db:"TABLE_ROWS"`     // `TABLE_ROWS` bigint(21) unsigned DEFAULT NULL,
+	AvgRowLength int64 `db:"AVG_ROW_LENGTH"` // `AVG_ROW_LENGTH` bigint(21) unsigned DEFAULT NULL,
+	DataLength   int64 `db:"DATA_LENGTH"`    // `DATA_LENGTH` bigint(21) unsigned DEFAULT NULL,
+	IndexLength  int64 `db:"INDEX_LENGTH"`   // `INDEX_LENGTH` bigint(21) unsigned DEFAULT NULL,
+	DataFree     int64 `db:"DATA_FREE"`      // `DATA_FREE` bigint(21) unsigned DEFAULT NULL,
+	// AutoIncrement the next value of the auto increment column, zero if the
+	// table has none.
+	AutoIncrement int64 `db:"AUTO_INCREMENT"` // `AUTO_INCREMENT` bigint(21) unsigned DEFAULT NULL,
+	// UpdateTime might be zero, for example for InnoDB tables prior MySQL 5.7.
+	UpdateTime time.Time `db:"UPDATE_TIME"` // `UPDATE_TIME` datetime DEFAULT NULL,
+}
+
+const selTableStats = `SELECT
+	TABLE_NAME, ENGINE, TABLE_ROWS, AVG_ROW_LENGTH, DATA_LENGTH, INDEX_LENGTH, DATA_FREE,
+		AUTO_INCREMENT, UPDATE_TIME
+	 FROM information_schema.TABLES WHERE TABLE_SCHEMA=DATABASE() AND TABLE_TYPE='BASE TABLE' AND TABLE_NAME IN (?)
+	 ORDER BY TABLE_NAME`
+
+const selAllTableStats = `SELECT
+	TABLE_NAME, ENGINE, TABLE_ROWS, AVG_ROW_LENGTH, DATA_LENGTH, INDEX_LENGTH, DATA_FREE,
+		AUTO_INCREMENT, UPDATE_TIME
+	 FROM information_schema.TABLES WHERE TABLE_SCHEMA=DATABASE() AND TABLE_TYPE='BASE TABLE'
+	 ORDER BY TABLE_NAME`
+
+// LoadTableStats returns the statistics of the tables in the current database.
+// Map key contains the table name. All tables get loaded when you don't
+// provide the argument `tables`. Views are not part of the map.
+func LoadTableStats(ctx context.Context, db dbr.Querier, tables ...string) (map[string]TableStat, error) {
+	var rows *sql.Rows
+
+	if len(tables) == 0 {
+		var err error
+		rows, err = db.QueryContext(ctx, selAllTableStats)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadTableStats QueryContext for tables %v", tables)
+		}
+	} else {
+		sqlStr, args, err := dbr.Repeat(selTableStats, dbr.ArgString(tables...))
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadTableStats dbr.Repeat for tables %v", tables)
+		}
+		rows, err = db.QueryContext(ctx, sqlStr, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadTableStats QueryContext for tables %v", tables)
+		}
+	}
+	defer rows.Close()
+
+	sm := make(map[string]TableStat)
+	for rows.Next() {
+		var ts TableStat
+		var engine dbr.NullString
+		var tRows, avgLen, dataLen, idxLen, free, autoInc dbr.NullInt64
+		var updated dbr.NullTime
+		if err := rows.Scan(&ts.Name, &engine, &tRows, &avgLen, &dataLen, &idxLen, &free, &autoInc, &updated); err != nil {
+			return nil, errors.Wrap(err, "[csdb] LoadTableStats Scan Query")
+		}
+		ts.Engine = engine.String
+		ts.Rows, ts.AvgRowLength, ts.DataLength = tRows.Int64, avgLen.Int64, dataLen.Int64
+		ts.IndexLength, ts.DataFree, ts.AutoIncrement = idxLen.Int64, free.Int64, autoInc.Int64
+		ts.UpdateTime = updated.Time
+		sm[ts.Name] = ts
+	}
+	return sm, errors.Wrap(rows.Err(), "[csdb] LoadTableStats rows.Err Query")
+}
+
+// CountOptions configures Table.CountRows.
+type CountOptions struct {
+	// Conditions optional WHERE conditions to count only a subset of the rows.
+	Conditions []dbr.ConditionArg
+	// Limit if greater zero, stops counting after Limit rows. Useful to check
+	// cheaply whether a table contains more than Limit rows. The result is then
+	// marked as Capped.
+	Limit uint64
+	// EstimateAbove if greater zero, returns the estimation of
+	// information_schema.TABLES without counting, when the estimation is above
+	// this threshold. Ignored when Conditions have been set.
+	EstimateAbove int64
+}
+
+// RowCount result of Table.CountRows.
+type RowCount struct {
+	Table string
+	Rows  int64
+	// Estimated is true when Rows contains the estimation of
+	// information_schema.TABLES.
+	Estimated bool
+	// Capped is true when counting stopped at CountOptions.Limit.
+	Capped bool
+}
+
+// EstimateRows returns the estimated number of rows of this table from
+// information_schema.TABLES. Error behaviour: NotFound
+func (t *Table) EstimateRows(ctx context.Context, db dbr.Querier) (int64, error) {
+	sm, err := LoadTableStats(ctx, db, t.Name)
+	if err != nil {
+		return 0, errors.Wrapf(err, "[csdb] Table.EstimateRows. Table %q", t.Name)
+	}
+	ts, ok := sm[t.Name]
+	if !ok {
+		return 0, errors.NewNotFoundf("[csdb] Table.EstimateRows: Table %q not found", t.Name)
+	}
+	return ts.Rows, nil
+}
+
+// CountRows counts the rows of the table with SELECT COUNT(*). A full count of
+// a large InnoDB table scans the whole index, so use the options to count a
+// subset, to stop counting at a limit or to fall back to the estimation for
+// large tables.
+func (t *Table) CountRows(ctx context.Context, db interface {
+	dbr.Querier
+	dbr.QueryRower
+}, o CountOptions) (RowCount, error) {
+	rc := RowCount{Table: t.Name}
+
+	if o.EstimateAbove > 0 && len(o.Conditions) == 0 {
+		est, err := t.EstimateRows(ctx, db)
+		if err != nil {
+			return rc, errors.Wrap(err, "[csdb] Table.CountRows")
+		}
+		if est > o.EstimateAbove {
+			rc.Rows, rc.Estimated = est, true
+			return rc, nil
+		}
+	}
+
+	var sel *dbr.Select
+	if o.Limit > 0 {
+		sel = dbr.NewSelectFromSub(
+			dbr.NewSelect("1").From(t.Name).Where(o.Conditions...).Limit(o.Limit),
+			"rc",
+		).AddColumns("COUNT(*)")
+	} else {
+		sel = dbr.NewSelect("COUNT(*)").From(t.Name).Where(o.Conditions...)
+	}
+	sqlStr, args, err := sel.ToSQL()
+	if err != nil {
+		return rc, errors.Wrapf(err, "[csdb] Table.CountRows.ToSQL. Table %q", t.Name)
+	}
+	if err := db.QueryRowContext(ctx, sqlStr, args.Interfaces()...).Scan(&rc.Rows); err != nil {
+		return rc, errors.Wrapf(err, "[csdb] Table.CountRows.Scan. Table %q", t.Name)
+	}
+	rc.Capped = o.Limit > 0 && uint64(rc.Rows) >= o.Limit
+	return rc, nil
+}
+
+// StatsSnapshot contains the statistics of the observed tables at a point in
+// time.
+type StatsSnapshot struct {
+	Time   time.Time
+	Tables map[string]TableStat
+}
+
+// StatsTrend compares the statistics of a table between two snapshots.
+type StatsTrend struct {
+	Table    string
+	From, To time.Time
+	// RowsFrom and RowsTo are the estimated rows of the oldest and the latest
+	// snapshot.
+	RowsFrom, RowsTo int64
+	// Inserted number of generated auto increment values between both
+	// snapshots. Zero if the table has no auto increment column.
+	Inserted int64
+}
+
+// Delta returns the difference of the estimated rows.
+func (st StatsTrend) Delta() int64 {
+	return st.RowsTo - st.RowsFrom
+}
+
+// ChangeRatio returns the amount of changed rows relative to the rows of the
+// oldest snapshot. Either the absolute row delta or the inserted rows, which
+// ever is greater, counts as changed. An empty table counts as one row.
+func (st StatsTrend) ChangeRatio() float64 {
+	changed := math.Abs(float64(st.Delta()))
+	if ins := float64(st.Inserted); ins > changed {
+		changed = ins
+	}
+	from := float64(st.RowsFrom)
+	if from < 1 {
+		from = 1
+	}
+	return changed / from
+}
+
+// RowsPerHour returns the growth of the estimated rows per hour.
+func (st StatsTrend) RowsPerHour() float64 {
+	h := st.To.Sub(st.From).Hours()
+	if h <= 0 {
+		return 0
+	}
+	return float64(st.Delta()) / h
+}
+
+// StatsSnapshotter periodically loads the statistics of the tables and keeps
+// the latest snapshots in memory to calculate trends. Indexers use the trends
+// to decide whether to run a full or a partial reindex.
+type StatsSnapshotter struct {
+	DB dbr.Querier
+	// Tables to observe. Empty observes all tables of the current database.
+	Tables []string
+	// MaxSnapshots number of kept snapshots. The oldest snapshot gets dropped.
+	// Defaults to 24.
+	MaxSnapshots int
+	Log          log.Logger
+
+	mu    sync.RWMutex
+	snaps []StatsSnapshot
+}
+
+// NewStatsSnapshotter creates a new snapshotter for the provided tables.
+func NewStatsSnapshotter(db dbr.Querier, tables ...string) *StatsSnapshotter {
+	return &StatsSnapshotter{
+		DB:           db,
+		Tables:       tables,
+		MaxSnapshots: 24,
+		Log:          log.BlackHole{},
+	}
+}
+
+// Snapshot loads the current statistics and appends them to the list of
+// snapshots.
+func (s *StatsSnapshotter) Snapshot(ctx context.Context) (StatsSnapshot, error) {
+	sm, err := LoadTableStats(ctx, s.DB, s.Tables...)
+	if err != nil {
+		return StatsSnapshot{}, errors.Wrap(err, "[csdb] StatsSnapshotter.Snapshot")
+	}
+	snap := StatsSnapshot{
+		Time:   time.Now().UTC(),
+		Tables: sm,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	max := s.MaxSnapshots
+	if max < 2 {
+		max = 2
+	}
+	s.snaps = append(s.snaps, snap)
+	if len(s.snaps) > max {
+		s.snaps = append(s.snaps[:0], s.snaps[len(s.snaps)-max:]...)
+	}
+	return snap, nil
+}
+
+// Run takes a snapshot in every interval until the context gets canceled.
+// Failed snapshots get logged and do not stop the loop. Run blocks and returns
+// the error of the context.
+func (s *StatsSnapshotter) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Snapshot(ctx); err != nil && s.Log.IsInfo() {
+			s.Log.Info("csdb.StatsSnapshotter.Run.Snapshot.Error", log.Err(err))
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "[csdb] StatsSnapshotter.Run")
+		case <-ticker.C:
+		}
+	}
+}
+
+// Snapshots returns a copy of the kept snapshots, oldest first.
+func (s *StatsSnapshotter) Snapshots() []StatsSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]StatsSnapshot(nil), s.snaps...)
+}
+
+// Trend compares the oldest and the latest snapshot containing the table. The
+// second argument is false if less than two snapshots contain the table.
+func (s *StatsSnapshotter) Trend(table string) (StatsTrend, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var first, last *StatsSnapshot
+	for i := range s.snaps {
+		if _, ok := s.snaps[i].Tables[table]; !ok {
+			continue
+		}
+		if first == nil {
+			first = &s.snaps[i]
+		}
+		last = &s.snaps[i]
+	}
+	if first == nil || first == last {
+		return StatsTrend{Table: table}, false
+	}
+
+	from, to := first.Tables[table], last.Tables[table]
+	st := StatsTrend{
+		Table:    table,
+		From:     first.Time,
+		To:       last.Time,
+		RowsFrom: from.Rows,
+		RowsTo:   to.Rows,
+	}
+	if from.AutoIncrement > 0 && to.AutoIncrement > from.AutoIncrement {
+		st.Inserted = to.AutoIncrement - from.AutoIncrement
+	}
+	return st, true
+}
+
+// FullReindex reports whether the change ratio of the table exceeds the
+// threshold, for example 0.3 for 30 percent. Without a trend a full reindex
+// is required.
+func (s *StatsSnapshotter) FullReindex(table string, threshold float64) bool {
+	st, ok := s.Trend(table)
+	return !ok || st.ChangeRatio() > threshold
+}
@@ -0,0 +1,223 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tableStatCols = []string{"TABLE_NAME", "ENGINE", "TABLE_ROWS", "AVG_ROW_LENGTH", "DATA_LENGTH", "INDEX_LENGTH", "DATA_FREE", "AUTO_INCREMENT", "UPDATE_TIME"}
+
+func TestLoadTableStats(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	updated := time.Date(2017, 2, 3, 4, 5, 6, 0, time.UTC)
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES WHERE TABLE_SCHEMA=DATABASE() AND TABLE_TYPE='BASE TABLE'\n")).
+		WillReturnRows(sqlmock.NewRows(tableStatCols).
+			AddRow("sales_order", "InnoDB", 120000, 512, 61440000, 20480000, 4194304, 120345, updated).
+			AddRow("core_config_data", "InnoDB", 830, 128, 106240, 32768, 0, nil, nil))
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES WHERE TABLE_SCHEMA=DATABASE() AND TABLE_TYPE='BASE TABLE' AND TABLE_NAME IN (?)")).
+		WithArgs("sales_order").
+		WillReturnRows(sqlmock.NewRows(tableStatCols).
+			AddRow("sales_order", "InnoDB", 120000, 512, 61440000, 20480000, 4194304, 120345, updated))
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES WHERE TABLE_SCHEMA=DATABASE() AND TABLE_TYPE='BASE TABLE' AND TABLE_NAME IN (?)")).
+		WithArgs("v_store").
+		WillReturnRows(sqlmock.NewRows(tableStatCols))
+
+	sm, err := csdb.LoadTableStats(context.TODO(), dbc.DB)
+	require.NoError(t, err, "%+v", err)
+	require.Len(t, sm, 2)
+	assert.Exactly(t, csdb.TableStat{
+		Name: "sales_order", Engine: "InnoDB", Rows: 120000, AvgRowLength: 512, DataLength: 61440000,
+		IndexLength: 20480000, DataFree: 4194304, AutoIncrement: 120345, UpdateTime: updated,
+	}, sm["sales_order"])
+	assert.Exactly(t, int64(0), sm["core_config_data"].AutoIncrement)
+	assert.True(t, sm["core_config_data"].UpdateTime.IsZero())
+
+	rows, err := csdb.NewTable("sales_order").EstimateRows(context.TODO(), dbc.DB)
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, int64(120000), rows)
+
+	rows, err = csdb.NewTable("v_store").EstimateRows(context.TODO(), dbc.DB)
+	assert.Exactly(t, int64(0), rows)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+}
+
+func TestTable_CountRows(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+	tbl := csdb.NewTable("sales_order")
+
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `sales_order`")).
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1234))
+	rc, err := tbl.CountRows(context.TODO(), dbc.DB, csdb.CountOptions{})
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, csdb.RowCount{Table: "sales_order", Rows: 1234}, rc)
+
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM (SELECT 1 FROM `sales_order` WHERE (`state` = ?) LIMIT 1000) AS `rc`")).
+		WithArgs("complete").
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1000))
+	rc, err = tbl.CountRows(context.TODO(), dbc.DB, csdb.CountOptions{
+		Conditions: []dbr.ConditionArg{dbr.Condition("state", dbr.ArgString("complete"))},
+		Limit:      1000,
+		// ignored because of the conditions
+		EstimateAbove: 1,
+	})
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, csdb.RowCount{Table: "sales_order", Rows: 1000, Capped: true}, rc)
+
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES")).
+		WithArgs("sales_order").
+		WillReturnRows(sqlmock.NewRows(tableStatCols).
+			AddRow("sales_order", "InnoDB", 120000, 512, 61440000, 20480000, 4194304, 120345, nil))
+	rc, err = tbl.CountRows(context.TODO(), dbc.DB, csdb.CountOptions{EstimateAbove: 100000})
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, csdb.RowCount{Table: "sales_order", Rows: 120000, Estimated: true}, rc)
+
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES")).
+		WithArgs("sales_order").
+		WillReturnRows(sqlmock.NewRows(tableStatCols).
+			AddRow("sales_order", "InnoDB", 120000, 512, 61440000, 20480000, 4194304, 120345, nil))
+	dbMock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM `sales_order`")).
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(119876))
+	rc, err = tbl.CountRows(context.TODO(), dbc.DB, csdb.CountOptions{EstimateAbove: 500000})
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, csdb.RowCount{Table: "sales_order", Rows: 119876}, rc)
+}
+
+func TestStatsTrend(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	tests := []struct {
+		trend       csdb.StatsTrend
+		wantRatio   float64
+		wantPerHour float64
+	}{
+		{csdb.StatsTrend{From: now, To: now.Add(2 * time.Hour), RowsFrom: 1000, RowsTo: 1200}, 0.2, 100},
+		{csdb.StatsTrend{From: now, To: now.Add(2 * time.Hour), RowsFrom: 1000, RowsTo: 900}, 0.1, -50},
+		// rows replaced: estimation stays the same but 500 new IDs
+		{csdb.StatsTrend{From: now, To: now.Add(time.Hour), RowsFrom: 1000, RowsTo: 1000, Inserted: 500}, 0.5, 0},
+		{csdb.StatsTrend{From: now, To: now, RowsFrom: 0, RowsTo: 10}, 10, 0},
+	}
+	for i, test := range tests {
+		assert.InDelta(t, test.wantRatio, test.trend.ChangeRatio(), 0.0001, "Index %d", i)
+		assert.InDelta(t, test.wantPerHour, test.trend.RowsPerHour(), 0.0001, "Index %d", i)
+	}
+}
+
+func TestStatsSnapshotter(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	for _, r := range [][2]int64{{1000, 1001}, {1100, 1120}, {1300, 1400}, {1350, 1450}} {
+		dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES")).
+			WithArgs("sales_order", "store").
+			WillReturnRows(sqlmock.NewRows(tableStatCols).
+				AddRow("sales_order", "InnoDB", r[0], 512, 0, 0, 0, r[1], nil).
+				AddRow("store", "InnoDB", 3, 128, 0, 0, 0, 4, nil))
+	}
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES")).
+		WithArgs("sales_order", "store").
+		WillReturnError(errors.NewConnectionFailedf("Connection lost"))
+
+	sn := csdb.NewStatsSnapshotter(dbc.DB, "sales_order", "store")
+	sn.MaxSnapshots = 3
+
+	_, ok := sn.Trend("sales_order")
+	assert.False(t, ok)
+	assert.True(t, sn.FullReindex("sales_order", 0.5), "No trend requires a full reindex")
+
+	for i := 0; i < 4; i++ {
+		_, err := sn.Snapshot(context.TODO())
+		require.NoError(t, err, "%+v", err)
+	}
+	_, err := sn.Snapshot(context.TODO())
+	assert.True(t, errors.IsConnectionFailed(err), "%+v", err)
+
+	snaps := sn.Snapshots()
+	require.Len(t, snaps, 3)
+	assert.Exactly(t, int64(1100), snaps[0].Tables["sales_order"].Rows)
+
+	st, ok := sn.Trend("sales_order")
+	require.True(t, ok)
+	assert.Exactly(t, int64(250), st.Delta())
+	assert.Exactly(t, int64(330), st.Inserted)
+	assert.InDelta(t, 0.3, st.ChangeRatio(), 0.0001)
+	assert.True(t, sn.FullReindex("sales_order", 0.25))
+	assert.False(t, sn.FullReindex("sales_order", 0.5))
+	assert.False(t, sn.FullReindex("store", 0.1))
+
+	_, ok = sn.Trend("catalog_product_entity")
+	assert.False(t, ok)
+}
+
+func TestStatsSnapshotter_Run(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+	}()
+	dbMock.MatchExpectationsInOrder(false)
+	for i := 0; i < 50; i++ {
+		dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TABLES")).
+			WillReturnRows(sqlmock.NewRows(tableStatCols).AddRow("store", "InnoDB", 3, 128, 0, 0, 0, 4, nil))
+	}
+
+	sn := csdb.NewStatsSnapshotter(dbc.DB)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := sn.Run(ctx, 10*time.Millisecond)
+	assert.Exactly(t, context.DeadlineExceeded, errors.Cause(err))
+	assert.True(t, len(sn.Snapshots()) >= 2, "Snapshots: %d", len(sn.Snapshots()))
+}
@@ -31,6 +31,11 @@ const (
 	ScopeTable      = "scope_table"
 )
 
+// DefaultTablePrefix gets applied to all Tables created with NewTables, if
+// not overwritten with the option WithTablePrefix. Set it once during the
+// start of the application, before any package initializes its tables.
+var DefaultTablePrefix string
+
 // TableOption applies options and helper functions when creating a new table.
 // For example loading column definitions.
 type TableOption struct {
@@ -44,6 +49,9 @@ type TableOption struct {
 type Tables struct {
 	// Schema represents the name of the database. Might be empty.
 	Schema string
+	// Prefix gets prepended to all table names, like the table prefix of a
+	// Magento installation. See WithTablePrefix.
+	Prefix string
 	mu     sync.RWMutex
 	// ts uses int as the table index.
 	// What is the reason to use int as the table index and not a name? Because
@@ -72,6 +80,7 @@ func WithTableOrViewFromQuery(ctx context.Context, db interface {
 			if err := IsValidIdentifier(objectName); err != nil {
 				return errors.Wrapf(err, "[csdb] WithTableOrViewFromQuery.IsValidIdentifier")
 			}
+			objectName = tm.PrefixName(objectName)
 
 			var viewOrTable string
 			switch typ {
@@ -147,7 +156,8 @@ func WithTableLoadColumns(ctx context.Context, db dbr.Querier, idx int, tableNam
 				return errors.Wrap(err, "[csdb] WithTableLoadColumns.IsValidIdentifier")
 			}
 
-			t := NewTable(tableName)
+			t := NewTable(tm.PrefixName(tableName))
+			t.Prefix = tm.Prefix
 			t.Schema = tm.Schema
 			if err := t.LoadColumns(ctx, db); err != nil {
 				return errors.Wrap(err, "[csdb] WithTableLoadColumns.LoadColumns")
@@ -273,10 +283,34 @@ func WithTableDMLListeners(idx int, events ...*dbr.ListenerBucket) TableOption {
 	}
 }
 
+// WithTablePrefix sets the prefix for all table names. Table names which
+// already start with the prefix won't get modified, same as Magento does it.
+// Already added tables get renamed. The prefix must be set before any table
+// loads its columns from the database, so better pass it as first option.
+func WithTablePrefix(prefix string) TableOption {
+	return TableOption{
+		fn: func(tm *Tables) error {
+			if prefix != "" {
+				if err := IsValidIdentifier(prefix); err != nil {
+					return errors.Wrap(err, "[csdb] WithTablePrefix.IsValidIdentifier")
+				}
+			}
+			tm.mu.Lock()
+			defer tm.mu.Unlock()
+			tm.Prefix = prefix
+			for _, t := range tm.ts {
+				t.applyPrefix(prefix)
+			}
+			return nil
+		},
+	}
+}
+
 // NewTables creates a new TableService satisfying interface Manager.
 func NewTables(opts ...TableOption) (*Tables, error) {
 	tm := &Tables{
-		ts: make(map[int]*Table),
+		Prefix: DefaultTablePrefix,
+		ts:     make(map[int]*Table),
 	}
 	if err := tm.Options(opts...); err != nil {
 		return nil, errors.Wrap(err, "[csdb] NewTables applied option error")
@@ -348,10 +382,19 @@ func (tm *Tables) Table(i int) (*Table, error) {
 	return nil, errors.NewNotFoundf("[csdb] Table at index %d not found.", i)
 }
 
-// TableByName returns a table object via its table name. Case sensitive.
+// PrefixName returns the table name with the prefix of the Tables. Useful to
+// build hand written queries with the dbr package:
+//		dbc.Select("*").From(tm.PrefixName("core_config_data"))
+func (tm *Tables) PrefixName(name string) string {
+	return prefixTableName(tm.Prefix, name)
+}
+
+// TableByName returns a table object via its table name, with or without the
+// prefix. Case sensitive.
 func (tm *Tables) TableByName(name string) (*Table, error) {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
+	name = tm.PrefixName(name)
 	for _, t := range tm.ts {
 		if t.Name == name {
 			return t, nil
@@ -405,7 +448,7 @@ func (tm *Tables) Len() int {
 // already exists, then the new table gets applied. The ListenerBuckets gets
 // merged from the existing table to the new table, they will be appended to the
 // new table buckets. Empty fields in the new table gets updated from the
-// existing table.
+// existing table. The table prefix gets applied to the new table.
 func (tm *Tables) Upsert(i int, tNew *Table) error {
 	_ = tNew.Name // let it panic as early as possible if *Table is nil
 
@@ -414,7 +457,7 @@ func (tm *Tables) Upsert(i int, tNew *Table) error {
 
 	tOld, ok := tm.ts[i]
 	if tOld == nil || !ok {
-		tm.ts[i] = tNew
+		tm.ts[i] = tNew.applyPrefix(tm.Prefix)
 		return nil
 	}
 
@@ -430,6 +473,7 @@ func (tm *Tables) Upsert(i int, tNew *Table) error {
 	if len(tNew.Columns) == 0 {
 		tNew.Columns = tOld.Columns
 	}
+	tNew.applyPrefix(tm.Prefix)
 
 	tm.ts[i] = tNew.update()
 	return nil
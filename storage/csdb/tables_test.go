@@ -491,3 +491,67 @@ func TestWithObjectFromQuery(t *testing.T) {
 	})
 
 }
+
+func TestWithTablePrefix(t *testing.T) {
+	t.Parallel()
+
+	t.Run("applied to new and existing tables", func(t *testing.T) {
+		tm, err := csdb.NewTables(
+			csdb.WithTable(1, "store", &csdb.Column{Field: "store_id", Key: "PRI"}, &csdb.Column{Field: "code"}),
+			csdb.WithTablePrefix("mage_"),
+			csdb.WithTableNames([]int{2, 3}, []string{"store_group", "mage_store_website"}),
+		)
+		require.NoError(t, err)
+
+		assert.Exactly(t, "mage_store", tm.Name(1))
+		assert.Exactly(t, "mage_store_group", tm.Name(2))
+		assert.Exactly(t, "mage_store_website", tm.Name(3), "prefix must not be applied twice")
+		assert.Exactly(t, "mage_core_config_data", tm.PrefixName("core_config_data"))
+
+		tbl, err := tm.TableByName("store_group")
+		require.NoError(t, err)
+		assert.Exactly(t, "mage_", tbl.Prefix)
+		tbl, err = tm.TableByName("mage_store_group")
+		require.NoError(t, err)
+		assert.Exactly(t, "mage_store_group", tbl.Name)
+
+		sStr, _, err := tm.MustTable(1).Select().ToSQL()
+		require.NoError(t, err)
+		assert.Contains(t, sStr, "FROM `mage_store` AS `main_table`")
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+		tm := csdb.MustNewTables(csdb.WithTablePrefix("m2_"))
+		require.NoError(t, tm.Upsert(5, csdb.NewTable("sales_order")))
+		assert.Exactly(t, "m2_sales_order", tm.Name(5))
+	})
+
+	t.Run("invalid prefix", func(t *testing.T) {
+		_, err := csdb.NewTables(csdb.WithTablePrefix("mage-"))
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+
+	t.Run("Rename and Truncate", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Error("there were unfulfilled expections", err)
+			}
+		}()
+		tm := csdb.MustNewTables(csdb.WithTablePrefix("mage_"), csdb.WithTable(1, "catalog_product_entity"))
+
+		dbMock.ExpectExec("TRUNCATE TABLE `mage_catalog_product_entity`").WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec("RENAME TABLE `mage_catalog_product_entity` TO `mage_catalog_product_entity_old`").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, tm.MustTable(1).Truncate(context.TODO(), dbc.DB))
+		assert.NoError(t, tm.MustTable(1).Rename(context.TODO(), dbc.DB, "catalog_product_entity_old"))
+	})
+
+	t.Run("PrefixName", func(t *testing.T) {
+		tm := &csdb.Tables{Prefix: "x_"}
+		assert.Exactly(t, "x_a", tm.PrefixName("a"))
+		assert.Exactly(t, "", tm.PrefixName(""))
+	})
+}
@@ -0,0 +1,261 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/errors"
+)
+
+// Trigger timings and events. A trigger gets activated BEFORE or AFTER an
+// INSERT, UPDATE or DELETE statement.
+const (
+	TriggerBefore = "BEFORE"
+	TriggerAfter  = "AFTER"
+	TriggerInsert = "INSERT"
+	TriggerUpdate = "UPDATE"
+	TriggerDelete = "DELETE"
+)
+
+// Triggers contains a slice of triggers.
+type Triggers []*Trigger
+
+// Trigger contains information about one trigger retrieved from
+// information_schema.TRIGGERS or defines a new trigger which gets created via
+// CREATE TRIGGER. To define a new trigger use the function NewTrigger.
+type Trigger struct {
+	Name string `db:"TRIGGER_NAME"` // `TRIGGER_NAME` varchar(64) NOT NULL DEFAULT '',
+	// Event can be INSERT, UPDATE or DELETE.
+	Event string `db:"EVENT_MANIPULATION"` // `EVENT_MANIPULATION` varchar(6) NOT NULL DEFAULT '',
+	// Table to which the trigger is associated.
+	Table string `db:"EVENT_OBJECT_TABLE"` // `EVENT_OBJECT_TABLE` varchar(64) NOT NULL DEFAULT '',
+	// Order ordinal position of the trigger's action within the list of
+	// triggers on the same table with the same Event and Timing values.
+	Order int64 `db:"ACTION_ORDER"` // `ACTION_ORDER` bigint(4) NOT NULL DEFAULT '0',
+	// Statement the trigger body; that is, the statement executed when the
+	// trigger activates.
+	Statement string `db:"ACTION_STATEMENT"` // `ACTION_STATEMENT` longtext NOT NULL,
+	// Timing can be BEFORE or AFTER.
+	Timing  string `db:"ACTION_TIMING"` // `ACTION_TIMING` varchar(6) NOT NULL DEFAULT '',
+	Definer string `db:"DEFINER"`       // `DEFINER` varchar(93) NOT NULL DEFAULT '',
+	// Follows or Precedes contains the name of an existing trigger on the same
+	// table with the same Event and Timing. Only used when creating a trigger.
+	// Precedes has precedence over Follows.
+	Follows  string
+	Precedes string
+}
+
+const selTablesTriggers = `SELECT
+	TRIGGER_NAME, EVENT_MANIPULATION, EVENT_OBJECT_TABLE, ACTION_ORDER, ACTION_STATEMENT,
+		ACTION_TIMING, DEFINER
+	 FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA=DATABASE() AND EVENT_OBJECT_TABLE IN (?)
+	 ORDER BY EVENT_OBJECT_TABLE, ACTION_TIMING, EVENT_MANIPULATION, ACTION_ORDER`
+
+const selAllTablesTriggers = `SELECT
+	TRIGGER_NAME, EVENT_MANIPULATION, EVENT_OBJECT_TABLE, ACTION_ORDER, ACTION_STATEMENT,
+		ACTION_TIMING, DEFINER
+	 FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA=DATABASE()
+	 ORDER BY EVENT_OBJECT_TABLE, ACTION_TIMING, EVENT_MANIPULATION, ACTION_ORDER`
+
+// LoadTriggers returns all triggers from a list of table names in the current
+// database. Map key contains the table name. Tables without triggers are not
+// part of the map. All triggers from all tables gets selected when you don't
+// provide the argument `tables`.
+func LoadTriggers(ctx context.Context, db dbr.Querier, tables ...string) (map[string]Triggers, error) {
+	var rows *sql.Rows
+
+	if len(tables) == 0 {
+		var err error
+		rows, err = db.QueryContext(ctx, selAllTablesTriggers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadTriggers QueryContext for tables %v", tables)
+		}
+	} else {
+		sqlStr, args, err := dbr.Repeat(selTablesTriggers, dbr.ArgString(tables...))
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadTriggers dbr.Repeat for tables %v", tables)
+		}
+		rows, err = db.QueryContext(ctx, sqlStr, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadTriggers QueryContext for tables %v", tables)
+		}
+	}
+	defer rows.Close()
+
+	tt := make(map[string]Triggers)
+	for rows.Next() {
+		tr := new(Trigger)
+		if err := rows.Scan(&tr.Name, &tr.Event, &tr.Table, &tr.Order, &tr.Statement, &tr.Timing, &tr.Definer); err != nil {
+			return nil, errors.Wrap(err, "[csdb] LoadTriggers Scan Query")
+		}
+		tt[tr.Table] = append(tt[tr.Table], tr)
+	}
+	return tt, errors.Wrap(rows.Err(), "[csdb] LoadTriggers rows.Err Query")
+}
+
+// NewTrigger creates a new trigger definition. By default the trigger fires
+// AFTER an INSERT. Use the methods to build the trigger and ToSQL to generate
+// the CREATE TRIGGER statement. Example for a change log trigger:
+//		csdb.NewTrigger("trg_cpe_after_update").After().OnUpdate().
+//			On("catalog_product_entity").
+//			Do("INSERT IGNORE INTO `cpe_cl` (`entity_id`) VALUES (NEW.`entity_id`)")
+func NewTrigger(name string) *Trigger {
+	return &Trigger{
+		Name:   name,
+		Timing: TriggerAfter,
+		Event:  TriggerInsert,
+	}
+}
+
+// Before activates the trigger before each row gets modified.
+func (tr *Trigger) Before() *Trigger {
+	tr.Timing = TriggerBefore
+	return tr
+}
+
+// After activates the trigger after each row has been modified.
+func (tr *Trigger) After() *Trigger {
+	tr.Timing = TriggerAfter
+	return tr
+}
+
+// OnInsert activates the trigger when a new row gets inserted.
+func (tr *Trigger) OnInsert() *Trigger {
+	tr.Event = TriggerInsert
+	return tr
+}
+
+// OnUpdate activates the trigger when a row gets modified.
+func (tr *Trigger) OnUpdate() *Trigger {
+	tr.Event = TriggerUpdate
+	return tr
+}
+
+// OnDelete activates the trigger when a row gets deleted.
+func (tr *Trigger) OnDelete() *Trigger {
+	tr.Event = TriggerDelete
+	return tr
+}
+
+// On sets the table name to which the trigger gets associated.
+func (tr *Trigger) On(table string) *Trigger {
+	tr.Table = table
+	return tr
+}
+
+// Do sets the statements of the trigger body. More than one statement gets
+// wrapped in a BEGIN ... END block. Statements must not end with a semicolon.
+func (tr *Trigger) Do(statements ...string) *Trigger {
+	if len(statements) == 1 {
+		tr.Statement = statements[0]
+		return tr
+	}
+	tr.Statement = "BEGIN\n" + strings.Join(statements, ";\n") + ";\nEND"
+	return tr
+}
+
+// ToSQL generates the CREATE TRIGGER statement. Returns a NotValid error
+// behaviour if the name or table are invalid identifiers, the timing or the
+// event are unknown or the trigger body is empty.
+func (tr *Trigger) ToSQL() (string, error) {
+	qName, err := dbr.Quoter.ValidateAndQuote(tr.Name)
+	if err != nil {
+		return "", errors.Wrap(err, "[csdb] Trigger.ToSQL.Name")
+	}
+	qTable, err := dbr.Quoter.ValidateAndQuote(tr.Table)
+	if err != nil {
+		return "", errors.Wrap(err, "[csdb] Trigger.ToSQL.Table")
+	}
+	switch tr.Timing {
+	case TriggerBefore, TriggerAfter:
+	default:
+		return "", errors.NewNotValidf("[csdb] Trigger %q has an unknown timing %q", tr.Name, tr.Timing)
+	}
+	switch tr.Event {
+	case TriggerInsert, TriggerUpdate, TriggerDelete:
+	default:
+		return "", errors.NewNotValidf("[csdb] Trigger %q has an unknown event %q", tr.Name, tr.Event)
+	}
+	if strings.TrimSpace(tr.Statement) == "" {
+		return "", errors.NewNotValidf("[csdb] Trigger %q has an empty body", tr.Name)
+	}
+
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	buf.WriteString("CREATE TRIGGER ")
+	buf.WriteString(qName)
+	buf.WriteByte(' ')
+	buf.WriteString(tr.Timing)
+	buf.WriteByte(' ')
+	buf.WriteString(tr.Event)
+	buf.WriteString(" ON ")
+	buf.WriteString(qTable)
+	buf.WriteString(" FOR EACH ROW ")
+
+	var order, other string
+	switch {
+	case tr.Precedes != "":
+		order, other = "PRECEDES ", tr.Precedes
+	case tr.Follows != "":
+		order, other = "FOLLOWS ", tr.Follows
+	}
+	if order != "" {
+		qOther, err := dbr.Quoter.ValidateAndQuote(other)
+		if err != nil {
+			return "", errors.Wrap(err, "[csdb] Trigger.ToSQL.FollowsPrecedes")
+		}
+		buf.WriteString(order)
+		buf.WriteString(qOther)
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(tr.Statement)
+	return buf.String(), nil
+}
+
+// LoadTriggers reads all triggers associated with this table from the DB.
+func (t *Table) LoadTriggers(ctx context.Context, db dbr.Querier) (Triggers, error) {
+	tt, err := LoadTriggers(ctx, db, t.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[csdb] table.LoadTriggers. Table %q", t.Name)
+	}
+	return tt[t.Name], nil
+}
+
+// CreateTrigger creates the trigger for this table. The table name of the
+// trigger gets overwritten with the name of this table.
+func (t *Table) CreateTrigger(ctx context.Context, execer dbr.Execer, tr *Trigger) error {
+	tr.Table = t.Name
+	ddl, err := tr.ToSQL()
+	if err != nil {
+		return errors.Wrapf(err, "[csdb] CreateTrigger for table %q", t.Name)
+	}
+	_, err = execer.ExecContext(ctx, ddl)
+	return errors.Wrapf(err, "[csdb] failed to create trigger %q", ddl)
+}
+
+// DropTrigger, if exists, drops the trigger.
+func (t *Table) DropTrigger(ctx context.Context, execer dbr.Execer, name string) error {
+	qName, err := dbr.Quoter.ValidateAndQuote(name)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] DropTrigger name")
+	}
+	_, err = execer.ExecContext(ctx, "DROP TRIGGER IF EXISTS "+qName)
+	return errors.Wrapf(err, "[csdb] failed to drop trigger %q", name)
+}
@@ -0,0 +1,129 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrigger_ToSQL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tr      *csdb.Trigger
+		want    string
+		wantErr bool
+	}{
+		{
+			csdb.NewTrigger("trg_cpe_ai").On("catalog_product_entity").
+				Do("INSERT IGNORE INTO `cpe_cl` (`entity_id`) VALUES (NEW.`entity_id`)"),
+			"CREATE TRIGGER `trg_cpe_ai` AFTER INSERT ON `catalog_product_entity` FOR EACH ROW INSERT IGNORE INTO `cpe_cl` (`entity_id`) VALUES (NEW.`entity_id`)",
+			false,
+		},
+		{
+			csdb.NewTrigger("trg_cpe_bu").Before().OnUpdate().On("catalog_product_entity").
+				Do("SET NEW.updated_at = NOW()", "SET @x = 1"),
+			"CREATE TRIGGER `trg_cpe_bu` BEFORE UPDATE ON `catalog_product_entity` FOR EACH ROW BEGIN\nSET NEW.updated_at = NOW();\nSET @x = 1;\nEND",
+			false,
+		},
+		{
+			func() *csdb.Trigger {
+				tr := csdb.NewTrigger("trg_cpe_ad").OnDelete().On("catalog_product_entity").Do("DELETE FROM x")
+				tr.Follows = "trg_other"
+				return tr
+			}(),
+			"CREATE TRIGGER `trg_cpe_ad` AFTER DELETE ON `catalog_product_entity` FOR EACH ROW FOLLOWS `trg_other` DELETE FROM x",
+			false,
+		},
+		{csdb.NewTrigger("trg x").On("a").Do("SET @x = 1"), "", true},
+		{csdb.NewTrigger("trg").Do("SET @x = 1"), "", true},
+		{csdb.NewTrigger("trg").On("a"), "", true},
+		{func() *csdb.Trigger {
+			tr := csdb.NewTrigger("trg").On("a").Do("SET @x = 1")
+			tr.Timing = "INSTEAD OF"
+			return tr
+		}(), "", true},
+	}
+	for i, test := range tests {
+		have, err := test.tr.ToSQL()
+		if test.wantErr {
+			assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+			continue
+		}
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
+
+func TestLoadTriggers(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	rows := sqlmock.NewRows([]string{"TRIGGER_NAME", "EVENT_MANIPULATION", "EVENT_OBJECT_TABLE", "ACTION_ORDER", "ACTION_STATEMENT", "ACTION_TIMING", "DEFINER"}).
+		AddRow("trg_a", "INSERT", "catalog_product_entity", 1, "SET @x = 1", "AFTER", "root@localhost").
+		AddRow("trg_b", "UPDATE", "catalog_product_entity", 1, "SET @x = 2", "AFTER", "root@localhost")
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA=DATABASE() AND EVENT_OBJECT_TABLE IN (?)")).
+		WithArgs("catalog_product_entity").
+		WillReturnRows(rows)
+
+	trs, err := csdb.NewTable("catalog_product_entity").LoadTriggers(context.TODO(), dbc.DB)
+	require.NoError(t, err, "%+v", err)
+	require.Len(t, trs, 2)
+	assert.Exactly(t, "trg_b", trs[1].Name)
+	assert.Exactly(t, csdb.TriggerUpdate, trs[1].Event)
+	assert.Exactly(t, int64(1), trs[1].Order)
+}
+
+func TestTable_CreateDropTrigger(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	dbMock.ExpectExec(regexp.QuoteMeta("CREATE TRIGGER `trg_a` AFTER INSERT ON `catalog_product_entity` FOR EACH ROW SET @x = 1")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec(regexp.QuoteMeta("DROP TRIGGER IF EXISTS `trg_a`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	tbl := csdb.NewTable("catalog_product_entity")
+	require.NoError(t, tbl.CreateTrigger(context.TODO(), dbc.DB, csdb.NewTrigger("trg_a").Do("SET @x = 1")))
+	require.NoError(t, tbl.DropTrigger(context.TODO(), dbc.DB, "trg_a"))
+
+	err := tbl.DropTrigger(context.TODO(), dbc.DB, "trg;a")
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
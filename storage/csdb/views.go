@@ -0,0 +1,192 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+)
+
+// View contains the definition of an existing view retrieved from
+// information_schema.VIEWS.
+type View struct {
+	Name string `db:"TABLE_NAME"` // `TABLE_NAME` varchar(64) NOT NULL DEFAULT '',
+	// Definition the SELECT statement of the view as rewritten by the server.
+	// All identifiers are fully qualified with the database name.
+	Definition string `db:"VIEW_DEFINITION"` // `VIEW_DEFINITION` longtext NOT NULL,
+	// CheckOption can be NONE, CASCADED or LOCAL.
+	CheckOption string `db:"CHECK_OPTION"` // `CHECK_OPTION` varchar(8) NOT NULL DEFAULT '',
+	// IsUpdatable reports whether UPDATE and DELETE statements are legal for
+	// the view.
+	IsUpdatable bool   `db:"IS_UPDATABLE"` // `IS_UPDATABLE` varchar(3) NOT NULL DEFAULT '',
+	Definer     string `db:"DEFINER"`      // `DEFINER` varchar(93) NOT NULL DEFAULT '',
+	// SecurityType can be DEFINER or INVOKER.
+	SecurityType        string `db:"SECURITY_TYPE"`        // `SECURITY_TYPE` varchar(7) NOT NULL DEFAULT '',
+	CharacterSetClient  string `db:"CHARACTER_SET_CLIENT"` // `CHARACTER_SET_CLIENT` varchar(32) NOT NULL DEFAULT '',
+	CollationConnection string `db:"COLLATION_CONNECTION"` // `COLLATION_CONNECTION` varchar(32) NOT NULL DEFAULT '',
+}
+
+const selTablesViews = `SELECT
+	TABLE_NAME, VIEW_DEFINITION, CHECK_OPTION, IS_UPDATABLE, DEFINER, SECURITY_TYPE,
+		CHARACTER_SET_CLIENT, COLLATION_CONNECTION
+	 FROM information_schema.VIEWS WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME IN (?)
+	 ORDER BY TABLE_NAME`
+
+const selAllTablesViews = `SELECT
+	TABLE_NAME, VIEW_DEFINITION, CHECK_OPTION, IS_UPDATABLE, DEFINER, SECURITY_TYPE,
+		CHARACTER_SET_CLIENT, COLLATION_CONNECTION
+	 FROM information_schema.VIEWS WHERE TABLE_SCHEMA=DATABASE()
+	 ORDER BY TABLE_NAME`
+
+// LoadViews returns the definitions of the views in the current database. Map
+// key contains the view name. All views get loaded when you don't provide the
+// argument `views`. Names which are not a view are not part of the map.
+func LoadViews(ctx context.Context, db dbr.Querier, views ...string) (map[string]*View, error) {
+	var rows *sql.Rows
+
+	if len(views) == 0 {
+		var err error
+		rows, err = db.QueryContext(ctx, selAllTablesViews)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadViews QueryContext for views %v", views)
+		}
+	} else {
+		sqlStr, args, err := dbr.Repeat(selTablesViews, dbr.ArgString(views...))
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadViews dbr.Repeat for views %v", views)
+		}
+		rows, err = db.QueryContext(ctx, sqlStr, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[csdb] LoadViews QueryContext for views %v", views)
+		}
+	}
+	defer rows.Close()
+
+	vm := make(map[string]*View)
+	for rows.Next() {
+		v := new(View)
+		var updatable string
+		if err := rows.Scan(&v.Name, &v.Definition, &v.CheckOption, &updatable, &v.Definer, &v.SecurityType, &v.CharacterSetClient, &v.CollationConnection); err != nil {
+			return nil, errors.Wrap(err, "[csdb] LoadViews Scan Query")
+		}
+		v.IsUpdatable = updatable == "YES"
+		vm[v.Name] = v
+	}
+	return vm, errors.Wrap(rows.Err(), "[csdb] LoadViews rows.Err Query")
+}
+
+// ViewSQL generates the CREATE VIEW statement for the SELECT builder. A view
+// cannot contain place holders, hence the arguments of the SELECT get
+// interpolated into the query string. If orReplace is true an existing view
+// gets replaced.
+func ViewSQL(name string, sel *dbr.Select, orReplace bool) (string, error) {
+	qName, err := dbr.Quoter.ValidateAndQuote(name)
+	if err != nil {
+		return "", errors.Wrap(err, "[csdb] ViewSQL.Name")
+	}
+	if sel == nil {
+		return "", errors.NewEmptyf("[csdb] ViewSQL %q: Select cannot be nil", name)
+	}
+	sqlStr, args, err := sel.ToSQL()
+	if err != nil {
+		return "", errors.Wrapf(err, "[csdb] ViewSQL %q Select.ToSQL", name)
+	}
+	if len(args) > 0 {
+		if sqlStr, err = dbr.Preprocess(sqlStr, args...); err != nil {
+			return "", errors.Wrapf(err, "[csdb] ViewSQL %q dbr.Preprocess", name)
+		}
+	}
+	create := "CREATE VIEW "
+	if orReplace {
+		create = "CREATE OR REPLACE VIEW "
+	}
+	return create + qName + " AS " + sqlStr, nil
+}
+
+// CreateViewFromSelect creates the view `name` from the SELECT builder. If
+// orReplace is true an existing view gets replaced, otherwise MySQL returns an
+// error if the view already exists. See ViewSQL.
+func CreateViewFromSelect(ctx context.Context, execer dbr.Execer, name string, sel *dbr.Select, orReplace bool) error {
+	ddl, err := ViewSQL(name, sel, orReplace)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] CreateViewFromSelect")
+	}
+	_, err = execer.ExecContext(ctx, ddl)
+	return errors.Wrapf(err, "[csdb] failed to create view %q", ddl)
+}
+
+// DropView, if exists, drops the view.
+func DropView(ctx context.Context, execer dbr.Execer, name string) error {
+	qName, err := dbr.Quoter.ValidateAndQuote(name)
+	if err != nil {
+		return errors.Wrap(err, "[csdb] DropView name")
+	}
+	_, err = execer.ExecContext(ctx, "DROP VIEW IF EXISTS "+qName)
+	return errors.Wrapf(err, "[csdb] failed to drop view %q", name)
+}
+
+// WithViewFromSelect creates or replaces the view from the SELECT builder and
+// adds it to the internal table manager including all loaded column
+// definitions. It is the typed sibling of WithTableOrViewFromQuery. The table
+// prefix gets applied to the view name.
+func WithViewFromSelect(ctx context.Context, db interface {
+	dbr.Execer
+	dbr.Querier
+}, idx int, viewName string, sel *dbr.Select, orReplace bool) TableOption {
+	return TableOption{
+		priority: 10,
+		fn: func(tm *Tables) error {
+			if err := IsValidIdentifier(viewName); err != nil {
+				return errors.Wrap(err, "[csdb] WithViewFromSelect.IsValidIdentifier")
+			}
+			viewName = tm.PrefixName(viewName)
+
+			if err := CreateViewFromSelect(ctx, db, viewName, sel, orReplace); err != nil {
+				return errors.Wrapf(err, "[csdb] WithViewFromSelect %q", viewName)
+			}
+
+			tc, err := LoadColumns(ctx, db, viewName)
+			if err != nil {
+				return errors.Wrapf(err, "[csdb] Load columns failed for %q", viewName)
+			}
+
+			if err := WithTable(idx, viewName, tc[viewName]...).fn(tm); err != nil {
+				return errors.Wrapf(err, "[csdb] Failed to add new view %q", viewName)
+			}
+
+			tm.mu.Lock()
+			defer tm.mu.Unlock()
+			tm.ts[idx].IsView = true
+			return nil
+		},
+	}
+}
+
+// LoadView reads the definition of this view from the DB. Returns a NotFound
+// error behaviour if the table is not a view.
+func (t *Table) LoadView(ctx context.Context, db dbr.Querier) (*View, error) {
+	vm, err := LoadViews(ctx, db, t.Name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[csdb] table.LoadView. Table %q", t.Name)
+	}
+	v, ok := vm[t.Name]
+	if !ok {
+		return nil, errors.NewNotFoundf("[csdb] View %q not found", t.Name)
+	}
+	return v, nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdb_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/csdb"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewSQL(t *testing.T) {
+	t.Parallel()
+
+	sel := dbr.NewSelect("store_id").
+		AddColumnsExprAlias("SUM(grand_total)", "revenue").
+		From("sales_order").
+		Where(dbr.Condition("state", dbr.ArgString("complete"))).
+		GroupBy("store_id")
+
+	tests := []struct {
+		name      string
+		sel       *dbr.Select
+		orReplace bool
+		want      string
+		wantErrBh errors.BehaviourFunc
+	}{
+		{"v_revenue", sel, false, "CREATE VIEW `v_revenue` AS SELECT store_id, SUM(grand_total) AS `revenue` FROM `sales_order` WHERE (`state` = 'complete') GROUP BY store_id", nil},
+		{"v_revenue", sel, true, "CREATE OR REPLACE VIEW `v_revenue` AS SELECT store_id, SUM(grand_total) AS `revenue` FROM `sales_order` WHERE (`state` = 'complete') GROUP BY store_id", nil},
+		{"v_store", dbr.NewSelect("*").From("store"), false, "CREATE VIEW `v_store` AS SELECT * FROM `store`", nil},
+		{"v revenue", sel, false, "", errors.IsNotValid},
+		{"v_revenue", nil, false, "", errors.IsEmpty},
+		{"v_revenue", dbr.NewSelect("*"), false, "", errors.IsEmpty},
+	}
+	for i, test := range tests {
+		have, err := csdb.ViewSQL(test.name, test.sel, test.orReplace)
+		if test.wantErrBh != nil {
+			assert.True(t, test.wantErrBh(err), "Index %d => %+v", i, err)
+			continue
+		}
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
+
+func TestCreateViewFromSelect(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	dbMock.ExpectExec(regexp.QuoteMeta("CREATE OR REPLACE VIEW `v_store` AS SELECT * FROM `store` WHERE (`store_id` > 0)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec(regexp.QuoteMeta("DROP VIEW IF EXISTS `v_store`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	sel := dbr.NewSelect("*").From("store").Where(dbr.Condition("store_id", dbr.ArgInt(0).Operator(dbr.Greater)))
+	require.NoError(t, csdb.CreateViewFromSelect(context.TODO(), dbc.DB, "v_store", sel, true))
+	require.NoError(t, csdb.DropView(context.TODO(), dbc.DB, "v_store"))
+
+	err := csdb.DropView(context.TODO(), dbc.DB, "v;store")
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestLoadViews(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	cols := []string{"TABLE_NAME", "VIEW_DEFINITION", "CHECK_OPTION", "IS_UPDATABLE", "DEFINER", "SECURITY_TYPE", "CHARACTER_SET_CLIENT", "COLLATION_CONNECTION"}
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.VIEWS WHERE TABLE_SCHEMA=DATABASE()\n")).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow("v_store", "select `m`.`store`.`store_id` AS `store_id` from `m`.`store`", "NONE", "YES", "root@localhost", "DEFINER", "utf8", "utf8_general_ci").
+			AddRow("v_revenue", "select sum(`m`.`sales_order`.`grand_total`) AS `revenue` from `m`.`sales_order`", "NONE", "NO", "root@localhost", "INVOKER", "utf8", "utf8_general_ci"))
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.VIEWS WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME IN (?)")).
+		WithArgs("v_store").
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow("v_store", "select `m`.`store`.`store_id` AS `store_id` from `m`.`store`", "NONE", "YES", "root@localhost", "DEFINER", "utf8", "utf8_general_ci"))
+	dbMock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.VIEWS WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME IN (?)")).
+		WithArgs("store").
+		WillReturnRows(sqlmock.NewRows(cols))
+
+	vm, err := csdb.LoadViews(context.TODO(), dbc.DB)
+	require.NoError(t, err, "%+v", err)
+	require.Len(t, vm, 2)
+	assert.True(t, vm["v_store"].IsUpdatable)
+	assert.False(t, vm["v_revenue"].IsUpdatable)
+	assert.Exactly(t, "INVOKER", vm["v_revenue"].SecurityType)
+
+	v, err := csdb.NewTable("v_store").LoadView(context.TODO(), dbc.DB)
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, "select `m`.`store`.`store_id` AS `store_id` from `m`.`store`", v.Definition)
+
+	v, err = csdb.NewTable("store").LoadView(context.TODO(), dbc.DB)
+	assert.Nil(t, v)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+}
+
+func TestWithViewFromSelect(t *testing.T) {
+	t.Parallel()
+
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	dbMock.
+		ExpectExec(cstesting.SQLMockQuoteMeta("CREATE OR REPLACE VIEW `mage_v_config` AS SELECT * FROM `core_config_data` WHERE (`scope` = 'default')")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery("SELECT.+FROM information_schema.COLUMNS WHERE").
+		WithArgs("mage_v_config").
+		WillReturnRows(
+			cstesting.MustMockRows(cstesting.WithFile("testdata/core_config_data_columns.csv")))
+
+	sel := dbr.NewSelect("*").From("core_config_data").Where(dbr.Condition("scope", dbr.ArgString("default")))
+	tbls, err := csdb.NewTables(
+		csdb.WithTablePrefix("mage_"),
+		csdb.WithViewFromSelect(context.TODO(), dbc.DB, 3, "v_config", sel, true),
+	)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, "mage_v_config", tbls.MustTable(3).Name)
+	assert.True(t, tbls.MustTable(3).IsView, "Table should be a view")
+}
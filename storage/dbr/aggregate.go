@@ -0,0 +1,203 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"github.com/corestoreio/csfw/util/bufferpool"
+)
+
+// AggExpr represents an aggregate function like SUM, COUNT or GROUP_CONCAT.
+// Expressions which are valid identifiers, like `price` or `t.price`, get
+// quoted, all other expressions are written unchanged. Use the Agg*
+// constructor functions and add the expression via Select.AddColumnsAggregate.
+//		dbr.AggGroupConcat("sku").Distinct().OrderByDesc("sku").SeparatedBy("|").As("skus")
+//		// GROUP_CONCAT(DISTINCT `sku` ORDER BY `sku` DESC SEPARATOR '|') AS `skus`
+type AggExpr struct {
+	// Func name of the aggregate function, e.g. SUM.
+	Func string
+	// Expressions the arguments of the function.
+	Expressions []string
+	IsDistinct  bool // See Distinct()
+	// OrderBys only supported by GROUP_CONCAT. See OrderBy()
+	OrderBys []string
+	// Separator only supported by GROUP_CONCAT. See SeparatedBy()
+	Separator    string
+	HasSeparator bool
+	// Alias optional alias name. See As()
+	Alias string
+	// Arguments for the place holders in the expressions.
+	Arguments Arguments
+}
+
+// newAggExpr must be called directly from the exported Agg* functions to
+// report the expressions with the correct location to the audit hook.
+func newAggExpr(fn string, args Arguments, expressions ...string) *AggExpr {
+	reportSQLExpr(expressions, 4)
+	return &AggExpr{
+		Func:        fn,
+		Expressions: expressions,
+		Arguments:   args,
+	}
+}
+
+// AggCount creates a COUNT(expr) expression. Use "*" to count all rows.
+func AggCount(expression string) *AggExpr {
+	return newAggExpr("COUNT", nil, expression)
+}
+
+// AggCountDistinct creates a COUNT(DISTINCT col1,col2) expression.
+func AggCountDistinct(columns ...string) *AggExpr {
+	return newAggExpr("COUNT", nil, columns...).Distinct()
+}
+
+// AggCountIf counts the rows for which the condition is true. The condition
+// may contain place holders.
+//		COUNT(IF((condition), 1, NULL))
+func AggCountIf(condition string, args ...Argument) *AggExpr {
+	return newAggExpr("COUNT", args, string(SQLIf(condition, "1", "NULL")))
+}
+
+// AggSum creates a SUM(expr) expression.
+func AggSum(expression string) *AggExpr {
+	return newAggExpr("SUM", nil, expression)
+}
+
+// AggSumIf sums the expression only for the rows for which the condition is
+// true. The condition and the expression may contain place holders.
+//		SUM(IF((condition), expression, 0))
+func AggSumIf(condition, expression string, args ...Argument) *AggExpr {
+	if isValidIdentifier(expression) == 0 {
+		expression = Quoter.QuoteAs(expression)
+	}
+	return newAggExpr("SUM", args, string(SQLIf(condition, expression, "0")))
+}
+
+// AggAvg creates an AVG(expr) expression.
+func AggAvg(expression string) *AggExpr {
+	return newAggExpr("AVG", nil, expression)
+}
+
+// AggMin creates a MIN(expr) expression.
+func AggMin(expression string) *AggExpr {
+	return newAggExpr("MIN", nil, expression)
+}
+
+// AggMax creates a MAX(expr) expression.
+func AggMax(expression string) *AggExpr {
+	return newAggExpr("MAX", nil, expression)
+}
+
+// AggGroupConcat creates a GROUP_CONCAT(expr) expression. The default
+// separator of MySQL is a comma. Be aware of the session variable
+// group_concat_max_len which truncates the result, default 1024 bytes.
+func AggGroupConcat(expressions ...string) *AggExpr {
+	return newAggExpr("GROUP_CONCAT", nil, expressions...)
+}
+
+// Distinct only aggregates distinct values.
+func (a *AggExpr) Distinct() *AggExpr {
+	a.IsDistinct = true
+	return a
+}
+
+// OrderBy sorts the values within GROUP_CONCAT ascending.
+func (a *AggExpr) OrderBy(ord ...SQLUnsafe) *AggExpr {
+	a.OrderBys = append(a.OrderBys, auditSQLExpr(ord...)...)
+	return a
+}
+
+// OrderByDesc sorts the values within GROUP_CONCAT descending.
+func (a *AggExpr) OrderByDesc(ord ...SQLUnsafe) *AggExpr {
+	a.OrderBys = orderByDesc(a.OrderBys, auditSQLExpr(ord...))
+	return a
+}
+
+// SeparatedBy sets the separator of GROUP_CONCAT. The separator gets escaped
+// as a string literal because MySQL does not allow a place holder here.
+func (a *AggExpr) SeparatedBy(sep string) *AggExpr {
+	a.Separator = sep
+	a.HasSeparator = true
+	return a
+}
+
+// As sets the alias name.
+func (a *AggExpr) As(alias string) *AggExpr {
+	a.Alias = alias
+	return a
+}
+
+// String returns the SQL expression including the alias.
+func (a *AggExpr) String() string {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	a.write(buf)
+	return buf.String()
+}
+
+func (a *AggExpr) write(w queryWriter) {
+	w.WriteString(a.Func)
+	w.WriteRune('(')
+	if a.IsDistinct {
+		w.WriteString("DISTINCT ")
+	}
+	for i, e := range a.Expressions {
+		if i > 0 {
+			w.WriteRune(',')
+		}
+		writeAggIdentifier(w, e)
+	}
+	for i, o := range a.OrderBys {
+		if i == 0 {
+			w.WriteString(" ORDER BY ")
+		} else {
+			w.WriteString(", ")
+		}
+		dir := ""
+		if l := len(o); l > 5 && o[l-5:] == " DESC" {
+			o, dir = o[:l-5], " DESC"
+		}
+		writeAggIdentifier(w, o)
+		w.WriteString(dir)
+	}
+	if a.HasSeparator {
+		w.WriteString(" SEPARATOR ")
+		dialect.EscapeString(w, a.Separator)
+	}
+	w.WriteRune(')')
+	if a.Alias != "" {
+		w.WriteString(" AS ")
+		Quoter.quote(w, a.Alias)
+	}
+}
+
+func writeAggIdentifier(w queryWriter, e string) {
+	if isValidIdentifier(e) == 0 {
+		Quoter.FquoteAs(w, e)
+		return
+	}
+	w.WriteString(e)
+}
+
+// AddColumnsAggregate appends the aggregate expressions to the Columns slice
+// and their arguments to the Arguments slice.
+//		AddColumnsAggregate(dbr.AggSumIf("status = ?", "grand_total", dbr.ArgString("complete")).As("revenue"))
+//		// SUM(IF((status = ?), `grand_total`, 0)) AS `revenue`
+func (b *Select) AddColumnsAggregate(aggs ...*AggExpr) *Select {
+	for _, a := range aggs {
+		b.Columns = append(b.Columns, a.String())
+		b.Arguments = append(b.Arguments, a.Arguments...)
+	}
+	return b
+}
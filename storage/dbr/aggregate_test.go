@@ -0,0 +1,80 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggExpr_String(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		agg  *dbr.AggExpr
+		want string
+	}{
+		{dbr.AggCount("*"), "COUNT(*)"},
+		{dbr.AggCount("e.entity_id").As("cnt"), "COUNT(`e`.`entity_id`) AS `cnt`"},
+		{dbr.AggCountDistinct("customer_id", "store_id"), "COUNT(DISTINCT `customer_id`,`store_id`)"},
+		{dbr.AggSum("grand_total"), "SUM(`grand_total`)"},
+		{dbr.AggSum("qty*price").As("row_total"), "SUM(qty*price) AS `row_total`"},
+		{dbr.AggAvg("price"), "AVG(`price`)"},
+		{dbr.AggMin("price"), "MIN(`price`)"},
+		{dbr.AggMax("o.created_at"), "MAX(`o`.`created_at`)"},
+		{dbr.AggGroupConcat("sku"), "GROUP_CONCAT(`sku`)"},
+		{
+			dbr.AggGroupConcat("sku").Distinct().OrderByDesc("sku").OrderBy("e.entity_id").SeparatedBy("|").As("skus"),
+			"GROUP_CONCAT(DISTINCT `sku` ORDER BY `sku` DESC, `e`.`entity_id` SEPARATOR '|') AS `skus`",
+		},
+		{dbr.AggGroupConcat("name", "' '", "sku").SeparatedBy("'\n"), "GROUP_CONCAT(`name`,' ',`sku` SEPARATOR '\\'\\n')"},
+		{dbr.AggSumIf("status = 'complete'", "grand_total"), "SUM(IF((status = 'complete'), `grand_total`, 0))"},
+		{dbr.AggCountIf("qty > 10").As("big"), "COUNT(IF((qty > 10), 1, NULL)) AS `big`"},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, test.agg.String(), "Index %d", i)
+	}
+}
+
+func TestSelect_AddColumnsAggregate(t *testing.T) {
+	t.Parallel()
+
+	sqlStr, args, err := dbr.NewSelect("store_id").
+		AddColumnsAggregate(
+			dbr.AggSumIf("status = ?", "grand_total", dbr.ArgString("complete")).As("revenue"),
+			dbr.AggCountIf("grand_total > ?", dbr.ArgFloat64(100)).As("big_orders"),
+			dbr.AggCountDistinct("customer_id").As("customers"),
+		).
+		From("sales_order").
+		Where(dbr.Condition("created_at", dbr.ArgString("2017-01-01").Operator(dbr.GreaterOrEqual))).
+		GroupBy("store_id").
+		ToSQL()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t,
+		"SELECT store_id, SUM(IF((status = ?), `grand_total`, 0)) AS `revenue`, COUNT(IF((grand_total > ?), 1, NULL)) AS `big_orders`, COUNT(DISTINCT `customer_id`) AS `customers` FROM `sales_order` WHERE (`created_at` >= ?) GROUP BY store_id",
+		sqlStr)
+	assert.Exactly(t, []interface{}{"complete", 100.0, "2017-01-01"}, args.Interfaces())
+
+	sqlPre, err := dbr.Preprocess(sqlStr, args...)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t,
+		"SELECT store_id, SUM(IF((status = 'complete'), `grand_total`, 0)) AS `revenue`, COUNT(IF((grand_total > 100), 1, NULL)) AS `big_orders`, COUNT(DISTINCT `customer_id`) AS `customers` FROM `sales_order` WHERE (`created_at` >= '2017-01-01') GROUP BY store_id",
+		sqlPre)
+}
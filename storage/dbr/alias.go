@@ -23,6 +23,19 @@ func MakeAlias(expressionAlias ...string) alias {
 	return a
 }
 
+// MakeAliasFromSub creates a derived table from the sub-select with the
+// required alias name. Use it as table argument for the Join functions of
+// Select, Update and Delete. The arguments of the sub-select get merged into
+// the arguments of the statement.
+//		dbr.MakeAliasFromSub(dbr.NewSelect("entity_id").From("sales_order_item").GroupBy("entity_id"), "soi")
+//		// (SELECT entity_id FROM `sales_order_item` GROUP BY entity_id) AS `soi`
+func MakeAliasFromSub(subSelect *Select, aliasName string) alias {
+	return alias{
+		Select: subSelect,
+		Alias:  aliasName,
+	}
+}
+
 func (t alias) String() string {
 	if isValidIdentifier(t.Expression) > 0 {
 		return Quoter.ExprAlias(t.Expression, t.Alias)
@@ -34,6 +47,18 @@ func (t alias) QuoteAs() string {
 	return Quoter.QuoteAs(t.Expression, t.Alias)
 }
 
+// qualifier returns the quoted alias or, if empty, the quoted table name. A
+// derived table without an alias returns an empty string.
+func (t alias) qualifier() string {
+	switch {
+	case t.Alias != "":
+		return Quoter.Quote(t.Alias)
+	case t.Select != nil, t.Expression == "":
+		return ""
+	}
+	return Quoter.QuoteAs(t.Expression)
+}
+
 // FquoteAs writes the quoted table and its maybe alias into w.
 func (t alias) FquoteAs(w queryWriter) (Arguments, error) {
 	if t.Select != nil {
@@ -217,44 +217,6 @@ func isNotIn(o byte) bool {
 	return true
 }
 
-//func ArgValuer(args ...driver.Valuer) (Argument, error) {
-//	if len(args) == 1 {
-//		dv, err := args[0].Value()
-//		if err != nil {
-//			return nil, errors.Wrap(err, "[dbr] args[0].Value")
-//		}
-//		switch v := dv.(type) {
-//		case int64:
-//			return ArgInt64(v), nil
-//		case []int64:
-//			return ArgInt64(v...), nil
-//		case float64:
-//			return ArgFloat64(v), nil
-//		case []float64:
-//			return ArgFloat64(v...), nil
-//		case bool:
-//			return ArgBool(v), nil
-//		case []bool:
-//			return ArgBool(v...), nil
-//		case []byte:
-//			return ArgBytes(v), nil
-//		case string:
-//			return ArgString(v), nil
-//		case []string:
-//			return ArgString(v...), nil
-//		case time.Time:
-//			return ArgTime(v), nil
-//		case []time.Time:
-//			return ArgTime(v...), nil
-//		case nil:
-//			return ArgNull(), nil
-//		default:
-//			return nil, errors.NewNotSupportedf("[dbr] Argument %#v not supported", dv)
-//		}
-//	}
-//	return nil, nil
-//}
-
 type argTimes struct {
 	op   byte
 	data []time.Time
@@ -675,6 +637,83 @@ func ArgInt64(args ...int64) Argument {
 	return &argInt64s{data: args}
 }
 
+// argUint64 implements interface Argument but does not allocate.
+type argUint64 uint64
+
+func (a argUint64) toIFace(args *[]interface{}) {
+	*args = append(*args, uint64ToIFace(uint64(a)))
+}
+
+func (a argUint64) writeTo(w queryWriter, _ int) error {
+	_, err := w.WriteString(strconv.FormatUint(uint64(a), 10))
+	return err
+}
+func (a argUint64) len() int { return 1 }
+
+// Operator sets the SQL operator (IN, =, LIKE, BETWEEN, ...). Please refer to
+// the constants Operator*.
+func (a argUint64) Operator(op byte) Argument {
+	return &argUint64s{
+		op:   op,
+		data: []uint64{uint64(a)},
+	}
+}
+func (a argUint64) operator() byte { return 0 }
+
+type argUint64s struct {
+	op   byte
+	data []uint64
+}
+
+func (a *argUint64s) toIFace(args *[]interface{}) {
+	for _, v := range a.data {
+		*args = append(*args, uint64ToIFace(v))
+	}
+}
+
+func (a *argUint64s) writeTo(w queryWriter, pos int) error {
+	if isNotIn(a.operator()) {
+		_, err := w.WriteString(strconv.FormatUint(a.data[pos], 10))
+		return err
+	}
+	l := len(a.data) - 1
+	w.WriteRune('(')
+	for i, v := range a.data {
+		w.WriteString(strconv.FormatUint(v, 10))
+		if i < l {
+			w.WriteRune(',')
+		}
+	}
+	w.WriteRune(')')
+	return nil
+}
+
+func (a *argUint64s) len() int {
+	if isNotIn(a.operator()) {
+		return len(a.data)
+	}
+	return 1
+}
+
+// Operator sets the SQL operator (IN, =, LIKE, BETWEEN, ...). Please refer to
+// the constants Operator*.
+func (a *argUint64s) Operator(op byte) Argument {
+	a.op = op
+	return a
+}
+
+func (a *argUint64s) operator() byte { return a.op }
+
+// ArgUint64 adds an unsigned integer or a slice of unsigned integers to the
+// argument list. Values larger than math.MaxInt64 get passed as a string to
+// the database driver. Providing no arguments returns a NULL type.
+func ArgUint64(args ...uint64) Argument {
+	if len(args) == 1 {
+		return argUint64(args[0])
+	}
+	return &argUint64s{data: args}
+}
+
 type argFloat64 float64
 
 func (a argFloat64) toIFace(args *[]interface{}) {
@@ -757,9 +796,13 @@ type expr struct {
 }
 
 // ArgExpr at a SQL fragment with placeholders, and a slice of args to replace them
-// with. Mostly used in UPDATE statements.
-func ArgExpr(sql string, args ...Argument) Argument {
-	return &expr{SQL: sql, Arguments: args}
+// with. Mostly used in UPDATE statements. The fragment gets reported to the
+// SQLUnsafe audit hook.
+//
+// Deprecated: Use ArgUnsafe which makes the raw SQL visible in the code.
+func ArgExpr(sql SQLUnsafe, args ...Argument) Argument {
+	auditSQLUnsafe(sql)
+	return &expr{SQL: string(sql), Arguments: args}
 }
 
 func (e *expr) toIFace(args *[]interface{}) {
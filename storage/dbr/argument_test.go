@@ -3,20 +3,25 @@ package dbr
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/corestoreio/errors"
 	"github.com/stretchr/testify/assert"
 )
 
 //var _ Argument = argInt(0)
 var _ Argument = argInt64(0)
+var _ Argument = argUint64(0)
 var _ Argument = argFloat64(0)
 var _ Argument = argBool(true)
 var _ Argument = (*argBytes)(nil)
 var _ Argument = (*argInts)(nil)
 var _ Argument = (*argInt64s)(nil)
+var _ Argument = (*argUint64s)(nil)
 var _ Argument = (*argFloat64s)(nil)
 var _ Argument = (*argTimes)(nil)
 var _ Argument = (*argBools)(nil)
@@ -30,6 +35,9 @@ var _ Argument = (*NullTime)(nil)
 var _ Argument = (*argNullTimes)(nil)
 var _ Argument = (*NullInt64)(nil)
 var _ Argument = (*argNullInt64s)(nil)
+var _ Argument = (*NullUint64)(nil)
+var _ Argument = (*argNullUint64s)(nil)
+var _ Argument = (*NullInt8)(nil)
 var _ Argument = (*NullBool)(nil)
 
 func TestNullStringFrom(t *testing.T) {
@@ -173,3 +181,96 @@ func newNullTypedRecordWithData() *nullTypedRecord {
 		BoolVal:    NullBool{NullBool: sql.NullBool{Bool: true, Valid: true}},
 	}
 }
+
+type testMoney struct {
+	cents int64
+	calls *int
+}
+
+func (m testMoney) Value() (driver.Value, error) {
+	if m.calls != nil {
+		*m.calls++
+	}
+	if m.cents < 0 {
+		return nil, errors.NewNotValidf("negative money")
+	}
+	return float64(m.cents) / 100, nil
+}
+
+type testSKU string
+
+func (s testSKU) MarshalText() ([]byte, error) { return []byte("SKU-" + s), nil }
+
+func TestArgValuer(t *testing.T) {
+
+	t.Run("Valuer in condition", func(t *testing.T) {
+		var calls int
+		sel := NewSelect("a").From("tableA").Where(Condition("price", ArgValuer(testMoney{cents: 1999, calls: &calls}).Operator(GreaterOrEqual)))
+		for i := 0; i < 2; i++ {
+			sqlStr, args, err := sel.ToSQL()
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			assert.Exactly(t, "SELECT a FROM `tableA` WHERE (`price` >= ?)", sqlStr)
+			assert.Exactly(t, []interface{}{19.99}, args.Interfaces())
+		}
+		assert.Exactly(t, "SELECT a FROM `tableA` WHERE (`price` >= 19.99)", sel.String())
+		assert.True(t, calls > 1, "Value must not be cached")
+	})
+
+	t.Run("concurrent usage", func(t *testing.T) {
+		sel := NewSelect("a").From("tableA").Where(Condition("sku", ArgValuer(testSKU("a"), testSKU("b")).Operator(In)))
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, args, err := sel.ToSQL()
+				assert.NoError(t, err)
+				assert.Exactly(t, []interface{}{"SKU-a", "SKU-b"}, args.Interfaces())
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("TextMarshaler IN clause", func(t *testing.T) {
+		sel := NewSelect("a").From("tableA").Where(Condition("sku", ArgValuer(testSKU("a"), testSKU("b")).Operator(In)))
+		sqlStr, args, err := sel.ToSQL()
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, "SELECT a FROM `tableA` WHERE (`sku` IN ?)", sqlStr)
+		assert.Exactly(t, []interface{}{"SKU-a", "SKU-b"}, args.Interfaces())
+		assert.Exactly(t, "SELECT a FROM `tableA` WHERE (`sku` IN ('SKU-a','SKU-b'))", sel.String())
+	})
+
+	t.Run("nil Valuer result", func(t *testing.T) {
+		arg := ArgValuer(sql.NullString{})
+		assert.Exactly(t, []interface{}{nil}, Arguments{arg}.Interfaces())
+	})
+
+	errTests := []struct {
+		arg    Argument
+		errBhf errors.BehaviourFunc
+	}{
+		{ArgValuer(4711), errors.IsNotSupported},
+		{ArgValuer(testMoney{cents: 1}, testSKU("a")), errors.IsNotValid},
+		{ArgValuer(testMoney{cents: -1}), errors.IsNotValid},
+		{ArgValuer(sql.NullString{}, sql.NullString{}), errors.IsNotSupported},
+	}
+	for i, test := range errTests {
+		_, _, err := NewSelect("a").From("tableA").Where(Condition("b", test.arg)).ToSQL()
+		assert.True(t, test.errBhf(err), "Index %d => %+v", i, err)
+		_, _, err = NewUpdate("tableA").Set("b", test.arg).ToSQL()
+		assert.True(t, test.errBhf(err), "Update Index %d => %+v", i, err)
+		_, _, err = NewInsert("tableA").AddColumns("b").AddValues(test.arg).ToSQL()
+		assert.True(t, test.errBhf(err), "Insert Index %d => %+v", i, err)
+		_, err = Preprocess("SELECT ?", test.arg)
+		assert.True(t, test.errBhf(err), "Preprocess Index %d => %+v", i, err)
+		_, _, err = Repeat("SELECT ?", test.arg)
+		assert.True(t, test.errBhf(err), "Repeat Index %d => %+v", i, err)
+		_, err = NewBoundStmt(nil).Bind(test.arg).Exec(context.TODO())
+		assert.True(t, test.errBhf(err), "BoundStmt Index %d => %+v", i, err)
+		assert.Empty(t, Arguments{test.arg}.Interfaces(), "Index %d", i)
+	}
+}
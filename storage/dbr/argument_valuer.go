@@ -0,0 +1,202 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"time"
+
+	"github.com/corestoreio/errors"
+)
+
+// argValuer converts custom types into one of the native Argument types. The
+// conversion happens each time the argument gets used. A cached result would
+// require a lock because a builder can be used concurrently.
+type argValuer struct {
+	data []interface{}
+	op   byte
+}
+
+// ArgValuer adds custom types to the argument list, for example domain types
+// like Money or SKU. Each value must implement driver.Valuer or
+// encoding.TextMarshaler, where driver.Valuer takes precedence. The values get
+// converted into the matching Argument type when the SQL gets generated, at
+// the latest during interpolation. All values must resolve to the same type,
+// this allows the usage in an IN clause. Conversion errors get returned by
+// ToSQL, Preprocess, Repeat or the BoundStmt with behaviour NotSupported or
+// NotValid.
+func ArgValuer(args ...interface{}) Argument {
+	return &argValuer{data: args}
+}
+
+func (a *argValuer) resolve() (Argument, error) {
+	arg, err := valuesToArgument(a.data)
+	if err == nil && a.op > 0 {
+		arg = arg.Operator(a.op)
+	}
+	return arg, err
+}
+
+// toIFace skips the values on a conversion error. The callers must check the
+// arguments with resolveValuers beforehand because toIFace cannot return the
+// error.
+func (a *argValuer) toIFace(args *[]interface{}) {
+	if arg, err := a.resolve(); err == nil {
+		arg.toIFace(args)
+	}
+}
+
+func (a *argValuer) writeTo(w queryWriter, pos int) error {
+	arg, err := a.resolve()
+	if err != nil {
+		return errors.Wrap(err, "[dbr] ArgValuer.writeTo")
+	}
+	return arg.writeTo(w, pos)
+}
+
+func (a *argValuer) len() int {
+	arg, err := a.resolve()
+	if err != nil {
+		if isNotIn(a.op) {
+			return len(a.data)
+		}
+		return 1
+	}
+	return arg.len()
+}
+
+// Operator sets the SQL operator (IN, =, LIKE, BETWEEN, ...). Please refer to
+// the constants Operator*.
+func (a *argValuer) Operator(op byte) Argument {
+	a.op = op
+	return a
+}
+
+func (a *argValuer) operator() byte { return a.op }
+
+// resolveValuers converts all ArgValuer types in args to detect conversion
+// errors before the arguments get passed to the driver.
+func resolveValuers(args []Argument) error {
+	for _, arg := range args {
+		if av, ok := arg.(*argValuer); ok {
+			if _, err := av.resolve(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// valuesToArgument converts the values via driver.Valuer or
+// encoding.TextMarshaler into a native Argument type.
+func valuesToArgument(data []interface{}) (Argument, error) {
+	if len(data) == 0 {
+		return ArgNull(), nil
+	}
+
+	vals := make([]driver.Value, len(data))
+	for i, d := range data {
+		switch v := d.(type) {
+		case driver.Valuer:
+			dv, err := v.Value()
+			if err != nil {
+				return nil, errors.Wrapf(err, "[dbr] ArgValuer: Value of type %T at index %d", d, i)
+			}
+			vals[i] = dv
+		case encoding.TextMarshaler:
+			t, err := v.MarshalText()
+			if err != nil {
+				return nil, errors.Wrapf(err, "[dbr] ArgValuer: MarshalText of type %T at index %d", d, i)
+			}
+			vals[i] = string(t)
+		default:
+			return nil, errors.NewNotSupportedf("[dbr] ArgValuer: Type %T at index %d implements neither driver.Valuer nor encoding.TextMarshaler", d, i)
+		}
+	}
+
+	if len(vals) == 1 {
+		switch v := vals[0].(type) {
+		case int64:
+			return ArgInt64(v), nil
+		case float64:
+			return ArgFloat64(v), nil
+		case bool:
+			return ArgBool(v), nil
+		case []byte:
+			return ArgBytes(v), nil
+		case string:
+			return ArgString(v), nil
+		case time.Time:
+			return ArgTime(v), nil
+		case nil:
+			return ArgNull(), nil
+		}
+		return nil, errors.NewNotSupportedf("[dbr] ArgValuer: Value type %T not supported", vals[0])
+	}
+
+	switch vals[0].(type) {
+	case int64:
+		s := make([]int64, len(vals))
+		for i, v := range vals {
+			var ok bool
+			if s[i], ok = v.(int64); !ok {
+				return nil, errMixedValues(vals[0], v, i)
+			}
+		}
+		return ArgInt64(s...), nil
+	case float64:
+		s := make([]float64, len(vals))
+		for i, v := range vals {
+			var ok bool
+			if s[i], ok = v.(float64); !ok {
+				return nil, errMixedValues(vals[0], v, i)
+			}
+		}
+		return ArgFloat64(s...), nil
+	case bool:
+		s := make([]bool, len(vals))
+		for i, v := range vals {
+			var ok bool
+			if s[i], ok = v.(bool); !ok {
+				return nil, errMixedValues(vals[0], v, i)
+			}
+		}
+		return ArgBool(s...), nil
+	case string:
+		s := make([]string, len(vals))
+		for i, v := range vals {
+			var ok bool
+			if s[i], ok = v.(string); !ok {
+				return nil, errMixedValues(vals[0], v, i)
+			}
+		}
+		return ArgString(s...), nil
+	case time.Time:
+		s := make([]time.Time, len(vals))
+		for i, v := range vals {
+			var ok bool
+			if s[i], ok = v.(time.Time); !ok {
+				return nil, errMixedValues(vals[0], v, i)
+			}
+		}
+		return ArgTime(s...), nil
+	}
+	return nil, errors.NewNotSupportedf("[dbr] ArgValuer: Value type %T not supported for multiple values", vals[0])
+}
+
+func errMixedValues(first, v driver.Value, idx int) error {
+	return errors.NewNotValidf("[dbr] ArgValuer: Value %T at index %d does not match the type %T of the first value", v, idx, first)
+}
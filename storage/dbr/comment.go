@@ -0,0 +1,77 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// sqlWriteComments writes each comment as a C style block followed by a
+// space. The space after the opening marker prevents that MySQL treats the
+// comment as an executable comment (/*!) or as an optimizer hint (/*+).
+// Comments often contain values from client headers, e.g. request IDs, so
+// all bytes which are not printable ASCII and the characters *, /, ?, quotes
+// and square brackets get replaced with an underscore. Without * and / no
+// comment marker can be created. Preprocess does not know about comments and
+// would count ? as placeholder and treat quotes and brackets as the start of a
+// string or an identifier.
+func sqlWriteComments(w queryWriter, comments []string) {
+	for _, c := range comments {
+		w.WriteString("/* ")
+		for i := 0; i < len(c); i++ {
+			if b := c[i]; isCommentByte(b) {
+				w.WriteRune(rune(b))
+			} else {
+				w.WriteRune('_')
+			}
+		}
+		w.WriteString(" */ ")
+	}
+}
+
+// isCommentByte reports whether sqlWriteComments writes the byte unchanged.
+func isCommentByte(b byte) bool {
+	switch {
+	case b < ' ' || b > '~':
+		return false
+	case b == '*', b == '/', b == '?', b == '\'', b == '"', b == '`', b == '[', b == ']':
+		return false
+	}
+	return true
+}
+
+// CommentCaller returns the function name, file and line of the caller. Use
+// it as argument to the Comment functions to find the origin of a query in
+// the MySQL slow query log:
+//		dbc.Select("*").From("catalog_product_entity").Comment(dbr.CommentCaller())
+// renders to
+//		/* catalog.LoadProducts product.go:42 */ SELECT * FROM `catalog_product_entity`
+func CommentCaller() string {
+	pc, file, line, ok := runtime.Caller(1)
+	if !ok {
+		return ""
+	}
+	var name string
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		name = fn.Name()
+		if pos := strings.LastIndexByte(name, '/'); pos >= 0 {
+			name = name[pos+1:]
+		}
+	}
+	return name + " " + filepath.Base(file) + ":" + strconv.Itoa(line)
+}
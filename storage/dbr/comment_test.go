@@ -0,0 +1,151 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComment(t *testing.T) {
+
+	t.Run("Select", func(t *testing.T) {
+		sel := dbr.NewSelect("a").From("tableX").Where(dbr.Condition("b", dbr.ArgInt(1))).
+			Comment("req-id 4711").Comment("second")
+		sStr, args, err := sel.ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "/* req-id 4711 */ /* second */ SELECT a FROM `tableX` WHERE (`b` = ?)", sStr)
+		assert.Len(t, args, 1)
+		assert.Exactly(t, "/* req-id 4711 */ /* second */ SELECT a FROM `tableX` WHERE (`b` = 1)", sel.String())
+	})
+
+	t.Run("Select raw", func(t *testing.T) {
+		sel := &dbr.Select{RawFullSQL: "SELECT 1"}
+		sStr, _, err := sel.Comment("raw").ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "/* raw */ SELECT 1", sStr)
+	})
+
+	t.Run("escaping", func(t *testing.T) {
+		sStr, _, err := dbr.NewSelect("a").From("tableX").
+			Comment("*/ DROP TABLE x; /*! evil? */").ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "/* __ DROP TABLE x; __! evil_ __ */ SELECT a FROM `tableX`", sStr)
+	})
+
+	t.Run("escaping bypass", func(t *testing.T) {
+		tests := []struct {
+			comment string
+			want    string
+		}{
+			{"x *\x00/ OR 1=1; -- ", "/* x ___ OR 1=1; --  */ SELECT 1"},
+			{"**//", "/* ____ */ SELECT 1"},
+			{"/\x00*", "/* ___ */ SELECT 1"},
+			{"*\n/ \xc3\xa4", "/* ___ __ */ SELECT 1"},
+			{"x [y", "/* x _y */ SELECT 1"},
+			{"it's \"a\" `b` [c]", "/* it_s _a_ _b_ _c_ */ SELECT 1"},
+		}
+		for i, test := range tests {
+			sel := &dbr.Select{RawFullSQL: "SELECT 1"}
+			sStr, _, err := sel.Comment(test.comment).ToSQL()
+			assert.NoError(t, err, "Index %d", i)
+			assert.Exactly(t, test.want, sStr, "Index %d", i)
+		}
+	})
+
+	t.Run("interpolation with quotes and brackets", func(t *testing.T) {
+		for _, c := range []string{"x [y", "it's", `say "hi`, "`id"} {
+			sel := dbr.NewSelect("a").From("tableX").Where(dbr.Condition("b", dbr.ArgString("it's"))).Comment(c)
+			sStr, args, err := sel.ToSQL()
+			assert.NoError(t, err, "%q", c)
+			fullSQL, err := dbr.Preprocess(sStr, args...)
+			assert.NoError(t, err, "%q %+v", c, err)
+			assert.Contains(t, fullSQL, "SELECT a FROM `tableX` WHERE (`b` = 'it\\'s')", "%q", c)
+			assert.Exactly(t, fullSQL, sel.String(), "%q", c)
+		}
+	})
+
+	t.Run("Load with quotes and brackets", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		}()
+		dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("/* x _y it_s */ SELECT a FROM `tableX` WHERE (`b` = 'c')")).
+			WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow("d"))
+		var vals []string
+		_, err := dbc.Select("a").From("tableX").Where(dbr.Condition("b", dbr.ArgString("c"))).
+			Comment("x [y it's").LoadValues(context.TODO(), &vals)
+		assert.NoError(t, err, "%+v", err)
+		assert.Exactly(t, []string{"d"}, vals)
+	})
+
+	t.Run("Insert", func(t *testing.T) {
+		sStr, _, err := dbr.NewInsert("a").AddColumns("b").AddValues(dbr.ArgInt(1)).Comment("ins").ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "/* ins */ INSERT INTO `a` (`b`) VALUES (?)", sStr)
+	})
+
+	t.Run("Insert FromSelect", func(t *testing.T) {
+		sStr, _, err := dbr.NewInsert("a").Comment("ins").FromSelect(dbr.NewSelect("b").From("c"))
+		assert.NoError(t, err)
+		assert.Exactly(t, "/* ins */ INSERT INTO `a` SELECT b FROM `c`", sStr)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		sStr, _, err := dbr.NewUpdate("a").Set("b", dbr.ArgInt(1)).Comment("upd").ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "/* upd */ UPDATE `a` SET `b`=?", sStr)
+
+		u := &dbr.Update{RawFullSQL: "UPDATE a SET b=1"}
+		sStr, _, err = u.Comment("raw").ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "/* raw */ UPDATE a SET b=1", sStr)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		sStr, _, err := dbr.NewDelete("a").Comment("del").ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "/* del */ DELETE FROM `a`", sStr)
+	})
+
+	t.Run("Union", func(t *testing.T) {
+		sStr, _, err := dbr.NewUnion(
+			dbr.NewSelect("a").From("tableA"),
+			dbr.NewSelect("b").From("tableB"),
+		).Comment("uni").ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "/* uni */ (SELECT a FROM `tableA`)\nUNION\n(SELECT b FROM `tableB`)", sStr)
+	})
+
+	t.Run("UnionTemplate", func(t *testing.T) {
+		sStr, _, err := dbr.NewUnionTemplate(dbr.NewSelect("a").From("t_{id}")).
+			StringReplace("{id}", "1", "2").Comment("tpl").ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "/* tpl */ (SELECT a FROM `t_1`)\nUNION\n(SELECT a FROM `t_2`)", sStr)
+	})
+}
+
+func TestCommentCaller(t *testing.T) {
+	c := dbr.CommentCaller()
+	assert.Contains(t, c, "dbr_test.TestCommentCaller")
+	assert.Contains(t, c, "comment_test.go:")
+}
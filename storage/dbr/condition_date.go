@@ -0,0 +1,150 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import "time"
+
+// DateOption applies options to the date conditions ConditionDateRange,
+// ConditionLastNDays and ConditionOlderThan.
+type DateOption func(*dateConfig)
+
+type dateConfig struct {
+	// location of the store, defines the boundaries of a day.
+	location *time.Location
+	// dbLocation time zone of the values in the column. Defaults to UTC.
+	dbLocation *time.Location
+	reference  time.Time
+	wholeDays  bool
+}
+
+func newDateConfig(opts []DateOption) dateConfig {
+	dc := dateConfig{
+		location:   time.UTC,
+		dbLocation: time.UTC,
+	}
+	for _, o := range opts {
+		o(&dc)
+	}
+	if dc.reference.IsZero() {
+		dc.reference = now()
+	}
+	return dc
+}
+
+// WithDateLocation sets the time zone of the store which defines where a day
+// starts and ends. For example the last seven days of a store in
+// Europe/Berlin start at midnight in Berlin. Defaults to UTC.
+func WithDateLocation(loc *time.Location) DateOption {
+	return func(dc *dateConfig) {
+		if loc != nil {
+			dc.location = loc
+		}
+	}
+}
+
+// WithDateDatabaseLocation sets the time zone in which the values of the
+// column have been stored. All arguments get converted into this time zone.
+// Defaults to UTC, like dbr.Now.
+func WithDateDatabaseLocation(loc *time.Location) DateOption {
+	return func(dc *dateConfig) {
+		if loc != nil {
+			dc.dbLocation = loc
+		}
+	}
+}
+
+// WithDateReference sets the point in time to which the relative conditions
+// refer. Defaults to the current time. Useful for jobs which must process a
+// fixed period independent of when they run.
+func WithDateReference(t time.Time) DateOption {
+	return func(dc *dateConfig) {
+		dc.reference = t
+	}
+}
+
+// WithDateWholeDays extends ConditionDateRange to the start of the day of
+// `from` and to the end of the day of `to`, both in the store time zone.
+func WithDateWholeDays() DateOption {
+	return func(dc *dateConfig) {
+		dc.wholeDays = true
+	}
+}
+
+func (dc dateConfig) startOfDay(t time.Time) time.Time {
+	t = t.In(dc.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, dc.location)
+}
+
+func (dc dateConfig) endOfDay(t time.Time) time.Time {
+	t = t.In(dc.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, dc.location)
+}
+
+func (dc dateConfig) between(column string, from, to time.Time) ConditionArg {
+	return &whereFragment{
+		Condition: column,
+		Arguments: Arguments{
+			ArgTime(from.In(dc.dbLocation), to.In(dc.dbLocation)).Operator(Between),
+		},
+	}
+}
+
+// ConditionDateRange adds a condition to a WHERE or HAVING statement which
+// matches all values of the column between `from` and `to`, both inclusive.
+// Swapped boundaries get corrected.
+//		ConditionDateRange("created_at", from, to, WithDateWholeDays())
+//		// `created_at` BETWEEN '2017-03-01 00:00:00' AND '2017-03-31 23:59:59'
+func ConditionDateRange(column string, from, to time.Time, opts ...DateOption) ConditionArg {
+	dc := newDateConfig(opts)
+	if from.After(to) {
+		from, to = to, from
+	}
+	if dc.wholeDays {
+		from, to = dc.startOfDay(from), dc.endOfDay(to)
+	}
+	return dc.between(column, from, to)
+}
+
+// ConditionLastNDays adds a condition to a WHERE or HAVING statement which
+// matches all values of the column from the start of the day n-1 days ago up
+// to the reference time. The current day counts as the first day, so n=1
+// matches today and n=7 the current week including today. A value of n lower
+// than one gets treated as one.
+//		ConditionLastNDays("created_at", 7, WithDateLocation(storeLoc))
+//		// `created_at` BETWEEN ? AND ?
+func ConditionLastNDays(column string, n int, opts ...DateOption) ConditionArg {
+	dc := newDateConfig(opts)
+	if n < 1 {
+		n = 1
+	}
+	ref := dc.reference.In(dc.location)
+	from := dc.startOfDay(time.Date(ref.Year(), ref.Month(), ref.Day()-(n-1), 12, 0, 0, 0, dc.location))
+	return dc.between(column, from, ref)
+}
+
+// ConditionOlderThan adds a condition to a WHERE or HAVING statement which
+// matches all values of the column older than the duration d, relative to the
+// reference time. Mostly used by cleanup jobs.
+//		ConditionOlderThan("updated_at", 30*24*time.Hour)
+//		// `updated_at` < ?
+func ConditionOlderThan(column string, d time.Duration, opts ...DateOption) ConditionArg {
+	dc := newDateConfig(opts)
+	return &whereFragment{
+		Condition: column,
+		Arguments: Arguments{
+			ArgTime(dc.reference.Add(-d).In(dc.dbLocation)).Operator(Less),
+		},
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// 2017-03-26 is the switch to daylight saving time in Berlin.
+	ref := time.Date(2017, 3, 27, 10, 30, 0, 0, time.UTC)
+	from := time.Date(2017, 3, 1, 14, 0, 0, 0, time.UTC)
+	to := time.Date(2017, 3, 31, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		cond    dbr.ConditionArg
+		wantSQL string
+	}{
+		{
+			dbr.ConditionDateRange("created_at", from, to),
+			"`created_at` BETWEEN '2017-03-01 14:00:00' AND '2017-03-31 09:00:00'",
+		},
+		{
+			dbr.ConditionDateRange("created_at", to, from),
+			"`created_at` BETWEEN '2017-03-01 14:00:00' AND '2017-03-31 09:00:00'",
+		},
+		{
+			dbr.ConditionDateRange("created_at", from, to, dbr.WithDateWholeDays()),
+			"`created_at` BETWEEN '2017-03-01 00:00:00' AND '2017-03-31 23:59:59'",
+		},
+		{
+			dbr.ConditionDateRange("created_at", from, to, dbr.WithDateWholeDays(), dbr.WithDateLocation(berlin)),
+			"`created_at` BETWEEN '2017-02-28 23:00:00' AND '2017-03-31 21:59:59'",
+		},
+		{
+			dbr.ConditionDateRange("created_at", from, to, dbr.WithDateWholeDays(), dbr.WithDateLocation(berlin), dbr.WithDateDatabaseLocation(berlin)),
+			"`created_at` BETWEEN '2017-03-01 00:00:00' AND '2017-03-31 23:59:59'",
+		},
+		{
+			dbr.ConditionLastNDays("s.created_at", 1, dbr.WithDateReference(ref)),
+			"`s`.`created_at` BETWEEN '2017-03-27 00:00:00' AND '2017-03-27 10:30:00'",
+		},
+		{
+			dbr.ConditionLastNDays("created_at", 0, dbr.WithDateReference(ref)),
+			"`created_at` BETWEEN '2017-03-27 00:00:00' AND '2017-03-27 10:30:00'",
+		},
+		{
+			dbr.ConditionLastNDays("created_at", 7, dbr.WithDateReference(ref), dbr.WithDateLocation(berlin)),
+			"`created_at` BETWEEN '2017-03-20 23:00:00' AND '2017-03-27 10:30:00'",
+		},
+		{
+			dbr.ConditionLastNDays("created_at", 2, dbr.WithDateReference(ref), dbr.WithDateLocation(berlin)),
+			"`created_at` BETWEEN '2017-03-25 23:00:00' AND '2017-03-27 10:30:00'",
+		},
+		{
+			dbr.ConditionOlderThan("updated_at", 48*time.Hour, dbr.WithDateReference(ref)),
+			"`updated_at` < '2017-03-25 10:30:00'",
+		},
+	}
+	for i, test := range tests {
+		sqlStr, args, err := dbr.NewSelect("sku").From("sales_order").Where(test.cond).ToSQL()
+		require.NoError(t, err, "Index %d", i)
+		iSQL, err := dbr.Preprocess(sqlStr, args...)
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, "SELECT sku FROM `sales_order` WHERE ("+test.wantSQL+")", iSQL, "Index %d", i)
+	}
+}
+
+func TestConditionLastNDays_Now(t *testing.T) {
+	_, args, err := dbr.NewSelect("sku").From("sales_order").
+		Where(dbr.ConditionLastNDays("created_at", 3)).ToSQL()
+	require.NoError(t, err)
+	ifs := args.Interfaces()
+	require.Len(t, ifs, 2)
+	from, to := ifs[0].(time.Time), ifs[1].(time.Time)
+	assert.Exactly(t, time.UTC, from.Location())
+	assert.True(t, to.Sub(from) >= 48*time.Hour, "%s", to.Sub(from))
+	assert.True(t, to.Sub(from) < 72*time.Hour, "%s", to.Sub(from))
+}
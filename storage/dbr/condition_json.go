@@ -0,0 +1,91 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"github.com/corestoreio/csfw/util/bufferpool"
+)
+
+// SQLJSONExtract returns the expression to extract and unquote the value at
+// path from a JSON column, the equivalent of the ->> operator:
+//		SQLJSONExtract("attributes", "$.color")
+//		// JSON_UNQUOTE(JSON_EXTRACT(`attributes`, '$.color'))
+// The column gets quoted and the path gets escaped as a string literal. The
+// expression can be used as a column in a SELECT or as the definition of a
+// generated column. MySQL uses the index of a generated column whenever a
+// condition contains the same expression, so frequently filtered JSON
+// attributes should be backed by an indexed virtual column:
+//		ALTER TABLE `catalog_product_entity` ADD COLUMN `color` VARCHAR(64)
+//			GENERATED ALWAYS AS (JSON_UNQUOTE(JSON_EXTRACT(`attributes`, '$.color'))) VIRTUAL,
+//			ADD INDEX (`color`)
+func SQLJSONExtract(column, path string) string {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	writeJSONExtract(buf, column, path)
+	return buf.String()
+}
+
+func writeJSONExtract(w queryWriter, column, path string) {
+	w.WriteString("JSON_UNQUOTE(JSON_EXTRACT(")
+	Quoter.FquoteAs(w, column)
+	w.WriteString(", ")
+	dialect.EscapeString(w, path)
+	w.WriteString("))")
+}
+
+// ConditionJSON adds a condition to a WHERE or HAVING statement which compares
+// the value at path of a JSON column with the argument. The operator of the
+// argument gets applied, without an operator it defaults to equal.
+//		ConditionJSON("attributes", "$.color", ArgString("red"))
+//		// JSON_UNQUOTE(JSON_EXTRACT(`attributes`, '$.color')) = ?
+//		ConditionJSON("attributes", "$.size", ArgInt64(40, 42).Operator(In))
+//		// JSON_UNQUOTE(JSON_EXTRACT(`attributes`, '$.size')) IN ?
+// JSON_UNQUOTE returns a string, so numbers get compared as strings. See
+// SQLJSONExtract for the quoting and for generated columns.
+func ConditionJSON(column, path string, arg Argument) ConditionArg {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	writeJSONExtract(buf, column, path)
+	if arg.operator() == 0 {
+		buf.WriteString(" = ?")
+	}
+	return &whereFragment{
+		Condition: buf.String(),
+		Arguments: Arguments{arg},
+	}
+}
+
+// ConditionJSONContains adds a JSON_CONTAINS condition to a WHERE or HAVING
+// statement. The candidate must be a valid JSON document, for example a quoted
+// string or an array. The optional path selects the part of the column which
+// gets searched.
+//		ConditionJSONContains("attributes", ArgString(`"sale"`), "$.tags")
+//		// JSON_CONTAINS(`attributes`, ?, '$.tags')
+func ConditionJSONContains(column string, candidate Argument, path ...string) ConditionArg {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	buf.WriteString("JSON_CONTAINS(")
+	Quoter.FquoteAs(buf, column)
+	buf.WriteString(", ?")
+	if len(path) > 0 && path[0] != "" {
+		buf.WriteString(", ")
+		dialect.EscapeString(buf, path[0])
+	}
+	buf.WriteRune(')')
+	return &whereFragment{
+		Condition: buf.String(),
+		Arguments: Arguments{candidate},
+	}
+}
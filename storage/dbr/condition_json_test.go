@@ -0,0 +1,81 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLJSONExtract(t *testing.T) {
+	assert.Exactly(t, "JSON_UNQUOTE(JSON_EXTRACT(`attributes`, '$.color'))", dbr.SQLJSONExtract("attributes", "$.color"))
+	assert.Exactly(t, "JSON_UNQUOTE(JSON_EXTRACT(`e`.`attributes`, '$.\\\"size chart\\\"'))", dbr.SQLJSONExtract("e.attributes", `$."size chart"`))
+	assert.Exactly(t, "JSON_UNQUOTE(JSON_EXTRACT(`attributes`, '$.a\\' OR 1=1 -- '))", dbr.SQLJSONExtract("attributes", `$.a' OR 1=1 -- `))
+}
+
+func TestConditionJSON(t *testing.T) {
+	tests := []struct {
+		cond     dbr.ConditionArg
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			dbr.ConditionJSON("attributes", "$.color", dbr.ArgString("red")),
+			"SELECT sku FROM `catalog_product_entity` WHERE (JSON_UNQUOTE(JSON_EXTRACT(`attributes`, '$.color')) = ?)",
+			[]interface{}{"red"},
+		},
+		{
+			dbr.ConditionJSON("attributes", "$.size", dbr.ArgInt64(40, 42).Operator(dbr.In)),
+			"SELECT sku FROM `catalog_product_entity` WHERE (JSON_UNQUOTE(JSON_EXTRACT(`attributes`, '$.size')) IN ?)",
+			[]interface{}{int64(40), int64(42)},
+		},
+		{
+			dbr.ConditionJSON("attributes", "$.color", dbr.ArgString("r%").Operator(dbr.Like)),
+			"SELECT sku FROM `catalog_product_entity` WHERE (JSON_UNQUOTE(JSON_EXTRACT(`attributes`, '$.color')) LIKE ?)",
+			[]interface{}{"r%"},
+		},
+		{
+			dbr.ConditionJSONContains("attributes", dbr.ArgString(`"sale"`), "$.tags"),
+			"SELECT sku FROM `catalog_product_entity` WHERE (JSON_CONTAINS(`attributes`, ?, '$.tags'))",
+			[]interface{}{`"sale"`},
+		},
+		{
+			dbr.ConditionJSONContains("attributes", dbr.ArgString(`{"color":"red"}`)),
+			"SELECT sku FROM `catalog_product_entity` WHERE (JSON_CONTAINS(`attributes`, ?))",
+			[]interface{}{`{"color":"red"}`},
+		},
+	}
+	for i, test := range tests {
+		sqlStr, args, err := dbr.NewSelect("sku").From("catalog_product_entity").Where(test.cond).ToSQL()
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantSQL, sqlStr, "Index %d", i)
+		assert.Exactly(t, test.wantArgs, args.Interfaces(), "Index %d", i)
+	}
+}
+
+func TestConditionJSON_Interpolate(t *testing.T) {
+	sqlStr, args, err := dbr.NewSelect("sku").From("catalog_product_entity").
+		Where(
+			dbr.ConditionJSON("attributes", "$.color", dbr.ArgString("red")),
+			dbr.Condition("entity_id", dbr.ArgInt64(3)),
+		).ToSQL()
+	require.NoError(t, err)
+	iSQL, err := dbr.Preprocess(sqlStr, args...)
+	require.NoError(t, err)
+	assert.Exactly(t, "SELECT sku FROM `catalog_product_entity` WHERE (JSON_UNQUOTE(JSON_EXTRACT(`attributes`, '$.color')) = 'red') AND (`entity_id` = 3)", iSQL)
+}
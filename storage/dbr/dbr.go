@@ -23,6 +23,18 @@ type Connection struct {
 	// DatabaseName contains the database name to which this connection has been
 	// bound to. It will only be set when a DSN has been parsed.
 	DatabaseName string
+	// Dialect gets passed to all builders created by this connection and its
+	// transactions. Nil means MySQL.
+	Dialect Dialect
+	// Scanners gets passed to all Select builders created by this connection
+	// and its transactions. Nil means no custom column scanning.
+	Scanners *ScannerRegistry
+	// TableNameMapper gets passed to all builders created by this connection
+	// and its transactions. Nil keeps the table names unchanged.
+	TableNameMapper TableNameMapper
+	// Metrics, if set, records all queries of the builders created by this
+	// connection and watches the connection pool.
+	Metrics *Metrics
 }
 
 // ConnectionOption can be used at an argument in NewConnection to configure a
@@ -49,6 +61,44 @@ func WithDSN(dsn string) ConnectionOption {
 	}
 }
 
+// WithDialect sets the SQL dialect for all statements created by the
+// connection. The default dialect writes MySQL syntax with back tick quoted
+// identifiers.
+func WithDialect(d Dialect) ConnectionOption {
+	return func(c *Connection) error {
+		if d == nil {
+			return errors.NewEmptyf("[dbr] WithDialect: Dialect cannot be nil")
+		}
+		c.Dialect = d
+		return nil
+	}
+}
+
+// WithScanners sets the column scanner registry used by LoadStructs,
+// LoadStruct and LoadMaps of all Select statements created by the connection.
+func WithScanners(sr *ScannerRegistry) ConnectionOption {
+	return func(c *Connection) error {
+		if sr == nil {
+			return errors.NewEmptyf("[dbr] WithScanners: ScannerRegistry cannot be nil")
+		}
+		c.Scanners = sr
+		return nil
+	}
+}
+
+// WithMetrics instruments all builders created by the connection with the
+// metrics collector. The pool statistics get exported under the database name
+// or under "default" if no DSN has been provided.
+func WithMetrics(m *Metrics) ConnectionOption {
+	return func(c *Connection) error {
+		if m == nil {
+			return errors.NewEmptyf("[dbr] WithMetrics: Metrics cannot be nil")
+		}
+		c.Metrics = m
+		return nil
+	}
+}
+
 // NewConnection instantiates a Connection for a given database/sql connection
 // and event receiver. An invalid drivername causes a NotImplemented error to be
 // returned. You can either apply a DSN or a pre configured *sql.DB type.
@@ -70,18 +120,32 @@ func NewConnection(opts ...ConnectionOption) (*Connection, error) {
 		c.DatabaseName = c.dsn.DBName
 	}
 
-	if c.DB != nil || c.dsn == nil {
-		return c, nil
+	if c.DB == nil && c.dsn != nil {
+		var err error
+		if c.DB, err = sql.Open(c.dn, c.dsn.FormatDSN()); err != nil {
+			return nil, errors.Wrap(err, "[dbr] sql.Open")
+		}
 	}
 
-	var err error
-	if c.DB, err = sql.Open(c.dn, c.dsn.FormatDSN()); err != nil {
-		return nil, errors.Wrap(err, "[dbr] sql.Open")
+	if c.Metrics != nil && c.DB != nil {
+		name := c.DatabaseName
+		if name == "" {
+			name = "default"
+		}
+		c.Metrics.WatchPool(name, c.DB)
 	}
-
 	return c, nil
 }
 
+// dber returns the database handle for the builders, instrumented if Metrics
+// has been set.
+func (c *Connection) dber() DBer {
+	if c.Metrics == nil {
+		return c.DB
+	}
+	return c.Metrics.Instrument(c.DB)
+}
+
 // MustConnectAndVerify at like NewConnection but it verifies the connection
 // and panics on errors.
 func MustConnectAndVerify(opts ...ConnectionOption) *Connection {
@@ -3,6 +3,7 @@ package dbr
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/corestoreio/csfw/util/bufferpool"
 	"github.com/corestoreio/errors"
@@ -12,17 +13,36 @@ import (
 // Delete contains the clauses for a DELETE statement
 type Delete struct {
 	Log log.Logger // Log optional logger
-	DB  struct {
+	// Dialect rewrites the generated SQL for the target database server. Nil
+	// or DialectMySQL keeps the MySQL syntax.
+	Dialect Dialect
+	// TableNameMapper rewrites the table names when generating the SQL, for
+	// example to add a per-tenant prefix. Nil keeps the names unchanged.
+	TableNameMapper TableNameMapper
+	// QueryTimeout cancels the execution after the duration. See Timeout()
+	QueryTimeout time.Duration
+	DB           struct {
 		Preparer
 		Execer
 	}
 	From alias
+	// JoinFragments turns the statement into a multiple-table DELETE. Only
+	// rows of the From table get deleted. See Join().
+	JoinFragments
 	WhereFragments
 	OrderBys    []string
 	LimitCount  uint64
 	OffsetCount uint64
 	LimitValid  bool
 	OffsetValid bool
+	// IsStrict enables the validation mode. See Strict()
+	IsStrict bool
+	// Statement modifiers, MySQL only.
+	IsLowPriority bool // See LowPriority()
+	IsQuick       bool // See Quick()
+	IsIgnore      bool // See Ignore()
+	// Comments get rendered as leading C style comments. See Comment()
+	Comments []string
 	// PropagationStopped set to true if you would like to interrupt the
 	// listener chain. Once set to true all sub sequent calls of the next
 	// listeners will be suppressed.
@@ -47,11 +67,15 @@ func NewDelete(from ...string) *Delete {
 func (c *Connection) DeleteFrom(from ...string) *Delete {
 	d := &Delete{
 		Log:            c.Log,
+		Dialect:        c.Dialect,
 		From:           MakeAlias(from...),
 		WhereFragments: make(WhereFragments, 0, 2),
+
+		TableNameMapper: c.TableNameMapper,
 	}
-	d.DB.Execer = c.DB
-	d.DB.Preparer = c.DB
+	db := c.dber()
+	d.DB.Execer = db
+	d.DB.Preparer = db
 	return d
 }
 
@@ -59,14 +83,46 @@ func (c *Connection) DeleteFrom(from ...string) *Delete {
 // in the context for a transaction
 func (tx *Tx) DeleteFrom(from ...string) *Delete {
 	d := &Delete{
-		Log:  tx.Logger,
-		From: MakeAlias(from...),
+		Log:     tx.Logger,
+		Dialect: tx.Dialect,
+		From:    MakeAlias(from...),
+
+		TableNameMapper: tx.TableNameMapper,
 	}
 	d.DB.Execer = tx.Tx
 	d.DB.Preparer = tx.Tx
 	return d
 }
 
+// Join creates an INNER join construct. By default, the onConditions are glued
+// together with AND. Only the rows of the From table get deleted. Together
+// with MakeAliasFromSub the rows can be deleted based on an aggregated
+// sub-query. The arguments of the derived table and of the onConditions get
+// merged. A multiple-table DELETE does not support ORDER BY and LIMIT.
+//		dbr.NewDelete("customer_visitor", "cv").
+//			Join(dbr.MakeAliasFromSub(sel, "t"), dbr.Condition("t.customer_id = cv.customer_id"))
+//		// DELETE `cv` FROM `customer_visitor` AS `cv` INNER JOIN (SELECT ...) AS `t`
+//		// ON (t.customer_id = cv.customer_id)
+func (b *Delete) Join(table alias, onConditions ...ConditionArg) *Delete {
+	return b.join("INNER", table, onConditions...)
+}
+
+// LeftJoin creates a LEFT join construct. By default, the onConditions are
+// glued together with AND. See Join().
+func (b *Delete) LeftJoin(table alias, onConditions ...ConditionArg) *Delete {
+	return b.join("LEFT", table, onConditions...)
+}
+
+func (b *Delete) join(j string, t alias, on ...ConditionArg) *Delete {
+	jf := &joinFragment{
+		JoinType: j,
+		Table:    t,
+	}
+	appendConditions(&jf.OnConditions, on...)
+	b.JoinFragments = append(b.JoinFragments, jf)
+	return b
+}
+
 // Where appends a WHERE clause to the statement whereSQLOrMap can be a
 // string or map. If it'ab a string, args wil replaces any places holders
 func (b *Delete) Where(args ...ConditionArg) *Delete {
@@ -75,15 +131,15 @@ func (b *Delete) Where(args ...ConditionArg) *Delete {
 }
 
 // OrderBy appends a column or an expression to ORDER the statement ascending.
-func (b *Delete) OrderBy(ord ...string) *Delete {
-	b.OrderBys = append(b.OrderBys, ord...)
+func (b *Delete) OrderBy(ord ...SQLUnsafe) *Delete {
+	b.OrderBys = append(b.OrderBys, auditSQLExpr(ord...)...)
 	return b
 }
 
 // OrderByDesc appends a column or an expression to ORDER the statement
 // descending.
-func (b *Delete) OrderByDesc(ord ...string) *Delete {
-	b.OrderBys = orderByDesc(b.OrderBys, ord)
+func (b *Delete) OrderByDesc(ord ...SQLUnsafe) *Delete {
+	b.OrderBys = orderByDesc(b.OrderBys, auditSQLExpr(ord...))
 	return b
 }
 
@@ -101,9 +157,65 @@ func (b *Delete) Offset(offset uint64) *Delete {
 	return b
 }
 
+// Ignore causes MySQL to ignore errors during the process of deleting rows.
+// Errors encountered during the parsing stage are processed in the usual
+// manner.
+func (b *Delete) Ignore() *Delete {
+	b.IsIgnore = true
+	return b
+}
+
+// LowPriority delays the execution of the DELETE until no other clients are
+// reading from the table. Affects only storage engines with table-level
+// locking like MyISAM, MEMORY and MERGE.
+func (b *Delete) LowPriority() *Delete {
+	b.IsLowPriority = true
+	return b
+}
+
+// Quick tells the MyISAM storage engine not to merge index leaves during
+// delete, which may speed up some kinds of delete operations.
+func (b *Delete) Quick() *Delete {
+	b.IsQuick = true
+	return b
+}
+
+// Comment adds a leading /* comment */ to the statement, see Select.Comment.
+func (b *Delete) Comment(c string) *Delete {
+	b.Comments = append(b.Comments, c)
+	return b
+}
+
+// Timeout cancels the execution of the statement after the duration, see
+// Select.Timeout.
+func (b *Delete) Timeout(d time.Duration) *Delete {
+	b.QueryTimeout = d
+	return b
+}
+
+// Strict enables the validation mode for the table name and the WHERE
+// conditions, see Select.Strict.
+func (b *Delete) Strict() *Delete {
+	b.IsStrict = true
+	return b
+}
+
 // ToSQL serialized the Delete to a SQL string
 // It returns the string with placeholders and a slice of query arguments
 func (b *Delete) ToSQL() (string, Arguments, error) {
+	sqlStr, args, err := b.rawSQL()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := resolveValuers(args); err != nil {
+		return "", nil, errors.Wrap(err, "[dbr] Delete.ToSQL.resolveValuers")
+	}
+	return Rebind(b.Dialect, sqlStr), args, nil
+}
+
+// rawSQL generates the statement in MySQL syntax without applying the
+// Dialect.
+func (b *Delete) rawSQL() (string, Arguments, error) {
 
 	if err := b.Listeners.dispatch(OnBeforeToSQL, b); err != nil {
 		return "", nil, errors.Wrap(err, "[dbr] Delete.Listeners.dispatch")
@@ -112,30 +224,53 @@ func (b *Delete) ToSQL() (string, Arguments, error) {
 	if len(b.From.Expression) == 0 {
 		return "", nil, errors.NewEmptyf(errTableMissing)
 	}
+	if len(b.JoinFragments) > 0 && (len(b.OrderBys) > 0 || b.LimitValid) {
+		return "", nil, errors.NewNotSupportedf("[dbr] Delete: ORDER BY and LIMIT cannot be used with JOINs")
+	}
+	if b.IsStrict {
+		if err := b.validate(); err != nil {
+			return "", nil, errors.Wrap(err, "[dbr] Delete.ToSQL.validate")
+		}
+	}
 
 	var buf = bufferpool.Get()
 	defer bufferpool.Put(buf)
 	var args Arguments // no make() lazy init the slice via append in cases where not WHERE has been provided.
 
-	buf.WriteString("DELETE FROM ")
-	b.From.FquoteAs(buf)
+	sqlWriteComments(buf, b.Comments)
+	buf.WriteString("DELETE ")
+	sqlWriteModifier(buf, b.IsLowPriority, "LOW_PRIORITY ")
+	sqlWriteModifier(buf, b.IsQuick, "QUICK ")
+	sqlWriteModifier(buf, b.IsIgnore, "IGNORE ")
+	from := b.From.mapTableName(b.TableNameMapper, b.Dialect, false)
+	if len(b.JoinFragments) > 0 {
+		buf.WriteString(from.qualifier())
+		buf.WriteRune(' ')
+	}
+	buf.WriteString("FROM ")
+	from.FquoteAs(buf)
+	if err := sqlWriteJoins(buf, b.TableNameMapper, b.Dialect, b.JoinFragments, &args); err != nil {
+		return "", nil, errors.Wrap(err, "[dbr] Delete.ToSQL.sqlWriteJoins")
+	}
 
 	// Write WHERE clause if we have any fragments
 	if len(b.WhereFragments) > 0 {
-		if err := writeWhereFragmentsToSQL(b.WhereFragments, buf, &args, 'w'); err != nil {
+		if err := writeWhereFragmentsToSQL(b.WhereFragments, buf, b.TableNameMapper, b.Dialect, &args, 'w'); err != nil {
 			return "", nil, errors.Wrap(err, "[dbr] Delete.ToSQL.writeWhereFragmentsToSQL")
 		}
 	}
 
 	sqlWriteOrderBy(buf, b.OrderBys, false)
-	sqlWriteLimitOffset(buf, b.LimitValid, b.LimitCount, b.OffsetValid, b.OffsetCount)
+	sqlWriteLimitOffset(buf, b.Dialect, b.LimitValid, b.LimitCount, b.OffsetValid, b.OffsetCount)
 	return buf.String(), args, nil
 }
 
 // Exec executes the statement represented by the Delete
 // It returns the raw database/sql Result and an error if there was one
 func (b *Delete) Exec(ctx context.Context) (sql.Result, error) {
-	sqlStr, args, err := b.ToSQL()
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
+	sqlStr, args, err := b.rawSQL()
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Delete.Exec.ToSQL")
 	}
@@ -144,6 +279,7 @@ func (b *Delete) Exec(ctx context.Context) (sql.Result, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "[dbr] Delete.Exec.Preprocess: %q", fullSQL)
 	}
+	fullSQL = Rebind(b.Dialect, fullSQL)
 
 	if b.Log != nil && b.Log.IsInfo() {
 		defer log.WhenDone(b.Log).Info("dbr.Delete.Exec.Timing", log.String("sql", fullSQL))
@@ -151,7 +287,7 @@ func (b *Delete) Exec(ctx context.Context) (sql.Result, error) {
 
 	result, err := b.DB.ExecContext(ctx, fullSQL)
 	if err != nil {
-		return result, errors.Wrap(err, "[dbr] delete.exec.Exec")
+		return result, wrapMySQLError(err, "[dbr] delete.exec.Exec")
 	}
 
 	return result, nil
@@ -161,6 +297,8 @@ func (b *Delete) Exec(ctx context.Context) (sql.Result, error) {
 // database/sql Statement and an error if there was one. Provided arguments in
 // the Delete are getting ignored. It panics when field Preparer at nil.
 func (b *Delete) Prepare(ctx context.Context) (*sql.Stmt, error) {
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
 	sqlStr, _, err := b.ToSQL() // TODO create a ToSQL version without any arguments
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Delete.Prepare.ToSQL")
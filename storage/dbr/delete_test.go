@@ -7,6 +7,7 @@ import (
 	"github.com/corestoreio/errors"
 	"github.com/corestoreio/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var benchmarkDeleteSQL Arguments
@@ -35,6 +36,23 @@ func TestDeleteAllToSQL(t *testing.T) {
 	assert.Equal(t, sql, "DELETE FROM `a` AS `b`")
 }
 
+func TestDelete_Modifiers(t *testing.T) {
+	tests := []struct {
+		del     *Delete
+		wantSQL string
+	}{
+		{NewDelete("a").Ignore(), "DELETE IGNORE FROM `a`"},
+		{NewDelete("a").Quick(), "DELETE QUICK FROM `a`"},
+		{NewDelete("a").LowPriority(), "DELETE LOW_PRIORITY FROM `a`"},
+		{NewDelete("a").Ignore().Quick().LowPriority().Limit(1), "DELETE LOW_PRIORITY QUICK IGNORE FROM `a` LIMIT 1"},
+	}
+	for i, test := range tests {
+		sqlStr, _, err := test.del.ToSQL()
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantSQL, sqlStr, "Index %d", i)
+	}
+}
+
 func TestDeleteSingleToSQL(t *testing.T) {
 	s := createFakeSession()
 
@@ -222,3 +240,38 @@ func TestDelete_Events(t *testing.T) {
 	})
 
 }
+
+func TestDelete_Join(t *testing.T) {
+	t.Run("derived table with arguments", func(t *testing.T) {
+		sub := NewSelect("customer_id").AddColumnsExprAlias("MAX(last_visit_at)", "last_visit").
+			From("customer_visitor").
+			Where(Condition("store_id", ArgInt64(3))).
+			GroupBy("customer_id").
+			Having(Condition("MAX(last_visit_at) < ?", ArgString("2017-01-01")))
+
+		sqlStr, args, err := NewDelete("customer_visitor", "cv").
+			Join(MakeAliasFromSub(sub, "t"), Condition("t.customer_id = cv.customer_id")).
+			Where(Condition("cv.is_guest", ArgBool(true))).ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t,
+			"DELETE `cv` FROM `customer_visitor` AS `cv` INNER JOIN (SELECT customer_id, MAX(last_visit_at) AS `last_visit` FROM `customer_visitor` WHERE (`store_id` = ?) GROUP BY customer_id HAVING (MAX(last_visit_at) < ?)) AS `t` ON (t.customer_id = cv.customer_id) WHERE (`cv`.`is_guest` = ?)",
+			sqlStr)
+		assert.Exactly(t, []interface{}{int64(3), "2017-01-01", true}, args.Interfaces())
+	})
+	t.Run("without alias", func(t *testing.T) {
+		sqlStr, args, err := NewDelete("customer_visitor").
+			LeftJoin(MakeAlias("customer_entity", "ce"), Condition("ce.entity_id = customer_visitor.customer_id")).
+			Where(Condition("ce.entity_id", ArgNull())).ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t,
+			"DELETE `customer_visitor` FROM `customer_visitor` LEFT JOIN `customer_entity` AS `ce` ON (ce.entity_id = customer_visitor.customer_id) WHERE (`ce`.`entity_id` IS NULL)",
+			sqlStr)
+		assert.Len(t, args, 0)
+	})
+	t.Run("LIMIT not supported", func(t *testing.T) {
+		_, _, err := NewDelete("customer_visitor", "cv").
+			Join(MakeAlias("customer_entity", "ce"), Condition("ce.entity_id = cv.customer_id")).
+			Limit(10).ToSQL()
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+}
@@ -1,16 +1,51 @@
 package dbr
 
 import (
+	"bytes"
 	"encoding/hex"
 	"strconv"
 	"strings"
 	"time"
-)
 
-// no other dialect will be supported. Maybe MariaDB ;-)
+	"github.com/corestoreio/csfw/util/bufferpool"
+)
 
+// dialect escapes the arguments during interpolation. Interpolation always
+// produces MySQL syntax, which gets afterwards rewritten by Rebind for the
+// target Dialect.
 var dialect dialecter = mysqlDialect{}
 
+// Dialect defines how a generated SQL statement gets written for a specific
+// database server. All builders render internally in MySQL syntax; a Dialect,
+// set on the Connection via WithDialect or directly on a builder, rewrites the
+// quoted identifiers and the place holders of the final statement and renders
+// the LIMIT and OFFSET clause. A nil Dialect means MySQL.
+type Dialect interface {
+	// Name returns the name of the dialect, mostly used for debugging.
+	Name() string
+	// QuoteIdent quotes a single identifier, for example a table or a column
+	// name. The identifier does not contain any dots.
+	QuoteIdent(ident string) string
+	// Placeholder returns the bind variable for the argument at position pos,
+	// starting at one.
+	Placeholder(pos int) string
+	// LimitOffset returns the LIMIT and OFFSET clause including a leading
+	// white space or an empty string.
+	LimitOffset(limitValid bool, limit uint64, offsetValid bool, offset uint64) string
+}
+
+// Supported SQL dialects. DialectMySQL is the default and generates back tick
+// quoted identifiers. DialectMySQLANSI writes double quoted identifiers which
+// requires the server SQL mode ANSI_QUOTES in MySQL or MariaDB.
+// DialectPostgres writes double quoted identifiers and numbered place holders
+// like $1, $2. It covers the identifier and bind variable syntax only, MySQL
+// specific functions and clauses must be avoided in the queries.
+var (
+	DialectMySQL     Dialect = mysqlDialect{}
+	DialectMySQLANSI Dialect = ansiDialect{name: "mysql_ansi", backslashEscapes: true}
+	DialectPostgres  Dialect = ansiDialect{name: "postgres", numbered: true}
+)
+
 // dialecter at an interface that wraps the diverse properties of individual
 // SQL drivers.
 type dialecter interface {
@@ -29,6 +64,18 @@ const DriverNameMySQL = "mysql"
 
 type mysqlDialect struct{}
 
+func (mysqlDialect) Name() string { return DriverNameMySQL }
+
+func (mysqlDialect) QuoteIdent(ident string) string {
+	return "`" + strings.Replace(ident, "`", "``", -1) + "`"
+}
+
+func (mysqlDialect) Placeholder(int) string { return "?" }
+
+func (mysqlDialect) LimitOffset(limitValid bool, limit uint64, offsetValid bool, offset uint64) string {
+	return limitOffsetClause(limitValid, limit, offsetValid, offset)
+}
+
 func (mysqlDialect) EscapeIdent(w queryWriter, ident string) {
 	w.WriteRune('`')
 	r := strings.NewReplacer("`", "``", ".", "`.`")
@@ -96,3 +143,209 @@ func (mysqlDialect) ApplyLimitAndOffset(w queryWriter, limit, offset uint64) {
 	}
 
 }
+
+// ansiDialect quotes identifiers with double quotes as defined in the SQL
+// standard.
+type ansiDialect struct {
+	name string
+	// numbered uses $1, $2 ... as place holders instead of question marks.
+	numbered bool
+	// backslashEscapes the server treats the backslash in string literals as
+	// escape character like MySQL does. Otherwise the literals follow the SQL
+	// standard where only a quote gets doubled.
+	backslashEscapes bool
+}
+
+func (d ansiDialect) Name() string { return d.name }
+
+func (ansiDialect) QuoteIdent(ident string) string {
+	return `"` + strings.Replace(ident, `"`, `""`, -1) + `"`
+}
+
+func (d ansiDialect) Placeholder(pos int) string {
+	if d.numbered {
+		return "$" + strconv.Itoa(pos)
+	}
+	return "?"
+}
+
+func (ansiDialect) LimitOffset(limitValid bool, limit uint64, offsetValid bool, offset uint64) string {
+	return limitOffsetClause(limitValid, limit, offsetValid, offset)
+}
+
+func limitOffsetClause(limitValid bool, limit uint64, offsetValid bool, offset uint64) string {
+	var buf [64]byte
+	b := buf[:0]
+	if limitValid {
+		b = append(b, " LIMIT "...)
+		b = strconv.AppendUint(b, limit, 10)
+	}
+	if offsetValid {
+		b = append(b, " OFFSET "...)
+		b = strconv.AppendUint(b, offset, 10)
+	}
+	return string(b)
+}
+
+// Rebind rewrites a query generated in MySQL syntax into the syntax of the
+// provided Dialect. Back tick quoted identifiers get quoted with
+// Dialect.QuoteIdent, question mark place holders get replaced by
+// Dialect.Placeholder and double quoted string literals become single quoted.
+// For dialects like Postgres, where the backslash is no escape character, the
+// MySQL escape sequences of the string literals get decoded and the literals
+// written in SQL standard syntax. Otherwise the interpolated value
+//		'\' OR 1=1 --'
+// would end after the backslash. Comments are copied unchanged. A nil Dialect
+// or DialectMySQL returns the query unchanged.
+func Rebind(d Dialect, query string) string {
+	if d == nil {
+		return query
+	}
+	if _, ok := d.(mysqlDialect); ok {
+		return query
+	}
+	backslashEscapes := false
+	if ad, ok := d.(ansiDialect); ok {
+		backslashEscapes = ad.backslashEscapes
+	}
+
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+
+	pos := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; c {
+		case '\'':
+			j, closed := endOfQuoted(query, i+1, c)
+			if backslashEscapes {
+				buf.WriteString(query[i:j])
+			} else {
+				inner := query[i+1 : j]
+				if closed {
+					inner = query[i+1 : j-1]
+				}
+				writeStandardLiteral(buf, inner, c)
+			}
+			i = j - 1
+		case '"':
+			j, closed := endOfQuoted(query, i+1, c)
+			inner := query[i+1 : j]
+			if closed {
+				inner = query[i+1 : j-1]
+			}
+			if !backslashEscapes {
+				writeStandardLiteral(buf, inner, c)
+				i = j - 1
+				continue
+			}
+			buf.WriteByte('\'')
+			for k := 0; k < len(inner); k++ {
+				switch {
+				case inner[k] == '\\' && k+1 < len(inner):
+					buf.WriteString(inner[k : k+2])
+					k++
+				case inner[k] == '"' && k+1 < len(inner) && inner[k+1] == '"':
+					buf.WriteByte('"')
+					k++
+				case inner[k] == '\'':
+					buf.WriteString("''")
+				default:
+					buf.WriteByte(inner[k])
+				}
+			}
+			buf.WriteByte('\'')
+			i = j - 1
+		case '`':
+			j, closed := endOfQuoted(query, i+1, c)
+			inner := query[i+1 : j]
+			if closed {
+				inner = query[i+1 : j-1]
+			}
+			buf.WriteString(d.QuoteIdent(strings.Replace(inner, "``", "`", -1)))
+			i = j - 1
+		case '?':
+			pos++
+			buf.WriteString(d.Placeholder(pos))
+		case '/':
+			if i+1 < len(query) && query[i+1] == '*' {
+				j := strings.Index(query[i+2:], "*/")
+				if j < 0 {
+					buf.WriteString(query[i:])
+					return buf.String()
+				}
+				j += i + 4
+				buf.WriteString(query[i:j])
+				i = j - 1
+				continue
+			}
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// endOfQuoted returns the index after the closing quote character q, starting
+// the search at position start. Doubled quote characters and, except for back
+// ticks, back slash escapes get skipped. Returns len(s) and false for an
+// unterminated quote.
+// writeStandardLiteral decodes the MySQL string literal inner, quoted with q,
+// and writes it as a single quoted literal of the SQL standard where only the
+// quote gets doubled.
+func writeStandardLiteral(buf *bytes.Buffer, inner string, q byte) {
+	buf.WriteByte('\'')
+	for k := 0; k < len(inner); k++ {
+		c := inner[k]
+		switch {
+		case c == '\\' && k+1 < len(inner):
+			k++
+			switch e := inner[k]; e {
+			case '0':
+				c = 0
+			case 'b':
+				c = '\b'
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'Z':
+				c = 0x1a
+			case '%', '_':
+				// MySQL keeps the backslash for LIKE patterns
+				buf.WriteByte('\\')
+				c = e
+			default:
+				c = e
+			}
+		case c == q && k+1 < len(inner) && inner[k+1] == q:
+			k++
+		}
+		if c == '\'' {
+			buf.WriteString("''")
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	buf.WriteByte('\'')
+}
+
+func endOfQuoted(s string, start int, q byte) (int, bool) {
+	for j := start; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if q != '`' {
+				j++
+			}
+		case q:
+			if j+1 < len(s) && s[j+1] == q {
+				j++
+				continue
+			}
+			return j + 1, true
+		}
+	}
+	return len(s), false
+}
@@ -0,0 +1,175 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebind(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		dialect dbr.Dialect
+		have    string
+		want    string
+	}{
+		{nil, "SELECT `a` FROM `b` WHERE (`c` = ?)", "SELECT `a` FROM `b` WHERE (`c` = ?)"},
+		{dbr.DialectMySQL, "SELECT `a` FROM `b` WHERE (`c` = ?)", "SELECT `a` FROM `b` WHERE (`c` = ?)"},
+		{dbr.DialectMySQLANSI, "SELECT `a` FROM `b`.`c` WHERE (`c` = ?)", `SELECT "a" FROM "b"."c" WHERE ("c" = ?)`},
+		{dbr.DialectPostgres, "SELECT `a` FROM `b` WHERE (`c` = ?) AND (`d` IN (?,?))", `SELECT "a" FROM "b" WHERE ("c" = $1) AND ("d" IN ($2,$3))`},
+		{dbr.DialectPostgres, "SELECT 'it''s `x` ?', 'a\\'?' FROM `t`", `SELECT 'it''s ` + "`x`" + ` ?', 'a''?' FROM "t"`},
+		{dbr.DialectPostgres, "SELECT \"he said 'hi'\" FROM `t`", `SELECT 'he said ''hi''' FROM "t"`},
+		{dbr.DialectPostgres, `SELECT '\\\' OR 1=1 -- ', "a\"b\n", 'c\%'`, `SELECT '\'' OR 1=1 -- ', 'a"b` + "\n" + `', 'c\%'`},
+		{dbr.DialectMySQLANSI, "SELECT 'a\\'?'", "SELECT 'a\\'?'"},
+		{dbr.DialectPostgres, "/* `x` ? */ SELECT `a``b`, `c\"d`", `/* ` + "`x`" + ` ? */ SELECT "a` + "`" + `b", "c""d"`},
+		{dbr.DialectMySQLANSI, "SELECT `unterminated", `SELECT "unterminated"`},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, dbr.Rebind(test.dialect, test.have), "Index %d", i)
+	}
+}
+
+func TestDialect_Names(t *testing.T) {
+	t.Parallel()
+	assert.Exactly(t, "mysql", dbr.DialectMySQL.Name())
+	assert.Exactly(t, "mysql_ansi", dbr.DialectMySQLANSI.Name())
+	assert.Exactly(t, "postgres", dbr.DialectPostgres.Name())
+	assert.Exactly(t, "$3", dbr.DialectPostgres.Placeholder(3))
+	assert.Exactly(t, "?", dbr.DialectMySQLANSI.Placeholder(3))
+	assert.Exactly(t, " LIMIT 5 OFFSET 10", dbr.DialectPostgres.LimitOffset(true, 5, true, 10))
+	assert.Exactly(t, "", dbr.DialectMySQL.LimitOffset(false, 5, false, 10))
+}
+
+func TestWithDialect(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil dialect", func(t *testing.T) {
+		c, err := dbr.NewConnection(dbr.WithDialect(nil))
+		assert.Nil(t, c)
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+	})
+
+	c, err := dbr.NewConnection(dbr.WithDialect(dbr.DialectPostgres))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	t.Run("Select", func(t *testing.T) {
+		sqlStr, args, err := c.Select("a", "b").From("tableA", "t").
+			Where(dbr.Condition("a", dbr.ArgInt64(1)), dbr.Condition("b", dbr.ArgString("x"))).
+			OrderBy("a").Limit(10).ToSQL()
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, `SELECT a, b FROM "tableA" AS "t" WHERE ("a" = $1) AND ("b" = $2) ORDER BY a LIMIT 10`, sqlStr)
+		assert.Exactly(t, []interface{}{int64(1), "x"}, args.Interfaces())
+	})
+	t.Run("Insert", func(t *testing.T) {
+		sqlStr, _, err := c.InsertInto("tableA").AddColumns("a", "b").
+			AddValues(dbr.ArgInt64(1), dbr.ArgString("it's")).ToSQL()
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, `INSERT INTO "tableA" ("a","b") VALUES ($1,$2)`, sqlStr)
+	})
+	t.Run("Update", func(t *testing.T) {
+		sqlStr, _, err := c.Update("tableA").Set("a", dbr.ArgInt64(1)).
+			Where(dbr.Condition("b", dbr.ArgInt64(2))).ToSQL()
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, `UPDATE "tableA" SET "a"=$1 WHERE ("b" = $2)`, sqlStr)
+	})
+	t.Run("Delete", func(t *testing.T) {
+		sqlStr, _, err := c.DeleteFrom("tableA").Where(dbr.Condition("b", dbr.ArgInt64(2))).ToSQL()
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, `DELETE FROM "tableA" WHERE ("b" = $1)`, sqlStr)
+	})
+	t.Run("String interpolates", func(t *testing.T) {
+		sel := dbr.NewSelect("a").From("tableA").Where(dbr.Condition("b", dbr.ArgString("it's")))
+		sel.Dialect = dbr.DialectMySQLANSI
+		assert.Exactly(t, `SELECT a FROM "tableA" WHERE ("b" = 'it\'s')`, sel.String())
+	})
+	t.Run("Postgres hostile string", func(t *testing.T) {
+		tests := []struct {
+			value string
+			want  string
+		}{
+			{`' OR 1=1 --`, `SELECT a FROM "tableA" WHERE ("b" = ''' OR 1=1 --')`},
+			{`\' OR 1=1 --`, `SELECT a FROM "tableA" WHERE ("b" = '\'' OR 1=1 --')`},
+			{`\`, `SELECT a FROM "tableA" WHERE ("b" = '\')`},
+			{"a\"b\nc", "SELECT a FROM \"tableA\" WHERE (\"b\" = 'a\"b\nc')"},
+		}
+		for i, test := range tests {
+			sel := c.Select("a").From("tableA").Where(dbr.Condition("b", dbr.ArgString(test.value)))
+			assert.Exactly(t, test.want, sel.String(), "Index %d", i)
+		}
+	})
+}
+
+func TestDialect_Exec_Postgres(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+	if err := dbc.Options(dbr.WithDialect(dbr.DialectPostgres)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta(`UPDATE "tableA" SET "a"=''' OR 1=1 --' WHERE ("b" = '\'' OR ''=''')`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := dbc.Update("tableA").Set("a", dbr.ArgString(`' OR 1=1 --`)).
+		Where(dbr.Condition("b", dbr.ArgString(`\' OR '='`))).Exec(context.TODO())
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+}
+
+func TestDialect_Exec(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+	if err := dbc.Options(dbr.WithDialect(dbr.DialectMySQLANSI)); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta(`UPDATE "tableA" SET "a"='x' WHERE ("b" = 2)`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := dbc.Update("tableA").Set("a", dbr.ArgString("x")).
+		Where(dbr.Condition("b", dbr.ArgInt64(2))).Exec(context.TODO())
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+}
@@ -5,3 +5,22 @@ const (
 	errColumnsMissing = "[dbr] no columns or map specified"
 	errRecordsMissing = "[dbr] no values or records specified"
 )
+
+const (
+	errLockingClauses    = "[dbr] Only one locking clause of LOCK IN SHARE MODE, FOR SHARE or FOR UPDATE can be used"
+	errLockingSkipNoWait = "[dbr] SKIP LOCKED and NOWAIT cannot be used together"
+	errLockingModifier   = "[dbr] SKIP LOCKED and NOWAIT require FOR UPDATE or FOR SHARE"
+	errOutfileName       = "[dbr] INTO OUTFILE requires a file name"
+)
+
+const (
+	errNullDecimalParse    = "[dbr] NullDecimal cannot parse %q"
+	errNullDecimalOverflow = "[dbr] NullDecimal %q overflows the precision of an uint64"
+	errNullUintNegative    = "[dbr] Negative value %d cannot be stored in an unsigned type"
+	errNullIntOutOfRange   = "[dbr] Value %d out of range for type %s"
+)
+
+const (
+	errWhereAlias       = "[dbr] WHERE condition %q references the alias %q of the SELECT list. Use Having() or AutoHaving()"
+	errWhereAliasNested = "[dbr] WHERE condition %q references the alias %q but cannot be moved into HAVING because of parenthesis or OR/XOR connected conditions"
+)
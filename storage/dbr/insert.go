@@ -3,6 +3,7 @@ package dbr
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/corestoreio/csfw/util/bufferpool"
 	"github.com/corestoreio/errors"
@@ -12,7 +13,15 @@ import (
 // Insert contains the clauses for an INSERT statement
 type Insert struct {
 	Log log.Logger // Log optional logger
-	DB  struct {
+	// Dialect rewrites the generated SQL for the target database server. Nil
+	// or DialectMySQL keeps the MySQL syntax.
+	Dialect Dialect
+	// TableNameMapper rewrites the table names when generating the SQL, for
+	// example to add a per-tenant prefix. Nil keeps the names unchanged.
+	TableNameMapper TableNameMapper
+	// QueryTimeout cancels the execution after the duration. See Timeout()
+	QueryTimeout time.Duration
+	DB           struct {
 		Preparer
 		Execer
 	}
@@ -29,6 +38,21 @@ type Insert struct {
 	// https://dev.mysql.com/doc/refman/5.7/en/insert-on-duplicate.html
 	OnDuplicateKey UpdatedColumns
 
+	// AutoIncrement describes how the server generates auto increment values.
+	// Only used in ExecMulti.
+	AutoIncrement AutoIncrement
+
+	// IsStrict enables the validation mode. See Strict()
+	IsStrict bool
+	// Statement modifiers, MySQL only. The priority modifiers exclude each
+	// other.
+	IsLowPriority  bool // See LowPriority()
+	IsHighPriority bool // See HighPriority()
+	IsDelayed      bool // See Delayed()
+	IsIgnore       bool // See Ignore()
+	// Comments get rendered as leading C style comments. See Comment()
+	Comments []string
+
 	// Listeners allows to dispatch certain functions in different
 	// situations.
 	Listeners InsertListeners
@@ -54,19 +78,26 @@ func NewInsert(into string) *Insert {
 // InsertInto instantiates a Insert for the given table
 func (c *Connection) InsertInto(into string) *Insert {
 	i := &Insert{
-		Log:  c.Log,
-		Into: into,
+		Log:     c.Log,
+		Dialect: c.Dialect,
+		Into:    into,
+
+		TableNameMapper: c.TableNameMapper,
 	}
-	i.DB.Execer = c.DB
-	i.DB.Preparer = c.DB
+	db := c.dber()
+	i.DB.Execer = db
+	i.DB.Preparer = db
 	return i
 }
 
 // InsertInto instantiates a Insert for the given table bound to a transaction
 func (tx *Tx) InsertInto(into string) *Insert {
 	i := &Insert{
-		Log:  tx.Logger,
-		Into: into,
+		Log:     tx.Logger,
+		Dialect: tx.Dialect,
+		Into:    into,
+
+		TableNameMapper: tx.TableNameMapper,
 	}
 	i.DB.Execer = tx.Tx
 	i.DB.Preparer = tx.Tx
@@ -95,6 +126,16 @@ func (b *Insert) AddRecords(recs ...ArgumentGenerater) *Insert {
 	return b
 }
 
+// AddStructs adds structs or pointers to structs as records. Types which do
+// not implement ArgumentGenerater get wrapped by StructRecord and their
+// arguments get generated via reflection.
+func (b *Insert) AddStructs(structs ...interface{}) *Insert {
+	for _, s := range structs {
+		b.Records = append(b.Records, StructRecord(s))
+	}
+	return b
+}
+
 // AddOnDuplicateKey has some hidden features for best flexibility. You can only
 // set the Columns itself to allow the following SQL construct:
 //		`columnA`=VALUES(`columnA`)
@@ -133,6 +174,59 @@ func (b *Insert) Pair(column string, arg Argument) *Insert {
 	return b
 }
 
+// Comment adds a leading /* comment */ to the statement, see Select.Comment.
+func (b *Insert) Comment(c string) *Insert {
+	b.Comments = append(b.Comments, c)
+	return b
+}
+
+// Timeout cancels the execution of the statement after the duration, see
+// Select.Timeout.
+func (b *Insert) Timeout(d time.Duration) *Insert {
+	b.QueryTimeout = d
+	return b
+}
+
+// Strict enables the validation mode, see Select.Strict. It checks the table
+// and column names and whether the values fit into the columns.
+func (b *Insert) Strict() *Insert {
+	b.IsStrict = true
+	return b
+}
+
+// Ignore ignores errors which occur while executing the INSERT statement.
+// Rows which duplicate an existing UNIQUE index or PRIMARY KEY value get
+// discarded and data conversion errors are reduced to warnings. Renders
+// INSERT IGNORE INTO.
+func (b *Insert) Ignore() *Insert {
+	b.IsIgnore = true
+	return b
+}
+
+// LowPriority delays the execution of the INSERT until no other clients are
+// reading from the table. Affects only storage engines with table-level
+// locking like MyISAM, MEMORY and MERGE.
+func (b *Insert) LowPriority() *Insert {
+	b.IsLowPriority, b.IsHighPriority, b.IsDelayed = true, false, false
+	return b
+}
+
+// HighPriority overrides the effect of the --low-priority-updates option if
+// the server was started with that option. It also causes concurrent inserts
+// not to be used.
+func (b *Insert) HighPriority() *Insert {
+	b.IsLowPriority, b.IsHighPriority, b.IsDelayed = false, true, false
+	return b
+}
+
+// Delayed renders the DELAYED modifier. It is deprecated since MySQL 5.6 and
+// the server handles the statement as a normal INSERT with a warning. Only
+// for legacy setups with MyISAM tables.
+func (b *Insert) Delayed() *Insert {
+	b.IsLowPriority, b.IsHighPriority, b.IsDelayed = false, false, true
+	return b
+}
+
 // FromSelect creates an "INSERT INTO `table` SELECT ..." statement from a
 // previously created SELECT statement.
 func (b *Insert) FromSelect(s *Select) (string, Arguments, error) {
@@ -148,7 +242,7 @@ func (b *Insert) FromSelect(s *Select) (string, Arguments, error) {
 		return "", nil, errors.NewEmptyf(errTableMissing)
 	}
 
-	sSQL, sArgs, err := s.ToSQL()
+	sSQL, sArgs, err := s.inherit(b.TableNameMapper, b.Dialect).rawSQL()
 	if err != nil {
 		return "", nil, errors.Wrap(err, "[dbr] Insert.FromSelect")
 	}
@@ -156,16 +250,30 @@ func (b *Insert) FromSelect(s *Select) (string, Arguments, error) {
 	var buf = bufferpool.Get()
 	defer bufferpool.Put(buf)
 
-	sqlWriteInsertInto(buf, b.Into)
+	sqlWriteComments(buf, b.Comments)
+	sqlWriteInsertInto(buf, b)
 	buf.WriteByte(' ')
 	buf.WriteString(sSQL)
 
-	return buf.String(), sArgs, nil
+	return Rebind(b.Dialect, buf.String()), sArgs, nil
 }
 
 // ToSQL serialized the Insert to a SQL string
 // It returns the string with placeholders and a slice of query arguments
 func (b *Insert) ToSQL() (string, Arguments, error) {
+	sqlStr, args, err := b.rawSQL()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := resolveValuers(args); err != nil {
+		return "", nil, errors.Wrap(err, "[dbr] Insert.ToSQL.resolveValuers")
+	}
+	return Rebind(b.Dialect, sqlStr), args, nil
+}
+
+// rawSQL generates the statement in MySQL syntax without applying the
+// Dialect.
+func (b *Insert) rawSQL() (string, Arguments, error) {
 	if b.previousError != nil {
 		return "", nil, errors.Wrap(b.previousError, "[dbr] Insert.ToSQL")
 	}
@@ -187,11 +295,17 @@ func (b *Insert) ToSQL() (string, Arguments, error) {
 			return "", nil, errors.NewEmptyf(errColumnsMissing)
 		}
 	}
+	if b.IsStrict {
+		if err := b.validate(); err != nil {
+			return "", nil, errors.Wrap(err, "[dbr] Insert.ToSQL.validate")
+		}
+	}
 
 	var buf = bufferpool.Get()
 	defer bufferpool.Put(buf)
 
-	sqlWriteInsertInto(buf, b.Into)
+	sqlWriteComments(buf, b.Comments)
+	sqlWriteInsertInto(buf, b)
 	buf.WriteString(" (")
 
 	if len(b.Maps) != 0 {
@@ -235,16 +349,14 @@ func (b *Insert) ToSQL() (string, Arguments, error) {
 	}
 
 	for i, rec := range b.Records {
-		a2, err := rec.GenerateArguments(StatementTypeInsert, b.Columns, nil)
+		if i > 0 || len(b.Values) > 0 {
+			buf.WriteRune(',')
+		}
+		a2, err := b.writeRecordPlaceholders(buf, rec, placeholderStr)
 		if err != nil {
 			return "", nil, errors.Wrap(err, "[dbr] Insert.ToSQL.Record")
 		}
-
 		args = append(args, a2...)
-		if i > 0 {
-			buf.WriteRune(',')
-		}
-		buf.WriteString(placeholderStr)
 	}
 
 	if err := b.OnDuplicateKey.writeOnDuplicateKey(buf, &args); err != nil {
@@ -299,7 +411,9 @@ func (b *Insert) mapToSQL(w queryWriter) (Arguments, error) {
 // the first inserted row only. The reason for this at to make it possible to
 // reproduce easily the same INSERT statement against some other server.
 func (b *Insert) Exec(ctx context.Context) (sql.Result, error) {
-	sql, args, err := b.ToSQL()
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
+	sql, args, err := b.rawSQL()
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Insert.Exec.ToSQL")
 	}
@@ -308,6 +422,7 @@ func (b *Insert) Exec(ctx context.Context) (sql.Result, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Insert.Exec.Preprocess")
 	}
+	fullSQL = Rebind(b.Dialect, fullSQL)
 
 	if b.Log != nil && b.Log.IsInfo() {
 		defer log.WhenDone(b.Log).Info("dbr.Insert.Exec.Timing", log.String("sql", fullSQL))
@@ -315,7 +430,7 @@ func (b *Insert) Exec(ctx context.Context) (sql.Result, error) {
 
 	result, err := b.DB.ExecContext(ctx, fullSQL)
 	if err != nil {
-		return result, errors.Wrap(err, "[dbr] Insert.Exec.Exec")
+		return result, wrapMySQLError(err, "[dbr] Insert.Exec.Exec")
 	}
 
 	return result, nil
@@ -323,6 +438,8 @@ func (b *Insert) Exec(ctx context.Context) (sql.Result, error) {
 
 // Prepare creates a prepared statement
 func (b *Insert) Prepare(ctx context.Context) (*sql.Stmt, error) {
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
 	rawSQL, _, err := b.ToSQL() // TODO create a ToSQL version without any arguments
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Insert.Exec.ToSQL")
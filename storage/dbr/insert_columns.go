@@ -0,0 +1,176 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"strings"
+
+	"github.com/corestoreio/errors"
+)
+
+// columnsRecord restricts a record to a subset of the columns of an Insert.
+// The remaining columns of the row get the DEFAULT value.
+type columnsRecord struct {
+	ArgumentGenerater
+	columns []string
+}
+
+// AssignLastInsertID forwards the ID to the wrapped record, if supported.
+func (cr columnsRecord) AssignLastInsertID(id int64) {
+	if a, ok := cr.ArgumentGenerater.(LastInsertIDAssigner); ok {
+		a.AssignLastInsertID(id)
+	}
+}
+
+// has reports whether the record provides a value for column c.
+func (cr columnsRecord) has(c string) bool {
+	return containsString(cr.columns, c)
+}
+
+// present returns the insert columns, in the order of the Insert, for which
+// the record provides a value.
+func (cr columnsRecord) present(insertColumns []string) []string {
+	cols := make([]string, 0, len(cr.columns))
+	for _, c := range insertColumns {
+		if cr.has(c) {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// AddRecordsColumns adds records which provide only the values for the
+// given subset of columns. Use case: imports where optional columns vary per
+// row. Columns not yet known get appended to the Insert columns. All other
+// columns of such a row get written as DEFAULT into the VALUES clause:
+//		INSERT INTO `customer` (`email`,`firstname`,`dob`) VALUES (?,?,?),(?,DEFAULT,DEFAULT)
+// Adding a new column after values have been added via AddValues would
+// shift those values, hence it results in a NotValid error. Use
+// SplitByColumns to create one statement per distinct column set instead of
+// DEFAULT values.
+func (b *Insert) AddRecordsColumns(columns []string, recs ...ArgumentGenerater) *Insert {
+	if b.previousError != nil {
+		return b
+	}
+	for _, c := range columns {
+		if containsString(b.Columns, c) {
+			continue
+		}
+		if len(b.Values) > 0 {
+			b.previousError = errors.NewNotValidf("[dbr] Insert.AddRecordsColumns: Column %q cannot be added after AddValues for table %q", c, b.Into)
+			return b
+		}
+		b.Columns = append(b.Columns, c)
+	}
+	for _, rec := range recs {
+		b.Records = append(b.Records, columnsRecord{ArgumentGenerater: rec, columns: columns})
+	}
+	return b
+}
+
+// writeRecordPlaceholders writes the value set for one record and returns its
+// arguments. Records added via AddRecordsColumns get a DEFAULT for each
+// missing column.
+func (b *Insert) writeRecordPlaceholders(w queryWriter, rec ArgumentGenerater, placeholderStr string) (Arguments, error) {
+	cr, ok := rec.(columnsRecord)
+	if !ok {
+		w.WriteString(placeholderStr)
+		return rec.GenerateArguments(StatementTypeInsert, b.Columns, nil)
+	}
+
+	cols := cr.present(b.Columns)
+	args, err := cr.GenerateArguments(StatementTypeInsert, cols, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != len(cols) {
+		return nil, errors.NewNotValidf("[dbr] Insert: Record returned %d arguments for the %d columns %v", len(args), len(cols), cols)
+	}
+	w.WriteRune('(')
+	for i, c := range b.Columns {
+		if i > 0 {
+			w.WriteRune(',')
+		}
+		if cr.has(c) {
+			w.WriteRune('?')
+		} else {
+			w.WriteString("DEFAULT")
+		}
+	}
+	w.WriteRune(')')
+	return args, nil
+}
+
+// SplitByColumns creates one Insert per distinct set of columns, in the order
+// the sets occur. Records added via AddRecordsColumns get grouped by their
+// columns and unwrapped, all other records and the values of AddValues use
+// all columns. The new Inserts share the DB connection, the comments, the
+// ON DUPLICATE KEY clause and the listeners. Use it when DEFAULT values are
+// not desired, e.g. because a column has no default value and must not be
+// touched by an ON DUPLICATE KEY UPDATE. Execute the returned statements in
+// a transaction to keep the import atomic.
+func (b *Insert) SplitByColumns() ([]*Insert, error) {
+	if b.previousError != nil {
+		return nil, errors.Wrap(b.previousError, "[dbr] Insert.SplitByColumns")
+	}
+
+	var inserts []*Insert
+	idx := make(map[string]int)
+	get := func(cols []string) *Insert {
+		key := strings.Join(cols, "\x00")
+		if i, ok := idx[key]; ok {
+			return inserts[i]
+		}
+		ni := &Insert{
+			Log:            b.Log,
+			DB:             b.DB,
+			Into:           b.Into,
+			Columns:        cols,
+			OnDuplicateKey: b.OnDuplicateKey,
+			AutoIncrement:  b.AutoIncrement,
+			IsStrict:       b.IsStrict,
+			Comments:       b.Comments,
+			Listeners:      b.Listeners,
+
+			TableNameMapper: b.TableNameMapper,
+		}
+		idx[key] = len(inserts)
+		inserts = append(inserts, ni)
+		return ni
+	}
+
+	if len(b.Values) > 0 {
+		get(b.Columns).Values = b.Values
+	}
+	for _, rec := range b.Records {
+		if cr, ok := rec.(columnsRecord); ok {
+			ni := get(cr.present(b.Columns))
+			ni.Records = append(ni.Records, cr.ArgumentGenerater)
+			continue
+		}
+		ni := get(b.Columns)
+		ni.Records = append(ni.Records, rec)
+	}
+	return inserts, nil
+}
+
+func containsString(sl []string, s string) bool {
+	for _, v := range sl {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"context"
+
+	"github.com/corestoreio/errors"
+)
+
+// InnoDB auto increment lock modes. See variable innodb_autoinc_lock_mode.
+// https://dev.mysql.com/doc/refman/5.7/en/innodb-auto-increment-handling.html
+const (
+	AutoIncLockModeTraditional int = 0
+	AutoIncLockModeConsecutive int = 1
+	AutoIncLockModeInterleaved int = 2
+)
+
+// AutoIncrement contains the server settings which define how auto increment
+// values get generated for a multi row INSERT. The zero value represents the
+// MySQL 5.7 defaults: consecutive lock mode and an increment of one. Use
+// LoadAutoIncrement to query the current settings of a server.
+type AutoIncrement struct {
+	// LockMode value of variable innodb_autoinc_lock_mode.
+	LockMode int
+	// Increment value of variable auto_increment_increment. Zero gets
+	// treated as one.
+	Increment int64
+}
+
+// LoadAutoIncrement queries the server variables innodb_autoinc_lock_mode
+// and auto_increment_increment.
+func LoadAutoIncrement(ctx context.Context, db QueryRower) (AutoIncrement, error) {
+	var ai AutoIncrement
+	err := db.QueryRowContext(ctx, "SELECT @@innodb_autoinc_lock_mode, @@auto_increment_increment").Scan(&ai.LockMode, &ai.Increment)
+	return ai, errors.Wrap(err, "[dbr] LoadAutoIncrement.QueryRowContext")
+}
+
+// LastInsertIDAssigner assigns the auto increment value generated by the
+// database to a record. Records added via AddRecords which implement this
+// interface receive their ID in Insert.ExecMulti.
+type LastInsertIDAssigner interface {
+	AssignLastInsertID(id int64)
+}
+
+// InsertedIDs describes the range of auto increment values generated by a
+// multi row INSERT statement. The IDs are First, First+Increment, ... up to
+// Count values.
+type InsertedIDs struct {
+	First     int64
+	Count     int64
+	Increment int64
+}
+
+// Last returns the ID of the last inserted row or zero if no row has been
+// inserted.
+func (ids InsertedIDs) Last() int64 {
+	if ids.Count == 0 {
+		return 0
+	}
+	return ids.At(int(ids.Count - 1))
+}
+
+// At returns the ID of the row at position i, starting at zero. It does not
+// check the bounds.
+func (ids InsertedIDs) At(i int) int64 {
+	return ids.First + int64(i)*ids.Increment
+}
+
+// Slice returns all IDs.
+func (ids InsertedIDs) Slice() []int64 {
+	ret := make([]int64, ids.Count)
+	for i := range ret {
+		ret[i] = ids.At(i)
+	}
+	return ret
+}
+
+// rowCount returns the number of value sets in the VALUES clause.
+func (b *Insert) rowCount() int {
+	if len(b.Maps) > 0 {
+		return 1
+	}
+	var n int
+	if lc := len(b.Columns); lc > 0 {
+		n = (len(b.Values) + lc - 1) / lc
+	}
+	return n + len(b.Records)
+}
+
+// ExecMulti executes a multi row INSERT and returns the range of the
+// generated auto increment IDs. LAST_INSERT_ID() returns only the ID of the
+// first inserted row, the others get calculated from the affected rows and
+// the AutoIncrement settings. Records which implement the interface
+// LastInsertIDAssigner get their ID assigned in the order they have been
+// added. Rows from AddValues come first in the VALUES clause.
+//
+// The calculation is only reliable if the server generates consecutive
+// values. Hence the interleaved lock mode and ON DUPLICATE KEY UPDATE return a
+// NotSupported error and a mismatch between the affected rows and the number
+// of value sets returns a NotValid error.
+func (b *Insert) ExecMulti(ctx context.Context) (InsertedIDs, error) {
+	if b.AutoIncrement.LockMode == AutoIncLockModeInterleaved {
+		return InsertedIDs{}, errors.NewNotSupportedf("[dbr] Insert.ExecMulti: innodb_autoinc_lock_mode %d does not generate consecutive IDs", b.AutoIncrement.LockMode)
+	}
+	if len(b.OnDuplicateKey.Columns) > 0 {
+		return InsertedIDs{}, errors.NewNotSupportedf("[dbr] Insert.ExecMulti: ON DUPLICATE KEY UPDATE does not generate consecutive IDs for table %q", b.Into)
+	}
+
+	res, err := b.Exec(ctx)
+	if err != nil {
+		return InsertedIDs{}, errors.Wrap(err, "[dbr] Insert.ExecMulti.Exec")
+	}
+	first, err := res.LastInsertId()
+	if err != nil {
+		return InsertedIDs{}, errors.Wrap(err, "[dbr] Insert.ExecMulti.LastInsertId")
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return InsertedIDs{}, errors.Wrap(err, "[dbr] Insert.ExecMulti.RowsAffected")
+	}
+	if rc := int64(b.rowCount()); affected != rc {
+		return InsertedIDs{}, errors.NewNotValidf("[dbr] Insert.ExecMulti: Affected rows %d do not match the inserted rows %d for table %q", affected, rc, b.Into)
+	}
+
+	ids := InsertedIDs{
+		First:     first,
+		Count:     affected,
+		Increment: b.AutoIncrement.Increment,
+	}
+	if ids.Increment < 1 {
+		ids.Increment = 1
+	}
+
+	offset := int(affected) - len(b.Records)
+	for i, rec := range b.Records {
+		if a, ok := rec.(LastInsertIDAssigner); ok {
+			a.AssignLastInsertID(ids.At(offset + i))
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ dbr.LastInsertIDAssigner = (*insertIDRecord)(nil)
+
+type insertIDRecord struct {
+	ID  int64
+	Sku string
+}
+
+func (r *insertIDRecord) GenerateArguments(statementType byte, columns, condition []string) (dbr.Arguments, error) {
+	return dbr.Arguments{dbr.ArgString(r.Sku)}, nil
+}
+
+func (r *insertIDRecord) AssignLastInsertID(id int64) {
+	r.ID = id
+}
+
+func TestInsertedIDs(t *testing.T) {
+	t.Parallel()
+	ids := dbr.InsertedIDs{First: 11, Count: 3, Increment: 2}
+	assert.Exactly(t, []int64{11, 13, 15}, ids.Slice())
+	assert.Exactly(t, int64(15), ids.Last())
+	assert.Exactly(t, int64(0), dbr.InsertedIDs{}.Last())
+}
+
+func TestInsert_ExecMulti(t *testing.T) {
+
+	t.Run("Records and Values", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Error("there were unfulfilled expections", err)
+			}
+		}()
+
+		dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("INSERT INTO `catalog_product_entity` (`sku`) VALUES ('a'),('b'),('c')")).
+			WillReturnResult(sqlmock.NewResult(100, 3))
+
+		recs := []*insertIDRecord{{Sku: "b"}, {Sku: "c"}}
+		in := dbc.InsertInto("catalog_product_entity").AddColumns("sku").
+			AddValues(dbr.ArgString("a")).
+			AddRecords(recs[0], recs[1])
+		in.AutoIncrement.Increment = 5
+
+		ids, err := in.ExecMulti(context.TODO())
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, []int64{100, 105, 110}, ids.Slice())
+		assert.Exactly(t, int64(105), recs[0].ID)
+		assert.Exactly(t, int64(110), recs[1].ID)
+	})
+
+	t.Run("affected rows mismatch", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Error("there were unfulfilled expections", err)
+			}
+		}()
+
+		dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("INSERT INTO `catalog_product_entity` (`sku`) VALUES ('b'),('c')")).
+			WillReturnResult(sqlmock.NewResult(100, 1))
+
+		rec := &insertIDRecord{Sku: "b"}
+		ids, err := dbc.InsertInto("catalog_product_entity").AddColumns("sku").
+			AddRecords(rec, &insertIDRecord{Sku: "c"}).ExecMulti(context.TODO())
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+		assert.Exactly(t, dbr.InsertedIDs{}, ids)
+		assert.Exactly(t, int64(0), rec.ID)
+	})
+
+	t.Run("interleaved lock mode", func(t *testing.T) {
+		in := dbr.NewInsert("catalog_product_entity").AddColumns("sku").AddValues(dbr.ArgString("a"))
+		in.AutoIncrement.LockMode = dbr.AutoIncLockModeInterleaved
+		_, err := in.ExecMulti(context.TODO())
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+
+	t.Run("on duplicate key", func(t *testing.T) {
+		in := dbr.NewInsert("catalog_product_entity").AddColumns("sku").AddValues(dbr.ArgString("a")).
+			AddOnDuplicateKey("sku", nil)
+		_, err := in.ExecMulti(context.TODO())
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+
+	t.Run("Exec Error", func(t *testing.T) {
+		in := dbr.NewInsert("catalog_product_entity").AddColumns("sku").AddValues(dbr.ArgString("a"))
+		in.DB.Execer = dbMock{
+			error: errors.NewAlreadyClosedf("Who closed myself?"),
+		}
+		_, err := in.ExecMulti(context.TODO())
+		assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
+	})
+}
+
+func TestLoadAutoIncrement(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT @@innodb_autoinc_lock_mode, @@auto_increment_increment")).
+		WillReturnRows(sqlmock.NewRows([]string{"a", "b"}).AddRow("2", "3"))
+
+	ai, err := dbr.LoadAutoIncrement(context.TODO(), dbc.DB)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, dbr.AutoIncrement{LockMode: dbr.AutoIncLockModeInterleaved, Increment: 3}, ai)
+}
@@ -80,6 +80,32 @@ func TestInsertSingleToSQL(t *testing.T) {
 	assert.Equal(t, []interface{}{int64(1), int64(2)}, args.Interfaces())
 }
 
+func TestInsert_Modifiers(t *testing.T) {
+	newIns := func() *Insert {
+		return NewInsert("a").AddColumns("b").AddValues(argInt(1))
+	}
+	tests := []struct {
+		ins     *Insert
+		wantSQL string
+	}{
+		{newIns().Ignore(), "INSERT IGNORE INTO `a` (`b`) VALUES (?)"},
+		{newIns().LowPriority(), "INSERT LOW_PRIORITY INTO `a` (`b`) VALUES (?)"},
+		{newIns().HighPriority().Ignore(), "INSERT HIGH_PRIORITY IGNORE INTO `a` (`b`) VALUES (?)"},
+		{newIns().Delayed(), "INSERT DELAYED INTO `a` (`b`) VALUES (?)"},
+		{newIns().LowPriority().HighPriority(), "INSERT HIGH_PRIORITY INTO `a` (`b`) VALUES (?)"},
+		{newIns().Ignore().Comment("import"), "/* import */ INSERT IGNORE INTO `a` (`b`) VALUES (?)"},
+	}
+	for i, test := range tests {
+		sqlStr, _, err := test.ins.ToSQL()
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantSQL, sqlStr, "Index %d", i)
+	}
+
+	sqlStr, _, err := NewInsert("a").Ignore().FromSelect(NewSelect("b").From("c"))
+	require.NoError(t, err)
+	assert.Exactly(t, "INSERT IGNORE INTO `a` SELECT b FROM `c`", sqlStr)
+}
+
 func TestInsertMultipleToSQL(t *testing.T) {
 	s := createFakeSession()
 
@@ -381,3 +407,96 @@ func TestInsert_FromSelect(t *testing.T) {
 	assert.Exactly(t, []interface{}{int64(1), "wat", int64(1), int64(2), int64(3)}, args.Interfaces())
 
 }
+
+func TestInsert_AddRecordsColumns(t *testing.T) {
+	t.Parallel()
+
+	t.Run("DEFAULT for missing columns", func(t *testing.T) {
+		sqlStr, args, err := NewInsert("a").
+			AddColumns("something_id", "user_id").
+			AddRecords(someRecord{1, 88, false}). // full record, uses all columns
+			AddRecordsColumns([]string{"other", "something_id"}, someRecord{2, 99, true}).
+			AddRecordsColumns([]string{"user_id"}, someRecord{3, 101, true}, someRecord{4, 102, true}).
+			ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "INSERT INTO `a` (`something_id`,`user_id`,`other`) VALUES (?,?,?),(?,DEFAULT,?),(DEFAULT,?,DEFAULT),(DEFAULT,?,DEFAULT)", sqlStr)
+		assert.Exactly(t, []interface{}{int64(1), int64(88), false, int64(2), true, int64(101), int64(102)}, args.Interfaces())
+
+		sqlPre, err := Preprocess(sqlStr, args...)
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "INSERT INTO `a` (`something_id`,`user_id`,`other`) VALUES (1,88,0),(2,DEFAULT,1),(DEFAULT,101,DEFAULT),(DEFAULT,102,DEFAULT)", sqlPre)
+	})
+
+	t.Run("new column after AddValues", func(t *testing.T) {
+		sqlStr, args, err := NewInsert("a").
+			AddColumns("something_id").
+			AddValues(argInt(1)).
+			AddRecordsColumns([]string{"user_id"}, someRecord{3, 101, true}).
+			ToSQL()
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+		assert.Empty(t, sqlStr)
+		assert.Nil(t, args)
+	})
+
+	t.Run("known column after AddValues", func(t *testing.T) {
+		sqlStr, _, err := NewInsert("a").
+			AddColumns("something_id", "user_id").
+			AddValues(argInt(1), argInt(2)).
+			AddRecordsColumns([]string{"user_id"}, someRecord{3, 101, true}).
+			ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "INSERT INTO `a` (`something_id`,`user_id`) VALUES (?,?),(DEFAULT,?)", sqlStr)
+	})
+
+	t.Run("record returns wrong number of arguments", func(t *testing.T) {
+		_, _, err := NewInsert("a").
+			AddRecordsColumns([]string{"user_id", "other"}, argumentGeneraterFunc(func(byte, []string, []string) (Arguments, error) {
+				return Arguments{argInt(1)}, nil
+			})).
+			ToSQL()
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+}
+
+func TestInsert_SplitByColumns(t *testing.T) {
+	t.Parallel()
+
+	in := NewInsert("a").
+		AddColumns("something_id", "user_id", "other").
+		AddValues(argInt(7), argInt(8), ArgBool(false)).
+		AddRecordsColumns([]string{"user_id"}, someRecord{3, 101, true}).
+		AddRecords(someRecord{1, 88, false}).
+		AddRecordsColumns([]string{"other", "something_id"}, someRecord{2, 99, true}).
+		AddRecordsColumns([]string{"user_id"}, someRecord{4, 102, true}).
+		AddOnDuplicateKey("user_id", nil).
+		Comment("import")
+
+	inserts, err := in.SplitByColumns()
+	require.NoError(t, err, "%+v", err)
+	require.Len(t, inserts, 3)
+
+	want := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{"/* import */ INSERT INTO `a` (`something_id`,`user_id`,`other`) VALUES (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE `user_id`=VALUES(`user_id`)", []interface{}{int64(7), int64(8), false, int64(1), int64(88), false}},
+		{"/* import */ INSERT INTO `a` (`user_id`) VALUES (?),(?) ON DUPLICATE KEY UPDATE `user_id`=VALUES(`user_id`)", []interface{}{int64(101), int64(102)}},
+		{"/* import */ INSERT INTO `a` (`something_id`,`other`) VALUES (?,?) ON DUPLICATE KEY UPDATE `user_id`=VALUES(`user_id`)", []interface{}{int64(2), true}},
+	}
+	for i, w := range want {
+		sqlStr, args, err := inserts[i].ToSQL()
+		require.NoError(t, err, "Index %d %+v", i, err)
+		assert.Exactly(t, w.sql, sqlStr, "Index %d", i)
+		assert.Exactly(t, w.args, args.Interfaces(), "Index %d", i)
+	}
+
+	_, err = NewInsert("a").AddColumns("x").AddValues(argInt(1)).
+		AddRecordsColumns([]string{"y"}, someRecord{}).SplitByColumns()
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+type argumentGeneraterFunc func(statementType byte, columns, condition []string) (Arguments, error)
+
+func (f argumentGeneraterFunc) GenerateArguments(statementType byte, columns, condition []string) (Arguments, error) {
+	return f(statementType, columns, condition)
+}
@@ -27,6 +27,9 @@ func Repeat(sql string, args ...Argument) (string, []interface{}, error) {
 	if want := len(args); markCount != want || want == 0 {
 		return "", nil, errors.NewMismatchf("[dbr] Repeat: Number of %s:%d do not match the number of repetitions: %d", qMarkStr, markCount, want)
 	}
+	if err := resolveValuers(args); err != nil {
+		return "", nil, errors.Wrap(err, "[dbr] Repeat.resolveValuers")
+	}
 
 	retArgs := make([]interface{}, 0, len(args)*2)
 
@@ -126,6 +129,9 @@ func Preprocess(sql string, args ...Argument) (string, error) {
 			pos += p + 1
 		case r == '[':
 			w := strings.IndexRune(sql[pos:], ']')
+			if w == -1 {
+				return "", errors.NewNotValidf("[dbr] Preprocess: Invalid syntax, missing closing bracket")
+			}
 			col := sql[pos : pos+w]
 			dialect.EscapeIdent(buf, col)
 			pos += w + 1 // size of ']'
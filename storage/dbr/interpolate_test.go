@@ -372,6 +372,7 @@ func TestPreprocess(t *testing.T) {
 			"SELECT * FROM `user` WHERE `name` = '[nick]'", nil},
 		{`SELECT * FROM [user] WHERE [name] = "nick[]"`, Arguments{ArgString()},
 			"SELECT * FROM `user` WHERE `name` = 'nick[]'", nil},
+		{"SELECT * FROM [user", Arguments{ArgString()}, "", errors.IsNotValid},
 	}
 
 	for i, test := range tests {
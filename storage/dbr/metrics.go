@@ -0,0 +1,334 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+)
+
+// MetricsDefaultNamespace gets used by NewMetrics when the namespace argument
+// is empty.
+const MetricsDefaultNamespace = "dbr"
+
+// List of statement kinds for which Metrics collects separate counters.
+const (
+	StatementSelect  = "select"
+	StatementInsert  = "insert"
+	StatementUpdate  = "update"
+	StatementDelete  = "delete"
+	StatementReplace = "replace"
+	StatementPrepare = "prepare"
+	StatementOther   = "other"
+)
+
+var statementKinds = [...]string{
+	StatementSelect, StatementInsert, StatementUpdate, StatementDelete,
+	StatementReplace, StatementPrepare, StatementOther,
+}
+
+const (
+	kindSelect = iota
+	kindInsert
+	kindUpdate
+	kindDelete
+	kindReplace
+	kindPrepare
+	kindOther
+	kindMax
+)
+
+// DBStatser gets implemented by *sql.DB and returns the connection pool
+// statistics.
+type DBStatser interface {
+	Stats() sql.DBStats
+}
+
+// Metrics collects query counts, durations and errors per statement kind and
+// the pool statistics of the watched connections. The counters get fed by the
+// DBer returned from Instrument. A Connection created with WithMetrics
+// instruments all its builders automatically. The csdb package accepts the
+// dbr interfaces, so passing an instrumented DBer to its functions feeds the
+// same collector.
+//
+// Metrics implements the expvar.Var interface and can be published via
+// Register under its Namespace. All methods are thread safe.
+type Metrics struct {
+	// Namespace under which Register publishes the metrics.
+	Namespace string
+	// Log, if set, receives an Info entry for each query which runs longer
+	// than SlowQuery. Debug enabled loggers receive all queries.
+	Log log.Logger
+	// SlowQuery defines the threshold for logging slow queries. Zero
+	// disables logging of slow queries.
+	SlowQuery time.Duration
+
+	stmts [kindMax]queryCounter
+
+	mu    sync.RWMutex
+	pools map[string]DBStatser
+}
+
+type queryCounter struct {
+	queries  uint64
+	errors   uint64
+	nanos    uint64
+	maxNanos uint64
+}
+
+func (qc *queryCounter) add(d time.Duration, err error) {
+	atomic.AddUint64(&qc.queries, 1)
+	if err != nil {
+		atomic.AddUint64(&qc.errors, 1)
+	}
+	n := uint64(d)
+	atomic.AddUint64(&qc.nanos, n)
+	for {
+		max := atomic.LoadUint64(&qc.maxNanos)
+		if n <= max || atomic.CompareAndSwapUint64(&qc.maxNanos, max, n) {
+			return
+		}
+	}
+}
+
+func (qc *queryCounter) stats() QueryStats {
+	return QueryStats{
+		Queries:     atomic.LoadUint64(&qc.queries),
+		Errors:      atomic.LoadUint64(&qc.errors),
+		Duration:    time.Duration(atomic.LoadUint64(&qc.nanos)),
+		MaxDuration: time.Duration(atomic.LoadUint64(&qc.maxNanos)),
+	}
+}
+
+// QueryStats contains the collected metrics of one statement kind.
+type QueryStats struct {
+	Queries uint64
+	Errors  uint64
+	// Duration total time spent in the database.
+	Duration time.Duration
+	// MaxDuration of the slowest query.
+	MaxDuration time.Duration
+}
+
+// ErrorRate returns the ratio of failed queries between 0 and 1.
+func (qs QueryStats) ErrorRate() float64 {
+	if qs.Queries == 0 {
+		return 0
+	}
+	return float64(qs.Errors) / float64(qs.Queries)
+}
+
+// AvgDuration returns the average duration of a query.
+func (qs QueryStats) AvgDuration() time.Duration {
+	if qs.Queries == 0 {
+		return 0
+	}
+	return qs.Duration / time.Duration(qs.Queries)
+}
+
+// NewMetrics creates a new collector. An empty namespace falls back to
+// MetricsDefaultNamespace.
+func NewMetrics(namespace string) *Metrics {
+	if namespace == "" {
+		namespace = MetricsDefaultNamespace
+	}
+	return &Metrics{
+		Namespace: namespace,
+		pools:     make(map[string]DBStatser),
+	}
+}
+
+// Register publishes the metrics via expvar under the Namespace. Returns an
+// AlreadyExists error if the namespace has already been taken, because
+// expvar.Publish would panic.
+func (m *Metrics) Register() error {
+	if expvar.Get(m.Namespace) != nil {
+		return errors.NewAlreadyExistsf("[dbr] Metrics.Register: Namespace %q already registered", m.Namespace)
+	}
+	expvar.Publish(m.Namespace, m)
+	return nil
+}
+
+// WatchPool adds a connection pool whose sql.DBStats get exported under the
+// provided name. An existing entry with the same name gets replaced.
+func (m *Metrics) WatchPool(name string, db DBStatser) {
+	m.mu.Lock()
+	if m.pools == nil {
+		m.pools = make(map[string]DBStatser)
+	}
+	m.pools[name] = db
+	m.mu.Unlock()
+}
+
+// Observe records a query. Mostly used by the instrumented DBer but can be
+// called directly for queries which bypass the dbr interfaces.
+func (m *Metrics) Observe(query string, d time.Duration, err error) {
+	m.observe(statementKind(query), query, d, err)
+}
+
+func (m *Metrics) observe(kind int, query string, d time.Duration, err error) {
+	m.stmts[kind].add(d, err)
+	if m.Log == nil {
+		return
+	}
+	switch {
+	case m.SlowQuery > 0 && d >= m.SlowQuery && m.Log.IsInfo():
+		m.Log.Info("dbr.Metrics.SlowQuery", log.String("sql", query), log.Duration("duration", d), log.Err(err))
+	case m.Log.IsDebug():
+		m.Log.Debug("dbr.Metrics.Query", log.String("sql", query), log.Duration("duration", d), log.Err(err))
+	}
+}
+
+// Stats returns the collected metrics of a statement kind. See the Statement*
+// constants. Unknown kinds return empty stats.
+func (m *Metrics) Stats(kind string) QueryStats {
+	for i, k := range statementKinds {
+		if k == kind {
+			return m.stmts[i].stats()
+		}
+	}
+	return QueryStats{}
+}
+
+// PoolStats returns the current statistics of all watched connection pools.
+func (m *Metrics) PoolStats() map[string]sql.DBStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ps := make(map[string]sql.DBStats, len(m.pools))
+	for name, db := range m.pools {
+		ps[name] = db.Stats()
+	}
+	return ps
+}
+
+// String implements the expvar.Var interface and returns the metrics as JSON.
+// Durations are in seconds.
+func (m *Metrics) String() string {
+	var buf bytes.Buffer
+	buf.WriteString(`{"statements":{`)
+	for i, k := range statementKinds {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		st := m.stmts[i].stats()
+		fmt.Fprintf(&buf, `%q:{"queries":%d,"errors":%d,"error_rate":%.4f,"duration_seconds":%.6f,"max_duration_seconds":%.6f}`,
+			k, st.Queries, st.Errors, st.ErrorRate(), st.Duration.Seconds(), st.MaxDuration.Seconds())
+	}
+	buf.WriteString(`},"pools":{`)
+
+	ps := m.PoolStats()
+	names := make([]string, 0, len(ps))
+	for name := range ps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:", name)
+		js, err := json.Marshal(ps[name])
+		if err != nil {
+			js = []byte("null")
+		}
+		buf.Write(js)
+	}
+	buf.WriteString(`}}`)
+	return buf.String()
+}
+
+// Instrument wraps db and records all queries running through it.
+func (m *Metrics) Instrument(db DBer) DBer {
+	return metricsDB{m: m, db: db}
+}
+
+type metricsDB struct {
+	m  *Metrics
+	db DBer
+}
+
+func (mdb metricsDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	now := time.Now()
+	stmt, err := mdb.db.PrepareContext(ctx, query)
+	mdb.m.observe(kindPrepare, query, time.Since(now), err)
+	return stmt, err
+}
+
+func (mdb metricsDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	now := time.Now()
+	res, err := mdb.db.ExecContext(ctx, query, args...)
+	mdb.m.Observe(query, time.Since(now), err)
+	return res, err
+}
+
+func (mdb metricsDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	now := time.Now()
+	rows, err := mdb.db.QueryContext(ctx, query, args...)
+	mdb.m.Observe(query, time.Since(now), err)
+	return rows, err
+}
+
+// QueryRowContext cannot record errors because they get deferred until Scan.
+func (mdb metricsDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	now := time.Now()
+	row := mdb.db.QueryRowContext(ctx, query, args...)
+	mdb.m.Observe(query, time.Since(now), nil)
+	return row
+}
+
+// statementKind detects the kind of a query by its first keyword. Leading
+// white spaces, comments and opening parentheses get skipped.
+func statementKind(query string) int {
+	for {
+		query = strings.TrimLeft(query, " \t\r\n(")
+		if !strings.HasPrefix(query, "/*") {
+			break
+		}
+		end := strings.Index(query, "*/")
+		if end < 0 {
+			return kindOther
+		}
+		query = query[end+2:]
+	}
+	kw := query
+	if i := strings.IndexAny(kw, " \t\r\n("); i > 0 {
+		kw = kw[:i]
+	}
+	switch strings.ToLower(kw) {
+	case "select", "with", "show", "explain", "describe":
+		return kindSelect
+	case "insert":
+		return kindInsert
+	case "update":
+		return kindUpdate
+	case "delete":
+		return kindDelete
+	case "replace":
+		return kindReplace
+	}
+	return kindOther
+}
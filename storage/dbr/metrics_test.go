@@ -0,0 +1,149 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Observe(t *testing.T) {
+	tests := []struct {
+		query string
+		kind  string
+	}{
+		{"SELECT 1", dbr.StatementSelect},
+		{"  (select a from b) UNION (select c from d)", dbr.StatementSelect},
+		{"/* report */ SELECT /*+ MAX_EXECUTION_TIME(10) */ sku FROM t", dbr.StatementSelect},
+		{"SHOW TABLES", dbr.StatementSelect},
+		{"INSERT INTO t VALUES (1)", dbr.StatementInsert},
+		{"update t SET a=1", dbr.StatementUpdate},
+		{"DELETE LOW_PRIORITY FROM t", dbr.StatementDelete},
+		{"REPLACE INTO t VALUES (1)", dbr.StatementReplace},
+		{"TRUNCATE TABLE t", dbr.StatementOther},
+		{"/* unterminated SELECT", dbr.StatementOther},
+		{"", dbr.StatementOther},
+	}
+	for i, test := range tests {
+		m := dbr.NewMetrics("")
+		m.Observe(test.query, time.Millisecond, nil)
+		assert.Exactly(t, uint64(1), m.Stats(test.kind).Queries, "Index %d", i)
+	}
+}
+
+func TestMetrics_Stats(t *testing.T) {
+	m := dbr.NewMetrics("")
+	assert.Exactly(t, dbr.MetricsDefaultNamespace, m.Namespace)
+
+	m.Observe("SELECT 1", 10*time.Millisecond, nil)
+	m.Observe("SELECT 2", 30*time.Millisecond, errors.New("ups"))
+	m.Observe("SELECT 3", 20*time.Millisecond, nil)
+	m.Observe("SELECT 4", 40*time.Millisecond, errors.New("ups"))
+
+	st := m.Stats(dbr.StatementSelect)
+	assert.Exactly(t, uint64(4), st.Queries)
+	assert.Exactly(t, uint64(2), st.Errors)
+	assert.Exactly(t, 0.5, st.ErrorRate())
+	assert.Exactly(t, 100*time.Millisecond, st.Duration)
+	assert.Exactly(t, 25*time.Millisecond, st.AvgDuration())
+	assert.Exactly(t, 40*time.Millisecond, st.MaxDuration)
+
+	assert.Exactly(t, dbr.QueryStats{}, m.Stats(dbr.StatementInsert))
+	assert.Exactly(t, dbr.QueryStats{}, m.Stats("merge"))
+	assert.Exactly(t, 0.0, dbr.QueryStats{}.ErrorRate())
+	assert.Exactly(t, time.Duration(0), dbr.QueryStats{}.AvgDuration())
+}
+
+func TestMetrics_Log(t *testing.T) {
+	m := dbr.NewMetrics("")
+	m.SlowQuery = 50 * time.Millisecond
+	m.Log = log.BlackHole{EnableInfo: true, EnableDebug: true}
+	m.Observe("SELECT fast", time.Millisecond, nil)
+	m.Observe("SELECT slow", time.Second, errors.New("ups"))
+	assert.Exactly(t, uint64(2), m.Stats(dbr.StatementSelect).Queries)
+}
+
+func TestConnection_WithMetrics(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	m := dbr.NewMetrics("dbr_test_connection")
+	dbc, err := dbr.NewConnection(dbr.WithDB(db), dbr.WithMetrics(m))
+	require.NoError(t, err)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	dbMock.ExpectQuery("SELECT sku FROM `catalog_product_entity`").
+		WillReturnRows(sqlmock.NewRows([]string{"sku"}).AddRow("a").AddRow("b"))
+	dbMock.ExpectQuery("SELECT sku FROM `catalog_product_entity`").
+		WillReturnError(errors.New("Table does not exist"))
+	dbMock.ExpectExec("UPDATE `catalog_product_entity` SET `sku`=").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectExec("DELETE FROM `catalog_product_entity`").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	var skus []string
+	_, err = dbc.Select("sku").From("catalog_product_entity").LoadValues(context.TODO(), &skus)
+	require.NoError(t, err)
+	_, err = dbc.Select("sku").From("catalog_product_entity").LoadValues(context.TODO(), &skus)
+	assert.Error(t, err)
+	_, err = dbc.Update("catalog_product_entity").Set("sku", dbr.ArgString("c")).Exec(context.TODO())
+	require.NoError(t, err)
+	_, err = dbc.DeleteFrom("catalog_product_entity").Exec(context.TODO())
+	require.NoError(t, err)
+
+	sel := m.Stats(dbr.StatementSelect)
+	assert.Exactly(t, uint64(2), sel.Queries)
+	assert.Exactly(t, uint64(1), sel.Errors)
+	assert.Exactly(t, uint64(1), m.Stats(dbr.StatementUpdate).Queries)
+	assert.Exactly(t, uint64(1), m.Stats(dbr.StatementDelete).Queries)
+	assert.Exactly(t, uint64(0), m.Stats(dbr.StatementInsert).Queries)
+
+	assert.Contains(t, m.PoolStats(), "default")
+
+	require.NoError(t, m.Register())
+	assert.True(t, errors.IsAlreadyExists(m.Register()), "Second Register should fail")
+
+	var exp struct {
+		Statements map[string]struct {
+			Queries   uint64  `json:"queries"`
+			Errors    uint64  `json:"errors"`
+			ErrorRate float64 `json:"error_rate"`
+		} `json:"statements"`
+		Pools map[string]json.RawMessage `json:"pools"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(m.String()), &exp), "%s", m.String())
+	assert.Exactly(t, uint64(2), exp.Statements[dbr.StatementSelect].Queries)
+	assert.Exactly(t, 0.5, exp.Statements[dbr.StatementSelect].ErrorRate)
+	assert.Len(t, exp.Statements, 7)
+	assert.Contains(t, exp.Pools, "default")
+}
+
+func TestWithMetrics_Nil(t *testing.T) {
+	_, err := dbr.NewConnection(dbr.WithMetrics(nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"fmt"
+
+	"github.com/corestoreio/errors"
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL server error numbers which get classified by the Exec and Load
+// functions. See https://dev.mysql.com/doc/refman/5.7/en/error-messages-server.html
+const (
+	MySQLErrDupEntry         uint16 = 1062 // ER_DUP_ENTRY
+	MySQLErrLockWaitTimeout  uint16 = 1205 // ER_LOCK_WAIT_TIMEOUT
+	MySQLErrLockDeadlock     uint16 = 1213 // ER_LOCK_DEADLOCK
+	MySQLErrNoReferencedRow  uint16 = 1216 // ER_NO_REFERENCED_ROW
+	MySQLErrRowIsReferenced  uint16 = 1217 // ER_ROW_IS_REFERENCED
+	MySQLErrRowIsReferenced2 uint16 = 1451 // ER_ROW_IS_REFERENCED_2
+	MySQLErrNoReferencedRow2 uint16 = 1452 // ER_NO_REFERENCED_ROW_2
+)
+
+// MySQLErrorNumber returns the error number of the underlying
+// *mysql.MySQLError or 0 if err has not been returned by the MySQL server.
+func MySQLErrorNumber(err error) uint16 {
+	if myErr, ok := errors.Cause(err).(*mysql.MySQLError); ok {
+		return myErr.Number
+	}
+	return 0
+}
+
+// IsDuplicateEntry reports whether a unique or primary key constraint has been
+// violated. Such errors have also the AlreadyExists behaviour.
+func IsDuplicateEntry(err error) bool {
+	return MySQLErrorNumber(err) == MySQLErrDupEntry
+}
+
+// IsDeadlock reports whether the transaction has been rolled back because of a
+// dead lock and can be repeated. Such errors have also the Aborted behaviour.
+func IsDeadlock(err error) bool {
+	return MySQLErrorNumber(err) == MySQLErrLockDeadlock
+}
+
+// IsLockWaitTimeout reports whether the statement waited too long for a row
+// lock. Such errors have also the Timeout behaviour.
+func IsLockWaitTimeout(err error) bool {
+	return MySQLErrorNumber(err) == MySQLErrLockWaitTimeout
+}
+
+// IsFKConstraint reports whether a foreign key constraint has been violated,
+// either by a missing parent row or by a still referenced row. Such errors
+// have also the NotValid behaviour.
+func IsFKConstraint(err error) bool {
+	switch MySQLErrorNumber(err) {
+	case MySQLErrNoReferencedRow, MySQLErrRowIsReferenced, MySQLErrRowIsReferenced2, MySQLErrNoReferencedRow2:
+		return true
+	}
+	return false
+}
+
+// wrapMySQLError wraps err with a message and, if known, with the behaviour
+// matching the MySQL error number. All Exec and Load functions must use it for
+// errors returned by the database.
+func wrapMySQLError(err error, msg string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	switch {
+	case IsDuplicateEntry(err):
+		return errors.NewAlreadyExists(err, msg)
+	case IsDeadlock(err):
+		return errors.NewAborted(err, msg)
+	case IsLockWaitTimeout(err):
+		return errors.NewTimeout(err, msg)
+	case IsFKConstraint(err):
+		return errors.NewNotValid(err, msg)
+	}
+	return errors.Wrap(err, msg)
+}
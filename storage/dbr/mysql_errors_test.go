@@ -0,0 +1,122 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMySQLErrorClassification(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		err          error
+		number       uint16
+		isDup        bool
+		isDeadlock   bool
+		isLockWait   bool
+		isFKConstrnt bool
+	}{
+		{nil, 0, false, false, false, false},
+		{errors.New("Duplicate entry"), 0, false, false, false, false},
+		{&mysql.MySQLError{Number: 1062}, 1062, true, false, false, false},
+		{errors.Wrap(&mysql.MySQLError{Number: 1062}, "wrapped"), 1062, true, false, false, false},
+		{&mysql.MySQLError{Number: 1213}, 1213, false, true, false, false},
+		{&mysql.MySQLError{Number: 1205}, 1205, false, false, true, false},
+		{&mysql.MySQLError{Number: 1216}, 1216, false, false, false, true},
+		{&mysql.MySQLError{Number: 1217}, 1217, false, false, false, true},
+		{&mysql.MySQLError{Number: 1451}, 1451, false, false, false, true},
+		{&mysql.MySQLError{Number: 1452}, 1452, false, false, false, true},
+		{&mysql.MySQLError{Number: 1146}, 1146, false, false, false, false},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.number, dbr.MySQLErrorNumber(test.err), "Index %d", i)
+		assert.Exactly(t, test.isDup, dbr.IsDuplicateEntry(test.err), "Index %d", i)
+		assert.Exactly(t, test.isDeadlock, dbr.IsDeadlock(test.err), "Index %d", i)
+		assert.Exactly(t, test.isLockWait, dbr.IsLockWaitTimeout(test.err), "Index %d", i)
+		assert.Exactly(t, test.isFKConstrnt, dbr.IsFKConstraint(test.err), "Index %d", i)
+	}
+}
+
+func TestMySQLErrorBehaviour(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		if err := dbMock.ExpectationsWereMet(); err != nil {
+			t.Error("there were unfulfilled expections", err)
+		}
+	}()
+
+	t.Run("Insert duplicate entry", func(t *testing.T) {
+		dbMock.ExpectExec("INSERT INTO `catalog_product_entity`").
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'SKU-1' for key 'sku'"})
+
+		_, err := dbc.InsertInto("catalog_product_entity").AddColumns("sku").
+			AddValues(dbr.ArgString("SKU-1")).Exec(context.TODO())
+		assert.True(t, dbr.IsDuplicateEntry(err), "%+v", err)
+		assert.True(t, errors.IsAlreadyExists(err), "%+v", err)
+	})
+
+	t.Run("Update dead lock", func(t *testing.T) {
+		dbMock.ExpectExec("UPDATE `catalog_product_entity`").
+			WillReturnError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+
+		_, err := dbc.Update("catalog_product_entity").Set("sku", dbr.ArgString("SKU-2")).
+			Where(dbr.Condition("entity_id", dbr.ArgInt64(2))).Exec(context.TODO())
+		assert.True(t, dbr.IsDeadlock(err), "%+v", err)
+		assert.True(t, errors.IsAborted(err), "%+v", err)
+		assert.False(t, dbr.IsDuplicateEntry(err), "%+v", err)
+	})
+
+	t.Run("Delete foreign key", func(t *testing.T) {
+		dbMock.ExpectExec("DELETE FROM `store_website`").
+			WillReturnError(&mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row"})
+
+		_, err := dbc.DeleteFrom("store_website").
+			Where(dbr.Condition("website_id", dbr.ArgInt64(1))).Exec(context.TODO())
+		assert.True(t, dbr.IsFKConstraint(err), "%+v", err)
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+
+	t.Run("Select lock wait timeout", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT sku FROM `catalog_product_entity`").
+			WillReturnError(&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"})
+
+		var sku string
+		err := dbc.Select("sku").From("catalog_product_entity").
+			Where(dbr.Condition("entity_id", dbr.ArgInt64(2))).LoadValue(context.TODO(), &sku)
+		assert.True(t, dbr.IsLockWaitTimeout(err), "%+v", err)
+		assert.True(t, errors.IsTimeout(err), "%+v", err)
+	})
+
+	t.Run("Select unclassified", func(t *testing.T) {
+		dbMock.ExpectQuery("SELECT sku FROM `catalog_product_entity`").
+			WillReturnRows(sqlmock.NewRows([]string{"sku"}).AddRow("SKU-1").
+				RowError(0, &mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}))
+
+		var skus []string
+		_, err := dbc.Select("sku").From("catalog_product_entity").LoadValues(context.TODO(), &skus)
+		assert.Exactly(t, uint16(1146), dbr.MySQLErrorNumber(err), "%+v", err)
+		assert.False(t, dbr.IsFKConstraint(err), "%+v", err)
+	})
+}
@@ -102,9 +102,10 @@ func sqlIfNullQuote4(w queryWriter, expressionAlias ...string) {
 //		IF(expr1,expr2,expr3)
 // If expr1 is TRUE (expr1 <> 0 and expr1 <> NULL) then IF() returns expr2;
 // otherwise it returns expr3. IF() returns a numeric or string value, depending
-// on the context in which it is used.
-func SQLIf(expression, true, false string) string {
-	return "IF((" + expression + "), " + true + ", " + false + ")"
+// on the context in which it is used. The arguments get written unchanged, so
+// the result is raw SQL.
+func SQLIf(expression, true, false string) SQLUnsafe {
+	return SQLUnsafe("IF((" + expression + "), " + true + ", " + false + ")")
 }
 
 // SQLCase generates a CASE ... WHEN ... THEN ... ELSE ... END statement.
@@ -112,9 +113,10 @@ func SQLIf(expression, true, false string) string {
 // empty and then won't get written. `compareResult` must be a balanced sliced
 // where index `i` represents the case part and index `i+1` the result.
 // If the slice is imbalanced the function assumes that the last item of compareResult
-// should be printed as an alias.
+// should be printed as an alias. The arguments get written unchanged, so the
+// result is raw SQL.
 // https://dev.mysql.com/doc/refman/5.7/en/control-flow-functions.html#operator_case
-func SQLCase(value, defaultValue string, compareResult ...string) string {
+func SQLCase(value, defaultValue string, compareResult ...string) SQLUnsafe {
 	if len(compareResult) == 1 {
 		return "<SQLCase error len(compareResult) == 1>"
 	}
@@ -146,5 +148,5 @@ func SQLCase(value, defaultValue string, compareResult ...string) string {
 		buf.WriteString(" AS ")
 		Quoter.quote(buf, compareResult[len(compareResult)-1])
 	}
-	return buf.String()
+	return SQLUnsafe(buf.String())
 }
@@ -168,7 +168,7 @@ func BenchmarkIfNull(b *testing.B) {
 }
 
 func TestSQLIf(t *testing.T) {
-	assert.Exactly(t, "IF((c.value_id > 0), c.value, d.value)", dbr.SQLIf("c.value_id > 0", "c.value", "d.value"))
+	assert.Exactly(t, dbr.SQLUnsafe("IF((c.value_id > 0), c.value, d.value)"), dbr.SQLIf("c.value_id > 0", "c.value", "d.value"))
 
 	s := dbr.NewSelect().AddColumnsQuoted("a", "b", "c").
 		From("table1").Where(
@@ -226,7 +226,7 @@ func TestSQLCase(t *testing.T) {
 
 	t.Run("cases", func(t *testing.T) {
 		assert.Exactly(t,
-			"CASE `product_id` WHEN 3456 THEN qty+1 WHEN 3457 THEN qty+4 WHEN 3458 THEN qty-3 ELSE qty END",
+			dbr.SQLUnsafe("CASE `product_id` WHEN 3456 THEN qty+1 WHEN 3457 THEN qty+4 WHEN 3458 THEN qty-3 ELSE qty END"),
 			dbr.SQLCase("`product_id`", "qty",
 				"3456", "qty+1",
 				"3457", "qty+4",
@@ -234,7 +234,7 @@ func TestSQLCase(t *testing.T) {
 			),
 		)
 		assert.Exactly(t,
-			"(CASE `product_id` WHEN 3456 THEN qty WHEN 3457 THEN qty ELSE qty END) AS `product_qty`",
+			dbr.SQLUnsafe("(CASE `product_id` WHEN 3456 THEN qty WHEN 3457 THEN qty ELSE qty END) AS `product_qty`"),
 			dbr.SQLCase("`product_id`", "qty",
 				"3456", "qty",
 				"3457", "qty",
@@ -242,7 +242,7 @@ func TestSQLCase(t *testing.T) {
 			),
 		)
 		assert.Exactly(t,
-			"CASE `product_id` WHEN 3456 THEN qty+1 WHEN 3457 THEN qty+4 WHEN 3458 THEN qty-3 END",
+			dbr.SQLUnsafe("CASE `product_id` WHEN 3456 THEN qty+1 WHEN 3457 THEN qty+4 WHEN 3458 THEN qty-3 END"),
 			dbr.SQLCase("`product_id`", "",
 				"3456", "qty+1",
 				"3457", "qty+4",
@@ -250,14 +250,14 @@ func TestSQLCase(t *testing.T) {
 			),
 		)
 		assert.Exactly(t,
-			"CASE  WHEN 1=1 THEN 2 WHEN 3=2 THEN 4 END",
+			dbr.SQLUnsafe("CASE  WHEN 1=1 THEN 2 WHEN 3=2 THEN 4 END"),
 			dbr.SQLCase("", "",
 				"1=1", "2",
 				"3=2", "4",
 			),
 		)
 		assert.Exactly(t,
-			"<SQLCase error len(compareResult) == 1>",
+			dbr.SQLUnsafe("<SQLCase error len(compareResult) == 1>"),
 			dbr.SQLCase("", "",
 				"1=1",
 			),
@@ -14,10 +14,7 @@
 
 package dbr
 
-import (
-	"fmt"
-	"strconv"
-)
+import "fmt"
 
 // QueryBuilder assembles a query and returns the raw SQL without parameter
 // substitution and the arguments.
@@ -31,8 +28,10 @@ type queryWriter interface {
 	WriteRune(r rune) (n int, err error)
 }
 
-func makeSQL(b QueryBuilder) string {
-	sRaw, vals, err := b.ToSQL()
+// makeSQL interpolates the MySQL query returned by rawSQL and rewrites it
+// afterwards for the dialect d.
+func makeSQL(d Dialect, rawSQL func() (string, Arguments, error)) string {
+	sRaw, vals, err := rawSQL()
 	if err != nil {
 		return fmt.Sprintf("[dbr] ToSQL Error: %+v", err)
 	}
@@ -40,31 +39,31 @@ func makeSQL(b QueryBuilder) string {
 	if err != nil {
 		return fmt.Sprintf("[dbr] Preprocess Error: %+v", err)
 	}
-	return sql
+	return Rebind(d, sql)
 }
 
 // String returns a string representing a preprocessed, interpolated, query.
 // On error, the error gets printed. Fulfills interface fmt.Stringer.
 func (b *Delete) String() string {
-	return makeSQL(b)
+	return makeSQL(b.Dialect, b.rawSQL)
 }
 
 // String returns a string representing a preprocessed, interpolated, query.
 // On error, the error gets printed. Fulfills interface fmt.Stringer.
 func (b *Insert) String() string {
-	return makeSQL(b)
+	return makeSQL(b.Dialect, b.rawSQL)
 }
 
 // String returns a string representing a preprocessed, interpolated, query.
 // On error, the error gets printed. Fulfills interface fmt.Stringer.
 func (b *Select) String() string {
-	return makeSQL(b)
+	return makeSQL(b.Dialect, b.rawSQL)
 }
 
 // String returns a string representing a preprocessed, interpolated, query.
 // On error, the error gets printed. Fulfills interface fmt.Stringer.
 func (b *Update) String() string {
-	return makeSQL(b)
+	return makeSQL(b.Dialect, b.rawSQL)
 }
 
 func sqlWriteUnionAll(w queryWriter, isAll bool) {
@@ -89,22 +88,31 @@ func sqlWriteOrderBy(w queryWriter, orderBys []string, br bool) {
 		if i > 0 {
 			w.WriteString(", ")
 		}
-		w.WriteString(s)
+		w.WriteString(quoteSortIfReserved(s))
 	}
 }
 
-func sqlWriteInsertInto(w queryWriter, into string) {
-	w.WriteString("INSERT INTO ")
-	Quoter.quote(w, into)
+func sqlWriteInsertInto(w queryWriter, b *Insert) {
+	w.WriteString("INSERT ")
+	sqlWriteModifier(w, b.IsLowPriority, "LOW_PRIORITY ")
+	sqlWriteModifier(w, b.IsHighPriority, "HIGH_PRIORITY ")
+	sqlWriteModifier(w, b.IsDelayed, "DELAYED ")
+	sqlWriteModifier(w, b.IsIgnore, "IGNORE ")
+	w.WriteString("INTO ")
+	Quoter.quote(w, b.TableNameMapper.mapTableName(b.Into))
 }
 
-func sqlWriteLimitOffset(w queryWriter, limitValid bool, limitCount uint64, offsetValid bool, offsetCount uint64) {
-	if limitValid {
-		w.WriteString(" LIMIT ")
-		w.WriteString(strconv.FormatUint(limitCount, 10))
+// sqlWriteModifier writes the MySQL statement modifier if enabled. The
+// modifier must contain a trailing white space.
+func sqlWriteModifier(w queryWriter, enabled bool, modifier string) {
+	if enabled {
+		w.WriteString(modifier)
 	}
-	if offsetValid {
-		w.WriteString(" OFFSET ")
-		w.WriteString(strconv.FormatUint(offsetCount, 10))
+}
+
+func sqlWriteLimitOffset(w queryWriter, d Dialect, limitValid bool, limitCount uint64, offsetValid bool, offsetCount uint64) {
+	if d == nil {
+		d = DialectMySQL
 	}
+	w.WriteString(d.LimitOffset(limitValid, limitCount, offsetValid, offsetCount))
 }
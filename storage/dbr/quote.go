@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/errors"
 )
 
 const quote string = "`"
@@ -149,3 +150,78 @@ func (q MysqlQuoter) TableColumnAlias(t string, cols ...string) []string {
 	}
 	return cols
 }
+
+// QuoteQualified quotes all non-empty parts and joins them with a dot. Use it
+// for schema qualified names:
+//		QuoteQualified("dbName", "tableName", "columnName") // `dbName`.`tableName`.`columnName`
+//		QuoteQualified("", "tableName") 			// `tableName`
+func (q MysqlQuoter) QuoteQualified(parts ...string) string {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('.')
+		}
+		q.quote(buf, p)
+	}
+	return buf.String()
+}
+
+// ValidateAndQuote checks that each non-empty part is a valid MySQL identifier
+// and returns the quoted and dot joined parts. Reserved words are valid
+// because they get quoted. At least one part must be non-empty. Returns a
+// NotValid error behaviour.
+//		ValidateAndQuote("dbName", "order") // `dbName`.`order`
+//		ValidateAndQuote("table;DROP")	    // error
+func (q MysqlQuoter) ValidateAndQuote(parts ...string) (string, error) {
+	var hasName bool
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		hasName = true
+		if len(p) > maxIdentifierLength {
+			return "", errors.NewNotValidf("[dbr] Incorrect identifier. Too long: %q", p)
+		}
+		for i := 0; i < len(p); i++ {
+			if !mapAlNum(p[i]) {
+				return "", errors.NewNotValidf("[dbr] Invalid character %q in identifier %q", p[i], p)
+			}
+		}
+	}
+	if !hasName {
+		return "", errors.NewNotValidf("[dbr] Incorrect identifier. Empty: %q", parts)
+	}
+	return q.QuoteQualified(parts...), nil
+}
+
+// QuoteIfReserved quotes an unquoted identifier, optionally qualified with a
+// table name, if one of its parts is a reserved word. All other strings, like
+// expressions or already quoted names, are returned unchanged.
+//		QuoteIfReserved("order") 	// `order`
+//		QuoteIfReserved("o.key") 	// `o`.`key`
+//		QuoteIfReserved("entity_id") 	// entity_id
+func (q MysqlQuoter) QuoteIfReserved(name string) string {
+	if isValidIdentifier(name) > 0 {
+		return name
+	}
+	qualifier, n := "", name
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		qualifier, n = name[:i], name[i+1:]
+	}
+	if !IsReservedWord(n) && (qualifier == "" || !IsReservedWord(qualifier)) {
+		return name
+	}
+	return q.QuoteQualified(qualifier, n)
+}
+
+// quoteSortIfReserved same as QuoteIfReserved but keeps a trailing sort
+// direction. Used for the GROUP BY and ORDER BY columns.
+//		quoteSortIfReserved("order DESC") // `order` DESC
+func quoteSortIfReserved(name string) string {
+	n, dir := splitSortDirection(name)
+	return Quoter.QuoteIfReserved(n) + dir
+}
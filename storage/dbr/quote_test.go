@@ -1,8 +1,10 @@
 package dbr
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/corestoreio/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -78,3 +80,125 @@ func TestMysqlQuoter_Quote(t *testing.T) {
 	assert.Exactly(t, "`tableName`", Quoter.Quote("", "tableName")) // qualifier is empty
 	assert.Exactly(t, "`databaseName`.`tableName`", Quoter.Quote("database`Name", "table`Name"))
 }
+
+func TestIsReservedWord(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsReservedWord("order"))
+	assert.True(t, IsReservedWord("KEY"))
+	assert.True(t, IsReservedWord("Group"))
+	assert.False(t, IsReservedWord("entity_id"))
+	assert.False(t, IsReservedWord("status"))
+	assert.False(t, IsReservedWord(""))
+}
+
+func TestMysqlQuoter_QuoteQualified(t *testing.T) {
+	t.Parallel()
+	assert.Exactly(t, "`db`.`tbl`.`col`", Quoter.QuoteQualified("db", "tbl", "col"))
+	assert.Exactly(t, "`tbl`", Quoter.QuoteQualified("", "tbl"))
+	assert.Exactly(t, "`tbl`", Quoter.QuoteQualified("`tbl`"))
+	assert.Exactly(t, "", Quoter.QuoteQualified())
+}
+
+func TestMysqlQuoter_ValidateAndQuote(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		have    []string
+		want    string
+		wantErr bool
+	}{
+		{[]string{"catalog_product_entity"}, "`catalog_product_entity`", false},
+		{[]string{"magento", "order"}, "`magento`.`order`", false},
+		{[]string{"", "order"}, "`order`", false},
+		{[]string{"tbl;DROP"}, "", true},
+		{[]string{"db.tbl"}, "", true},
+		{[]string{strings.Repeat("a", 65)}, "", true},
+		{[]string{""}, "", true},
+		{nil, "", true},
+	}
+	for i, test := range tests {
+		have, err := Quoter.ValidateAndQuote(test.have...)
+		if test.wantErr {
+			assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+}
+
+func TestMysqlQuoter_QuoteIfReserved(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		have, want string
+	}{
+		{"order", "`order`"},
+		{"o.key", "`o`.`key`"},
+		{"group.sku", "`group`.`sku`"},
+		{"entity_id", "entity_id"},
+		{"e.entity_id", "e.entity_id"},
+		{"`order`", "`order`"},
+		{"COUNT(*)", "COUNT(*)"},
+		{"*", "*"},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, Quoter.QuoteIfReserved(test.have), "Index %d", i)
+	}
+}
+
+func TestSelect_ReservedWordColumns(t *testing.T) {
+	t.Parallel()
+	sqlStr, _, err := NewSelect("order", "o.key", "entity_id", "COUNT(*) AS cnt").From("sales").ToSQL()
+	assert.NoError(t, err)
+	assert.Exactly(t, "SELECT `order`, `o`.`key`, entity_id, COUNT(*) AS cnt FROM `sales`", sqlStr)
+}
+
+func TestSelect_ReservedWordClauses(t *testing.T) {
+	t.Parallel()
+	sqlStr, _, err := NewSelect("o.entity_id").From("sales", "o").
+		Join(MakeAlias("sales_item", "i"), Condition("i.order", ArgInt(1)), Condition("key", ArgString("x"))).
+		Where(Condition("order", ArgInt(2)), Condition("o.group", ArgInt(3))).
+		GroupBy("group", "o.key").
+		OrderBy("order", "i.key").OrderByDesc("group").
+		OrderByNullsLast("index").ToSQL()
+	assert.NoError(t, err)
+	assert.Exactly(t,
+		"SELECT o.entity_id FROM `sales` AS `o` INNER JOIN `sales_item` AS `i` ON (`i`.`order` = ?) AND (`key` = ?) "+
+			"WHERE (`order` = ?) AND (`o`.`group` = ?) GROUP BY `group`, `o`.`key` "+
+			"ORDER BY `order`, `i`.`key`, `group` DESC, ISNULL(`index`), `index`",
+		sqlStr)
+}
+
+func TestInsert_ReservedWordColumns(t *testing.T) {
+	t.Parallel()
+	sqlStr, _, err := NewInsert("sales").AddColumns("order", "key", "entity_id").
+		AddValues(ArgInt(1), ArgString("a"), ArgInt(2)).
+		AddOnDuplicateKey("key", ArgString("b")).ToSQL()
+	assert.NoError(t, err)
+	assert.Exactly(t, "INSERT INTO `sales` (`order`,`key`,`entity_id`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `key`=?", sqlStr)
+}
+
+func TestUpdate_ReservedWordColumns(t *testing.T) {
+	t.Parallel()
+	sqlStr, _, err := NewUpdate("sales").Set("order", ArgInt(1)).Set("key", ArgString("a")).
+		Where(Condition("group", ArgInt(2))).OrderBy("index").OrderByDesc("key").Limit(1).ToSQL()
+	assert.NoError(t, err)
+	assert.Exactly(t, "UPDATE `sales` SET `order`=?, `key`=? WHERE (`group` = ?) ORDER BY `index`, `key` DESC LIMIT 1", sqlStr)
+}
+
+func TestDelete_ReservedWordColumns(t *testing.T) {
+	t.Parallel()
+	sqlStr, _, err := NewDelete("sales").Where(Condition("order", ArgInt(1))).
+		OrderBy("key").OrderByDesc("entity_id").Limit(1).ToSQL()
+	assert.NoError(t, err)
+	assert.Exactly(t, "DELETE FROM `sales` WHERE (`order` = ?) ORDER BY `key`, entity_id DESC LIMIT 1", sqlStr)
+}
+
+func TestUnion_ReservedWordColumns(t *testing.T) {
+	t.Parallel()
+	sqlStr, _, err := NewUnion(
+		NewSelect("order").From("a"),
+		NewSelect("order").From("b"),
+	).OrderBy("order").OrderByDesc("COUNT(*)").ToSQL()
+	assert.NoError(t, err)
+	assert.Exactly(t, "(SELECT `order` FROM `a`)\nUNION\n(SELECT `order` FROM `b`)\nORDER BY `order`, COUNT(*) DESC", sqlStr)
+}
@@ -0,0 +1,214 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/corestoreio/errors"
+)
+
+// ScanFunc converts the raw value of a column into dst. src contains the value
+// as returned from the driver, for MySQL mostly a []byte or nil. The byte
+// slice gets reused by the driver after the function returns, so a ScanFunc
+// must copy it when retaining it. dst is the address of the struct field in
+// LoadStructs and LoadStruct or a pointer to an empty interface in LoadMaps.
+type ScanFunc func(src interface{}, dst interface{}) error
+
+type scanNameRule struct {
+	pattern string
+	fn      ScanFunc
+}
+
+// ScannerRegistry maps column database types or column name patterns to a
+// ScanFunc. It gets used by LoadStructs, LoadStruct and LoadMaps so custom
+// MySQL types like JSON or SET columns can be decoded directly while loading
+// the rows. Name patterns take precedence over the database type and get
+// checked in the order of registration. A ScannerRegistry is safe for
+// concurrent use.
+//
+//		sr := dbr.NewScannerRegistry().
+//			RegisterType("JSON", dbr.ScanJSON).
+//			RegisterType("SET", dbr.ScanSet)
+//		dbc, err := dbr.NewConnection(dbr.WithDSN(dsn), dbr.WithScanners(sr))
+type ScannerRegistry struct {
+	mu    sync.RWMutex
+	types map[string]ScanFunc
+	names []scanNameRule
+}
+
+// NewScannerRegistry creates a new empty registry.
+func NewScannerRegistry() *ScannerRegistry {
+	return &ScannerRegistry{
+		types: make(map[string]ScanFunc),
+	}
+}
+
+// RegisterType sets the ScanFunc for a database type name as reported by
+// sql.ColumnType.DatabaseTypeName, for example JSON, SET or ENUM. The type name
+// is case insensitive. A nil fn removes the type.
+func (sr *ScannerRegistry) RegisterType(dbType string, fn ScanFunc) *ScannerRegistry {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	dbType = strings.ToUpper(dbType)
+	if fn == nil {
+		delete(sr.types, dbType)
+		return sr
+	}
+	sr.types[dbType] = fn
+	return sr
+}
+
+// RegisterName appends a ScanFunc for all columns whose name matches the
+// pattern. The pattern syntax is the one of path.Match, for example
+// "*_json" or "tags". Returns a NotValid error if the pattern is malformed.
+func (sr *ScannerRegistry) RegisterName(pattern string, fn ScanFunc) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errors.NewNotValidf("[dbr] ScannerRegistry.RegisterName: Invalid pattern %q: %s", pattern, err)
+	}
+	if fn == nil {
+		return errors.NewEmptyf("[dbr] ScannerRegistry.RegisterName: ScanFunc for pattern %q cannot be nil", pattern)
+	}
+	sr.mu.Lock()
+	sr.names = append(sr.names, scanNameRule{pattern: pattern, fn: fn})
+	sr.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the ScanFunc for a column name and its database type name or
+// nil if nothing has been registered.
+func (sr *ScannerRegistry) Lookup(column, dbType string) ScanFunc {
+	if sr == nil {
+		return nil
+	}
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	for _, r := range sr.names {
+		if ok, _ := path.Match(r.pattern, column); ok {
+			return r.fn
+		}
+	}
+	if dbType == "" {
+		return nil
+	}
+	return sr.types[strings.ToUpper(dbType)]
+}
+
+func (sr *ScannerRegistry) isEmpty() bool {
+	if sr == nil {
+		return true
+	}
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	return len(sr.types) == 0 && len(sr.names) == 0
+}
+
+// columnScanners returns for each column the registered ScanFunc. The returned
+// slice is nil if the registry is empty or no column matches.
+func (sr *ScannerRegistry) columnScanners(rows *sql.Rows, columns []string) ([]ScanFunc, error) {
+	if sr.isEmpty() {
+		return nil, nil
+	}
+	cTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] ScannerRegistry.rows.ColumnTypes")
+	}
+	var fns []ScanFunc
+	for i, col := range columns {
+		var dbType string
+		if i < len(cTypes) && cTypes[i] != nil {
+			dbType = cTypes[i].DatabaseTypeName()
+		}
+		if fn := sr.Lookup(col, dbType); fn != nil {
+			if fns == nil {
+				fns = make([]ScanFunc, len(columns))
+			}
+			fns[i] = fn
+		}
+	}
+	return fns, nil
+}
+
+// scanFuncAdapter implements sql.Scanner and forwards the raw value to a
+// ScanFunc.
+type scanFuncAdapter struct {
+	fn  ScanFunc
+	dst interface{}
+}
+
+// Scan implements the sql.Scanner interface.
+func (sa scanFuncAdapter) Scan(src interface{}) error {
+	return sa.fn(src, sa.dst)
+}
+
+func scanBytes(src interface{}) ([]byte, bool) {
+	switch v := src.(type) {
+	case []byte:
+		return v, true
+	case string:
+		return []byte(v), true
+	}
+	return nil, false
+}
+
+// ScanJSON decodes a JSON column into dst with json.Unmarshal. dst can be a
+// pointer to a struct, map, slice or empty interface. A NULL value or an empty
+// column leaves dst untouched.
+func ScanJSON(src interface{}, dst interface{}) error {
+	if src == nil {
+		return nil
+	}
+	b, ok := scanBytes(src)
+	if !ok {
+		return errors.NewNotSupportedf("[dbr] ScanJSON: Unsupported source type %T", src)
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		return errors.NewNotValid(err, "[dbr] ScanJSON.Unmarshal")
+	}
+	return nil
+}
+
+// ScanSet splits a MySQL SET column at the comma into a []string. dst must be
+// a *[]string or a *interface{}. A NULL value or an empty SET results in a nil
+// slice.
+func ScanSet(src interface{}, dst interface{}) error {
+	var set []string
+	if src != nil {
+		b, ok := scanBytes(src)
+		if !ok {
+			return errors.NewNotSupportedf("[dbr] ScanSet: Unsupported source type %T", src)
+		}
+		if len(b) > 0 {
+			set = strings.Split(string(b), ",")
+		}
+	}
+	switch d := dst.(type) {
+	case *[]string:
+		*d = set
+	case *interface{}:
+		*d = set
+	default:
+		return errors.NewNotSupportedf("[dbr] ScanSet: Unsupported destination type %T", dst)
+	}
+	return nil
+}
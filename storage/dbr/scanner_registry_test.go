@@ -0,0 +1,198 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScannerRegistry_Lookup(t *testing.T) {
+	t.Parallel()
+	sr := dbr.NewScannerRegistry().
+		RegisterType("json", dbr.ScanJSON).
+		RegisterType("SET", dbr.ScanSet)
+	require.NoError(t, sr.RegisterName("*_tags", dbr.ScanSet))
+
+	assert.NotNil(t, sr.Lookup("attributes", "JSON"))
+	assert.NotNil(t, sr.Lookup("product_tags", "VARCHAR"))
+	assert.NotNil(t, sr.Lookup("product_tags", ""))
+	assert.Nil(t, sr.Lookup("sku", "VARCHAR"))
+	assert.Nil(t, sr.Lookup("sku", ""))
+
+	sr.RegisterType("JSON", nil)
+	assert.Nil(t, sr.Lookup("attributes", "JSON"))
+
+	var nilSR *dbr.ScannerRegistry
+	assert.Nil(t, nilSR.Lookup("attributes", "JSON"))
+
+	err := sr.RegisterName("[", dbr.ScanSet)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	err = sr.RegisterName("*", nil)
+	assert.True(t, errors.IsEmpty(err), "%+v", err)
+}
+
+func TestScanJSON(t *testing.T) {
+	t.Parallel()
+	type attr struct {
+		Color string `json:"color"`
+		Size  int    `json:"size"`
+	}
+	var a attr
+	require.NoError(t, dbr.ScanJSON([]byte(`{"color":"red","size":42}`), &a))
+	assert.Exactly(t, attr{Color: "red", Size: 42}, a)
+
+	var a2 attr
+	require.NoError(t, dbr.ScanJSON(nil, &a2))
+	require.NoError(t, dbr.ScanJSON([]byte(" "), &a2))
+	assert.Exactly(t, attr{}, a2)
+
+	err := dbr.ScanJSON(int64(3), &a2)
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	err = dbr.ScanJSON([]byte(`{"color":`), &a2)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestScanSet(t *testing.T) {
+	t.Parallel()
+	var s []string
+	require.NoError(t, dbr.ScanSet([]byte("a,b,c"), &s))
+	assert.Exactly(t, []string{"a", "b", "c"}, s)
+	require.NoError(t, dbr.ScanSet([]byte(""), &s))
+	assert.Nil(t, s)
+	require.NoError(t, dbr.ScanSet(nil, &s))
+	assert.Nil(t, s)
+
+	var i interface{}
+	require.NoError(t, dbr.ScanSet("x", &i))
+	assert.Exactly(t, []string{"x"}, i)
+
+	var wrong string
+	err := dbr.ScanSet([]byte("a"), &wrong)
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	err = dbr.ScanSet(1.2, &s)
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+}
+
+type scannedProduct struct {
+	SKU        string `db:"sku"`
+	Attributes struct {
+		Color string `json:"color"`
+	}
+	Tags []string
+}
+
+func newScannerMock(t *testing.T) (*dbr.Connection, sqlmock.Sqlmock) {
+	dbc, dbMock := cstesting.MockDB(t)
+	dbc.Scanners = dbr.NewScannerRegistry()
+	require.NoError(t, dbc.Scanners.RegisterName("attributes", dbr.ScanJSON))
+	require.NoError(t, dbc.Scanners.RegisterName("*tags", dbr.ScanSet))
+	return dbc, dbMock
+}
+
+func TestSelect_LoadStructs_Scanners(t *testing.T) {
+	t.Parallel()
+	dbc, dbMock := newScannerMock(t)
+	defer func() {
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	dbMock.ExpectQuery("SELECT sku, attributes, tags FROM `catalog_product_entity`").
+		WillReturnRows(sqlmock.NewRows([]string{"sku", "attributes", "tags"}).
+			AddRow("SKU1", []byte(`{"color":"red"}`), []byte("new,sale")).
+			AddRow("SKU2", nil, []byte("")),
+		)
+
+	var prods []*scannedProduct
+	n, err := dbc.Select("sku", "attributes", "tags").From("catalog_product_entity").LoadStructs(context.TODO(), &prods)
+	require.NoError(t, err)
+	assert.Exactly(t, 2, n)
+	assert.Exactly(t, "SKU1", prods[0].SKU)
+	assert.Exactly(t, "red", prods[0].Attributes.Color)
+	assert.Exactly(t, []string{"new", "sale"}, prods[0].Tags)
+	assert.Exactly(t, "SKU2", prods[1].SKU)
+	assert.Exactly(t, "", prods[1].Attributes.Color)
+	assert.Nil(t, prods[1].Tags)
+}
+
+func TestSelect_LoadStruct_Scanners(t *testing.T) {
+	t.Parallel()
+	dbc, dbMock := newScannerMock(t)
+	defer func() {
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	dbMock.ExpectQuery("SELECT sku, attributes FROM `catalog_product_entity`").
+		WillReturnRows(sqlmock.NewRows([]string{"sku", "attributes"}).
+			AddRow("SKU1", []byte(`{"color":`)),
+		)
+
+	var prod scannedProduct
+	err := dbc.Select("sku", "attributes").From("catalog_product_entity").LoadStruct(context.TODO(), &prod)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "[dbr] ScanJSON.Unmarshal")
+}
+
+func TestSelect_LoadMaps(t *testing.T) {
+	t.Parallel()
+	dbc, dbMock := newScannerMock(t)
+	defer func() {
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	dbMock.ExpectQuery("SELECT sku, attributes, tags, qty FROM `catalog_product_entity`").
+		WillReturnRows(sqlmock.NewRows([]string{"sku", "attributes", "tags", "qty"}).
+			AddRow([]byte("SKU1"), []byte(`{"color":"red"}`), []byte("new,sale"), int64(3)).
+			AddRow([]byte("SKU2"), nil, nil, nil),
+		)
+
+	var rows []map[string]interface{}
+	n, err := dbc.Select("sku", "attributes", "tags", "qty").From("catalog_product_entity").LoadMaps(context.TODO(), &rows)
+	require.NoError(t, err)
+	assert.Exactly(t, 2, n)
+	assert.Exactly(t, []map[string]interface{}{
+		{
+			"sku":        "SKU1",
+			"attributes": map[string]interface{}{"color": "red"},
+			"tags":       []string{"new", "sale"},
+			"qty":        int64(3),
+		},
+		{
+			"sku":        "SKU2",
+			"attributes": nil,
+			"tags":       []string(nil),
+			"qty":        nil,
+		},
+	}, rows)
+}
+
+func TestWithScanners(t *testing.T) {
+	t.Parallel()
+	sr := dbr.NewScannerRegistry()
+	dbc, err := dbr.NewConnection(dbr.WithDSN("root@tcp(localhost:3306)/test"), dbr.WithScanners(sr))
+	require.NoError(t, err)
+	assert.Exactly(t, sr, dbc.Select("a").Scanners)
+	assert.Exactly(t, sr, dbc.SelectBySQL("SELECT 1").Scanners)
+
+	_, err = dbr.NewConnection(dbr.WithScanners(nil))
+	assert.True(t, errors.IsEmpty(err), "%+v", err)
+}
@@ -2,6 +2,7 @@ package dbr
 
 import (
 	"strings"
+	"time"
 
 	"github.com/corestoreio/csfw/util/bufferpool"
 	"github.com/corestoreio/errors"
@@ -11,6 +12,17 @@ import (
 // Select contains the clauses for a SELECT statement
 type Select struct {
 	Log log.Logger // Log optional logger
+	// Dialect rewrites the generated SQL for the target database server. Nil
+	// or DialectMySQL keeps the MySQL syntax.
+	Dialect Dialect
+	// TableNameMapper rewrites the table names when generating the SQL, for
+	// example to add a per-tenant prefix. Nil keeps the names unchanged.
+	TableNameMapper TableNameMapper
+	// QueryTimeout cancels the execution after the duration. See Timeout()
+	QueryTimeout time.Duration
+	// Scanners optional registry of column scanners used by LoadStructs,
+	// LoadStruct and LoadMaps.
+	Scanners *ScannerRegistry
 	// DB gets required once the Load*() functions will be used.
 	DB struct {
 		Querier
@@ -31,6 +43,7 @@ type Select struct {
 	GroupBys          []string
 	HavingFragments   WhereFragments
 	OrderBys          []string
+	OrderByArgs       Arguments // See OrderByExpr()
 	LimitCount        uint64
 	OffsetCount       uint64
 	LimitValid        bool
@@ -40,6 +53,17 @@ type Select struct {
 	IsSQLNoCache      bool // See SQLNoCache()
 	IsForUpdate       bool // See ForUpdate()
 	IsLockInShareMode bool // See LockInShareMode()
+	IsForShare        bool // See ForShare()
+	IsSkipLocked      bool // See SkipLocked()
+	IsNoWait          bool // See NoWait()
+	IsStrict          bool // See Strict()
+	IsQualifyColumns  bool // See QualifyColumns()
+	IsAutoHaving      bool // See AutoHaving()
+	// Comments get rendered as leading C style comments. See Comment()
+	Comments []string
+	// Outfile if set writes the result set into a file on the server host.
+	// See IntoOutfile()
+	Outfile *Outfile
 	// PropagationStopped set to true if you would like to interrupt the
 	// listener chain. Once set to true all sub sequent calls of the next
 	// listeners will be suppressed.
@@ -54,10 +78,11 @@ type Select struct {
 }
 
 // NewSelect creates a new Select object with a black hole logger and selecting
-// from the specified columns. The provided columns won't get quoted.
-func NewSelect(columns ...string) *Select {
+// from the specified columns. The provided columns won't get quoted,
+// except reserved words.
+func NewSelect(columns ...SQLUnsafe) *Select {
 	return &Select{
-		Columns: columns,
+		Columns: auditSQLExpr(columns...),
 	}
 }
 
@@ -78,36 +103,51 @@ func NewSelectFromSub(subSelect *Select, aliasName string) *Select {
 }
 
 // Select creates a new Select which selects from the provided columns.
-// Columns won't get quoted.
-func (c *Connection) Select(columns ...string) *Select {
+// Columns won't get quoted, except reserved words.
+func (c *Connection) Select(columns ...SQLUnsafe) *Select {
 	s := &Select{
-		Log:     c.Log,
-		Columns: columns,
+		Log:      c.Log,
+		Dialect:  c.Dialect,
+		Scanners: c.Scanners,
+		Columns:  auditSQLExpr(columns...),
+
+		TableNameMapper: c.TableNameMapper,
 	}
-	s.DB.Querier = c.DB
-	s.DB.QueryRower = c.DB
-	s.DB.Preparer = c.DB
+	db := c.dber()
+	s.DB.Querier = db
+	s.DB.QueryRower = db
+	s.DB.Preparer = db
 	return s
 }
 
 // SelectBySQL creates a new Select for the given SQL string and arguments
 func (c *Connection) SelectBySQL(sql string, args ...Argument) *Select {
+	auditSQLUnsafe(SQLUnsafe(sql))
 	s := &Select{
 		Log:        c.Log,
+		Dialect:    c.Dialect,
+		Scanners:   c.Scanners,
 		RawFullSQL: sql,
 		Arguments:  args,
+
+		TableNameMapper: c.TableNameMapper,
 	}
-	s.DB.Querier = c.DB
-	s.DB.QueryRower = c.DB
-	s.DB.Preparer = c.DB
+	db := c.dber()
+	s.DB.Querier = db
+	s.DB.QueryRower = db
+	s.DB.Preparer = db
 	return s
 }
 
 // Select creates a new Select that select that given columns bound to the transaction
-func (tx *Tx) Select(columns ...string) *Select {
+func (tx *Tx) Select(columns ...SQLUnsafe) *Select {
 	s := &Select{
-		Log:     tx.Logger,
-		Columns: columns,
+		Log:      tx.Logger,
+		Dialect:  tx.Dialect,
+		Scanners: tx.Scanners,
+		Columns:  auditSQLExpr(columns...),
+
+		TableNameMapper: tx.TableNameMapper,
 	}
 	s.DB.Querier = tx.Tx
 	s.DB.QueryRower = tx.Tx
@@ -117,10 +157,15 @@ func (tx *Tx) Select(columns ...string) *Select {
 
 // SelectBySQL creates a new Select for the given SQL string and arguments bound to the transaction
 func (tx *Tx) SelectBySQL(sql string, args ...Argument) *Select {
+	auditSQLUnsafe(SQLUnsafe(sql))
 	s := &Select{
 		Log:        tx.Logger,
+		Dialect:    tx.Dialect,
+		Scanners:   tx.Scanners,
 		RawFullSQL: sql,
 		Arguments:  args,
+
+		TableNameMapper: tx.TableNameMapper,
 	}
 	s.DB.Querier = tx.Tx
 	s.DB.QueryRower = tx.Tx
@@ -180,6 +225,84 @@ func (b *Select) LockInShareMode() *Select {
 	return b
 }
 
+// QualifyColumns prefixes all plain column names with the alias of the main
+// table, or its name if no alias has been set, and quotes them. Prevents
+// ambiguous column errors in queries with JOINs.
+//		NewSelect("entity_id", "sku", "*").From("catalog_product_entity", "e").QualifyColumns()
+//		// SELECT `e`.`entity_id`, `e`.`sku`, `e`.* FROM `catalog_product_entity` AS `e`
+// Already qualified or quoted columns and expressions get not modified. Use
+// AddColumnsUnqualified to opt-out for a single column.
+func (b *Select) QualifyColumns() *Select {
+	b.IsQualifyColumns = true
+	return b
+}
+
+// ForShare is the MySQL 8 successor of LockInShareMode and sets a shared mode
+// lock on the rows read. Can be combined with SkipLocked() or NoWait().
+// https://dev.mysql.com/doc/refman/8.0/en/innodb-locking-reads.html
+func (b *Select) ForShare() *Select {
+	b.IsForShare = true
+	return b
+}
+
+// SkipLocked never waits to acquire a row lock. The query executes immediately
+// and removes locked rows from the result set. Useful for queue tables where
+// multiple consumers must not process the same row. Requires ForUpdate() or
+// ForShare() and MySQL >= 8.0.1.
+func (b *Select) SkipLocked() *Select {
+	b.IsSkipLocked = true
+	return b
+}
+
+// NoWait never waits to acquire a row lock. The query executes immediately and
+// fails with an error if a requested row is locked. Requires ForUpdate() or
+// ForShare() and MySQL >= 8.0.1.
+func (b *Select) NoWait() *Select {
+	b.IsNoWait = true
+	return b
+}
+
+// IntoOutfile writes the selected rows into a file on the server host. The
+// file must not exist and the MySQL user requires the FILE privilege. Use
+// the Outfile fields to define the format.
+func (b *Select) IntoOutfile(o *Outfile) *Select {
+	b.Outfile = o
+	return b
+}
+
+// Comment adds a leading /* comment */ to the statement, for example a
+// request ID or the output of CommentCaller, to correlate queries in the
+// MySQL slow query log with application requests. Markers within the
+// comment get escaped.
+func (b *Select) Comment(c string) *Select {
+	b.Comments = append(b.Comments, c)
+	return b
+}
+
+// Timeout limits the execution time of the query. The Load*() functions,
+// Prepare, Rows and Row cancel the context after the duration and the MySQL
+// dialects emit the optimizer hint
+//		SELECT /*+ MAX_EXECUTION_TIME(1500) */ ...
+// so the server aborts the query too and slow reports cannot exhaust the
+// connection pool. The duration for Rows and Row includes reading the result,
+// rows not consumed in time return a context error. Because *sql.Rows and
+// *sql.Row cannot cancel the context on Close, Rows and Row keep the context
+// and its timer alive until the duration has elapsed. Use a short timeout or
+// pass a context with a deadline for a high number of calls.
+func (b *Select) Timeout(d time.Duration) *Select {
+	b.QueryTimeout = d
+	return b
+}
+
+// Strict enables the validation mode. ToSQL checks then the identifiers, the
+// balance of the parenthesis and the number of place holders against the
+// number of arguments and returns a NotValid error behaviour instead of
+// generating broken SQL. Strict mode has a small performance penalty.
+func (b *Select) Strict() *Select {
+	b.IsStrict = true
+	return b
+}
+
 // From sets the table to SELECT FROM. If second argument will be provided this
 // at then considered at the alias. SELECT ... FROM table AS alias.
 func (b *Select) From(from ...string) *Select {
@@ -206,8 +329,10 @@ func splitColumns(cols []string) []string {
 // its values appended to the Columns slice. Columns won't get quoted.
 // 		AddColumns("a","b") 		// []string{"a","b"}
 // 		AddColumns("a,b","z","c,d")	// []string{"a","b","z","c","d"}
-func (b *Select) AddColumns(cols ...string) *Select {
-	b.Columns = append(b.Columns, splitColumns(cols)...)
+func (b *Select) AddColumns(cols ...SQLUnsafe) *Select {
+	sCols := splitColumns(unsafeStrings(cols))
+	reportSQLExpr(sCols, 3)
+	b.Columns = append(b.Columns, sCols...)
 	return b
 }
 
@@ -237,13 +362,30 @@ func (b *Select) AddColumnsQuotedAlias(columnAliases ...string) *Select {
 	return b
 }
 
+// AddColumnsUnqualified appends columns which must not be prefixed with the
+// main table alias when QualifyColumns has been enabled. Plain identifiers get
+// quoted, expressions are added unchanged.
+//		AddColumnsUnqualified("@rank", "entity_id") // []string{"@rank", "`entity_id`"}
+func (b *Select) AddColumnsUnqualified(cols ...SQLUnsafe) *Select {
+	sCols := splitColumns(unsafeStrings(cols))
+	reportSQLExpr(sCols, 3)
+	for i, c := range sCols {
+		if isValidIdentifier(c) == 0 {
+			sCols[i] = Quoter.QuoteAs(c)
+		}
+	}
+	b.Columns = append(b.Columns, sCols...)
+	return b
+}
+
 // AddColumnsExprAlias expects a balanced slice of "expression, AliasName" and
 // adds both concatenated and quoted to the Columns slice. It panics when the
 // provided `expressionAlias` seems not be balanced.
 // 		AddColumnsExprAlias("(e.price*x.tax*t.weee)", "final_price") // (e.price*x.tax*t.weee) AS `final_price`
-func (b *Select) AddColumnsExprAlias(expressionAliases ...string) *Select {
+func (b *Select) AddColumnsExprAlias(expressionAliases ...SQLUnsafe) *Select {
 	for i := 0; i < len(expressionAliases); i = i + 2 {
-		b.Columns = append(b.Columns, Quoter.ExprAlias(expressionAliases[i], expressionAliases[i+1]))
+		e := auditSQLExpr(expressionAliases[i])
+		b.Columns = append(b.Columns, Quoter.ExprAlias(e[0], string(expressionAliases[i+1])))
 	}
 	return b
 }
@@ -264,8 +406,8 @@ func (b *Select) Where(c ...ConditionArg) *Select {
 }
 
 // GroupBy appends a column or an expression to group the statement.
-func (b *Select) GroupBy(groups ...string) *Select {
-	b.GroupBys = append(b.GroupBys, groups...)
+func (b *Select) GroupBy(groups ...SQLUnsafe) *Select {
+	b.GroupBys = append(b.GroupBys, auditSQLExpr(groups...)...)
 	return b
 }
 
@@ -276,15 +418,62 @@ func (b *Select) Having(c ...ConditionArg) *Select {
 }
 
 // OrderBy appends a column or an expression to ORDER the statement ascending.
-func (b *Select) OrderBy(ord ...string) *Select {
-	b.OrderBys = append(b.OrderBys, ord...)
+func (b *Select) OrderBy(ord ...SQLUnsafe) *Select {
+	b.OrderBys = append(b.OrderBys, auditSQLExpr(ord...)...)
 	return b
 }
 
 // OrderByDesc appends a column or an expression to ORDER the statement
 // descending.
-func (b *Select) OrderByDesc(ord ...string) *Select {
-	b.OrderBys = orderByDesc(b.OrderBys, ord)
+func (b *Select) OrderByDesc(ord ...SQLUnsafe) *Select {
+	b.OrderBys = orderByDesc(b.OrderBys, auditSQLExpr(ord...))
+	return b
+}
+
+// OrderByExpr appends an expression with place holders and its arguments to
+// ORDER the statement. The variadic place holder "?..." expands to as many
+// place holders as its argument contains values. The expression won't get
+// quoted. Add ASC or DESC to the expression to define the direction.
+//		OrderByExpr("FIELD(code, ?...)", dbr.ArgString("de", "at", "ch"))
+//		// ORDER BY FIELD(code, 'de', 'at', 'ch')
+func (b *Select) OrderByExpr(expression SQLUnsafe, args ...Argument) *Select {
+	e := auditSQLExpr(expression)
+	b.OrderBys = append(b.OrderBys, expandPlaceholders(e[0], args))
+	b.OrderByArgs = append(b.OrderByArgs, args...)
+	return b
+}
+
+// OrderByNullsFirst appends a column or an expression to ORDER the statement
+// ascending and sorts NULL values before all other values. MySQL has no NULLS
+// FIRST clause, so an ISNULL() expression gets prepended.
+//		OrderByNullsFirst("sort_order") // ORDER BY ISNULL(sort_order) DESC, sort_order
+func (b *Select) OrderByNullsFirst(ord ...SQLUnsafe) *Select {
+	b.OrderBys = orderByNulls(b.OrderBys, auditSQLExpr(ord...), false, true)
+	return b
+}
+
+// OrderByNullsLast appends a column or an expression to ORDER the statement
+// ascending and sorts NULL values after all other values. MySQL has no NULLS
+// LAST clause, so an ISNULL() expression gets prepended.
+//		OrderByNullsLast("sort_order") // ORDER BY ISNULL(sort_order), sort_order
+func (b *Select) OrderByNullsLast(ord ...SQLUnsafe) *Select {
+	b.OrderBys = orderByNulls(b.OrderBys, auditSQLExpr(ord...), false, false)
+	return b
+}
+
+// OrderByDescNullsFirst appends a column or an expression to ORDER the
+// statement descending and sorts NULL values before all other values.
+//		OrderByDescNullsFirst("sort_order") // ORDER BY ISNULL(sort_order) DESC, sort_order DESC
+func (b *Select) OrderByDescNullsFirst(ord ...SQLUnsafe) *Select {
+	b.OrderBys = orderByNulls(b.OrderBys, auditSQLExpr(ord...), true, true)
+	return b
+}
+
+// OrderByDescNullsLast appends a column or an expression to ORDER the
+// statement descending and sorts NULL values after all other values.
+//		OrderByDescNullsLast("sort_order") // ORDER BY ISNULL(sort_order), sort_order DESC
+func (b *Select) OrderByDescNullsLast(ord ...SQLUnsafe) *Select {
+	b.OrderBys = orderByNulls(b.OrderBys, auditSQLExpr(ord...), true, false)
 	return b
 }
 
@@ -311,7 +500,18 @@ func (b *Select) Paginate(page, perPage uint64) *Select {
 }
 
 // ToSQL converts the select statement into a string and returns its arguments.
+// The SQL gets rewritten for the Dialect, if set.
 func (b *Select) ToSQL() (string, Arguments, error) {
+	sqlStr, args, err := b.rawSQL()
+	if err == nil {
+		err = errors.Wrap(resolveValuers(args), "[dbr] Select.ToSQL.resolveValuers")
+	}
+	return Rebind(b.Dialect, sqlStr), args, err
+}
+
+// rawSQL generates the statement in MySQL syntax without applying the
+// Dialect.
+func (b *Select) rawSQL() (string, Arguments, error) {
 	var w = bufferpool.Get()
 	defer bufferpool.Put(w)
 	args, err := b.toSQL(w)
@@ -330,6 +530,7 @@ func (b *Select) toSQL(w queryWriter) (Arguments, error) {
 	// has been set to false, then query gets regenerated.
 
 	if b.RawFullSQL != "" {
+		sqlWriteComments(w, b.Comments)
 		w.WriteString(b.RawFullSQL)
 		return b.Arguments, nil
 	}
@@ -340,13 +541,27 @@ func (b *Select) toSQL(w queryWriter) (Arguments, error) {
 	if len(b.Columns) == 0 {
 		return nil, errors.NewEmptyf(errColumnsMissing)
 	}
+	if b.IsStrict {
+		if err := b.validate(); err != nil {
+			return nil, errors.Wrap(err, "[dbr] Select.toSQL.validate")
+		}
+	}
+	if err := b.validateLocking(); err != nil {
+		return nil, errors.Wrap(err, "[dbr] Select.toSQL.validateLocking")
+	}
+	whereFragments, havingFragments, err := b.splitAliasConditions()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] Select.toSQL.splitAliasConditions")
+	}
 
 	// not sure if copying is necessary but leaves at least b.Arguments in pristine
 	// condition
 	var args = make(Arguments, len(b.Arguments), len(b.Arguments)+len(b.JoinFragments)+len(b.WhereFragments))
 	copy(args, b.Arguments)
 
+	sqlWriteComments(w, b.Comments)
 	w.WriteString("SELECT ")
+	sqlWriteMaxExecutionTime(w, b.Dialect, b.QueryTimeout)
 
 	if b.IsDistinct {
 		w.WriteString("DISTINCT ")
@@ -358,34 +573,45 @@ func (b *Select) toSQL(w queryWriter) (Arguments, error) {
 		w.WriteString("SQL_NO_CACHE ")
 	}
 
+	table := b.Table.mapTableName(b.TableNameMapper, b.Dialect, true)
+	var qualifier string
+	if b.IsQualifyColumns {
+		qualifier = table.qualifier()
+	}
 	for i, s := range b.Columns {
 		if i > 0 {
 			w.WriteString(", ")
 		}
-		w.WriteString(s)
+		switch {
+		case qualifier != "" && s == "*":
+			w.WriteString(qualifier)
+			w.WriteString(".*")
+		case qualifier != "" && isValidIdentifier(s) == 0:
+			if strings.IndexByte(s, '.') > 0 {
+				Quoter.FquoteAs(w, s)
+				continue
+			}
+			w.WriteString(qualifier)
+			w.WriteRune('.')
+			Quoter.quote(w, s)
+		default:
+			w.WriteString(Quoter.QuoteIfReserved(s))
+		}
 	}
 
 	w.WriteString(" FROM ")
-	tArgs, err := b.Table.FquoteAs(w)
+	tArgs, err := table.FquoteAs(w)
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Selec.toSQL.Table.FquoteAs")
 	}
 	args = append(args, tArgs...)
 
-	if len(b.JoinFragments) > 0 {
-		for _, f := range b.JoinFragments {
-			w.WriteRune(' ')
-			w.WriteString(f.JoinType)
-			w.WriteString(" JOIN ")
-			f.Table.FquoteAs(w)
-			if err := writeWhereFragmentsToSQL(f.OnConditions, w, &args, 'j'); err != nil {
-				return nil, errors.Wrap(err, "[dbr] Select.toSQL.writeWhereFragmentsToSQL")
-			}
-		}
+	if err := sqlWriteJoins(w, b.TableNameMapper, b.Dialect, b.JoinFragments, &args); err != nil {
+		return nil, errors.Wrap(err, "[dbr] Select.toSQL.sqlWriteJoins")
 	}
 
-	if len(b.WhereFragments) > 0 {
-		if err := writeWhereFragmentsToSQL(b.WhereFragments, w, &args, 'w'); err != nil {
+	if len(whereFragments) > 0 {
+		if err := writeWhereFragmentsToSQL(whereFragments, w, b.TableNameMapper, b.Dialect, &args, 'w'); err != nil {
 			return nil, errors.Wrap(err, "[dbr] Select.toSQL.writeWhereFragmentsToSQL")
 		}
 	}
@@ -396,23 +622,50 @@ func (b *Select) toSQL(w queryWriter) (Arguments, error) {
 			if i > 0 {
 				w.WriteString(", ")
 			}
-			w.WriteString(s)
+			w.WriteString(quoteSortIfReserved(s))
 		}
 	}
 
-	if len(b.HavingFragments) > 0 {
-		if err := writeWhereFragmentsToSQL(b.HavingFragments, w, &args, 'h'); err != nil {
+	if len(havingFragments) > 0 {
+		if err := writeWhereFragmentsToSQL(havingFragments, w, b.TableNameMapper, b.Dialect, &args, 'h'); err != nil {
 			return nil, errors.Wrap(err, "[dbr] Select.toSQL.writeWhereFragmentsToSQL")
 		}
 	}
 
 	sqlWriteOrderBy(w, b.OrderBys, false)
-	sqlWriteLimitOffset(w, b.LimitValid, b.LimitCount, b.OffsetValid, b.OffsetCount)
+	args = append(args, b.OrderByArgs...)
+	sqlWriteLimitOffset(w, b.Dialect, b.LimitValid, b.LimitCount, b.OffsetValid, b.OffsetCount)
+	if b.Outfile != nil {
+		if err := b.Outfile.writeTo(w); err != nil {
+			return nil, errors.Wrap(err, "[dbr] Select.toSQL.Outfile")
+		}
+	}
 	switch {
 	case b.IsLockInShareMode:
 		w.WriteString(" LOCK IN SHARE MODE")
+	case b.IsForShare:
+		w.WriteString(" FOR SHARE")
 	case b.IsForUpdate:
 		w.WriteString(" FOR UPDATE")
 	}
+	switch {
+	case b.IsSkipLocked:
+		w.WriteString(" SKIP LOCKED")
+	case b.IsNoWait:
+		w.WriteString(" NOWAIT")
+	}
 	return args, nil
 }
+
+// validateLocking checks that the lock modifiers can be combined.
+func (b *Select) validateLocking() error {
+	switch {
+	case b.IsLockInShareMode && (b.IsForShare || b.IsForUpdate), b.IsForShare && b.IsForUpdate:
+		return errors.NewNotValidf(errLockingClauses)
+	case b.IsSkipLocked && b.IsNoWait:
+		return errors.NewNotValidf(errLockingSkipNoWait)
+	case (b.IsSkipLocked || b.IsNoWait) && !b.IsForShare && !b.IsForUpdate:
+		return errors.NewNotValidf(errLockingModifier)
+	}
+	return nil
+}
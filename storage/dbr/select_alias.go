@@ -0,0 +1,214 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"strings"
+
+	"github.com/corestoreio/errors"
+)
+
+// MySQL evaluates the WHERE clause before the SELECT list, hence a condition
+// cannot refer to the alias of a selected expression:
+//		SELECT SUM(qty) AS `total_qty` FROM `sales` WHERE (`total_qty` > ?)
+// fails with "Unknown column 'total_qty' in 'where clause'". The functions in
+// this file detect such conditions. Either the Select returns a NotValid error
+// behaviour or, with AutoHaving(), moves the conditions into the HAVING clause.
+
+// AutoHaving moves WHERE conditions which reference an alias of the SELECT
+// list into the HAVING clause instead of returning an error. Only conditions
+// connected via AND outside of any parenthesis can be moved because moving
+// them would otherwise change the logic of the WHERE clause.
+func (b *Select) AutoHaving() *Select {
+	b.IsAutoHaving = true
+	return b
+}
+
+// columnAlias extracts the alias name of a column definition in the form of
+// "expression AS alias". It returns an empty string if the column has no
+// alias or if the alias equals the column name, e.g. `e`.`sku` AS `sku`,
+// because a WHERE condition then refers to the table column.
+func columnAlias(col string) string {
+	pos := -1
+	for i := len(col) - 4; i >= 0; i-- {
+		if col[i] == ' ' && col[i+3] == ' ' && (col[i+1] == 'A' || col[i+1] == 'a') && (col[i+2] == 'S' || col[i+2] == 's') {
+			pos = i
+			break
+		}
+	}
+	if pos < 0 {
+		return ""
+	}
+	aliasName := Quoter.unQuote(strings.TrimSpace(col[pos+4:]))
+	if aliasName == "" || strings.IndexByte(aliasName, '.') >= 0 || isValidIdentifier(aliasName) != 0 {
+		return "" // something like CAST(x AS CHAR)
+	}
+	expr := Quoter.unQuote(strings.TrimSpace(col[:pos]))
+	if isValidIdentifier(expr) == 0 {
+		if i := strings.LastIndexByte(expr, '.'); i >= 0 {
+			expr = expr[i+1:]
+		}
+		if strings.EqualFold(expr, aliasName) {
+			return ""
+		}
+	}
+	return aliasName
+}
+
+// columnAliases returns all alias names of the SELECT list.
+func (b *Select) columnAliases() []string {
+	var aliases []string
+	for _, c := range b.Columns {
+		if a := columnAlias(c); a != "" {
+			aliases = append(aliases, a)
+		}
+	}
+	return aliases
+}
+
+// referencedAlias returns the first alias which gets referenced by the
+// condition or an empty string. A column name condition must match an alias
+// exactly, qualified names like t.total belong to a table. In an expression
+// all unqualified identifiers outside of string literals get compared. Alias
+// names are case insensitive.
+func referencedAlias(condition string, aliases []string) string {
+	if isValidIdentifier(condition) == 0 {
+		if strings.IndexByte(condition, '.') >= 0 {
+			return ""
+		}
+		return matchAlias(condition, aliases)
+	}
+
+	for i := 0; i < len(condition); i++ {
+		c := condition[i]
+		switch {
+		case c == '\'' || c == '"':
+			if p := strings.IndexByte(condition[i+1:], c); p >= 0 {
+				i += p + 1
+			}
+		case c == quoteByte:
+			p := strings.IndexByte(condition[i+1:], quoteByte)
+			if p < 0 {
+				return ""
+			}
+			start, end := i+1, i+1+p
+			i = end
+			if isQualifiedAt(condition, start-1, end+1) {
+				continue
+			}
+			if a := matchAlias(condition[start:end], aliases); a != "" {
+				return a
+			}
+		case mapAlNum(c):
+			start := i
+			for i < len(condition) && mapAlNum(condition[i]) {
+				i++
+			}
+			end := i
+			i-- // loop increments
+			if '0' <= c && c <= '9' || isQualifiedAt(condition, start, end) {
+				continue
+			}
+			if end < len(condition) && condition[end] == '(' {
+				continue // function name
+			}
+			if start > 0 && condition[start-1] == '@' {
+				continue // user variable
+			}
+			if a := matchAlias(condition[start:end], aliases); a != "" {
+				return a
+			}
+		}
+	}
+	return ""
+}
+
+// isQualifiedAt reports whether the identifier between start and end gets
+// prefixed by a qualifier or is itself a qualifier.
+func isQualifiedAt(s string, start, end int) bool {
+	return (start > 0 && s[start-1] == '.') || (end < len(s) && s[end] == '.')
+}
+
+func matchAlias(name string, aliases []string) string {
+	for _, a := range aliases {
+		if strings.EqualFold(name, a) {
+			return a
+		}
+	}
+	return ""
+}
+
+// splitAliasConditions checks the WHERE fragments for references to an alias
+// of the SELECT list. Without AutoHaving such a reference returns a NotValid
+// error. With AutoHaving the referencing fragments get appended to the
+// returned HAVING fragments. The fields of the Select stay untouched.
+func (b *Select) splitAliasConditions() (where, having WhereFragments, err error) {
+	where, having = b.WhereFragments, b.HavingFragments
+	if len(b.WhereFragments) == 0 {
+		return where, having, nil
+	}
+	aliases := b.columnAliases()
+	if len(aliases) == 0 {
+		return where, having, nil
+	}
+
+	var movable = true
+	var depth int
+	var moveIdx []int
+	for i, f := range b.WhereFragments {
+		switch f.Condition {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		}
+		if depth == 0 && i > 0 && f.Logical != 0 && f.Logical != logicalAnd {
+			movable = false
+		}
+		a := referencedAlias(f.Condition, aliases)
+		if a == "" {
+			continue
+		}
+		if !b.IsAutoHaving {
+			return nil, nil, errors.NewNotValidf(errWhereAlias, f.Condition, a)
+		}
+		if depth > 0 {
+			return nil, nil, errors.NewNotValidf(errWhereAliasNested, f.Condition, a)
+		}
+		moveIdx = append(moveIdx, i)
+	}
+	if len(moveIdx) == 0 {
+		return where, having, nil
+	}
+	if !movable {
+		f := b.WhereFragments[moveIdx[0]]
+		return nil, nil, errors.NewNotValidf(errWhereAliasNested, f.Condition, referencedAlias(f.Condition, aliases))
+	}
+
+	where = make(WhereFragments, 0, len(b.WhereFragments)-len(moveIdx))
+	having = make(WhereFragments, 0, len(b.HavingFragments)+len(moveIdx))
+	having = append(having, b.HavingFragments...)
+	for i, f := range b.WhereFragments {
+		if len(moveIdx) > 0 && moveIdx[0] == i {
+			moveIdx = moveIdx[1:]
+			having = append(having, f)
+			continue
+		}
+		where = append(where, f)
+	}
+	return where, having, nil
+}
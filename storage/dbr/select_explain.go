@@ -0,0 +1,180 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/corestoreio/errors"
+	"github.com/corestoreio/log"
+)
+
+// ExplainRow represents one row of the traditional EXPLAIN output. Columns
+// which are not available in the MySQL/MariaDB version or which are NULL
+// contain their zero value.
+type ExplainRow struct {
+	ID           int64
+	SelectType   string
+	Table        string
+	Partitions   string
+	Type         string // join type, ALL indicates a full table scan
+	PossibleKeys string
+	Key          string
+	KeyLen       string
+	Ref          string
+	Rows         int64
+	Filtered     float64
+	Extra        string
+}
+
+// IsFullTableScan returns true if the join type is ALL.
+func (er ExplainRow) IsFullTableScan() bool {
+	return strings.EqualFold(er.Type, "ALL")
+}
+
+// QueryPlan contains the EXPLAIN output of a query.
+type QueryPlan struct {
+	// SQL the explained query without the EXPLAIN keyword.
+	SQL  string
+	Rows []ExplainRow
+	// Warnings contains a message for each full table scan on one of the
+	// large tables passed to Explain.
+	Warnings []string
+}
+
+// FullTableScans returns all rows which read the whole table.
+func (qp *QueryPlan) FullTableScans() []ExplainRow {
+	var ers []ExplainRow
+	for _, er := range qp.Rows {
+		if er.IsFullTableScan() {
+			ers = append(ers, er)
+		}
+	}
+	return ers
+}
+
+// TotalRows returns the product of the examined rows estimation which
+// reflects the effort of nested loop joins.
+func (qp *QueryPlan) TotalRows() int64 {
+	var t int64 = 1
+	for _, er := range qp.Rows {
+		if er.Rows > 0 {
+			t *= er.Rows
+		}
+	}
+	if len(qp.Rows) == 0 {
+		return 0
+	}
+	return t
+}
+
+// Explain runs EXPLAIN for the SELECT statement and returns the query plan.
+// If argument db is nil, the Querier of the Select gets used. For each full
+// table scan on one of the largeTables a warning gets added to the
+// QueryPlan and, if enabled, logged with level info. This helps to detect
+// missing indexes in automated performance tests. The names in largeTables
+// get compared with the table column of EXPLAIN which shows the alias, if
+// set.
+func (b *Select) Explain(ctx context.Context, db Querier, largeTables ...string) (*QueryPlan, error) {
+	sqlStr, args, err := b.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] Select.Explain.ToSQL")
+	}
+	if db == nil {
+		db = b.DB.Querier
+	}
+
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+sqlStr, args.Interfaces()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] Select.Explain.QueryContext")
+	}
+	defer rows.Close()
+
+	qp := &QueryPlan{SQL: sqlStr}
+	if qp.Rows, err = scanExplainRows(rows); err != nil {
+		return nil, errors.Wrap(err, "[dbr] Select.Explain.scanExplainRows")
+	}
+
+	for _, er := range qp.FullTableScans() {
+		for _, lt := range largeTables {
+			if er.Table != lt {
+				continue
+			}
+			msg := fmt.Sprintf("[dbr] Full table scan on large table %q examining %d rows", er.Table, er.Rows)
+			qp.Warnings = append(qp.Warnings, msg)
+			if b.Log != nil && b.Log.IsInfo() {
+				b.Log.Info("dbr.Select.Explain.FullTableScan", log.String("table", er.Table), log.Int64("rows", er.Rows), log.String("sql", sqlStr))
+			}
+		}
+	}
+	return qp, nil
+}
+
+// scanExplainRows maps the columns by name because the EXPLAIN output
+// differs between the server versions.
+func scanExplainRows(rows *sql.Rows) ([]ExplainRow, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] scanExplainRows.Columns")
+	}
+	vals := make([]sql.NullString, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+
+	var ers []ExplainRow
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, errors.Wrap(err, "[dbr] scanExplainRows.Scan")
+		}
+		var er ExplainRow
+		for i, c := range cols {
+			v := vals[i].String
+			switch strings.ToLower(c) {
+			case "id":
+				er.ID, _ = strconv.ParseInt(v, 10, 64)
+			case "select_type":
+				er.SelectType = v
+			case "table":
+				er.Table = v
+			case "partitions":
+				er.Partitions = v
+			case "type":
+				er.Type = v
+			case "possible_keys":
+				er.PossibleKeys = v
+			case "key":
+				er.Key = v
+			case "key_len":
+				er.KeyLen = v
+			case "ref":
+				er.Ref = v
+			case "rows":
+				er.Rows, _ = strconv.ParseInt(v, 10, 64)
+			case "filtered":
+				er.Filtered, _ = strconv.ParseFloat(v, 64)
+			case "extra":
+				er.Extra = v
+			}
+		}
+		ers = append(ers, er)
+	}
+	return ers, errors.Wrap(rows.Err(), "[dbr] scanExplainRows.Rows.Err")
+}
@@ -0,0 +1,102 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelect_Explain(t *testing.T) {
+
+	t.Run("ToSQL Error", func(t *testing.T) {
+		sel := &dbr.Select{Columns: []string{"a"}}
+		qp, err := sel.Explain(context.TODO(), nil)
+		assert.Nil(t, qp)
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+	})
+
+	t.Run("Query Error", func(t *testing.T) {
+		sel := dbr.NewSelect("a").From("tableX")
+		sel.DB.Querier = dbMock{
+			error: errors.NewAlreadyClosedf("Who closed myself?"),
+		}
+		qp, err := sel.Explain(context.TODO(), nil)
+		assert.Nil(t, qp)
+		assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
+	})
+
+	t.Run("full table scan on large table", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+			if err := dbMock.ExpectationsWereMet(); err != nil {
+				t.Error("there were unfulfilled expections", err)
+			}
+		}()
+
+		rows := sqlmock.NewRows([]string{"id", "select_type", "table", "partitions", "type", "possible_keys", "key", "key_len", "ref", "rows", "filtered", "Extra"}).
+			AddRow("1", "SIMPLE", "cpe", nil, "ALL", nil, nil, nil, nil, "98765", "10.00", "Using where").
+			AddRow("1", "SIMPLE", "cs", nil, "eq_ref", "PRIMARY", "PRIMARY", "2", "cpe.store_id", "1", "100.00", nil)
+		dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("EXPLAIN SELECT cpe.sku FROM `catalog_product_entity` AS `cpe` INNER JOIN `store` AS `cs` ON (cs.store_id = cpe.store_id) WHERE (`cpe`.`type_id` = ?)")).
+			WithArgs("simple").
+			WillReturnRows(rows)
+
+		sel := dbc.Select("cpe.sku").From("catalog_product_entity", "cpe").
+			Join(dbr.MakeAlias("store", "cs"), dbr.Condition("cs.store_id = cpe.store_id")).
+			Where(dbr.Condition("`cpe`.`type_id` = ?", dbr.ArgString("simple")))
+
+		qp, err := sel.Explain(context.TODO(), nil, "cpe")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Len(t, qp.Rows, 2)
+		assert.Exactly(t, dbr.ExplainRow{
+			ID: 1, SelectType: "SIMPLE", Table: "cpe", Type: "ALL", Rows: 98765, Filtered: 10, Extra: "Using where",
+		}, qp.Rows[0])
+		assert.Exactly(t, "cpe.store_id", qp.Rows[1].Ref)
+		assert.Len(t, qp.FullTableScans(), 1)
+		assert.Exactly(t, int64(98765), qp.TotalRows())
+		assert.Len(t, qp.Warnings, 1)
+		assert.Contains(t, qp.Warnings[0], `"cpe"`)
+	})
+
+	t.Run("old server without filtered column", func(t *testing.T) {
+		dbc, dbMock := cstesting.MockDB(t)
+		defer func() {
+			dbMock.ExpectClose()
+			assert.NoError(t, dbc.Close())
+		}()
+
+		dbMock.ExpectQuery("EXPLAIN SELECT").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "select_type", "table", "type", "possible_keys", "key", "key_len", "ref", "rows", "Extra"}).
+				AddRow("1", "SIMPLE", "core_config_data", "ALL", nil, nil, nil, nil, "42", nil),
+		)
+		qp, err := dbr.NewSelect("*").From("core_config_data").Explain(context.TODO(), dbc.DB)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, int64(42), qp.Rows[0].Rows)
+		assert.True(t, qp.Rows[0].IsFullTableScan())
+		assert.Empty(t, qp.Warnings, "no large tables configured")
+	})
+}
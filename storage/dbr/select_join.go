@@ -1,5 +1,7 @@
 package dbr
 
+import "github.com/corestoreio/errors"
+
 // JoinFragments defines multiple join conditions.
 type JoinFragments []*joinFragment
 
@@ -51,3 +53,24 @@ func (b *Select) OuterJoin(table alias, onConditions ...ConditionArg) *Select {
 func (b *Select) CrossJoin(table alias, onConditions ...ConditionArg) *Select {
 	return b.join("CROSS", table, onConditions...)
 }
+
+// sqlWriteJoins writes all JOIN clauses. The arguments of a derived table and
+// of the ON conditions get appended to args. The table names get rewritten by
+// the optional mapper. Derived tables and sub-selects inherit the mapper and
+// the dialect.
+func sqlWriteJoins(w queryWriter, m TableNameMapper, d Dialect, joins JoinFragments, args *Arguments) error {
+	for _, f := range joins {
+		w.WriteRune(' ')
+		w.WriteString(f.JoinType)
+		w.WriteString(" JOIN ")
+		tArgs, err := f.Table.mapTableName(m, d, true).FquoteAs(w)
+		if err != nil {
+			return errors.Wrap(err, "[dbr] sqlWriteJoins.Table.FquoteAs")
+		}
+		*args = append(*args, tArgs...)
+		if err := writeWhereFragmentsToSQL(f.OnConditions, w, m, d, args, 'j'); err != nil {
+			return errors.Wrap(err, "[dbr] sqlWriteJoins.writeWhereFragmentsToSQL")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,656 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/corestoreio/errors"
+)
+
+// This file contains the JSON serialization of the Select builder. Saved
+// filters or grid configurations can be stored in a database and re-hydrated
+// later into an executable builder. Only the query parts get serialized; the
+// DB, Log, Listeners and locking flags must be set by the caller.
+//
+// Stored JSON must be treated like user input. UnmarshalJSON rejects all parts
+// which bypass the quoting and the place holders: a raw SQL statement,
+// arguments created by ArgExpr or ArgUnsafe, conditions created by
+// ConditionUnsafe and every column, condition, GROUP BY or ORDER BY entry
+// which is not a plain or qualified identifier. Trusted data containing such
+// parts can be decoded with UnmarshalJSONUnsafe.
+
+// joinTypes contains the allowed values of joinJSON.Type. The join type gets
+// written unquoted into the query.
+var joinTypes = map[string]bool{
+	"INNER": true,
+	"LEFT":  true,
+	"RIGHT": true,
+	"CROSS": true,
+	"OUTER": true,
+}
+
+// operatorNames maps the Operator* constants to their JSON names.
+var operatorNames = map[byte]string{
+	Null:           "null",
+	NotNull:        "not_null",
+	In:             "in",
+	NotIn:          "not_in",
+	Between:        "between",
+	NotBetween:     "not_between",
+	Like:           "like",
+	NotLike:        "not_like",
+	Greatest:       "greatest",
+	Least:          "least",
+	Equal:          "=",
+	NotEqual:       "!=",
+	Exists:         "exists",
+	NotExists:      "not_exists",
+	Less:           "<",
+	Greater:        ">",
+	LessOrEqual:    "<=",
+	GreaterOrEqual: ">=",
+	Regexp:         "regexp",
+	NotRegexp:      "not_regexp",
+	Xor:            "xor",
+}
+
+var logicalNames = map[byte]string{
+	logicalAnd: "and",
+	logicalOr:  "or",
+	logicalXor: "xor",
+	logicalNot: "not",
+}
+
+func byteByName(m map[byte]string, kind, name string) (byte, error) {
+	if name == "" {
+		return 0, nil
+	}
+	for b, n := range m {
+		if n == name {
+			return b, nil
+		}
+	}
+	return 0, errors.NewNotValidf("[dbr] JSON: Unknown %s %q", kind, name)
+}
+
+type argumentJSON struct {
+	Type     string          `json:"type"`
+	Operator string          `json:"op,omitempty"`
+	Values   json.RawMessage `json:"values,omitempty"`
+	// SQL and Args are used by the types expr and tuple.
+	SQL   string         `json:"sql,omitempty"`
+	Width int            `json:"width,omitempty"`
+	Args  []argumentJSON `json:"args,omitempty"`
+}
+
+type aliasJSON struct {
+	Expression string          `json:"expression,omitempty"`
+	Alias      string          `json:"alias,omitempty"`
+	Select     json.RawMessage `json:"select,omitempty"`
+}
+
+type conditionJSON struct {
+	Logical     string          `json:"logical,omitempty"`
+	Condition   string          `json:"condition,omitempty"`
+	Unsafe      bool            `json:"unsafe,omitempty"`
+	Args        []argumentJSON  `json:"args,omitempty"`
+	Sub         json.RawMessage `json:"sub,omitempty"`
+	SubOperator string          `json:"sub_op,omitempty"`
+	Using       []string        `json:"using,omitempty"`
+}
+
+type joinJSON struct {
+	Type  string          `json:"type"`
+	Table aliasJSON       `json:"table"`
+	On    []conditionJSON `json:"on,omitempty"`
+}
+
+type selectJSON struct {
+	RawSQL      string          `json:"raw_sql,omitempty"`
+	Columns     []string        `json:"columns,omitempty"`
+	Args        []argumentJSON  `json:"args,omitempty"`
+	From        *aliasJSON      `json:"from,omitempty"`
+	Distinct    bool            `json:"distinct,omitempty"`
+	Joins       []joinJSON      `json:"joins,omitempty"`
+	Where       []conditionJSON `json:"where,omitempty"`
+	GroupBy     []string        `json:"group_by,omitempty"`
+	Having      []conditionJSON `json:"having,omitempty"`
+	OrderBy     []string        `json:"order_by,omitempty"`
+	OrderByArgs []argumentJSON  `json:"order_by_args,omitempty"`
+	Limit       *uint64         `json:"limit,omitempty"`
+	Offset      *uint64         `json:"offset,omitempty"`
+	Strict      bool            `json:"strict,omitempty"`
+	Comments    []string        `json:"comments,omitempty"`
+}
+
+// MarshalJSON serializes the columns, the table, the joins, the WHERE and
+// HAVING conditions with their typed arguments, GROUP BY, ORDER BY, LIMIT and
+// OFFSET. Supported arguments are those created by ArgString, ArgInt,
+// ArgInt64, ArgUint64, ArgFloat64, ArgBool, ArgTime, ArgBytes, ArgNull,
+// ArgNotNull, ArgExpr and ArgTuple. Other argument types return a
+// NotSupported error behaviour.
+func (b *Select) MarshalJSON() ([]byte, error) {
+	sj := selectJSON{
+		RawSQL:   b.RawFullSQL,
+		Columns:  b.Columns,
+		Distinct: b.IsDistinct,
+		GroupBy:  b.GroupBys,
+		OrderBy:  b.OrderBys,
+		Strict:   b.IsStrict,
+		Comments: b.Comments,
+	}
+	var err error
+	if sj.Args, err = marshalArguments(b.Arguments); err != nil {
+		return nil, errors.Wrap(err, "[dbr] Select.MarshalJSON.Arguments")
+	}
+	if sj.OrderByArgs, err = marshalArguments(b.OrderByArgs); err != nil {
+		return nil, errors.Wrap(err, "[dbr] Select.MarshalJSON.OrderByArgs")
+	}
+	if b.Table.Expression != "" || b.Table.Select != nil {
+		if sj.From, err = marshalAlias(b.Table); err != nil {
+			return nil, errors.Wrap(err, "[dbr] Select.MarshalJSON.Table")
+		}
+	}
+	for _, jf := range b.JoinFragments {
+		j := joinJSON{
+			Type: jf.JoinType,
+		}
+		t, err := marshalAlias(jf.Table)
+		if err != nil {
+			return nil, errors.Wrap(err, "[dbr] Select.MarshalJSON.JoinFragments")
+		}
+		j.Table = *t
+		if j.On, err = marshalConditions(jf.OnConditions); err != nil {
+			return nil, errors.Wrap(err, "[dbr] Select.MarshalJSON.JoinFragments")
+		}
+		sj.Joins = append(sj.Joins, j)
+	}
+	if sj.Where, err = marshalConditions(b.WhereFragments); err != nil {
+		return nil, errors.Wrap(err, "[dbr] Select.MarshalJSON.WhereFragments")
+	}
+	if sj.Having, err = marshalConditions(b.HavingFragments); err != nil {
+		return nil, errors.Wrap(err, "[dbr] Select.MarshalJSON.HavingFragments")
+	}
+	if b.LimitValid {
+		l := b.LimitCount
+		sj.Limit = &l
+	}
+	if b.OffsetValid {
+		o := b.OffsetCount
+		sj.Offset = &o
+	}
+	return json.Marshal(sj)
+}
+
+// UnmarshalJSON re-hydrates a builder serialized with MarshalJSON. All query
+// parts get replaced; the DB, Log, Dialect, Scanners and Listeners stay
+// untouched, so a builder created by a Connection can be used. The result
+// gets validated like in Strict mode: identifiers, parenthesis and the number
+// of place holders must match. A raw SQL statement, ArgExpr arguments,
+// conditions created by ConditionUnsafe and expressions get rejected, see
+// UnmarshalJSONUnsafe. Columns may contain a star and ORDER BY columns may end
+// with ASC or DESC. The join type must be one of INNER, LEFT, RIGHT, CROSS or
+// OUTER and comments must not contain characters which would get replaced
+// when writing the comment. Error behaviour: NotValid, NotSupported,
+// NotAllowed or Empty.
+func (b *Select) UnmarshalJSON(data []byte) error {
+	var d selectDecoder
+	return errors.Wrap(d.decode(data, b), "[dbr] Select.UnmarshalJSON")
+}
+
+// UnmarshalJSONUnsafe same as UnmarshalJSON but accepts additionally a raw SQL
+// statement, ArgExpr arguments, conditions created by ConditionUnsafe,
+// expressions and all comments. Use it only for trusted data. Each raw SQL fragment gets reported to the
+// SQLUnsafe audit hook with the location of the caller.
+func (b *Select) UnmarshalJSONUnsafe(data []byte) error {
+	d := selectDecoder{allowUnsafe: true}
+	if err := d.decode(data, b); err != nil {
+		return errors.Wrap(err, "[dbr] Select.UnmarshalJSONUnsafe")
+	}
+	for _, u := range d.unsafe {
+		reportSQLUnsafe(u, 2)
+	}
+	return nil
+}
+
+// selectDecoder re-hydrates a Select and its nested Selects with the same
+// settings.
+type selectDecoder struct {
+	allowUnsafe bool
+	// unsafe collects the raw SQL fragments for the audit hook.
+	unsafe []SQLUnsafe
+}
+
+// checkUnsafe returns a NotAllowed error if raw SQL is not allowed.
+func (d *selectDecoder) checkUnsafe(kind, raw string) error {
+	if !d.allowUnsafe {
+		return errors.NewNotAllowedf("[dbr] JSON: %s %q requires Select.UnmarshalJSONUnsafe", kind, raw)
+	}
+	d.unsafe = append(d.unsafe, SQLUnsafe(raw))
+	return nil
+}
+
+// checkIdentifiers treats each name which is not a plain or qualified
+// identifier as raw SQL, see checkUnsafe. Argument star allows "*" and
+// "table.*", argument sort allows the suffix ASC or DESC.
+func (d *selectDecoder) checkIdentifiers(kind string, star, sort bool, names []string) error {
+	for _, n := range names {
+		if !isJSONIdentifier(n, star, sort) {
+			if err := d.checkUnsafe(kind, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isJSONIdentifier(n string, star, sort bool) bool {
+	if star {
+		if n == "*" {
+			return true
+		}
+		if strings.HasSuffix(n, ".*") {
+			return !strings.Contains(n[:len(n)-2], ".") && isValidIdentifier(n[:len(n)-2]) == 0
+		}
+	}
+	if sort {
+		n, _ = splitSortDirection(n)
+	}
+	return isValidIdentifier(n) == 0
+}
+
+// checkComments returns a NotValid error if a comment contains characters
+// which sqlWriteComments would replace. Trusted data skips the check.
+func (d *selectDecoder) checkComments(comments []string) error {
+	if d.allowUnsafe {
+		return nil
+	}
+	for _, c := range comments {
+		for i := 0; i < len(c); i++ {
+			if !isCommentByte(c[i]) {
+				return errors.NewNotValidf("[dbr] JSON: Comment %q contains invalid characters", c)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *selectDecoder) decode(data []byte, b *Select) error {
+	var sj selectJSON
+	if err := json.Unmarshal(data, &sj); err != nil {
+		return errors.NewNotValid(err, "[dbr] JSON: Failed to unmarshal Select")
+	}
+	if sj.RawSQL == "" && sj.From == nil {
+		return errors.NewEmptyf("[dbr] JSON: Table is missing")
+	}
+	if sj.RawSQL != "" {
+		if err := d.checkUnsafe("raw SQL", sj.RawSQL); err != nil {
+			return err
+		}
+	}
+	if err := d.checkIdentifiers("Column", true, false, sj.Columns); err != nil {
+		return err
+	}
+	if err := d.checkIdentifiers("GROUP BY", false, false, sj.GroupBy); err != nil {
+		return err
+	}
+	if err := d.checkIdentifiers("ORDER BY", false, true, sj.OrderBy); err != nil {
+		return err
+	}
+	if err := d.checkComments(sj.Comments); err != nil {
+		return err
+	}
+
+	args, err := d.arguments(sj.Args)
+	if err != nil {
+		return errors.Wrap(err, "[dbr] JSON: Arguments")
+	}
+	orderArgs, err := d.arguments(sj.OrderByArgs)
+	if err != nil {
+		return errors.Wrap(err, "[dbr] JSON: OrderByArgs")
+	}
+	var table alias
+	if sj.From != nil {
+		if table, err = d.alias(*sj.From); err != nil {
+			return errors.Wrap(err, "[dbr] JSON: Table")
+		}
+	}
+	var joins JoinFragments
+	for _, j := range sj.Joins {
+		if !joinTypes[j.Type] {
+			return errors.NewNotValidf("[dbr] JSON: Unknown join type %q", j.Type)
+		}
+		jf := &joinFragment{
+			JoinType: j.Type,
+		}
+		if jf.Table, err = d.alias(j.Table); err != nil {
+			return errors.Wrap(err, "[dbr] JSON: JoinFragments")
+		}
+		if jf.OnConditions, err = d.conditions(j.On); err != nil {
+			return errors.Wrap(err, "[dbr] JSON: JoinFragments")
+		}
+		joins = append(joins, jf)
+	}
+	where, err := d.conditions(sj.Where)
+	if err != nil {
+		return errors.Wrap(err, "[dbr] JSON: WhereFragments")
+	}
+	having, err := d.conditions(sj.Having)
+	if err != nil {
+		return errors.Wrap(err, "[dbr] JSON: HavingFragments")
+	}
+
+	b.RawFullSQL = sj.RawSQL
+	b.Columns = sj.Columns
+	b.Arguments = args
+	b.Table = table
+	b.IsDistinct = sj.Distinct
+	b.JoinFragments = joins
+	b.WhereFragments = where
+	b.GroupBys = sj.GroupBy
+	b.HavingFragments = having
+	b.OrderBys = sj.OrderBy
+	b.OrderByArgs = orderArgs
+	b.LimitCount, b.LimitValid = 0, sj.Limit != nil
+	if b.LimitValid {
+		b.LimitCount = *sj.Limit
+	}
+	b.OffsetCount, b.OffsetValid = 0, sj.Offset != nil
+	if b.OffsetValid {
+		b.OffsetCount = *sj.Offset
+	}
+	b.IsStrict = sj.Strict
+	b.Comments = sj.Comments
+
+	return errors.Wrap(b.validate(), "[dbr] JSON: validate")
+}
+
+func (d *selectDecoder) alias(aj aliasJSON) (alias, error) {
+	t := alias{Expression: aj.Expression, Alias: aj.Alias}
+	if len(aj.Select) > 0 {
+		t.Select = new(Select)
+		if err := d.decode(aj.Select, t.Select); err != nil {
+			return t, errors.Wrap(err, "[dbr] JSON: Derived table")
+		}
+	}
+	return t, nil
+}
+
+func marshalAlias(t alias) (*aliasJSON, error) {
+	aj := &aliasJSON{Expression: t.Expression, Alias: t.Alias}
+	var err error
+	aj.Select, err = marshalSub(t.Select)
+	return aj, err
+}
+
+// marshalSub returns nil if s is nil.
+func marshalSub(s *Select) (json.RawMessage, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.MarshalJSON()
+}
+
+func marshalConditions(wfs WhereFragments) ([]conditionJSON, error) {
+	if len(wfs) == 0 {
+		return nil, nil
+	}
+	cjs := make([]conditionJSON, 0, len(wfs))
+	for _, wf := range wfs {
+		cj := conditionJSON{
+			Logical:   logicalNames[wf.Logical],
+			Condition: wf.Condition,
+			Unsafe:    wf.isUnsafe,
+			Using:     wf.Using,
+		}
+		if wf.Sub.Operator > 0 {
+			cj.SubOperator = operatorNames[wf.Sub.Operator]
+		}
+		var err error
+		if cj.Sub, err = marshalSub(wf.Sub.Select); err != nil {
+			return nil, errors.Wrapf(err, "[dbr] Condition %q", wf.Condition)
+		}
+		if cj.Args, err = marshalArguments(wf.Arguments); err != nil {
+			return nil, errors.Wrapf(err, "[dbr] Condition %q", wf.Condition)
+		}
+		cjs = append(cjs, cj)
+	}
+	return cjs, nil
+}
+
+func (d *selectDecoder) conditions(cjs []conditionJSON) (WhereFragments, error) {
+	if len(cjs) == 0 {
+		return nil, nil
+	}
+	wfs := make(WhereFragments, 0, len(cjs))
+	for _, cj := range cjs {
+		wf := &whereFragment{
+			Condition: cj.Condition,
+			Using:     cj.Using,
+			isUnsafe:  cj.Unsafe,
+		}
+		switch {
+		case cj.Unsafe:
+			if err := d.checkUnsafe("ConditionUnsafe", cj.Condition); err != nil {
+				return nil, err
+			}
+		case cj.Condition == "", cj.Condition == "(", cj.Condition == ")":
+			// USING, EXISTS or parenthesis
+		case isValidIdentifier(cj.Condition) > 0:
+			if err := d.checkUnsafe("Condition expression", cj.Condition); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if wf.Logical, err = byteByName(logicalNames, "logical operator", cj.Logical); err != nil {
+			return nil, errors.Wrapf(err, "[dbr] Condition %q", cj.Condition)
+		}
+		if wf.Sub.Operator, err = byteByName(operatorNames, "operator", cj.SubOperator); err != nil {
+			return nil, errors.Wrapf(err, "[dbr] Condition %q", cj.Condition)
+		}
+		if len(cj.Sub) > 0 {
+			wf.Sub.Select = new(Select)
+			if err := d.decode(cj.Sub, wf.Sub.Select); err != nil {
+				return nil, errors.Wrapf(err, "[dbr] Condition %q", cj.Condition)
+			}
+		}
+		if wf.Arguments, err = d.arguments(cj.Args); err != nil {
+			return nil, errors.Wrapf(err, "[dbr] Condition %q", cj.Condition)
+		}
+		wfs = append(wfs, wf)
+	}
+	return wfs, nil
+}
+
+func marshalArguments(args Arguments) ([]argumentJSON, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	ajs := make([]argumentJSON, 0, len(args))
+	for _, a := range args {
+		aj, err := marshalArgument(a)
+		if err != nil {
+			return nil, err
+		}
+		ajs = append(ajs, aj)
+	}
+	return ajs, nil
+}
+
+func marshalArgument(a Argument) (aj argumentJSON, err error) {
+	var values interface{}
+	switch v := a.(type) {
+	case argString:
+		aj.Type, values = "string", []string{string(v)}
+	case *argStrings:
+		aj.Type, values = "string", v.data
+	case argInt:
+		aj.Type, values = "int", []int{int(v)}
+	case *argInts:
+		aj.Type, values = "int", v.data
+	case argInt64:
+		aj.Type, values = "int64", []int64{int64(v)}
+	case *argInt64s:
+		aj.Type, values = "int64", v.data
+	case argUint64:
+		aj.Type, values = "uint64", []uint64{uint64(v)}
+	case *argUint64s:
+		aj.Type, values = "uint64", v.data
+	case argFloat64:
+		aj.Type, values = "float64", []float64{float64(v)}
+	case *argFloat64s:
+		aj.Type, values = "float64", v.data
+	case argBool:
+		aj.Type, values = "bool", []bool{bool(v)}
+	case *argBools:
+		aj.Type, values = "bool", v.data
+	case *argTimes:
+		aj.Type, values = "time", v.data
+	case argBytes:
+		aj.Type, values = "bytes", []byte(v)
+	case argNull:
+		aj.Type = "null"
+	case *expr:
+		aj.Type, aj.SQL = "expr", v.SQL
+		if aj.Args, err = marshalArguments(v.Arguments); err != nil {
+			return aj, errors.Wrap(err, "[dbr] ArgExpr")
+		}
+	case *argTuple:
+		aj.Type, aj.Width = "tuple", v.width
+		if aj.Args, err = marshalArguments(v.args); err != nil {
+			return aj, errors.Wrap(err, "[dbr] ArgTuple")
+		}
+	default:
+		return aj, errors.NewNotSupportedf("[dbr] JSON: Argument type %T not supported", a)
+	}
+	if op := a.operator(); op > 0 {
+		aj.Operator = operatorNames[op]
+	}
+	if values != nil {
+		aj.Values, err = json.Marshal(values)
+	}
+	return aj, errors.Wrapf(err, "[dbr] JSON: Failed to marshal %s values", aj.Type)
+}
+
+func (d *selectDecoder) arguments(ajs []argumentJSON) (Arguments, error) {
+	if len(ajs) == 0 {
+		return nil, nil
+	}
+	args := make(Arguments, 0, len(ajs))
+	for _, aj := range ajs {
+		a, err := d.argument(aj)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+	}
+	return args, nil
+}
+
+func (d *selectDecoder) argument(aj argumentJSON) (Argument, error) {
+	op, err := byteByName(operatorNames, "operator", aj.Operator)
+	if err != nil {
+		return nil, err
+	}
+
+	// decode unmarshals the values into the typed slice and checks that at
+	// least one value has been provided.
+	decode := func(dst interface{}, l func() int) error {
+		if len(aj.Values) > 0 {
+			if err := json.Unmarshal(aj.Values, dst); err != nil {
+				return errors.NewNotValid(err, "[dbr] JSON: Failed to unmarshal "+aj.Type+" values")
+			}
+		}
+		if l() == 0 {
+			return errors.NewEmptyf("[dbr] JSON: Argument type %q requires values", aj.Type)
+		}
+		return nil
+	}
+
+	var a Argument
+	switch aj.Type {
+	case "string":
+		var v []string
+		err = decode(&v, func() int { return len(v) })
+		a = ArgString(v...)
+	case "int":
+		var v []int
+		err = decode(&v, func() int { return len(v) })
+		a = ArgInt(v...)
+	case "int64":
+		var v []int64
+		err = decode(&v, func() int { return len(v) })
+		a = ArgInt64(v...)
+	case "uint64":
+		var v []uint64
+		err = decode(&v, func() int { return len(v) })
+		a = ArgUint64(v...)
+	case "float64":
+		var v []float64
+		err = decode(&v, func() int { return len(v) })
+		a = ArgFloat64(v...)
+	case "bool":
+		var v []bool
+		err = decode(&v, func() int { return len(v) })
+		a = ArgBool(v...)
+	case "time":
+		var v []time.Time
+		err = decode(&v, func() int { return len(v) })
+		a = ArgTime(v...)
+	case "bytes":
+		var v []byte
+		if len(aj.Values) > 0 {
+			if err := json.Unmarshal(aj.Values, &v); err != nil {
+				return nil, errors.NewNotValid(err, "[dbr] JSON: Failed to unmarshal bytes values")
+			}
+		}
+		return ArgBytes(v), nil
+	case "null":
+		if op == NotNull {
+			return ArgNotNull(), nil
+		}
+		return ArgNull(), nil
+	case "expr":
+		if err := d.checkUnsafe("ArgExpr", aj.SQL); err != nil {
+			return nil, err
+		}
+		args, err := d.arguments(aj.Args)
+		if err != nil {
+			return nil, errors.Wrap(err, "[dbr] ArgExpr")
+		}
+		a = &expr{SQL: aj.SQL, Arguments: args}
+	case "tuple":
+		args, err := d.arguments(aj.Args)
+		if err != nil {
+			return nil, errors.Wrap(err, "[dbr] ArgTuple")
+		}
+		if aj.Width < 1 {
+			return nil, errors.NewNotValidf("[dbr] JSON: ArgTuple width must be greater zero")
+		}
+		a = ArgTuple(aj.Width, args...)
+	default:
+		return nil, errors.NewNotSupportedf("[dbr] JSON: Argument type %q not supported", aj.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if op > 0 {
+		a = a.Operator(op)
+	}
+	return a, nil
+}
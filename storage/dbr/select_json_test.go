@@ -0,0 +1,334 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect_JSON_Roundtrip(t *testing.T) {
+	now := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		unsafe bool
+		sel    *dbr.Select
+	}{
+		{
+			"full", true,
+			dbr.NewSelect("e.entity_id", "e.sku", "COUNT(*) AS cnt").Distinct().
+				From("catalog_product_entity", "e").
+				LeftJoin(dbr.MakeAlias("catalog_product_entity_int", "i"),
+					dbr.Condition("e.entity_id = i.entity_id"),
+					dbr.Condition("i.attribute_id", dbr.ArgInt64(42)),
+				).
+				Where(
+					dbr.ParenthesisOpen(),
+					dbr.Condition("e.sku", dbr.ArgString("a%").Operator(dbr.Like)),
+					dbr.Condition("e.type_id", dbr.ArgString("simple", "virtual").Operator(dbr.In)).Or(),
+					dbr.ParenthesisClose(),
+					dbr.Condition("e.created_at", dbr.ArgTime(now, now.Add(time.Hour)).Operator(dbr.Between)),
+					dbr.Condition("e.price", dbr.ArgFloat64(9.99).Operator(dbr.GreaterOrEqual)),
+					dbr.Condition("e.has_options", dbr.ArgBool(true)),
+					dbr.Condition("e.required_options", dbr.ArgNotNull()),
+					dbr.Condition("e.attribute_set_id", dbr.ArgUint64(4, 9).Operator(dbr.NotIn)),
+					dbr.Condition("e.updated_at > ?", dbr.ArgExpr("NOW()")),
+					dbr.Tuple([]string{"e.entity_id", "e.row_id"}, dbr.ArgTuple(2, dbr.ArgInt(1, 11, 2, 22))),
+				).
+				GroupBy("e.entity_id").
+				Having(dbr.Condition("cnt", dbr.ArgInt(3).Operator(dbr.Greater))).
+				OrderByDesc("e.sku").
+				Limit(10).Offset(20),
+		},
+		{
+			"sub select", false,
+			dbr.NewSelect("entity_id").From("catalog_product_entity").
+				Where(dbr.SubSelect("entity_id", dbr.In,
+					dbr.NewSelect("product_id").From("catalog_category_product").
+						Where(dbr.Condition("category_id", dbr.ArgInt64(3))),
+				)),
+		},
+		{
+			"order by expression", true,
+			dbr.NewSelect("code").From("store").
+				OrderByExpr("FIELD(code, ?...)", dbr.ArgString("de", "at")).
+				OrderByNullsLast("sort_order"),
+		},
+		{
+			"unsafe condition", true,
+			dbr.NewSelect("entity_id").From("catalog_product_entity").
+				Where(dbr.ConditionUnsafe("entity_id")).
+				Join(dbr.MakeAliasFromSub(dbr.NewSelect("entity_id").From("catalog_product_entity_int").
+					Where(dbr.Condition("value", dbr.ArgExpr("FLOOR(?)", dbr.ArgFloat64(2.5)))), "i"),
+					dbr.Condition("i.entity_id = catalog_product_entity.entity_id")),
+		},
+		{
+			"raw", true,
+			&dbr.Select{RawFullSQL: "SELECT * FROM `core_config_data` WHERE path = ?", Arguments: dbr.Arguments{dbr.ArgString("web/url")}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wantSQL, wantArgs, err := test.sel.ToSQL()
+			require.NoError(t, err, "%+v", err)
+
+			data, err := json.Marshal(test.sel)
+			require.NoError(t, err, "%+v", err)
+
+			var have dbr.Select
+			if test.unsafe {
+				err = json.Unmarshal(data, &have)
+				assert.True(t, errors.IsNotAllowed(err), "%+v", err)
+				require.NoError(t, have.UnmarshalJSONUnsafe(data))
+			} else {
+				require.NoError(t, json.Unmarshal(data, &have))
+			}
+			haveSQL, haveArgs, err := have.ToSQL()
+			require.NoError(t, err, "%+v", err)
+
+			assert.Exactly(t, wantSQL, haveSQL)
+			assert.Equal(t, wantArgs.Interfaces(), haveArgs.Interfaces())
+		})
+	}
+}
+
+func TestSelect_MarshalJSON(t *testing.T) {
+	sel := dbr.NewSelect("sku").From("catalog_product_entity").
+		Where(dbr.Condition("entity_id", dbr.ArgInt64(3, 4).Operator(dbr.In))).Limit(5)
+	data, err := json.Marshal(sel)
+	require.NoError(t, err)
+	assert.Exactly(t,
+		`{"columns":["sku"],"from":{"expression":"catalog_product_entity"},"where":[{"condition":"entity_id","args":[{"type":"int64","op":"in","values":[3,4]}]}],"limit":5}`,
+		string(data))
+
+	t.Run("unsupported argument", func(t *testing.T) {
+		sel := dbr.NewSelect("sku").From("catalog_product_entity").
+			Where(dbr.Condition("sku", dbr.ArgNullString(dbr.MakeNullString("a"))))
+		_, err := json.Marshal(sel)
+		assert.True(t, errors.IsNotSupported(errors.Cause(err.(*json.MarshalerError).Err)), "%+v", err)
+	})
+}
+
+func TestSelect_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantSQL string
+		errBhf  errors.BehaviourFunc
+	}{
+		{
+			"valid",
+			`{"columns":["sku"],"from":{"expression":"catalog_product_entity","alias":"e"},"where":[{"condition":"e.entity_id","args":[{"type":"int64","op":">","values":[3]}]}]}`,
+			"SELECT sku FROM `catalog_product_entity` AS `e` WHERE (`e`.`entity_id` > ?)",
+			nil,
+		},
+		{
+			"missing table",
+			`{"columns":["sku"]}`,
+			"",
+			errors.IsEmpty,
+		},
+		{
+			"invalid table",
+			`{"columns":["sku"],"from":{"expression":"catalog_product_entity; DROP TABLE x"}}`,
+			"",
+			errors.IsNotValid,
+		},
+		{
+			"unknown operator",
+			`{"from":{"expression":"a"},"where":[{"condition":"b","args":[{"type":"int","op":"~","values":[1]}]}]}`,
+			"",
+			errors.IsNotValid,
+		},
+		{
+			"unknown argument type",
+			`{"from":{"expression":"a"},"where":[{"condition":"b","args":[{"type":"complex","values":[1]}]}]}`,
+			"",
+			errors.IsNotSupported,
+		},
+		{
+			"argument without values",
+			`{"from":{"expression":"a"},"where":[{"condition":"b","args":[{"type":"string"}]}]}`,
+			"",
+			errors.IsEmpty,
+		},
+		{
+			"wrong value type",
+			`{"from":{"expression":"a"},"where":[{"condition":"b","args":[{"type":"int","values":["x"]}]}]}`,
+			"",
+			errors.IsNotValid,
+		},
+		{
+			"star and sort direction",
+			`{"columns":["*","e.*"],"from":{"expression":"a","alias":"e"},"group_by":["e.b"],"order_by":["e.b DESC","c"],"comments":["req-id 4711"]}`,
+			"/* req-id 4711 */ SELECT *, e.* FROM `a` AS `e` GROUP BY e.b ORDER BY e.b DESC, c",
+			nil,
+		},
+		{
+			"join type",
+			`{"columns":["a.d"],"from":{"expression":"a"},"joins":[{"type":"LEFT","table":{"expression":"b"},"on":[{"condition":"b.c","args":[{"type":"int","values":[1]}]}]}]}`,
+			"SELECT a.d FROM `a` LEFT JOIN `b` ON (`b`.`c` = ?)",
+			nil,
+		},
+		{
+			"place holder mismatch",
+			`{"from":{"expression":"a"},"where":[{"condition":"b","args":[{"type":"int","values":[1]},{"type":"int","values":[2]}]}]}`,
+			"",
+			errors.IsNotValid,
+		},
+		{
+			"expression in column",
+			`{"columns":["(SELECT password FROM admin_user) AS p"],"from":{"expression":"a"}}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"sort direction in column",
+			`{"columns":["b DESC"],"from":{"expression":"a"}}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"expression in group by",
+			`{"from":{"expression":"a"},"group_by":["b HAVING SLEEP(10)"]}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"expression in order by",
+			`{"from":{"expression":"a"},"order_by":["IF((SELECT COUNT(*) FROM admin_user) > 0, b, c)"]}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"star in order by",
+			`{"from":{"expression":"a"},"order_by":["*"]}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"expression in safe condition",
+			`{"from":{"expression":"a"},"where":[{"condition":"1=1) UNION SELECT password FROM admin_user -- "}]}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"expression in join condition",
+			`{"from":{"expression":"a"},"joins":[{"type":"INNER","table":{"expression":"b"},"on":[{"condition":"1=1 OR SLEEP(10)"}]}]}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"unknown join type",
+			`{"from":{"expression":"a"},"joins":[{"type":"INNER JOIN admin_user ON 1=1 INNER","table":{"expression":"b"}}]}`,
+			"",
+			errors.IsNotValid,
+		},
+		{
+			"comment with invalid characters",
+			`{"from":{"expression":"a"},"comments":["*/ DROP TABLE a; /*"]}`,
+			"",
+			errors.IsNotValid,
+		},
+		{
+			"raw SQL",
+			`{"raw_sql":"SELECT * FROM admin_user","from":{"expression":"a"}}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"expr argument",
+			`{"from":{"expression":"a"},"where":[{"condition":"b","args":[{"type":"expr","sql":"(SELECT password FROM admin_user)"}]}]}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"unsafe condition",
+			`{"from":{"expression":"a"},"where":[{"condition":"1=1) UNION SELECT password FROM admin_user -- ","unsafe":true}]}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"expr argument in sub select",
+			`{"from":{"expression":"a"},"where":[{"condition":"b","sub_op":"in","sub":{"columns":["c"],"from":{"expression":"d"},"where":[{"condition":"e","args":[{"type":"expr","sql":"SLEEP(10)"}]}]}}]}`,
+			"",
+			errors.IsNotAllowed,
+		},
+		{
+			"invalid sub select",
+			`{"from":{"expression":"a"},"where":[{"condition":"b","sub_op":"in","sub":{"columns":["c"],"from":{"expression":"d; DROP TABLE x"}}}]}`,
+			"",
+			errors.IsNotValid,
+		},
+		{
+			"unbalanced parenthesis",
+			`{"from":{"expression":"a"},"where":[{"condition":"("},{"condition":"b","args":[{"type":"int","values":[1]}]}]}`,
+			"",
+			errors.IsNotValid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sel dbr.Select
+			err := json.Unmarshal([]byte(test.data), &sel)
+			if test.errBhf != nil {
+				assert.True(t, test.errBhf(err), "%+v", err)
+				return
+			}
+			require.NoError(t, err, "%+v", err)
+			sqlStr, _, err := sel.ToSQL()
+			require.NoError(t, err, "%+v", err)
+			assert.Exactly(t, test.wantSQL, sqlStr)
+		})
+	}
+}
+
+func TestSelect_UnmarshalJSONUnsafe(t *testing.T) {
+	var usages []dbr.SQLUnsafeUsage
+	dbr.SetSQLUnsafeAudit(func(u dbr.SQLUnsafeUsage) {
+		usages = append(usages, u)
+	})
+	defer dbr.SetSQLUnsafeAudit(nil)
+
+	t.Run("raw SQL place holder mismatch", func(t *testing.T) {
+		var sel dbr.Select
+		err := sel.UnmarshalJSONUnsafe([]byte(`{"raw_sql":"SELECT * FROM a WHERE b = ?"}`))
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+
+	usages = nil
+	var sel dbr.Select
+	require.NoError(t, sel.UnmarshalJSONUnsafe([]byte(
+		`{"columns":["a","COUNT(*)"],"from":{"expression":"b"},"where":[{"condition":"c","args":[{"type":"expr","sql":"NOW()"}]},{"condition":"d IS NULL","unsafe":true},{"condition":"e > 1"}],"comments":["*/"]}`,
+	)))
+	sqlStr, _, err := sel.ToSQL()
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, "/* __ */ SELECT a, COUNT(*) FROM `b` WHERE (`c` = ?) AND (d IS NULL) AND (e > 1)", sqlStr)
+
+	require.Len(t, usages, 4)
+	assert.Exactly(t, dbr.SQLUnsafe("COUNT(*)"), usages[0].SQL)
+	assert.Exactly(t, dbr.SQLUnsafe("NOW()"), usages[1].SQL)
+	assert.Exactly(t, dbr.SQLUnsafe("d IS NULL"), usages[2].SQL)
+	assert.Exactly(t, dbr.SQLUnsafe("e > 1"), usages[3].SQL)
+	assert.Exactly(t, "dbr_test.TestSelect_UnmarshalJSONUnsafe", usages[0].Func)
+	assert.Contains(t, usages[0].File, "select_json_test.go")
+}
@@ -22,8 +22,8 @@ func (b *Select) Rows(ctx context.Context) (*sql.Rows, error) {
 		defer log.WhenDone(b.Log).Info("dbr.Select.Rows.Timing", log.String("sql", sqlStr))
 	}
 
-	rows, err := b.DB.QueryContext(ctx, sqlStr, args.Interfaces()...)
-	return rows, errors.Wrap(err, "[store] Select.Rows.QueryContext")
+	rows, err := b.DB.QueryContext(withQueryDeadline(ctx, b.QueryTimeout), sqlStr, args.Interfaces()...)
+	return rows, wrapMySQLError(err, "[store] Select.Rows.QueryContext")
 }
 
 // Row executes a query that at expected to return at most one row. QueryRow
@@ -36,11 +36,13 @@ func (b *Select) Row(ctx context.Context) *sql.Row {
 		panic(err) // todo remove panic and log error .... ?
 		// return nil, errors.Wrap(err, "[store] Select.Rows.ToSQL")
 	}
-	return b.DB.QueryRowContext(ctx, sqlStr, args.Interfaces()...)
+	return b.DB.QueryRowContext(withQueryDeadline(ctx, b.QueryTimeout), sqlStr, args.Interfaces()...)
 }
 
 // Prepare prepares a SQL statement.
 func (b *Select) Prepare(ctx context.Context) (*sql.Stmt, error) {
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
 
 	sqlStr, _, err := b.ToSQL()
 	if err != nil {
@@ -62,6 +64,8 @@ func (b *Select) Prepare(ctx context.Context) (*sql.Stmt, error) {
 // number of items found (which at not necessarily the # of items set). Slow
 // because of the massive use of reflection.
 func (b *Select) LoadStructs(ctx context.Context, dest interface{}) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
 	//
 	// Validate the dest, and extract the reflection values we need.
 	//
@@ -96,7 +100,7 @@ func (b *Select) LoadStructs(ctx context.Context, dest interface{}) (int, error)
 	//
 	// Get full SQL
 	//
-	tSQL, tArg, err := b.ToSQL()
+	tSQL, tArg, err := b.rawSQL()
 	if err != nil {
 		return 0, errors.Wrap(err, "[dbr] Select.LoadStructs.ToSQL")
 	}
@@ -105,6 +109,7 @@ func (b *Select) LoadStructs(ctx context.Context, dest interface{}) (int, error)
 	if err != nil {
 		return 0, errors.Wrap(err, "[dbr] Select.LoadStructs.Preprocess")
 	}
+	fullSQL = Rebind(b.Dialect, fullSQL)
 
 	numberOfRowsReturned := 0
 
@@ -116,7 +121,7 @@ func (b *Select) LoadStructs(ctx context.Context, dest interface{}) (int, error)
 	// Run the query:
 	rows, err := b.DB.QueryContext(ctx, fullSQL)
 	if err != nil {
-		return 0, errors.Wrap(err, "[dbr] Select.LoadStructs.query")
+		return 0, wrapMySQLError(err, "[dbr] Select.LoadStructs.query")
 	}
 	defer rows.Close()
 
@@ -132,6 +137,11 @@ func (b *Select) LoadStructs(ctx context.Context, dest interface{}) (int, error)
 		return numberOfRowsReturned, errors.Wrap(err, "[dbr] Select.LoadStructs.calculateFieldMap")
 	}
 
+	scanFns, err := b.Scanners.columnScanners(rows, columns)
+	if err != nil {
+		return numberOfRowsReturned, errors.Wrap(err, "[dbr] Select.LoadStructs.columnScanners")
+	}
+
 	// Build a 'holder', which at an []interface{}. Each value will be the set to address of the field corresponding to our newly made records:
 	holder := make([]interface{}, len(fieldMap))
 
@@ -143,7 +153,7 @@ func (b *Select) LoadStructs(ctx context.Context, dest interface{}) (int, error)
 		newRecord := reflect.Indirect(pointerToNewRecord)
 
 		// Prepare the holder for this record
-		scannable, err := prepareHolderFor(newRecord, fieldMap, holder)
+		scannable, err := prepareHolderFor(newRecord, fieldMap, scanFns, holder)
 		if err != nil {
 			return numberOfRowsReturned, errors.Wrap(err, "[dbr] Select.LoadStructs.holderFor")
 		}
@@ -163,7 +173,7 @@ func (b *Select) LoadStructs(ctx context.Context, dest interface{}) (int, error)
 
 	// Check for errors at the end. Supposedly these are error that can happen during iteration.
 	if err = rows.Err(); err != nil {
-		return numberOfRowsReturned, errors.Wrap(err, "[dbr] Select.LoadStructs.rows_err")
+		return numberOfRowsReturned, wrapMySQLError(err, "[dbr] Select.LoadStructs.rows_err")
 	}
 
 	return numberOfRowsReturned, nil
@@ -173,6 +183,8 @@ func (b *Select) LoadStructs(ctx context.Context, dest interface{}) (int, error)
 // dest must be a pointer to a struct Returns ErrNotFound behaviour. Slow
 // because of the massive use of reflection.
 func (b *Select) LoadStruct(ctx context.Context, dest interface{}) error {
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
 	//
 	// Validate the dest, and extract the reflection values we need.
 	//
@@ -189,7 +201,7 @@ func (b *Select) LoadStruct(ctx context.Context, dest interface{}) error {
 	//
 	// Get full SQL
 	//
-	tSQL, tArg, err := b.ToSQL()
+	tSQL, tArg, err := b.rawSQL()
 	if err != nil {
 		return errors.Wrap(err, "[dbr] Select.LoadStruct.ToSQL")
 	}
@@ -198,6 +210,7 @@ func (b *Select) LoadStruct(ctx context.Context, dest interface{}) error {
 	if err != nil {
 		return err
 	}
+	fullSQL = Rebind(b.Dialect, fullSQL)
 
 	if b.Log != nil && b.Log.IsInfo() {
 		defer log.WhenDone(b.Log).Info("dbr.Select.LoadStruct.ExecContext.timing", log.String("sql", fullSQL))
@@ -206,7 +219,7 @@ func (b *Select) LoadStruct(ctx context.Context, dest interface{}) error {
 	// Run the query:
 	rows, err := b.DB.QueryContext(ctx, fullSQL)
 	if err != nil {
-		return errors.Wrap(err, "[dbr] Select.load_one.query")
+		return wrapMySQLError(err, "[dbr] Select.load_one.query")
 	}
 	defer rows.Close()
 
@@ -222,6 +235,11 @@ func (b *Select) LoadStruct(ctx context.Context, dest interface{}) error {
 		return errors.Wrap(err, "[dbr] Select.load_one.calculateFieldMap")
 	}
 
+	scanFns, err := b.Scanners.columnScanners(rows, columns)
+	if err != nil {
+		return errors.Wrap(err, "[dbr] Select.load_one.columnScanners")
+	}
+
 	// Build a 'holder', which at an []interface{}. Each value will be the set to
 	// address of the field corresponding to our newly made records:
 	holder := make([]interface{}, len(fieldMap))
@@ -229,7 +247,7 @@ func (b *Select) LoadStruct(ctx context.Context, dest interface{}) error {
 	if rows.Next() {
 		// Build a 'holder', which at an []interface{}. Each value will be the address
 		// of the field corresponding to our newly made record:
-		scannable, err := prepareHolderFor(indirectOfDest, fieldMap, holder)
+		scannable, err := prepareHolderFor(indirectOfDest, fieldMap, scanFns, holder)
 		if err != nil {
 			return errors.Wrap(err, "[dbr] Select.load_one.holderFor")
 		}
@@ -243,16 +261,93 @@ func (b *Select) LoadStruct(ctx context.Context, dest interface{}) error {
 	}
 
 	if err := rows.Err(); err != nil {
-		return errors.Wrap(err, "[dbr] Select.load_one.rows_err")
+		return wrapMySQLError(err, "[dbr] Select.load_one.rows_err")
 	}
 
 	return errors.NewNotFoundf("[dbr] Entry not found")
 }
 
+// LoadMaps executes the Select and appends each row as a map, column name to
+// value, to dest. Columns with a registered ScanFunc get decoded by that
+// function, all other []byte values get converted to a string. Returns the
+// number of rows loaded.
+func (b *Select) LoadMaps(ctx context.Context, dest *[]map[string]interface{}) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
+	if dest == nil {
+		return 0, errors.NewNotValidf("[dbr] invalid type passed to LoadMaps. Need a pointer to a slice of maps")
+	}
+
+	tSQL, tArg, err := b.rawSQL()
+	if err != nil {
+		return 0, errors.Wrap(err, "[dbr] Select.LoadMaps.ToSQL")
+	}
+
+	fullSQL, err := Preprocess(tSQL, tArg...)
+	if err != nil {
+		return 0, errors.Wrap(err, "[dbr] Select.LoadMaps.Preprocess")
+	}
+	fullSQL = Rebind(b.Dialect, fullSQL)
+
+	if b.Log != nil && b.Log.IsInfo() {
+		defer log.WhenDone(b.Log).Info("dbr.Select.LoadMaps.QueryContext.timing", log.String("sql", tSQL))
+	}
+
+	rows, err := b.DB.QueryContext(ctx, fullSQL)
+	if err != nil {
+		return 0, wrapMySQLError(err, "[dbr] Select.LoadMaps.query")
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, errors.Wrap(err, "[dbr] Select.LoadMaps.rows.Columns")
+	}
+
+	scanFns, err := b.Scanners.columnScanners(rows, columns)
+	if err != nil {
+		return 0, errors.Wrap(err, "[dbr] Select.LoadMaps.columnScanners")
+	}
+
+	numberOfRowsReturned := 0
+	values := make([]interface{}, len(columns))
+	holder := make([]interface{}, len(columns))
+	for rows.Next() {
+		for i := range values {
+			values[i] = nil
+			holder[i] = &values[i]
+			if scanFns != nil && scanFns[i] != nil {
+				holder[i] = scanFuncAdapter{fn: scanFns[i], dst: &values[i]}
+			}
+		}
+		if err := rows.Scan(holder...); err != nil {
+			return numberOfRowsReturned, errors.Wrap(err, "[dbr] Select.LoadMaps.scan")
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			if bv, ok := values[i].([]byte); ok {
+				row[col] = string(bv)
+				continue
+			}
+			row[col] = values[i]
+		}
+		*dest = append(*dest, row)
+		numberOfRowsReturned++
+	}
+
+	if err = rows.Err(); err != nil {
+		return numberOfRowsReturned, wrapMySQLError(err, "[dbr] Select.LoadMaps.rows_err")
+	}
+	return numberOfRowsReturned, nil
+}
+
 // LoadValues executes the Select and loads the resulting data into a slice of
 // primitive values Returns ErrNotFound behaviour if no value was found, and it
 // was therefore not set. Slow because of the massive use of reflection.
 func (b *Select) LoadValues(ctx context.Context, dest interface{}) (int, error) {
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
 	// Validate the dest and reflection values we need
 
 	// This must be a pointer to a slice
@@ -281,7 +376,7 @@ func (b *Select) LoadValues(ctx context.Context, dest interface{}) (int, error)
 	//
 	// Get full SQL
 	//
-	tSQL, tArg, err := b.ToSQL()
+	tSQL, tArg, err := b.rawSQL()
 	if err != nil {
 		return 0, errors.Wrap(err, "[dbr] Select.load_values.ToSQL")
 	}
@@ -290,6 +385,7 @@ func (b *Select) LoadValues(ctx context.Context, dest interface{}) (int, error)
 	if err != nil {
 		return 0, err
 	}
+	fullSQL = Rebind(b.Dialect, fullSQL)
 
 	numberOfRowsReturned := 0
 
@@ -300,7 +396,7 @@ func (b *Select) LoadValues(ctx context.Context, dest interface{}) (int, error)
 	// Run the query:
 	rows, err := b.DB.QueryContext(ctx, fullSQL)
 	if err != nil {
-		return numberOfRowsReturned, errors.Wrap(err, "[dbr] Select.LoadValues.query")
+		return numberOfRowsReturned, wrapMySQLError(err, "[dbr] Select.LoadValues.query")
 	}
 	defer rows.Close()
 
@@ -323,7 +419,7 @@ func (b *Select) LoadValues(ctx context.Context, dest interface{}) (int, error)
 	valueOfDest.Set(sliceValue)
 
 	if err := rows.Err(); err != nil {
-		return numberOfRowsReturned, errors.Wrap(err, "[dbr] Select.LoadValues.rows_err")
+		return numberOfRowsReturned, wrapMySQLError(err, "[dbr] Select.LoadValues.rows_err")
 	}
 
 	return numberOfRowsReturned, nil
@@ -333,6 +429,8 @@ func (b *Select) LoadValues(ctx context.Context, dest interface{}) (int, error)
 // value Returns ErrNotFound if no value was found, and it was therefore not
 // set. Slow because of the massive use of reflection.
 func (b *Select) LoadValue(ctx context.Context, dest interface{}) error {
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
 	// Validate the dest
 	valueOfDest := reflect.ValueOf(dest)
 	kindOfDest := valueOfDest.Kind()
@@ -344,7 +442,7 @@ func (b *Select) LoadValue(ctx context.Context, dest interface{}) error {
 	//
 	// Get full SQL
 	//
-	tSQL, tArg, err := b.ToSQL()
+	tSQL, tArg, err := b.rawSQL()
 	if err != nil {
 		return errors.Wrap(err, "[dbr] Select.LoadValue.ToSQL")
 	}
@@ -353,6 +451,7 @@ func (b *Select) LoadValue(ctx context.Context, dest interface{}) error {
 	if err != nil {
 		return err
 	}
+	fullSQL = Rebind(b.Dialect, fullSQL)
 
 	if b.Log != nil && b.Log.IsInfo() {
 		defer log.WhenDone(b.Log).Info("dbr.Select.LoadValue.QueryContext.timing", log.String("sql", fullSQL))
@@ -361,7 +460,7 @@ func (b *Select) LoadValue(ctx context.Context, dest interface{}) error {
 	// Run the query:
 	rows, err := b.DB.QueryContext(ctx, fullSQL)
 	if err != nil {
-		return errors.Wrap(err, "[dbr] Select.LoadValue.Query")
+		return wrapMySQLError(err, "[dbr] Select.LoadValue.Query")
 	}
 	defer rows.Close()
 
@@ -370,7 +469,7 @@ func (b *Select) LoadValue(ctx context.Context, dest interface{}) error {
 	}
 
 	if err := rows.Err(); err != nil {
-		return errors.Wrap(err, "[dbr] Select.LoadValue.Rows_err")
+		return wrapMySQLError(err, "[dbr] Select.LoadValue.Rows_err")
 	}
 
 	return errors.NewNotFoundf("[dbr] Entry not found")
@@ -0,0 +1,86 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect_OrderByExpr(t *testing.T) {
+	t.Run("variadic place holder", func(t *testing.T) {
+		sel := dbr.NewSelect("code").From("store").
+			Where(dbr.Condition("is_active", dbr.ArgInt(1))).
+			OrderByExpr("FIELD(code, ?...)", dbr.ArgString("de", "at", "ch")).
+			OrderBy("sort_order")
+		sqlStr, args, err := sel.ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "SELECT code FROM `store` WHERE (`is_active` = ?) ORDER BY FIELD(code, ?, ?, ?), sort_order", sqlStr)
+		assert.Exactly(t, []interface{}{int64(1), "de", "at", "ch"}, args.Interfaces())
+
+		str, err := dbr.Preprocess(sqlStr, args...)
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "SELECT code FROM `store` WHERE (`is_active` = 1) ORDER BY FIELD(code, 'de', 'at', 'ch'), sort_order", str)
+	})
+
+	t.Run("mixed place holders", func(t *testing.T) {
+		sel := dbr.NewSelect("sku").From("catalog_product_entity").
+			OrderByExpr("IF(type_id = ?, 0, 1), FIELD(entity_id, ?...) DESC, '?...'", dbr.ArgString("simple"), dbr.ArgInt64(3, 1))
+		sqlStr, args, err := sel.ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "SELECT sku FROM `catalog_product_entity` ORDER BY IF(type_id = ?, 0, 1), FIELD(entity_id, ?, ?) DESC, '?...'", sqlStr)
+		assert.Exactly(t, []interface{}{"simple", int64(3), int64(1)}, args.Interfaces())
+	})
+
+	t.Run("strict mismatch", func(t *testing.T) {
+		sel := dbr.NewSelect("code").From("store").Strict().
+			OrderByExpr("FIELD(code, ?, ?)", dbr.ArgString("de"))
+		_, _, err := sel.ToSQL()
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+}
+
+func TestSelect_OrderByNulls(t *testing.T) {
+	tests := []struct {
+		sel     *dbr.Select
+		wantSQL string
+	}{
+		{
+			dbr.NewSelect("name").From("store").OrderByNullsFirst("sort_order"),
+			"SELECT name FROM `store` ORDER BY ISNULL(sort_order) DESC, sort_order",
+		},
+		{
+			dbr.NewSelect("name").From("store").OrderByNullsLast("sort_order", "name"),
+			"SELECT name FROM `store` ORDER BY ISNULL(sort_order), sort_order, ISNULL(name), name",
+		},
+		{
+			dbr.NewSelect("name").From("store").OrderByDescNullsFirst("sort_order"),
+			"SELECT name FROM `store` ORDER BY ISNULL(sort_order) DESC, sort_order DESC",
+		},
+		{
+			dbr.NewSelect("name").From("store").OrderBy("website_id").OrderByDescNullsLast("sort_order"),
+			"SELECT name FROM `store` ORDER BY website_id, ISNULL(sort_order), sort_order DESC",
+		},
+	}
+	for i, test := range tests {
+		sqlStr, _, err := test.sel.ToSQL()
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantSQL, sqlStr, "Index %d", i)
+	}
+}
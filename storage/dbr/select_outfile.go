@@ -0,0 +1,97 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import "github.com/corestoreio/errors"
+
+// Outfile defines the target file and the export format of a SELECT ... INTO
+// OUTFILE statement. Empty fields are omitted and MySQL applies its defaults:
+// tab separated fields, no enclosing, backslash as escape character and
+// newline terminated lines.
+type Outfile struct {
+	// FileName absolute path on the database server host. Required.
+	FileName string
+	// CharacterSet optional character set, e.g. utf8mb4.
+	CharacterSet string
+	// FieldsTerminatedBy separator between two columns, e.g. ",".
+	FieldsTerminatedBy string
+	// FieldsEnclosedBy character to enclose column values with, e.g. `"`.
+	FieldsEnclosedBy string
+	// FieldsOptionallyEnclosed encloses only string values with
+	// FieldsEnclosedBy.
+	FieldsOptionallyEnclosed bool
+	// FieldsEscapedBy escape character.
+	FieldsEscapedBy string
+	// LinesStartingBy prefix for each line.
+	LinesStartingBy string
+	// LinesTerminatedBy line separator, e.g. "\r\n".
+	LinesTerminatedBy string
+}
+
+// NewOutfileCSV creates a new Outfile which writes comma separated values
+// enclosed with double quotes.
+func NewOutfileCSV(fileName string) *Outfile {
+	return &Outfile{
+		FileName:                 fileName,
+		FieldsTerminatedBy:       ",",
+		FieldsEnclosedBy:         `"`,
+		FieldsOptionallyEnclosed: true,
+		LinesTerminatedBy:        "\n",
+	}
+}
+
+func (o *Outfile) writeTo(w queryWriter) error {
+	if o.FileName == "" {
+		return errors.NewEmptyf(errOutfileName)
+	}
+	w.WriteString(" INTO OUTFILE ")
+	dialect.EscapeString(w, o.FileName)
+	if o.CharacterSet != "" {
+		w.WriteString(" CHARACTER SET ")
+		w.WriteString(o.CharacterSet)
+	}
+
+	if o.FieldsTerminatedBy != "" || o.FieldsEnclosedBy != "" || o.FieldsEscapedBy != "" {
+		w.WriteString(" FIELDS")
+		if o.FieldsTerminatedBy != "" {
+			w.WriteString(" TERMINATED BY ")
+			dialect.EscapeString(w, o.FieldsTerminatedBy)
+		}
+		if o.FieldsEnclosedBy != "" {
+			if o.FieldsOptionallyEnclosed {
+				w.WriteString(" OPTIONALLY")
+			}
+			w.WriteString(" ENCLOSED BY ")
+			dialect.EscapeString(w, o.FieldsEnclosedBy)
+		}
+		if o.FieldsEscapedBy != "" {
+			w.WriteString(" ESCAPED BY ")
+			dialect.EscapeString(w, o.FieldsEscapedBy)
+		}
+	}
+
+	if o.LinesStartingBy != "" || o.LinesTerminatedBy != "" {
+		w.WriteString(" LINES")
+		if o.LinesStartingBy != "" {
+			w.WriteString(" STARTING BY ")
+			dialect.EscapeString(w, o.LinesStartingBy)
+		}
+		if o.LinesTerminatedBy != "" {
+			w.WriteString(" TERMINATED BY ")
+			dialect.EscapeString(w, o.LinesTerminatedBy)
+		}
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ package dbr
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/corestoreio/errors"
 	"github.com/corestoreio/log"
@@ -593,6 +594,121 @@ func TestSelect_Locks(t *testing.T) {
 			sql,
 		)
 	})
+	t.Run("FOR UPDATE SKIP LOCKED", func(t *testing.T) {
+		s := NewSelect("id", "payload").From("queue").
+			Where(Condition("status", ArgString("new"))).
+			OrderBy("id").Limit(10).
+			ForUpdate().SkipLocked()
+		sql, _, err := s.ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t,
+			"SELECT id, payload FROM `queue` WHERE (`status` = ?) ORDER BY id LIMIT 10 FOR UPDATE SKIP LOCKED",
+			sql,
+		)
+	})
+	t.Run("FOR SHARE NOWAIT", func(t *testing.T) {
+		sql, _, err := NewSelect("id").From("queue").ForShare().NoWait().ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT id FROM `queue` FOR SHARE NOWAIT", sql)
+	})
+	t.Run("invalid combinations", func(t *testing.T) {
+		for i, s := range []*Select{
+			NewSelect("id").From("queue").SkipLocked(),
+			NewSelect("id").From("queue").ForUpdate().SkipLocked().NoWait(),
+			NewSelect("id").From("queue").ForUpdate().ForShare(),
+			NewSelect("id").From("queue").ForUpdate().LockInShareMode(),
+		} {
+			sql, _, err := s.ToSQL()
+			assert.Empty(t, sql, "Index %d", i)
+			assert.True(t, errors.IsNotValid(err), "Index %d: %+v", i, err)
+		}
+	})
+}
+
+func TestSelect_QualifyColumns(t *testing.T) {
+	tests := []struct {
+		sel  *Select
+		want string
+	}{
+		{
+			NewSelect("entity_id", "sku", "*").From("catalog_product_entity", "e").QualifyColumns(),
+			"SELECT `e`.`entity_id`, `e`.`sku`, `e`.* FROM `catalog_product_entity` AS `e`",
+		},
+		{
+			NewSelect("name").From("store").QualifyColumns(),
+			"SELECT `store`.`name` FROM `store`",
+		},
+		{
+			NewSelect("name").From("magento.store").QualifyColumns(),
+			"SELECT `magento`.`store`.`name` FROM `magento`.`store`",
+		},
+		{
+			NewSelect("name", "cg.code", "COUNT(*)").
+				AddColumnsQuoted("website_id").
+				AddColumnsExprAlias("MAX(e.sort_order)", "max_sort").
+				AddColumnsUnqualified("@rank", "sort_order").
+				From("store", "main_table").QualifyColumns(),
+			"SELECT `main_table`.`name`, `cg`.`code`, COUNT(*), `website_id`, MAX(e.sort_order) AS `max_sort`, @rank, `sort_order` FROM `store` AS `main_table`",
+		},
+		{
+			NewSelect("name", "order").From("store", "main_table"),
+			"SELECT name, `order` FROM `store` AS `main_table`",
+		},
+	}
+	for i, test := range tests {
+		sql, _, err := test.sel.ToSQL()
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, sql, "Index %d", i)
+	}
+
+	t.Run("with JOIN", func(t *testing.T) {
+		sql, _, err := NewSelect("entity_id", "value").
+			From("catalog_product_entity", "e").
+			Join(MakeAlias("catalog_product_entity_varchar", "v"), Condition("e.entity_id = v.entity_id")).
+			QualifyColumns().
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t,
+			"SELECT `e`.`entity_id`, `e`.`value` FROM `catalog_product_entity` AS `e` INNER JOIN `catalog_product_entity_varchar` AS `v` ON (e.entity_id = v.entity_id)",
+			sql)
+	})
+}
+
+func TestSelect_IntoOutfile(t *testing.T) {
+	t.Run("CSV", func(t *testing.T) {
+		sql, _, err := NewSelect("id", "email").From("dbr_people").
+			Where(Condition("id", ArgInt64(3).Operator(Greater))).
+			IntoOutfile(NewOutfileCSV("/tmp/people.csv")).
+			ForUpdate().
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t,
+			"SELECT id, email FROM `dbr_people` WHERE (`id` > ?) INTO OUTFILE '/tmp/people.csv' FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '\\\"' LINES TERMINATED BY '\\n' FOR UPDATE",
+			sql,
+		)
+	})
+	t.Run("all options", func(t *testing.T) {
+		sql, _, err := NewSelect("id").From("dbr_people").
+			IntoOutfile(&Outfile{
+				FileName:           "/tmp/o'ne.txt",
+				CharacterSet:       "utf8mb4",
+				FieldsTerminatedBy: "|",
+				FieldsEnclosedBy:   "'",
+				FieldsEscapedBy:    "\\",
+				LinesStartingBy:    ">",
+				LinesTerminatedBy:  "\r\n",
+			}).
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t,
+			"SELECT id FROM `dbr_people` INTO OUTFILE '/tmp/o\\'ne.txt' CHARACTER SET utf8mb4 FIELDS TERMINATED BY '|' ENCLOSED BY '\\'' ESCAPED BY '\\\\' LINES STARTING BY '>' TERMINATED BY '\\r\\n'",
+			sql,
+		)
+	})
+	t.Run("missing file name", func(t *testing.T) {
+		_, _, err := NewSelect("id").From("dbr_people").IntoOutfile(&Outfile{}).ToSQL()
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+	})
 }
 
 func TestSelect_Events(t *testing.T) {
@@ -968,3 +1084,130 @@ func TestParenthesisOpen_Close(t *testing.T) {
 		assert.Equal(t, "SELECT a, b FROM `c` AS `cc` WHERE (`f` = ?) AND ((`d` = ?) OR (`e` = ?)) AND (`p` = ?) GROUP BY ab HAVING (j = k) AND ((`m` = ?) OR (`n` = ?)) AND (`q` IS NOT NULL)", sql)
 	})
 }
+
+func TestSelect_WhereAlias(t *testing.T) {
+	t.Parallel()
+
+	newSel := func() *Select {
+		return NewSelect("customer_id").
+			AddColumnsExprAlias("SUM(grand_total)", "revenue").
+			AddColumnsAggregate(AggCount("*").As("orders")).
+			AddColumnsQuotedAlias("e.store_id", "store_id", "e.website_id", "wid").
+			From("sales_order", "e").
+			GroupBy("customer_id")
+	}
+
+	t.Run("column references alias returns NotValid", func(t *testing.T) {
+		sqlStr, args, err := newSel().Where(Condition("revenue", ArgFloat64(100).Operator(GreaterOrEqual))).ToSQL()
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+		assert.Empty(t, sqlStr)
+		assert.Nil(t, args)
+	})
+
+	t.Run("expression references alias returns NotValid", func(t *testing.T) {
+		_, _, err := newSel().Where(Condition("`orders` > ? + 1", argInt(2))).ToSQL()
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+
+	t.Run("alias of plain column differs from column name", func(t *testing.T) {
+		_, _, err := newSel().Where(Condition("wid", argInt(1))).ToSQL()
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+
+	t.Run("alias equals column name", func(t *testing.T) {
+		sqlStr, _, err := newSel().Where(Condition("store_id", argInt(1))).ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "SELECT customer_id, SUM(grand_total) AS `revenue`, COUNT(*) AS `orders`, `e`.`store_id` AS `store_id`, `e`.`website_id` AS `wid` FROM `sales_order` AS `e` WHERE (`store_id` = ?) GROUP BY customer_id", sqlStr)
+	})
+
+	t.Run("qualified names, strings and functions are no alias", func(t *testing.T) {
+		sqlStr, args, err := newSel().Where(
+			Condition("e.revenue", argInt(3)),
+			Condition("`e`.`orders` > ?", argInt(4)),
+			Condition("comment <> 'revenue'"),
+			Condition("REVENUE(x) > 0"),
+		).ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "SELECT customer_id, SUM(grand_total) AS `revenue`, COUNT(*) AS `orders`, `e`.`store_id` AS `store_id`, `e`.`website_id` AS `wid` FROM `sales_order` AS `e` WHERE (`e`.`revenue` = ?) AND (`e`.`orders` > ?) AND (comment <> 'revenue') AND (REVENUE(x) > 0) GROUP BY customer_id", sqlStr)
+		assert.Exactly(t, []interface{}{int64(3), int64(4)}, args.Interfaces())
+	})
+
+	t.Run("AutoHaving moves conditions", func(t *testing.T) {
+		sel := newSel().AutoHaving().
+			Where(
+				Condition("REVENUE", ArgFloat64(100).Operator(GreaterOrEqual)),
+				Condition("e.state", ArgString("complete")),
+				Condition("orders > ?", argInt(2)),
+			).
+			Having(Condition("MAX(grand_total) < ?", argInt(5000)))
+
+		sqlStr, args, err := sel.ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "SELECT customer_id, SUM(grand_total) AS `revenue`, COUNT(*) AS `orders`, `e`.`store_id` AS `store_id`, `e`.`website_id` AS `wid` FROM `sales_order` AS `e` WHERE (`e`.`state` = ?) GROUP BY customer_id HAVING (MAX(grand_total) < ?) AND (`REVENUE` >= ?) AND (orders > ?)", sqlStr)
+		assert.Exactly(t, []interface{}{"complete", int64(5000), 100.0, int64(2)}, args.Interfaces())
+		// the fields of the Select stay untouched
+		assert.Len(t, sel.WhereFragments, 3)
+		assert.Len(t, sel.HavingFragments, 1)
+	})
+
+	t.Run("AutoHaving without other WHERE conditions", func(t *testing.T) {
+		sqlStr, _, err := newSel().AutoHaving().Where(Condition("orders", argInt(1).Operator(Greater))).ToSQL()
+		assert.NoError(t, err)
+		assert.Exactly(t, "SELECT customer_id, SUM(grand_total) AS `revenue`, COUNT(*) AS `orders`, `e`.`store_id` AS `store_id`, `e`.`website_id` AS `wid` FROM `sales_order` AS `e` GROUP BY customer_id HAVING (`orders` > ?)", sqlStr)
+	})
+
+	t.Run("AutoHaving cannot move nested condition", func(t *testing.T) {
+		_, _, err := newSel().AutoHaving().Where(
+			ParenthesisOpen(),
+			Condition("orders", argInt(1).Operator(Greater)),
+			Condition("e.state", ArgString("new")).Or(),
+			ParenthesisClose(),
+		).ToSQL()
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+
+	t.Run("AutoHaving cannot move OR connected condition", func(t *testing.T) {
+		_, _, err := newSel().AutoHaving().Where(
+			Condition("e.state", ArgString("new")),
+			Condition("orders", argInt(1).Operator(Greater)).Or(),
+		).ToSQL()
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+}
+
+func TestColumnAlias(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		col  string
+		want string
+	}{
+		{"a", ""},
+		{"SUM(x) AS `total`", "total"},
+		{"SUM(x) as total", "total"},
+		{"`e`.`sku` AS `sku`", ""},
+		{"e.sku AS product_sku", "product_sku"},
+		{"CAST(x AS CHAR)", ""},
+		{"CAST(x AS CHAR) AS `c`", "c"},
+		{"x AS `a`.`b`", ""},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, columnAlias(test.col), "Index %d", i)
+	}
+}
+
+func TestWithQueryDeadline(t *testing.T) {
+	ctx := context.Background()
+	assert.Exactly(t, ctx, withQueryDeadline(ctx, 0), "Zero duration returns the parent")
+
+	// the context of Rows and Row gets released by its timer after the
+	// duration, even if nobody cancels it.
+	dctx := withQueryDeadline(ctx, 10*time.Millisecond)
+	_, ok := dctx.Deadline()
+	assert.True(t, ok, "Context must have a deadline")
+	select {
+	case <-dctx.Done():
+		assert.Exactly(t, context.DeadlineExceeded, dctx.Err())
+	case <-time.After(time.Second):
+		t.Fatal("Context must be released after the duration")
+	}
+}
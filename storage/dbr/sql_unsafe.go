@@ -0,0 +1,194 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// SQLUnsafe defines a raw SQL fragment which gets written unchanged into the
+// query. The builders quote identifiers and use place holders for values,
+// SQLUnsafe bypasses both. Never convert user input to SQLUnsafe.
+//
+// Untyped string constants can be used directly, a string variable requires an
+// explicit conversion. This makes each place where raw SQL enters a builder
+// visible in the code review:
+//		dbr.ConditionUnsafe("e.updated_at > NOW() - INTERVAL 1 DAY")
+//		dbr.ConditionUnsafe(dbr.SQLUnsafe(expr)) // expr is a string variable
+//
+// The builder functions accepting a column name or an expression, for example
+// Condition, SubSelect, ArgExpr, the columns of a Select and the ORDER BY and
+// GROUP BY functions, take a SQLUnsafe too. A column name stored in a string
+// variable must be converted, so no user input can end up there by accident:
+//		dbr.Condition(dbr.SQLUnsafe(col), dbr.ArgInt(1))
+//
+// Use SetSQLUnsafeAudit to log all usages during runtime. The audit includes
+// SelectBySQL and the expressions passed to the functions above. Plain
+// identifiers do not get reported.
+type SQLUnsafe string
+
+// String returns the raw SQL fragment.
+func (u SQLUnsafe) String() string {
+	return string(u)
+}
+
+// SQLUnsafeUsage describes one usage of a SQLUnsafe fragment and the location
+// in the source code from where it has been passed to the builder.
+type SQLUnsafeUsage struct {
+	SQL SQLUnsafe
+	// Func contains the package qualified name of the calling function.
+	Func string
+	File string
+	Line int
+}
+
+// sqlUnsafeAudit stores a func(SQLUnsafeUsage). Loaded for each usage of a
+// SQLUnsafe fragment, hence lock-free.
+var sqlUnsafeAudit atomic.Value
+
+// SetSQLUnsafeAudit sets a global hook which gets called each time a SQLUnsafe
+// fragment or a raw SQL expression as plain string gets passed to a builder
+// function. The hook receives the calling
+// function, file and line to make it auditable where raw SQL bypasses the
+// builder. A nil argument removes the hook. The hook gets called concurrently
+// and must not block.
+//		dbr.SetSQLUnsafeAudit(func(u dbr.SQLUnsafeUsage) {
+//			log.Info("dbr.SQLUnsafe", log.String("sql", u.SQL.String()), log.String("func", u.Func), log.Int("line", u.Line))
+//		})
+func SetSQLUnsafeAudit(fn func(SQLUnsafeUsage)) {
+	sqlUnsafeAudit.Store(fn)
+}
+
+// auditSQLUnsafe calls the audit hook, if set, with the location of the caller
+// of the exported builder function. Must only be called directly from the
+// exported function otherwise the stack depth is wrong.
+func auditSQLUnsafe(u SQLUnsafe) {
+	reportSQLUnsafe(u, 3)
+}
+
+// auditSQLExpr reports each fragment which is not a plain or qualified
+// identifier to the audit hook and returns the fragments as strings. The
+// builder functions accepting a column or an expression write the expression
+// unquoted, so only expressions get audited. Same call depth requirement as
+// auditSQLUnsafe.
+func auditSQLExpr(exprs ...SQLUnsafe) []string {
+	s := unsafeStrings(exprs)
+	reportSQLExpr(s, 4)
+	return s
+}
+
+func unsafeStrings(exprs []SQLUnsafe) []string {
+	s := make([]string, len(exprs))
+	for i, e := range exprs {
+		s[i] = string(e)
+	}
+	return s
+}
+
+// reportSQLExpr same as auditSQLExpr but with a custom stack frame, see
+// reportSQLUnsafe.
+func reportSQLExpr(exprs []string, skip int) {
+	if fn, _ := sqlUnsafeAudit.Load().(func(SQLUnsafeUsage)); fn == nil {
+		return
+	}
+	for _, e := range exprs {
+		if isSQLExpr(e) {
+			reportSQLUnsafe(SQLUnsafe(e), skip)
+		}
+	}
+}
+
+// isSQLExpr returns false for a star and for an identifier optionally
+// qualified, followed by ".*" or by the sort direction.
+func isSQLExpr(e string) bool {
+	if e == "" || e == "*" {
+		return false
+	}
+	if strings.HasSuffix(e, ".*") {
+		e = e[:len(e)-2]
+	} else {
+		e, _ = splitSortDirection(e)
+	}
+	return isValidIdentifier(e) > 0
+}
+
+// reportSQLUnsafe calls the audit hook, if set, with the location of the
+// stack frame skip. Like in runtime.Caller zero identifies reportSQLUnsafe
+// itself and one its caller.
+func reportSQLUnsafe(u SQLUnsafe, skip int) {
+	fn, _ := sqlUnsafeAudit.Load().(func(SQLUnsafeUsage))
+	if fn == nil {
+		return
+	}
+	usage := SQLUnsafeUsage{SQL: u}
+	if pc, file, line, ok := runtime.Caller(skip); ok {
+		usage.File = file
+		usage.Line = line
+		if f := runtime.FuncForPC(pc); f != nil {
+			usage.Func = f.Name()
+			if pos := strings.LastIndexByte(usage.Func, '/'); pos >= 0 {
+				usage.Func = usage.Func[pos+1:]
+			}
+		}
+	}
+	fn(usage)
+}
+
+// ConditionUnsafe adds a raw SQL condition to a WHERE, HAVING or JOIN
+// statement. In contrast to Condition the fragment gets always treated as an
+// expression, even if it looks like a column name. Place holders get replaced
+// by the arguments.
+//		dbr.ConditionUnsafe("DATE(e.created_at) = CURDATE() - INTERVAL ? DAY", dbr.ArgInt(1))
+func ConditionUnsafe(raw SQLUnsafe, arg ...Argument) ConditionArg {
+	auditSQLUnsafe(raw)
+	return &whereFragment{
+		Condition: string(raw),
+		Arguments: arg,
+		isUnsafe:  true,
+	}
+}
+
+// ArgUnsafe adds a raw SQL fragment with place holders and a slice of args
+// to replace them with. Mostly used in UPDATE statements or ON DUPLICATE KEY
+// clauses. Same as ArgExpr but audited.
+//		dbr.NewUpdate("cataloginventory_stock_item").Set("qty", dbr.ArgUnsafe("qty - ?", dbr.ArgInt(1)))
+func ArgUnsafe(raw SQLUnsafe, args ...Argument) Argument {
+	auditSQLUnsafe(raw)
+	return &expr{SQL: string(raw), Arguments: args}
+}
+
+// AddColumnsUnsafe appends raw SQL expressions to the Columns slice. The
+// expressions won't get quoted or qualified.
+//		AddColumnsUnsafe("COUNT(*) AS `cnt`", "MAX(e.updated_at)")
+func (b *Select) AddColumnsUnsafe(exprs ...SQLUnsafe) *Select {
+	for _, e := range exprs {
+		auditSQLUnsafe(e)
+		b.Columns = append(b.Columns, string(e))
+	}
+	return b
+}
+
+// OrderByUnsafe appends a raw SQL expression to ORDER the statement. The
+// expression won't get quoted.
+//		OrderByUnsafe("FIELD(e.entity_id, 3, 1, 2)")
+func (b *Select) OrderByUnsafe(exprs ...SQLUnsafe) *Select {
+	for _, e := range exprs {
+		auditSQLUnsafe(e)
+		b.OrderBys = append(b.OrderBys, string(e))
+	}
+	return b
+}
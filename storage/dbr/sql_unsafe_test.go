@@ -0,0 +1,158 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLUnsafe_ToSQL(t *testing.T) {
+	dbr.SetSQLUnsafeAudit(nil)
+
+	t.Run("condition looks like a column", func(t *testing.T) {
+		sqlStr, args, err := dbr.NewSelect("sku").From("catalog_product_entity").
+			Where(dbr.ConditionUnsafe("is_active")).ToSQL()
+		require.NoError(t, err)
+		assert.Empty(t, args)
+		assert.Exactly(t, "SELECT sku FROM `catalog_product_entity` WHERE (is_active)", sqlStr)
+	})
+	t.Run("condition with arguments", func(t *testing.T) {
+		sqlStr, args, err := dbr.NewSelect("sku").From("catalog_product_entity").
+			Where(
+				dbr.Condition("type_id", dbr.ArgString("simple")),
+				dbr.ConditionUnsafe("DATE(created_at) >= CURDATE() - INTERVAL ? DAY", dbr.ArgInt(7)),
+			).ToSQL()
+		require.NoError(t, err)
+		assert.Exactly(t, []interface{}{"simple", int64(7)}, args.Interfaces())
+		assert.Exactly(t,
+			"SELECT sku FROM `catalog_product_entity` WHERE (`type_id` = ?) AND (DATE(created_at) >= CURDATE() - INTERVAL ? DAY)",
+			sqlStr)
+	})
+	t.Run("columns and order by", func(t *testing.T) {
+		sqlStr, _, err := dbr.NewSelect().From("catalog_product_entity").
+			AddColumnsUnsafe("COUNT(*) AS `cnt`", "MAX(updated_at)").
+			OrderByUnsafe("FIELD(entity_id, 3, 1, 2)").ToSQL()
+		require.NoError(t, err)
+		assert.Exactly(t,
+			"SELECT COUNT(*) AS `cnt`, MAX(updated_at) FROM `catalog_product_entity` ORDER BY FIELD(entity_id, 3, 1, 2)",
+			sqlStr)
+	})
+	t.Run("update set", func(t *testing.T) {
+		sqlStr, args, err := dbr.NewUpdate("cataloginventory_stock_item").
+			Set("qty", dbr.ArgUnsafe("qty - ?", dbr.ArgInt(2))).
+			Where(dbr.Condition("product_id", dbr.ArgInt64(33))).ToSQL()
+		require.NoError(t, err)
+		assert.Exactly(t, []interface{}{int64(2), int64(33)}, args.Interfaces())
+		assert.Exactly(t,
+			"UPDATE `cataloginventory_stock_item` SET `qty`=qty - ? WHERE (`product_id` = ?)",
+			sqlStr)
+	})
+}
+
+func TestSetSQLUnsafeAudit(t *testing.T) {
+	var mu sync.Mutex
+	var usages []dbr.SQLUnsafeUsage
+	dbr.SetSQLUnsafeAudit(func(u dbr.SQLUnsafeUsage) {
+		mu.Lock()
+		usages = append(usages, u)
+		mu.Unlock()
+	})
+	defer dbr.SetSQLUnsafeAudit(nil)
+
+	raw := "NOW()"
+	dbr.NewSelect().From("a").
+		AddColumnsUnsafe("COUNT(*)").
+		OrderByUnsafe("RAND()").
+		Where(dbr.ConditionUnsafe("b > " + dbr.SQLUnsafe(raw)))
+	dbr.ArgUnsafe("c + 1")
+
+	require.Len(t, usages, 4)
+	var sqls []dbr.SQLUnsafe
+	for _, u := range usages {
+		sqls = append(sqls, u.SQL)
+		assert.Exactly(t, "sql_unsafe_test.go", filepath.Base(u.File))
+		assert.Exactly(t, "dbr_test.TestSetSQLUnsafeAudit", u.Func)
+		assert.True(t, u.Line > 0)
+	}
+	assert.Exactly(t, []dbr.SQLUnsafe{"COUNT(*)", "RAND()", "b > NOW()", "c + 1"}, sqls)
+
+	dbr.SetSQLUnsafeAudit(nil)
+	dbr.ConditionUnsafe("d = 1")
+	assert.Len(t, usages, 4)
+}
+
+func TestSetSQLUnsafeAudit_PlainStrings(t *testing.T) {
+	var mu sync.Mutex
+	var sqls []dbr.SQLUnsafe
+	dbr.SetSQLUnsafeAudit(func(u dbr.SQLUnsafeUsage) {
+		assert.Exactly(t, "sql_unsafe_test.go", filepath.Base(u.File), "%q", u.SQL)
+		assert.Exactly(t, "dbr_test.TestSetSQLUnsafeAudit_PlainStrings", u.Func, "%q", u.SQL)
+		mu.Lock()
+		sqls = append(sqls, u.SQL)
+		mu.Unlock()
+	})
+	defer dbr.SetSQLUnsafeAudit(nil)
+
+	// identifiers do not get reported
+	dbr.NewSelect("*", "e.*", "sku", "e.entity_id").From("a").
+		AddColumns("b,c").
+		Where(dbr.Condition("e.sku", dbr.ArgString("x"))).
+		GroupBy("e.sku").
+		OrderBy("sku", "sku DESC").OrderByDesc("e.entity_id").
+		AddColumnsAggregate(dbr.AggCount("*"), dbr.AggSum("price"))
+	require.Empty(t, sqls)
+
+	var c dbr.Connection
+	dbr.NewSelect("COUNT(*) AS cnt").From("a").
+		AddColumns("MAX(b)").
+		AddColumnsUnqualified("@rank := 0").
+		AddColumnsExprAlias("(b*c)", "d").
+		Where(dbr.Condition("e = f"), dbr.SubSelect("(g)", dbr.In, dbr.NewSelect("h").From("i"))).
+		GroupBy("DATE(j)").
+		OrderBy("RAND()").
+		OrderByDesc("LENGTH(k)").
+		OrderByExpr("FIELD(l, ?...)", dbr.ArgInt(1, 2)).
+		OrderByNullsLast("COALESCE(m, n)").
+		AddColumnsAggregate(dbr.AggSum("o * p"))
+	dbr.ArgExpr("NOW()")
+	c.SelectBySQL("SELECT 1")
+	c.UpdateBySQL("UPDATE q SET r = 1")
+	dbr.NewUpdate("s").OrderBy("t + 0")
+	dbr.NewDelete("u").OrderByDesc("v + 0")
+	dbr.NewUnion().OrderBy("w + 0")
+
+	assert.Exactly(t, []dbr.SQLUnsafe{
+		"COUNT(*) AS cnt", "MAX(b)", "@rank := 0", "(b*c)", "e = f", "(g)",
+		"DATE(j)", "RAND()", "LENGTH(k)", "FIELD(l, ?...)", "COALESCE(m, n)", "o * p",
+		"NOW()", "SELECT 1", "UPDATE q SET r = 1", "t + 0", "v + 0", "w + 0",
+	}, sqls)
+}
+
+func TestSQLUnsafe_StringVariables(t *testing.T) {
+	// a string variable requires the explicit conversion
+	col, expr := "e.sku", "COUNT(*)"
+	sStr, _, err := dbr.NewSelect(dbr.SQLUnsafe(col), dbr.SQLUnsafe(expr)).From("a", "e").
+		Where(dbr.Condition(dbr.SQLUnsafe(col), dbr.ArgString("x"))).
+		GroupBy(dbr.SQLUnsafe(col)).
+		OrderBy(dbr.SQLUnsafe(col)).ToSQL()
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, "SELECT e.sku, COUNT(*) FROM `a` AS `e` WHERE (`e`.`sku` = ?) GROUP BY e.sku ORDER BY e.sku", sStr)
+}
@@ -0,0 +1,142 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/corestoreio/errors"
+)
+
+// BoundStmt wraps a prepared statement and binds the arguments for the next
+// execution. The same prepared query can be executed many times with different
+// argument sets without building the SQL again. The argument slice gets
+// reused between the executions. BoundStmt is not safe for concurrent use,
+// create a BoundStmt per goroutine with NewBoundStmt from a shared *sql.Stmt.
+type BoundStmt struct {
+	// SQL contains the prepared query string. Empty if the BoundStmt has been
+	// created with NewBoundStmt.
+	SQL  string
+	stmt Stmter
+	args []interface{}
+	// err contains the first conversion error of a bound ArgValuer.
+	err error
+}
+
+// PrepareBound creates a prepared statement from the query builder. The
+// arguments of the query builder are getting bound as the initial argument
+// set. Use Rebind to replace them. A Select, Insert, Update or Delete object
+// can be used as QueryBuilder. Each bound argument set must contain one value
+// per place holder.
+func PrepareBound(ctx context.Context, p Preparer, qb QueryBuilder) (*BoundStmt, error) {
+	sqlStr, args, err := qb.ToSQL()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] PrepareBound.ToSQL")
+	}
+	stmt, err := p.PrepareContext(ctx, sqlStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "[dbr] PrepareBound.PrepareContext with query %q", sqlStr)
+	}
+	s := NewBoundStmt(stmt)
+	s.SQL = sqlStr
+	return s.Bind(args...), nil
+}
+
+// NewBoundStmt creates a new BoundStmt from an already prepared statement, for
+// example a statement kept open by a cache like csdb.ResurrectStmt or a
+// statement bound to a transaction.
+func NewBoundStmt(stmt Stmter) *BoundStmt {
+	return &BoundStmt{
+		stmt: stmt,
+	}
+}
+
+// Bind appends the arguments to the currently bound argument set. A
+// conversion error of an ArgValuer gets returned by Exec and Query.
+func (s *BoundStmt) Bind(args ...Argument) *BoundStmt {
+	if err := resolveValuers(args); err != nil && s.err == nil {
+		s.err = errors.Wrap(err, "[dbr] BoundStmt.Bind")
+	}
+	for _, a := range args {
+		a.toIFace(&s.args)
+	}
+	return s
+}
+
+// Rebind removes the currently bound arguments and binds the new arguments.
+// The underlying slice gets reused.
+func (s *BoundStmt) Rebind(args ...Argument) *BoundStmt {
+	s.args = s.args[:0]
+	s.err = nil
+	return s.Bind(args...)
+}
+
+// Arguments returns the bound arguments. The returned slice gets reused by the
+// next call to Rebind.
+func (s *BoundStmt) Arguments() []interface{} {
+	return s.args
+}
+
+// Exec executes the prepared statement with the bound arguments.
+func (s *BoundStmt) Exec(ctx context.Context) (sql.Result, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	res, err := s.stmt.ExecContext(ctx, s.args...)
+	return res, wrapMySQLError(err, "[dbr] BoundStmt.Exec")
+}
+
+// Query executes the prepared statement with the bound arguments and returns
+// the rows.
+func (s *BoundStmt) Query(ctx context.Context) (*sql.Rows, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	rows, err := s.stmt.QueryContext(ctx, s.args...)
+	return rows, wrapMySQLError(err, "[dbr] BoundStmt.Query")
+}
+
+// QueryRow executes the prepared statement with the bound arguments and
+// returns at most one row.
+func (s *BoundStmt) QueryRow(ctx context.Context) *sql.Row {
+	return s.stmt.QueryRowContext(ctx, s.args...)
+}
+
+// ExecMany executes the prepared statement once for each argument set. Each
+// set gets bound with Rebind. Execution stops at the first error and returns
+// the results of the successful executions. After ExecMany the last set stays
+// bound.
+func (s *BoundStmt) ExecMany(ctx context.Context, sets ...Arguments) ([]sql.Result, error) {
+	results := make([]sql.Result, 0, len(sets))
+	for i, set := range sets {
+		res, err := s.Rebind(set...).Exec(ctx)
+		if err != nil {
+			return results, errors.Wrapf(err, "[dbr] BoundStmt.ExecMany at argument set %d", i)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Close closes the underlying statement if it implements io.Closer.
+func (s *BoundStmt) Close() error {
+	if c, ok := s.stmt.(interface {
+		Close() error
+	}); ok {
+		return errors.Wrap(c.Close(), "[dbr] BoundStmt.Close")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoundStmt_ExecMany(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	prep := dbMock.ExpectPrepare(cstesting.SQLMockQuoteMeta("UPDATE `cataloginventory_stock_item` SET `qty`=? WHERE (`product_id` = ?)"))
+	prep.ExpectExec().WithArgs(0, 0).WillReturnResult(sqlmock.NewResult(0, 0))
+	prep.ExpectExec().WithArgs(10, 33).WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WithArgs(11, 34).WillReturnResult(sqlmock.NewResult(0, 1))
+	prep.ExpectExec().WithArgs(12, 35).WillReturnError(errors.NewAlreadyClosedf("Connection gone"))
+	prep.WillBeClosed()
+
+	up := dbr.NewUpdate("cataloginventory_stock_item").
+		Set("qty", dbr.ArgInt(0)).
+		Where(dbr.Condition("product_id", dbr.ArgInt(0)))
+
+	stmt, err := dbr.PrepareBound(context.TODO(), dbc.DB, up)
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, "UPDATE `cataloginventory_stock_item` SET `qty`=? WHERE (`product_id` = ?)", stmt.SQL)
+	assert.Exactly(t, []interface{}{int64(0), int64(0)}, stmt.Arguments(), "Arguments of the builder")
+
+	_, err = stmt.Exec(context.TODO())
+	require.NoError(t, err, "%+v", err)
+
+	results, err := stmt.ExecMany(context.TODO(),
+		dbr.Arguments{dbr.ArgInt(10), dbr.ArgInt(33)},
+		dbr.Arguments{dbr.ArgInt(11), dbr.ArgInt(34)},
+		dbr.Arguments{dbr.ArgInt(12), dbr.ArgInt(35)},
+	)
+	assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
+	assert.Len(t, results, 2)
+	assert.Exactly(t, []interface{}{int64(12), int64(35)}, stmt.Arguments())
+
+	require.NoError(t, stmt.Close())
+}
+
+func TestBoundStmt_Rebind(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	prep := dbMock.ExpectPrepare(cstesting.SQLMockQuoteMeta("SELECT sku FROM `catalog_product_entity` WHERE (`entity_id` >= ?) AND (`entity_id` <= ?)"))
+	prep.ExpectQuery().WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"sku"}).AddRow("a").AddRow("b"))
+	prep.ExpectQuery().WithArgs(3, 4).WillReturnRows(sqlmock.NewRows([]string{"sku"}).AddRow("c"))
+
+	sel := dbr.NewSelect("sku").From("catalog_product_entity").
+		Where(
+			dbr.Condition("entity_id", dbr.ArgInt64(1).Operator(dbr.GreaterOrEqual)),
+			dbr.Condition("entity_id", dbr.ArgInt64(2).Operator(dbr.LessOrEqual)),
+		)
+	stmt, err := dbr.PrepareBound(context.TODO(), dbc.DB, sel)
+	require.NoError(t, err, "%+v", err)
+
+	var skus []string
+	rows, err := stmt.Query(context.TODO())
+	require.NoError(t, err, "%+v", err)
+	for rows.Next() {
+		var s string
+		require.NoError(t, rows.Scan(&s))
+		skus = append(skus, s)
+	}
+	require.NoError(t, rows.Close())
+
+	var sku string
+	require.NoError(t, stmt.Rebind(dbr.ArgInt64(3)).Bind(dbr.ArgInt64(4)).QueryRow(context.TODO()).Scan(&sku))
+	skus = append(skus, sku)
+	assert.Exactly(t, []string{"a", "b", "c"}, skus)
+}
+
+func TestPrepareBound_Error(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	stmt, err := dbr.PrepareBound(context.TODO(), dbc.DB, dbr.NewDelete(""))
+	assert.Nil(t, stmt)
+	assert.True(t, errors.IsEmpty(err), "%+v", err)
+
+	dbMock.ExpectPrepare(cstesting.SQLMockQuoteMeta("DELETE FROM `sales_flat_quote`")).WillReturnError(errors.NewAlreadyClosedf("Connection gone"))
+	stmt, err = dbr.PrepareBound(context.TODO(), dbc.DB, dbr.NewDelete("sales_flat_quote"))
+	assert.Nil(t, stmt)
+	assert.True(t, errors.IsAlreadyClosed(err), "%+v", err)
+}
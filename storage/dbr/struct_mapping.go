@@ -72,7 +72,7 @@ func calculateFieldMap(recordType reflect.Type, columns []string, requireAllColu
 	return fieldMap, nil
 }
 
-func prepareHolderFor(record reflect.Value, fieldMap [][]int, holder []interface{}) ([]interface{}, error) {
+func prepareHolderFor(record reflect.Value, fieldMap [][]int, scanFns []ScanFunc, holder []interface{}) ([]interface{}, error) {
 	// Given a query and given a structure (field list), there'ab 2 sets of fields.
 	// Take the intersection. We can fill those in. great.
 	// For fields in the structure that aren't in the query, we'll let that slide if db:"-"
@@ -85,6 +85,9 @@ func prepareHolderFor(record reflect.Value, fieldMap [][]int, holder []interface
 		} else {
 			field := record.FieldByIndex(fieldIndex)
 			holder[i] = field.Addr().Interface()
+			if scanFns != nil && scanFns[i] != nil {
+				holder[i] = scanFuncAdapter{fn: scanFns[i], dst: holder[i]}
+			}
 		}
 	}
 
@@ -0,0 +1,223 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/corestoreio/csfw/util"
+	"github.com/corestoreio/errors"
+)
+
+// structPlans caches for each struct type the mapping of column names to the
+// field index. Reflection on the type happens only once.
+var structPlans = struct {
+	sync.RWMutex
+	m map[reflect.Type]map[string][]int
+}{
+	m: make(map[reflect.Type]map[string][]int),
+}
+
+// structPlan returns the cached column to field index mapping. Column names
+// are taken from the `db` struct tag or the field name gets converted from
+// camel case to snake case. Fields tagged with `db:"-"` and unexported fields
+// are skipped. Fields of embedded or nested structs are resolved breadth
+// first, so a field on a higher level wins.
+func structPlan(t reflect.Type) map[string][]int {
+	structPlans.RLock()
+	p, ok := structPlans.m[t]
+	structPlans.RUnlock()
+	if ok {
+		return p
+	}
+
+	p = make(map[string][]int)
+	queue := []fieldMapQueueElement{{Type: t}}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for j := 0; j < cur.Type.NumField(); j++ {
+			f := cur.Type.Field(j)
+			name := f.Tag.Get("db")
+			if name == "-" {
+				continue
+			}
+			idxs := make([]int, len(cur.Idxs), len(cur.Idxs)+1)
+			copy(idxs, cur.Idxs)
+			idxs = append(idxs, j)
+
+			ft := f.Type
+			isNested := ft.Kind() == reflect.Struct && ft != typeTime && !ft.Implements(typeArgument) && !reflect.PtrTo(ft).Implements(typeDriverValuer)
+			if isNested && (f.PkgPath == "" || f.Anonymous) {
+				queue = append(queue, fieldMapQueueElement{Type: ft, Idxs: idxs})
+			}
+			// the exported fields of an embedded struct are accessible even
+			// if the struct type itself has not been exported.
+			if f.PkgPath != "" || (isNested && f.Anonymous) {
+				continue
+			}
+
+			if name == "" {
+				name = util.CamelCaseToUnderscore(f.Name)
+			}
+			if _, ok := p[name]; !ok {
+				p[name] = idxs
+			}
+		}
+	}
+
+	structPlans.Lock()
+	structPlans.m[t] = p
+	structPlans.Unlock()
+	return p
+}
+
+var (
+	typeTime         = reflect.TypeOf(time.Time{})
+	typeArgument     = reflect.TypeOf((*Argument)(nil)).Elem()
+	typeDriverValuer = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// structRecord implements ArgumentGenerater via reflection.
+type structRecord struct {
+	rv reflect.Value
+}
+
+// StructRecord wraps a struct or a pointer to a struct and generates the
+// arguments via reflection. It is the fallback for types which do not
+// implement the ArgumentGenerater interface. The column names get matched
+// against the `db` struct tag or the snake case field name. Supported field
+// types are all integers, floats, bool, string, []byte, time.Time, types
+// implementing Argument or driver.Valuer and pointers to them. A nil pointer
+// becomes NULL.
+//
+// For StatementTypeUpdate and StatementTypeDelete the conditions get
+// appended after the columns. A qualifier in a condition gets removed, e.g.
+// `cpe.entity_id` maps to the column entity_id. If the record implements
+// ArgumentGenerater it gets returned unchanged.
+func StructRecord(record interface{}) ArgumentGenerater {
+	if ag, ok := record.(ArgumentGenerater); ok {
+		return ag
+	}
+	return structRecord{rv: reflect.Indirect(reflect.ValueOf(record))}
+}
+
+// GenerateArguments implements the ArgumentGenerater interface.
+func (sr structRecord) GenerateArguments(statementType byte, columns, condition []string) (Arguments, error) {
+	if sr.rv.Kind() != reflect.Struct {
+		return nil, errors.NewNotSupportedf("[dbr] StructRecord: Type %s is not a struct", sr.rv.Type())
+	}
+	plan := structPlan(sr.rv.Type())
+
+	args := make(Arguments, 0, len(columns)+len(condition))
+	var err error
+	if args, err = sr.appendArgs(plan, args, columns); err != nil {
+		return nil, errors.Wrap(err, "[dbr] StructRecord.GenerateArguments.columns")
+	}
+	switch statementType {
+	case StatementTypeUpdate, StatementTypeDelete:
+		if args, err = sr.appendArgs(plan, args, condition); err != nil {
+			return nil, errors.Wrap(err, "[dbr] StructRecord.GenerateArguments.condition")
+		}
+	}
+	return args, nil
+}
+
+func (sr structRecord) appendArgs(plan map[string][]int, args Arguments, columns []string) (Arguments, error) {
+	for _, c := range columns {
+		if i := strings.LastIndexByte(c, '.'); i >= 0 {
+			c = c[i+1:]
+		}
+		idx, ok := plan[c]
+		if !ok {
+			return nil, errors.NewNotFoundf("[dbr] StructRecord: Column %q not found in type %s", c, sr.rv.Type())
+		}
+		arg, err := reflectValueToArgument(sr.rv.FieldByIndex(idx))
+		if err != nil {
+			return nil, errors.Wrapf(err, "[dbr] StructRecord: Column %q", c)
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func reflectValueToArgument(v reflect.Value) (Argument, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ArgNull(), nil
+		}
+		v = v.Elem()
+	}
+
+	if v.CanInterface() {
+		switch t := v.Interface().(type) {
+		case Argument:
+			return t, nil
+		case time.Time:
+			return ArgTime(t), nil
+		case []byte:
+			return ArgBytes(t), nil
+		case driver.Valuer:
+			return driverValueToArgument(t)
+		}
+		if v.CanAddr() {
+			if dv, ok := v.Addr().Interface().(driver.Valuer); ok {
+				return driverValueToArgument(dv)
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ArgInt64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ArgUint64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return ArgFloat64(v.Float()), nil
+	case reflect.Bool:
+		return ArgBool(v.Bool()), nil
+	case reflect.String:
+		return ArgString(v.String()), nil
+	}
+	return nil, errors.NewNotSupportedf("[dbr] Type %s not supported", v.Type())
+}
+
+func driverValueToArgument(dv driver.Valuer) (Argument, error) {
+	v, err := dv.Value()
+	if err != nil {
+		return nil, errors.Wrap(err, "[dbr] driver.Valuer.Value")
+	}
+	switch t := v.(type) {
+	case nil:
+		return ArgNull(), nil
+	case int64:
+		return ArgInt64(t), nil
+	case float64:
+		return ArgFloat64(t), nil
+	case bool:
+		return ArgBool(t), nil
+	case []byte:
+		return ArgBytes(t), nil
+	case string:
+		return ArgString(t), nil
+	case time.Time:
+		return ArgTime(t), nil
+	}
+	return nil, errors.NewNotSupportedf("[dbr] driver.Value %T not supported", v)
+}
@@ -0,0 +1,106 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type structRecordAudit struct {
+	CreatedAt time.Time
+	UpdatedBy *string
+}
+
+type structRecordProduct struct {
+	EntityID int64 `db:"entity_id"`
+	Sku      string
+	Price    float32
+	IsActive bool `db:"status"`
+	Weight   dbr.NullFloat64
+	Ignored  string `db:"-"`
+	internal int
+	structRecordAudit
+}
+
+func TestStructRecord_Insert(t *testing.T) {
+	t.Parallel()
+
+	updater := "admin"
+	p1 := structRecordProduct{EntityID: 1, Sku: "SKU1", Price: 2.5, IsActive: true, Weight: dbr.MakeNullFloat64(3.25)}
+	p1.CreatedAt = time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+	p1.UpdatedBy = &updater
+	p2 := &structRecordProduct{EntityID: 2, Sku: "SKU'2"}
+	p2.CreatedAt = p1.CreatedAt
+
+	sqlStr, args, err := dbr.NewInsert("catalog_product_entity").
+		AddColumns("entity_id", "sku", "price", "status", "weight", "created_at", "updated_by").
+		AddStructs(p1, p2).ToSQL()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, "INSERT INTO `catalog_product_entity` (`entity_id`,`sku`,`price`,`status`,`weight`,`created_at`,`updated_by`) VALUES (?,?,?,?,?,?,?),(?,?,?,?,?,?,?)", sqlStr)
+
+	sqlPre, err := dbr.Preprocess(sqlStr, args...)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, "INSERT INTO `catalog_product_entity` (`entity_id`,`sku`,`price`,`status`,`weight`,`created_at`,`updated_by`) VALUES (1,'SKU1',2.5,1,3.25,'2017-03-04 05:06:07','admin'),(2,'SKU\\'2',0,0,NULL,'2017-03-04 05:06:07',NULL)", sqlPre)
+}
+
+func TestStructRecord_GenerateArguments(t *testing.T) {
+	t.Parallel()
+
+	p := &structRecordProduct{EntityID: 33, Sku: "SKU33", Ignored: "x"}
+
+	t.Run("update with condition", func(t *testing.T) {
+		args, err := dbr.StructRecord(p).GenerateArguments(dbr.StatementTypeUpdate, []string{"sku"}, []string{"cpe.entity_id"})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, []interface{}{"SKU33", int64(33)}, args.Interfaces())
+	})
+	t.Run("insert ignores condition", func(t *testing.T) {
+		args, err := dbr.StructRecord(*p).GenerateArguments(dbr.StatementTypeInsert, []string{"sku"}, []string{"entity_id"})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		assert.Exactly(t, []interface{}{"SKU33"}, args.Interfaces())
+	})
+	t.Run("skipped field not found", func(t *testing.T) {
+		_, err := dbr.StructRecord(p).GenerateArguments(dbr.StatementTypeInsert, []string{"ignored"}, nil)
+		assert.True(t, errors.IsNotFound(err), "%+v", err)
+	})
+	t.Run("unexported field not found", func(t *testing.T) {
+		_, err := dbr.StructRecord(p).GenerateArguments(dbr.StatementTypeInsert, []string{"internal"}, nil)
+		assert.True(t, errors.IsNotFound(err), "%+v", err)
+	})
+	t.Run("no struct", func(t *testing.T) {
+		_, err := dbr.StructRecord(3).GenerateArguments(dbr.StatementTypeInsert, []string{"a"}, nil)
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+	t.Run("unsupported field type", func(t *testing.T) {
+		_, err := dbr.StructRecord(struct{ Tags []string }{}).GenerateArguments(dbr.StatementTypeInsert, []string{"tags"}, nil)
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+	t.Run("ArgumentGenerater unchanged", func(t *testing.T) {
+		rec := &insertIDRecord{Sku: "a"}
+		assert.Exactly(t, dbr.ArgumentGenerater(rec), dbr.StructRecord(rec))
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"strings"
+
+	"github.com/corestoreio/errors"
+)
+
+// TableNameMapper rewrites a table name before it gets written into the SQL
+// string. Use it to inject per-tenant prefixes or schema names so that the
+// same builders work across tenant schemas. The mapper must be safe for
+// concurrent use. It gets applied to plain table names but never to
+// expressions. Derived tables and sub-selects in the FROM, JOIN and WHERE
+// clauses and the parts of a Union inherit the mapper of the parent
+// statement if they do not have their own.
+type TableNameMapper func(table string) string
+
+// TablePrefix returns a TableNameMapper which prepends the prefix to the table
+// name. A schema qualified name keeps its schema and only the table part gets
+// prefixed.
+//		dbr.TablePrefix("t1_")("catalog_product_entity") // t1_catalog_product_entity
+//		dbr.TablePrefix("t1_")("magento.core_store")     // magento.t1_core_store
+func TablePrefix(prefix string) TableNameMapper {
+	return func(table string) string {
+		if pos := strings.LastIndexByte(table, '.'); pos >= 0 {
+			return table[:pos+1] + prefix + table[pos+1:]
+		}
+		return prefix + table
+	}
+}
+
+// TableSchema returns a TableNameMapper which qualifies the table name with
+// the database schema. An already schema qualified name stays unchanged.
+//		dbr.TableSchema("tenant1")("core_store") // tenant1.core_store
+func TableSchema(schema string) TableNameMapper {
+	return func(table string) string {
+		if strings.IndexByte(table, '.') >= 0 {
+			return table
+		}
+		return schema + "." + table
+	}
+}
+
+// WithTableNameMapper sets the mapper which rewrites all table names of the
+// statements created by the Connection or by its transactions.
+func WithTableNameMapper(m TableNameMapper) ConnectionOption {
+	return func(c *Connection) error {
+		if m == nil {
+			return errors.NewEmptyf("[dbr] WithTableNameMapper: TableNameMapper cannot be nil")
+		}
+		c.TableNameMapper = m
+		return nil
+	}
+}
+
+// mapTableName applies the mapper to a plain table name. If withAlias is true
+// and the table has no alias, the unmapped table name becomes the alias, so
+// that qualified column references in the statement keep working. A derived
+// table inherits the mapper and the dialect d.
+func (t alias) mapTableName(m TableNameMapper, d Dialect, withAlias bool) alias {
+	if t.Select != nil {
+		t.Select = t.Select.inherit(m, d)
+		return t
+	}
+	if m == nil || t.Expression == "" || isValidIdentifier(t.Expression) != 0 {
+		return t
+	}
+	name := m(t.Expression)
+	if withAlias && t.Alias == "" && name != t.Expression {
+		t.Alias = t.Expression
+		if pos := strings.LastIndexByte(t.Alias, '.'); pos >= 0 {
+			t.Alias = t.Alias[pos+1:]
+		}
+	}
+	t.Expression = name
+	return t
+}
+
+// mapTableName applies the mapper to a plain table name.
+func (m TableNameMapper) mapTableName(table string) string {
+	if m == nil || table == "" || isValidIdentifier(table) != 0 {
+		return table
+	}
+	return m(table)
+}
+
+// inherit returns a shallow copy of the nested Select which uses the table
+// name mapper m and the dialect d of the parent statement, if the nested
+// Select does not have its own. The nested Select itself stays unchanged
+// because it might be shared between statements of different tenants.
+func (b *Select) inherit(m TableNameMapper, d Dialect) *Select {
+	if b == nil || (m == nil || b.TableNameMapper != nil) && (d == nil || b.Dialect != nil) {
+		return b
+	}
+	sc := *b
+	if sc.TableNameMapper == nil {
+		sc.TableNameMapper = m
+	}
+	if sc.Dialect == nil {
+		sc.Dialect = d
+	}
+	return &sc
+}
@@ -0,0 +1,165 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTablePrefix(t *testing.T) {
+	t.Parallel()
+	m := dbr.TablePrefix("t1_")
+	assert.Exactly(t, "t1_core_store", m("core_store"))
+	assert.Exactly(t, "magento.t1_core_store", m("magento.core_store"))
+}
+
+func TestTableSchema(t *testing.T) {
+	t.Parallel()
+	m := dbr.TableSchema("tenant1")
+	assert.Exactly(t, "tenant1.core_store", m("core_store"))
+	assert.Exactly(t, "magento.core_store", m("magento.core_store"))
+}
+
+func TestWithTableNameMapper(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil mapper", func(t *testing.T) {
+		c, err := dbr.NewConnection(dbr.WithTableNameMapper(nil))
+		assert.Nil(t, c)
+		assert.True(t, errors.IsEmpty(err), "%+v", err)
+	})
+
+	c, err := dbr.NewConnection(dbr.WithTableNameMapper(dbr.TablePrefix("t1_")))
+	require.NoError(t, err, "%+v", err)
+
+	t.Run("Select with joins", func(t *testing.T) {
+		sqlStr, _, err := c.Select("code", "name").From("store").QualifyColumns().
+			Join(dbr.MakeAlias("store_group", "sg"), dbr.Condition("sg.group_id = store.group_id")).
+			LeftJoin(dbr.MakeAlias("store_website"), dbr.Condition("store_website.website_id = store.website_id")).
+			Where(dbr.Condition("store.is_active", dbr.ArgInt(1))).
+			ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t,
+			"SELECT `store`.`code`, `store`.`name` FROM `t1_store` AS `store` INNER JOIN `t1_store_group` AS `sg` ON (sg.group_id = store.group_id) LEFT JOIN `t1_store_website` AS `store_website` ON (store_website.website_id = store.website_id) WHERE (`store`.`is_active` = ?)",
+			sqlStr)
+	})
+
+	t.Run("Select derived table inherits mapper", func(t *testing.T) {
+		sub := dbr.NewSelect("a").From("c")
+		sqlStr, _, err := c.Select("a").From("b").
+			Join(dbr.MakeAliasFromSub(sub, "x"), dbr.Condition("x.a = b.a")).
+			ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "SELECT a FROM `t1_b` AS `b` INNER JOIN (SELECT a FROM `t1_c` AS `c`) AS `x` ON (x.a = b.a)", sqlStr)
+		assert.Nil(t, sub.TableNameMapper, "Sub select must not be modified")
+	})
+
+	t.Run("Select FROM derived table", func(t *testing.T) {
+		sel := dbr.NewSelectFromSub(dbr.NewSelect("a").From("c"), "x").AddColumns("a")
+		sel.TableNameMapper = dbr.TablePrefix("t1_")
+		sqlStr, _, err := sel.ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "SELECT a FROM (SELECT a FROM `t1_c` AS `c`) AS `x`", sqlStr)
+	})
+
+	t.Run("Select WHERE sub select", func(t *testing.T) {
+		sqlStr, _, err := c.Select("a").From("b").
+			Where(dbr.SubSelect("b.id", dbr.In, dbr.NewSelect("id").From("c").Where(dbr.Condition("d", dbr.ArgInt(1))))).
+			ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "SELECT a FROM `t1_b` AS `b` WHERE (`b`.`id` IN (SELECT id FROM `t1_c` AS `c` WHERE (`d` = ?)))", sqlStr)
+	})
+
+	t.Run("Sub select keeps own mapper", func(t *testing.T) {
+		sub := dbr.NewSelect("a").From("c")
+		sub.TableNameMapper = dbr.TablePrefix("t2_")
+		sqlStr, _, err := c.Select("a").From("b").
+			Join(dbr.MakeAliasFromSub(sub, "x"), dbr.Condition("x.a = b.a")).
+			ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "SELECT a FROM `t1_b` AS `b` INNER JOIN (SELECT a FROM `t2_c` AS `c`) AS `x` ON (x.a = b.a)", sqlStr)
+	})
+
+	t.Run("Union", func(t *testing.T) {
+		u := dbr.NewUnion(
+			dbr.NewSelect("a").From("b"),
+			c.Select("a").From("c"),
+		)
+		u.TableNameMapper = dbr.TablePrefix("t3_")
+		sqlStr, _, err := u.ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "(SELECT a FROM `t3_b` AS `b`)\nUNION\n(SELECT a FROM `t1_c` AS `c`)", sqlStr)
+	})
+
+	t.Run("Insert FromSelect", func(t *testing.T) {
+		sqlStr, _, err := c.InsertInto("store").FromSelect(dbr.NewSelect("code").From("store_tmp"))
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "INSERT INTO `t1_store` SELECT code FROM `t1_store_tmp` AS `store_tmp`", sqlStr)
+	})
+
+	t.Run("Insert", func(t *testing.T) {
+		sqlStr, _, err := c.InsertInto("store").AddColumns("code").AddValues(dbr.ArgString("de")).ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "INSERT INTO `t1_store` (`code`) VALUES (?)", sqlStr)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		sqlStr, _, err := c.Update("store").Set("code", dbr.ArgString("de")).
+			Where(dbr.Condition("store_id", dbr.ArgInt64(1))).ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "UPDATE `t1_store` SET `code`=? WHERE (`store_id` = ?)", sqlStr)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		sqlStr, _, err := c.DeleteFrom("store").Where(dbr.Condition("store_id", dbr.ArgInt64(1))).ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "DELETE FROM `t1_store` WHERE (`store_id` = ?)", sqlStr)
+	})
+
+	t.Run("Update WHERE sub select", func(t *testing.T) {
+		sqlStr, _, err := c.Update("store").Set("is_active", dbr.ArgInt(0)).
+			Where(dbr.SubSelect("group_id", dbr.In, dbr.NewSelect("group_id").From("store_group"))).ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "UPDATE `t1_store` SET `is_active`=? WHERE (`group_id` IN (SELECT group_id FROM `t1_store_group` AS `store_group`))", sqlStr)
+	})
+
+	t.Run("Delete with join", func(t *testing.T) {
+		sqlStr, _, err := c.DeleteFrom("customer_visitor", "cv").
+			Join(dbr.MakeAlias("customer_entity", "ce"), dbr.Condition("ce.entity_id = cv.customer_id")).
+			ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "DELETE `cv` FROM `t1_customer_visitor` AS `cv` INNER JOIN `t1_customer_entity` AS `ce` ON (ce.entity_id = cv.customer_id)", sqlStr)
+	})
+}
+
+func TestWithTableNameMapper_Schema(t *testing.T) {
+	t.Parallel()
+
+	c, err := dbr.NewConnection(dbr.WithTableNameMapper(dbr.TableSchema("tenant2")))
+	require.NoError(t, err, "%+v", err)
+
+	sqlStr, _, err := c.Select("*").From("core_config_data", "ccd").ToSQL()
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, "SELECT * FROM `tenant2`.`core_config_data` AS `ccd`", sqlStr)
+
+	sqlStr, _, err = c.Select("*").From("core_config_data").ToSQL()
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, "SELECT * FROM `tenant2`.`core_config_data` AS `core_config_data`", sqlStr)
+}
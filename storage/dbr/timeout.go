@@ -0,0 +1,66 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// withQueryTimeout derives a context which gets canceled after the duration.
+// A zero duration returns the context unchanged. An earlier deadline of the
+// parent context always wins.
+func withQueryTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// withQueryDeadline same as withQueryTimeout but for results which outlive the
+// function call, like *sql.Rows and *sql.Row. Both types are structs of
+// package database/sql and provide no hook to cancel the context when they get
+// closed. Therefore the cancel function gets discarded and the context with
+// its timer stays alive until the duration elapses, even if the rows have been
+// closed earlier. The leak is bounded by the duration. The caller must consume
+// the result within the duration.
+func withQueryDeadline(ctx context.Context, d time.Duration) context.Context {
+	ctx, _ = withQueryTimeout(ctx, d) // released by the timer of the deadline
+	return ctx
+}
+
+// supportsMaxExecutionTime reports whether the optimizer hint
+// MAX_EXECUTION_TIME can be written. MySQL >= 5.7.8 evaluates the hint, older
+// versions and MariaDB treat it as a comment.
+func supportsMaxExecutionTime(d Dialect) bool {
+	return d == nil || d == DialectMySQL || d == DialectMySQLANSI
+}
+
+// sqlWriteMaxExecutionTime writes the optimizer hint after the SELECT
+// keyword. The server aborts the query once the duration in milliseconds has
+// been exceeded.
+func sqlWriteMaxExecutionTime(w queryWriter, d Dialect, timeout time.Duration) {
+	if timeout <= 0 || !supportsMaxExecutionTime(d) {
+		return
+	}
+	ms := int64(timeout / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	w.WriteString("/*+ MAX_EXECUTION_TIME(")
+	w.WriteString(strconv.FormatInt(ms, 10))
+	w.WriteString(") */ ")
+}
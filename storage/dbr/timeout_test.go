@@ -0,0 +1,123 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/csfw/util/cstesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect_Timeout_ToSQL(t *testing.T) {
+	tests := []struct {
+		sel     *dbr.Select
+		wantSQL string
+	}{
+		{
+			dbr.NewSelect("sku").From("catalog_product_entity").Timeout(1500 * time.Millisecond),
+			"SELECT /*+ MAX_EXECUTION_TIME(1500) */ sku FROM `catalog_product_entity`",
+		},
+		{
+			dbr.NewSelect("sku").Distinct().From("catalog_product_entity").Comment("report").Timeout(time.Microsecond),
+			"/* report */ SELECT /*+ MAX_EXECUTION_TIME(1) */ DISTINCT sku FROM `catalog_product_entity`",
+		},
+		{
+			dbr.NewSelect("sku").From("catalog_product_entity"),
+			"SELECT sku FROM `catalog_product_entity`",
+		},
+		{
+			func() *dbr.Select {
+				s := dbr.NewSelect("sku").From("catalog_product_entity").Timeout(time.Second)
+				s.Dialect = dbr.DialectPostgres
+				return s
+			}(),
+			`SELECT sku FROM "catalog_product_entity"`,
+		},
+	}
+	for i, test := range tests {
+		sqlStr, _, err := test.sel.ToSQL()
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantSQL, sqlStr, "Index %d", i)
+	}
+}
+
+func TestTimeout_Exec(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+
+	dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT /*+ MAX_EXECUTION_TIME(10) */ sku FROM `catalog_product_entity`")).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"sku"}).AddRow("a"))
+	var skus []string
+	_, err := dbc.Select("sku").From("catalog_product_entity").Timeout(10*time.Millisecond).
+		LoadValues(context.TODO(), &skus)
+	assert.Error(t, err, "Query should have been canceled")
+
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("UPDATE `catalog_product_entity` SET `sku`='b'")).
+		WillDelayFor(time.Second).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = dbc.Update("catalog_product_entity").Set("sku", dbr.ArgString("b")).Timeout(10 * time.Millisecond).
+		Exec(context.TODO())
+	assert.Error(t, err, "Query should have been canceled")
+
+	dbMock.ExpectExec(cstesting.SQLMockQuoteMeta("DELETE FROM `catalog_product_entity`")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = dbc.DeleteFrom("catalog_product_entity").Timeout(time.Second).Exec(context.TODO())
+	assert.NoError(t, err)
+}
+
+func TestTimeout_RowsRow(t *testing.T) {
+	dbc, dbMock := cstesting.MockDB(t)
+	defer func() {
+		dbMock.ExpectClose()
+		assert.NoError(t, dbc.Close())
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	}()
+	const sqlStr = "SELECT /*+ MAX_EXECUTION_TIME(10) */ sku FROM `catalog_product_entity`"
+
+	dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta(sqlStr)).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"sku"}).AddRow("a"))
+	rows, err := dbc.Select("sku").From("catalog_product_entity").Timeout(10 * time.Millisecond).
+		Rows(context.TODO())
+	assert.Nil(t, rows)
+	assert.Error(t, err, "Query should have been canceled")
+
+	dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta(sqlStr)).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"sku"}).AddRow("a"))
+	var sku string
+	err = dbc.Select("sku").From("catalog_product_entity").Timeout(10 * time.Millisecond).
+		Row(context.TODO()).Scan(&sku)
+	assert.Error(t, err, "Query should have been canceled")
+	assert.Empty(t, sku)
+
+	dbMock.ExpectQuery(cstesting.SQLMockQuoteMeta("SELECT /*+ MAX_EXECUTION_TIME(1000) */ sku FROM `catalog_product_entity`")).
+		WillReturnRows(sqlmock.NewRows([]string{"sku"}).AddRow("b"))
+	err = dbc.Select("sku").From("catalog_product_entity").Timeout(time.Second).
+		Row(context.TODO()).Scan(&sku)
+	assert.NoError(t, err)
+	assert.Exactly(t, "b", sku)
+}
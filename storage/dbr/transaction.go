@@ -11,6 +11,12 @@ import (
 type Tx struct {
 	log.Logger
 	*sql.Tx
+	// Dialect inherited from the Connection.
+	Dialect Dialect
+	// Scanners inherited from the Connection.
+	Scanners *ScannerRegistry
+	// TableNameMapper inherited from the Connection.
+	TableNameMapper TableNameMapper
 }
 
 // Begin creates a transaction for the given session
@@ -20,7 +26,11 @@ func (c *Connection) Begin() (*Tx, error) {
 		return nil, errors.Wrap(err, "[dbr] transaction.begin.error")
 	}
 	tx := &Tx{
-		Tx: dbTx,
+		Tx:       dbTx,
+		Dialect:  c.Dialect,
+		Scanners: c.Scanners,
+
+		TableNameMapper: c.TableNameMapper,
 	}
 	if c.Log != nil {
 		tx.Logger = c.Log.With(log.Bool("transaction", true))
@@ -30,7 +40,7 @@ func (c *Connection) Begin() (*Tx, error) {
 
 // Commit finishes the transaction
 func (tx *Tx) Commit() error {
-	return errors.Wrap(tx.Tx.Commit(), "[dbr] transaction.commit.error")
+	return wrapMySQLError(tx.Tx.Commit(), "[dbr] transaction.commit.error")
 }
 
 // Rollback cancels the transaction
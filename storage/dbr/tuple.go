@@ -0,0 +1,134 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/errors"
+)
+
+// Tuple creates a row-value condition to compare several columns at once, for
+// example to look up composite keys like (entity_id, attribute_id) in EAV
+// tables. The columns get quoted and a dot separates the qualifier:
+//
+//	Tuple([]string{"entity_id", "attribute_id"}, ArgTuple(2, ArgInt64(1, 11, 2, 22)))
+//	(`entity_id`,`attribute_id`) IN ((?,?),(?,?))
+//
+// The argument should be created with ArgTuple. The operators In (default)
+// and NotIn are supported.
+func Tuple(columns []string, arg Argument) ConditionArg {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	buf.WriteRune('(')
+	for i, c := range columns {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+		Quoter.splitDotAndQuote(buf, c)
+	}
+	buf.WriteRune(')')
+	return &whereFragment{
+		Condition: buf.String(),
+		Arguments: Arguments{arg},
+	}
+}
+
+// argTuple contains the flattened values of all row-value tuples.
+type argTuple struct {
+	op    byte
+	width int
+	args  Arguments
+}
+
+// ArgTuple creates a list of row-value tuples for the IN operator. Argument
+// width defines the number of values of a single tuple and must be equal to
+// the number of columns. The values of all arguments get flattened in the
+// order provided, so multi value arguments can be mixed with single values:
+//
+//	ArgTuple(2, ArgInt64(1, 11, 2, 22))
+//	ArgTuple(2, ArgInt64(1), ArgString("a"), ArgInt64(2), ArgString("b"))
+//
+// Both render to ((?,?),(?,?)) and the arguments contain four values. Do not
+// set an operator on the inner arguments.
+func ArgTuple(width int, args ...Argument) Argument {
+	return &argTuple{
+		width: width,
+		args:  args,
+	}
+}
+
+func (a *argTuple) toIFace(args *[]interface{}) {
+	for _, arg := range a.args {
+		arg.toIFace(args)
+	}
+}
+
+// writeTo writes the value at the flattened position pos.
+func (a *argTuple) writeTo(w queryWriter, pos int) error {
+	for _, arg := range a.args {
+		if l := arg.len(); pos >= l {
+			pos -= l
+			continue
+		}
+		return arg.writeTo(w, pos)
+	}
+	return errors.NewNotValidf("[dbr] ArgTuple position %d out of range", pos)
+}
+
+// len returns the number of all flattened values because each value has its
+// own place holder.
+func (a *argTuple) len() int { return a.args.len() }
+
+// Operator sets the operator In or NotIn. Any other operator falls back to In.
+func (a *argTuple) Operator(op byte) Argument {
+	a.op = op
+	return a
+}
+
+func (a *argTuple) operator() byte {
+	if a.op == NotIn {
+		return NotIn
+	}
+	return In
+}
+
+// writePlaceholders writes the operator and for each tuple the place holders,
+// e.g.: IN ((?,?),(?,?))
+func (a *argTuple) writePlaceholders(w queryWriter) error {
+	l := a.len()
+	if a.width < 1 || l == 0 || l%a.width != 0 {
+		return errors.NewNotValidf("[dbr] ArgTuple: %d values cannot be split into tuples of width %d", l, a.width)
+	}
+	if a.op == NotIn {
+		w.WriteString(" NOT IN (")
+	} else {
+		w.WriteString(" IN (")
+	}
+	for i := 0; i < l; i += a.width {
+		if i > 0 {
+			w.WriteRune(',')
+		}
+		w.WriteRune('(')
+		for j := 0; j < a.width; j++ {
+			if j > 0 {
+				w.WriteRune(',')
+			}
+			w.WriteRune('?')
+		}
+		w.WriteRune(')')
+	}
+	w.WriteRune(')')
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/storage/dbr"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTuple(t *testing.T) {
+	t.Parallel()
+
+	runner := func(cnd dbr.ConditionArg, wantSQL string, wantArgs []interface{}, wantPre string) func(*testing.T) {
+		return func(t *testing.T) {
+			sqlStr, args, err := dbr.NewSelect("value").From("catalog_product_entity_int").
+				Where(dbr.Condition("store_id", dbr.ArgInt64(0)), cnd).ToSQL()
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			assert.Exactly(t, wantSQL, sqlStr)
+			assert.Exactly(t, wantArgs, args.Interfaces())
+
+			sqlPre, err := dbr.Preprocess(sqlStr, args...)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			assert.Exactly(t, wantPre, sqlPre)
+		}
+	}
+
+	t.Run("IN multi value argument", runner(
+		dbr.Tuple([]string{"entity_id", "attribute_id"}, dbr.ArgTuple(2, dbr.ArgInt64(1, 11, 2, 22))),
+		"SELECT value FROM `catalog_product_entity_int` WHERE (`store_id` = ?) AND ((`entity_id`,`attribute_id`) IN ((?,?),(?,?)))",
+		[]interface{}{int64(0), int64(1), int64(11), int64(2), int64(22)},
+		"SELECT value FROM `catalog_product_entity_int` WHERE (`store_id` = 0) AND ((`entity_id`,`attribute_id`) IN ((1,11),(2,22)))",
+	))
+	t.Run("NOT IN mixed arguments", runner(
+		dbr.Tuple([]string{"e.entity_id", "e.sku"}, dbr.ArgTuple(2,
+			dbr.ArgInt64(3), dbr.ArgString("a'b"),
+			dbr.ArgInt64(4), dbr.ArgString("c"),
+		).Operator(dbr.NotIn)),
+		"SELECT value FROM `catalog_product_entity_int` WHERE (`store_id` = ?) AND ((`e`.`entity_id`,`e`.`sku`) NOT IN ((?,?),(?,?)))",
+		[]interface{}{int64(0), int64(3), "a'b", int64(4), "c"},
+		"SELECT value FROM `catalog_product_entity_int` WHERE (`store_id` = 0) AND ((`e`.`entity_id`,`e`.`sku`) NOT IN ((3,'a\\'b'),(4,'c')))",
+	))
+	t.Run("single tuple", runner(
+		dbr.Tuple([]string{"entity_id", "attribute_id", "store_id"}, dbr.ArgTuple(3, dbr.ArgInt64(5, 6, 7))),
+		"SELECT value FROM `catalog_product_entity_int` WHERE (`store_id` = ?) AND ((`entity_id`,`attribute_id`,`store_id`) IN ((?,?,?)))",
+		[]interface{}{int64(0), int64(5), int64(6), int64(7)},
+		"SELECT value FROM `catalog_product_entity_int` WHERE (`store_id` = 0) AND ((`entity_id`,`attribute_id`,`store_id`) IN ((5,6,7)))",
+	))
+}
+
+func TestTuple_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []dbr.Argument{
+		dbr.ArgTuple(2, dbr.ArgInt64(1, 2, 3)),
+		dbr.ArgTuple(0, dbr.ArgInt64(1, 2)),
+		dbr.ArgTuple(2),
+	}
+	for i, arg := range tests {
+		_, _, err := dbr.NewSelect("a").From("b").
+			Where(dbr.Tuple([]string{"c", "d"}, arg)).ToSQL()
+		assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"database/sql/driver"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/corestoreio/errors"
+)
+
+// NullDecimal defines a nullable decimal type for exact numeric values like
+// prices and tax amounts. The value gets stored as an unsigned integer with a
+// scale: Precision 12345 with Scale 2 represents 123.45. The precision is
+// limited to the range of an uint64, which means 19 significant digits
+// are always safe. Larger MySQL DECIMAL values return an error while scanning.
+// NullDecimal implements interface Argument.
+type NullDecimal struct {
+	// Precision all digits without the decimal point.
+	Precision uint64
+	// Scale number of digits after the decimal point.
+	Scale int32
+	// Negative true if the value is smaller than zero.
+	Negative bool
+	// Valid false if the value is NULL.
+	Valid bool
+	opt   byte
+}
+
+// MakeNullDecimalInt64 creates a new valid NullDecimal from a signed integer
+// and a scale. For example MakeNullDecimalInt64(-12345, 2) represents -123.45.
+func MakeNullDecimalInt64(value int64, scale int32) NullDecimal {
+	d := NullDecimal{Scale: scale, Valid: true}
+	if value < 0 {
+		d.Negative = true
+		d.Precision = uint64(-(value + 1)) + 1 // handles math.MinInt64
+	} else {
+		d.Precision = uint64(value)
+	}
+	return d
+}
+
+// ParseNullDecimal parses a decimal string like "-123.450". An empty string or
+// "null" returns a NULL value. Scientific notation is not supported. Returns a
+// NotValid error behaviour on malformed input or an overflow of the precision.
+func ParseNullDecimal(s string) (NullDecimal, error) {
+	var d NullDecimal
+	if s == "" || s == "null" {
+		return d, nil
+	}
+	if err := d.parse(s); err != nil {
+		return NullDecimal{}, err
+	}
+	return d, nil
+}
+
+func (d *NullDecimal) parse(s string) error {
+	orig := s
+	d.Negative, d.Valid, d.Scale, d.Precision = false, false, 0, 0
+	switch {
+	case strings.HasPrefix(s, "-"):
+		d.Negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	digits := s
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits = s[:i] + s[i+1:]
+		d.Scale = int32(len(s) - i - 1)
+	}
+	if digits == "" {
+		return errors.NewNotValidf(errNullDecimalParse, orig)
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return errors.NewNotValidf(errNullDecimalParse, orig)
+		}
+	}
+	p, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return errors.NewNotValidf(errNullDecimalOverflow, orig)
+	}
+	d.Precision = p
+	if d.Precision == 0 {
+		d.Negative = false
+	}
+	d.Valid = true
+	return nil
+}
+
+// String returns the decimal representation, e.g. "-123.45" or "NULL" if the
+// value is not valid.
+func (d NullDecimal) String() string {
+	if !d.Valid {
+		return sqlStrNull
+	}
+	return string(d.appendTo(make([]byte, 0, 24)))
+}
+
+func (d NullDecimal) appendTo(buf []byte) []byte {
+	if d.Negative && d.Precision > 0 {
+		buf = append(buf, '-')
+	}
+	digits := strconv.FormatUint(d.Precision, 10)
+	if d.Scale <= 0 {
+		return append(buf, digits...)
+	}
+	scale := int(d.Scale)
+	if len(digits) <= scale {
+		buf = append(buf, '0', '.')
+		for i := len(digits); i < scale; i++ {
+			buf = append(buf, '0')
+		}
+		return append(buf, digits...)
+	}
+	buf = append(buf, digits[:len(digits)-scale]...)
+	buf = append(buf, '.')
+	return append(buf, digits[len(digits)-scale:]...)
+}
+
+// Float64 converts the decimal to a float64. Might lose precision. Returns 0
+// for NULL values.
+func (d NullDecimal) Float64() float64 {
+	if !d.Valid {
+		return 0
+	}
+	f, _ := strconv.ParseFloat(string(d.appendTo(nil)), 64)
+	return f
+}
+
+// toBig returns the signed unscaled value as a big.Int.
+func (d NullDecimal) toBig() *big.Int {
+	b := new(big.Int).SetUint64(d.Precision)
+	if d.Negative {
+		b.Neg(b)
+	}
+	return b
+}
+
+// scaleTo returns the unscaled value adjusted to a larger scale.
+func (d NullDecimal) scaleTo(scale int32) *big.Int {
+	b := d.toBig()
+	if diff := scale - d.Scale; diff > 0 {
+		b.Mul(b, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(diff)), nil))
+	}
+	return b
+}
+
+func makeNullDecimalBig(b *big.Int, scale int32) (NullDecimal, error) {
+	d := NullDecimal{Scale: scale, Valid: true, Negative: b.Sign() < 0}
+	abs := new(big.Int).Abs(b)
+	if !abs.IsUint64() {
+		return NullDecimal{}, errors.NewNotValidf(errNullDecimalOverflow, b.String())
+	}
+	d.Precision = abs.Uint64()
+	return d, nil
+}
+
+func maxScale(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Add returns d + o. If one of the values is NULL the result is NULL. Returns
+// a NotValid error behaviour if the result overflows.
+func (d NullDecimal) Add(o NullDecimal) (NullDecimal, error) {
+	if !d.Valid || !o.Valid {
+		return NullDecimal{}, nil
+	}
+	s := maxScale(d.Scale, o.Scale)
+	return makeNullDecimalBig(new(big.Int).Add(d.scaleTo(s), o.scaleTo(s)), s)
+}
+
+// Sub returns d - o. If one of the values is NULL the result is NULL. Returns
+// a NotValid error behaviour if the result overflows.
+func (d NullDecimal) Sub(o NullDecimal) (NullDecimal, error) {
+	if !d.Valid || !o.Valid {
+		return NullDecimal{}, nil
+	}
+	s := maxScale(d.Scale, o.Scale)
+	return makeNullDecimalBig(new(big.Int).Sub(d.scaleTo(s), o.scaleTo(s)), s)
+}
+
+// Mul returns d * o with the sum of both scales. If one of the values is NULL
+// the result is NULL. Returns a NotValid error behaviour if the result
+// overflows.
+func (d NullDecimal) Mul(o NullDecimal) (NullDecimal, error) {
+	if !d.Valid || !o.Valid {
+		return NullDecimal{}, nil
+	}
+	return makeNullDecimalBig(new(big.Int).Mul(d.toBig(), o.toBig()), d.Scale+o.Scale)
+}
+
+// Cmp compares d and o and returns -1 if d < o, 0 if d == o and +1 if d > o.
+// NULL values are smaller than all valid values.
+func (d NullDecimal) Cmp(o NullDecimal) int {
+	switch {
+	case !d.Valid && !o.Valid:
+		return 0
+	case !d.Valid:
+		return -1
+	case !o.Valid:
+		return 1
+	}
+	s := maxScale(d.Scale, o.Scale)
+	return d.scaleTo(s).Cmp(o.scaleTo(s))
+}
+
+// Scan implements the sql.Scanner interface. Supports []byte, string, int64
+// and float64 database values.
+func (d *NullDecimal) Scan(value interface{}) (err error) {
+	switch v := value.(type) {
+	case nil:
+		*d = NullDecimal{}
+	case []byte:
+		err = d.parse(string(v))
+	case string:
+		err = d.parse(v)
+	case int64:
+		*d = MakeNullDecimalInt64(v, 0)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return errors.NewNotValidf(errNullDecimalParse, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		err = d.parse(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		err = errors.NewNotSupportedf("[dbr] NullDecimal.Scan type %T not supported", value)
+	}
+	return err
+}
+
+// Value implements the driver.Valuer interface. Returns the decimal as a
+// string to avoid any precision loss.
+func (d NullDecimal) Value() (driver.Value, error) {
+	if !d.Valid {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+func (d NullDecimal) toIFace(args *[]interface{}) {
+	if d.Valid {
+		*args = append(*args, d.String())
+	} else {
+		*args = append(*args, nil)
+	}
+}
+
+func (d NullDecimal) writeTo(w queryWriter, _ int) error {
+	_, err := w.WriteString(d.String())
+	return err
+}
+
+func (d NullDecimal) len() int { return 1 }
+
+// Operator sets the SQL operator (IN, =, LIKE, BETWEEN, ...). Please refer to
+// the constants Operator*.
+func (d NullDecimal) Operator(opt byte) Argument {
+	d.opt = opt
+	return d
+}
+
+func (d NullDecimal) operator() byte { return d.opt }
+
+// MarshalJSON implements json.Marshaler. A valid decimal gets encoded as a
+// JSON number without losing precision.
+func (d NullDecimal) MarshalJSON() ([]byte, error) {
+	if !d.Valid {
+		return []byte("null"), nil
+	}
+	return d.appendTo(make([]byte, 0, 24)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Supports a JSON number, a quoted
+// decimal string and null.
+func (d *NullDecimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*d = NullDecimal{}
+		return nil
+	}
+	if len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	return d.parse(s)
+}
+
+// MarshalText implements encoding.TextMarshaler. It will encode a blank
+// string if this NullDecimal is null.
+func (d NullDecimal) MarshalText() ([]byte, error) {
+	if !d.Valid {
+		return []byte{}, nil
+	}
+	return d.appendTo(make([]byte, 0, 24)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. A blank string or "null"
+// creates a NULL value.
+func (d *NullDecimal) UnmarshalText(text []byte) error {
+	if len(text) == 0 || string(text) == "null" {
+		*d = NullDecimal{}
+		return nil
+	}
+	return d.parse(string(text))
+}
+
+type argNullDecimals struct {
+	opt  byte
+	data []NullDecimal
+}
+
+func (a argNullDecimals) toIFace(args *[]interface{}) {
+	for _, d := range a.data {
+		d.toIFace(args)
+	}
+}
+
+func (a argNullDecimals) writeTo(w queryWriter, pos int) error {
+	if a.operator() != In && a.operator() != NotIn {
+		_, err := w.WriteString(a.data[pos].String())
+		return err
+	}
+	l := len(a.data) - 1
+	w.WriteRune('(')
+	for i, d := range a.data {
+		w.WriteString(d.String())
+		if i < l {
+			w.WriteRune(',')
+		}
+	}
+	_, err := w.WriteRune(')')
+	return err
+}
+
+func (a argNullDecimals) len() int {
+	if isNotIn(a.operator()) {
+		return len(a.data)
+	}
+	return 1
+}
+
+// Operator sets the SQL operator (IN, =, LIKE, BETWEEN, ...). Please refer to
+// the constants Operator*.
+func (a argNullDecimals) Operator(opt byte) Argument {
+	a.opt = opt
+	return a
+}
+
+func (a argNullDecimals) operator() byte { return a.opt }
+
+// ArgNullDecimal adds a nullable decimal or a slice of nullable decimals to the
+// argument list. Providing no arguments returns a NULL type.
+func ArgNullDecimal(args ...NullDecimal) Argument {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return argNullDecimals{data: args}
+}
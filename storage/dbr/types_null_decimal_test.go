@@ -0,0 +1,206 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Argument = (*NullDecimal)(nil)
+var _ Argument = (*argNullDecimals)(nil)
+var _ json.Marshaler = (*NullDecimal)(nil)
+var _ json.Unmarshaler = (*NullDecimal)(nil)
+
+func TestParseNullDecimal(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in      string
+		want    NullDecimal
+		wantStr string
+		errBhf  errors.BehaviourFunc
+	}{
+		{"123.45", NullDecimal{Precision: 12345, Scale: 2, Valid: true}, "123.45", nil},
+		{"-0.05", NullDecimal{Precision: 5, Scale: 2, Negative: true, Valid: true}, "-0.05", nil},
+		{"+7", NullDecimal{Precision: 7, Valid: true}, "7", nil},
+		{"-0.000", NullDecimal{Precision: 0, Scale: 3, Valid: true}, "0.000", nil},
+		{"1.", NullDecimal{Precision: 1, Valid: true}, "1", nil},
+		{".5", NullDecimal{Precision: 5, Scale: 1, Valid: true}, "0.5", nil},
+		{"18446744073709551615", NullDecimal{Precision: math.MaxUint64, Valid: true}, "18446744073709551615", nil},
+		{"", NullDecimal{}, "NULL", nil},
+		{"null", NullDecimal{}, "NULL", nil},
+		{"18446744073709551616", NullDecimal{}, "NULL", errors.IsNotValid},
+		{"1.2e3", NullDecimal{}, "NULL", errors.IsNotValid},
+		{"-", NullDecimal{}, "NULL", errors.IsNotValid},
+		{"1,5", NullDecimal{}, "NULL", errors.IsNotValid},
+	}
+	for _, test := range tests {
+		have, err := ParseNullDecimal(test.in)
+		if test.errBhf != nil {
+			assert.True(t, test.errBhf(err), "%q: %+v", test.in, err)
+		} else {
+			assert.NoError(t, err, "%q", test.in)
+		}
+		assert.Exactly(t, test.want, have, "%q", test.in)
+		assert.Exactly(t, test.wantStr, have.String(), "%q", test.in)
+	}
+}
+
+func TestMakeNullDecimalInt64(t *testing.T) {
+	t.Parallel()
+	assert.Exactly(t, "-123.45", MakeNullDecimalInt64(-12345, 2).String())
+	assert.Exactly(t, "0.0001", MakeNullDecimalInt64(1, 4).String())
+	assert.Exactly(t, "-9223372036854775808", MakeNullDecimalInt64(math.MinInt64, 0).String())
+	assert.Exactly(t, -123.45, MakeNullDecimalInt64(-12345, 2).Float64())
+}
+
+func TestNullDecimal_Arithmetic(t *testing.T) {
+	t.Parallel()
+	price := MakeNullDecimalInt64(1999, 2) // 19.99
+	tax := MakeNullDecimalInt64(19, 2)     // 0.19
+
+	sum, err := price.Add(MakeNullDecimalInt64(1, 3))
+	assert.NoError(t, err)
+	assert.Exactly(t, "19.991", sum.String())
+
+	diff, err := MakeNullDecimalInt64(10, 1).Sub(price)
+	assert.NoError(t, err)
+	assert.Exactly(t, "-18.99", diff.String())
+
+	prod, err := price.Mul(tax)
+	assert.NoError(t, err)
+	assert.Exactly(t, "3.7981", prod.String())
+
+	null, err := price.Add(NullDecimal{})
+	assert.NoError(t, err)
+	assert.False(t, null.Valid)
+
+	_, err = NullDecimal{Precision: math.MaxUint64, Valid: true}.Mul(MakeNullDecimalInt64(2, 0))
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+
+	assert.Exactly(t, 0, MakeNullDecimalInt64(150, 2).Cmp(MakeNullDecimalInt64(15, 1)))
+	assert.Exactly(t, 1, price.Cmp(tax))
+	assert.Exactly(t, -1, MakeNullDecimalInt64(-1, 0).Cmp(tax))
+	assert.Exactly(t, -1, NullDecimal{}.Cmp(tax))
+}
+
+func TestNullDecimal_Scan(t *testing.T) {
+	t.Parallel()
+	var d NullDecimal
+	assert.NoError(t, d.Scan([]byte("4711.0010")))
+	assert.Exactly(t, "4711.0010", d.String())
+
+	assert.NoError(t, d.Scan(int64(-3)))
+	assert.Exactly(t, "-3", d.String())
+
+	assert.NoError(t, d.Scan(0.1))
+	assert.Exactly(t, "0.1", d.String())
+
+	assert.NoError(t, d.Scan(nil))
+	assert.False(t, d.Valid)
+	v, err := d.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	assert.True(t, errors.IsNotSupported(d.Scan(true)))
+	assert.True(t, errors.IsNotValid(d.Scan(math.NaN())))
+
+	v, err = MakeNullDecimalInt64(-5, 1).Value()
+	assert.NoError(t, err)
+	assert.Exactly(t, "-0.5", v)
+}
+
+func TestNullDecimal_JSON_Text(t *testing.T) {
+	t.Parallel()
+	type product struct {
+		Price NullDecimal
+		Tax   NullDecimal
+	}
+	data, err := json.Marshal(product{Price: MakeNullDecimalInt64(12990, 3)})
+	assert.NoError(t, err)
+	assert.Exactly(t, `{"Price":12.990,"Tax":null}`, string(data))
+
+	var p product
+	assert.NoError(t, json.Unmarshal([]byte(`{"Price":"0.30","Tax":19.00}`), &p))
+	assert.Exactly(t, "0.30", p.Price.String())
+	assert.Exactly(t, "19.00", p.Tax.String())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"Price":true}`), &p))
+
+	txt, err := MakeNullDecimalInt64(-1, 2).MarshalText()
+	assert.NoError(t, err)
+	assert.Exactly(t, "-0.01", string(txt))
+	txt, err = NullDecimal{}.MarshalText()
+	assert.NoError(t, err)
+	assert.Exactly(t, "", string(txt))
+
+	var d NullDecimal
+	assert.NoError(t, d.UnmarshalText([]byte("1.5")))
+	assert.Exactly(t, "1.5", d.String())
+	assert.NoError(t, d.UnmarshalText([]byte("")))
+	assert.False(t, d.Valid)
+}
+
+func TestNullDecimal_Argument(t *testing.T) {
+	t.Parallel()
+	nds := []NullDecimal{{}, MakeNullDecimalInt64(-2799, 2)}
+	var buf bytes.Buffer
+	args := make([]interface{}, 0, 2)
+	for i, nd := range nds {
+		nd.toIFace(&args)
+		assert.NoError(t, nd.writeTo(&buf, i))
+
+		arg := nd.Operator(NotBetween)
+		assert.Exactly(t, NotBetween, arg.operator(), "Index %d", i)
+		assert.Exactly(t, 1, arg.len(), "Length must be always one")
+	}
+	assert.Exactly(t, []interface{}{interface{}(nil), "-27.99"}, args)
+	assert.Exactly(t, "NULL-27.99", buf.String())
+}
+
+func TestArgNullDecimal(t *testing.T) {
+	t.Parallel()
+	args := ArgNullDecimal(MakeNullDecimalInt64(1, 1), NullDecimal{}, MakeNullDecimalInt64(25, 0))
+	assert.Exactly(t, 3, args.len())
+	args = args.Operator(In)
+	assert.Exactly(t, 1, args.len())
+
+	var buf bytes.Buffer
+	argIF := make([]interface{}, 0, 3)
+	assert.NoError(t, args.writeTo(&buf, 0))
+	args.toIFace(&argIF)
+	assert.Exactly(t, []interface{}{"0.1", interface{}(nil), "25"}, argIF)
+	assert.Exactly(t, "(0.1,NULL,25)", buf.String())
+
+	args = args.Operator(Equal)
+	buf.Reset()
+	assert.NoError(t, args.writeTo(&buf, 2))
+	assert.Exactly(t, "25", buf.String())
+
+	sStr, sArgs, err := NewSelect("a").From("catalog_product_entity_decimal").
+		Where(Condition("value", ArgNullDecimal(MakeNullDecimalInt64(1999, 2)).Operator(GreaterOrEqual))).ToSQL()
+	assert.NoError(t, err)
+	assert.Exactly(t, "SELECT a FROM `catalog_product_entity_decimal` WHERE (`value` >= ?)", sStr)
+	assert.Exactly(t, []interface{}{"19.99"}, sArgs.Interfaces())
+
+	iStr, err := Preprocess("SELECT a FROM b WHERE price >= ?", MakeNullDecimalInt64(1999, 2))
+	assert.NoError(t, err)
+	assert.Exactly(t, "SELECT a FROM b WHERE price >= 19.99", iStr)
+}
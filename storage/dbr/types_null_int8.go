@@ -0,0 +1,194 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"database/sql/driver"
+	"math"
+	"strconv"
+
+	"github.com/corestoreio/errors"
+)
+
+// NullInt8 is a nullable int8 for MySQL TINYINT columns, often used as flags.
+// It does not consider zero values to be null. It will decode to null, not
+// zero, if null. Scanning a value out of the int8 range returns a NotValid
+// error behaviour. NullInt8 implements interface Argument.
+type NullInt8 struct {
+	Int8  int8
+	Valid bool // Valid is true if Int8 is not NULL
+	opt   byte
+}
+
+func (a *NullInt8) setInt64(v int64) error {
+	if v < math.MinInt8 || v > math.MaxInt8 {
+		return errors.NewNotValidf(errNullIntOutOfRange, v, "NullInt8")
+	}
+	a.Int8, a.Valid = int8(v), true
+	return nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (a *NullInt8) Scan(value interface{}) error {
+	a.Int8, a.Valid = 0, false
+	var v int64
+	var err error
+	switch x := value.(type) {
+	case nil:
+		return nil
+	case int64:
+		v = x
+	case []byte:
+		v, err = strconv.ParseInt(string(x), 10, 64)
+	case string:
+		v, err = strconv.ParseInt(x, 10, 64)
+	case bool:
+		if x {
+			v = 1
+		}
+	default:
+		return errors.NewNotSupportedf("[dbr] NullInt8.Scan type %T not supported", value)
+	}
+	if err != nil {
+		return errors.NewNotValidf("[dbr] NullInt8.Scan: %s", err)
+	}
+	return a.setInt64(v)
+}
+
+// Value implements the driver.Valuer interface.
+func (a NullInt8) Value() (driver.Value, error) {
+	if !a.Valid {
+		return nil, nil
+	}
+	return int64(a.Int8), nil
+}
+
+func (a NullInt8) toIFace(args *[]interface{}) {
+	if a.Valid {
+		*args = append(*args, int64(a.Int8))
+	} else {
+		*args = append(*args, nil)
+	}
+}
+
+func (a NullInt8) writeTo(w queryWriter, _ int) error {
+	if a.Valid {
+		_, err := w.WriteString(strconv.FormatInt(int64(a.Int8), 10))
+		return err
+	}
+	_, err := w.WriteString(sqlStrNull)
+	return err
+}
+
+func (a NullInt8) len() int { return 1 }
+
+// Operator sets the SQL operator (IN, =, LIKE, BETWEEN, ...). Please refer to
+// the constants Operator*.
+func (a NullInt8) Operator(opt byte) Argument {
+	a.opt = opt
+	return a
+}
+
+func (a NullInt8) operator() byte { return a.opt }
+
+// MakeNullInt8 creates a new NullInt8. Setting the second optional argument
+// to false, the value will not be valid anymore, hence NULL. NullInt8
+// implements interface Argument.
+func MakeNullInt8(i int8, valid ...bool) NullInt8 {
+	v := true
+	if len(valid) == 1 {
+		v = valid[0]
+	}
+	return NullInt8{
+		Int8:  i,
+		Valid: v,
+	}
+}
+
+// GoString prints an optimized Go representation.
+func (a NullInt8) GoString() string {
+	if !a.Valid {
+		return "dbr.NullInt8{}"
+	}
+	return "dbr.MakeNullInt8(" + strconv.FormatInt(int64(a.Int8), 10) + ")"
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It supports number and null
+// input. Numbers out of the int8 range return an error.
+func (a *NullInt8) UnmarshalJSON(data []byte) error {
+	a.Int8, a.Valid = 0, false
+	if string(data) == "null" {
+		return nil
+	}
+	v, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return errors.NewNotValidf("[dbr] json: cannot unmarshal %q into Go value of type dbr.NullInt8: %s", data, err)
+	}
+	return a.setInt64(v)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It will unmarshal to a
+// null NullInt8 if the input is a blank or "null".
+func (a *NullInt8) UnmarshalText(text []byte) error {
+	a.Int8, a.Valid = 0, false
+	str := string(text)
+	if str == "" || str == "null" {
+		return nil
+	}
+	v, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return errors.NewNotValidf("[dbr] NullInt8.UnmarshalText: %s", err)
+	}
+	return a.setInt64(v)
+}
+
+// MarshalJSON implements json.Marshaler.
+// It will encode null if this NullInt8 is null.
+func (a NullInt8) MarshalJSON() ([]byte, error) {
+	if !a.Valid {
+		return []byte("null"), nil
+	}
+	return strconv.AppendInt([]byte{}, int64(a.Int8), 10), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+// It will encode a blank string if this NullInt8 is null.
+func (a NullInt8) MarshalText() ([]byte, error) {
+	if !a.Valid {
+		return []byte{}, nil
+	}
+	return strconv.AppendInt([]byte{}, int64(a.Int8), 10), nil
+}
+
+// SetValid changes this NullInt8's value and also sets it to be non-null.
+func (a *NullInt8) SetValid(n int8) {
+	a.Int8 = n
+	a.Valid = true
+}
+
+// Ptr returns a pointer to this NullInt8's value, or a nil pointer if this
+// NullInt8 is null.
+func (a NullInt8) Ptr() *int8 {
+	if !a.Valid {
+		return nil
+	}
+	return &a.Int8
+}
+
+// IsZero returns true for invalid NullInt8's. A non-null NullInt8 with a 0
+// value will not be considered zero.
+func (a NullInt8) IsZero() bool {
+	return !a.Valid
+}
@@ -0,0 +1,98 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullInt8_Scan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in     interface{}
+		want   NullInt8
+		errBhf errors.BehaviourFunc
+	}{
+		{nil, NullInt8{}, nil},
+		{int64(-128), MakeNullInt8(-128), nil},
+		{[]byte("127"), MakeNullInt8(127), nil},
+		{"1", MakeNullInt8(1), nil},
+		{true, MakeNullInt8(1), nil},
+		{int64(128), NullInt8{}, errors.IsNotValid},
+		{[]byte("-129"), NullInt8{}, errors.IsNotValid},
+		{[]byte("x"), NullInt8{}, errors.IsNotValid},
+		{3.14, NullInt8{}, errors.IsNotSupported},
+	}
+	for i, test := range tests {
+		var ni NullInt8
+		err := ni.Scan(test.in)
+		if test.errBhf != nil {
+			assert.True(t, test.errBhf(err), "Index %d => %+v", i, err)
+			assert.False(t, ni.Valid, "Index %d", i)
+			continue
+		}
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, ni, "Index %d", i)
+	}
+}
+
+func TestNullInt8_JSON(t *testing.T) {
+	t.Parallel()
+
+	var ni NullInt8
+	require.NoError(t, json.Unmarshal([]byte(`-12`), &ni))
+	assert.Exactly(t, MakeNullInt8(-12), ni)
+	data, err := json.Marshal(ni)
+	require.NoError(t, err)
+	assert.Exactly(t, `-12`, string(data))
+
+	err = json.Unmarshal([]byte(`300`), &ni)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	assert.False(t, ni.Valid)
+
+	data, err = json.Marshal(ni)
+	require.NoError(t, err)
+	assert.Exactly(t, `null`, string(data))
+
+	require.NoError(t, ni.UnmarshalText([]byte("")))
+	assert.True(t, ni.IsZero())
+	assert.Nil(t, ni.Ptr())
+	assert.Exactly(t, "dbr.NullInt8{}", ni.GoString())
+}
+
+func TestNullInt8_Argument(t *testing.T) {
+	t.Parallel()
+
+	nss := []NullInt8{MakeNullInt8(3, false), MakeNullInt8(-5)}
+	var buf bytes.Buffer
+	args := make([]interface{}, 0, 2)
+	for i, ns := range nss {
+		ns.toIFace(&args)
+		require.NoError(t, ns.writeTo(&buf, i))
+
+		arg := ns.Operator(NotBetween)
+		assert.Exactly(t, NotBetween, arg.operator(), "Index %d", i)
+		assert.Exactly(t, 1, arg.len(), "Length must be always one")
+	}
+	assert.Exactly(t, []interface{}{interface{}(nil), int64(-5)}, args)
+	assert.Exactly(t, "NULL-5", buf.String())
+}
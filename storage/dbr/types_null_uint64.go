@@ -0,0 +1,248 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"database/sql/driver"
+	"math"
+	"strconv"
+
+	"github.com/corestoreio/errors"
+)
+
+// NullUint64 is a nullable uint64 for MySQL BIGINT UNSIGNED columns. It does
+// not consider zero values to be null. It will decode to null, not zero, if
+// null. NullUint64 implements interface Argument.
+type NullUint64 struct {
+	Uint64 uint64
+	Valid  bool // Valid is true if Uint64 is not NULL
+	opt    byte
+}
+
+// uint64ToIFace converts an uint64 into a valid driver.Value. Values larger
+// than math.MaxInt64 are not supported by the database/sql package and get
+// passed as a string which MySQL converts back into a number.
+func uint64ToIFace(v uint64) interface{} {
+	if v > math.MaxInt64 {
+		return strconv.FormatUint(v, 10)
+	}
+	return int64(v)
+}
+
+// Scan implements the sql.Scanner interface. Negative numbers return a
+// NotValid error behaviour.
+func (a *NullUint64) Scan(value interface{}) (err error) {
+	a.Uint64, a.Valid = 0, false
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		a.Uint64, err = strconv.ParseUint(string(v), 10, 64)
+	case string:
+		a.Uint64, err = strconv.ParseUint(v, 10, 64)
+	case int64:
+		if v < 0 {
+			return errors.NewNotValidf(errNullUintNegative, v)
+		}
+		a.Uint64 = uint64(v)
+	default:
+		return errors.NewNotSupportedf("[dbr] NullUint64.Scan type %T not supported", value)
+	}
+	if err != nil {
+		return errors.NewNotValidf("[dbr] NullUint64.Scan: %s", err)
+	}
+	a.Valid = true
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (a NullUint64) Value() (driver.Value, error) {
+	if !a.Valid {
+		return nil, nil
+	}
+	return uint64ToIFace(a.Uint64), nil
+}
+
+func (a NullUint64) toIFace(args *[]interface{}) {
+	if a.Valid {
+		*args = append(*args, uint64ToIFace(a.Uint64))
+	} else {
+		*args = append(*args, nil)
+	}
+}
+
+func (a NullUint64) writeTo(w queryWriter, _ int) error {
+	if a.Valid {
+		_, err := w.WriteString(strconv.FormatUint(a.Uint64, 10))
+		return err
+	}
+	_, err := w.WriteString(sqlStrNull)
+	return err
+}
+
+func (a NullUint64) len() int { return 1 }
+
+// Operator sets the SQL operator (IN, =, LIKE, BETWEEN, ...). Please refer to
+// the constants Operator*.
+func (a NullUint64) Operator(opt byte) Argument {
+	a.opt = opt
+	return a
+}
+
+func (a NullUint64) operator() byte { return a.opt }
+
+// MakeNullUint64 creates a new NullUint64. Setting the second optional argument
+// to false, the value will not be valid anymore, hence NULL. NullUint64
+// implements interface Argument.
+func MakeNullUint64(i uint64, valid ...bool) NullUint64 {
+	v := true
+	if len(valid) == 1 {
+		v = valid[0]
+	}
+	return NullUint64{
+		Uint64: i,
+		Valid:  v,
+	}
+}
+
+// GoString prints an optimized Go representation.
+func (a NullUint64) GoString() string {
+	if !a.Valid {
+		return "dbr.NullUint64{}"
+	}
+	return "dbr.MakeNullUint64(" + strconv.FormatUint(a.Uint64, 10) + ")"
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It supports number and null
+// input. 0 will not be considered a null NullUint64. Negative numbers and
+// numbers larger than an uint64 return an error.
+func (a *NullUint64) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		a.Uint64, a.Valid = 0, false
+		return nil
+	}
+	var err error
+	a.Uint64, err = strconv.ParseUint(string(data), 10, 64)
+	a.Valid = err == nil
+	if err != nil {
+		return errors.NewNotValidf("[dbr] json: cannot unmarshal %q into Go value of type dbr.NullUint64: %s", data, err)
+	}
+	return nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It will unmarshal to a
+// null NullUint64 if the input is a blank or "null".
+func (a *NullUint64) UnmarshalText(text []byte) error {
+	str := string(text)
+	if str == "" || str == "null" {
+		a.Valid = false
+		return nil
+	}
+	var err error
+	a.Uint64, err = strconv.ParseUint(str, 10, 64)
+	a.Valid = err == nil
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+// It will encode null if this NullUint64 is null.
+func (a NullUint64) MarshalJSON() ([]byte, error) {
+	if !a.Valid {
+		return []byte("null"), nil
+	}
+	return strconv.AppendUint([]byte{}, a.Uint64, 10), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+// It will encode a blank string if this NullUint64 is null.
+func (a NullUint64) MarshalText() ([]byte, error) {
+	if !a.Valid {
+		return []byte{}, nil
+	}
+	return strconv.AppendUint([]byte{}, a.Uint64, 10), nil
+}
+
+// SetValid changes this NullUint64's value and also sets it to be non-null.
+func (a *NullUint64) SetValid(n uint64) {
+	a.Uint64 = n
+	a.Valid = true
+}
+
+// Ptr returns a pointer to this NullUint64's value, or a nil pointer if this
+// NullUint64 is null.
+func (a NullUint64) Ptr() *uint64 {
+	if !a.Valid {
+		return nil
+	}
+	return &a.Uint64
+}
+
+// IsZero returns true for invalid NullUint64's, for future omitempty support.
+// A non-null NullUint64 with a 0 value will not be considered zero.
+func (a NullUint64) IsZero() bool {
+	return !a.Valid
+}
+
+type argNullUint64s struct {
+	opt  byte
+	data []NullUint64
+}
+
+func (a argNullUint64s) toIFace(args *[]interface{}) {
+	for _, s := range a.data {
+		s.toIFace(args)
+	}
+}
+
+func (a argNullUint64s) writeTo(w queryWriter, pos int) error {
+	if a.operator() != In && a.operator() != NotIn {
+		return a.data[pos].writeTo(w, pos)
+	}
+	l := len(a.data) - 1
+	w.WriteRune('(')
+	for i, v := range a.data {
+		v.writeTo(w, i)
+		if i < l {
+			w.WriteRune(',')
+		}
+	}
+	_, err := w.WriteRune(')')
+	return err
+}
+
+func (a argNullUint64s) len() int {
+	if isNotIn(a.operator()) {
+		return len(a.data)
+	}
+	return 1
+}
+
+// Operator sets the SQL operator (IN, =, LIKE, BETWEEN, ...). Please refer to
+// the constants Operator*.
+func (a argNullUint64s) Operator(opt byte) Argument {
+	a.opt = opt
+	return a
+}
+
+func (a argNullUint64s) operator() byte { return a.opt }
+
+// ArgNullUint64 adds a nullable uint64 or a slice of nullable uint64s to the
+// argument list. Providing no arguments returns a NULL type.
+func ArgNullUint64(args ...NullUint64) Argument {
+	if len(args) == 1 {
+		return args[0]
+	}
+	return argNullUint64s{data: args}
+}
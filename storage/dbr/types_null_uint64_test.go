@@ -0,0 +1,148 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArgUint64(t *testing.T) {
+	t.Parallel()
+
+	t.Run("single", func(t *testing.T) {
+		var buf bytes.Buffer
+		argIF := make([]interface{}, 0, 2)
+		a := ArgUint64(math.MaxUint64)
+		require.NoError(t, a.writeTo(&buf, 0))
+		a.toIFace(&argIF)
+		assert.Exactly(t, "18446744073709551615", buf.String())
+		assert.Exactly(t, []interface{}{"18446744073709551615"}, argIF)
+	})
+
+	t.Run("IN operator", func(t *testing.T) {
+		var buf bytes.Buffer
+		argIF := make([]interface{}, 0, 2)
+		a := ArgUint64(3, math.MaxUint64).Operator(In)
+		assert.Exactly(t, 1, a.len())
+		require.NoError(t, a.writeTo(&buf, 0))
+		a.toIFace(&argIF)
+		assert.Exactly(t, "(3,18446744073709551615)", buf.String())
+		assert.Exactly(t, []interface{}{int64(3), "18446744073709551615"}, argIF)
+	})
+
+	t.Run("interpolate", func(t *testing.T) {
+		str, err := Preprocess("SELECT * FROM x WHERE a = ? AND b IN ?",
+			ArgUint64(math.MaxUint64), ArgUint64(1, 2).Operator(In))
+		require.NoError(t, err)
+		assert.Exactly(t, "SELECT * FROM x WHERE a = 18446744073709551615 AND b IN (1,2)", str)
+	})
+}
+
+func TestNullUint64_Scan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in     interface{}
+		want   NullUint64
+		errBhf errors.BehaviourFunc
+	}{
+		{nil, NullUint64{}, nil},
+		{int64(42), MakeNullUint64(42), nil},
+		{[]byte("18446744073709551615"), MakeNullUint64(math.MaxUint64), nil},
+		{"18446744073709551614", MakeNullUint64(math.MaxUint64 - 1), nil},
+		{int64(-1), NullUint64{}, errors.IsNotValid},
+		{[]byte("18446744073709551616"), NullUint64{}, errors.IsNotValid},
+		{3.14, NullUint64{}, errors.IsNotSupported},
+	}
+	for i, test := range tests {
+		var nu NullUint64
+		err := nu.Scan(test.in)
+		if test.errBhf != nil {
+			assert.True(t, test.errBhf(err), "Index %d => %+v", i, err)
+			assert.False(t, nu.Valid, "Index %d", i)
+			continue
+		}
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, nu, "Index %d", i)
+	}
+}
+
+func TestNullUint64_Value(t *testing.T) {
+	t.Parallel()
+	v, err := MakeNullUint64(math.MaxUint64).Value()
+	require.NoError(t, err)
+	assert.Exactly(t, "18446744073709551615", v)
+
+	v, err = MakeNullUint64(math.MaxInt64).Value()
+	require.NoError(t, err)
+	assert.Exactly(t, int64(math.MaxInt64), v)
+
+	v, err = MakeNullUint64(1, false).Value()
+	require.NoError(t, err)
+	assert.Nil(t, v)
+}
+
+func TestNullUint64_JSON(t *testing.T) {
+	t.Parallel()
+
+	var nu NullUint64
+	require.NoError(t, json.Unmarshal([]byte(`18446744073709551615`), &nu))
+	assert.Exactly(t, MakeNullUint64(math.MaxUint64), nu)
+
+	data, err := json.Marshal(nu)
+	require.NoError(t, err)
+	assert.Exactly(t, `18446744073709551615`, string(data))
+
+	require.NoError(t, json.Unmarshal([]byte(`null`), &nu))
+	assert.False(t, nu.Valid)
+	data, err = json.Marshal(nu)
+	require.NoError(t, err)
+	assert.Exactly(t, `null`, string(data))
+
+	err = json.Unmarshal([]byte(`-1`), &nu)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	assert.False(t, nu.Valid)
+
+	require.NoError(t, nu.UnmarshalText([]byte("7")))
+	txt, err := nu.MarshalText()
+	require.NoError(t, err)
+	assert.Exactly(t, "7", string(txt))
+	assert.Exactly(t, "dbr.MakeNullUint64(7)", nu.GoString())
+	assert.Exactly(t, uint64(7), *nu.Ptr())
+	assert.False(t, nu.IsZero())
+}
+
+func TestArgNullUint64(t *testing.T) {
+	t.Parallel()
+
+	args := ArgNullUint64(MakeNullUint64(1), MakeNullUint64(2, false), MakeNullUint64(math.MaxUint64))
+	assert.Exactly(t, 3, args.len())
+	args = args.Operator(In)
+	assert.Exactly(t, 1, args.len())
+
+	var buf bytes.Buffer
+	argIF := make([]interface{}, 0, 3)
+	require.NoError(t, args.writeTo(&buf, 0))
+	args.toIFace(&argIF)
+	assert.Exactly(t, "(1,NULL,18446744073709551615)", buf.String())
+	assert.Exactly(t, []interface{}{int64(1), interface{}(nil), "18446744073709551615"}, argIF)
+}
@@ -28,6 +28,13 @@ type Union struct {
 	Selects  []*Select
 	OrderBys []string
 	IsAll    bool
+	// Comments get rendered as leading C style comments. See Comment()
+	Comments []string
+	// Dialect rewrites the generated SQL, see Select.Dialect.
+	Dialect Dialect
+	// TableNameMapper gets applied to all Selects which do not have their
+	// own mapper, see Select.TableNameMapper.
+	TableNameMapper TableNameMapper
 }
 
 // NewUnion creates a new Union object.
@@ -43,6 +50,12 @@ func (u *Union) Append(selects ...*Select) *Union {
 	return u
 }
 
+// Comment adds a leading /* comment */ to the statement, see Select.Comment.
+func (u *Union) Comment(c string) *Union {
+	u.Comments = append(u.Comments, c)
+	return u
+}
+
 // All returns all rows. The default behavior for UNION is that duplicate rows
 // are removed from the result. Enabling ALL returns all rows.
 func (u *Union) All() *Union {
@@ -57,7 +70,7 @@ func (u *Union) All() *Union {
 // as a sort column and add an ORDER BY following the last SELECT.
 func (u *Union) PreserveResultSet() *Union {
 	for i, s := range u.Selects {
-		s.AddColumnsExprAlias(strconv.Itoa(i), "_preserve_result_set")
+		s.AddColumnsExprAlias(SQLUnsafe(strconv.Itoa(i)), "_preserve_result_set")
 	}
 	u.OrderBys = append([]string{"`_preserve_result_set`"}, u.OrderBys...)
 	return u
@@ -66,16 +79,16 @@ func (u *Union) PreserveResultSet() *Union {
 // OrderBy appends a column or an expression to ORDER the statement ascending.
 // MySQL will order the result set in a temporary table, which is slow.
 // https://dev.mysql.com/doc/relnotes/mysql/5.7/en/news-5-7-3.html
-func (u *Union) OrderBy(ord ...string) *Union {
-	u.OrderBys = append(u.OrderBys, ord...)
+func (u *Union) OrderBy(ord ...SQLUnsafe) *Union {
+	u.OrderBys = append(u.OrderBys, auditSQLExpr(ord...)...)
 	return u
 }
 
 // OrderByDesc appends a column or an expression to ORDER the statement
 // descending. MySQL will order the result set in a temporary table, which is
 // slow.
-func (u *Union) OrderByDesc(ord ...string) *Union {
-	u.OrderBys = orderByDesc(u.OrderBys, ord)
+func (u *Union) OrderByDesc(ord ...SQLUnsafe) *Union {
+	u.OrderBys = orderByDesc(u.OrderBys, auditSQLExpr(ord...))
 	return u
 }
 
@@ -85,6 +98,7 @@ func (u *Union) ToSQL() (string, Arguments, error) {
 	var w = bufferpool.Get()
 	defer bufferpool.Put(w)
 
+	sqlWriteComments(w, u.Comments)
 	args := make(Arguments, 0, len(u.Selects))
 	for i, s := range u.Selects {
 
@@ -92,7 +106,7 @@ func (u *Union) ToSQL() (string, Arguments, error) {
 			sqlWriteUnionAll(w, u.IsAll)
 		}
 		w.WriteRune('(')
-		sArgs, err := s.toSQL(w)
+		sArgs, err := s.inherit(u.TableNameMapper, u.Dialect).toSQL(w)
 		if err != nil {
 			return "", nil, errors.Wrapf(err, "[dbr] Union.ToSQL at Select index %d", i)
 		}
@@ -100,18 +114,23 @@ func (u *Union) ToSQL() (string, Arguments, error) {
 		args = append(args, sArgs...)
 	}
 	sqlWriteOrderBy(w, u.OrderBys, true)
-	return w.String(), args, nil
+	if err := resolveValuers(args); err != nil {
+		return "", nil, errors.Wrap(err, "[dbr] Union.ToSQL.resolveValuers")
+	}
+	return Rebind(u.Dialect, w.String()), args, nil
 }
 
 // UnionTemplate builds multiple select statements joined by UNION and all based
 // on a common template.
 type UnionTemplate struct {
-	Select        *Select
-	oldNew        [][]string
-	repls         []*strings.Replacer
-	stmtCount     int
-	OrderBys      []string
-	IsAll         bool
+	Select    *Select
+	oldNew    [][]string
+	repls     []*strings.Replacer
+	stmtCount int
+	OrderBys  []string
+	IsAll     bool
+	// Comments get rendered as leading C style comments. See Comment()
+	Comments      []string
 	previousError error
 }
 
@@ -123,6 +142,13 @@ func NewUnionTemplate(selectTemplate *Select) *UnionTemplate {
 	}
 }
 
+// Comment adds a leading /* comment */ to the statement, see Select.Comment.
+// A comment of the template Select gets repeated in each generated SELECT.
+func (ut *UnionTemplate) Comment(c string) *UnionTemplate {
+	ut.Comments = append(ut.Comments, c)
+	return ut
+}
+
 // All returns all rows. The default behavior for UNION is that duplicate rows
 // are removed from the result. Enabling ALL returns all rows.
 func (ut *UnionTemplate) All() *UnionTemplate {
@@ -149,16 +175,16 @@ func (ut *UnionTemplate) PreserveResultSet() *UnionTemplate {
 // OrderBy appends a column or an expression to ORDER the statement ascending.
 // MySQL will order the result set in a temporary table, which is slow.
 // https://dev.mysql.com/doc/relnotes/mysql/5.7/en/news-5-7-3.html
-func (ut *UnionTemplate) OrderBy(ord ...string) *UnionTemplate {
-	ut.OrderBys = append(ut.OrderBys, ord...)
+func (ut *UnionTemplate) OrderBy(ord ...SQLUnsafe) *UnionTemplate {
+	ut.OrderBys = append(ut.OrderBys, auditSQLExpr(ord...)...)
 	return ut
 }
 
 // OrderByDesc appends a column or an expression to ORDER the statement
 // descending. MySQL will order the result set in a temporary table, which is
 // slow.
-func (ut *UnionTemplate) OrderByDesc(ord ...string) *UnionTemplate {
-	ut.OrderBys = orderByDesc(ut.OrderBys, ord)
+func (ut *UnionTemplate) OrderByDesc(ord ...SQLUnsafe) *UnionTemplate {
+	ut.OrderBys = orderByDesc(ut.OrderBys, auditSQLExpr(ord...))
 	return ut
 }
 
@@ -222,6 +248,7 @@ func (ut *UnionTemplate) ToSQL() (string, Arguments, error) {
 
 	wu := bufferpool.Get()
 	defer bufferpool.Put(wu)
+	sqlWriteComments(wu, ut.Comments)
 
 	for i := 0; i < ut.stmtCount; i++ {
 		repl := ut.repls[i]
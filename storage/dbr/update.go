@@ -3,6 +3,7 @@ package dbr
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/corestoreio/csfw/util/bufferpool"
 	"github.com/corestoreio/errors"
@@ -12,16 +13,25 @@ import (
 // Update contains the clauses for an UPDATE statement
 type Update struct {
 	Log log.Logger
-	DB  struct {
+	// Dialect rewrites the generated SQL for the target database server. Nil
+	// or DialectMySQL keeps the MySQL syntax.
+	Dialect Dialect
+	// TableNameMapper rewrites the table names when generating the SQL, for
+	// example to add a per-tenant prefix. Nil keeps the names unchanged.
+	TableNameMapper TableNameMapper
+	// QueryTimeout cancels the execution after the duration. See Timeout()
+	QueryTimeout time.Duration
+	DB           struct {
 		Preparer
 		Execer
 	}
-	// TODO: add UPDATE JOINS
-
 	RawFullSQL   string
 	RawArguments Arguments
 
 	Table alias
+	// JoinFragments turns the statement into a multiple-table UPDATE. See
+	// Join().
+	JoinFragments
 	// SetClauses contains the column/argument association. For each column
 	// there must be one argument.
 	SetClauses UpdatedColumns
@@ -31,6 +41,13 @@ type Update struct {
 	OffsetCount uint64
 	LimitValid  bool
 	OffsetValid bool
+	// IsStrict enables the validation mode. See Strict()
+	IsStrict bool
+	// Statement modifiers, MySQL only.
+	IsLowPriority bool // See LowPriority()
+	IsIgnore      bool // See Ignore()
+	// Comments get rendered as leading C style comments. See Comment()
+	Comments []string
 	// PropagationStopped set to true if you would like to interrupt the
 	// listener chain. Once set to true all sub sequent calls of the next
 	// listeners will be suppressed.
@@ -56,29 +73,39 @@ func NewUpdate(table ...string) *Update {
 // Update creates a new Update for the given table
 func (c *Connection) Update(table ...string) *Update {
 	u := &Update{
-		Log:   c.Log,
-		Table: MakeAlias(table...),
+		Log:     c.Log,
+		Dialect: c.Dialect,
+		Table:   MakeAlias(table...),
+
+		TableNameMapper: c.TableNameMapper,
 	}
-	u.DB.Execer = c.DB
+	u.DB.Execer = c.dber()
 	return u
 }
 
 // UpdateBySQL creates a new Update for the given SQL string and arguments
 func (c *Connection) UpdateBySQL(sql string, args ...Argument) *Update {
+	auditSQLUnsafe(SQLUnsafe(sql))
 	u := &Update{
 		Log:          c.Log,
+		Dialect:      c.Dialect,
 		RawFullSQL:   sql,
 		RawArguments: args,
+
+		TableNameMapper: c.TableNameMapper,
 	}
-	u.DB.Execer = c.DB
+	u.DB.Execer = c.dber()
 	return u
 }
 
 // Update creates a new Update for the given table bound to a transaction
 func (tx *Tx) Update(table ...string) *Update {
 	u := &Update{
-		Log:   tx.Logger,
-		Table: MakeAlias(table...),
+		Log:     tx.Logger,
+		Dialect: tx.Dialect,
+		Table:   MakeAlias(table...),
+
+		TableNameMapper: tx.TableNameMapper,
 	}
 	u.DB.Execer = tx.Tx
 	return u
@@ -87,15 +114,49 @@ func (tx *Tx) Update(table ...string) *Update {
 // UpdateBySQL creates a new Update for the given SQL string and arguments bound
 // to a transaction
 func (tx *Tx) UpdateBySQL(sql string, args ...Argument) *Update {
+	auditSQLUnsafe(SQLUnsafe(sql))
 	u := &Update{
 		Log:          tx.Logger,
+		Dialect:      tx.Dialect,
 		RawFullSQL:   sql,
 		RawArguments: args,
+
+		TableNameMapper: tx.TableNameMapper,
 	}
 	u.DB.Execer = tx.Tx
 	return u
 }
 
+// Join creates an INNER join construct. By default, the onConditions are glued
+// together with AND. Together with MakeAliasFromSub the rows can be updated
+// with the values of a derived table. The arguments of the derived table and of
+// the onConditions get merged. A multiple-table UPDATE does not support ORDER
+// BY and LIMIT.
+//		dbr.NewUpdate("catalog_product_entity", "e").
+//			Join(dbr.MakeAliasFromSub(sel, "t"), dbr.Condition("t.entity_id = e.entity_id")).
+//			Set("e.sold_qty", dbr.ArgExpr("t.qty"))
+//		// UPDATE `catalog_product_entity` AS `e` INNER JOIN (SELECT ...) AS `t`
+//		// ON (t.entity_id = e.entity_id) SET `e`.`sold_qty`=t.qty
+func (b *Update) Join(table alias, onConditions ...ConditionArg) *Update {
+	return b.join("INNER", table, onConditions...)
+}
+
+// LeftJoin creates a LEFT join construct. By default, the onConditions are
+// glued together with AND. See Join().
+func (b *Update) LeftJoin(table alias, onConditions ...ConditionArg) *Update {
+	return b.join("LEFT", table, onConditions...)
+}
+
+func (b *Update) join(j string, t alias, on ...ConditionArg) *Update {
+	jf := &joinFragment{
+		JoinType: j,
+		Table:    t,
+	}
+	appendConditions(&jf.OnConditions, on...)
+	b.JoinFragments = append(b.JoinFragments, jf)
+	return b
+}
+
 // Set appends a column/value pair for the statement
 func (b *Update) Set(column string, arg Argument) *Update {
 	if b.previousError != nil {
@@ -128,15 +189,15 @@ func (b *Update) Where(args ...ConditionArg) *Update {
 }
 
 // OrderBy appends a column or an expression to ORDER the statement ascending.
-func (b *Update) OrderBy(ord ...string) *Update {
-	b.OrderBys = append(b.OrderBys, ord...)
+func (b *Update) OrderBy(ord ...SQLUnsafe) *Update {
+	b.OrderBys = append(b.OrderBys, auditSQLExpr(ord...)...)
 	return b
 }
 
 // OrderByDesc appends a column or an expression to ORDER the statement
 // descending.
-func (b *Update) OrderByDesc(ord ...string) *Update {
-	b.OrderBys = orderByDesc(b.OrderBys, ord)
+func (b *Update) OrderByDesc(ord ...SQLUnsafe) *Update {
+	b.OrderBys = orderByDesc(b.OrderBys, auditSQLExpr(ord...))
 	return b
 }
 
@@ -154,9 +215,59 @@ func (b *Update) Offset(offset uint64) *Update {
 	return b
 }
 
+// Ignore does not abort the UPDATE statement even if errors occur. Rows for
+// which duplicate-key conflicts occur on a unique key value are not updated.
+// Rows updated to values that would cause data conversion errors are updated
+// to the closest valid values instead.
+func (b *Update) Ignore() *Update {
+	b.IsIgnore = true
+	return b
+}
+
+// LowPriority delays the execution of the UPDATE until no other clients are
+// reading from the table. Affects only storage engines with table-level
+// locking like MyISAM, MEMORY and MERGE.
+func (b *Update) LowPriority() *Update {
+	b.IsLowPriority = true
+	return b
+}
+
+// Comment adds a leading /* comment */ to the statement, see Select.Comment.
+func (b *Update) Comment(c string) *Update {
+	b.Comments = append(b.Comments, c)
+	return b
+}
+
+// Timeout cancels the execution of the statement after the duration, see
+// Select.Timeout.
+func (b *Update) Timeout(d time.Duration) *Update {
+	b.QueryTimeout = d
+	return b
+}
+
+// Strict enables the validation mode, see Select.Strict. Additionally each
+// column in the SET clause must have exactly one argument.
+func (b *Update) Strict() *Update {
+	b.IsStrict = true
+	return b
+}
+
 // ToSQL serialized the Update to a SQL string
 // It returns the string with placeholders and a slice of query arguments
 func (b *Update) ToSQL() (string, Arguments, error) {
+	sqlStr, args, err := b.rawSQL()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := resolveValuers(args); err != nil {
+		return "", nil, errors.Wrap(err, "[dbr] Update.ToSQL.resolveValuers")
+	}
+	return Rebind(b.Dialect, sqlStr), args, nil
+}
+
+// rawSQL generates the statement in MySQL syntax without applying the
+// Dialect.
+func (b *Update) rawSQL() (string, Arguments, error) {
 	if b.previousError != nil {
 		return "", nil, errors.Wrap(b.previousError, "[dbr] Update.ToSQL")
 	}
@@ -166,7 +277,14 @@ func (b *Update) ToSQL() (string, Arguments, error) {
 	}
 
 	if b.RawFullSQL != "" {
-		return b.RawFullSQL, b.RawArguments, nil
+		if len(b.Comments) == 0 {
+			return b.RawFullSQL, b.RawArguments, nil
+		}
+		var buf = bufferpool.Get()
+		defer bufferpool.Put(buf)
+		sqlWriteComments(buf, b.Comments)
+		buf.WriteString(b.RawFullSQL)
+		return buf.String(), b.RawArguments, nil
 	}
 
 	if len(b.Table.Expression) == 0 {
@@ -175,14 +293,28 @@ func (b *Update) ToSQL() (string, Arguments, error) {
 	if len(b.SetClauses.Columns) == 0 {
 		return "", nil, errors.NewEmptyf("[dbr] Update: SetClauses are empty")
 	}
+	if len(b.JoinFragments) > 0 && (len(b.OrderBys) > 0 || b.LimitValid) {
+		return "", nil, errors.NewNotSupportedf("[dbr] Update: ORDER BY and LIMIT cannot be used with JOINs")
+	}
+	if b.IsStrict {
+		if err := b.validate(); err != nil {
+			return "", nil, errors.Wrap(err, "[dbr] Update.ToSQL.validate")
+		}
+	}
 
 	var buf = bufferpool.Get()
 	defer bufferpool.Put(buf)
 
 	var args = make(Arguments, 0, len(b.SetClauses.Arguments)+len(b.WhereFragments))
 
+	sqlWriteComments(buf, b.Comments)
 	buf.WriteString("UPDATE ")
-	b.Table.FquoteAs(buf)
+	sqlWriteModifier(buf, b.IsLowPriority, "LOW_PRIORITY ")
+	sqlWriteModifier(buf, b.IsIgnore, "IGNORE ")
+	b.Table.mapTableName(b.TableNameMapper, b.Dialect, false).FquoteAs(buf)
+	if err := sqlWriteJoins(buf, b.TableNameMapper, b.Dialect, b.JoinFragments, &args); err != nil {
+		return "", nil, errors.Wrap(err, "[dbr] Update.ToSQL.sqlWriteJoins")
+	}
 	buf.WriteString(" SET ")
 
 	// Build SET clause SQL with placeholders and add values to args
@@ -208,19 +340,21 @@ func (b *Update) ToSQL() (string, Arguments, error) {
 
 	// Write WHERE clause if we have any fragments
 	if len(b.WhereFragments) > 0 {
-		if err := writeWhereFragmentsToSQL(b.WhereFragments, buf, &args, 'w'); err != nil {
+		if err := writeWhereFragmentsToSQL(b.WhereFragments, buf, b.TableNameMapper, b.Dialect, &args, 'w'); err != nil {
 			return "", nil, errors.Wrap(err, "[dbr] Update.ToSQL.writeWhereFragmentsToSQL")
 		}
 	}
 	sqlWriteOrderBy(buf, b.OrderBys, false)
-	sqlWriteLimitOffset(buf, b.LimitValid, b.LimitCount, b.OffsetValid, b.OffsetCount)
+	sqlWriteLimitOffset(buf, b.Dialect, b.LimitValid, b.LimitCount, b.OffsetValid, b.OffsetCount)
 	return buf.String(), args, nil
 }
 
 // Exec executes the statement represented by the Update object. It returns the
 // raw database/sql Result and an error if there was one.
 func (b *Update) Exec(ctx context.Context) (sql.Result, error) {
-	rawSQL, args, err := b.ToSQL()
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
+	rawSQL, args, err := b.rawSQL()
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Update.Exec.ToSQL")
 	}
@@ -229,6 +363,7 @@ func (b *Update) Exec(ctx context.Context) (sql.Result, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Update.Exec.Preprocess")
 	}
+	fullSQL = Rebind(b.Dialect, fullSQL)
 
 	if b.Log != nil && b.Log.IsInfo() {
 		defer log.WhenDone(b.Log).Info("dbr.Update.Exec.Timing", log.String("sql", fullSQL))
@@ -236,7 +371,7 @@ func (b *Update) Exec(ctx context.Context) (sql.Result, error) {
 
 	result, err := b.DB.ExecContext(ctx, fullSQL)
 	if err != nil {
-		return result, errors.Wrap(err, "[dbr] Update.Exec.Exec")
+		return result, wrapMySQLError(err, "[dbr] Update.Exec.Exec")
 	}
 
 	return result, nil
@@ -245,6 +380,8 @@ func (b *Update) Exec(ctx context.Context) (sql.Result, error) {
 // Prepare creates a new prepared statement represented by the Update object. It
 // returns the raw database/sql Stmt and an error if there was one.
 func (b *Update) Prepare(ctx context.Context) (*sql.Stmt, error) {
+	ctx, cancel := withQueryTimeout(ctx, b.QueryTimeout)
+	defer cancel()
 	rawSQL, _, err := b.ToSQL() // TODO create a ToSQL version without any arguments
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] Update.Prepare.ToSQL")
@@ -371,6 +508,15 @@ func (b *UpdateMulti) AddRecords(recs ...ArgumentGenerater) *UpdateMulti {
 	return b
 }
 
+// AddStructs adds structs or pointers to structs as records. See
+// Insert.AddStructs.
+func (b *UpdateMulti) AddStructs(structs ...interface{}) *UpdateMulti {
+	for _, s := range structs {
+		b.Records = append(b.Records, StructRecord(s))
+	}
+	return b
+}
+
 func (b *UpdateMulti) validate() error {
 	if len(b.Update.SetClauses.Columns) == 0 {
 		return errors.NewEmptyf("[dbr] UpdateMulti: Columns are empty")
@@ -389,7 +535,7 @@ func txUpdateMultiRollback(tx Txer, previousErr error, msg string, args ...inter
 		eArg := []interface{}{previousErr}
 		return nil, errors.Wrapf(err, "[dbr] UpdateMulti.Tx.Rollback. Previous Error: %s. "+msg, append(eArg, args...)...)
 	}
-	return nil, errors.Wrapf(previousErr, msg, args...)
+	return nil, wrapMySQLError(previousErr, msg, args...)
 }
 
 // Exec creates a transaction
@@ -398,7 +544,7 @@ func (b *UpdateMulti) Exec(ctx context.Context) ([]sql.Result, error) {
 		return nil, errors.Wrap(err, "[dbr] UpdateMulti.Exec")
 	}
 
-	rawSQL, _, err := b.Update.ToSQL()
+	rawSQL, _, err := b.Update.rawSQL()
 	if err != nil {
 		return nil, errors.Wrap(err, "[dbr] UpdateMulti.Exec.ToSQL")
 	}
@@ -426,7 +572,7 @@ func (b *UpdateMulti) Exec(ctx context.Context) ([]sql.Result, error) {
 	var stmt *sql.Stmt
 	if !b.UsePreprocess {
 		var err error
-		stmt, err = prep.PrepareContext(ctx, rawSQL)
+		stmt, err = prep.PrepareContext(ctx, Rebind(b.Update.Dialect, rawSQL))
 		if err != nil {
 			return txUpdateMultiRollback(tx, err, "[dbr] UpdateMulti.Exec.Prepare. with Query: %q", rawSQL)
 		}
@@ -456,7 +602,7 @@ func (b *UpdateMulti) Exec(ctx context.Context) ([]sql.Result, error) {
 				return txUpdateMultiRollback(tx, err, "[dbr] UpdateMulti.Exec.Preprocess. Index %d with Query: %q", i, rawSQL)
 			}
 
-			results[i], err = exec.ExecContext(ctx, fullSQL)
+			results[i], err = exec.ExecContext(ctx, Rebind(b.Update.Dialect, fullSQL))
 			if err != nil {
 				return txUpdateMultiRollback(tx, err, "[dbr] UpdateMulti.Exec.Exec. Index %d with Query: %q", i, rawSQL)
 			}
@@ -469,7 +615,7 @@ func (b *UpdateMulti) Exec(ctx context.Context) ([]sql.Result, error) {
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, errors.Wrapf(err, "[dbr] UpdateMulti.Tx.Commit. Query: %q", rawSQL)
+		return nil, wrapMySQLError(err, "[dbr] UpdateMulti.Tx.Commit. Query: %q", rawSQL)
 	}
 
 	return results, nil
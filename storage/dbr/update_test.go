@@ -8,6 +8,7 @@ import (
 	"github.com/corestoreio/errors"
 	"github.com/corestoreio/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var benchmarkUpdateValuesSQL Arguments
@@ -61,6 +62,25 @@ func TestUpdateSingleToSQL(t *testing.T) {
 	assert.Equal(t, []interface{}{int64(1), int64(2), int64(1)}, args.Interfaces())
 }
 
+func TestUpdate_Modifiers(t *testing.T) {
+	newUpd := func() *Update {
+		return NewUpdate("a").Set("b", argInt(1))
+	}
+	tests := []struct {
+		upd     *Update
+		wantSQL string
+	}{
+		{newUpd().Ignore(), "UPDATE IGNORE `a` SET `b`=?"},
+		{newUpd().LowPriority(), "UPDATE LOW_PRIORITY `a` SET `b`=?"},
+		{newUpd().Ignore().LowPriority().Where(Condition("id", argInt(2))), "UPDATE LOW_PRIORITY IGNORE `a` SET `b`=? WHERE (`id` = ?)"},
+	}
+	for i, test := range tests {
+		sqlStr, _, err := test.upd.ToSQL()
+		require.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantSQL, sqlStr, "Index %d", i)
+	}
+}
+
 func TestUpdateSetMapToSQL(t *testing.T) {
 	s := createFakeSession()
 
@@ -392,3 +412,30 @@ func BenchmarkUpdatedColumns_writeOnDuplicateKey(b *testing.B) {
 		args = args[:0]
 	}
 }
+
+func TestUpdate_Join(t *testing.T) {
+	t.Run("derived table with arguments", func(t *testing.T) {
+		sub := NewSelect("product_id").AddColumnsExprAlias("SUM(qty_ordered)", "qty").
+			From("sales_order_item").
+			Where(Condition("created_at > ?", ArgString("2017-01-01"))).
+			GroupBy("product_id")
+
+		sqlStr, args, err := NewUpdate("catalog_product_entity", "e").
+			Join(MakeAliasFromSub(sub, "t"), Condition("t.product_id = e.entity_id")).
+			Set("e.sold_qty", ArgExpr("t.qty + ?", ArgInt(1))).
+			Set("e.updated_at", ArgString("2017-02-01")).
+			Where(Condition("e.type_id", ArgString("simple"))).ToSQL()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t,
+			"UPDATE `catalog_product_entity` AS `e` INNER JOIN (SELECT product_id, SUM(qty_ordered) AS `qty` FROM `sales_order_item` WHERE (created_at > ?) GROUP BY product_id) AS `t` ON (t.product_id = e.entity_id) SET `e`.`sold_qty`=t.qty + ?, `e`.`updated_at`=? WHERE (`e`.`type_id` = ?)",
+			sqlStr)
+		assert.Exactly(t, []interface{}{"2017-01-01", int64(1), "2017-02-01", "simple"}, args.Interfaces())
+	})
+	t.Run("ORDER BY not supported", func(t *testing.T) {
+		_, _, err := NewUpdate("catalog_product_entity", "e").
+			LeftJoin(MakeAlias("catalog_product_website", "w"), Condition("w.product_id = e.entity_id")).
+			Set("e.has_website", ArgBool(false)).
+			OrderBy("e.entity_id").ToSQL()
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+}
@@ -1,6 +1,10 @@
 package dbr
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/corestoreio/csfw/util/bufferpool"
+)
 
 // Stmt is helper for various method to check statements
 var Stmt = stmtChecker{}
@@ -33,9 +37,80 @@ func (sc stmtChecker) IsInsert(sql string) bool {
 	return sc.startContain(sql, "insert", " ")
 }
 
+// splitSortDirection splits off the suffix ASC or DESC, if present.
+func splitSortDirection(s string) (name, dir string) {
+	for _, suffix := range [...]string{" ASC", " DESC", " asc", " desc"} {
+		if strings.HasSuffix(s, suffix) {
+			return s[:len(s)-len(suffix)], suffix
+		}
+	}
+	return s, ""
+}
+
 func orderByDesc(orderBys, ord []string) []string {
 	for _, o := range ord {
 		orderBys = append(orderBys, o+" DESC")
 	}
 	return orderBys
 }
+
+// orderByNulls emulates NULLS FIRST and NULLS LAST with an ISNULL() prefix
+// because MySQL sorts NULL values always as the lowest values.
+func orderByNulls(orderBys, ord []string, desc, nullsFirst bool) []string {
+	dir, nullDir := "", ""
+	if desc {
+		dir = " DESC"
+	}
+	if nullsFirst {
+		nullDir = " DESC"
+	}
+	for _, o := range ord {
+		orderBys = append(orderBys, "ISNULL("+Quoter.QuoteIfReserved(o)+")"+nullDir, o+dir)
+	}
+	return orderBys
+}
+
+// expandPlaceholders replaces each variadic place holder "?..." with as many
+// comma separated place holders as the corresponding argument contains
+// values. Each place holder, variadic or not, consumes one argument. Place
+// holders within quotes get ignored.
+func expandPlaceholders(expression string, args Arguments) string {
+	if !strings.Contains(expression, "?...") {
+		return expression
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	var argPos int
+	for i := 0; i < len(expression); i++ {
+		switch c := expression[i]; c {
+		case '?':
+			if strings.HasPrefix(expression[i+1:], "...") {
+				n := 1
+				if argPos < len(args) {
+					n = args[argPos].len()
+				}
+				for j := 0; j < n; j++ {
+					if j > 0 {
+						buf.WriteString(", ")
+					}
+					buf.WriteByte('?')
+				}
+				i += 3
+			} else {
+				buf.WriteByte('?')
+			}
+			argPos++
+		case '`', '\'', '"':
+			p := strings.IndexByte(expression[i+1:], c)
+			if p < 0 {
+				buf.WriteString(expression[i:])
+				return buf.String()
+			}
+			buf.WriteString(expression[i : i+p+2])
+			i += p + 1
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,223 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"strings"
+
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/errors"
+)
+
+// The functions in this file get only called when a builder runs in strict
+// mode. See for example Select.Strict(). They check the already assembled
+// parts of a statement and return a NotValid error behaviour instead of
+// generating broken SQL. The identifier checks follow the same rules as
+// csdb.IsValidIdentifier but cannot use that package due to an import cycle.
+
+// validateIdentifiers checks that each name, except empty ones, is a valid
+// MySQL identifier optionally qualified by a database or table name. The
+// argument `kind` gets used in the error message.
+func validateIdentifiers(kind string, names ...string) error {
+	for _, n := range names {
+		if n == "" {
+			continue
+		}
+		switch isValidIdentifier(n) {
+		case 1:
+			return errors.NewNotValidf("[dbr] Strict: %s identifier %q is too long or contains an empty part", kind, n)
+		case 2:
+			return errors.NewNotValidf("[dbr] Strict: %s identifier %q contains invalid characters", kind, n)
+		}
+	}
+	return nil
+}
+
+// validateTable checks the table name and its alias. A derived table requires
+// an alias.
+func validateTable(t alias) error {
+	if t.Select != nil {
+		if t.Alias == "" {
+			return errors.NewNotValidf("[dbr] Strict: Derived table requires an alias")
+		}
+		return validateIdentifiers("alias", t.Alias)
+	}
+	if err := validateIdentifiers("table", t.Expression); err != nil {
+		return err
+	}
+	return validateIdentifiers("alias", t.Alias)
+}
+
+// countPlaceholders counts the question marks in an expression but skips
+// those in quoted strings or quoted identifiers.
+func countPlaceholders(expression string) (n int) {
+	for i := 0; i < len(expression); i++ {
+		switch c := expression[i]; c {
+		case '?':
+			n++
+		case '`', '\'', '"':
+			if p := strings.IndexByte(expression[i+1:], c); p >= 0 {
+				i += p + 1
+			}
+		}
+	}
+	return n
+}
+
+// operatorPlaceholders returns the number of place holders an operator writes
+// and whether the operator consumes an argument at all.
+func operatorPlaceholders(operator byte) (n int, addArg bool) {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	addArg = writeOperator(buf, operator, true)
+	return countPlaceholders(buf.String()), addArg
+}
+
+// validateWhereFragments checks for balanced parenthesis and that the number
+// of place holders matches the number of provided arguments. Argument `clause`
+// describes the fragments in the error message, e.g. WHERE, HAVING or JOIN.
+func validateWhereFragments(clause string, wfs WhereFragments) error {
+	var depth int
+	for i, f := range wfs {
+		switch {
+		case f.Condition == "(":
+			depth++
+			continue
+		case f.Condition == ")":
+			if depth--; depth < 0 {
+				return errors.NewNotValidf("[dbr] Strict: %s has a closing parenthesis without an opening one at fragment %d", clause, i)
+			}
+			continue
+		case len(f.Using) > 0:
+			if err := validateIdentifiers("USING column", f.Using...); err != nil {
+				return errors.Wrapf(err, "[dbr] Strict: %s fragment %d", clause, i)
+			}
+			continue
+		}
+
+		var placeholders int
+		if f.isUnsafe || isValidIdentifier(f.Condition) > 0 { // expression
+			placeholders = countPlaceholders(f.Condition)
+			if len(f.Arguments) == 1 && f.Arguments[0].operator() > 0 {
+				if _, ok := f.Arguments[0].(*argTuple); ok {
+					placeholders += f.Arguments[0].len()
+				} else {
+					n, _ := operatorPlaceholders(f.Arguments[0].operator())
+					placeholders += n
+				}
+			}
+		} else {
+			if f.Sub.Select != nil {
+				continue
+			}
+			if len(f.Arguments) != 1 {
+				return errors.NewNotValidf("[dbr] Strict: %s column %q requires exactly one argument but got %d", clause, f.Condition, len(f.Arguments))
+			}
+			n, addArg := operatorPlaceholders(f.Arguments[0].operator())
+			if !addArg {
+				continue
+			}
+			placeholders = n
+		}
+
+		if al := f.Arguments.len(); al != placeholders {
+			return errors.NewNotValidf("[dbr] Strict: %s condition %q has %d place holders but %d arguments", clause, f.Condition, placeholders, al)
+		}
+	}
+	if depth != 0 {
+		return errors.NewNotValidf("[dbr] Strict: %s has %d unclosed parenthesis", clause, depth)
+	}
+	return nil
+}
+
+func (b *Select) validate() error {
+	if b.RawFullSQL != "" {
+		if al, ph := b.Arguments.len(), countPlaceholders(b.RawFullSQL); al != ph {
+			return errors.NewNotValidf("[dbr] Strict: Raw SQL has %d place holders but %d arguments", ph, al)
+		}
+		return nil
+	}
+	if err := validateTable(b.Table); err != nil {
+		return errors.Wrap(err, "[dbr] Select.validate.Table")
+	}
+	for _, f := range b.JoinFragments {
+		if err := validateTable(f.Table); err != nil {
+			return errors.Wrap(err, "[dbr] Select.validate.JoinFragments")
+		}
+		if err := validateWhereFragments("JOIN", f.OnConditions); err != nil {
+			return errors.Wrap(err, "[dbr] Select.validate.JoinFragments")
+		}
+	}
+	if err := validateWhereFragments("WHERE", b.WhereFragments); err != nil {
+		return errors.Wrap(err, "[dbr] Select.validate.WhereFragments")
+	}
+	if err := validateWhereFragments("HAVING", b.HavingFragments); err != nil {
+		return errors.Wrap(err, "[dbr] Select.validate.HavingFragments")
+	}
+	if al, ph := b.Arguments.len(), countPlaceholders(strings.Join(b.Columns, ",")); al != ph {
+		return errors.NewNotValidf("[dbr] Strict: Columns have %d place holders but %d arguments", ph, al)
+	}
+	if al, ph := b.OrderByArgs.len(), countPlaceholders(strings.Join(b.OrderBys, ",")); al != ph {
+		return errors.NewNotValidf("[dbr] Strict: ORDER BY has %d place holders but %d arguments", ph, al)
+	}
+	return nil
+}
+
+func (b *Update) validate() error {
+	if err := validateTable(b.Table); err != nil {
+		return errors.Wrap(err, "[dbr] Update.validate.Table")
+	}
+	if err := validateIdentifiers("SET column", b.SetClauses.Columns...); err != nil {
+		return errors.Wrap(err, "[dbr] Update.validate.SetClauses")
+	}
+	if lc, la := len(b.SetClauses.Columns), len(b.SetClauses.Arguments); lc != la {
+		return errors.NewNotValidf("[dbr] Strict: SET clause has %d columns but %d arguments", lc, la)
+	}
+	if err := validateWhereFragments("WHERE", b.WhereFragments); err != nil {
+		return errors.Wrap(err, "[dbr] Update.validate.WhereFragments")
+	}
+	return nil
+}
+
+func (b *Delete) validate() error {
+	if err := validateTable(b.From); err != nil {
+		return errors.Wrap(err, "[dbr] Delete.validate.From")
+	}
+	if err := validateWhereFragments("WHERE", b.WhereFragments); err != nil {
+		return errors.Wrap(err, "[dbr] Delete.validate.WhereFragments")
+	}
+	return nil
+}
+
+func (b *Insert) validate() error {
+	if err := validateIdentifiers("table", b.Into); err != nil {
+		return errors.Wrap(err, "[dbr] Insert.validate.Into")
+	}
+	if err := validateIdentifiers("column", b.Columns...); err != nil {
+		return errors.Wrap(err, "[dbr] Insert.validate.Columns")
+	}
+	for c := range b.Maps {
+		if err := validateIdentifiers("column", c); err != nil {
+			return errors.Wrap(err, "[dbr] Insert.validate.Maps")
+		}
+	}
+	if err := validateIdentifiers("ON DUPLICATE KEY column", b.OnDuplicateKey.Columns...); err != nil {
+		return errors.Wrap(err, "[dbr] Insert.validate.OnDuplicateKey")
+	}
+	if lc, lv := len(b.Columns), len(b.Values); lc > 0 && lv > 0 && lv%lc != 0 {
+		return errors.NewNotValidf("[dbr] Strict: %d values cannot be distributed over %d columns", lv, lc)
+	}
+	return nil
+}
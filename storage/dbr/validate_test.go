@@ -0,0 +1,124 @@
+// Copyright 2015-2017, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbr
+
+import (
+	"testing"
+
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountPlaceholders(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		have string
+		want int
+	}{
+		{"", 0},
+		{"a = ?", 1},
+		{"a BETWEEN ? AND ?", 2},
+		{"a = '?' AND b = ?", 1},
+		{"`a?` = \"?\"", 0},
+		{"a = 'unclosed ?", 1},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, countPlaceholders(test.have), "Index %d", i)
+	}
+}
+
+func TestSelect_Strict(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		sel    *Select
+		errBhf errors.BehaviourFunc
+	}{
+		{NewSelect("a").From("tableA", "tA").Strict(), nil},
+		{NewSelect("a").From("db.tableA").Strict().Where(Condition("a", ArgInt64(1))), nil},
+		{NewSelect("a").From("tableA").Strict().Where(Condition("a", ArgInt64(1, 2).Operator(Between))), nil},
+		{NewSelect("a").From("tableA").Strict().Where(Condition("a", ArgNull().Operator(Null))), nil},
+		{NewSelect("a").From("tableA").Strict().Where(Condition("a = ? OR b = ?", ArgInt64(1), ArgString("x"))), nil},
+		{NewSelect("a").From("tableA").Strict().Where(
+			ParenthesisOpen(), Condition("a", ArgInt64(1)), Condition("b", ArgInt64(2)).Or(), ParenthesisClose(),
+		), nil},
+		{NewSelect("a").From("tableA").Strict().Where(Tuple([]string{"a", "b"}, ArgTuple(2, ArgInt64(1, 2, 3, 4)))), nil},
+		{NewSelect("a").From("table A").Strict(), errors.IsNotValid},
+		{NewSelect("a").From("tableA", "t-A").Strict(), errors.IsNotValid},
+		{NewSelectFromSub(NewSelect("a").From("tableA"), "").AddColumns("a").Strict(), errors.IsNotValid},
+		{NewSelect("a").From("tableA").Strict().Where(ParenthesisOpen(), Condition("a", ArgInt64(1))), errors.IsNotValid},
+		{NewSelect("a").From("tableA").Strict().Where(Condition("a", ArgInt64(1)), ParenthesisClose()), errors.IsNotValid},
+		{NewSelect("a").From("tableA").Strict().Where(Condition("a")), errors.IsNotValid},
+		{NewSelect("a").From("tableA").Strict().Where(Condition("a", ArgInt64(1), ArgInt64(2))), errors.IsNotValid},
+		{NewSelect("a").From("tableA").Strict().Where(Condition("a", ArgInt64(1).Operator(Between))), errors.IsNotValid},
+		{NewSelect("a").From("tableA").Strict().Where(Condition("a = ? OR b = ?", ArgInt64(1))), errors.IsNotValid},
+		{NewSelect("a").From("tableA").Strict().Having(Condition("COUNT(*) > ?")), errors.IsNotValid},
+		{NewSelect("a").From("tableA").Strict().Join(MakeAlias("tableB", "t B"), Condition("a = b")), errors.IsNotValid},
+		{NewSelect().AddColumnsExprAlias("IF(a > ?, 1, 0)", "x").From("tableA").Strict(), errors.IsNotValid},
+		// Without strict mode broken SQL gets generated.
+		{NewSelect("a").From("tableA").Where(Condition("a = ? OR b = ?", ArgInt64(1))), nil},
+	}
+	for i, test := range tests {
+		_, _, err := test.sel.ToSQL()
+		if test.errBhf != nil {
+			assert.True(t, test.errBhf(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+	}
+}
+
+func TestUpdate_Strict(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := NewUpdate("tableA").Strict().Set("a", ArgInt64(1)).Where(Condition("b", ArgInt64(2))).ToSQL()
+	require.NoError(t, err)
+
+	_, _, err = NewUpdate("tableA").Strict().Set("a b", ArgInt64(1)).ToSQL()
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+
+	u := NewUpdate("tableA").Strict()
+	u.SetClauses.Columns = []string{"a", "b"}
+	u.SetClauses.Arguments = Arguments{ArgInt64(1)}
+	_, _, err = u.ToSQL()
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+
+	_, _, err = NewUpdate("tableA").Strict().ToSQL()
+	assert.True(t, errors.IsEmpty(err), "%+v", err)
+}
+
+func TestDelete_Strict(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := NewDelete("tableA").Strict().Where(Condition("a", ArgInt64(1))).ToSQL()
+	require.NoError(t, err)
+
+	_, _, err = NewDelete("tableA").Strict().Where(Condition("a IN (?,?)", ArgInt64(1))).ToSQL()
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestInsert_Strict(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := NewInsert("tableA").Strict().AddColumns("a", "b").AddValues(ArgInt64(1), ArgInt64(2)).ToSQL()
+	require.NoError(t, err)
+
+	_, _, err = NewInsert("tableA").Strict().AddColumns("a", "b").AddValues(ArgInt64(1), ArgInt64(2), ArgInt64(3)).ToSQL()
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+
+	_, _, err = NewInsert("tableA").Strict().AddColumns("a;DROP").AddValues(ArgInt64(1)).ToSQL()
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
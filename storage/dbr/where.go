@@ -74,6 +74,9 @@ type whereFragment struct {
 		Operator byte
 	}
 	Using []string
+	// isUnsafe gets set by ConditionUnsafe and forces to write the Condition
+	// as an expression.
+	isUnsafe bool
 }
 
 func (wf *whereFragment) appendConditions(wfs *WhereFragments) {
@@ -114,19 +117,23 @@ func Using(columns ...string) ConditionArg {
 // SubSelect creates a condition for a WHERE or JOIN statement to compare the
 // data in `rawStatementOrColumnName` with the returned value/s of the
 // sub-select.
-func SubSelect(rawStatementOrColumnName string, operator byte, s *Select) ConditionArg {
+func SubSelect(rawStatementOrColumnName SQLUnsafe, operator byte, s *Select) ConditionArg {
+	c := auditSQLExpr(rawStatementOrColumnName)
 	wf := &whereFragment{
-		Condition: rawStatementOrColumnName,
+		Condition: c[0],
 	}
 	wf.Sub.Select = s
 	wf.Sub.Operator = operator
 	return wf
 }
 
-// Condition adds a condition to a WHERE or HAVING statement.
-func Condition(rawStatementOrColumnName string, arg ...Argument) ConditionArg {
+// Condition adds a condition to a WHERE or HAVING statement. Argument
+// rawStatementOrColumnName gets quoted if it is a column name, an expression
+// gets written unchanged, see SQLUnsafe.
+func Condition(rawStatementOrColumnName SQLUnsafe, arg ...Argument) ConditionArg {
+	c := auditSQLExpr(rawStatementOrColumnName)
 	return &whereFragment{
-		Condition: rawStatementOrColumnName,
+		Condition: c[0],
 		Arguments: arg,
 	}
 }
@@ -155,7 +162,8 @@ func appendConditions(wf *WhereFragments, wargs ...ConditionArg) {
 
 // Invariant: only called when len(fragments) > 0
 // stmtType enum of j=join, w=where, h=having
-func writeWhereFragmentsToSQL(fragments WhereFragments, w queryWriter, args *Arguments, stmtType byte) error {
+// Sub-selects inherit the table name mapper m and the dialect d.
+func writeWhereFragmentsToSQL(fragments WhereFragments, w queryWriter, m TableNameMapper, d Dialect, args *Arguments, stmtType byte) error {
 
 	switch stmtType {
 	case 'w':
@@ -167,6 +175,10 @@ func writeWhereFragmentsToSQL(fragments WhereFragments, w queryWriter, args *Arg
 	i := 0
 	for _, f := range fragments {
 
+		if err := resolveValuers(f.Arguments); err != nil {
+			return errors.Wrapf(err, "[dbr] writeWhereFragmentsToSQL failed ArgValuer for condition: %q", f.Condition)
+		}
+
 		if stmtType == 'j' {
 			if len(f.Using) > 0 {
 				w.WriteString(" USING (")
@@ -213,11 +225,17 @@ func writeWhereFragmentsToSQL(fragments WhereFragments, w queryWriter, args *Arg
 
 		w.WriteRune('(')
 		addArg := false
-		if isValidIdentifier(f.Condition) > 0 { // must be an expression
+		if f.isUnsafe || isValidIdentifier(f.Condition) > 0 { // must be an expression
 			_, _ = w.WriteString(f.Condition)
 			addArg = true
 			if len(f.Arguments) == 1 && f.Arguments[0].operator() > 0 {
-				writeOperator(w, f.Arguments[0].operator(), true)
+				if t, ok := f.Arguments[0].(*argTuple); ok {
+					if err := t.writePlaceholders(w); err != nil {
+						return errors.Wrapf(err, "[dbr] writeWhereFragmentsToSQL failed Tuple for condition: %q", f.Condition)
+					}
+				} else {
+					writeOperator(w, f.Arguments[0].operator(), true)
+				}
 			}
 		} else {
 			Quoter.FquoteAs(w, f.Condition)
@@ -225,7 +243,7 @@ func writeWhereFragmentsToSQL(fragments WhereFragments, w queryWriter, args *Arg
 			if f.Sub.Select != nil {
 				writeOperator(w, f.Sub.Operator, false)
 				w.WriteRune('(')
-				subArgs, err := f.Sub.Select.toSQL(w)
+				subArgs, err := f.Sub.Select.inherit(m, d).toSQL(w)
 				w.WriteRune(')')
 				if err != nil {
 					return errors.Wrapf(err, "[dbr] writeWhereFragmentsToSQL failed SubSelect for table: %q", f.Sub.Select.Table.String())
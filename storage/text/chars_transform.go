@@ -0,0 +1,191 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Truncate returns a copy of the text shortened to at most maxRunes runes. If
+// the text gets shortened the ellipsis gets appended and counts into
+// maxRunes. Multi byte runes never get split and trailing white spaces before
+// the ellipsis get removed. If the ellipsis is longer than maxRunes it won't be
+// appended.
+func (c Chars) Truncate(maxRunes int, ellipsis string) Chars {
+	if maxRunes <= 0 {
+		return Chars{}
+	}
+	if utf8.RuneCount(c) <= maxRunes {
+		return c.Clone()
+	}
+	keep := maxRunes - utf8.RuneCountInString(ellipsis)
+	if keep <= 0 {
+		keep, ellipsis = maxRunes, ""
+	}
+	pos := 0
+	for i := 0; i < keep; i++ {
+		_, size := utf8.DecodeRune(c[pos:])
+		pos += size
+	}
+	buf := make(Chars, 0, pos+len(ellipsis))
+	buf = append(buf, bytes.TrimRightFunc(c[:pos], unicode.IsSpace)...)
+	return append(buf, ellipsis...)
+}
+
+// translitSpecial contains characters which cannot be decomposed into an
+// ASCII base character and a combining mark.
+var translitSpecial = map[rune]string{
+	'ß': "ss", 'ẞ': "SS", 'Æ': "AE", 'æ': "ae", 'Ø': "O", 'ø': "o", 'Œ': "OE",
+	'œ': "oe", 'Đ': "D", 'đ': "d", 'Ð': "D", 'ð': "d", 'Þ': "TH", 'þ': "th",
+	'Ł': "L", 'ł': "l", 'ı': "i", 'Ħ': "H", 'ħ': "h", 'Ŀ': "L", 'ŀ': "l",
+	'‘': "'", '’': "'", '‚': "'", '“': `"`, '”': `"`, '„': `"`, '–': "-",
+	'—': "-", '…': "...", '€': "EUR", '£': "GBP",
+}
+
+// translitCyrillic maps lower case Cyrillic letters to their Latin
+// representation. Upper case letters get derived.
+var translitCyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'ї': "yi",
+	'є': "ye", 'ґ': "g",
+}
+
+// translitLanguage contains language specific replacements which take
+// precedence over the generic rules.
+var translitLanguage = map[language.Base]map[rune]string{
+	mustBase("de"): {
+		'ä': "ae", 'ö': "oe", 'ü': "ue", 'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue",
+	},
+	mustBase("da"): {
+		'å': "aa", 'Å': "Aa",
+	},
+}
+
+func mustBase(s string) language.Base {
+	b, err := language.ParseBase(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Transliterate returns a copy of the text where non-ASCII characters get
+// replaced by their ASCII representation. Accents get removed, ligatures and
+// Cyrillic letters get spelled out. The language tag applies language
+// specific rules, e.g. for German "ä" becomes "ae" instead of "a". Use
+// language.Und for the generic rules. Characters without an ASCII
+// representation are kept.
+func (c Chars) Transliterate(t language.Tag) Chars {
+	base, _ := t.Base()
+	langMap := translitLanguage[base]
+
+	buf := make(Chars, 0, len(c))
+	for i := 0; i < len(c); {
+		r, size := utf8.DecodeRune(c[i:])
+		i += size
+		if r < utf8.RuneSelf {
+			buf = append(buf, byte(r))
+			continue
+		}
+		if s, ok := langMap[r]; ok {
+			buf = append(buf, s...)
+			continue
+		}
+		if s, ok := translitSpecial[r]; ok {
+			buf = append(buf, s...)
+			continue
+		}
+		if s, ok := translitCyrillic[unicode.ToLower(r)]; ok {
+			if unicode.IsUpper(r) && s != "" {
+				buf = append(buf, byte(unicode.ToUpper(rune(s[0]))))
+				s = s[1:]
+			}
+			buf = append(buf, s...)
+			continue
+		}
+		var ascii []byte
+		for _, d := range norm.NFD.String(string(r)) {
+			if d < utf8.RuneSelf {
+				ascii = append(ascii, byte(d))
+			}
+		}
+		if len(ascii) == 0 {
+			buf = append(buf, c[i-size:i]...)
+			continue
+		}
+		buf = append(buf, ascii...)
+	}
+	return buf
+}
+
+// Slug generates a lower case URL key from the text, for example a product
+// name. The text gets transliterated according to the language tag, all
+// characters except a-z and 0-9 get replaced by a single hyphen and leading and
+// trailing hyphens get removed.
+//		text.Chars("Äpfel & Birnen!").Slug(language.German) // "aepfel-birnen"
+func (c Chars) Slug(t language.Tag) Chars {
+	tl := c.Transliterate(t)
+	buf := tl[:0] // tl is a new allocated slice, so reuse it
+	sep := false
+	for _, b := range tl {
+		switch {
+		case 'a' <= b && b <= 'z', '0' <= b && b <= '9':
+		case 'A' <= b && b <= 'Z':
+			b += 'a' - 'A'
+		default:
+			sep = len(buf) > 0
+			continue
+		}
+		if sep {
+			buf = append(buf, '-')
+			sep = false
+		}
+		buf = append(buf, b)
+	}
+	return buf
+}
+
+// Fold returns a case folded copy of the text. Folded texts can be compared
+// for case insensitive equality independent of the language.
+func (c Chars) Fold() Chars {
+	return cases.Fold().Bytes(c)
+}
+
+// EqualFold reports whether the text and b are equal under Unicode case
+// folding.
+func (c Chars) EqualFold(b []byte) bool {
+	return bytes.EqualFold(c, b)
+}
+
+// ToLower returns a lower case copy of the text according to the rules of
+// the language tag, e.g. the Turkish dotted I.
+func (c Chars) ToLower(t language.Tag) Chars {
+	return cases.Lower(t).Bytes(c)
+}
+
+// ToUpper returns an upper case copy of the text according to the rules of
+// the language tag.
+func (c Chars) ToUpper(t language.Tag) Chars {
+	return cases.Upper(t).Bytes(c)
+}
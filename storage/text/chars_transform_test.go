@@ -0,0 +1,95 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/storage/text"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestChars_Truncate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		have     text.Chars
+		max      int
+		ellipsis string
+		want     string
+	}{
+		{text.Chars(`Hello World`), 20, "…", `Hello World`},
+		{text.Chars(`Hello World`), 11, "…", `Hello World`},
+		{text.Chars(`Hello World`), 7, "…", `Hello…`},
+		{text.Chars(`Hello World`), 8, "...", `Hello...`},
+		{text.Chars(`Größenänderung`), 6, "…", `Größe…`},
+		{text.Chars(`日本語のテキスト`), 4, "…", `日本語…`},
+		{text.Chars(`Hello World`), 2, "...", `He`},
+		{text.Chars(`Hello World`), 0, "...", ``},
+		{nil, 5, "...", ``},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, test.have.Truncate(test.max, test.ellipsis).String(), "Index %d", i)
+	}
+}
+
+func TestChars_Transliterate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		have text.Chars
+		tag  language.Tag
+		want string
+	}{
+		{text.Chars(`Crème brûlée`), language.Und, `Creme brulee`},
+		{text.Chars(`Äpfel Straße`), language.Und, `Apfel Strasse`},
+		{text.Chars(`Äpfel Straße`), language.German, `Aepfel Strasse`},
+		{text.Chars(`Äpfel`), language.MustParse("de-CH"), `Aepfel`},
+		{text.Chars(`Łódź Smørrebrød`), language.Und, `Lodz Smorrebrod`},
+		{text.Chars(`Щука Жук`), language.Russian, `Shchuka Zhuk`},
+		{text.Chars(`日本`), language.Japanese, `日本`},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, test.have.Transliterate(test.tag).String(), "Index %d", i)
+	}
+}
+
+func TestChars_Slug(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		have text.Chars
+		tag  language.Tag
+		want string
+	}{
+		{text.Chars(`Äpfel & Birnen!`), language.German, `aepfel-birnen`},
+		{text.Chars(`  Crème Brûlée -- 250g  `), language.French, `creme-brulee-250g`},
+		{text.Chars(`Сыр Гауда`), language.Russian, `syr-gauda`},
+		{text.Chars(`!!!`), language.Und, ``},
+		{text.Chars(`日本 Tea`), language.Und, `tea`},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, test.have.Slug(test.tag).String(), "Index %d", i)
+	}
+}
+
+func TestChars_CaseFolding(t *testing.T) {
+	t.Parallel()
+	assert.Exactly(t, `strasse`, text.Chars(`STRASSE`).Fold().String())
+	assert.Exactly(t, text.Chars(`Straße`).Fold().String(), text.Chars(`STRAßE`).Fold().String())
+	assert.True(t, text.Chars(`Gopher`).EqualFold([]byte(`GOPHER`)))
+	assert.False(t, text.Chars(`Gopher`).EqualFold([]byte(`Gophers`)))
+	assert.Exactly(t, `ıi`, text.Chars(`Iİ`).ToLower(language.Turkish).String())
+	assert.Exactly(t, `ii̇`, text.Chars(`Iİ`).ToLower(language.English).String())
+	assert.Exactly(t, `İSTANBUL`, text.Chars(`istanbul`).ToUpper(language.Turkish).String())
+}
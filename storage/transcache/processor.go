@@ -16,6 +16,7 @@ package transcache
 
 import (
 	"io"
+	"time"
 
 	"github.com/corestoreio/csfw/util/bufferpool"
 	"github.com/corestoreio/errors"
@@ -31,6 +32,14 @@ type Cacher interface {
 	Close() error
 }
 
+// SetNXer can be implemented by a Cacher which supports to add a key
+// atomically with a time to live. SetNX adds the key only if it does not
+// exist and reports if the key has been added. For example used to share the
+// nonces of signed requests between several instances.
+type SetNXer interface {
+	SetNX(key, value []byte, ttl time.Duration) (bool, error)
+}
+
 // Transcacher represents the function for storing and retrieving arbitrary Go
 // types.
 type Transcacher interface {
@@ -151,6 +151,22 @@ func (w wrapper) Set(key []byte, value []byte) error {
 	return nil
 }
 
+// SetNX implements the transcache.SetNXer interface with the NX and PX
+// arguments of the SET command.
+func (w wrapper) SetNX(key, value []byte, ttl time.Duration) (bool, error) {
+	conn := w.Pool.Get()
+	defer conn.Close()
+
+	_, err := redis.String(conn.Do("SET", key, value, "PX", int64(ttl/time.Millisecond), "NX"))
+	switch {
+	case err == redis.ErrNil:
+		return false, nil // key exists
+	case err != nil:
+		return false, errors.NewFatalf("[tcredis] wrapper.SetNX.Do: %s", err)
+	}
+	return true, nil
+}
+
 func (w wrapper) Get(key []byte) ([]byte, error) {
 	conn := w.Pool.Get()
 	defer conn.Close()
@@ -0,0 +1,333 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/corestoreio/errors"
+)
+
+// Default and maximum page sizes of the AdminHandler list endpoints.
+const (
+	AdminDefaultPerPage = 50
+	AdminMaxPerPage     = 500
+)
+
+// AdminPage wraps a list response of the AdminHandler.
+type AdminPage struct {
+	Total   int         `json:"total"`
+	Page    int         `json:"page"`
+	PerPage int         `json:"per_page"`
+	Items   interface{} `json:"items"`
+}
+
+// adminWebsite, adminGroup and adminStore define the JSON representation
+// including the optional embedded relations.
+type adminWebsite struct {
+	*TableWebsite
+	Groups []*TableGroup `json:"Groups,omitempty"`
+	Stores []*TableStore `json:"Stores,omitempty"`
+}
+
+type adminGroup struct {
+	*TableGroup
+	Website *TableWebsite `json:"Website,omitempty"`
+	Stores  []*TableStore `json:"Stores,omitempty"`
+}
+
+type adminStore struct {
+	*TableStore
+	Website *TableWebsite `json:"Website,omitempty"`
+	Group   *TableGroup   `json:"Group,omitempty"`
+}
+
+// DataVersion returns a hash of the raw website, group and store data. The
+// hash changes whenever LoadFromResource loads different data and gets used
+// as ETag by the AdminHandler.
+func (s *Service) DataVersion() string {
+	s.mu.RLock()
+	v := s.dataVersion
+	s.mu.RUnlock()
+	if v != "" {
+		return v
+	}
+
+	s.backend.mu.RLock()
+	raw, err := json.Marshal([]interface{}{s.backend.websites, s.backend.groups, s.backend.stores})
+	s.backend.mu.RUnlock()
+	if err != nil {
+		return "" // cannot happen with the table structs
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(raw)
+	v = strconv.FormatUint(h.Sum64(), 36)
+
+	s.mu.Lock()
+	s.dataVersion = v
+	s.mu.Unlock()
+	return v
+}
+
+// AdminHandler returns a read only JSON handler for operations dashboards to
+// inspect the resolved website, group and store graph. Mount it with
+// http.StripPrefix and protect it with an authentication middleware. Routes:
+//		GET /websites			list of websites
+//		GET /websites/{id|code}	one website
+//		GET /groups				list of groups
+//		GET /groups/{id}		one group
+//		GET /stores				list of stores
+//		GET /stores/{id|code}	one store
+// The query parameter embed, e.g. ?embed=groups,stores, adds the related
+// websites, groups or stores. Lists support the parameters page, starting at
+// one, and per_page. Each response carries an ETag of the DataVersion, a
+// matching If-None-Match header returns 304 Not Modified.
+func (s *Service) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		etag := `"` + s.DataVersion() + `"`
+		w.Header().Set("ETag", etag)
+		if match := r.Header.Get("If-None-Match"); match != "" && (match == etag || match == "*") {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) > 2 {
+			writeAdminError(w, http.StatusNotFound, "unknown route")
+			return
+		}
+		var key string
+		if len(parts) == 2 {
+			key = parts[1]
+		}
+		embed := parseAdminEmbed(r.URL.Query().Get("embed"))
+
+		var data interface{}
+		var err error
+		switch parts[0] {
+		case "websites":
+			data, err = s.adminWebsites(r, key, embed)
+		case "groups":
+			data, err = s.adminGroups(r, key, embed)
+		case "stores":
+			data, err = s.adminStores(r, key, embed)
+		default:
+			writeAdminError(w, http.StatusNotFound, "unknown route")
+			return
+		}
+		switch {
+		case errors.IsNotFound(err):
+			writeAdminError(w, http.StatusNotFound, err.Error())
+			return
+		case errors.IsNotValid(err):
+			writeAdminError(w, http.StatusBadRequest, err.Error())
+			return
+		case err != nil:
+			writeAdminError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(data); err != nil {
+			writeAdminError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if r.Method != "HEAD" {
+			_, _ = buf.WriteTo(w)
+		}
+	})
+}
+
+func (s *Service) adminWebsites(r *http.Request, key string, embed map[string]bool) (interface{}, error) {
+	toJSON := func(ws Website) adminWebsite {
+		aw := adminWebsite{TableWebsite: ws.Data}
+		if embed["groups"] {
+			aw.Groups = make([]*TableGroup, 0, len(ws.Groups))
+			for _, g := range ws.Groups {
+				aw.Groups = append(aw.Groups, g.Data)
+			}
+		}
+		if embed["stores"] {
+			aw.Stores = make([]*TableStore, 0, len(ws.Stores))
+			for _, st := range ws.Stores {
+				aw.Stores = append(aw.Stores, st.Data)
+			}
+		}
+		return aw
+	}
+
+	websites := s.Websites()
+	if key == "" {
+		start, end, ap, err := adminPaginate(r, len(websites))
+		if err != nil {
+			return nil, errors.Wrap(err, "[store] AdminHandler.Websites")
+		}
+		items := make([]adminWebsite, 0, end-start)
+		for _, ws := range websites[start:end] {
+			items = append(items, toJSON(ws))
+		}
+		ap.Items = items
+		return ap, nil
+	}
+
+	id, isID := parseAdminID(key)
+	for _, ws := range websites {
+		if (isID && ws.ID() == id) || (!isID && ws.Code() == key) {
+			return toJSON(ws), nil
+		}
+	}
+	return nil, errors.NewNotFoundf("[store] AdminHandler Website %q not found", key)
+}
+
+func (s *Service) adminGroups(r *http.Request, key string, embed map[string]bool) (interface{}, error) {
+	toJSON := func(g Group) adminGroup {
+		ag := adminGroup{TableGroup: g.Data}
+		if embed["website"] {
+			ag.Website = g.Website.Data
+		}
+		if embed["stores"] {
+			ag.Stores = make([]*TableStore, 0, len(g.Stores))
+			for _, st := range g.Stores {
+				ag.Stores = append(ag.Stores, st.Data)
+			}
+		}
+		return ag
+	}
+
+	groups := s.Groups()
+	if key == "" {
+		start, end, ap, err := adminPaginate(r, len(groups))
+		if err != nil {
+			return nil, errors.Wrap(err, "[store] AdminHandler.Groups")
+		}
+		items := make([]adminGroup, 0, end-start)
+		for _, g := range groups[start:end] {
+			items = append(items, toJSON(g))
+		}
+		ap.Items = items
+		return ap, nil
+	}
+
+	id, isID := parseAdminID(key)
+	if !isID {
+		return nil, errors.NewNotValidf("[store] AdminHandler Group ID %q must be a number", key)
+	}
+	g, err := s.Group(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "[store] AdminHandler.Group")
+	}
+	return toJSON(g), nil
+}
+
+func (s *Service) adminStores(r *http.Request, key string, embed map[string]bool) (interface{}, error) {
+	toJSON := func(st Store) adminStore {
+		as := adminStore{TableStore: st.Data}
+		if embed["website"] {
+			as.Website = st.Website.Data
+		}
+		if embed["group"] {
+			as.Group = st.Group.Data
+		}
+		return as
+	}
+
+	stores := s.Stores()
+	if key == "" {
+		start, end, ap, err := adminPaginate(r, len(stores))
+		if err != nil {
+			return nil, errors.Wrap(err, "[store] AdminHandler.Stores")
+		}
+		items := make([]adminStore, 0, end-start)
+		for _, st := range stores[start:end] {
+			items = append(items, toJSON(st))
+		}
+		ap.Items = items
+		return ap, nil
+	}
+
+	id, isID := parseAdminID(key)
+	for _, st := range stores {
+		if (isID && st.ID() == id) || (!isID && st.Code() == key) {
+			return toJSON(st), nil
+		}
+	}
+	return nil, errors.NewNotFoundf("[store] AdminHandler Store %q not found", key)
+}
+
+// adminPaginate reads the page and per_page query parameters and returns the
+// slice boundaries for a list of length total.
+func adminPaginate(r *http.Request, total int) (start, end int, ap AdminPage, err error) {
+	ap = AdminPage{Total: total, Page: 1, PerPage: AdminDefaultPerPage}
+	q := r.URL.Query()
+	if v := q.Get("page"); v != "" {
+		if ap.Page, err = strconv.Atoi(v); err != nil || ap.Page < 1 {
+			return 0, 0, ap, errors.NewNotValidf("[store] Invalid page %q", v)
+		}
+	}
+	if v := q.Get("per_page"); v != "" {
+		if ap.PerPage, err = strconv.Atoi(v); err != nil || ap.PerPage < 1 {
+			return 0, 0, ap, errors.NewNotValidf("[store] Invalid per_page %q", v)
+		}
+		if ap.PerPage > AdminMaxPerPage {
+			ap.PerPage = AdminMaxPerPage
+		}
+	}
+	start = (ap.Page - 1) * ap.PerPage
+	if start > total {
+		start = total
+	}
+	end = start + ap.PerPage
+	if end > total {
+		end = total
+	}
+	return start, end, ap, nil
+}
+
+func parseAdminID(key string) (int64, bool) {
+	id, err := strconv.ParseInt(key, 10, 64)
+	return id, err == nil
+}
+
+func parseAdminEmbed(v string) map[string]bool {
+	if v == "" {
+		return nil
+	}
+	m := make(map[string]bool)
+	for _, e := range strings.Split(v, ",") {
+		m[strings.TrimSpace(e)] = true
+	}
+	return m
+}
+
+func writeAdminError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{msg})
+}
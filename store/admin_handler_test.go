@@ -0,0 +1,194 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/csfw/util/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type adminEntity struct {
+	WebsiteID int64
+	GroupID   int64
+	StoreID   int64
+	Code      string
+	Name      string
+	Website   *adminEntity
+	Group     *adminEntity
+	Groups    []adminEntity
+	Stores    []adminEntity
+}
+
+type adminPage struct {
+	Total   int           `json:"total"`
+	Page    int           `json:"page"`
+	PerPage int           `json:"per_page"`
+	Items   []adminEntity `json:"items"`
+}
+
+func serveAdmin(t *testing.T, srv *store.Service, method, target string, hdr ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for i := 0; i < len(hdr); i = i + 2 {
+		req.Header.Set(hdr[i], hdr[i+1])
+	}
+	rec := httptest.NewRecorder()
+	srv.AdminHandler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestService_AdminHandler_Lists(t *testing.T) {
+	t.Parallel()
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+
+	t.Run("websites with embedded relations", func(t *testing.T) {
+		rec := serveAdmin(t, srv, "GET", "/websites?embed=groups,stores")
+		require.Exactly(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Exactly(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+
+		var ap adminPage
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ap))
+		assert.Exactly(t, 3, ap.Total)
+		assert.Exactly(t, 1, ap.Page)
+		assert.Exactly(t, store.AdminDefaultPerPage, ap.PerPage)
+		require.Len(t, ap.Items, 3)
+		assert.Exactly(t, "euro", ap.Items[1].Code)
+		assert.Len(t, ap.Items[1].Groups, 2)
+		assert.Len(t, ap.Items[1].Stores, 4)
+	})
+
+	t.Run("websites without relations", func(t *testing.T) {
+		rec := serveAdmin(t, srv, "GET", "/websites")
+		require.Exactly(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.NotContains(t, rec.Body.String(), `"Groups"`)
+	})
+
+	t.Run("stores paginated", func(t *testing.T) {
+		rec := serveAdmin(t, srv, "GET", "/stores?page=2&per_page=3&embed=website,group")
+		require.Exactly(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var ap adminPage
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ap))
+		assert.Exactly(t, 7, ap.Total)
+		assert.Exactly(t, 2, ap.Page)
+		assert.Exactly(t, 3, ap.PerPage)
+		require.Len(t, ap.Items, 3)
+		for _, it := range ap.Items {
+			require.NotNil(t, it.Website)
+			require.NotNil(t, it.Group)
+			assert.Exactly(t, it.WebsiteID, it.Website.WebsiteID)
+			assert.Exactly(t, it.GroupID, it.Group.GroupID)
+		}
+	})
+
+	t.Run("page after the end", func(t *testing.T) {
+		rec := serveAdmin(t, srv, "GET", "/groups?page=9")
+		require.Exactly(t, http.StatusOK, rec.Code, rec.Body.String())
+		var ap adminPage
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ap))
+		assert.Exactly(t, 4, ap.Total)
+		assert.Len(t, ap.Items, 0)
+	})
+
+	t.Run("invalid page", func(t *testing.T) {
+		rec := serveAdmin(t, srv, "GET", "/groups?per_page=-1")
+		assert.Exactly(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		assert.Contains(t, rec.Body.String(), `"error"`)
+	})
+}
+
+func TestService_AdminHandler_Entities(t *testing.T) {
+	t.Parallel()
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+
+	tests := []struct {
+		target   string
+		wantCode int
+		check    func(t *testing.T, e adminEntity)
+	}{
+		{"/websites/2", http.StatusOK, func(t *testing.T, e adminEntity) { assert.Exactly(t, "oz", e.Code) }},
+		{"/websites/euro?embed=stores", http.StatusOK, func(t *testing.T, e adminEntity) {
+			assert.Exactly(t, int64(1), e.WebsiteID)
+			assert.Len(t, e.Stores, 4)
+			assert.Nil(t, e.Groups)
+		}},
+		{"/websites/mars", http.StatusNotFound, nil},
+		{"/groups/3?embed=website,stores", http.StatusOK, func(t *testing.T, e adminEntity) {
+			assert.Exactly(t, "Australia", e.Name)
+			assert.Exactly(t, "oz", e.Website.Code)
+			assert.Len(t, e.Stores, 2)
+		}},
+		{"/groups/dach", http.StatusBadRequest, nil},
+		{"/groups/99", http.StatusNotFound, nil},
+		{"/stores/at?embed=group", http.StatusOK, func(t *testing.T, e adminEntity) {
+			assert.Exactly(t, int64(2), e.StoreID)
+			assert.Exactly(t, "DACH Group", e.Group.Name)
+			assert.Nil(t, e.Website)
+		}},
+		{"/stores/0", http.StatusOK, func(t *testing.T, e adminEntity) { assert.Exactly(t, "admin", e.Code) }},
+		{"/stores/xx", http.StatusNotFound, nil},
+		{"/customers", http.StatusNotFound, nil},
+		{"/stores/de/x", http.StatusNotFound, nil},
+	}
+	for _, test := range tests {
+		rec := serveAdmin(t, srv, "GET", test.target)
+		require.Exactly(t, test.wantCode, rec.Code, "%s: %s", test.target, rec.Body.String())
+		if test.check == nil {
+			continue
+		}
+		var e adminEntity
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &e), test.target)
+		test.check(t, e)
+	}
+}
+
+func TestService_AdminHandler_ETag(t *testing.T) {
+	t.Parallel()
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+
+	rec := serveAdmin(t, srv, "GET", "/stores/de")
+	require.Exactly(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.Exactly(t, `"`+srv.DataVersion()+`"`, etag)
+
+	rec = serveAdmin(t, srv, "GET", "/stores/de", "If-None-Match", etag)
+	assert.Exactly(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = serveAdmin(t, srv, "HEAD", "/stores/de")
+	assert.Exactly(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = serveAdmin(t, srv, "POST", "/stores/de")
+	assert.Exactly(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Exactly(t, "GET, HEAD", rec.Header().Get("Allow"))
+
+	// different data creates a different version
+	srv2 := storemock.NewEurozzyService(cfgmock.NewService(),
+		store.WithTableStores(&store.TableStore{StoreID: 7, Code: null.StringFrom("fr"), WebsiteID: 1, GroupID: 2, Name: "France", IsActive: true}),
+	)
+	assert.NotEqual(t, srv.DataVersion(), srv2.DataVersion())
+	rec = serveAdmin(t, srv2, "GET", "/stores/de", "If-None-Match", etag)
+	assert.Exactly(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+}
@@ -0,0 +1,174 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"strings"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/errors"
+)
+
+// Configuration paths of the allowed countries and currencies. The allow paths
+// contain comma separated ISO codes. All paths can be set up to the store
+// scope.
+const (
+	PathCountryAllow    = `general/country/allow`
+	PathCountryDefault  = `general/country/default`
+	PathCurrencyAllow   = `currency/options/allow`
+	PathCurrencyDefault = `currency/options/default`
+)
+
+var (
+	cfgCountryAllow    = cfgmodel.NewStringCSV(PathCountryAllow, cfgmodel.WithScopeStore())
+	cfgCountryDefault  = cfgmodel.NewStr(PathCountryDefault, cfgmodel.WithScopeStore())
+	cfgCurrencyAllow   = cfgmodel.NewStringCSV(PathCurrencyAllow, cfgmodel.WithScopeStore())
+	cfgCurrencyDefault = cfgmodel.NewStr(PathCurrencyDefault, cfgmodel.WithScopeStore())
+)
+
+// AllowedLists contains the allowed countries and currencies of a website or
+// store together with their defaults. All codes are upper case ISO codes.
+// Checkout-facing services use it to validate the address country and the
+// currency of a quote.
+type AllowedLists struct {
+	// Countries ISO 3166-1 alpha-2 codes. Empty means all countries are
+	// allowed.
+	Countries      []string
+	DefaultCountry string
+	// Currencies ISO 4217 codes. Empty means all currencies are allowed.
+	Currencies      []string
+	DefaultCurrency string
+}
+
+// IsCountryAllowed reports whether the country code is allowed. Case
+// insensitive.
+func (al AllowedLists) IsCountryAllowed(code string) bool {
+	return containsCode(al.Countries, code)
+}
+
+// IsCurrencyAllowed reports whether the currency code is allowed. Case
+// insensitive.
+func (al AllowedLists) IsCurrencyAllowed(code string) bool {
+	return containsCode(al.Currencies, code)
+}
+
+// Validate checks that the default country and the default currency are
+// within the allowed sets. Error behaviour: NotValid.
+func (al AllowedLists) Validate() error {
+	if al.DefaultCountry != "" && !al.IsCountryAllowed(al.DefaultCountry) {
+		return errors.NewNotValidf("[store] Default country %q not in allowed countries %v", al.DefaultCountry, al.Countries)
+	}
+	if al.DefaultCurrency != "" && !al.IsCurrencyAllowed(al.DefaultCurrency) {
+		return errors.NewNotValidf("[store] Default currency %q not in allowed currencies %v", al.DefaultCurrency, al.Currencies)
+	}
+	return nil
+}
+
+func containsCode(codes []string, code string) bool {
+	if len(codes) == 0 {
+		return code != ""
+	}
+	for _, c := range codes {
+		if strings.EqualFold(c, code) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeCodes(codes []string) []string {
+	ret := codes[:0]
+	for _, c := range codes {
+		if c = strings.ToUpper(strings.TrimSpace(c)); c != "" {
+			ret = append(ret, c)
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
+
+func allowedCountries(cfg config.Scoped) ([]string, error) {
+	c, err := cfgCountryAllow.Get(cfg)
+	return normalizeCodes(c), errors.Wrap(err, "[store] AllowedCountries")
+}
+
+func allowedCurrencies(cfg config.Scoped) ([]string, error) {
+	c, err := cfgCurrencyAllow.Get(cfg)
+	return normalizeCodes(c), errors.Wrap(err, "[store] AllowedCurrencies")
+}
+
+// loadAllowedLists reads all four paths and validates the defaults.
+func loadAllowedLists(cfg config.Scoped) (al AllowedLists, err error) {
+	if al.Countries, err = allowedCountries(cfg); err != nil {
+		return AllowedLists{}, err
+	}
+	if al.Currencies, err = allowedCurrencies(cfg); err != nil {
+		return AllowedLists{}, err
+	}
+	if al.DefaultCountry, err = cfgCountryDefault.Get(cfg); err != nil {
+		return AllowedLists{}, errors.Wrap(err, "[store] DefaultCountry")
+	}
+	if al.DefaultCurrency, err = cfgCurrencyDefault.Get(cfg); err != nil {
+		return AllowedLists{}, errors.Wrap(err, "[store] DefaultCurrency")
+	}
+	al.DefaultCountry = strings.ToUpper(strings.TrimSpace(al.DefaultCountry))
+	al.DefaultCurrency = strings.ToUpper(strings.TrimSpace(al.DefaultCurrency))
+	return al, al.Validate()
+}
+
+// AllowedCountries returns the allowed country codes configured for the
+// website. An empty slice means all countries are allowed.
+func (w Website) AllowedCountries() ([]string, error) {
+	return allowedCountries(w.Config)
+}
+
+// AllowedCurrencies returns the allowed currency codes configured for the
+// website. An empty slice means all currencies are allowed.
+func (w Website) AllowedCurrencies() ([]string, error) {
+	return allowedCurrencies(w.Config)
+}
+
+// AllowedLists returns the allowed countries and currencies with their
+// defaults of the website. Error behaviour: NotValid if a default is not
+// allowed.
+func (w Website) AllowedLists() (AllowedLists, error) {
+	al, err := loadAllowedLists(w.Config)
+	return al, errors.Wrapf(err, "[store] Website %d", w.ID())
+}
+
+// AllowedCountries returns the allowed country codes configured for the store
+// with fallback to its website and the default scope. An empty slice means
+// all countries are allowed.
+func (s Store) AllowedCountries() ([]string, error) {
+	return allowedCountries(s.Config)
+}
+
+// AllowedCurrencies returns the allowed currency codes configured for the
+// store with fallback to its website and the default scope. An empty slice
+// means all currencies are allowed.
+func (s Store) AllowedCurrencies() ([]string, error) {
+	return allowedCurrencies(s.Config)
+}
+
+// AllowedLists returns the allowed countries and currencies with their
+// defaults of the store. Error behaviour: NotValid if a default is not
+// allowed.
+func (s Store) AllowedLists() (AllowedLists, error) {
+	al, err := loadAllowedLists(s.Config)
+	return al, errors.Wrapf(err, "[store] Store %d", s.ID())
+}
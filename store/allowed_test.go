@@ -0,0 +1,99 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAllowedService() *store.Service {
+	return storemock.NewEurozzyService(cfgmock.NewService(cfgmock.PathValue{
+		cfgpath.MustNewByParts(store.PathCountryAllow).String():                   "DE,AT,CH,NZ",
+		cfgpath.MustNewByParts(store.PathCountryAllow).BindWebsite(1).String():    "de, at,ch",
+		cfgpath.MustNewByParts(store.PathCountryDefault).String():                 "DE",
+		cfgpath.MustNewByParts(store.PathCurrencyAllow).String():                  "EUR,CHF",
+		cfgpath.MustNewByParts(store.PathCurrencyAllow).BindWebsite(2).String():   "NZD,AUD",
+		cfgpath.MustNewByParts(store.PathCurrencyDefault).String():                "EUR",
+		cfgpath.MustNewByParts(store.PathCurrencyDefault).BindWebsite(2).String(): "NZD",
+		cfgpath.MustNewByParts(store.PathCountryDefault).BindStore(6).String():    "NZ",
+		cfgpath.MustNewByParts(store.PathCurrencyDefault).BindStore(5).String():   "USD",
+	}))
+}
+
+func TestWebsite_AllowedLists(t *testing.T) {
+	srv := newAllowedService()
+
+	w, err := srv.Website(1)
+	require.NoError(t, err)
+	countries, err := w.AllowedCountries()
+	require.NoError(t, err)
+	assert.Exactly(t, []string{"DE", "AT", "CH"}, countries)
+
+	al, err := w.AllowedLists()
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, store.AllowedLists{
+		Countries:       []string{"DE", "AT", "CH"},
+		DefaultCountry:  "DE",
+		Currencies:      []string{"EUR", "CHF"},
+		DefaultCurrency: "EUR",
+	}, al)
+	assert.True(t, al.IsCountryAllowed("at"))
+	assert.False(t, al.IsCountryAllowed("NZ"))
+	assert.True(t, al.IsCurrencyAllowed("CHF"))
+	assert.False(t, al.IsCurrencyAllowed("NZD"))
+
+	w, err = srv.Website(2)
+	require.NoError(t, err)
+	currencies, err := w.AllowedCurrencies()
+	require.NoError(t, err)
+	assert.Exactly(t, []string{"NZD", "AUD"}, currencies)
+}
+
+func TestStore_AllowedLists(t *testing.T) {
+	srv := newAllowedService()
+
+	t.Run("fallback to website", func(t *testing.T) {
+		s, err := srv.Store(6)
+		require.NoError(t, err)
+		al, err := s.AllowedLists()
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, "NZ", al.DefaultCountry)
+		assert.Exactly(t, "NZD", al.DefaultCurrency)
+		assert.Exactly(t, []string{"NZD", "AUD"}, al.Currencies)
+	})
+
+	t.Run("default not allowed", func(t *testing.T) {
+		s, err := srv.Store(5)
+		require.NoError(t, err)
+		_, err = s.AllowedLists()
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+}
+
+func TestAllowedLists_Validate(t *testing.T) {
+	assert.NoError(t, store.AllowedLists{}.Validate())
+	assert.NoError(t, store.AllowedLists{DefaultCountry: "CH"}.Validate(), "empty list allows all")
+	assert.True(t, errors.IsNotValid(store.AllowedLists{Countries: []string{"DE"}, DefaultCountry: "CH"}.Validate()))
+	assert.True(t, errors.IsNotValid(store.AllowedLists{Currencies: []string{"EUR"}, DefaultCurrency: "CHF"}.Validate()))
+	assert.False(t, store.AllowedLists{}.IsCountryAllowed(""))
+}
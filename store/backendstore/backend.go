@@ -15,10 +15,13 @@
 package backendstore
 
 import (
+	"time"
+
 	"github.com/corestoreio/csfw/config"
 	"github.com/corestoreio/csfw/config/cfgmodel"
 	"github.com/corestoreio/csfw/config/cfgsource"
 	"github.com/corestoreio/csfw/config/element"
+	"github.com/corestoreio/csfw/store"
 	"github.com/corestoreio/csfw/store/scope"
 	"github.com/corestoreio/errors"
 )
@@ -76,6 +79,29 @@ type Configuration struct {
 	// will not be taken into account if system has more than one store view.
 	// Path: general/single_store_mode/enabled
 	GeneralSingleStoreModeEnabled cfgmodel.Bool
+
+	// WebUnsecureBaseURL => Base URL.
+	// Path: web/unsecure/base_url
+	WebUnsecureBaseURL cfgmodel.BaseURL
+
+	// WebCookieCookiePath => Cookie Path. If empty the path of the base URL
+	// gets used.
+	// Path: web/cookie/cookie_path
+	WebCookieCookiePath cfgmodel.Str
+
+	// WebCookieCookieDomain => Cookie Domain. If empty the domain gets derived
+	// from the base URL.
+	// Path: web/cookie/cookie_domain
+	WebCookieCookieDomain cfgmodel.Str
+
+	// WebCookieCookieHttponly => Use HTTP Only.
+	// Path: web/cookie/cookie_httponly
+	WebCookieCookieHttponly cfgmodel.Bool
+
+	// WebCookieStoreCookieLifetime => Store Cookie Lifetime. Duration how long
+	// the selected store code gets remembered in the store cookie.
+	// Path: web/cookie/store_cookie_lifetime
+	WebCookieStoreCookieLifetime cfgmodel.Duration
 }
 
 // New initializes the backend configuration models containing the cfgpath.Route
@@ -98,6 +124,12 @@ func New(cfgStruct element.SectionSlice, opts ...cfgmodel.Option) *Configuration
 	be.GeneralStoreInformationStreetLine1 = cfgmodel.NewStr(`general/store_information/street_line1`, opts...)
 	be.GeneralStoreInformationStreetLine2 = cfgmodel.NewStr(`general/store_information/street_line2`, opts...)
 	be.GeneralStoreInformationMerchantVatNumber = cfgmodel.NewStr(`general/store_information/merchant_vat_number`, opts...)
+
+	be.WebUnsecureBaseURL = cfgmodel.NewBaseURL(`web/unsecure/base_url`, opts...)
+	be.WebCookieCookiePath = cfgmodel.NewStr(`web/cookie/cookie_path`, opts...)
+	be.WebCookieCookieDomain = cfgmodel.NewStr(`web/cookie/cookie_domain`, opts...)
+	be.WebCookieCookieHttponly = cfgmodel.NewBool(`web/cookie/cookie_httponly`, opts...)
+	be.WebCookieStoreCookieLifetime = cfgmodel.NewDuration(`web/cookie/store_cookie_lifetime`, opts...)
 	return be
 }
 
@@ -176,3 +208,62 @@ func (c *Configuration) StoreInformation(sg config.Scoped) (*StoreInformation, e
 		Vat:         vat,
 	}, nil
 }
+
+// StoreCookie defines the settings of the cookie which persists the store code
+// selected by a user.
+type StoreCookie struct {
+	ScopeID  scope.TypeID
+	Lifetime time.Duration
+	Domain   string
+	Path     string
+	HTTPOnly bool
+}
+
+// StoreCookie reads the store cookie settings from the configuration depending
+// on the scope. An empty cookie domain or cookie path gets derived from the
+// unsecure base URL.
+func (c *Configuration) StoreCookie(sg config.Scoped) (*StoreCookie, error) {
+	lt, err := c.WebCookieStoreCookieLifetime.Get(sg)
+	if err != nil {
+		return nil, errors.Wrap(err, "[backendstore] WebCookieStoreCookieLifetime")
+	}
+	domain, err := c.WebCookieCookieDomain.Get(sg)
+	if err != nil {
+		return nil, errors.Wrap(err, "[backendstore] WebCookieCookieDomain")
+	}
+	path, err := c.WebCookieCookiePath.Get(sg)
+	if err != nil {
+		return nil, errors.Wrap(err, "[backendstore] WebCookieCookiePath")
+	}
+	httpOnly, err := c.WebCookieCookieHttponly.Get(sg)
+	if err != nil {
+		return nil, errors.Wrap(err, "[backendstore] WebCookieCookieHttponly")
+	}
+
+	if domain == "" || path == "" {
+		baseURL, err := c.WebUnsecureBaseURL.Get(sg)
+		if err != nil {
+			return nil, errors.Wrap(err, "[backendstore] WebUnsecureBaseURL")
+		}
+		if baseURL != "" {
+			bDomain, bPath, err := store.CodeCookieDomain(baseURL)
+			if err != nil {
+				return nil, errors.Wrap(err, "[backendstore] CodeCookieDomain")
+			}
+			if domain == "" {
+				domain = bDomain
+			}
+			if path == "" {
+				path = bPath
+			}
+		}
+	}
+
+	return &StoreCookie{
+		ScopeID:  sg.ScopeID(),
+		Lifetime: lt,
+		Domain:   domain,
+		Path:     path,
+		HTTPOnly: httpOnly,
+	}, nil
+}
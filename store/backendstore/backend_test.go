@@ -16,6 +16,7 @@ package backendstore_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/corestoreio/csfw/config"
 	"github.com/corestoreio/csfw/config/cfgmock"
@@ -100,3 +101,41 @@ func TestConfiguration_AddressData_Region_Error(t *testing.T) {
 	assert.True(t, errors.IsUnauthorized(err), "%+v", err)
 	assert.Nil(t, ad)
 }
+
+func TestConfiguration_StoreCookie(t *testing.T) {
+	t.Run("DerivedFromBaseURL", func(t *testing.T) {
+		sg := cfgmock.NewService(cfgmock.PathValue{
+			backend.WebUnsecureBaseURL.MustFQStore(4): `https://www.example.com/shop/`,
+		}).NewScoped(3, 4)
+
+		sc, err := backend.StoreCookie(sg)
+		assert.NoError(t, err)
+		want := &backendstore.StoreCookie{ScopeID: scope.Store.Pack(4), Lifetime: 8760 * time.Hour, Domain: "example.com", Path: "/shop/", HTTPOnly: true}
+		assert.Exactly(t, want, sc)
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		sg := cfgmock.NewService(cfgmock.PathValue{
+			backend.WebUnsecureBaseURL.MustFQStore(4):             `https://www.example.com/shop/`,
+			backend.WebCookieCookieDomain.MustFQStore(4):          `shop.example.com`,
+			backend.WebCookieCookiePath.MustFQStore(4):            `/`,
+			backend.WebCookieCookieHttponly.MustFQWebsite(3):      0,
+			backend.WebCookieStoreCookieLifetime.MustFQWebsite(3): `720h`,
+		}).NewScoped(3, 4)
+
+		sc, err := backend.StoreCookie(sg)
+		assert.NoError(t, err)
+		want := &backendstore.StoreCookie{ScopeID: scope.Store.Pack(4), Lifetime: 720 * time.Hour, Domain: "shop.example.com", Path: "/", HTTPOnly: false}
+		assert.Exactly(t, want, sc)
+	})
+
+	t.Run("InvalidBaseURL", func(t *testing.T) {
+		sg := cfgmock.NewService(cfgmock.PathValue{
+			backend.WebUnsecureBaseURL.MustFQStore(4): `shop/`,
+		}).NewScoped(3, 4)
+
+		sc, err := backend.StoreCookie(sg)
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+		assert.Nil(t, sc)
+	})
+}
@@ -164,5 +164,85 @@ func NewConfigStructure() (element.SectionSlice, error) {
 				},
 			),
 		},
+		element.Section{
+			ID:        cfgpath.NewRoute("web"),
+			Label:     text.Chars(`Web`),
+			SortOrder: 20,
+			Scopes:    scope.PermStore,
+			Groups: element.NewGroupSlice(
+				element.Group{
+					ID:        cfgpath.NewRoute("unsecure"),
+					Label:     text.Chars(`Base URLs`),
+					SortOrder: 10,
+					Scopes:    scope.PermStore,
+					Fields: element.NewFieldSlice(
+						element.Field{
+							// Path: web/unsecure/base_url
+							ID:        cfgpath.NewRoute("base_url"),
+							Label:     text.Chars(`Base URL`),
+							Comment:   text.Chars(`Specify URL or {{base_url}} placeholder.`),
+							Type:      element.TypeText,
+							SortOrder: 10,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermStore,
+						},
+					),
+				},
+
+				element.Group{
+					ID:        cfgpath.NewRoute("cookie"),
+					Label:     text.Chars(`Default Cookie Settings`),
+					SortOrder: 50,
+					Scopes:    scope.PermStore,
+					Fields: element.NewFieldSlice(
+						element.Field{
+							// Path: web/cookie/cookie_path
+							ID:        cfgpath.NewRoute("cookie_path"),
+							Label:     text.Chars(`Cookie Path`),
+							Comment:   text.Chars(`If empty the path of the base URL gets used.`),
+							Type:      element.TypeText,
+							SortOrder: 20,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermStore,
+						},
+
+						element.Field{
+							// Path: web/cookie/cookie_domain
+							ID:        cfgpath.NewRoute("cookie_domain"),
+							Label:     text.Chars(`Cookie Domain`),
+							Comment:   text.Chars(`If empty the domain gets derived from the base URL.`),
+							Type:      element.TypeText,
+							SortOrder: 30,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermStore,
+						},
+
+						element.Field{
+							// Path: web/cookie/cookie_httponly
+							ID:        cfgpath.NewRoute("cookie_httponly"),
+							Label:     text.Chars(`Use HTTP Only`),
+							Comment:   text.Chars(`<strong style="color:red">Warning</strong>:  Do not set to "No". User security could be compromised.`),
+							Type:      element.TypeSelect,
+							SortOrder: 40,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   true,
+						},
+
+						element.Field{
+							// Path: web/cookie/store_cookie_lifetime
+							ID:        cfgpath.NewRoute("store_cookie_lifetime"),
+							Label:     text.Chars(`Store Cookie Lifetime`),
+							Comment:   text.Chars(`Duration how long the selected store gets remembered, e.g. 720h.`),
+							Type:      element.TypeText,
+							SortOrder: 50,
+							Visible:   element.VisibleYes,
+							Scopes:    scope.PermWebsite,
+							Default:   `8760h`,
+						},
+					),
+				},
+			),
+		},
 	)
 }
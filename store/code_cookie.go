@@ -0,0 +1,100 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/corestoreio/errors"
+)
+
+// codeSignSeparator separates the store code from its signature. The dot can
+// never be part of a valid store code.
+const codeSignSeparator = '.'
+
+// CodeSign appends a HMAC-SHA256 signature to a store code and returns the
+// value which can be stored in a cookie. The signature gets encoded with the
+// unpadded URL base64 alphabet. An empty key returns the code unchanged.
+func CodeSign(key []byte, code string) string {
+	if len(key) == 0 {
+		return code
+	}
+	return code + string(codeSignSeparator) + codeSignature(key, code)
+}
+
+// CodeVerify checks the signature of a value created with CodeSign and returns
+// the store code. An empty key returns the value unchanged after the store code
+// validation. Values without a signature or with a non-matching signature
+// return an error. Comparison of the signatures runs in constant time.
+// Error behaviour: NotValid
+func CodeVerify(key []byte, value string) (string, error) {
+	if len(key) == 0 {
+		if err := CodeIsValid(value); err != nil {
+			return "", errors.Wrap(err, "[store] CodeVerify.CodeIsValid")
+		}
+		return value, nil
+	}
+	pos := strings.LastIndexByte(value, codeSignSeparator)
+	if pos < 1 {
+		return "", errors.NewNotValidf("[store] CodeVerify: Signature missing in %q", value)
+	}
+	code, sig := value[:pos], value[pos+1:]
+	if err := CodeIsValid(code); err != nil {
+		return "", errors.Wrap(err, "[store] CodeVerify.CodeIsValid")
+	}
+	if !hmac.Equal([]byte(sig), []byte(codeSignature(key, code))) {
+		return "", errors.NewNotValidf("[store] CodeVerify: Signature mismatch for code %q", code)
+	}
+	return code, nil
+}
+
+func codeSignature(key []byte, code string) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(code))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// CodeCookieDomain derives the cookie domain and the cookie path from a base
+// URL, for example "https://www.example.com:8443/shop/" returns
+// "example.com" and "/shop/". The port and a leading "www." gets removed so
+// that the cookie is valid for all sub domains. IP addresses and host names
+// without a dot get returned unchanged. An empty path defaults to "/".
+// Error behaviour: NotValid
+func CodeCookieDomain(baseURL string) (domain, path string, err error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", "", errors.NewNotValidf("[store] CodeCookieDomain.url.Parse: %v", err)
+	}
+	domain = u.Host
+	if h, _, err := net.SplitHostPort(domain); err == nil {
+		domain = h
+	}
+	if domain == "" {
+		return "", "", errors.NewNotValidf("[store] CodeCookieDomain: Host missing in base URL %q", baseURL)
+	}
+	if net.ParseIP(domain) == nil && strings.Count(domain, ".") > 1 {
+		domain = strings.TrimPrefix(domain, "www.")
+	}
+	path = u.Path
+	if path == "" {
+		path = "/"
+	}
+	return domain, path, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeSignVerify(t *testing.T) {
+	key := []byte(`secret`)
+	signed := store.CodeSign(key, "de_ch")
+	assert.Len(t, signed, len("de_ch")+1+43)
+	assert.Exactly(t, "de_ch", store.CodeSign(nil, "de_ch"))
+
+	tests := []struct {
+		key        []byte
+		value      string
+		wantCode   string
+		wantErrBhf errors.BehaviourFunc
+	}{
+		{key, signed, "de_ch", nil},
+		{nil, "de_ch", "de_ch", nil},
+		{nil, "de'ch", "", errors.IsNotValid},
+		{key, "de_ch", "", errors.IsNotValid},
+		{key, ".abc", "", errors.IsNotValid},
+		{key, "at" + signed[5:], "", errors.IsNotValid},
+		{[]byte(`other`), signed, "", errors.IsNotValid},
+		{key, signed + "x", "", errors.IsNotValid},
+	}
+	for i, test := range tests {
+		code, err := store.CodeVerify(test.key, test.value)
+		if test.wantErrBhf != nil {
+			assert.True(t, test.wantErrBhf(err), "Index %d => %+v", i, err)
+		} else {
+			assert.NoError(t, err, "Index %d", i)
+		}
+		assert.Exactly(t, test.wantCode, code, "Index %d", i)
+	}
+}
+
+func TestCodeCookieDomain(t *testing.T) {
+	tests := []struct {
+		baseURL    string
+		wantDomain string
+		wantPath   string
+		wantErrBhf errors.BehaviourFunc
+	}{
+		{"https://www.example.com:8443/shop/", "example.com", "/shop/", nil},
+		{"http://www.example.com", "example.com", "/", nil},
+		{"http://www.de", "www.de", "/", nil},
+		{"http://shop.example.co.uk/", "shop.example.co.uk", "/", nil},
+		{"http://127.0.0.1:3000/", "127.0.0.1", "/", nil},
+		{"http://localhost/", "localhost", "/", nil},
+		{"/shop/", "", "", errors.IsNotValid},
+		{"http://[::1", "", "", errors.IsNotValid},
+	}
+	for i, test := range tests {
+		d, p, err := store.CodeCookieDomain(test.baseURL)
+		if test.wantErrBhf != nil {
+			assert.True(t, test.wantErrBhf(err), "Index %d => %+v", i, err)
+		} else {
+			assert.NoError(t, err, "Index %d", i)
+		}
+		assert.Exactly(t, test.wantDomain, d, "Index %d", i)
+		assert.Exactly(t, test.wantPath, p, "Index %d", i)
+	}
+}
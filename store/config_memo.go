@@ -0,0 +1,205 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/net/mw"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// List of the Getter functions to separate the memoized values of the same
+// path.
+const (
+	memoByte uint8 = iota + 1
+	memoString
+	memoBool
+	memoFloat64
+	memoInt
+	memoTime
+	memoDuration
+)
+
+type configMemoKey struct {
+	hash uint32 // contains scope, scope ID and route
+	kind uint8
+}
+
+type configMemoValue struct {
+	value interface{}
+	err   error // only NotFound errors get memoized
+}
+
+var _ config.Getter = (*ConfigMemo)(nil)
+
+// ConfigMemo implements the config.Getter interface and memoizes all values
+// and NotFound errors of the Root Getter. Keys are the hash of the scope, the
+// scope ID and the path. The store -> website -> default fallback of a
+// config.Scoped resolves therefore only once per path.
+//
+// A ConfigMemo never invalidates its values, so it should live only as long
+// as one request. Use the middleware WithConfigMemo. Byte slices get shared
+// between callers and must not be modified. Thread safe.
+type ConfigMemo struct {
+	// Root the wrapped Getter.
+	Root config.Getter
+
+	mu     sync.Mutex
+	values map[configMemoKey]configMemoValue
+
+	hits   uint64
+	misses uint64
+}
+
+// NewConfigMemo creates a new memoizing Getter on top of root.
+func NewConfigMemo(root config.Getter) *ConfigMemo {
+	return &ConfigMemo{
+		Root:   root,
+		values: make(map[configMemoKey]configMemoValue),
+	}
+}
+
+func (cm *ConfigMemo) get(p cfgpath.Path, kind uint8, fn func() (interface{}, error)) (interface{}, error) {
+	h32, err := p.Hash(-1)
+	if err != nil {
+		return nil, errors.Wrap(err, "[store] ConfigMemo.Path.Hash")
+	}
+	key := configMemoKey{hash: h32, kind: kind}
+
+	cm.mu.Lock()
+	mv, ok := cm.values[key]
+	cm.mu.Unlock()
+	if ok {
+		atomic.AddUint64(&cm.hits, 1)
+		return mv.value, mv.err
+	}
+	atomic.AddUint64(&cm.misses, 1)
+
+	v, err := fn()
+	if err != nil && !errors.IsNotFound(err) {
+		return v, err
+	}
+	cm.mu.Lock()
+	cm.values[key] = configMemoValue{value: v, err: err}
+	cm.mu.Unlock()
+	return v, err
+}
+
+// Stats returns the number of memo hits and misses.
+func (cm *ConfigMemo) Stats() (hits, misses uint64) {
+	return atomic.LoadUint64(&cm.hits), atomic.LoadUint64(&cm.misses)
+}
+
+// NewScoped creates a new scoped configuration which reads its values through
+// the memo.
+func (cm *ConfigMemo) NewScoped(websiteID, storeID int64) config.Scoped {
+	return config.NewScoped(cm, websiteID, storeID)
+}
+
+// Byte implements the config.Getter interface.
+func (cm *ConfigMemo) Byte(p cfgpath.Path) ([]byte, error) {
+	v, err := cm.get(p, memoByte, func() (interface{}, error) { return cm.Root.Byte(p) })
+	b, _ := v.([]byte)
+	return b, err
+}
+
+// String implements the config.Getter interface.
+func (cm *ConfigMemo) String(p cfgpath.Path) (string, error) {
+	v, err := cm.get(p, memoString, func() (interface{}, error) { return cm.Root.String(p) })
+	s, _ := v.(string)
+	return s, err
+}
+
+// Bool implements the config.Getter interface.
+func (cm *ConfigMemo) Bool(p cfgpath.Path) (bool, error) {
+	v, err := cm.get(p, memoBool, func() (interface{}, error) { return cm.Root.Bool(p) })
+	b, _ := v.(bool)
+	return b, err
+}
+
+// Float64 implements the config.Getter interface.
+func (cm *ConfigMemo) Float64(p cfgpath.Path) (float64, error) {
+	v, err := cm.get(p, memoFloat64, func() (interface{}, error) { return cm.Root.Float64(p) })
+	f, _ := v.(float64)
+	return f, err
+}
+
+// Int implements the config.Getter interface.
+func (cm *ConfigMemo) Int(p cfgpath.Path) (int, error) {
+	v, err := cm.get(p, memoInt, func() (interface{}, error) { return cm.Root.Int(p) })
+	i, _ := v.(int)
+	return i, err
+}
+
+// Time implements the config.Getter interface.
+func (cm *ConfigMemo) Time(p cfgpath.Path) (time.Time, error) {
+	v, err := cm.get(p, memoTime, func() (interface{}, error) { return cm.Root.Time(p) })
+	t, _ := v.(time.Time)
+	return t, err
+}
+
+// Duration implements the config.Getter interface.
+func (cm *ConfigMemo) Duration(p cfgpath.Path) (time.Duration, error) {
+	v, err := cm.get(p, memoDuration, func() (interface{}, error) { return cm.Root.Duration(p) })
+	d, _ := v.(time.Duration)
+	return d, err
+}
+
+type ctxConfigMemoKey struct{}
+
+// WithContextConfigMemo adds the memo to the context.
+func WithContextConfigMemo(ctx context.Context, cm *ConfigMemo) context.Context {
+	return context.WithValue(ctx, ctxConfigMemoKey{}, cm)
+}
+
+// FromContextConfigMemo returns the memo of the current request.
+func FromContextConfigMemo(ctx context.Context) (*ConfigMemo, bool) {
+	cm, ok := ctx.Value(ctxConfigMemoKey{}).(*ConfigMemo)
+	return cm, ok && cm != nil
+}
+
+// FromContextScopedGetter returns a scoped configuration bound to the website
+// and store of the context, see scope.FromContext. The values get read through
+// the memo of the request, if available, otherwise directly from root. Without
+// a scope in the context the default scope applies.
+func FromContextScopedGetter(ctx context.Context, root config.Getter) config.Scoped {
+	if cm, ok := FromContextConfigMemo(ctx); ok {
+		root = cm
+	}
+	websiteID, storeID, _ := scope.FromContext(ctx)
+	return config.NewScoped(root, websiteID, storeID)
+}
+
+// WithConfigMemo is a middleware which creates for each request a new
+// ConfigMemo on top of root and adds it to the request context. Subsequent
+// middlewares and handlers retrieve their configuration via
+// FromContextScopedGetter and share the memoized values. The memo gets
+// discarded when the request has been served.
+func WithConfigMemo(root config.Getter) mw.Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithContextConfigMemo(r.Context(), NewConfigMemo(root))
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigMemo_Scoped(t *testing.T) {
+	srv := cfgmock.NewService(cfgmock.PathValue{
+		"default/0/web/unsecure/base_url": "http://shop.io/",
+		"stores/2/web/secure/base_url":    "https://secure.shop.de/",
+		"websites/1/web/cookie/lifetime":  3600,
+		"default/0/web/cookie/httponly":   true,
+		"default/0/web/cookie/ratio":      0.5,
+		"default/0/web/cookie/secret":     []byte("s3cr3t"),
+		"default/0/web/cookie/since":      time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC),
+		"default/0/web/cookie/ttl":        time.Minute,
+	})
+	cm := store.NewConfigMemo(srv)
+	sg := cm.NewScoped(1, 2)
+
+	for i := 0; i < 3; i++ {
+		s, err := sg.String(cfgpath.NewRoute("web/unsecure/base_url"))
+		require.NoError(t, err)
+		assert.Exactly(t, "http://shop.io/", s)
+
+		s, err = sg.String(cfgpath.NewRoute("web/secure/base_url"))
+		require.NoError(t, err)
+		assert.Exactly(t, "https://secure.shop.de/", s)
+
+		_, err = sg.String(cfgpath.NewRoute("web/not/found"))
+		assert.True(t, errors.IsNotFound(err), "%+v", err)
+
+		in, err := sg.Int(cfgpath.NewRoute("web/cookie/lifetime"))
+		require.NoError(t, err)
+		assert.Exactly(t, 3600, in)
+
+		b, err := sg.Bool(cfgpath.NewRoute("web/cookie/httponly"))
+		require.NoError(t, err)
+		assert.True(t, b)
+
+		f, err := sg.Float64(cfgpath.NewRoute("web/cookie/ratio"))
+		require.NoError(t, err)
+		assert.Exactly(t, 0.5, f)
+
+		bt, err := sg.Byte(cfgpath.NewRoute("web/cookie/secret"))
+		require.NoError(t, err)
+		assert.Exactly(t, []byte("s3cr3t"), bt)
+
+		tm, err := sg.Time(cfgpath.NewRoute("web/cookie/since"))
+		require.NoError(t, err)
+		assert.Exactly(t, 2016, tm.Year())
+
+		d, err := sg.Duration(cfgpath.NewRoute("web/cookie/ttl"))
+		require.NoError(t, err)
+		assert.Exactly(t, time.Minute, d)
+	}
+
+	// each path and scope hits the backend only once
+	for path, count := range srv.AllInvocations() {
+		assert.Exactly(t, 1, count, "Path %q", path)
+	}
+	hits, misses := cm.Stats()
+	assert.Exactly(t, uint64(srv.AllInvocations().Sum()), misses)
+	assert.Exactly(t, 2*misses, hits)
+}
+
+func TestConfigMemo_NotMemoizedErrors(t *testing.T) {
+	srv := cfgmock.NewService()
+	var calls int
+	srv.StringFn = func(path string) (string, error) {
+		calls++
+		return "", errors.NewFatalf("Backend down")
+	}
+	cm := store.NewConfigMemo(srv)
+	p := cfgpath.MustNewByParts("web/unsecure/base_url")
+	for i := 0; i < 2; i++ {
+		_, err := cm.String(p)
+		assert.True(t, errors.IsFatal(err), "%+v", err)
+	}
+	assert.Exactly(t, 2, calls)
+
+	_, err := cm.String(cfgpath.Path{})
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestWithConfigMemo(t *testing.T) {
+	srv := cfgmock.NewService(cfgmock.PathValue{
+		"default/0/web/unsecure/base_url": "http://shop.io/",
+		"stores/2/web/unsecure/base_url":  "http://shop.de/",
+	})
+	route := cfgpath.NewRoute("web/unsecure/base_url")
+
+	var wg sync.WaitGroup
+	handler := store.WithConfigMemo(srv)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer wg.Done()
+		_, ok := store.FromContextConfigMemo(r.Context())
+		assert.True(t, ok)
+		for i := 0; i < 5; i++ {
+			s, err := store.FromContextScopedGetter(r.Context(), nil).String(route)
+			require.NoError(t, err)
+			assert.Exactly(t, "http://shop.de/", s)
+		}
+	}))
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		req := httptest.NewRequest("GET", "http://shop.de", nil)
+		req = req.WithContext(scope.WithContext(req.Context(), 1, 2))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	wg.Wait()
+	// one lookup per request
+	assert.Exactly(t, 3, srv.AllInvocations().Sum())
+
+	// without memo and scope falls back to root and default scope
+	s, err := store.FromContextScopedGetter(context.Background(), srv).String(route)
+	require.NoError(t, err)
+	assert.Exactly(t, "http://shop.io/", s)
+	_, ok := store.FromContextConfigMemo(context.Background())
+	assert.False(t, ok)
+}
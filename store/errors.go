@@ -31,3 +31,8 @@ const (
 	errWebsiteDefaultGroupNotFound = "[store] Website Default Group not found"
 	errWebsiteStoreDefaultNotFound = "[store] Website Default Store not found"
 )
+
+const (
+	errFactoryPoolTenantNil          = "[store] FactoryPool TenantLoader returned a nil Service for TenantID %q"
+	errFactoryPoolTenantNotInContext = "[store] FactoryPool Tenant ID not found in context"
+)
@@ -0,0 +1,160 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/corestoreio/csfw/sync/singleflight"
+	"github.com/corestoreio/errors"
+)
+
+// TenantLoader creates a new store Service for a tenant. A tenant can be a
+// separate Magento installation identified by its DSN or a subset of websites
+// within one database. The FactoryPool calls the TenantLoader lazily, once per
+// tenant, when the Service gets requested for the first time.
+type TenantLoader func(tenantID string) (*Service, error)
+
+// FactoryPool manages multiple store Services, one for each tenant. It allows
+// a single binary to serve several installations. All functions are thread
+// safe.
+type FactoryPool struct {
+	loader   TenantLoader
+	inflight singleflight.Group
+
+	mu       sync.RWMutex
+	services map[string]*Service
+}
+
+// NewFactoryPool creates a new pool. The loader gets called for each unknown
+// tenant. Panics if the loader is nil.
+func NewFactoryPool(loader TenantLoader) *FactoryPool {
+	if loader == nil {
+		panic("[store] NewFactoryPool: TenantLoader cannot be nil")
+	}
+	return &FactoryPool{
+		loader:   loader,
+		services: make(map[string]*Service),
+	}
+}
+
+// Service returns the store Service for a tenant. The Service gets created by
+// the TenantLoader on first access. Concurrent calls for the same tenant wait
+// for the one running loader. Errors of the loader won't be cached.
+func (fp *FactoryPool) Service(tenantID string) (*Service, error) {
+	fp.mu.RLock()
+	s, ok := fp.services[tenantID]
+	fp.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	v, err, _ := fp.inflight.Do(tenantID, func() (interface{}, error) {
+		fp.mu.RLock()
+		s, ok := fp.services[tenantID]
+		fp.mu.RUnlock()
+		if ok {
+			return s, nil
+		}
+		s, err := fp.loader(tenantID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[store] FactoryPool.Service.loader TenantID %q", tenantID)
+		}
+		if s == nil {
+			return nil, errors.NewNotFoundf(errFactoryPoolTenantNil, tenantID)
+		}
+		fp.mu.Lock()
+		fp.services[tenantID] = s
+		fp.mu.Unlock()
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Service), nil
+}
+
+// ServiceByContext returns the store Service for the tenant found in the
+// context. Returns a NotFound error behaviour if the context does not contain a
+// tenant ID. See WithContextTenant.
+func (fp *FactoryPool) ServiceByContext(ctx context.Context) (*Service, error) {
+	tenantID, ok := FromContextTenant(ctx)
+	if !ok {
+		return nil, errors.NewNotFoundf(errFactoryPoolTenantNotInContext)
+	}
+	s, err := fp.Service(tenantID)
+	return s, errors.Wrap(err, "[store] FactoryPool.ServiceByContext")
+}
+
+// ClearCache clears the internal caches of the store Services of the provided
+// tenants. The Services stay in the pool. Unknown tenants will be ignored.
+func (fp *FactoryPool) ClearCache(tenantIDs ...string) {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+	for _, id := range tenantIDs {
+		if s, ok := fp.services[id]; ok {
+			s.ClearCache()
+		}
+	}
+}
+
+// Invalidate removes the store Services of the provided tenants from the pool.
+// The next call to Service triggers the TenantLoader again. Calling it without
+// arguments removes all tenants.
+func (fp *FactoryPool) Invalidate(tenantIDs ...string) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	if len(tenantIDs) == 0 {
+		for id, s := range fp.services {
+			s.ClearCache()
+			delete(fp.services, id)
+		}
+		return
+	}
+	for _, id := range tenantIDs {
+		if s, ok := fp.services[id]; ok {
+			s.ClearCache()
+			delete(fp.services, id)
+		}
+	}
+}
+
+// Tenants returns a sorted list of all tenant IDs which have been loaded.
+func (fp *FactoryPool) Tenants() []string {
+	fp.mu.RLock()
+	defer fp.mu.RUnlock()
+	ids := make([]string, 0, len(fp.services))
+	for id := range fp.services {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+type ctxTenantKey struct{}
+
+// WithContextTenant adds the tenant ID to the context. A middleware can set
+// the tenant ID depending on the host name or a request header.
+func WithContextTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, ctxTenantKey{}, tenantID)
+}
+
+// FromContextTenant returns the tenant ID from the context.
+func FromContextTenant(ctx context.Context) (tenantID string, ok bool) {
+	tenantID, ok = ctx.Value(ctxTenantKey{}).(string)
+	return
+}
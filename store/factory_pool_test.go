@@ -0,0 +1,90 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactoryPool_Service(t *testing.T) {
+	var loaded int32
+	fp := store.NewFactoryPool(func(tenantID string) (*store.Service, error) {
+		atomic.AddInt32(&loaded, 1)
+		if tenantID == "broken" {
+			return nil, errors.NewNotFoundf("DSN for %q", tenantID)
+		}
+		return storemock.NewEurozzyService(cfgmock.NewService()), nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := fp.Service("shop_a")
+			assert.NoError(t, err)
+			assert.NotNil(t, s)
+		}()
+	}
+	wg.Wait()
+	assert.Exactly(t, int32(1), atomic.LoadInt32(&loaded))
+
+	sA, err := fp.Service("shop_a")
+	assert.NoError(t, err)
+	sB, err := fp.ServiceByContext(store.WithContextTenant(context.Background(), "shop_b"))
+	assert.NoError(t, err)
+	assert.True(t, sA != sB, "Tenants must have different Services")
+	assert.Exactly(t, []string{"shop_a", "shop_b"}, fp.Tenants())
+
+	_, err = fp.Service("broken")
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+	assert.Exactly(t, []string{"shop_a", "shop_b"}, fp.Tenants())
+
+	_, err = fp.ServiceByContext(context.Background())
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+}
+
+func TestFactoryPool_Invalidate(t *testing.T) {
+	var loaded int32
+	fp := store.NewFactoryPool(func(tenantID string) (*store.Service, error) {
+		atomic.AddInt32(&loaded, 1)
+		return storemock.NewEurozzyService(cfgmock.NewService()), nil
+	})
+	sA, _ := fp.Service("shop_a")
+	_, _ = fp.Service("shop_b")
+
+	_, err := sA.Store(4)
+	assert.NoError(t, err)
+	assert.False(t, sA.IsCacheEmpty())
+	fp.ClearCache("shop_a", "unknown")
+	assert.True(t, sA.IsCacheEmpty())
+
+	fp.Invalidate("shop_b")
+	assert.Exactly(t, []string{"shop_a"}, fp.Tenants())
+	_, _ = fp.Service("shop_b")
+	assert.Exactly(t, int32(3), atomic.LoadInt32(&loaded))
+
+	fp.Invalidate()
+	assert.Empty(t, fp.Tenants())
+}
@@ -0,0 +1,88 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// The catalog price scope defines whether product prices are the same in all
+// websites or differ per website. Configured at path catalog/price/scope in
+// the default scope.
+const (
+	// PriceScopeGlobal prices are for all stores and websites the same.
+	PriceScopeGlobal int = 0
+	// PriceScopeWebsite prices are in each website different.
+	PriceScopeWebsite int = 1
+)
+
+// PriceScope returns the configured catalog price scope, either
+// PriceScopeGlobal or PriceScopeWebsite. If the BackendPriceScope has not been
+// set, the price scope is global. Error behaviour: NotValid or any error from
+// the configuration.
+func (s *Service) PriceScope(cfg config.Scoped) (int, error) {
+	if !s.BackendPriceScope.IsSet() {
+		return PriceScopeGlobal, nil
+	}
+	ps, err := s.BackendPriceScope.Get(cfg)
+	if err != nil {
+		return 0, errors.Wrap(err, "[store] Service.PriceScope")
+	}
+	if ps != PriceScopeGlobal && ps != PriceScopeWebsite {
+		return 0, errors.NewNotValidf("[store] Service.PriceScope: Unknown price scope %d", ps)
+	}
+	return ps, nil
+}
+
+// PriceScopeID maps a store to the scope to which its prices are bound. For
+// the global price scope it returns scope.DefaultTypeID and for the website
+// price scope the website of the store. The ID of the returned scope can be
+// used as website_id for the price index tables. Error behaviour: NotFound,
+// NotValid.
+func (s *Service) PriceScopeID(storeID int64) (scope.TypeID, error) {
+	st, err := s.Store(storeID)
+	if err != nil {
+		return 0, errors.Wrap(err, "[store] Service.PriceScopeID.Store")
+	}
+	ps, err := s.PriceScope(st.Config)
+	if err != nil {
+		return 0, errors.Wrapf(err, "[store] Service.PriceScopeID Store %d", storeID)
+	}
+	if ps == PriceScopeWebsite {
+		return scope.MakeTypeID(scope.Website, st.WebsiteID()), nil
+	}
+	return scope.DefaultTypeID, nil
+}
+
+// PriceStoreID maps a store to the store ID of the EAV value rows of price
+// attributes. For the global price scope the values are stored with the
+// admin store ID 0, for the website price scope each store view of a website
+// contains its own value row. Error behaviour: NotFound, NotValid.
+func (s *Service) PriceStoreID(storeID int64) (int64, error) {
+	st, err := s.Store(storeID)
+	if err != nil {
+		return 0, errors.Wrap(err, "[store] Service.PriceStoreID.Store")
+	}
+	ps, err := s.PriceScope(st.Config)
+	if err != nil {
+		return 0, errors.Wrapf(err, "[store] Service.PriceStoreID Store %d", storeID)
+	}
+	if ps == PriceScopeWebsite {
+		return st.ID(), nil
+	}
+	return DefaultStoreID, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/store/storemock"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const pathCatalogPriceScope = `catalog/price/scope`
+
+func newPriceScopeService(priceScope int) *store.Service {
+	srv := storemock.NewEurozzyService(cfgmock.NewService(cfgmock.PathValue{
+		cfgpath.MustNewByParts(pathCatalogPriceScope).String(): priceScope,
+	}))
+	srv.BackendPriceScope = cfgmodel.NewInt(pathCatalogPriceScope)
+	return srv
+}
+
+func TestService_PriceScopeID(t *testing.T) {
+	tests := []struct {
+		srv         *store.Service
+		storeID     int64
+		wantScopeID scope.TypeID
+		wantStoreID int64
+		wantErrBhf  errors.BehaviourFunc
+	}{
+		{storemock.NewEurozzyService(cfgmock.NewService()), 1, scope.DefaultTypeID, 0, nil}, // backend not set
+		{newPriceScopeService(store.PriceScopeGlobal), 1, scope.DefaultTypeID, 0, nil},
+		{newPriceScopeService(store.PriceScopeGlobal), 6, scope.DefaultTypeID, 0, nil},
+		{newPriceScopeService(store.PriceScopeWebsite), 1, scope.MakeTypeID(scope.Website, 1), 1, nil},
+		{newPriceScopeService(store.PriceScopeWebsite), 4, scope.MakeTypeID(scope.Website, 1), 4, nil},
+		{newPriceScopeService(store.PriceScopeWebsite), 6, scope.MakeTypeID(scope.Website, 2), 6, nil},
+		{newPriceScopeService(store.PriceScopeWebsite), 0, scope.MakeTypeID(scope.Website, 0), 0, nil},
+		{newPriceScopeService(store.PriceScopeWebsite), 99, 0, 0, errors.IsNotFound},
+		{newPriceScopeService(3), 1, 0, 0, errors.IsNotValid},
+	}
+	for i, test := range tests {
+		haveScopeID, err := test.srv.PriceScopeID(test.storeID)
+		haveStoreID, err2 := test.srv.PriceStoreID(test.storeID)
+		if test.wantErrBhf != nil {
+			assert.True(t, test.wantErrBhf(err), "Index %d => %+v", i, err)
+			assert.True(t, test.wantErrBhf(err2), "Index %d => %+v", i, err2)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.NoError(t, err2, "Index %d", i)
+		assert.Exactly(t, test.wantScopeID, haveScopeID, "Index %d", i)
+		assert.Exactly(t, test.wantStoreID, haveStoreID, "Index %d", i)
+	}
+}
+
+func TestService_PriceScope_Error(t *testing.T) {
+	srv := storemock.NewEurozzyService(cfgmock.NewService())
+	srv.BackendPriceScope = cfgmodel.NewInt(pathCatalogPriceScope)
+	srv.BackendPriceScope.LastError = errors.NewNotImplementedf("Ups")
+
+	ps, err := srv.PriceScope(cfgmock.NewService().NewScoped(1, 1))
+	assert.True(t, errors.IsNotImplemented(err), "%+v", err)
+	assert.Exactly(t, 0, ps)
+}
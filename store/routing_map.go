@@ -0,0 +1,238 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/errors"
+)
+
+// RoutingFormat defines the syntax of the generated routing map.
+type RoutingFormat uint8
+
+// Supported routing map formats.
+const (
+	// RoutingNginx generates a map block for the ngx_http_map_module.
+	//		map $host $store_code {
+	//			hostnames;
+	//			default de;
+	//			shop.de de;
+	//		}
+	RoutingNginx RoutingFormat = iota + 1
+	// RoutingCaddy generates a map directive for Caddy v2.
+	//		map {host} {store_code} {
+	//			shop.de de
+	//			default de
+	//		}
+	RoutingCaddy
+	// RoutingHAProxy generates a map file for the HAProxy map converter. One
+	// host and store code per line.
+	//		shop.de de
+	RoutingHAProxy
+)
+
+// RoutingEntry maps a host name to a store code.
+type RoutingEntry struct {
+	Host        string
+	StoreCode   string
+	WebsiteCode string
+}
+
+// RoutingTable returns for all active stores the host names of their secure
+// and unsecure web base URL, sorted by host name. If more than one store uses
+// the same host, the default store of the group wins, otherwise the store
+// with the lowest ID. The admin store gets skipped.
+func (s *Service) RoutingTable(uc URLConfig) ([]RoutingEntry, error) {
+	type candidate struct {
+		Store
+		isDefault bool
+	}
+	hosts := make(map[string]candidate)
+
+	for _, st := range s.Stores() {
+		if st.ID() == DefaultStoreID || !st.IsActive() {
+			continue
+		}
+		ub := st.URLBuilder()
+		ub.URLConfig = uc
+		for _, isSecure := range [...]bool{false, true} {
+			u, err := ub.BaseURL(config.URLTypeWeb, isSecure)
+			if err != nil {
+				return nil, errors.Wrapf(err, "[store] RoutingTable for store %q", st.Code())
+			}
+			c := candidate{Store: st, isDefault: st.Group.DefaultStoreID() == st.ID()}
+			prev, ok := hosts[u.Hostname()]
+			switch {
+			case !ok,
+				c.isDefault && !prev.isDefault,
+				c.isDefault == prev.isDefault && c.ID() < prev.ID():
+				hosts[u.Hostname()] = c
+			}
+		}
+	}
+
+	rt := make([]RoutingEntry, 0, len(hosts))
+	for h, c := range hosts {
+		rt = append(rt, RoutingEntry{Host: h, StoreCode: c.Code(), WebsiteCode: c.Website.Code()})
+	}
+	sort.Slice(rt, func(i, j int) bool { return rt[i].Host < rt[j].Host })
+	return rt, nil
+}
+
+// RoutingExporter generates a routing map (host to store code) for a reverse
+// proxy from the base URL configuration of all stores. The proxy can then
+// pass the store code to the application, e.g. as a header or as a run mode
+// parameter. In watch mode the map gets regenerated each time a configuration
+// value below the path "web" changes.
+type RoutingExporter struct {
+	Service *Service
+	Format  RoutingFormat
+	// Variable name of the nginx or Caddy variable which receives the store
+	// code. Defaults to store_code.
+	Variable string
+	// DefaultStoreCode gets used for unknown hosts. Defaults to the code of
+	// the default store view. HAProxy map files do not support a default
+	// entry; set the default in the map converter.
+	DefaultStoreCode string
+	// URLConfig defaults to DefaultURLConfig.
+	URLConfig *URLConfig
+	// Filename if set, Export writes the map atomically into this file.
+	Filename string
+	// OnUpdate gets called in watch mode after the map has been regenerated,
+	// for example to reload the proxy. The error argument is nil on success.
+	OnUpdate func(error)
+
+	mu sync.Mutex // serializes exports
+}
+
+// WriteTo generates the routing map and writes it to w.
+func (re *RoutingExporter) WriteTo(w io.Writer) (int64, error) {
+	uc := DefaultURLConfig
+	if re.URLConfig != nil {
+		uc = *re.URLConfig
+	}
+	rt, err := re.Service.RoutingTable(uc)
+	if err != nil {
+		return 0, errors.Wrap(err, "[store] RoutingExporter.RoutingTable")
+	}
+
+	def := re.DefaultStoreCode
+	if def == "" {
+		if st, err := re.Service.DefaultStoreView(); err == nil {
+			def = st.Code()
+		}
+	}
+	variable := re.Variable
+	if variable == "" {
+		variable = "store_code"
+	}
+
+	var buf bytes.Buffer
+	switch re.Format {
+	case RoutingNginx:
+		fmt.Fprintf(&buf, "map $host $%s {\n\thostnames;\n", variable)
+		if def != "" {
+			fmt.Fprintf(&buf, "\tdefault %s;\n", def)
+		}
+		for _, e := range rt {
+			fmt.Fprintf(&buf, "\t%s %s;\n", e.Host, e.StoreCode)
+		}
+		buf.WriteString("}\n")
+	case RoutingCaddy:
+		fmt.Fprintf(&buf, "map {host} {%s} {\n", variable)
+		for _, e := range rt {
+			fmt.Fprintf(&buf, "\t%s %s\n", e.Host, e.StoreCode)
+		}
+		if def != "" {
+			fmt.Fprintf(&buf, "\tdefault %s\n", def)
+		}
+		buf.WriteString("}\n")
+	case RoutingHAProxy:
+		for _, e := range rt {
+			fmt.Fprintf(&buf, "%s %s\n", e.Host, e.StoreCode)
+		}
+	default:
+		return 0, errors.NewNotSupportedf("[store] Unknown RoutingFormat %d", re.Format)
+	}
+	return buf.WriteTo(w)
+}
+
+// Export writes the routing map into Filename. The file gets replaced
+// atomically so a reloading proxy never reads a partial map.
+func (re *RoutingExporter) Export() error {
+	if re.Filename == "" {
+		return errors.NewEmptyf("[store] RoutingExporter.Filename cannot be empty")
+	}
+	re.mu.Lock()
+	defer re.mu.Unlock()
+
+	var buf bytes.Buffer
+	if _, err := re.WriteTo(&buf); err != nil {
+		return errors.Wrap(err, "[store] RoutingExporter.Export")
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(re.Filename), filepath.Base(re.Filename)+".tmp")
+	if err != nil {
+		return errors.NewFatal(err, "[store] RoutingExporter.Export.TempFile")
+	}
+	if _, err := buf.WriteTo(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return errors.NewWriteFailed(err, "[store] RoutingExporter.Export.Write %q", f.Name())
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return errors.NewWriteFailed(err, "[store] RoutingExporter.Export.Close %q", f.Name())
+	}
+	if err := os.Rename(f.Name(), re.Filename); err != nil {
+		_ = os.Remove(f.Name())
+		return errors.NewWriteFailed(err, "[store] RoutingExporter.Export.Rename %q", re.Filename)
+	}
+	return nil
+}
+
+// MessageConfig implements the config.MessageReceiver interface and
+// regenerates the routing map. Errors get passed to OnUpdate, so the exporter
+// stays subscribed.
+func (re *RoutingExporter) MessageConfig(_ cfgpath.Path) error {
+	err := re.Export()
+	if re.OnUpdate != nil {
+		re.OnUpdate(err)
+	}
+	return nil
+}
+
+// MessageStore implements the EventReceiver interface and regenerates the
+// routing map after the stores have been reloaded.
+func (re *RoutingExporter) MessageStore(_ Events) error {
+	return re.MessageConfig(cfgpath.Path{})
+}
+
+// Watch subscribes the exporter to all configuration changes below the path
+// "web" and therefore to all base URL changes. Returns the subscription ID.
+func (re *RoutingExporter) Watch(sub config.Subscriber) (int, error) {
+	id, err := sub.Subscribe(cfgpath.NewRoute("web"), re)
+	return id, errors.Wrap(err, "[store] RoutingExporter.Watch.Subscribe")
+}
@@ -0,0 +1,137 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/config/cfgpath"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/util/null"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRoutingTestService(t *testing.T) *store.Service {
+	return store.MustNewService(
+		cfgmock.NewService(cfgmock.PathValue{
+			"default/0/web/unsecure/base_url": "http://shop.io/",
+			"stores/1/web/unsecure/base_url":  "http://shop.de/",
+			"stores/1/web/secure/base_url":    "https://secure.shop.de/",
+			"stores/2/web/unsecure/base_url":  "http://shop.at/",
+			"stores/3/web/unsecure/base_url":  "http://shop.de/",
+			"stores/4/web/unsecure/base_url":  "http://shop.nz/",
+		}),
+		store.WithTableWebsites(
+			&store.TableWebsite{WebsiteID: 1, Code: null.StringFrom("euro"), Name: null.StringFrom("Europe"), DefaultGroupID: 1, IsDefault: null.BoolFrom(true)},
+			&store.TableWebsite{WebsiteID: 2, Code: null.StringFrom("oz"), Name: null.StringFrom("OZ"), DefaultGroupID: 2},
+		),
+		store.WithTableGroups(
+			&store.TableGroup{GroupID: 1, WebsiteID: 1, Name: "DACH Group", DefaultStoreID: 3},
+			&store.TableGroup{GroupID: 2, WebsiteID: 2, Name: "OZ Group", DefaultStoreID: 5},
+		),
+		store.WithTableStores(
+			&store.TableStore{StoreID: 1, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 1, Name: "Germany", IsActive: true},
+			&store.TableStore{StoreID: 2, Code: null.StringFrom("at"), WebsiteID: 1, GroupID: 1, Name: "Austria", IsActive: true},
+			&store.TableStore{StoreID: 3, Code: null.StringFrom("ch"), WebsiteID: 1, GroupID: 1, Name: "Schweiz", IsActive: true},
+			&store.TableStore{StoreID: 4, Code: null.StringFrom("nz"), WebsiteID: 2, GroupID: 2, Name: "Kiwi", IsActive: false},
+			&store.TableStore{StoreID: 5, Code: null.StringFrom("au"), WebsiteID: 2, GroupID: 2, Name: "Australia", IsActive: true},
+		),
+	)
+}
+
+func TestService_RoutingTable(t *testing.T) {
+	rt, err := newRoutingTestService(t).RoutingTable(store.DefaultURLConfig)
+	require.NoError(t, err, "%+v", err)
+	assert.Exactly(t, []store.RoutingEntry{
+		{Host: "secure.shop.de", StoreCode: "de", WebsiteCode: "euro"},
+		{Host: "shop.at", StoreCode: "at", WebsiteCode: "euro"},
+		{Host: "shop.de", StoreCode: "ch", WebsiteCode: "euro"}, // ch is the default store of the group
+		{Host: "shop.io", StoreCode: "au", WebsiteCode: "oz"},
+	}, rt)
+}
+
+func TestRoutingExporter_WriteTo(t *testing.T) {
+	tests := []struct {
+		format store.RoutingFormat
+		want   string
+	}{
+		{store.RoutingNginx, "map $host $store_code {\n\thostnames;\n\tdefault ch;\n\tsecure.shop.de de;\n\tshop.at at;\n\tshop.de ch;\n\tshop.io au;\n}\n"},
+		{store.RoutingCaddy, "map {host} {store_code} {\n\tsecure.shop.de de\n\tshop.at at\n\tshop.de ch\n\tshop.io au\n\tdefault ch\n}\n"},
+		{store.RoutingHAProxy, "secure.shop.de de\nshop.at at\nshop.de ch\nshop.io au\n"},
+	}
+	srv := newRoutingTestService(t)
+	for _, test := range tests {
+		re := &store.RoutingExporter{Service: srv, Format: test.format}
+		var buf bytes.Buffer
+		_, err := re.WriteTo(&buf)
+		require.NoError(t, err, "%+v", err)
+		assert.Exactly(t, test.want, buf.String(), "Format %d", test.format)
+	}
+
+	re := &store.RoutingExporter{Service: srv, Format: store.RoutingNginx, Variable: "mage_run_code", DefaultStoreCode: "de"}
+	var buf bytes.Buffer
+	_, err := re.WriteTo(&buf)
+	require.NoError(t, err, "%+v", err)
+	assert.Contains(t, buf.String(), "map $host $mage_run_code {\n\thostnames;\n\tdefault de;\n")
+
+	_, err = (&store.RoutingExporter{Service: srv}).WriteTo(&buf)
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+}
+
+func TestRoutingExporter_Watch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "routing_map")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var updates []error
+	re := &store.RoutingExporter{
+		Service:  newRoutingTestService(t),
+		Format:   store.RoutingHAProxy,
+		Filename: filepath.Join(dir, "stores.map"),
+		OnUpdate: func(err error) {
+			updates = append(updates, err)
+		},
+	}
+
+	var receiver config.MessageReceiver
+	id, err := re.Watch(&cfgmock.Service{
+		SubscribeFn: func(r cfgpath.Route, mr config.MessageReceiver) (int, error) {
+			assert.Exactly(t, "web", r.String())
+			receiver = mr
+			return 4711, nil
+		},
+	})
+	require.NoError(t, err)
+	assert.Exactly(t, 4711, id)
+
+	require.NoError(t, receiver.MessageConfig(cfgpath.MustNewByParts("web/unsecure/base_url")))
+	assert.Exactly(t, []error{nil}, updates)
+	data, err := ioutil.ReadFile(re.Filename)
+	require.NoError(t, err)
+	assert.Exactly(t, "secure.shop.de de\nshop.at at\nshop.de ch\nshop.io au\n", string(data))
+
+	re.Filename = ""
+	require.NoError(t, receiver.MessageConfig(cfgpath.MustNewByParts("web/unsecure/base_url")))
+	require.Len(t, updates, 2)
+	assert.True(t, errors.IsEmpty(updates[1]), "%+v", updates[1])
+}
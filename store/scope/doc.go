@@ -38,4 +38,14 @@
 //
 // A group scope does not make sense in the above schema but is supported by
 // other Go types in this package.
+//
+// PermMatrix declares per configuration route or action which scopes can be
+// read, written or executed. Admin APIs, like the config write endpoints,
+// authorize a request with the scopes granted to the current user:
+//
+//	pm := scope.NewPermMatrix(map[string]scope.PermRule{
+//		"web/secure": {Read: scope.PermStore, Write: scope.PermWebsite},
+//	})
+//	err := pm.Authorize(userScopes, scope.AccessWrite, "web/secure/base_url", scope.Website.Pack(2))
+//	// errors.IsUnauthorized(err)
 package scope
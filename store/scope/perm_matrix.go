@@ -0,0 +1,150 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scope
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/corestoreio/errors"
+)
+
+// Access defines the kind of operation which gets authorized by a PermMatrix.
+type Access uint8
+
+// Access types. AccessExecute applies to actions like flushing a cache or
+// reindexing, which are not bound to a configuration path.
+const (
+	AccessRead Access = iota
+	AccessWrite
+	AccessExecute
+)
+
+var accessNames = [...]string{"read", "write", "execute"}
+
+// String returns the name of the Access type.
+func (a Access) String() string {
+	if int(a) < len(accessNames) {
+		return accessNames[a]
+	}
+	return "unknown"
+}
+
+// PermRule declares within which scopes a route can be read, written or
+// executed.
+type PermRule struct {
+	Read    Perm
+	Write   Perm
+	Execute Perm
+}
+
+func (pr PermRule) perm(a Access) Perm {
+	switch a {
+	case AccessRead:
+		return pr.Read
+	case AccessWrite:
+		return pr.Write
+	case AccessExecute:
+		return pr.Execute
+	}
+	return 0
+}
+
+// PermMatrix declares per configuration route or per action name the allowed
+// scopes and authorizes requests of an admin user against the scopes granted
+// to that user. A rule for "web/secure" applies to all paths below, e.g.
+// "web/secure/base_url", unless a more specific rule exists. Routes without
+// any rule are denied. A PermMatrix is safe for concurrent use.
+type PermMatrix struct {
+	mu    sync.RWMutex
+	rules map[string]PermRule
+}
+
+// NewPermMatrix creates a new matrix with optional rules. The keys of the map
+// are routes or action names.
+func NewPermMatrix(rules map[string]PermRule) *PermMatrix {
+	pm := &PermMatrix{
+		rules: make(map[string]PermRule, len(rules)),
+	}
+	for r, pr := range rules {
+		pm.rules[r] = pr
+	}
+	return pm
+}
+
+// Set adds or overwrites the rule of a route or action.
+func (pm *PermMatrix) Set(route string, pr PermRule) *PermMatrix {
+	pm.mu.Lock()
+	pm.rules[route] = pr
+	pm.mu.Unlock()
+	return pm
+}
+
+// Rule returns the most specific rule for a route. It walks the route from
+// the full path up to its first level.
+func (pm *PermMatrix) Rule(route string) (PermRule, bool) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	for route != "" {
+		if pr, ok := pm.rules[route]; ok {
+			return pr, true
+		}
+		pos := strings.LastIndexByte(route, '/')
+		if pos < 0 {
+			break
+		}
+		route = route[:pos]
+	}
+	return PermRule{}, false
+}
+
+// Authorize checks whether a user with the granted scopes may access the
+// route within the target scope. First the Type of the target must be part of
+// the Perm of the rule. Second the target must be covered by the granted
+// scopes: the DefaultTypeID grants everything, otherwise the target itself
+// or one of its parents must be granted. Parents are for example the website
+// of a store target; invalid parents get ignored.
+//
+// Error behaviour: Unauthorized.
+func (pm *PermMatrix) Authorize(granted TypeIDs, a Access, route string, target TypeID, parents ...TypeID) error {
+	pr, ok := pm.Rule(route)
+	if !ok {
+		return errors.NewUnauthorizedf("[scope] PermMatrix: No rule declared for route %q", route)
+	}
+	if !pr.perm(a).Has(target.Type()) {
+		return errors.NewUnauthorizedf("[scope] PermMatrix: Route %q does not allow %s access in scope %s. Allowed: %v", route, a, target, pr.perm(a).Human())
+	}
+	if !granted.Covers(target, parents...) {
+		return errors.NewUnauthorizedf("[scope] PermMatrix: Scope %s for route %q not granted. Granted: %s", target, route, granted)
+	}
+	return nil
+}
+
+// Covers reports whether the TypeIDs grant access to the target scope. It
+// returns true if t contains the DefaultTypeID, the target or a valid parent
+// of the target.
+func (t TypeIDs) Covers(target TypeID, parents ...TypeID) bool {
+	for _, g := range t {
+		if g == DefaultTypeID || g == target {
+			return true
+		}
+		for _, p := range parents {
+			if p == g && target.ValidParent(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scope_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermMatrix_Rule(t *testing.T) {
+	pm := scope.NewPermMatrix(map[string]scope.PermRule{
+		"web":            {Read: scope.PermStore, Write: scope.PermDefault},
+		"web/secure":     {Read: scope.PermStore, Write: scope.PermWebsite},
+		"cache/flush":    {Execute: scope.PermDefault},
+		"web/secure/xyz": {},
+	})
+
+	pr, ok := pm.Rule("web/secure/base_url")
+	assert.True(t, ok)
+	assert.Exactly(t, scope.PermWebsite, pr.Write)
+
+	pr, ok = pm.Rule("web/unsecure/base_url")
+	assert.True(t, ok)
+	assert.Exactly(t, scope.PermDefault, pr.Write)
+
+	_, ok = pm.Rule("webx/unsecure/base_url")
+	assert.False(t, ok)
+
+	pm.Set("webx", scope.PermRule{Read: scope.PermDefault})
+	_, ok = pm.Rule("webx/unsecure/base_url")
+	assert.True(t, ok)
+}
+
+func TestPermMatrix_Authorize(t *testing.T) {
+	pm := scope.NewPermMatrix(map[string]scope.PermRule{
+		"web/secure":  {Read: scope.PermStore, Write: scope.PermWebsite},
+		"cache/flush": {Execute: scope.PermDefault},
+	})
+
+	website2 := scope.Website.Pack(2)
+	store4 := scope.Store.Pack(4)
+
+	tests := []struct {
+		granted scope.TypeIDs
+		access  scope.Access
+		route   string
+		target  scope.TypeID
+		parents scope.TypeIDs
+		wantOK  bool
+	}{
+		{scope.TypeIDs{scope.DefaultTypeID}, scope.AccessWrite, "web/secure/base_url", website2, nil, true},
+		{scope.TypeIDs{scope.DefaultTypeID}, scope.AccessWrite, "web/secure/base_url", store4, nil, false}, // store not allowed for write
+		{scope.TypeIDs{scope.DefaultTypeID}, scope.AccessRead, "web/secure/base_url", store4, nil, true},
+		{scope.TypeIDs{website2}, scope.AccessWrite, "web/secure/base_url", website2, nil, true},
+		{scope.TypeIDs{website2}, scope.AccessWrite, "web/secure/base_url", scope.Website.Pack(3), nil, false},
+		{scope.TypeIDs{website2}, scope.AccessWrite, "web/secure/base_url", scope.DefaultTypeID, nil, false},
+		{scope.TypeIDs{website2}, scope.AccessRead, "web/secure/base_url", store4, nil, false},
+		{scope.TypeIDs{website2}, scope.AccessRead, "web/secure/base_url", store4, scope.TypeIDs{website2}, true},
+		{scope.TypeIDs{store4}, scope.AccessRead, "web/secure/base_url", store4, nil, true},
+		{scope.TypeIDs{store4}, scope.AccessRead, "web/secure/base_url", scope.Store.Pack(5), scope.TypeIDs{store4}, false}, // store is not a parent
+		{scope.TypeIDs{scope.DefaultTypeID}, scope.AccessExecute, "cache/flush", scope.DefaultTypeID, nil, true},
+		{scope.TypeIDs{website2}, scope.AccessExecute, "cache/flush", scope.DefaultTypeID, nil, false},
+		{scope.TypeIDs{scope.DefaultTypeID}, scope.AccessWrite, "cache/flush", scope.DefaultTypeID, nil, false},
+		{scope.TypeIDs{scope.DefaultTypeID}, scope.AccessRead, "catalog/seo/title", scope.DefaultTypeID, nil, false},
+		{nil, scope.AccessRead, "web/secure/base_url", scope.DefaultTypeID, nil, false},
+	}
+	for i, test := range tests {
+		err := pm.Authorize(test.granted, test.access, test.route, test.target, test.parents...)
+		if test.wantOK {
+			assert.NoError(t, err, "Index %d", i)
+		} else {
+			assert.True(t, errors.IsUnauthorized(err), "Index %d => %+v", i, err)
+		}
+	}
+}
+
+func TestAccess_String(t *testing.T) {
+	assert.Exactly(t, "read", scope.AccessRead.String())
+	assert.Exactly(t, "execute", scope.AccessExecute.String())
+	assert.Exactly(t, "unknown", scope.Access(9).String())
+}
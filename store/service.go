@@ -62,6 +62,11 @@ type Service struct {
 	// value is optional.
 	BackendSingleStore cfgmodel.Bool
 
+	// BackendPriceScope contains the path to the catalog price scope
+	// configuration, usually catalog/price/scope. If not set, prices have a
+	// global scope. See PriceScope().
+	BackendPriceScope cfgmodel.Int
+
 	// backend communicates with the database in rw mode and creates
 	// new store, group and website pointers. If nil, panics.
 	backend *factory
@@ -81,6 +86,13 @@ type Service struct {
 	cacheGroup       map[int64]Group
 	cacheStore       map[int64]Store
 	cacheSingleStore map[scope.TypeID]bool
+	// dataVersion hash of the raw data, see DataVersion()
+	dataVersion string
+
+	// subMu protects the subscribed EventReceivers, see Subscribe()
+	subMu      sync.RWMutex
+	subMap     map[int]EventReceiver
+	subAutoInc int
 }
 
 func newService() *Service {
@@ -479,8 +491,12 @@ func (s *Service) DefaultStoreView() (Store, error) {
 
 // LoadFromDB reloads the website, store group and store view data from the database.
 // After reloading internal cache will be cleared if there are no errors.
+// The subscribed EventReceivers get notified about created, updated and
+// deleted websites, groups and stores.
 func (s *Service) LoadFromResource(twr TableWebsitesResourcer, tgr TableGroupsResourcer, tsr TableStoresResourcer) error {
 
+	before := s.backend.snapshot()
+
 	if err := s.backend.LoadFromResource(twr, tgr, tsr); err != nil {
 		return errors.Wrap(err, "[store] LoadFromDB.Backend")
 	}
@@ -493,7 +509,11 @@ func (s *Service) LoadFromResource(twr TableWebsitesResourcer, tgr TableGroupsRe
 		WithTableGroups(s.backend.groups...),
 		WithTableStores(s.backend.stores...),
 	)
-	return errors.Wrap(err, "[store] LoadFromDB.ApplyStorage")
+	if err != nil {
+		return errors.Wrap(err, "[store] LoadFromDB.ApplyStorage")
+	}
+	s.publish(before.diff(s.backend.snapshot()))
+	return nil
 }
 
 // ClearCache resets the internal caches which stores the pointers to Websites,
@@ -519,6 +539,7 @@ func (s *Service) ClearCache() {
 	}
 	s.cacheSingleStore = make(map[scope.TypeID]bool)
 	s.defaultStoreID = -1
+	s.dataVersion = ""
 	s.websites = nil
 	s.groups = nil
 	s.stores = nil
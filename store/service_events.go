@@ -0,0 +1,243 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"sort"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// EventType defines the kind of change of a website, group or store.
+type EventType uint8
+
+// EventType* constants define the life cycle of an entity.
+const (
+	EventCreated EventType = iota + 1
+	EventUpdated
+	EventDeleted
+)
+
+// String returns the lower case name of the event type.
+func (et EventType) String() string {
+	switch et {
+	case EventCreated:
+		return "created"
+	case EventUpdated:
+		return "updated"
+	case EventDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// Event describes the change of one website, group or store after reloading
+// the data with LoadFromResource.
+type Event struct {
+	Type EventType
+	// Scope can be scope.Website, scope.Group or scope.Store.
+	Scope scope.Type
+	// ID of the website, group or store.
+	ID int64
+	// Code of the website or store. Empty for groups. Deleted entities
+	// contain the old code.
+	Code string
+}
+
+// Events a list of events, ordered by website, group and store and within
+// each scope by the ID.
+type Events []Event
+
+// ByScope returns all events of a scope.
+func (es Events) ByScope(scp scope.Type) Events {
+	var ret Events
+	for _, e := range es {
+		if e.Scope == scp {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+// EventReceiver gets notified after LoadFromResource has changed the websites,
+// groups or stores. Dependent caches like URL maps, configuration overlays or
+// JWT scope bindings can invalidate themselves. If an error gets returned or
+// the receiver panics, it gets unsubscribed.
+type EventReceiver interface {
+	// MessageStore receives all changes of one reload. Events is never empty.
+	MessageStore(Events) error
+}
+
+// EventReceiverFunc type is an adapter to allow the use of ordinary functions
+// as EventReceiver.
+type EventReceiverFunc func(Events) error
+
+// MessageStore calls f(es).
+func (f EventReceiverFunc) MessageStore(es Events) error {
+	return f(es)
+}
+
+// Subscribe adds an EventReceiver which gets called synchronously at the end
+// of LoadFromResource. Returns a unique identifier for the later removal.
+func (s *Service) Subscribe(er EventReceiver) (subscriptionID int, err error) {
+	if er == nil {
+		return 0, errors.NewEmptyf("[store] Service.Subscribe: EventReceiver cannot be nil")
+	}
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	if s.subMap == nil {
+		s.subMap = make(map[int]EventReceiver)
+	}
+	s.subAutoInc++
+	s.subMap[s.subAutoInc] = er
+	return s.subAutoInc, nil
+}
+
+// Unsubscribe removes a subscriber with a specific ID.
+func (s *Service) Unsubscribe(subscriptionID int) error {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	delete(s.subMap, subscriptionID)
+	return nil
+}
+
+// publish sends the events to all subscribers and evicts the failing ones.
+func (s *Service) publish(es Events) {
+	if len(es) == 0 {
+		return
+	}
+	s.subMu.RLock()
+	ids := make([]int, 0, len(s.subMap))
+	for id := range s.subMap {
+		ids = append(ids, id)
+	}
+	s.subMu.RUnlock()
+	sort.Ints(ids) // subscription order
+
+	for _, id := range ids {
+		s.subMu.RLock()
+		er, ok := s.subMap[id]
+		s.subMu.RUnlock()
+		if !ok {
+			continue
+		}
+		if err := sendEventsRecoverable(er, es); err != nil {
+			_ = s.Unsubscribe(id)
+		}
+	}
+}
+
+func sendEventsRecoverable(er EventReceiver, es Events) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if recErr, ok := r.(error); ok {
+				err = recErr
+			} else {
+				err = errors.Errorf("%#v", r)
+			}
+		}
+	}()
+	return er.MessageStore(es)
+}
+
+// dataSnapshot copies the raw data of the factory to detect changes after a
+// reload.
+type dataSnapshot struct {
+	websites map[int64]TableWebsite
+	groups   map[int64]TableGroup
+	stores   map[int64]TableStore
+}
+
+func (f *factory) snapshot() dataSnapshot {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	ds := dataSnapshot{
+		websites: make(map[int64]TableWebsite, len(f.websites)),
+		groups:   make(map[int64]TableGroup, len(f.groups)),
+		stores:   make(map[int64]TableStore, len(f.stores)),
+	}
+	for _, w := range f.websites {
+		ds.websites[w.WebsiteID] = *w
+	}
+	for _, g := range f.groups {
+		ds.groups[g.GroupID] = *g
+	}
+	for _, st := range f.stores {
+		c := *st
+		c.DB = nil // not part of the data
+		c.HookDML = nil
+		ds.stores[st.StoreID] = c
+	}
+	return ds
+}
+
+// diff calculates the events to get from ds to the newer snapshot.
+func (ds dataSnapshot) diff(newer dataSnapshot) Events {
+	var es Events
+	add := func(et EventType, scp scope.Type, id int64, code string) {
+		es = append(es, Event{Type: et, Scope: scp, ID: id, Code: code})
+	}
+
+	for id, o := range ds.websites {
+		switch n, ok := newer.websites[id]; {
+		case !ok:
+			add(EventDeleted, scope.Website, id, o.Code.String)
+		case o != n:
+			add(EventUpdated, scope.Website, id, n.Code.String)
+		}
+	}
+	for id, n := range newer.websites {
+		if _, ok := ds.websites[id]; !ok {
+			add(EventCreated, scope.Website, id, n.Code.String)
+		}
+	}
+
+	for id, o := range ds.groups {
+		switch n, ok := newer.groups[id]; {
+		case !ok:
+			add(EventDeleted, scope.Group, id, "")
+		case o != n:
+			add(EventUpdated, scope.Group, id, "")
+		}
+	}
+	for id := range newer.groups {
+		if _, ok := ds.groups[id]; !ok {
+			add(EventCreated, scope.Group, id, "")
+		}
+	}
+
+	for id, o := range ds.stores {
+		switch n, ok := newer.stores[id]; {
+		case !ok:
+			add(EventDeleted, scope.Store, id, o.Code.String)
+		case o != n:
+			add(EventUpdated, scope.Store, id, n.Code.String)
+		}
+	}
+	for id, n := range newer.stores {
+		if _, ok := ds.stores[id]; !ok {
+			add(EventCreated, scope.Store, id, n.Code.String)
+		}
+	}
+
+	sort.Slice(es, func(i, j int) bool {
+		if es[i].Scope != es[j].Scope {
+			return es[i].Scope < es[j].Scope
+		}
+		return es[i].ID < es[j].ID
+	})
+	return es
+}
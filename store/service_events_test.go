@@ -0,0 +1,141 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/null"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type resourceMock struct {
+	websites store.TableWebsiteSlice
+	groups   store.TableGroupSlice
+	stores   store.TableStoreSlice
+}
+
+func (rm *resourceMock) PrepareSelect() error { return nil }
+func (rm *resourceMock) Close() error         { return nil }
+
+type websitesResourceMock struct{ *resourceMock }
+
+func (m websitesResourceMock) Select() (store.TableWebsiteSlice, error) {
+	return m.websites, nil
+}
+func (m websitesResourceMock) Insert(store.TableWebsiteSlice) (int, error) { return 0, nil }
+func (m websitesResourceMock) Update(store.TableWebsiteSlice) (int, error) { return 0, nil }
+func (m websitesResourceMock) Delete(store.TableWebsiteSlice) (int, error) { return 0, nil }
+
+type groupsResourceMock struct{ *resourceMock }
+
+func (m groupsResourceMock) Select() (store.TableGroupSlice, error) {
+	return m.groups, nil
+}
+func (m groupsResourceMock) Insert(store.TableGroupSlice) (int, error) { return 0, nil }
+func (m groupsResourceMock) Update(store.TableGroupSlice) (int, error) { return 0, nil }
+func (m groupsResourceMock) Delete(store.TableGroupSlice) (int, error) { return 0, nil }
+
+type storesResourceMock struct{ *resourceMock }
+
+func (m storesResourceMock) Select(_ ...interface{}) (store.TableStoreSlice, error) {
+	return m.stores, nil
+}
+func (m storesResourceMock) Insert(store.TableStoreSlice) (int, error) { return 0, nil }
+func (m storesResourceMock) Update(store.TableStoreSlice) (int, error) { return 0, nil }
+func (m storesResourceMock) Delete(store.TableStoreSlice) (int, error) { return 0, nil }
+
+func (rm *resourceMock) load(srv *store.Service) error {
+	return srv.LoadFromResource(websitesResourceMock{rm}, groupsResourceMock{rm}, storesResourceMock{rm})
+}
+
+func TestService_Subscribe_LoadFromResource(t *testing.T) {
+	srv := store.MustNewService(cfgmock.NewService(),
+		store.WithTableWebsites(&store.TableWebsite{WebsiteID: 1, Code: null.StringFrom("euro"), DefaultGroupID: 1, IsDefault: null.BoolFrom(true)}),
+		store.WithTableGroups(&store.TableGroup{GroupID: 1, WebsiteID: 1, Name: "DACH Group", DefaultStoreID: 1}),
+		store.WithTableStores(
+			&store.TableStore{StoreID: 1, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 1, Name: "Germany", IsActive: true},
+			&store.TableStore{StoreID: 2, Code: null.StringFrom("at"), WebsiteID: 1, GroupID: 1, Name: "Austria", IsActive: true},
+		),
+	)
+
+	var received []store.Events
+	id, err := srv.Subscribe(store.EventReceiverFunc(func(es store.Events) error {
+		received = append(received, es)
+		return nil
+	}))
+	require.NoError(t, err)
+	assert.Exactly(t, 1, id)
+
+	failID, err := srv.Subscribe(store.EventReceiverFunc(func(es store.Events) error {
+		panic("Evict me")
+	}))
+	require.NoError(t, err)
+	assert.Exactly(t, 2, failID)
+
+	rm := &resourceMock{
+		websites: store.TableWebsiteSlice{
+			{WebsiteID: 1, Code: null.StringFrom("euro"), DefaultGroupID: 1, IsDefault: null.BoolFrom(true)},
+		},
+		groups: store.TableGroupSlice{
+			{GroupID: 1, WebsiteID: 1, Name: "DACH Group", DefaultStoreID: 1},
+		},
+		stores: store.TableStoreSlice{
+			{StoreID: 1, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 1, Name: "Deutschland", IsActive: true},
+			{StoreID: 3, Code: null.StringFrom("ch"), WebsiteID: 1, GroupID: 1, Name: "Schweiz", IsActive: true},
+		},
+	}
+	require.NoError(t, rm.load(srv))
+
+	require.Len(t, received, 1)
+	assert.Exactly(t, store.Events{
+		{Type: store.EventUpdated, Scope: scope.Store, ID: 1, Code: "de"},
+		{Type: store.EventDeleted, Scope: scope.Store, ID: 2, Code: "at"},
+		{Type: store.EventCreated, Scope: scope.Store, ID: 3, Code: "ch"},
+	}, received[0])
+	assert.Len(t, received[0].ByScope(scope.Website), 0)
+
+	// the new data is available
+	st, err := srv.Store(3)
+	require.NoError(t, err)
+	assert.Exactly(t, "ch", st.Code())
+	_, err = srv.Store(2)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+
+	// unchanged data triggers no events
+	require.NoError(t, rm.load(srv))
+	assert.Len(t, received, 1)
+
+	// the panicking subscriber has been evicted and the first one removed
+	rm.groups[0].Name = "Alps"
+	require.NoError(t, srv.Unsubscribe(id))
+	require.NoError(t, rm.load(srv))
+	assert.Len(t, received, 1)
+
+	_, err = srv.Subscribe(nil)
+	assert.True(t, errors.IsEmpty(err), "%+v", err)
+}
+
+func TestEventType_String(t *testing.T) {
+	assert.Exactly(t, "created", store.EventCreated.String())
+	assert.Exactly(t, "updated", store.EventUpdated.String())
+	assert.Exactly(t, "deleted", store.EventDeleted.String())
+	assert.Exactly(t, "unknown", store.EventType(0).String())
+}
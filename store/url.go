@@ -0,0 +1,241 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgmodel"
+	"github.com/corestoreio/errors"
+)
+
+// SIDQueryName defines the name of the GET parameter which transports the
+// session ID, if enabled via web/session/use_frontend_sid.
+const SIDQueryName = `SID`
+
+// maxPlaceholderDepth limits the resolving of nested placeholders like
+// {{secure_base_url}} => {{unsecure_base_url}} => {{base_url}}.
+const maxPlaceholderDepth = 3
+
+// URLConfig contains the configuration paths used by the URLBuilder.
+// NewURLConfig sets the default Magento paths.
+type URLConfig struct {
+	// DistroBaseURL replaces the placeholder {{base_url}}. Path:
+	// web/corestore/base_url, default config.CSBaseURL.
+	DistroBaseURL cfgmodel.Str
+
+	UnsecureBaseURL       cfgmodel.BaseURL // web/unsecure/base_url
+	UnsecureBaseStaticURL cfgmodel.BaseURL // web/unsecure/base_static_url
+	UnsecureBaseMediaURL  cfgmodel.BaseURL // web/unsecure/base_media_url
+	SecureBaseURL         cfgmodel.BaseURL // web/secure/base_url
+	SecureBaseStaticURL   cfgmodel.BaseURL // web/secure/base_static_url
+	SecureBaseMediaURL    cfgmodel.BaseURL // web/secure/base_media_url
+
+	// SecureInFrontend selects the secure URLs. Path: web/secure/use_in_frontend
+	SecureInFrontend cfgmodel.Bool
+	// UseStoreCode adds the store code to the web URLs. Path: web/url/use_store
+	UseStoreCode cfgmodel.Bool
+	// UseFrontendSID appends the session ID to web URLs. Path:
+	// web/session/use_frontend_sid
+	UseFrontendSID cfgmodel.Bool
+}
+
+// NewURLConfig creates the URL configuration with the Magento paths. All
+// values can be set on store scope.
+func NewURLConfig(opts ...cfgmodel.Option) URLConfig {
+	opts = append([]cfgmodel.Option{cfgmodel.WithScopeStore()}, opts...)
+	return URLConfig{
+		DistroBaseURL:         cfgmodel.NewStr(config.PathCSBaseURL, opts...),
+		UnsecureBaseURL:       cfgmodel.NewBaseURL("web/unsecure/base_url", opts...),
+		UnsecureBaseStaticURL: cfgmodel.NewBaseURL("web/unsecure/base_static_url", opts...),
+		UnsecureBaseMediaURL:  cfgmodel.NewBaseURL("web/unsecure/base_media_url", opts...),
+		SecureBaseURL:         cfgmodel.NewBaseURL("web/secure/base_url", opts...),
+		SecureBaseStaticURL:   cfgmodel.NewBaseURL("web/secure/base_static_url", opts...),
+		SecureBaseMediaURL:    cfgmodel.NewBaseURL("web/secure/base_media_url", opts...),
+		SecureInFrontend:      cfgmodel.NewBool("web/secure/use_in_frontend", opts...),
+		UseStoreCode:          cfgmodel.NewBool("web/url/use_store", opts...),
+		UseFrontendSID:        cfgmodel.NewBool("web/session/use_frontend_sid", opts...),
+	}
+}
+
+// DefaultURLConfig gets used by Store.URLBuilder.
+var DefaultURLConfig = NewURLConfig()
+
+// URLBuilder composes the base, secure, static and media URLs of a Store.
+// Values in the configuration can contain the placeholders {{base_url}},
+// {{unsecure_base_url}} and {{secure_base_url}}. Empty values fall back to
+// the Magento defaults, e.g. the static URL becomes {{unsecure_base_url}}static/.
+type URLBuilder struct {
+	URLConfig
+	// Config scoped to the store.
+	Config config.Scoped
+	// StoreCode gets added to the path if web/url/use_store is enabled.
+	StoreCode string
+	// SID optional session ID, see SIDQueryName.
+	SID string
+}
+
+// URLBuilder returns a new URLBuilder bound to the configuration and the code
+// of the Store.
+func (s Store) URLBuilder() URLBuilder {
+	return URLBuilder{
+		URLConfig: DefaultURLConfig,
+		Config:    s.Config,
+		StoreCode: s.Code(),
+	}
+}
+
+// IsSecure returns true if the frontend must use the secure URLs.
+func (ub URLBuilder) IsSecure() (bool, error) {
+	if !ub.SecureInFrontend.IsSet() {
+		return false, nil
+	}
+	ok, err := ub.SecureInFrontend.Get(ub.Config)
+	return ok, errors.Wrap(err, "[store] URLBuilder.IsSecure")
+}
+
+// BaseURL returns the parsed base URL for a type with a trailing slash and all
+// placeholders replaced. Supported types are config.URLTypeWeb,
+// config.URLTypeStatic and config.URLTypeMedia. Error behaviour:
+// NotSupported, NotValid.
+func (ub URLBuilder) BaseURL(ut config.URLType, isSecure bool) (*url.URL, error) {
+	raw, err := ub.rawBaseURL(ut, isSecure, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "[store] URLBuilder.BaseURL")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.NewNotValid(err, "[store] URLBuilder.BaseURL.Parse")
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return nil, errors.NewNotValidf("[store] Base URL %q must be absolute", raw)
+	}
+	return u, nil
+}
+
+// URL creates an absolute URL for a path. The scheme depends on the setting
+// web/secure/use_in_frontend. For the type config.URLTypeWeb the store code
+// and the session ID get added, if enabled.
+func (ub URLBuilder) URL(ut config.URLType, path string) (*url.URL, error) {
+	isSecure, err := ub.IsSecure()
+	if err != nil {
+		return nil, errors.Wrap(err, "[store] URLBuilder.URL")
+	}
+	base, err := ub.BaseURL(ut, isSecure)
+	if err != nil {
+		return nil, errors.Wrap(err, "[store] URLBuilder.URL")
+	}
+
+	path = strings.TrimLeft(path, "/")
+	if ut == config.URLTypeWeb && ub.StoreCode != "" && ub.UseStoreCode.IsSet() {
+		useCode, err := ub.UseStoreCode.Get(ub.Config)
+		if err != nil {
+			return nil, errors.Wrap(err, "[store] URLBuilder.URL.UseStoreCode")
+		}
+		if useCode {
+			path = ub.StoreCode + "/" + path
+		}
+	}
+
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, errors.NewNotValid(err, "[store] URLBuilder.URL.Parse")
+	}
+	u := base.ResolveReference(ref)
+
+	if ut == config.URLTypeWeb && ub.SID != "" && ub.UseFrontendSID.IsSet() {
+		useSID, err := ub.UseFrontendSID.Get(ub.Config)
+		if err != nil {
+			return nil, errors.Wrap(err, "[store] URLBuilder.URL.UseFrontendSID")
+		}
+		if useSID {
+			q := u.Query()
+			q.Set(SIDQueryName, ub.SID)
+			u.RawQuery = q.Encode()
+		}
+	}
+	return u, nil
+}
+
+func (ub URLBuilder) rawBaseURL(ut config.URLType, isSecure bool, depth int) (string, error) {
+	if depth > maxPlaceholderDepth {
+		return "", errors.NewNotValidf("[store] Too many nested placeholders for URL type %d", ut)
+	}
+
+	var p cfgmodel.BaseURL
+	var fallback string
+	switch {
+	case ut == config.URLTypeWeb && isSecure:
+		p, fallback = ub.SecureBaseURL, cfgmodel.PlaceholderBaseURLUnSecure
+	case ut == config.URLTypeWeb:
+		p, fallback = ub.UnsecureBaseURL, cfgmodel.PlaceholderBaseURL
+	case ut == config.URLTypeStatic && isSecure:
+		p, fallback = ub.SecureBaseStaticURL, cfgmodel.PlaceholderBaseURLSecure+"static/"
+	case ut == config.URLTypeStatic:
+		p, fallback = ub.UnsecureBaseStaticURL, cfgmodel.PlaceholderBaseURLUnSecure+"static/"
+	case ut == config.URLTypeMedia && isSecure:
+		p, fallback = ub.SecureBaseMediaURL, cfgmodel.PlaceholderBaseURLSecure+"media/"
+	case ut == config.URLTypeMedia:
+		p, fallback = ub.UnsecureBaseMediaURL, cfgmodel.PlaceholderBaseURLUnSecure+"media/"
+	default:
+		return "", errors.NewNotSupportedf("[store] URL type %d not supported", ut)
+	}
+
+	raw := fallback
+	if p.IsSet() {
+		v, err := p.Get(ub.Config)
+		if err != nil {
+			return "", errors.Wrapf(err, "[store] Route %q", p.String())
+		}
+		if v = strings.TrimSpace(v); v != "" {
+			raw = v
+		}
+	}
+
+	var err error
+	if raw, err = ub.replacePlaceholder(raw, cfgmodel.PlaceholderBaseURLUnSecure, false, depth); err != nil {
+		return "", errors.Wrap(err, "[store] URLBuilder.replacePlaceholder")
+	}
+	if raw, err = ub.replacePlaceholder(raw, cfgmodel.PlaceholderBaseURLSecure, true, depth); err != nil {
+		return "", errors.Wrap(err, "[store] URLBuilder.replacePlaceholder")
+	}
+	if strings.Contains(raw, cfgmodel.PlaceholderBaseURL) {
+		distro := config.CSBaseURL
+		if ub.DistroBaseURL.IsSet() {
+			d, err := ub.DistroBaseURL.Get(ub.Config)
+			if err != nil {
+				return "", errors.Wrap(err, "[store] URLBuilder.DistroBaseURL")
+			}
+			if d != "" {
+				distro = d
+			}
+		}
+		raw = strings.Replace(raw, cfgmodel.PlaceholderBaseURL, strings.TrimRight(distro, "/")+"/", -1)
+	}
+	return strings.TrimRight(raw, "/") + "/", nil
+}
+
+func (ub URLBuilder) replacePlaceholder(raw, placeholder string, isSecure bool, depth int) (string, error) {
+	if !strings.Contains(raw, placeholder) {
+		return raw, nil
+	}
+	web, err := ub.rawBaseURL(config.URLTypeWeb, isSecure, depth+1)
+	if err != nil {
+		return "", errors.Wrap(err, "[store] URLBuilder.rawBaseURL")
+	}
+	return strings.Replace(raw, placeholder, web, -1), nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store_test
+
+import (
+	"testing"
+
+	"github.com/corestoreio/csfw/config"
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/store"
+	"github.com/corestoreio/csfw/util/null"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newURLTestStore(pv cfgmock.PathValue) store.Store {
+	return store.MustNewStore(
+		cfgmock.NewService(pv),
+		&store.TableStore{StoreID: 2, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 1, Name: "Germany", IsActive: true},
+		&store.TableWebsite{WebsiteID: 1, Code: null.StringFrom("euro"), Name: null.StringFrom("Europe")},
+		&store.TableGroup{GroupID: 1, WebsiteID: 1, Name: "DACH"},
+	)
+}
+
+func TestURLBuilder_BaseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		pv       cfgmock.PathValue
+		ut       config.URLType
+		isSecure bool
+		want     string
+	}{
+		{"empty config uses distro URL", cfgmock.PathValue{}, config.URLTypeWeb, false, config.CSBaseURL},
+		{"secure falls back to unsecure", cfgmock.PathValue{}, config.URLTypeWeb, true, config.CSBaseURL},
+		{"static default", cfgmock.PathValue{}, config.URLTypeStatic, false, config.CSBaseURL + "static/"},
+		{"media default", cfgmock.PathValue{}, config.URLTypeMedia, true, config.CSBaseURL + "media/"},
+		{"distro from config",
+			cfgmock.PathValue{"default/0/web/corestore/base_url": "https://corestore.io"},
+			config.URLTypeWeb, false, "https://corestore.io/"},
+		{"store scope overrides default",
+			cfgmock.PathValue{
+				"default/0/web/unsecure/base_url": "http://shop.io/",
+				"stores/2/web/unsecure/base_url":  "http://de.shop.io",
+			},
+			config.URLTypeWeb, false, "http://de.shop.io/"},
+		{"secure placeholder",
+			cfgmock.PathValue{
+				"default/0/web/secure/base_url":         "https://shop.io/",
+				"default/0/web/secure/base_media_url":   "{{secure_base_url}}pub/media/",
+				"default/0/web/unsecure/base_media_url": "http://cdn.shop.io/media/",
+			},
+			config.URLTypeMedia, true, "https://shop.io/pub/media/"},
+		{"unsecure media CDN",
+			cfgmock.PathValue{"default/0/web/unsecure/base_media_url": "http://cdn.shop.io/media"},
+			config.URLTypeMedia, false, "http://cdn.shop.io/media/"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u, err := newURLTestStore(test.pv).URLBuilder().BaseURL(test.ut, test.isSecure)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			assert.Exactly(t, test.want, u.String())
+		})
+	}
+}
+
+func TestURLBuilder_BaseURL_Errors(t *testing.T) {
+	t.Run("unsupported type", func(t *testing.T) {
+		_, err := newURLTestStore(cfgmock.PathValue{}).URLBuilder().BaseURL(config.URLTypeAbsent, false)
+		assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	})
+	t.Run("placeholder loop", func(t *testing.T) {
+		_, err := newURLTestStore(cfgmock.PathValue{
+			"default/0/web/secure/base_url":   "{{unsecure_base_url}}",
+			"default/0/web/unsecure/base_url": "{{secure_base_url}}",
+		}).URLBuilder().BaseURL(config.URLTypeWeb, false)
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+	t.Run("relative URL", func(t *testing.T) {
+		_, err := newURLTestStore(cfgmock.PathValue{
+			"default/0/web/unsecure/base_url": "/shop/",
+		}).URLBuilder().BaseURL(config.URLTypeWeb, false)
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+}
+
+func TestURLBuilder_URL(t *testing.T) {
+	pv := cfgmock.PathValue{
+		"default/0/web/unsecure/base_url":       "http://shop.io/",
+		"default/0/web/secure/base_url":         "https://shop.io/",
+		"stores/2/web/secure/use_in_frontend":   true,
+		"stores/2/web/url/use_store":            true,
+		"stores/2/web/session/use_frontend_sid": true,
+	}
+	ub := newURLTestStore(pv).URLBuilder()
+	ub.SID = "s3ss10n"
+
+	u, err := ub.URL(config.URLTypeWeb, "/checkout/cart?a=b")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, "https://shop.io/de/checkout/cart?SID=s3ss10n&a=b", u.String())
+
+	u, err = ub.URL(config.URLTypeStatic, "frontend/styles.css")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, "https://shop.io/static/frontend/styles.css", u.String())
+
+	ub = newURLTestStore(cfgmock.PathValue{"default/0/web/unsecure/base_url": "http://shop.io/"}).URLBuilder()
+	ub.SID = "s3ss10n"
+	u, err = ub.URL(config.URLTypeWeb, "customer/account")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.Exactly(t, "http://shop.io/customer/account", u.String())
+}
@@ -0,0 +1,278 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/errors"
+)
+
+// Validation issue kinds reported by the Validate functions. The values are
+// stable and can be used by monitoring systems to group the findings.
+const (
+	IssueDuplicateID          = "duplicate_id"
+	IssueDuplicateCode        = "duplicate_code"
+	IssueInvalidCode          = "invalid_code"
+	IssueDefaultWebsite       = "default_website"
+	IssueDefaultGroupMissing  = "default_group_missing"
+	IssueDefaultGroupParent   = "default_group_parent"
+	IssueDefaultStoreMissing  = "default_store_missing"
+	IssueDefaultStoreParent   = "default_store_parent"
+	IssueDefaultStoreInactive = "default_store_inactive"
+	IssueWebsiteMissing       = "website_missing"
+	IssueGroupMissing         = "group_missing"
+	IssueGroupParent          = "group_parent"
+)
+
+// ValidationIssue describes a single integrity violation found in the website,
+// group or store table data.
+type ValidationIssue struct {
+	// Kind one of the Issue* constants.
+	Kind string `json:"kind"`
+	// Scope in which the row with the error has been found.
+	Scope scope.Type `json:"scope"`
+	// ID of the website, group or store.
+	ID      int64  `json:"id"`
+	Message string `json:"message"`
+}
+
+// String implements fmt.Stringer.
+func (vi ValidationIssue) String() string {
+	return fmt.Sprintf("%s %d %s: %s", vi.Scope, vi.ID, vi.Kind, vi.Message)
+}
+
+// ValidationReport contains all integrity violations found while checking the
+// website, group and store table data. An empty Issues slice means the data is
+// consistent. The report can be serialized to JSON, for example in a health
+// check endpoint.
+type ValidationReport struct {
+	Websites int               `json:"websites"`
+	Groups   int               `json:"groups"`
+	Stores   int               `json:"stores"`
+	Issues   []ValidationIssue `json:"issues"`
+}
+
+func (r *ValidationReport) add(kind string, scp scope.Type, id int64, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{
+		Kind:    kind,
+		Scope:   scp,
+		ID:      id,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *ValidationReport) merge(o ValidationReport) {
+	r.Issues = append(r.Issues, o.Issues...)
+}
+
+// IsValid returns true when no issues have been found.
+func (r ValidationReport) IsValid() bool {
+	return len(r.Issues) == 0
+}
+
+// HasKind reports whether at least one issue of the provided kind exists.
+func (r ValidationReport) HasKind(kind string) bool {
+	for _, vi := range r.Issues {
+		if vi.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns all issues as one NotValid error or nil when the report is
+// valid.
+func (r ValidationReport) Err() error {
+	if r.IsValid() {
+		return nil
+	}
+	var buf bytes.Buffer
+	for i, vi := range r.Issues {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(vi.String())
+	}
+	return errors.NewNotValidf("[store] Validation failed with %d issue(s): %s", len(r.Issues), buf.String())
+}
+
+// Validate checks the websites for duplicate IDs, duplicate or invalid codes
+// and that exactly one website has been flagged as default.
+func (s TableWebsiteSlice) Validate() ValidationReport {
+	r := ValidationReport{Websites: len(s)}
+	ids := make(map[int64]bool, len(s))
+	codes := make(map[string]int64, len(s))
+	var defaults []int64
+	for _, w := range s {
+		if ids[w.WebsiteID] {
+			r.add(IssueDuplicateID, scope.Website, w.WebsiteID, "WebsiteID %d occurs more than once", w.WebsiteID)
+		}
+		ids[w.WebsiteID] = true
+
+		if w.Code.Valid {
+			if err := CodeIsValid(w.Code.String); err != nil {
+				r.add(IssueInvalidCode, scope.Website, w.WebsiteID, "Invalid code %q", w.Code.String)
+			}
+			if otherID, ok := codes[w.Code.String]; ok {
+				r.add(IssueDuplicateCode, scope.Website, w.WebsiteID, "Code %q already used by WebsiteID %d", w.Code.String, otherID)
+			} else {
+				codes[w.Code.String] = w.WebsiteID
+			}
+		}
+		if w.IsDefault.Valid && w.IsDefault.Bool {
+			defaults = append(defaults, w.WebsiteID)
+		}
+	}
+	switch {
+	case len(s) > 0 && len(defaults) == 0:
+		r.add(IssueDefaultWebsite, scope.Default, 0, "No default website found")
+	case len(defaults) > 1:
+		r.add(IssueDefaultWebsite, scope.Default, 0, "Multiple default websites found: %v", defaults)
+	}
+	return r
+}
+
+// Validate checks the groups for duplicate IDs.
+func (s TableGroupSlice) Validate() ValidationReport {
+	r := ValidationReport{Groups: len(s)}
+	ids := make(map[int64]bool, len(s))
+	for _, g := range s {
+		if ids[g.GroupID] {
+			r.add(IssueDuplicateID, scope.Group, g.GroupID, "GroupID %d occurs more than once", g.GroupID)
+		}
+		ids[g.GroupID] = true
+	}
+	return r
+}
+
+// Validate checks the stores for duplicate IDs and for duplicate or invalid
+// codes.
+func (s TableStoreSlice) Validate() ValidationReport {
+	r := ValidationReport{Stores: len(s)}
+	ids := make(map[int64]bool, len(s))
+	codes := make(map[string]int64, len(s))
+	for _, st := range s {
+		if ids[st.StoreID] {
+			r.add(IssueDuplicateID, scope.Store, st.StoreID, "StoreID %d occurs more than once", st.StoreID)
+		}
+		ids[st.StoreID] = true
+
+		if !st.Code.Valid {
+			continue
+		}
+		if err := CodeIsValid(st.Code.String); err != nil {
+			r.add(IssueInvalidCode, scope.Store, st.StoreID, "Invalid code %q", st.Code.String)
+		}
+		if otherID, ok := codes[st.Code.String]; ok {
+			r.add(IssueDuplicateCode, scope.Store, st.StoreID, "Code %q already used by StoreID %d", st.Code.String, otherID)
+		} else {
+			codes[st.Code.String] = st.StoreID
+		}
+	}
+	return r
+}
+
+// Validate checks the referential integrity between the websites, groups and
+// stores. Besides the checks of the slice Validate functions it verifies that
+// each DefaultGroupID and DefaultStoreID exists, belongs to its parent and
+// that the default store of a group is active. Every group and store must
+// point to an existing website and group.
+func (f *factory) Validate() ValidationReport {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	r := f.websites.Validate()
+	r.Groups = len(f.groups)
+	r.Stores = len(f.stores)
+	r.merge(f.groups.Validate())
+	r.merge(f.stores.Validate())
+
+	for _, w := range f.websites {
+		g, found := f.group(w.DefaultGroupID)
+		switch {
+		case !found:
+			r.add(IssueDefaultGroupMissing, scope.Website, w.WebsiteID, "DefaultGroupID %d not found", w.DefaultGroupID)
+		case g.WebsiteID != w.WebsiteID:
+			r.add(IssueDefaultGroupParent, scope.Website, w.WebsiteID, "DefaultGroupID %d belongs to WebsiteID %d", g.GroupID, g.WebsiteID)
+		}
+	}
+
+	for _, g := range f.groups {
+		if _, found := f.website(g.WebsiteID); !found {
+			r.add(IssueWebsiteMissing, scope.Group, g.GroupID, "WebsiteID %d not found", g.WebsiteID)
+		}
+		st, found := f.store(g.DefaultStoreID)
+		switch {
+		case !found:
+			r.add(IssueDefaultStoreMissing, scope.Group, g.GroupID, "DefaultStoreID %d not found", g.DefaultStoreID)
+		case st.GroupID != g.GroupID:
+			r.add(IssueDefaultStoreParent, scope.Group, g.GroupID, "DefaultStoreID %d belongs to GroupID %d", st.StoreID, st.GroupID)
+		case !st.IsActive:
+			r.add(IssueDefaultStoreInactive, scope.Group, g.GroupID, "DefaultStoreID %d is inactive", st.StoreID)
+		}
+	}
+
+	for _, st := range f.stores {
+		if _, found := f.website(st.WebsiteID); !found {
+			r.add(IssueWebsiteMissing, scope.Store, st.StoreID, "WebsiteID %d not found", st.WebsiteID)
+		}
+		g, found := f.group(st.GroupID)
+		switch {
+		case !found:
+			r.add(IssueGroupMissing, scope.Store, st.StoreID, "GroupID %d not found", st.GroupID)
+		case g.WebsiteID != st.WebsiteID:
+			r.add(IssueGroupParent, scope.Store, st.StoreID, "GroupID %d belongs to WebsiteID %d but store has WebsiteID %d", g.GroupID, g.WebsiteID, st.WebsiteID)
+		}
+	}
+	return r
+}
+
+// Validate runs the integrity checks on the raw website, group and store data.
+// See ValidationReport.
+func (s *Service) Validate() ValidationReport {
+	if s.backend == nil {
+		return ValidationReport{}
+	}
+	return s.backend.Validate()
+}
+
+// HealthHandler returns a handler which writes the ValidationReport as JSON.
+// The status code is 200 when the data is consistent otherwise 503. Mount it
+// on a health check route.
+func (s *Service) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rep := s.Validate()
+		if rep.Issues == nil {
+			rep.Issues = []ValidationIssue{}
+		}
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(rep); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		code := http.StatusOK
+		if !rep.IsValid() {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(code)
+		_, _ = buf.WriteTo(w)
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corestoreio/csfw/config/cfgmock"
+	"github.com/corestoreio/csfw/store/scope"
+	"github.com/corestoreio/csfw/util/null"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactory_Validate_Valid(t *testing.T) {
+	rep := testFactory.Validate()
+	assert.True(t, rep.IsValid(), "%v", rep.Issues)
+	assert.NoError(t, rep.Err())
+	assert.Exactly(t, 3, rep.Websites)
+	assert.Exactly(t, 4, rep.Groups)
+	assert.Exactly(t, 7, rep.Stores)
+}
+
+func TestFactory_Validate_Issues(t *testing.T) {
+	f := mustNewFactory(
+		cfgmock.NewService(),
+		WithTableWebsites(
+			&TableWebsite{WebsiteID: 1, Code: null.StringFrom("euro"), DefaultGroupID: 1, IsDefault: null.BoolFrom(true)},
+			&TableWebsite{WebsiteID: 2, Code: null.StringFrom("euro"), DefaultGroupID: 9, IsDefault: null.BoolFrom(true)},
+			&TableWebsite{WebsiteID: 3, Code: null.StringFrom("1nvalid"), DefaultGroupID: 2},
+		),
+		WithTableGroups(
+			&TableGroup{GroupID: 1, WebsiteID: 1, DefaultStoreID: 2},
+			&TableGroup{GroupID: 2, WebsiteID: 1, DefaultStoreID: 3},
+			&TableGroup{GroupID: 4, WebsiteID: 7, DefaultStoreID: 99},
+		),
+		WithTableStores(
+			&TableStore{StoreID: 1, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 1, IsActive: true},
+			&TableStore{StoreID: 2, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 1, IsActive: false},
+			&TableStore{StoreID: 3, Code: null.StringFrom("at"), WebsiteID: 2, GroupID: 1, IsActive: true},
+			&TableStore{StoreID: 4, Code: null.StringFrom("ch"), WebsiteID: 8, GroupID: 5, IsActive: true},
+		),
+	)
+	rep := f.Validate()
+	assert.False(t, rep.IsValid())
+	assert.True(t, errors.IsNotValid(rep.Err()), "%+v", rep.Err())
+
+	tests := []struct {
+		kind string
+		scp  scope.Type
+		id   int64
+	}{
+		{IssueDuplicateCode, scope.Website, 2},
+		{IssueInvalidCode, scope.Website, 3},
+		{IssueDefaultWebsite, scope.Default, 0},
+		{IssueDefaultGroupMissing, scope.Website, 2},
+		{IssueDefaultGroupParent, scope.Website, 3},
+		{IssueDuplicateCode, scope.Store, 2},
+		{IssueDefaultStoreInactive, scope.Group, 1},
+		{IssueDefaultStoreParent, scope.Group, 2},
+		{IssueWebsiteMissing, scope.Group, 4},
+		{IssueDefaultStoreMissing, scope.Group, 4},
+		{IssueGroupParent, scope.Store, 3},
+		{IssueWebsiteMissing, scope.Store, 4},
+		{IssueGroupMissing, scope.Store, 4},
+	}
+	for i, test := range tests {
+		var found bool
+		for _, vi := range rep.Issues {
+			if vi.Kind == test.kind && vi.Scope == test.scp && vi.ID == test.id {
+				found = true
+			}
+		}
+		assert.True(t, found, "Index %d: missing %s for %s %d in %v", i, test.kind, test.scp, test.id, rep.Issues)
+	}
+	assert.Len(t, rep.Issues, len(tests))
+}
+
+func TestTableSlices_Validate(t *testing.T) {
+	assert.True(t, TableWebsiteSlice{}.Validate().IsValid())
+
+	rep := TableWebsiteSlice{
+		&TableWebsite{WebsiteID: 1, Code: null.StringFrom("euro")},
+	}.Validate()
+	assert.True(t, rep.HasKind(IssueDefaultWebsite))
+
+	rep = TableGroupSlice{
+		&TableGroup{GroupID: 1},
+		&TableGroup{GroupID: 1},
+	}.Validate()
+	assert.True(t, rep.HasKind(IssueDuplicateID))
+	assert.Exactly(t, 2, rep.Groups)
+
+	rep = TableStoreSlice{
+		&TableStore{StoreID: 1, Code: null.StringFrom("de")},
+		&TableStore{StoreID: 1, Code: null.StringFrom("at")},
+		&TableStore{StoreID: 2},
+	}.Validate()
+	assert.True(t, rep.HasKind(IssueDuplicateID))
+	assert.False(t, rep.HasKind(IssueInvalidCode), "NULL codes are allowed")
+}
+
+func TestService_HealthHandler(t *testing.T) {
+	srv, err := NewService(cfgmock.NewService(),
+		WithTableWebsites(&TableWebsite{WebsiteID: 1, Code: null.StringFrom("euro"), DefaultGroupID: 1, IsDefault: null.BoolFrom(true)}),
+		WithTableGroups(&TableGroup{GroupID: 1, WebsiteID: 1, DefaultStoreID: 1}),
+		WithTableStores(&TableStore{StoreID: 1, Code: null.StringFrom("de"), WebsiteID: 1, GroupID: 1, IsActive: true}),
+	)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	srv.HealthHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/health/store", nil))
+	assert.Exactly(t, http.StatusOK, rec.Code)
+
+	var rep ValidationReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rep))
+	assert.Exactly(t, 1, rep.Stores)
+	assert.Empty(t, rep.Issues)
+
+	srv.backend.stores[0].IsActive = false
+	rec = httptest.NewRecorder()
+	srv.HealthHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/health/store", nil))
+	assert.Exactly(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), IssueDefaultStoreInactive)
+}
@@ -0,0 +1,80 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command jwtclaimgen generates a custom claim type from a JSON schema.
+//
+// Example usage:
+//     jwtclaimgen -schema customer.json -pkg claims -out customer_claim.go
+//     cat customer.json | jwtclaimgen -schema - -pkg claims -type Customer -ffjson
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/corestoreio/csfw/util/csjwt/jwtclaim/claimgen"
+)
+
+var (
+	flagSchema  = flag.String("schema", "-", "path to the JSON schema file or '-' to read from stdin")
+	flagPackage = flag.String("pkg", "", "package name of the generated file")
+	flagType    = flag.String("type", "", "name of the generated type, defaults to the schema title")
+	flagOut     = flag.String("out", "", "path of the generated file, defaults to stdout")
+	flagFFJSON  = flag.Bool("ffjson", false, "add a go:generate directive for ffjson")
+)
+
+func main() {
+	flag.Parse()
+	if *flagPackage == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var r io.Reader = os.Stdin
+	if *flagSchema != "-" {
+		f, err := os.Open(*flagSchema)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	s, err := claimgen.ParseSchema(r)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *flagOut != "" {
+		f, err := os.Create(*flagOut)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return claimgen.Generate(w, claimgen.Options{
+		Package:  *flagPackage,
+		TypeName: *flagType,
+		FFJSON:   *flagFFJSON,
+	}, s)
+}
@@ -0,0 +1,192 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csjwt
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/corestoreio/csfw/storage/text"
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/errors"
+)
+
+// claimExpiresAt same as jwtclaim.KeyExpiresAt, copied to avoid an import
+// cycle.
+const claimExpiresAt = "exp"
+
+// SameSite defines the SameSite attribute of a cookie. It protects against
+// cross-site request forgery by restricting when the browser sends the cookie.
+type SameSite uint8
+
+// SameSite* constants define the available attribute values. SameSiteDefault
+// omits the attribute.
+const (
+	SameSiteDefault SameSite = iota
+	SameSiteLax
+	SameSiteStrict
+)
+
+// String returns the attribute value or an empty string.
+func (s SameSite) String() string {
+	switch s {
+	case SameSiteLax:
+		return "Lax"
+	case SameSiteStrict:
+		return "Strict"
+	}
+	return ""
+}
+
+// Cookie transports a token in an HTTP cookie. The expiry of the cookie gets
+// synced with the "exp" claim of the token. Use NewCookie to get secure
+// defaults.
+type Cookie struct {
+	// Name of the cookie. Defaults to HTTPFormInputName which is also the
+	// default CookieName of the Verification type.
+	Name string
+	// Path optional, defaults to "/".
+	Path string
+	// Domain optional, see function WithBaseURL.
+	Domain string
+	// Secure sends the cookie only over HTTPS connections.
+	Secure bool
+	// HTTPOnly denies JavaScript the access to the cookie.
+	HTTPOnly bool
+	SameSite SameSite
+}
+
+// NewCookie creates a new cookie configuration with the name
+// HTTPFormInputName, path "/" and enabled Secure, HTTPOnly and SameSite=Lax
+// flags.
+func NewCookie() Cookie {
+	return Cookie{
+		Name:     HTTPFormInputName,
+		Path:     "/",
+		Secure:   true,
+		HTTPOnly: true,
+		SameSite: SameSiteLax,
+	}
+}
+
+// WithBaseURL returns a copy of the cookie with the Domain and Path taken
+// from the base URL of a store. An http scheme disables the Secure flag.
+// Error behaviour: NotValid
+func (c Cookie) WithBaseURL(baseURL string) (Cookie, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return c, errors.NewNotValid(err, "[csjwt] Cookie.WithBaseURL.url.Parse")
+	}
+	if u.Hostname() == "" {
+		return c, errors.NewNotValidf(errCookieBaseURLHostEmpty, baseURL)
+	}
+	c.Domain = u.Hostname()
+	c.Path = u.Path
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	c.Secure = u.Scheme == "https"
+	return c, nil
+}
+
+// Write signs the token and adds it as cookie to the response. Expires and
+// Max-Age of the cookie match the "exp" claim. A token without "exp" claim
+// becomes a session cookie. Error behaviour: NotValid or from the signing
+// process.
+func (c Cookie) Write(w http.ResponseWriter, t Token, method Signer, key Key) error {
+	exp := t.Claims.Expires()
+	if exp <= 0 && hasExpiresAt(t.Claims) {
+		return errors.NewNotValidf(errCookieTokenExpired)
+	}
+	raw, err := t.SignedString(method, key)
+	if err != nil {
+		return errors.Wrap(err, "[csjwt] Cookie.Write.SignedString")
+	}
+	c.writeRaw(w, raw, exp)
+	return nil
+}
+
+// WriteRaw adds an already signed token as cookie to the response. The
+// expires argument defines the lifetime of the cookie, zero creates a session
+// cookie.
+func (c Cookie) WriteRaw(w http.ResponseWriter, rawToken text.Chars, expires time.Duration) {
+	c.writeRaw(w, rawToken, expires)
+}
+
+// Delete instructs the browser to remove the cookie.
+func (c Cookie) Delete(w http.ResponseWriter) {
+	c.writeRaw(w, nil, -1)
+}
+
+func (c Cookie) writeRaw(w http.ResponseWriter, rawToken text.Chars, expires time.Duration) {
+	hc := &http.Cookie{
+		Name:     c.Name,
+		Value:    rawToken.String(),
+		Path:     c.Path,
+		Domain:   c.Domain,
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly,
+	}
+	if hc.Name == "" {
+		hc.Name = HTTPFormInputName
+	}
+	switch {
+	case expires < 0:
+		hc.MaxAge = -1
+		hc.Expires = time.Unix(1, 0)
+	case expires > 0:
+		hc.MaxAge = int((expires + time.Second - 1) / time.Second) // round up
+		hc.Expires = TimeFunc().Add(expires).UTC()
+	}
+
+	v := hc.String()
+	if ss := c.SameSite.String(); ss != "" {
+		v += "; SameSite=" + ss
+	}
+	w.Header().Add("Set-Cookie", v)
+}
+
+// Extract returns the raw token stored in the cookie of the request. Error
+// behaviour: NotFound
+func (c Cookie) Extract(r *http.Request) (text.Chars, error) {
+	name := c.Name
+	if name == "" {
+		name = HTTPFormInputName
+	}
+	keks, err := r.Cookie(name)
+	if err != nil || keks.Value == "" {
+		return nil, errors.NewNotFoundf(errTokenNotInRequest)
+	}
+	return text.Chars(keks.Value), nil
+}
+
+// Parse extracts the token from the cookie and parses it with the
+// Verification into dst. Error behaviour: NotFound, NotValid, Empty
+func (c Cookie) Parse(vf *Verification, dst *Token, keyFunc Keyfunc, r *http.Request) error {
+	raw, err := c.Extract(r)
+	if err != nil {
+		return errors.Wrap(err, "[csjwt] Cookie.Parse.Extract")
+	}
+	return vf.Parse(dst, raw, keyFunc)
+}
+
+func hasExpiresAt(c Claimer) bool {
+	v, err := c.Get(claimExpiresAt)
+	if err != nil || v == nil {
+		return false
+	}
+	return conv.ToInt64(v) > 0
+}
@@ -0,0 +1,132 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csjwt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/util/csjwt"
+	"github.com/corestoreio/csfw/util/csjwt/jwtclaim"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCookie_WithBaseURL(t *testing.T) {
+	tests := []struct {
+		baseURL    string
+		wantDomain string
+		wantPath   string
+		wantSecure bool
+		wantErrBhf errors.BehaviourFunc
+	}{
+		{"https://www.corestore.io/", "www.corestore.io", "/", true, nil},
+		{"http://de.shop.io:8080/de", "de.shop.io", "/de", false, nil},
+		{"https://shop.io", "shop.io", "/", true, nil},
+		{"/relative/path", "", "", false, errors.IsNotValid},
+		{"http://[::1]:namedport", "", "", false, errors.IsNotValid},
+	}
+	for i, test := range tests {
+		c, err := csjwt.NewCookie().WithBaseURL(test.baseURL)
+		if test.wantErrBhf != nil {
+			assert.True(t, test.wantErrBhf(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.wantDomain, c.Domain, "Index %d", i)
+		assert.Exactly(t, test.wantPath, c.Path, "Index %d", i)
+		assert.Exactly(t, test.wantSecure, c.Secure, "Index %d", i)
+	}
+}
+
+func TestCookie_WriteParse(t *testing.T) {
+	hs256 := csjwt.NewSigningMethodHS256()
+	pw := csjwt.WithPassword([]byte(`Rump3lst!lzch3n`))
+
+	claim := jwtclaim.NewStore()
+	claim.ExpiresAt = time.Now().Add(time.Hour).Unix()
+	claim.Store = "de"
+
+	c := csjwt.NewCookie()
+	c.Domain = "corestore.io"
+	c.SameSite = csjwt.SameSiteStrict
+
+	rec := httptest.NewRecorder()
+	if err := c.Write(rec, csjwt.NewToken(claim), hs256, pw); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	sc := rec.Header().Get("Set-Cookie")
+	assert.Contains(t, sc, csjwt.HTTPFormInputName+"=")
+	assert.Contains(t, sc, "Domain=corestore.io")
+	assert.Contains(t, sc, "HttpOnly")
+	assert.Contains(t, sc, "Secure")
+	assert.Contains(t, sc, "SameSite=Strict")
+	assert.Contains(t, sc, "Max-Age=3600")
+
+	resp := http.Response{Header: rec.Header()}
+	req := httptest.NewRequest("GET", "https://corestore.io/", nil)
+	for _, keks := range resp.Cookies() {
+		req.AddCookie(keks)
+	}
+
+	vf := csjwt.NewVerification(hs256)
+	have := csjwt.NewToken(jwtclaim.NewStore())
+	if err := c.Parse(vf, &have, csjwt.NewKeyFunc(hs256, pw), req); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	assert.True(t, have.Valid)
+	assert.Exactly(t, "de", have.Claims.(*jwtclaim.Store).Store)
+
+	// the default Verification reads the same cookie name
+	have = csjwt.NewToken(jwtclaim.NewStore())
+	vf.CookieName = csjwt.HTTPFormInputName
+	assert.NoError(t, vf.ParseFromRequest(&have, csjwt.NewKeyFunc(hs256, pw), req))
+}
+
+func TestCookie_Write_SessionAndExpired(t *testing.T) {
+	hs256 := csjwt.NewSigningMethodHS256()
+	pw := csjwt.WithPassword([]byte(`Rump3lst!lzch3n`))
+
+	rec := httptest.NewRecorder()
+	err := csjwt.NewCookie().Write(rec, csjwt.NewToken(jwtclaim.Map{"name": "Gopher"}), hs256, pw)
+	assert.NoError(t, err)
+	sc := rec.Header().Get("Set-Cookie")
+	assert.NotContains(t, sc, "Max-Age")
+	assert.NotContains(t, sc, "Expires")
+
+	claim := jwtclaim.NewStore()
+	claim.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	rec = httptest.NewRecorder()
+	err = csjwt.NewCookie().Write(rec, csjwt.NewToken(claim), hs256, pw)
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	assert.Empty(t, rec.Header().Get("Set-Cookie"))
+}
+
+func TestCookie_Delete(t *testing.T) {
+	rec := httptest.NewRecorder()
+	csjwt.NewCookie().Delete(rec)
+	sc := rec.Header().Get("Set-Cookie")
+	assert.Contains(t, sc, "Max-Age=0")
+	assert.Contains(t, sc, "Expires=Thu, 01 Jan 1970")
+}
+
+func TestCookie_Extract_NotFound(t *testing.T) {
+	raw, err := csjwt.NewCookie().Extract(httptest.NewRequest("GET", "/", nil))
+	assert.Nil(t, raw)
+	assert.True(t, errors.IsNotFound(err), "%+v", err)
+}
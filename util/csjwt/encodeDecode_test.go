@@ -128,4 +128,7 @@ func BenchmarkTokenDecode(b *testing.B) {
 	b.Run("Json_HS256", func(b *testing.B) {
 		testRunner(b, csjwt.JSONEncoding{})
 	})
+	b.Run("FastJson_HS256", func(b *testing.B) {
+		testRunner(b, csjwt.FastJSONEncoding{})
+	})
 }
@@ -0,0 +1,266 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csjwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+
+	"github.com/corestoreio/errors"
+)
+
+// FieldUnmarshaler can be implemented by headers and claims to get decoded
+// by FastJSONEncoding without reflection. UnmarshalJWTField gets called for
+// each top level key of the JSON object with the raw JSON value of that key.
+// Both byte slices are only valid during the call and must be copied, which
+// the ParseJSON* functions do. Unknown keys should be ignored.
+type FieldUnmarshaler interface {
+	UnmarshalJWTField(key, value []byte) error
+}
+
+// FastJSONEncoding decodes the base64 segment into a pooled buffer and scans
+// the JSON object manually if the destination implements FieldUnmarshaler.
+// Other destinations fall back to encoding/json. Serialize uses encoding/json.
+type FastJSONEncoding struct{}
+
+// Deserialize decodes a JWT segment into dst. Error behaviour: NotValid.
+func (FastJSONEncoding) Deserialize(src []byte, dst interface{}) error {
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+
+	n := base64.RawURLEncoding.DecodedLen(len(src))
+	buf.Grow(n)
+	dec := buf.Bytes()[:n]
+	n, err := base64.RawURLEncoding.Decode(dec, src)
+	if err != nil {
+		return errors.NewNotValid(err, "[csjwt] FastJSONEncoding.Deserialize.DecodeSegment")
+	}
+	dec = dec[:n]
+
+	fu, ok := dst.(FieldUnmarshaler)
+	if !ok {
+		return errors.Wrap(json.Unmarshal(dec, dst), "[csjwt] FastJSONEncoding.Deserialize.Unmarshal")
+	}
+	return errors.Wrap(scanJSONObject(dec, fu.UnmarshalJWTField), "[csjwt] FastJSONEncoding.Deserialize.Scan")
+}
+
+// Serialize encodes a value using encoding/json.
+func (FastJSONEncoding) Serialize(src interface{}) ([]byte, error) {
+	return JSONEncoding{}.Serialize(src)
+}
+
+const errJSONSyntax = "[csjwt] Invalid JSON at offset %d: %q"
+
+// scanJSONObject iterates over the key/value pairs of a JSON object. Nested
+// objects and arrays are passed as raw values.
+func scanJSONObject(data []byte, fn func(key, value []byte) error) error {
+	pos := skipWS(data, 0)
+	if pos >= len(data) || data[pos] != '{' {
+		return errors.NewNotValidf(errJSONSyntax, pos, "expected {")
+	}
+	pos = skipWS(data, pos+1)
+	if pos < len(data) && data[pos] == '}' {
+		return nil
+	}
+	for pos < len(data) {
+		end, err := scanJSONValue(data, pos)
+		if err != nil {
+			return errors.Wrap(err, "[csjwt] scanJSONObject.Key")
+		}
+		if data[pos] != '"' {
+			return errors.NewNotValidf(errJSONSyntax, pos, "expected key")
+		}
+		key := data[pos+1 : end-1]
+		if bytes.IndexByte(key, '\\') >= 0 {
+			var k string
+			if err := json.Unmarshal(data[pos:end], &k); err != nil {
+				return errors.NewNotValid(err, "[csjwt] scanJSONObject.Key.Unmarshal")
+			}
+			key = []byte(k)
+		}
+
+		pos = skipWS(data, end)
+		if pos >= len(data) || data[pos] != ':' {
+			return errors.NewNotValidf(errJSONSyntax, pos, "expected :")
+		}
+		pos = skipWS(data, pos+1)
+		if end, err = scanJSONValue(data, pos); err != nil {
+			return errors.Wrap(err, "[csjwt] scanJSONObject.Value")
+		}
+		if err := fn(key, data[pos:end]); err != nil {
+			return errors.Wrapf(err, "[csjwt] scanJSONObject.Field %q", key)
+		}
+
+		pos = skipWS(data, end)
+		if pos >= len(data) {
+			break
+		}
+		switch data[pos] {
+		case ',':
+			pos = skipWS(data, pos+1)
+		case '}':
+			if skipWS(data, pos+1) != len(data) {
+				return errors.NewNotValidf(errJSONSyntax, pos+1, "data after object")
+			}
+			return nil
+		default:
+			return errors.NewNotValidf(errJSONSyntax, pos, "expected , or }")
+		}
+	}
+	return errors.NewNotValidf(errJSONSyntax, len(data), "unexpected end")
+}
+
+// scanJSONValue returns the position after the value starting at pos.
+func scanJSONValue(data []byte, pos int) (int, error) {
+	if pos >= len(data) {
+		return 0, errors.NewNotValidf(errJSONSyntax, pos, "unexpected end")
+	}
+	switch c := data[pos]; c {
+	case '"':
+		for i := pos + 1; i < len(data); i++ {
+			switch data[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, nil
+			}
+		}
+		return 0, errors.NewNotValidf(errJSONSyntax, pos, "unterminated string")
+	case '{', '[':
+		depth := 0
+		for i := pos; i < len(data); i++ {
+			switch data[i] {
+			case '"':
+				end, err := scanJSONValue(data, i)
+				if err != nil {
+					return 0, err
+				}
+				i = end - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1, nil
+				}
+			}
+		}
+		return 0, errors.NewNotValidf(errJSONSyntax, pos, "unterminated "+string(c))
+	}
+	i := pos
+	for i < len(data) {
+		c := data[i]
+		if c == ',' || c == '}' || c == ']' || c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			break
+		}
+		i++
+	}
+	if i == pos {
+		return 0, errors.NewNotValidf(errJSONSyntax, pos, "empty value")
+	}
+	return i, nil
+}
+
+func skipWS(data []byte, pos int) int {
+	for pos < len(data) {
+		switch data[pos] {
+		case ' ', '\t', '\n', '\r':
+			pos++
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// ParseJSONString converts a raw JSON string value into a Go string. A JSON
+// null returns an empty string. Error behaviour: NotValid.
+func ParseJSONString(raw []byte) (string, error) {
+	if isJSONNull(raw) {
+		return "", nil
+	}
+	if len(raw) < 2 || raw[0] != '"' || raw[len(raw)-1] != '"' {
+		return "", errors.NewNotValidf("[csjwt] ParseJSONString: Expecting a string, got %q", raw)
+	}
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw[1 : len(raw)-1]), nil
+	}
+	var s string
+	err := json.Unmarshal(raw, &s)
+	return s, errors.NewNotValid(err, "[csjwt] ParseJSONString.Unmarshal")
+}
+
+// ParseJSONInt64 converts a raw JSON number into an int64. Numbers with a
+// fraction or exponent get truncated. A JSON null returns zero. Error
+// behaviour: NotValid.
+func ParseJSONInt64(raw []byte) (int64, error) {
+	if isJSONNull(raw) {
+		return 0, nil
+	}
+	i, err := strconv.ParseInt(string(raw), 10, 64)
+	if err == nil {
+		return i, nil
+	}
+	f, err := strconv.ParseFloat(string(raw), 64)
+	if err != nil {
+		return 0, errors.NewNotValid(err, "[csjwt] ParseJSONInt64")
+	}
+	return int64(f), nil
+}
+
+// ParseJSONValue converts a raw JSON value into the same Go types as
+// encoding/json does when decoding into an interface{}. Error behaviour:
+// NotValid.
+func ParseJSONValue(raw []byte) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, errors.NewNotValidf("[csjwt] ParseJSONValue: Empty value")
+	}
+	switch raw[0] {
+	case '"':
+		return ParseJSONString(raw)
+	case 't', 'f':
+		b, err := strconv.ParseBool(string(raw))
+		return b, errors.NewNotValid(err, "[csjwt] ParseJSONValue.ParseBool")
+	case 'n':
+		if isJSONNull(raw) {
+			return nil, nil
+		}
+	case '{', '[':
+		var v interface{}
+		err := json.Unmarshal(raw, &v)
+		return v, errors.NewNotValid(err, "[csjwt] ParseJSONValue.Unmarshal")
+	default:
+		f, err := strconv.ParseFloat(string(raw), 64)
+		return f, errors.NewNotValid(err, "[csjwt] ParseJSONValue.ParseFloat")
+	}
+	return nil, errors.NewNotValidf("[csjwt] ParseJSONValue: Invalid value %q", raw)
+}
+
+func isJSONNull(raw []byte) bool {
+	return len(raw) == 4 && raw[0] == 'n' && raw[1] == 'u' && raw[2] == 'l' && raw[3] == 'l'
+}
+
+// UnmarshalJWTField implements the FieldUnmarshaler interface.
+func (s *Head) UnmarshalJWTField(key, value []byte) (err error) {
+	switch string(key) {
+	case headerAlg:
+		s.Algorithm, err = ParseJSONString(value)
+	case headerTyp:
+		s.Type, err = ParseJSONString(value)
+	}
+	return err
+}
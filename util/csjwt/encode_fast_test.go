@@ -0,0 +1,197 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csjwt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corestoreio/csfw/util/csjwt"
+	"github.com/corestoreio/csfw/util/csjwt/jwtclaim"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ csjwt.Deserializer = (*csjwt.FastJSONEncoding)(nil)
+var _ csjwt.Serializer = (*csjwt.FastJSONEncoding)(nil)
+var _ csjwt.FieldUnmarshaler = (*csjwt.Head)(nil)
+var _ csjwt.FieldUnmarshaler = (*jwtclaim.HeadSegments)(nil)
+var _ csjwt.FieldUnmarshaler = (*jwtclaim.Standard)(nil)
+var _ csjwt.FieldUnmarshaler = (*jwtclaim.Store)(nil)
+var _ csjwt.FieldUnmarshaler = (jwtclaim.Map)(nil)
+
+type fieldRecorder map[string]string
+
+func (fr fieldRecorder) UnmarshalJWTField(key, value []byte) error {
+	fr[string(key)] = string(value)
+	return nil
+}
+
+func TestFastJSONEncoding_Scan(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    fieldRecorder
+		wantErr bool
+	}{
+		{`{}`, fieldRecorder{}, false},
+		{` { "a" : 1 , "b":"x,}\"y" } `, fieldRecorder{"a": "1", "b": `"x,}\"y"`}, false},
+		{`{"o":{"a":[1,{"b":"]}"}]},"n":null,"t":true,"f":-1.5e3}`, fieldRecorder{"o": `{"a":[1,{"b":"]}"}]}`, "n": "null", "t": "true", "f": "-1.5e3"}, false},
+		{`{"ab":1}`, fieldRecorder{"ab": "1"}, false},
+		{``, nil, true},
+		{`[]`, nil, true},
+		{`{"a":1`, nil, true},
+		{`{"a" 1}`, nil, true},
+		{`{"a":1 "b":2}`, nil, true},
+		{`{"a":"1}`, nil, true},
+		{`{"a":{"b":1}`, nil, true},
+		{`{a:1}`, nil, true},
+		{`{"a":1}x`, nil, true},
+	}
+	for i, test := range tests {
+		have := fieldRecorder{}
+		err := csjwt.FastJSONEncoding{}.Deserialize(csjwt.EncodeSegment([]byte(test.json)), have)
+		if test.wantErr {
+			assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+
+	err := csjwt.FastJSONEncoding{}.Deserialize([]byte("!!"), fieldRecorder{})
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestParseJSONValue(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{`"abc"`, "abc", false},
+		{`"a\"bä"`, "a\"bä", false},
+		{`null`, nil, false},
+		{`true`, true, false},
+		{`false`, false, false},
+		{`12`, float64(12), false},
+		{`-1.5e2`, float64(-150), false},
+		{`[1,"a"]`, []interface{}{float64(1), "a"}, false},
+		{`{"a":1}`, map[string]interface{}{"a": float64(1)}, false},
+		{`nul`, nil, true},
+		{`tru`, nil, true},
+		{`1x`, nil, true},
+		{``, nil, true},
+	}
+	for i, test := range tests {
+		have, err := csjwt.ParseJSONValue([]byte(test.raw))
+		if test.wantErr {
+			assert.True(t, errors.IsNotValid(err), "Index %d => %+v", i, err)
+			continue
+		}
+		assert.NoError(t, err, "Index %d", i)
+		assert.Exactly(t, test.want, have, "Index %d", i)
+	}
+
+	i, err := csjwt.ParseJSONInt64([]byte(`1.48e9`))
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(1480000000), i)
+	i, err = csjwt.ParseJSONInt64([]byte(`null`))
+	assert.NoError(t, err)
+	assert.Exactly(t, int64(0), i)
+	_, err = csjwt.ParseJSONInt64([]byte(`"1"`))
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+	_, err = csjwt.ParseJSONString([]byte(`1`))
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+func TestFastJSONEncoding_Parse(t *testing.T) {
+	m := csjwt.NewSigningMethodHS256()
+	pw := csjwt.WithPasswordRandom()
+	exp := time.Now().Add(time.Hour).Unix()
+
+	t.Run("Store", func(t *testing.T) {
+		sc := jwtclaim.NewStore()
+		sc.Store = "ch-de"
+		sc.UserID = "eé\"1"
+		sc.ExpiresAt = exp
+		sc.Audience = "LOTR"
+		tk := csjwt.NewToken(sc)
+		tk.Header = jwtclaim.NewHeadSegments()
+		tkChar, err := tk.SignedString(m, pw)
+		require.NoError(t, err)
+
+		vrf := csjwt.NewVerification(m)
+		vrf.Deserializer = csjwt.FastJSONEncoding{}
+		newTk := csjwt.NewToken(&jwtclaim.Store{})
+		newTk.Header = jwtclaim.NewHeadSegments()
+		require.NoError(t, vrf.Parse(&newTk, tkChar, csjwt.NewKeyFunc(m, pw)))
+		assert.True(t, newTk.Valid)
+		assert.Exactly(t, sc, newTk.Claims)
+		assert.Exactly(t, "HS256", newTk.Header.Alg())
+	})
+
+	t.Run("Map", func(t *testing.T) {
+		tk := csjwt.NewToken(jwtclaim.Map{"exp": exp, "roles": []string{"a", "b"}, "admin": true})
+		tkChar, err := tk.SignedString(m, pw)
+		require.NoError(t, err)
+
+		vrf := csjwt.NewVerification(m)
+		vrf.Deserializer = csjwt.FastJSONEncoding{}
+		fastTk := csjwt.NewToken(&jwtclaim.Map{})
+		require.NoError(t, vrf.Parse(&fastTk, tkChar, csjwt.NewKeyFunc(m, pw)))
+
+		vrf.Deserializer = csjwt.JSONEncoding{}
+		stdTk := csjwt.NewToken(&jwtclaim.Map{})
+		require.NoError(t, vrf.Parse(&stdTk, tkChar, csjwt.NewKeyFunc(m, pw)))
+
+		assert.Exactly(t, stdTk.Claims, fastTk.Claims)
+		assert.Exactly(t, stdTk.Header, fastTk.Header)
+	})
+}
+
+func BenchmarkTokenDecode_Map(b *testing.B) {
+	var testRunner = func(b *testing.B, dec csjwt.Deserializer) {
+		tk := csjwt.NewToken(jwtclaim.Map{
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"store": "ch-de",
+			"admin": true,
+		})
+		m := csjwt.NewSigningMethodHS256()
+		pw := csjwt.WithPasswordRandom()
+		tkChar, err := tk.SignedString(m, pw)
+		if err != nil {
+			b.Fatalf("%+v", err)
+		}
+		vrf := csjwt.NewVerification(m)
+		vrf.Deserializer = dec
+		kf := csjwt.NewKeyFunc(m, pw)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			newTk := csjwt.NewToken(&jwtclaim.Map{})
+			if err := vrf.Parse(&newTk, tkChar, kf); err != nil {
+				b.Fatalf("%+v", err)
+			}
+		}
+	}
+	b.Run("Json_HS256", func(b *testing.B) {
+		testRunner(b, csjwt.JSONEncoding{})
+	})
+	b.Run("FastJson_HS256", func(b *testing.B) {
+		testRunner(b, csjwt.FastJSONEncoding{})
+	})
+}
@@ -40,6 +40,18 @@ const (
 	errKeyMustBePEMEncoded           = "[csjwt] invalid key: Key must be PEM encoded PKCS1 or PKCS8 private key"
 	errKeyNonECDSAPublicKey          = "[csjwt] invalid key: Not a valid ECDSA public key"
 	errKeyNonRSAPrivateKey           = "[csjwt] invalid key: Not a valid RSA private key"
+	errCookieBaseURLHostEmpty        = "[csjwt] Cookie base URL %q contains no host"
+	errCookieTokenExpired            = "[csjwt] Cannot write an already expired token into a cookie"
+)
+
+const (
+	errJWEAlgNotSupported     = `[csjwt] JWE key management algorithm %q not supported`
+	errJWEEncNotSupported     = `[csjwt] JWE content encryption %q not supported`
+	errJWECurveNotSupported   = `[csjwt] JWE ECDH-ES curve %q not supported`
+	errJWEAlgorithmMismatch   = `[csjwt] JWE header alg %q and enc %q do not match the expected %q and %q`
+	errJWEEphemeralKeyInvalid = `[csjwt] JWE ECDH-ES ephemeral public key invalid`
+	errJWEDecryptionFailed    = `[csjwt] JWE decryption failed`
+	errJWEDecrypterMissing    = `[csjwt] Token is encrypted but no Decrypter has been set in the Verification`
 )
 
 // ErrECDSAVerification sadly this is missing from crypto/ecdsa compared to crypto/rsa
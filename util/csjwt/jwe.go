@@ -0,0 +1,328 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csjwt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"hash"
+	"io"
+	"math/big"
+
+	"github.com/corestoreio/csfw/storage/text"
+	"github.com/corestoreio/errors"
+)
+
+// Key management algorithms for JSON Web Encryption (JWE).
+// https://tools.ietf.org/html/rfc7518#section-4.1
+const (
+	RSAOAEP    = `RSA-OAEP`
+	RSAOAEP256 = `RSA-OAEP-256`
+	ECDHES     = `ECDH-ES`
+)
+
+// Content encryption algorithms for JSON Web Encryption (JWE).
+// https://tools.ietf.org/html/rfc7518#section-5.1
+const (
+	A128GCM = `A128GCM`
+	A256GCM = `A256GCM`
+)
+
+// gcmTagSize authentication tag size in bytes of AES-GCM.
+const gcmTagSize = 16
+
+// jweHeader protected header of an encrypted token.
+type jweHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Cty string `json:"cty,omitempty"`
+	Epk *jwkEC `json:"epk,omitempty"`
+}
+
+// jwkEC ephemeral public key used with ECDH-ES.
+type jwkEC struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Encryption wraps a signed token into a JSON Web Encryption (JWE) compact
+// serialization with five segments. The signed token gets encrypted as a
+// nested JWT (header cty=JWT), so the claims are not readable by the client.
+// The public key is required for encryption and the private key for
+// decryption. Supports RSA-OAEP, RSA-OAEP-256 and ECDH-ES (direct key
+// agreement) for the key management together with A128GCM and A256GCM for the
+// content encryption. An Encryption is safe for concurrent use.
+// https://tools.ietf.org/html/rfc7516
+type Encryption struct {
+	keyAlg string
+	enc    string
+	key    Key
+}
+
+// NewEncryption creates a new JWE encrypter and decrypter. The Key must contain
+// an RSA key for the RSA-OAEP algorithms or an ECDSA key for ECDH-ES. Error
+// behaviour: NotSupported or NotValid.
+func NewEncryption(keyAlg, enc string, key Key) (*Encryption, error) {
+	if key.Error != nil {
+		return nil, errors.Wrap(key.Error, "[csjwt] NewEncryption.Key")
+	}
+	switch enc {
+	case A128GCM, A256GCM:
+	default:
+		return nil, errors.NewNotSupportedf(errJWEEncNotSupported, enc)
+	}
+	switch keyAlg {
+	case RSAOAEP, RSAOAEP256:
+		if key.rsaKeyPub == nil && key.rsaKeyPriv == nil {
+			return nil, errors.NewNotValidf(errRSAPublicKeyEmpty)
+		}
+	case ECDHES:
+		if key.ecdsaKeyPub == nil && key.ecdsaKeyPriv == nil {
+			return nil, errors.NewNotValidf(errECDSAPublicKeyEmpty)
+		}
+	default:
+		return nil, errors.NewNotSupportedf(errJWEAlgNotSupported, keyAlg)
+	}
+	return &Encryption{
+		keyAlg: keyAlg,
+		enc:    enc,
+		key:    key,
+	}, nil
+}
+
+// Alg returns the name of the key management algorithm.
+func (e *Encryption) Alg() string { return e.keyAlg }
+
+// Enc returns the name of the content encryption algorithm.
+func (e *Encryption) Enc() string { return e.enc }
+
+// Encrypt encrypts the payload, mostly a signed token, and returns the JWE in
+// compact serialization.
+func (e *Encryption) Encrypt(payload []byte) (text.Chars, error) {
+	h := jweHeader{
+		Alg: e.keyAlg,
+		Enc: e.enc,
+		Cty: ContentTypeJWT,
+	}
+
+	var cek, encKey []byte
+	switch e.keyAlg {
+	case RSAOAEP, RSAOAEP256:
+		pub := e.key.rsaKeyPub
+		if pub == nil {
+			pub = &e.key.rsaKeyPriv.PublicKey
+		}
+		cek = make([]byte, e.keySize())
+		if _, err := io.ReadFull(rand.Reader, cek); err != nil {
+			return nil, errors.Wrap(err, "[csjwt] Encryption.Encrypt.Random")
+		}
+		var err error
+		if encKey, err = rsa.EncryptOAEP(e.oaepHash(), rand.Reader, pub, cek, nil); err != nil {
+			return nil, errors.Wrap(err, "[csjwt] Encryption.Encrypt.EncryptOAEP")
+		}
+	case ECDHES:
+		pub := e.key.ecdsaKeyPub
+		if pub == nil {
+			pub = &e.key.ecdsaKeyPriv.PublicKey
+		}
+		crv, ok := curveNames[pub.Curve]
+		if !ok {
+			return nil, errors.NewNotSupportedf(errJWECurveNotSupported, pub.Curve.Params().Name)
+		}
+		eph, err := ecdsa.GenerateKey(pub.Curve, rand.Reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "[csjwt] Encryption.Encrypt.GenerateKey")
+		}
+		h.Epk = &jwkEC{
+			Kty: "EC",
+			Crv: crv,
+			X:   string(EncodeSegment(eph.X.Bytes())),
+			Y:   string(EncodeSegment(eph.Y.Bytes())),
+		}
+		cek = ecdhDeriveKey(pub.Curve, pub.X, pub.Y, eph.D, e.enc, e.keySize())
+	}
+
+	hj, err := json.Marshal(h)
+	if err != nil {
+		return nil, errors.Wrap(err, "[csjwt] Encryption.Encrypt.Marshal")
+	}
+	header := EncodeSegment(hj)
+
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, errors.Wrap(err, "[csjwt] Encryption.Encrypt.newGCM")
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, errors.Wrap(err, "[csjwt] Encryption.Encrypt.Random")
+	}
+	sealed := aead.Seal(nil, iv, payload, header)
+	ct, tag := sealed[:len(sealed)-gcmTagSize], sealed[len(sealed)-gcmTagSize:]
+
+	return bytes.Join([][]byte{header, EncodeSegment(encKey), EncodeSegment(iv), EncodeSegment(ct), EncodeSegment(tag)}, []byte{'.'}), nil
+}
+
+// Decrypt decrypts a JWE in compact serialization and returns the payload.
+// The algorithms in the JWE header must match the algorithms of the
+// Encryption. Error behaviour: NotValid.
+func (e *Encryption) Decrypt(token []byte) (text.Chars, error) {
+	parts := bytes.Split(token, []byte{'.'})
+	if len(parts) != 5 {
+		return nil, errors.NewNotValidf(errTokenInvalidSegmentCounts)
+	}
+
+	hj, err := DecodeSegment(parts[0])
+	if err != nil {
+		return nil, errors.NewNotValidf(errTokenMalformed, err)
+	}
+	var h jweHeader
+	if err := json.Unmarshal(hj, &h); err != nil {
+		return nil, errors.NewNotValidf(errTokenMalformed, err)
+	}
+	if h.Alg != e.keyAlg || h.Enc != e.enc {
+		return nil, errors.NewNotValidf(errJWEAlgorithmMismatch, h.Alg, h.Enc, e.keyAlg, e.enc)
+	}
+
+	var segs [4][]byte
+	for i := range segs {
+		if segs[i], err = DecodeSegment(parts[i+1]); err != nil {
+			return nil, errors.NewNotValidf(errTokenMalformed, err)
+		}
+	}
+	encKey, iv, ct, tag := segs[0], segs[1], segs[2], segs[3]
+
+	var cek []byte
+	switch e.keyAlg {
+	case RSAOAEP, RSAOAEP256:
+		if e.key.rsaKeyPriv == nil {
+			return nil, errors.NewNotValidf(errRSAPrivateKeyEmpty)
+		}
+		cek, err = rsa.DecryptOAEP(e.oaepHash(), rand.Reader, e.key.rsaKeyPriv, encKey, nil)
+		if err != nil || len(cek) != e.keySize() {
+			return nil, errors.NewNotValidf(errJWEDecryptionFailed)
+		}
+	case ECDHES:
+		priv := e.key.ecdsaKeyPriv
+		if priv == nil {
+			return nil, errors.NewNotValidf(errECDSAPrivateKeyEmpty)
+		}
+		if len(encKey) != 0 || h.Epk == nil || h.Epk.Kty != "EC" || h.Epk.Crv != curveNames[priv.Curve] {
+			return nil, errors.NewNotValidf(errJWEEphemeralKeyInvalid)
+		}
+		x, errX := DecodeSegment([]byte(h.Epk.X))
+		y, errY := DecodeSegment([]byte(h.Epk.Y))
+		px, py := new(big.Int).SetBytes(x), new(big.Int).SetBytes(y)
+		if errX != nil || errY != nil || !priv.Curve.IsOnCurve(px, py) {
+			return nil, errors.NewNotValidf(errJWEEphemeralKeyInvalid)
+		}
+		cek = ecdhDeriveKey(priv.Curve, px, py, priv.D, e.enc, e.keySize())
+	}
+
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, errors.Wrap(err, "[csjwt] Encryption.Decrypt.newGCM")
+	}
+	if len(iv) != aead.NonceSize() || len(tag) != gcmTagSize {
+		return nil, errors.NewNotValidf(errJWEDecryptionFailed)
+	}
+	payload, err := aead.Open(nil, iv, append(ct, tag...), parts[0])
+	if err != nil {
+		return nil, errors.NewNotValidf(errJWEDecryptionFailed)
+	}
+	return payload, nil
+}
+
+func (e *Encryption) keySize() int {
+	if e.enc == A128GCM {
+		return 16
+	}
+	return 32
+}
+
+func (e *Encryption) oaepHash() hash.Hash {
+	if e.keyAlg == RSAOAEP256 {
+		return sha256.New()
+	}
+	return sha1.New()
+}
+
+func newGCM(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var curveNames = map[elliptic.Curve]string{
+	elliptic.P256(): "P-256",
+	elliptic.P384(): "P-384",
+	elliptic.P521(): "P-521",
+}
+
+// ecdhDeriveKey computes the shared secret of the public point x,y and the
+// private scalar d and derives the content encryption key with the Concat KDF
+// as defined in RFC 7518 section 4.6.2. PartyUInfo and PartyVInfo are empty.
+func ecdhDeriveKey(c elliptic.Curve, x, y, d *big.Int, enc string, keySize int) []byte {
+	zx, _ := c.ScalarMult(x, y, d.Bytes())
+	z := make([]byte, (c.Params().BitSize+7)/8)
+	zb := zx.Bytes()
+	copy(z[len(z)-len(zb):], zb)
+
+	var otherInfo []byte
+	otherInfo = appendLenPrefixed(otherInfo, []byte(enc))
+	otherInfo = appendLenPrefixed(otherInfo, nil) // PartyUInfo
+	otherInfo = appendLenPrefixed(otherInfo, nil) // PartyVInfo
+	otherInfo = appendUint32(otherInfo, uint32(keySize*8))
+
+	h := sha256.New()
+	out := make([]byte, 0, keySize+h.Size())
+	for counter := uint32(1); len(out) < keySize; counter++ {
+		h.Reset()
+		var cb [4]byte
+		binary.BigEndian.PutUint32(cb[:], counter)
+		_, _ = h.Write(cb[:])
+		_, _ = h.Write(z)
+		_, _ = h.Write(otherInfo)
+		out = h.Sum(out)
+	}
+	return out[:keySize]
+}
+
+func appendLenPrefixed(dst, data []byte) []byte {
+	return append(appendUint32(dst, uint32(len(data))), data...)
+}
+
+func appendUint32(dst []byte, v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return append(dst, b[:]...)
+}
+
+// isEncrypted reports whether the raw token has the five segments of a JWE.
+func isEncrypted(rawToken []byte) bool {
+	return bytes.Count(rawToken, []byte{'.'}) == 4
+}
@@ -0,0 +1,402 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package claimgen generates custom claim types for csjwt from a JSON schema
+// or a Go struct.
+//
+// The generated type embeds *jwtclaim.Standard and implements the interface
+// csjwt.Claimer with typed fields, key constants, Valid() checks derived from
+// the schema and JSON marshalling without interface{} maps. Use it instead of
+// jwtclaim.Map when the set of claims is known at compile time.
+//
+// A schema like
+//		{
+//			"title": "customer",
+//			"properties": {
+//				"customer_id": {"type": "integer", "minimum": 1},
+//				"group": {"type": "string", "enum": ["retail", "wholesale"]}
+//			},
+//			"required": ["customer_id"]
+//		}
+// generates a type Customer with the fields CustomerID int64 and Group string.
+// The command util/csjwt/cmd/jwtclaimgen wraps this package.
+package claimgen
+
+import (
+	"go/format"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/corestoreio/csfw/util"
+	"github.com/corestoreio/csfw/util/bufferpool"
+	"github.com/corestoreio/csfw/util/wordwrap"
+	"github.com/corestoreio/errors"
+)
+
+// Options defines the output of the Generate function.
+type Options struct {
+	// Package name of the generated file. Required.
+	Package string
+	// TypeName of the generated claim type. Defaults to the camel cased title
+	// of the schema or to "Claim".
+	TypeName string
+	// FFJSON adds a go:generate directive for ffjson to the generated file.
+	// ffjson creates then the encoder and decoder for the flat JSON type which
+	// backs MarshalJSON and UnmarshalJSON.
+	FFJSON bool
+}
+
+// standardKeys JSON names of the claims in jwtclaim.Standard. A schema
+// property cannot reuse them.
+var standardKeys = map[string]bool{
+	"aud": true, "exp": true, "jti": true, "iat": true, "iss": true, "nbf": true, "sub": true,
+}
+
+// goTypes maps the JSON schema type to the Go type and the conv function.
+var goTypes = map[string][2]string{
+	TypeString:  {"string", "ToStringE"},
+	TypeInteger: {"int64", "ToInt64E"},
+	TypeNumber:  {"float64", "ToFloat64E"},
+	TypeBoolean: {"bool", "ToBoolE"},
+	TypeArray:   {"[]string", "ToStringSliceE"},
+}
+
+type genField struct {
+	Key      string
+	Name     string
+	Const    string
+	GoType   string
+	ConvFunc string
+	Comments []string
+	// Checks contains Go conditions which, when true, render the claim
+	// invalid, with the corresponding error message.
+	Checks []genCheck
+}
+
+type genCheck struct {
+	Cond string
+	Msg  string
+}
+
+type genData struct {
+	Options
+	Recv        string
+	Description []string
+	Fields      []genField
+}
+
+const tplClaim = `// Auto generated by claimgen.Generate. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/corestoreio/csfw/util/conv"
+	"github.com/corestoreio/csfw/util/csjwt"
+	"github.com/corestoreio/csfw/util/csjwt/jwtclaim"
+	"github.com/corestoreio/errors"
+)
+{{if .FFJSON}}
+//go:generate ffjson $GOFILE
+{{end}}
+// Key{{.TypeName}}... are the claims supported by type {{.TypeName}} in
+// addition to the claims of jwtclaim.Standard.
+const (
+{{range .Fields}}	{{.Const}} = {{printf "%q" .Key}}
+{{end}})
+
+var _ csjwt.Claimer = (*{{.TypeName}})(nil)
+
+var keys{{.TypeName}} = [...]string{
+	jwtclaim.KeyAudience, jwtclaim.KeyExpiresAt, jwtclaim.KeyID, jwtclaim.KeyIssuedAt,
+	jwtclaim.KeyIssuer, jwtclaim.KeyNotBefore, jwtclaim.KeySubject,
+{{range .Fields}}	{{.Const}},
+{{end}}}
+
+{{range .Description}}// {{.}}
+{{end}}// ffjson: skip
+type {{.TypeName}} struct {
+	*jwtclaim.Standard
+{{range .Fields}}{{range .Comments}}	// {{.}}
+{{end}}	{{.Name}} {{.GoType}} ` + "`" + `json:"{{.Key}},omitempty"` + "`" + `
+{{end}}}
+
+// New{{.TypeName}} creates a new {{.TypeName}} pointer with an initialized
+// Standard claim.
+func New{{.TypeName}}() *{{.TypeName}} {
+	return &{{.TypeName}}{
+		Standard: new(jwtclaim.Standard),
+	}
+}
+
+// Set sets a claim and falls back to the Standard claims. Error behaviour:
+// NotSupported, NotValid
+func ({{.Recv}} *{{.TypeName}}) Set(key string, value interface{}) (err error) {
+	switch key {
+{{range .Fields}}	case {{.Const}}:
+		{{$.Recv}}.{{.Name}}, err = conv.{{.ConvFunc}}(value)
+		return errors.Wrap(err, "[{{$.Package}}] {{$.TypeName}}.{{.Name}}.{{.ConvFunc}}")
+{{end}}	}
+	if {{.Recv}}.Standard == nil {
+		{{.Recv}}.Standard = new(jwtclaim.Standard)
+	}
+	return {{.Recv}}.Standard.Set(key, value)
+}
+
+// Get returns the value of a claim and falls back to the Standard claims.
+// Error behaviour: NotSupported
+func ({{.Recv}} *{{.TypeName}}) Get(key string) (interface{}, error) {
+	switch key {
+{{range .Fields}}	case {{.Const}}:
+		return {{$.Recv}}.{{.Name}}, nil
+{{end}}	}
+	if {{.Recv}}.Standard == nil {
+		return new(jwtclaim.Standard).Get(key)
+	}
+	return {{.Recv}}.Standard.Get(key)
+}
+
+// Keys returns all available keys which this type supports.
+func ({{.Recv}} *{{.TypeName}}) Keys() []string {
+	return keys{{.TypeName}}[:]
+}
+
+// Expires duration when a token expires.
+func ({{.Recv}} *{{.TypeName}}) Expires() time.Duration {
+	if {{.Recv}}.Standard == nil {
+		return 0
+	}
+	return {{.Recv}}.Standard.Expires()
+}
+
+// Valid validates the time based Standard claims and the constraints of the
+// schema. Error behaviour: NotValid
+func ({{.Recv}} *{{.TypeName}}) Valid() error {
+	if {{.Recv}}.Standard != nil {
+		if err := {{.Recv}}.Standard.Valid(); err != nil {
+			return errors.Wrap(err, "[{{.Package}}] {{.TypeName}}.Valid")
+		}
+	}
+{{range $f := .Fields}}{{range .Checks}}	if {{.Cond}} {
+		return errors.NewNotValidf("[{{$.Package}}] {{$.TypeName}}.Valid: Claim %q {{.Msg}}", {{$f.Const}})
+	}
+{{end}}{{end}}	return nil
+}
+
+// String human readable output via JSON.
+func ({{.Recv}} *{{.TypeName}}) String() string {
+	b, err := {{.Recv}}.MarshalJSON()
+	if err != nil {
+		return errors.NewFatalf("[{{.Package}}] {{.TypeName}}.String(): MarshalJSON Error: %s", err).Error()
+	}
+	return string(b)
+}
+
+// json{{.TypeName}} flat representation of {{.TypeName}} without methods and
+// without the embedded pointer.
+type json{{.TypeName}} struct {
+	Audience  string ` + "`" + `json:"aud,omitempty"` + "`" + `
+	ExpiresAt int64  ` + "`" + `json:"exp,omitempty"` + "`" + `
+	ID        string ` + "`" + `json:"jti,omitempty"` + "`" + `
+	IssuedAt  int64  ` + "`" + `json:"iat,omitempty"` + "`" + `
+	Issuer    string ` + "`" + `json:"iss,omitempty"` + "`" + `
+	NotBefore int64  ` + "`" + `json:"nbf,omitempty"` + "`" + `
+	Subject   string ` + "`" + `json:"sub,omitempty"` + "`" + `
+{{range .Fields}}	{{.Name}} {{.GoType}} ` + "`" + `json:"{{.Key}},omitempty"` + "`" + `
+{{end}}}
+
+// MarshalJSON encodes the Standard and the custom claims into one object.
+func ({{.Recv}} *{{.TypeName}}) MarshalJSON() ([]byte, error) {
+	j := &json{{.TypeName}}{
+{{range .Fields}}		{{.Name}}: {{$.Recv}}.{{.Name}},
+{{end}}	}
+	if s := {{.Recv}}.Standard; s != nil {
+		j.Audience, j.ExpiresAt, j.ID, j.IssuedAt = s.Audience, s.ExpiresAt, s.ID, s.IssuedAt
+		j.Issuer, j.NotBefore, j.Subject = s.Issuer, s.NotBefore, s.Subject
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes the Standard and the custom claims.
+func ({{.Recv}} *{{.TypeName}}) UnmarshalJSON(data []byte) error {
+	j := new(json{{.TypeName}})
+	if err := json.Unmarshal(data, j); err != nil {
+		return errors.Wrap(err, "[{{.Package}}] {{.TypeName}}.UnmarshalJSON")
+	}
+	if {{.Recv}}.Standard == nil {
+		{{.Recv}}.Standard = new(jwtclaim.Standard)
+	}
+	s := {{.Recv}}.Standard
+	s.Audience, s.ExpiresAt, s.ID, s.IssuedAt = j.Audience, j.ExpiresAt, j.ID, j.IssuedAt
+	s.Issuer, s.NotBefore, s.Subject = j.Issuer, j.NotBefore, j.Subject
+{{range .Fields}}	{{$.Recv}}.{{.Name}} = j.{{.Name}}
+{{end}}	return nil
+}
+`
+
+var tplClaimParsed = template.Must(template.New("claim").Parse(tplClaim))
+
+// Generate writes the formatted Go source code of a claim type described by
+// the schema to w. Error behaviour: NotValid, NotSupported, NotFound, Fatal or
+// WriteFailed.
+func Generate(w io.Writer, o Options, s *Schema) error {
+	if o.Package == "" {
+		return errors.NewNotValidf("[claimgen] Generate: Package name cannot be empty")
+	}
+	if s == nil || len(s.Properties) == 0 {
+		return errors.NewNotValidf("[claimgen] Generate: Schema contains no properties")
+	}
+	if s.Type != "" && s.Type != "object" {
+		return errors.NewNotSupportedf("[claimgen] Generate: Schema type %q not supported", s.Type)
+	}
+	if o.TypeName == "" {
+		o.TypeName = util.UnderscoreCamelize(s.Title)
+	}
+	if o.TypeName == "" {
+		o.TypeName = "Claim"
+	}
+
+	required := make(map[string]bool, len(s.Required))
+	for _, r := range s.Required {
+		if _, ok := s.Properties[r]; !ok {
+			return errors.NewNotFoundf("[claimgen] Generate: Required property %q not found", r)
+		}
+		required[r] = true
+	}
+
+	gd := genData{
+		Options: o,
+		Recv:    strings.ToLower(o.TypeName[:1]),
+		Fields:  make([]genField, 0, len(s.Properties)),
+	}
+	if s.Description != "" {
+		gd.Description = wrap(o.TypeName + " " + s.Description)
+	} else {
+		gd.Description = []string{o.TypeName + " contains the Standard and custom claims of a token."}
+	}
+
+	keys := make([]string, 0, len(s.Properties))
+	for k := range s.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	names := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if standardKeys[k] {
+			return errors.NewNotValidf("[claimgen] Generate: Property %q collides with a Standard claim", k)
+		}
+		f, err := newGenField(gd.Recv, k, s.Properties[k], required[k])
+		if err != nil {
+			return errors.Wrapf(err, "[claimgen] Generate.newGenField %q", k)
+		}
+		if prev, ok := names[f.Name]; ok {
+			return errors.NewNotValidf("[claimgen] Generate: Properties %q and %q map both to field %q", prev, k, f.Name)
+		}
+		names[f.Name] = k
+		f.Const = "Key" + o.TypeName + f.Name
+		gd.Fields = append(gd.Fields, f)
+	}
+
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err := tplClaimParsed.Execute(buf, gd); err != nil {
+		return errors.NewFatal(err, "[claimgen] Generate.template.Execute")
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.NewFatal(err, "[claimgen] Generate.format.Source")
+	}
+	if _, err := w.Write(src); err != nil {
+		return errors.NewWriteFailed(err, "[claimgen] Generate.Write")
+	}
+	return nil
+}
+
+func newGenField(recv, key string, p *Property, required bool) (genField, error) {
+	if p == nil {
+		return genField{}, errors.NewNotValidf("[claimgen] Property %q is empty", key)
+	}
+	if p.Type == TypeArray && (p.Items == nil || p.Items.Type != TypeString) {
+		return genField{}, errors.NewNotSupportedf("[claimgen] Property %q: Only arrays of strings are supported", key)
+	}
+	gt, ok := goTypes[p.Type]
+	if !ok {
+		return genField{}, errors.NewNotSupportedf("[claimgen] Property %q: Type %q not supported", key, p.Type)
+	}
+
+	f := genField{
+		Key:      key,
+		Name:     util.UnderscoreCamelize(key),
+		GoType:   gt[0],
+		ConvFunc: gt[1],
+	}
+	if f.Name == "" || f.Name[0] < 'A' || f.Name[0] > 'Z' {
+		return genField{}, errors.NewNotValidf("[claimgen] Property %q cannot be converted into an exported Go identifier", key)
+	}
+	if p.Description != "" {
+		f.Comments = wrap(f.Name + " " + p.Description)
+	}
+
+	field := recv + "." + f.Name
+	if required {
+		switch p.Type {
+		case TypeString:
+			f.Checks = append(f.Checks, genCheck{field + ` == ""`, "is required"})
+		case TypeInteger, TypeNumber:
+			f.Checks = append(f.Checks, genCheck{field + ` == 0`, "is required"})
+		case TypeArray:
+			f.Checks = append(f.Checks, genCheck{`len(` + field + `) == 0`, "is required"})
+		case TypeBoolean:
+			f.Checks = append(f.Checks, genCheck{`!` + field, "must be true"})
+		}
+	}
+	if len(p.Enum) > 0 && p.Type == TypeString {
+		conds := make([]string, 0, len(p.Enum))
+		for _, e := range p.Enum {
+			conds = append(conds, field+" != "+strconv.Quote(e))
+		}
+		cond := strings.Join(conds, " && ")
+		if !required {
+			cond = field + ` != "" && ` + cond
+		}
+		f.Checks = append(f.Checks, genCheck{cond, "contains a value which is not allowed"})
+	}
+	if p.Type == TypeInteger || p.Type == TypeNumber {
+		if p.Minimum != nil {
+			f.Checks = append(f.Checks, genCheck{field + " < " + formatNumber(*p.Minimum, p.Type), "is below the minimum of " + formatNumber(*p.Minimum, p.Type)})
+		}
+		if p.Maximum != nil {
+			f.Checks = append(f.Checks, genCheck{field + " > " + formatNumber(*p.Maximum, p.Type), "is above the maximum of " + formatNumber(*p.Maximum, p.Type)})
+		}
+	}
+	return f, nil
+}
+
+func formatNumber(f float64, typ string) string {
+	if typ == TypeInteger {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// wrap splits a text into comment lines.
+func wrap(s string) []string {
+	return strings.Split(wordwrap.String(strings.Join(strings.Fields(s), " "), 76), "\n")
+}
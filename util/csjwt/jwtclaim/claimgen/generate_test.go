@@ -0,0 +1,165 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package claimgen_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/corestoreio/csfw/util/csjwt/jwtclaim"
+	"github.com/corestoreio/csfw/util/csjwt/jwtclaim/claimgen"
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const customerSchema = `{
+	"title": "customer",
+	"description": "contains the claims of a logged in customer.",
+	"type": "object",
+	"properties": {
+		"customer_id": {"type": "integer", "minimum": 1, "description": "primary key of table customer_entity."},
+		"group": {"type": "string", "enum": ["retail", "wholesale"]},
+		"discount": {"type": "number", "maximum": 0.5},
+		"newsletter": {"type": "boolean"},
+		"roles": {"type": "array", "items": {"type": "string"}}
+	},
+	"required": ["customer_id"]
+}`
+
+func TestGenerate(t *testing.T) {
+	s, err := claimgen.ParseSchema(strings.NewReader(customerSchema))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, claimgen.Generate(&buf, claimgen.Options{Package: "claims", FFJSON: true}, s))
+	src := buf.String()
+
+	for _, want := range []string{
+		"package claims\n",
+		"//go:generate ffjson $GOFILE",
+		"KeyCustomerCustomerID = \"customer_id\"",
+		"KeyCustomerGroup      = \"group\"",
+		"// Customer contains the claims of a logged in customer.\n// ffjson: skip\ntype Customer struct {\n\t*jwtclaim.Standard",
+		"\t// CustomerID primary key of table customer_entity.\n\tCustomerID int64    `json:\"customer_id,omitempty\"`",
+		"\tDiscount   float64  `json:\"discount,omitempty\"`",
+		"\tNewsletter bool     `json:\"newsletter,omitempty\"`",
+		"\tRoles      []string `json:\"roles,omitempty\"`",
+		"c.CustomerID, err = conv.ToInt64E(value)",
+		"c.Roles, err = conv.ToStringSliceE(value)",
+		"if c.CustomerID == 0 {",
+		"if c.CustomerID < 1 {",
+		"if c.Discount > 0.5 {",
+		`if c.Group != "" && c.Group != "retail" && c.Group != "wholesale" {`,
+		"func (c *Customer) MarshalJSON() ([]byte, error) {",
+		"func (c *Customer) UnmarshalJSON(data []byte) error {",
+		"var _ csjwt.Claimer = (*Customer)(nil)",
+	} {
+		assert.Contains(t, src, want)
+	}
+	assert.NotContains(t, src, "c.Newsletter {")
+}
+
+func TestGenerate_TypeName(t *testing.T) {
+	s := &claimgen.Schema{
+		Properties: map[string]*claimgen.Property{
+			"active": {Type: claimgen.TypeBoolean},
+		},
+		Required: []string{"active"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, claimgen.Generate(&buf, claimgen.Options{Package: "claims"}, s))
+	assert.Contains(t, buf.String(), "type Claim struct {")
+	assert.Contains(t, buf.String(), "if !c.Active {")
+	assert.NotContains(t, buf.String(), "ffjson $GOFILE")
+
+	buf.Reset()
+	require.NoError(t, claimgen.Generate(&buf, claimgen.Options{Package: "claims", TypeName: "Admin"}, s))
+	assert.Contains(t, buf.String(), "func (a *Admin) Valid() error {")
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	prop := func(typ string) map[string]*claimgen.Property {
+		return map[string]*claimgen.Property{"x": {Type: typ}}
+	}
+	tests := []struct {
+		opt    claimgen.Options
+		schema *claimgen.Schema
+		errBhf errors.BehaviourFunc
+	}{
+		{claimgen.Options{}, &claimgen.Schema{Properties: prop("string")}, errors.IsNotValid},
+		{claimgen.Options{Package: "p"}, nil, errors.IsNotValid},
+		{claimgen.Options{Package: "p"}, &claimgen.Schema{}, errors.IsNotValid},
+		{claimgen.Options{Package: "p"}, &claimgen.Schema{Type: "array", Properties: prop("string")}, errors.IsNotSupported},
+		{claimgen.Options{Package: "p"}, &claimgen.Schema{Properties: prop("object")}, errors.IsNotSupported},
+		{claimgen.Options{Package: "p"}, &claimgen.Schema{Properties: prop("array")}, errors.IsNotSupported},
+		{claimgen.Options{Package: "p"}, &claimgen.Schema{Properties: prop("string"), Required: []string{"y"}}, errors.IsNotFound},
+		{claimgen.Options{Package: "p"}, &claimgen.Schema{Properties: map[string]*claimgen.Property{"exp": {Type: "integer"}}}, errors.IsNotValid},
+		{claimgen.Options{Package: "p"}, &claimgen.Schema{Properties: map[string]*claimgen.Property{"9lives": {Type: "integer"}}}, errors.IsNotValid},
+		{claimgen.Options{Package: "p"}, &claimgen.Schema{Properties: map[string]*claimgen.Property{"user_id": {Type: "integer"}, "user-id": {Type: "integer"}}}, errors.IsNotValid},
+	}
+	for i, test := range tests {
+		var buf bytes.Buffer
+		err := claimgen.Generate(&buf, test.opt, test.schema)
+		assert.True(t, test.errBhf(err), "Index %d => %+v", i, err)
+		assert.Empty(t, buf.String(), "Index %d", i)
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	s, err := claimgen.ParseSchema(strings.NewReader(customerSchema))
+	require.NoError(t, err)
+	assert.Exactly(t, "customer", s.Title)
+	assert.Exactly(t, []string{"customer_id"}, s.Required)
+	assert.Exactly(t, 1.0, *s.Properties["customer_id"].Minimum)
+	assert.Exactly(t, claimgen.TypeString, s.Properties["roles"].Items.Type)
+
+	_, err = claimgen.ParseSchema(strings.NewReader(`{"title":`))
+	assert.True(t, errors.IsNotValid(err), "%+v", err)
+}
+
+type adminClaim struct {
+	*jwtclaim.Standard
+	AdminID int    `json:"admin_id" claimgen:"required"`
+	Role    string `json:"role,omitempty"`
+	Scopes  []string
+	Score   float32
+	Root    bool   `json:"root"`
+	Ignored string `json:"-"`
+	private string
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	s, err := claimgen.SchemaFromStruct(&adminClaim{})
+	require.NoError(t, err)
+	assert.Exactly(t, "admin_claim", s.Title)
+	assert.Exactly(t, []string{"admin_id"}, s.Required)
+	assert.Len(t, s.Properties, 5)
+	assert.Exactly(t, claimgen.TypeInteger, s.Properties["admin_id"].Type)
+	assert.Exactly(t, claimgen.TypeString, s.Properties["role"].Type)
+	assert.Exactly(t, claimgen.TypeArray, s.Properties["Scopes"].Type)
+	assert.Exactly(t, claimgen.TypeNumber, s.Properties["Score"].Type)
+	assert.Exactly(t, claimgen.TypeBoolean, s.Properties["root"].Type)
+
+	var buf bytes.Buffer
+	require.NoError(t, claimgen.Generate(&buf, claimgen.Options{Package: "claims"}, s))
+	assert.Contains(t, buf.String(), "type AdminClaim struct {")
+
+	_, err = claimgen.SchemaFromStruct("x")
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+	_, err = claimgen.SchemaFromStruct(struct{ M map[string]string }{})
+	assert.True(t, errors.IsNotSupported(err), "%+v", err)
+}
@@ -0,0 +1,130 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package claimgen
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/corestoreio/csfw/util"
+	"github.com/corestoreio/errors"
+)
+
+// Supported JSON schema types of a property.
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeArray   = "array"
+)
+
+// Schema represents the subset of a JSON schema which describes the custom
+// claims of a token. Only a flat object with scalar properties or arrays of
+// strings can be converted into Go code.
+type Schema struct {
+	// Title gets used as the default type name.
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Type must be empty or "object".
+	Type       string               `json:"type,omitempty"`
+	Properties map[string]*Property `json:"properties"`
+	// Required lists the property names which must contain a non-zero value
+	// to pass the Valid() check of the generated type.
+	Required []string `json:"required,omitempty"`
+}
+
+// Property describes a single claim.
+type Property struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	// Items describes the element type of an array. Only "string" is
+	// supported.
+	Items *Property `json:"items,omitempty"`
+	// Enum restricts a string claim to a fixed set of values.
+	Enum []string `json:"enum,omitempty"`
+	// Minimum and Maximum restrict integer and number claims.
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+}
+
+// ParseSchema decodes a JSON schema from r. Error behaviour: NotValid.
+func ParseSchema(r io.Reader) (*Schema, error) {
+	s := new(Schema)
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, errors.NewNotValid(err, "[claimgen] ParseSchema.Decode")
+	}
+	return s, nil
+}
+
+// SchemaFromStruct creates a Schema from the exported fields of a struct. The
+// property names are taken from the json struct tag. Embedded fields, like
+// *jwtclaim.Standard, and fields tagged with json:"-" get skipped. A field
+// marked with the struct tag claimgen:"required" gets added to the required
+// list. Error behaviour: NotSupported.
+func SchemaFromStruct(v interface{}) (*Schema, error) {
+	rt := reflect.TypeOf(v)
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, errors.NewNotSupportedf("[claimgen] SchemaFromStruct: Type %T is not a struct", v)
+	}
+
+	s := &Schema{
+		Title:      util.CamelCaseToUnderscore(rt.Name()),
+		Type:       "object",
+		Properties: make(map[string]*Property, rt.NumField()),
+	}
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.Anonymous || f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+
+		p := new(Property)
+		switch k := f.Type.Kind(); {
+		case k == reflect.String:
+			p.Type = TypeString
+		case k >= reflect.Int && k <= reflect.Uint64:
+			p.Type = TypeInteger
+		case k == reflect.Float32 || k == reflect.Float64:
+			p.Type = TypeNumber
+		case k == reflect.Bool:
+			p.Type = TypeBoolean
+		case k == reflect.Slice && f.Type.Elem().Kind() == reflect.String:
+			p.Type = TypeArray
+			p.Items = &Property{Type: TypeString}
+		default:
+			return nil, errors.NewNotSupportedf("[claimgen] SchemaFromStruct: Field %q with type %s not supported", f.Name, f.Type)
+		}
+		s.Properties[name] = p
+		if f.Tag.Get("claimgen") == "required" {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s, nil
+}
@@ -16,6 +16,9 @@
 //
 // ffjson encoder supported
 //
+// Custom claim types with typed fields can be generated from a JSON schema with
+// the package claimgen.
+//
 // See README.md for more info.
 // http://self-issued.info/docs/draft-jones-json-web-token.html
 package jwtclaim
@@ -0,0 +1,93 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwtclaim
+
+import (
+	"github.com/corestoreio/csfw/util/csjwt"
+	"github.com/corestoreio/errors"
+)
+
+// The UnmarshalJWTField functions enable csjwt.FastJSONEncoding to decode
+// the types without reflection.
+
+// UnmarshalJWTField implements the csjwt.FieldUnmarshaler interface.
+func (s *HeadSegments) UnmarshalJWTField(key, value []byte) (err error) {
+	switch string(key) {
+	case HeaderAlg:
+		s.Algorithm, err = csjwt.ParseJSONString(value)
+	case HeaderTyp:
+		s.Type, err = csjwt.ParseJSONString(value)
+	case "jku":
+		s.JKU, err = csjwt.ParseJSONString(value)
+	case "kid":
+		s.KID, err = csjwt.ParseJSONString(value)
+	case "x5u":
+		s.X5U, err = csjwt.ParseJSONString(value)
+	case "x5t":
+		s.X5T, err = csjwt.ParseJSONString(value)
+	}
+	return err
+}
+
+// UnmarshalJWTField implements the csjwt.FieldUnmarshaler interface.
+func (s *Standard) UnmarshalJWTField(key, value []byte) (err error) {
+	switch string(key) {
+	case KeyAudience:
+		s.Audience, err = csjwt.ParseJSONString(value)
+	case KeyExpiresAt:
+		s.ExpiresAt, err = csjwt.ParseJSONInt64(value)
+	case KeyID:
+		s.ID, err = csjwt.ParseJSONString(value)
+	case KeyIssuedAt:
+		s.IssuedAt, err = csjwt.ParseJSONInt64(value)
+	case KeyIssuer:
+		s.Issuer, err = csjwt.ParseJSONString(value)
+	case KeyNotBefore:
+		s.NotBefore, err = csjwt.ParseJSONInt64(value)
+	case KeySubject:
+		s.Subject, err = csjwt.ParseJSONString(value)
+	}
+	return err
+}
+
+// UnmarshalJWTField implements the csjwt.FieldUnmarshaler interface.
+func (s *Store) UnmarshalJWTField(key, value []byte) (err error) {
+	switch string(key) {
+	case KeyStore:
+		s.Store, err = csjwt.ParseJSONString(value)
+		return err
+	case KeyUserID:
+		s.UserID, err = csjwt.ParseJSONString(value)
+		return err
+	}
+	if s.Standard == nil {
+		s.Standard = new(Standard)
+	}
+	return s.Standard.UnmarshalJWTField(key, value)
+}
+
+// UnmarshalJWTField implements the csjwt.FieldUnmarshaler interface. The
+// values have the same types as when decoded with encoding/json.
+func (m Map) UnmarshalJWTField(key, value []byte) error {
+	if m == nil {
+		return errors.NewNotValidf("[jwtclaim] Map is nil")
+	}
+	v, err := csjwt.ParseJSONValue(value)
+	if err != nil {
+		return errors.Wrap(err, "[jwtclaim] Map.UnmarshalJWTField")
+	}
+	m[string(key)] = v
+	return nil
+}
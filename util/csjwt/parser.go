@@ -42,6 +42,19 @@ type Verification struct {
 	// Decoder interface to pass in a custom decoder parser. Can be nil, falls
 	// back to JSON.
 	Deserializer
+
+	// Decrypter optional JWE decrypter. If set, tokens with five segments get
+	// decrypted first and the nested token gets verified afterwards. If nil,
+	// encrypted tokens cannot be parsed.
+	Decrypter *Encryption
+
+	// Cache optional cache of already verified tokens. If set, the signature
+	// of a recently seen token does not get verified again until the token
+	// expires.
+	Cache *VerificationCache
+	// Strict bypasses the Cache and verifies the signature of each token. Use a
+	// copy of the Verification with Strict enabled for sensitive operations.
+	Strict bool
 }
 
 // NewVerification creates new verification parser with the default signing
@@ -57,8 +70,21 @@ func NewVerification(availableSigners ...Signer) *Verification {
 // Parse parses a rawToken into the destination token and may return an error.
 // You must make sure to set the correct expected headers and claims in the
 // template Token. The Header and Claims field in the destination token must be
-// a pointer as the token itself. Error behaviour: Empty, NotFound, NotValid
+// a pointer as the token itself. Encrypted tokens get decrypted with the
+// Decrypter, the field Raw contains then the nested signed token. Error
+// behaviour: Empty, NotFound, NotValid, NotSupported
 func (vf *Verification) Parse(dst *Token, rawToken []byte, keyFunc Keyfunc) error {
+	if isEncrypted(rawToken) {
+		if vf.Decrypter == nil {
+			return errors.NewNotSupportedf(errJWEDecrypterMissing)
+		}
+		plain, err := vf.Decrypter.Decrypt(rawToken)
+		if err != nil {
+			return errors.Wrap(err, "[csjwt] Verification.Parse.Decrypt")
+		}
+		rawToken = plain
+	}
+
 	pos, valid := dotPositions(rawToken)
 	if !valid {
 		return errors.NewNotValidf(errTokenInvalidSegmentCounts)
@@ -111,9 +137,17 @@ func (vf *Verification) Parse(dst *Token, rawToken []byte, keyFunc Keyfunc) erro
 
 	// Perform validation
 	dst.Signature = dst.Raw[pos[1]+1:]
+	useCache := vf.Cache != nil && !vf.Strict
+	if useCache && vf.Cache.isVerified(dst.Raw, key) {
+		dst.Valid = true
+		return nil
+	}
 	if err := method.Verify(dst.Raw[:pos[1]], dst.Signature, key); err != nil {
 		return errors.NewNotValidf(errSignatureInvalid, err, dst)
 	}
+	if useCache {
+		vf.Cache.add(dst.Raw, key, dst.Claims.Expires())
+	}
 
 	dst.Valid = true
 	return nil
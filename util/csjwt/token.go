@@ -25,8 +25,8 @@ import (
 	"github.com/corestoreio/log"
 )
 
-// ContentTypeJWT defines the content type of a token. At the moment only JWT is
-// supported. JWE may be added in the future JSON Web Encryption (JWE).
+// ContentTypeJWT defines the content type of a token. Used as the cty header
+// of an encrypted token (JWE) to mark the payload as a nested JWT.
 // https://tools.ietf.org/html/rfc7519
 const ContentTypeJWT = `JWT`
 
@@ -96,6 +96,17 @@ func (t Token) SignedString(method Signer, key Key) (text.Chars, error) {
 	return buf.Bytes(), nil
 }
 
+// EncryptedString signs the token like SignedString and encrypts afterwards
+// the signed token with the Encryption. Returns a JWE in compact serialization.
+func (t Token) EncryptedString(method Signer, key Key, enc *Encryption) (text.Chars, error) {
+	signed, err := t.SignedString(method, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "[csjwt] Token.EncryptedString.SignedString")
+	}
+	jwe, err := enc.Encrypt(signed)
+	return jwe, errors.Wrap(err, "[csjwt] Token.EncryptedString.Encrypt")
+}
+
 // SigningString generates the signing string. This is the most expensive part
 // of the whole deal.  Unless you need this for something special, just go
 // straight for the SignedString. Returns a buffer which can be used for further
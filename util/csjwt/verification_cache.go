@@ -0,0 +1,237 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csjwt
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultVerificationCacheSize maximum number of cached tokens of a
+// VerificationCache.
+const DefaultVerificationCacheSize = 4096
+
+// VerificationCacheOption applies options to the NewVerificationCache
+// function.
+type VerificationCacheOption func(*VerificationCache)
+
+// WithVerificationCacheSize sets the maximum number of cached tokens. If the
+// limit has been reached, the least recently used token gets evicted.
+func WithVerificationCacheSize(size int) VerificationCacheOption {
+	return func(vc *VerificationCache) {
+		if size > 0 {
+			vc.size = size
+		}
+	}
+}
+
+// WithVerificationCacheMaxTTL limits the time a token stays in the cache. The
+// expiration of the token wins if it is earlier. Zero means that a token gets
+// cached until it expires.
+func WithVerificationCacheMaxTTL(ttl time.Duration) VerificationCacheOption {
+	return func(vc *VerificationCache) {
+		vc.maxTTL = ttl
+	}
+}
+
+// VerificationCache remembers tokens whose signature has been successfully
+// verified. A Verification with a cache skips the signature verification for
+// a recently seen token until the token expires. Tokens without an expiration
+// never get cached. The header and the claims still get decoded and validated
+// and the Keyfunc still gets called for each token. A cached token only
+// matches if the Keyfunc returns the same key as during the first
+// verification, so a rotated key invalidates the cached tokens.
+//
+// The cache key is the SHA-256 hash of the raw token. The cache reduces CPU
+// usage for RSA and ECDSA signatures, for HMAC signatures the hashing costs
+// about the same as the verification. See the benchmarks
+// BenchmarkVerification_Cache*.
+//
+// VerificationCache implements the expvar.Var interface and can be published
+// via expvar.Publish("jwt_verification_cache", vc). Thread safe.
+type VerificationCache struct {
+	size   int
+	maxTTL time.Duration
+	// now returns the current time. Can be changed for testing.
+	now func() time.Time
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[[sha256.Size]byte]*list.Element
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type verificationEntry struct {
+	hash    [sha256.Size]byte
+	key     Key
+	expires time.Time
+}
+
+// NewVerificationCache creates a new bounded LRU cache for verified tokens.
+// Default size is DefaultVerificationCacheSize.
+func NewVerificationCache(opts ...VerificationCacheOption) *VerificationCache {
+	vc := &VerificationCache{
+		size:  DefaultVerificationCacheSize,
+		now:   time.Now,
+		ll:    list.New(),
+		items: make(map[[sha256.Size]byte]*list.Element),
+	}
+	for _, o := range opts {
+		if o != nil {
+			o(vc)
+		}
+	}
+	return vc
+}
+
+// isVerified reports whether the raw token has already been verified with the
+// same key and has not yet expired.
+func (vc *VerificationCache) isVerified(rawToken []byte, key Key) bool {
+	h := sha256.Sum256(rawToken)
+	now := vc.now()
+	vc.mu.Lock()
+	el, ok := vc.items[h]
+	if ok {
+		ve := el.Value.(*verificationEntry)
+		if now.Before(ve.expires) && ve.key.equal(key) {
+			vc.ll.MoveToFront(el)
+			vc.mu.Unlock()
+			atomic.AddUint64(&vc.hits, 1)
+			return true
+		}
+		vc.removeElement(el)
+	}
+	vc.mu.Unlock()
+	atomic.AddUint64(&vc.misses, 1)
+	return false
+}
+
+// add stores a successfully verified token until it expires.
+func (vc *VerificationCache) add(rawToken []byte, key Key, expires time.Duration) {
+	if expires <= 0 {
+		return
+	}
+	if vc.maxTTL > 0 && expires > vc.maxTTL {
+		expires = vc.maxTTL
+	}
+	ve := &verificationEntry{
+		hash:    sha256.Sum256(rawToken),
+		key:     key,
+		expires: vc.now().Add(expires),
+	}
+	vc.mu.Lock()
+	if el, ok := vc.items[ve.hash]; ok {
+		vc.removeElement(el)
+	}
+	vc.items[ve.hash] = vc.ll.PushFront(ve)
+	for vc.ll.Len() > vc.size {
+		vc.removeElement(vc.ll.Back())
+		atomic.AddUint64(&vc.evictions, 1)
+	}
+	vc.mu.Unlock()
+}
+
+// Purge removes all tokens from the cache. Call it after a key has been
+// revoked.
+func (vc *VerificationCache) Purge() {
+	vc.mu.Lock()
+	vc.ll.Init()
+	vc.items = make(map[[sha256.Size]byte]*list.Element)
+	vc.mu.Unlock()
+}
+
+// Len returns the number of cached tokens.
+func (vc *VerificationCache) Len() int {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	return vc.ll.Len()
+}
+
+// removeElement caller must hold the lock.
+func (vc *VerificationCache) removeElement(el *list.Element) {
+	vc.ll.Remove(el)
+	delete(vc.items, el.Value.(*verificationEntry).hash)
+}
+
+// VerificationCacheStats contains the metrics of a VerificationCache.
+type VerificationCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// HitRatio returns the percentage of hits between 0 and 1.
+func (vs VerificationCacheStats) HitRatio() float64 {
+	if total := vs.Hits + vs.Misses; total > 0 {
+		return float64(vs.Hits) / float64(total)
+	}
+	return 0
+}
+
+// Stats returns the current metrics.
+func (vc *VerificationCache) Stats() VerificationCacheStats {
+	return VerificationCacheStats{
+		Hits:      atomic.LoadUint64(&vc.hits),
+		Misses:    atomic.LoadUint64(&vc.misses),
+		Evictions: atomic.LoadUint64(&vc.evictions),
+		Size:      vc.Len(),
+	}
+}
+
+// String implements the expvar.Var interface and returns the metrics as JSON.
+func (vc *VerificationCache) String() string {
+	st := vc.Stats()
+	return fmt.Sprintf(`{"hits":%d,"misses":%d,"evictions":%d,"size":%d,"hit_ratio":%.4f}`,
+		st.Hits, st.Misses, st.Evictions, st.Size, st.HitRatio())
+}
+
+// equal compares the keys used for verification. Two separately loaded keys
+// with the same content are equal.
+func (k Key) equal(o Key) bool {
+	if k.Error != nil || o.Error != nil {
+		return false
+	}
+	if len(k.hmacPassword) != len(o.hmacPassword) {
+		return false
+	}
+	if len(k.hmacPassword) > 0 && subtle.ConstantTimeCompare(k.hmacPassword, o.hmacPassword) != 1 {
+		return false
+	}
+	switch {
+	case k.rsaKeyPub == nil && o.rsaKeyPub == nil:
+	case k.rsaKeyPub == nil || o.rsaKeyPub == nil:
+		return false
+	case k.rsaKeyPub != o.rsaKeyPub && (k.rsaKeyPub.E != o.rsaKeyPub.E || k.rsaKeyPub.N.Cmp(o.rsaKeyPub.N) != 0):
+		return false
+	}
+	switch {
+	case k.ecdsaKeyPub == nil && o.ecdsaKeyPub == nil:
+	case k.ecdsaKeyPub == nil || o.ecdsaKeyPub == nil:
+		return false
+	case k.ecdsaKeyPub != o.ecdsaKeyPub && (k.ecdsaKeyPub.Curve != o.ecdsaKeyPub.Curve ||
+		k.ecdsaKeyPub.X.Cmp(o.ecdsaKeyPub.X) != 0 || k.ecdsaKeyPub.Y.Cmp(o.ecdsaKeyPub.Y) != 0):
+		return false
+	}
+	return true
+}
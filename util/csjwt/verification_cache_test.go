@@ -0,0 +1,211 @@
+// Copyright 2015-2016, Cyrill @ Schumacher.fm and the CoreStore contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csjwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/corestoreio/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheClaims minimal Claimer to avoid the import cycle with package
+// jwtclaim.
+type cacheClaims struct {
+	Subject   string `json:"sub,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+func (c *cacheClaims) Valid() error {
+	if c.ExpiresAt > 0 && time.Now().Unix() > c.ExpiresAt {
+		return errors.NewNotValidf("token is expired")
+	}
+	return nil
+}
+
+func (c *cacheClaims) Expires() time.Duration {
+	if c.ExpiresAt > 0 {
+		if d := time.Until(time.Unix(c.ExpiresAt, 0)); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func (c *cacheClaims) Set(key string, value interface{}) error { return nil }
+
+func (c *cacheClaims) Get(key string) (interface{}, error) { return nil, nil }
+
+func (c *cacheClaims) Keys() []string { return []string{"sub", "exp"} }
+
+func newCacheToken(t testing.TB, sub string, exp time.Duration, s Signer, key Key) []byte {
+	c := &cacheClaims{Subject: sub}
+	if exp != 0 {
+		c.ExpiresAt = time.Now().Add(exp).Unix()
+	}
+	tk, err := NewToken(c).SignedString(s, key)
+	require.NoError(t, err)
+	return tk
+}
+
+func parseCached(vf *Verification, raw []byte, key Key) error {
+	dst := NewToken(&cacheClaims{})
+	return vf.Parse(&dst, raw, func(*Token) (Key, error) { return key, nil })
+}
+
+func TestVerificationCache_Parse(t *testing.T) {
+	hs := NewSigningMethodHS256()
+	keyA := WithPassword([]byte(`Pa$$w0rd-A`))
+	keyB := WithPassword([]byte(`Pa$$w0rd-B`))
+
+	vc := NewVerificationCache()
+	vf := NewVerification(hs)
+	vf.Cache = vc
+
+	tk := newCacheToken(t, "gopher", time.Hour, hs, keyA)
+
+	assert.NoError(t, parseCached(vf, tk, keyA))
+	assert.NoError(t, parseCached(vf, tk, WithPassword([]byte(`Pa$$w0rd-A`))))
+	assert.Exactly(t, VerificationCacheStats{Hits: 1, Misses: 1, Size: 1}, vc.Stats())
+
+	t.Run("other key does not match", func(t *testing.T) {
+		err := parseCached(vf, tk, keyB)
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+		assert.Exactly(t, 0, vc.Len())
+	})
+
+	t.Run("strict bypasses the cache", func(t *testing.T) {
+		assert.NoError(t, parseCached(vf, tk, keyA))
+		strict := *vf
+		strict.Strict = true
+		st := vc.Stats()
+		assert.NoError(t, parseCached(&strict, tk, keyA))
+		assert.Exactly(t, st, vc.Stats())
+	})
+
+	t.Run("manipulated signature", func(t *testing.T) {
+		tk2 := append([]byte(nil), tk...)
+		tk2[len(tk2)-2]++
+		err := parseCached(vf, tk2, keyA)
+		assert.True(t, errors.IsNotValid(err), "%+v", err)
+	})
+
+	t.Run("token without expiration", func(t *testing.T) {
+		vc.Purge()
+		assert.NoError(t, parseCached(vf, newCacheToken(t, "gopher", 0, hs, keyA), keyA))
+		assert.Exactly(t, 0, vc.Len())
+	})
+
+	t.Run("expired entry", func(t *testing.T) {
+		vc.Purge()
+		defer func() { vc.now = time.Now }()
+		assert.NoError(t, parseCached(vf, tk, keyA))
+		assert.Exactly(t, 1, vc.Len())
+		vc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		assert.False(t, vc.isVerified(tk, keyA))
+		assert.Exactly(t, 0, vc.Len())
+	})
+}
+
+func TestVerificationCache_MaxTTL_Eviction(t *testing.T) {
+	hs := NewSigningMethodHS256()
+	key := WithPassword([]byte(`Pa$$w0rd`))
+
+	vc := NewVerificationCache(WithVerificationCacheSize(2), WithVerificationCacheMaxTTL(time.Minute))
+	now := time.Now()
+	vc.now = func() time.Time { return now }
+
+	tk1 := newCacheToken(t, "a", time.Hour, hs, key)
+	tk2 := newCacheToken(t, "b", time.Hour, hs, key)
+	tk3 := newCacheToken(t, "c", time.Hour, hs, key)
+
+	vc.add(tk1, key, time.Hour)
+	vc.add(tk2, key, time.Hour)
+	assert.True(t, vc.isVerified(tk1, key)) // tk2 is now the least recently used
+	vc.add(tk3, key, time.Hour)
+
+	assert.Exactly(t, 2, vc.Len())
+	assert.True(t, vc.isVerified(tk1, key))
+	assert.False(t, vc.isVerified(tk2, key))
+	assert.True(t, vc.isVerified(tk3, key))
+
+	now = now.Add(61 * time.Second)
+	assert.False(t, vc.isVerified(tk1, key), "MaxTTL exceeded")
+
+	st := vc.Stats()
+	assert.Exactly(t, uint64(1), st.Evictions)
+	assert.Exactly(t, uint64(3), st.Hits)
+	assert.Exactly(t, uint64(2), st.Misses)
+	assert.InDelta(t, 0.6, st.HitRatio(), 0.0001)
+	assert.Exactly(t, `{"hits":3,"misses":2,"evictions":1,"size":1,"hit_ratio":0.6000}`, vc.String())
+}
+
+func TestKey_equal(t *testing.T) {
+	rsaPub := WithRSAPublicKeyFromFile("test/sample_key.pub")
+	rsaPriv := WithRSAPrivateKeyFromFile("test/sample_key")
+	rsaOther := WithRSAPrivateKeyFromFile("test/test_rsa_np")
+	ecPub := WithECPublicKeyFromFile("test/ec256-public.pem")
+	ecPriv := WithECPrivateKeyFromFile("test/ec256-private.pem")
+	ecOther := WithECPublicKeyFromFile("test/ec384-public.pem")
+
+	tests := []struct {
+		a, b Key
+		want bool
+	}{
+		{Key{}, Key{}, true},
+		{WithPassword([]byte(`a`)), WithPassword([]byte(`a`)), true},
+		{WithPassword([]byte(`a`)), WithPassword([]byte(`b`)), false},
+		{WithPassword([]byte(`a`)), WithPassword([]byte(`ab`)), false},
+		{rsaPub, WithRSAPublicKeyFromFile("test/sample_key.pub"), true},
+		{rsaPub, rsaPriv, true},
+		{rsaPub, rsaOther, false},
+		{rsaPub, Key{}, false},
+		{ecPub, ecPriv, true},
+		{ecPub, ecOther, false},
+		{ecPub, rsaPub, false},
+		{Key{Error: errors.New("Ups")}, Key{Error: errors.New("Ups")}, false},
+	}
+	for i, test := range tests {
+		assert.Exactly(t, test.want, test.a.equal(test.b), "Index %d", i)
+	}
+}
+
+func benchmarkVerificationCache(b *testing.B, vc *VerificationCache) {
+	rs := NewSigningMethodRS256()
+	tk := newCacheToken(b, "gopher", time.Hour, rs, WithRSAPrivateKeyFromFile("test/sample_key"))
+	key := WithRSAPublicKeyFromFile("test/sample_key.pub")
+	vf := NewVerification(rs)
+	vf.Cache = vc
+	keyFunc := func(*Token) (Key, error) { return key, nil }
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst := NewToken(&cacheClaims{})
+		if err := vf.Parse(&dst, tk, keyFunc); err != nil {
+			b.Fatalf("%+v", err)
+		}
+	}
+}
+
+func BenchmarkVerification_Cache_RS256(b *testing.B) {
+	benchmarkVerificationCache(b, NewVerificationCache())
+}
+
+func BenchmarkVerification_NoCache_RS256(b *testing.B) {
+	benchmarkVerificationCache(b, nil)
+}
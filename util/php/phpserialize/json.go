@@ -0,0 +1,242 @@
+package phpserialize
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// JSONKeyMode defines how non-string keys of a PhpArray get converted into
+// JSON object keys.
+type JSONKeyMode uint8
+
+const (
+	// JSONKeyConvert converts int, bool and float keys into strings like PHP's
+	// json_encode does.
+	JSONKeyConvert JSONKeyMode = iota
+	// JSONKeyError returns an error for each non-string key.
+	JSONKeyError
+)
+
+// JSONOptions configures the conversion between PhpValue trees and JSON.
+type JSONOptions struct {
+	// Keys defines the handling of non-string keys of objects.
+	Keys JSONKeyMode
+	// ListsAsObjects encodes arrays with the sequential keys 0..n-1 as JSON
+	// objects instead of JSON arrays.
+	ListsAsObjects bool
+	// ClassField if not empty stores the class name of a PhpObject in this
+	// field of the JSON object. When converting back, JSON objects containing
+	// this field become a PhpObject. If empty, objects get converted into
+	// plain JSON objects and the class name gets lost.
+	ClassField string
+}
+
+// ToJSON converts a PhpValue tree, e.g. returned by UnSerialize, into JSON.
+// Supported types are nil, bool, all numbers, string, PhpArray, PhpSlice and
+// PhpObject. PhpObjectSerialized and PhpSplArray return an error.
+func ToJSON(v PhpValue, o JSONOptions) (json.RawMessage, error) {
+	iv, err := o.toJSONValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(iv)
+}
+
+// FromJSON converts JSON into a PhpValue tree which can be passed to
+// Serialize. JSON arrays become a PhpArray with int keys, JSON objects a
+// PhpArray with string keys where numeric strings get converted into int keys
+// like PHP does. Integral numbers become int, other numbers float64. Objects
+// containing the field JSONOptions.ClassField become a *PhpObject.
+func FromJSON(data json.RawMessage, o JSONOptions) (PhpValue, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var iv interface{}
+	if err := dec.Decode(&iv); err != nil {
+		return nil, fmt.Errorf("phpserialize: Unable to decode JSON: %v", err)
+	}
+	return o.fromJSONValue(iv)
+}
+
+func (o JSONOptions) toJSONValue(v PhpValue) (interface{}, error) {
+	switch t := v.(type) {
+	case nil, bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return t, nil
+	case float32:
+		return o.toJSONFloat(float64(t))
+	case float64:
+		return o.toJSONFloat(t)
+	case PhpSlice:
+		return o.toJSONArray(phpSliceToArray(t))
+	case PhpArray:
+		return o.toJSONArray(t)
+	case map[PhpValue]PhpValue:
+		return o.toJSONArray(PhpArray(t))
+	case *PhpObject:
+		m, err := o.toJSONObject(t.GetMembers())
+		if err != nil {
+			return nil, fmt.Errorf("phpserialize: Object %q: %v", t.GetClassName(), err)
+		}
+		if o.ClassField != "" {
+			if _, ok := m[o.ClassField]; ok {
+				return nil, fmt.Errorf("phpserialize: Object %q contains the class field %q", t.GetClassName(), o.ClassField)
+			}
+			m[o.ClassField] = t.GetClassName()
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("phpserialize: Type %T not supported in JSON conversion", v)
+}
+
+func (o JSONOptions) toJSONFloat(f float64) (interface{}, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("phpserialize: Float %v not supported in JSON", f)
+	}
+	return f, nil
+}
+
+func phpSliceToArray(s PhpSlice) PhpArray {
+	arr := make(PhpArray, len(s))
+	for i, v := range s {
+		arr[i] = v
+	}
+	return arr
+}
+
+func (o JSONOptions) toJSONArray(arr PhpArray) (interface{}, error) {
+	if !o.ListsAsObjects && isPhpList(arr) {
+		l := make([]interface{}, len(arr))
+		for i := range l {
+			v, err := o.toJSONValue(arr[i])
+			if err != nil {
+				return nil, err
+			}
+			l[i] = v
+		}
+		return l, nil
+	}
+	return o.toJSONObject(arr)
+}
+
+// isPhpList reports whether the array has the int keys 0..n-1.
+func isPhpList(arr PhpArray) bool {
+	for i := 0; i < len(arr); i++ {
+		if _, ok := arr[i]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (o JSONOptions) toJSONObject(arr PhpArray) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, len(arr))
+	for k, v := range arr {
+		key, err := o.toJSONKey(k)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("phpserialize: Duplicate key %q after conversion", key)
+		}
+		if m[key], err = o.toJSONValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (o JSONOptions) toJSONKey(k PhpValue) (string, error) {
+	if s, ok := k.(string); ok {
+		return s, nil
+	}
+	if o.Keys == JSONKeyError {
+		return "", fmt.Errorf("phpserialize: Key %#v of type %T is not a string", k, k)
+	}
+	switch t := k.(type) {
+	case nil:
+		return "", nil
+	case bool:
+		if t {
+			return "1", nil
+		}
+		return "0", nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", t), nil
+	case float32:
+		return strconv.FormatInt(int64(t), 10), nil
+	case float64:
+		return strconv.FormatInt(int64(t), 10), nil
+	}
+	return "", fmt.Errorf("phpserialize: Key type %T not supported", k)
+}
+
+func (o JSONOptions) fromJSONValue(iv interface{}) (PhpValue, error) {
+	switch t := iv.(type) {
+	case nil, bool, string:
+		return t, nil
+	case json.Number:
+		if i, err := strconv.Atoi(t.String()); err == nil {
+			return i, nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("phpserialize: Unable to convert %s to float: %v", t, err)
+		}
+		return f, nil
+	case []interface{}:
+		arr := make(PhpArray, len(t))
+		for i, v := range t {
+			pv, err := o.fromJSONValue(v)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = pv
+		}
+		return arr, nil
+	case map[string]interface{}:
+		var obj *PhpObject
+		if o.ClassField != "" {
+			if cn, ok := t[o.ClassField]; ok {
+				name, ok := cn.(string)
+				if !ok {
+					return nil, fmt.Errorf("phpserialize: Class field %q must be a string, have %T", o.ClassField, cn)
+				}
+				obj = NewPhpObject(name)
+			}
+		}
+		arr := make(PhpArray, len(t))
+		for k, v := range t {
+			if obj != nil && k == o.ClassField {
+				continue
+			}
+			pv, err := o.fromJSONValue(v)
+			if err != nil {
+				return nil, err
+			}
+			if obj == nil {
+				arr[phpArrayKey(k)] = pv
+			} else {
+				arr[k] = pv
+			}
+		}
+		if obj != nil {
+			return obj.SetMembers(arr), nil
+		}
+		return arr, nil
+	}
+	return nil, fmt.Errorf("phpserialize: JSON type %T not supported", iv)
+}
+
+// phpArrayKey converts a decimal integer string without leading zeros into an
+// int like PHP does with array keys.
+func phpArrayKey(k string) PhpValue {
+	if k == "" || (len(k) > 1 && k[0] == '0') || (len(k) > 1 && k[0] == '-' && k[1] == '0') {
+		return k
+	}
+	if i, err := strconv.Atoi(k); err == nil {
+		return i
+	}
+	return k
+}
@@ -0,0 +1,121 @@
+package phpserialize
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	obj := NewPhpObject("Varien_Object")
+	obj.SetPublic("sku", "SKU-1")
+	obj.SetPublic("qty", 2)
+
+	tests := []struct {
+		name    string
+		v       PhpValue
+		o       JSONOptions
+		want    string
+		wantErr bool
+	}{
+		{"scalar", "a", JSONOptions{}, `"a"`, false},
+		{"null", nil, JSONOptions{}, `null`, false},
+		{"list", PhpArray{0: "a", 1: 2, 2: true}, JSONOptions{}, `["a",2,true]`, false},
+		{"list as object", PhpArray{0: "a", 1: 2.5}, JSONOptions{ListsAsObjects: true}, `{"0":"a","1":2.5}`, false},
+		{"slice", PhpSlice{"x", nil}, JSONOptions{}, `["x",null]`, false},
+		{"mixed keys", PhpArray{"a": 1, 5: "b", true: nil}, JSONOptions{}, `{"1":null,"5":"b","a":1}`, false},
+		{"mixed keys error", PhpArray{"a": 1, 5: "b"}, JSONOptions{Keys: JSONKeyError}, ``, true},
+		{"duplicate key", PhpArray{1: "a", "1": "b"}, JSONOptions{}, ``, true},
+		{"object", obj, JSONOptions{}, `{"qty":2,"sku":"SKU-1"}`, false},
+		{"object with class", obj, JSONOptions{ClassField: "__class"}, `{"__class":"Varien_Object","qty":2,"sku":"SKU-1"}`, false},
+		{"object class collision", obj, JSONOptions{ClassField: "sku"}, ``, true},
+		{"serialized object", NewPhpObjectSerialized("Foo"), JSONOptions{}, ``, true},
+	}
+	for _, test := range tests {
+		have, err := ToJSON(test.v, test.o)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: Expected an error but got %s", test.name, have)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Unexpected error: %v", test.name, err)
+			continue
+		}
+		if string(have) != test.want {
+			t.Errorf("%s: Expected %s but got %s", test.name, test.want, have)
+		}
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		o       JSONOptions
+		want    PhpValue
+		wantErr bool
+	}{
+		{"numbers", `[1, 2.5, -3, 1e3]`, JSONOptions{}, PhpArray{0: 1, 1: 2.5, 2: -3, 3: float64(1000)}, false},
+		{"numeric keys", `{"1":"a","01":"b","-2":"c","x":null}`, JSONOptions{}, PhpArray{1: "a", "01": "b", -2: "c", "x": nil}, false},
+		{"nested", `{"a":{"b":[true]}}`, JSONOptions{}, PhpArray{"a": PhpArray{"b": PhpArray{0: true}}}, false},
+		{"class ignored", `{"__class":"Foo","a":1}`, JSONOptions{}, PhpArray{"__class": "Foo", "a": 1}, false},
+		{"class", `{"__class":"Foo","a":1}`, JSONOptions{ClassField: "__class"}, NewPhpObject("Foo").SetMembers(PhpArray{"a": 1}), false},
+		{"class not a string", `{"__class":1}`, JSONOptions{ClassField: "__class"}, nil, true},
+		{"invalid", `{"a":`, JSONOptions{}, nil, true},
+	}
+	for _, test := range tests {
+		have, err := FromJSON(json.RawMessage(test.data), test.o)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: Expected an error but got %#v", test.name, have)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(have, test.want) {
+			t.Errorf("%s: Expected %#v but got %#v", test.name, test.want, have)
+		}
+	}
+}
+
+func TestJSON_RoundTrip(t *testing.T) {
+	const serialized = `a:3:{s:4:"name";s:7:"Gophers";s:5:"items";a:2:{i:0;i:1;i:1;d:2.5;}s:6:"object";O:3:"Foo":1:{s:3:"bar";b:1;}}`
+	o := JSONOptions{ClassField: "__class"}
+
+	v, err := UnSerialize([]byte(serialized))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := ToJSON(v, o)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := `{"items":[1,2.5],"name":"Gophers","object":{"__class":"Foo","bar":true}}`; string(data) != want {
+		t.Errorf("Expected %s but got %s", want, data)
+	}
+
+	back, err := FromJSON(data, o)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(v, back) {
+		t.Errorf("Expected %#v but got %#v", v, back)
+	}
+
+	str, err := Serialize(back)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	again, err := UnSerialize([]byte(str))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(v, again) {
+		t.Errorf("Expected %#v but got %#v", v, again)
+	}
+}
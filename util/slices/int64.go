@@ -150,3 +150,67 @@ func (l *Int64) Unique() Int64 {
 	*l = unique
 	return *l
 }
+
+// Intersect returns a new slice containing all unique int64s which are in l
+// and in the argument slice. The order of l is preserved.
+func (l Int64) Intersect(in Int64) Int64 {
+	seen := make(map[int64]bool, len(in))
+	for _, v := range in {
+		seen[v] = true
+	}
+	var ret Int64
+	for _, v := range l {
+		if seen[v] {
+			ret = append(ret, v)
+			delete(seen, v)
+		}
+	}
+	return ret
+}
+
+// Union returns a new slice containing all unique int64s from l and the
+// argument slice. The order of l followed by the order of the argument is
+// preserved.
+func (l Int64) Union(in Int64) Int64 {
+	ret := make(Int64, 0, len(l)+len(in))
+	ret = append(ret, l...)
+	ret = append(ret, in...)
+	return ret.Unique()
+}
+
+// Diff returns a new slice containing all int64s of l which are not in the
+// argument slice. The order of l is preserved.
+func (l Int64) Diff(in Int64) Int64 {
+	seen := make(map[int64]bool, len(in))
+	for _, v := range in {
+		seen[v] = true
+	}
+	var ret Int64
+	for _, v := range l {
+		if !seen[v] {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// Chunk splits the slice into chunks of size n. The last chunk might contain
+// less than n entries. The chunks share the underlying array with l. A size
+// smaller than one returns the whole slice as one chunk.
+func (l Int64) Chunk(n int) []Int64 {
+	if len(l) == 0 {
+		return nil
+	}
+	if n < 1 || n >= len(l) {
+		return []Int64{l[:len(l):len(l)]}
+	}
+	ret := make([]Int64, 0, (len(l)+n-1)/n)
+	for i := 0; i < len(l); i += n {
+		end := i + n
+		if end > len(l) {
+			end = len(l)
+		}
+		ret = append(ret, l[i:end:end])
+	}
+	return ret
+}
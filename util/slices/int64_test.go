@@ -141,3 +141,39 @@ func BenchmarkInt64_Unique(b *testing.B) {
 	}
 
 }
+
+func TestInt64Intersect(t *testing.T) {
+	l := slices.Int64{5, 3, 1, 3, 7}
+	assert.Exactly(t, slices.Int64{3, 7}, l.Intersect(slices.Int64{7, 3, 3, 9}))
+	assert.Nil(t, l.Intersect(nil))
+}
+
+func TestInt64Union(t *testing.T) {
+	l := slices.Int64{5, 3, 5}
+	assert.Exactly(t, slices.Int64{5, 3, 1, 7}, l.Union(slices.Int64{3, 1, 7}))
+	assert.Exactly(t, slices.Int64{5, 3, 5}, l, "Union must not modify the receiver")
+}
+
+func TestInt64Diff(t *testing.T) {
+	l := slices.Int64{5, 3, 1, 3, 7}
+	assert.Exactly(t, slices.Int64{5, 1}, l.Diff(slices.Int64{3, 7}))
+	assert.Exactly(t, slices.Int64(nil), slices.Int64{3}.Diff(slices.Int64{3}))
+}
+
+func TestInt64Chunk(t *testing.T) {
+	l := slices.Int64{1, 2, 3, 4, 5}
+	assert.Exactly(t, []slices.Int64{{1, 2}, {3, 4}, {5}}, l.Chunk(2))
+	assert.Exactly(t, []slices.Int64{{1, 2, 3, 4, 5}}, l.Chunk(0))
+	assert.Exactly(t, []slices.Int64{{1, 2, 3, 4, 5}}, l.Chunk(10))
+	assert.Nil(t, slices.Int64{}.Chunk(2))
+
+	ch := l.Chunk(2)
+	ch[0] = append(ch[0], 99)
+	assert.Exactly(t, slices.Int64{1, 2, 3, 4, 5}, l, "append to a chunk must not overwrite the next chunk")
+
+	spare := append(make(slices.Int64, 0, 10), 1, 2, 3)
+	ch = spare.Chunk(5)
+	ch[0] = append(ch[0], 99)
+	assert.Exactly(t, slices.Int64{1, 2, 3}, spare)
+	assert.Exactly(t, slices.Int64{1, 2, 3, 0}, spare[:4], "append to a single chunk must not write into the spare capacity")
+}
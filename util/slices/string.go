@@ -227,3 +227,84 @@ func (l *String) Shuffle() String {
 	}
 	return *l
 }
+
+// IndexFold returns -1 if not found or the current index for target t using
+// case-insensitive comparison.
+func (l String) IndexFold(t string) int {
+	for i, v := range l {
+		if strings.EqualFold(v, t) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ContainsFold returns true if the target string t is in the slice. The
+// comparison is case-insensitive, see strings.EqualFold.
+func (l String) ContainsFold(t string) bool {
+	return l.IndexFold(t) >= 0
+}
+
+// Intersect returns a new slice containing all unique strings which are in l
+// and in the argument slice. The order of l is preserved.
+func (l String) Intersect(in String) String {
+	seen := make(map[string]bool, len(in))
+	for _, v := range in {
+		seen[v] = true
+	}
+	var ret String
+	for _, v := range l {
+		if seen[v] {
+			ret = append(ret, v)
+			delete(seen, v)
+		}
+	}
+	return ret
+}
+
+// Union returns a new slice containing all unique and non-empty strings from l
+// and the argument slice. The order of l followed by the order of the
+// argument is preserved.
+func (l String) Union(in String) String {
+	ret := make(String, 0, len(l)+len(in))
+	ret = append(ret, l...)
+	ret = append(ret, in...)
+	return ret.Unique()
+}
+
+// Diff returns a new slice containing all strings of l which are not in the
+// argument slice. The order of l is preserved.
+func (l String) Diff(in String) String {
+	seen := make(map[string]bool, len(in))
+	for _, v := range in {
+		seen[v] = true
+	}
+	var ret String
+	for _, v := range l {
+		if !seen[v] {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// Chunk splits the slice into chunks of size n. The last chunk might contain
+// less than n entries. The chunks share the underlying array with l. A size
+// smaller than one returns the whole slice as one chunk.
+func (l String) Chunk(n int) []String {
+	if len(l) == 0 {
+		return nil
+	}
+	if n < 1 || n >= len(l) {
+		return []String{l[:len(l):len(l)]}
+	}
+	ret := make([]String, 0, (len(l)+n-1)/n)
+	for i := 0; i < len(l); i += n {
+		end := i + n
+		if end > len(l) {
+			end = len(l)
+		}
+		ret = append(ret, l[i:end:end])
+	}
+	return ret
+}
@@ -319,3 +319,39 @@ func TestStringSliceStartsWithReverse(t *testing.T) {
 		assert.Equal(t, test.want, test.in.StartsWithReverse(test.have), "Test: %#v", test)
 	}
 }
+
+func TestStringContainsFold(t *testing.T) {
+	l := slices.String{"DE", "ch", "At"}
+	assert.True(t, l.ContainsFold("de"))
+	assert.True(t, l.ContainsFold("CH"))
+	assert.Exactly(t, 2, l.IndexFold("at"))
+	assert.False(t, l.ContainsFold("nz"))
+	assert.False(t, l.Contains("de"))
+}
+
+func TestStringIntersect(t *testing.T) {
+	l := slices.String{"a", "b", "c", "b"}
+	assert.Exactly(t, slices.String{"b", "c"}, l.Intersect(slices.String{"c", "b", "x"}))
+}
+
+func TestStringUnion(t *testing.T) {
+	l := slices.String{"a", "b"}
+	assert.Exactly(t, slices.String{"a", "b", "c"}, l.Union(slices.String{"b", "", "c"}))
+	assert.Exactly(t, slices.String{"a", "b"}, l)
+}
+
+func TestStringDiff(t *testing.T) {
+	l := slices.String{"a", "b", "c", "b"}
+	assert.Exactly(t, slices.String{"a", "c"}, l.Diff(slices.String{"b"}))
+}
+
+func TestStringChunk(t *testing.T) {
+	l := slices.String{"a", "b", "c"}
+	assert.Exactly(t, []slices.String{{"a", "b"}, {"c"}}, l.Chunk(2))
+	assert.Exactly(t, []slices.String{{"a", "b", "c"}}, l.Chunk(-1))
+
+	spare := append(make(slices.String, 0, 10), "a", "b")
+	ch := spare.Chunk(5)
+	ch[0] = append(ch[0], "z")
+	assert.Exactly(t, slices.String{"a", "b", ""}, spare[:3], "append to a single chunk must not write into the spare capacity")
+}